@@ -98,6 +98,22 @@ const (
 	RemoteParam      = "remote"
 	BranchParam      = "branch"
 	TrackFlag        = "track"
+	UntrackedFlag    = "include-untracked"
+	InteractiveFlag  = "interactive"
+	ContinueFlag     = "continue"
+	CleanIgnoredFlag = "ignored"
+	MinParentsParam  = "min-parents"
+	MergesFlag       = "merges"
+	ParentsFlag      = "parents"
+	DecorateParam    = "decorate"
+	NotParam         = "not"
+	TablesParam      = "tables"
+	DepthParam       = "depth"
+	SingleBranchFlag = "single-branch"
+	UnshallowFlag    = "unshallow"
+	UserParam        = "user"
+	DirParam         = "dir"
+	ToParam          = "to"
 )
 
 const (
@@ -145,7 +161,8 @@ func CreatePushArgParser() *argparser.ArgParser {
 func CreateAddArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"table", "Working table(s) to add to the list tables staged to be committed. The abbreviation '.' can be used to add all tables."})
-	ap.SupportsFlag("all", "A", "Stages any and all changes (adds, deletes, and modifications).")
+	ap.SupportsFlag("all", "A", "Stages any and all changes (adds, deletes, and modifications), except for untracked tables ignored by {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}}.")
+	ap.SupportsFlag(ForceFlag, "f", "Allow adding tables that are ignored by {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}}.")
 	return ap
 }
 
@@ -153,10 +170,15 @@ func CreateCloneArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsString(RemoteParam, "", "name", "Name of the remote to be added to the cloned database. The default is 'origin'.")
 	ap.SupportsString(BranchParam, "b", "branch", "The branch to be cloned. If not specified all branches will be cloned.")
+	ap.SupportsInt(DepthParam, "", "depth", "Create a shallow clone with a history truncated to the specified number of commits. Implies --single-branch.")
+	ap.SupportsFlag(SingleBranchFlag, "", "Clone only the history leading to the tip of a single branch, either the one specified by --branch or the remote's default branch.")
 	ap.SupportsString(dbfactory.AWSRegionParam, "", "region", "")
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, dbfactory.AWSCredTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file.")
 	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use.")
+	ap.SupportsString(dbfactory.S3EndpointParam, "", "url", "Endpoint of the S3 compatible object store of an s3 remote.")
+	ap.SupportsString(dbfactory.S3PathStyleParam, "", "true|false", "Whether the bucket of an s3 remote is addressed in the path of requests instead of the host name. Defaults to true when s3-endpoint is set.")
+	ap.SupportsString(UserParam, "u", "user", "User name to authenticate to the remote with when it is the remotesapi endpoint of a sql-server. The password is read from the DOLT_REMOTE_PASSWORD environment variable.")
 	return ap
 }
 
@@ -173,12 +195,16 @@ func CreateRemoteArgParser() *argparser.ArgParser {
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, dbfactory.AWSCredTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file")
 	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use")
+	ap.SupportsString(dbfactory.S3EndpointParam, "", "url", "Endpoint of the S3 compatible object store of an s3 remote.")
+	ap.SupportsString(dbfactory.S3PathStyleParam, "", "true|false", "Whether the bucket of an s3 remote is addressed in the path of requests instead of the host name. Defaults to true when s3-endpoint is set.")
+	ap.SupportsString(UserParam, "u", "user", "User name to authenticate to the remote with when it is the remotesapi endpoint of a sql-server. The password is read from the DOLT_REMOTE_PASSWORD environment variable.")
 	return ap
 }
 
 func CreateCleanArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(DryRunFlag, "", "Tests removing untracked tables without modifying the working set.")
+	ap.SupportsFlag(CleanIgnoredFlag, "x", "Also remove untracked tables that are ignored by {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}}.")
 	return ap
 }
 
@@ -198,6 +224,8 @@ func CreateCherryPickArgParser() *argparser.ArgParser {
 func CreateFetchArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(ForceFlag, "f", "Update refs to remote branches with the current state of the remote, overwriting any conflicting history.")
+	ap.SupportsInt(DepthParam, "", "depth", "Limit fetching to the specified number of commits from the tip of each remote branch history.")
+	ap.SupportsFlag(UnshallowFlag, "", "Fetch the history missing from a shallow clone, making it a complete repository.")
 	return ap
 }
 
@@ -249,6 +277,53 @@ func CreateBackupArgParser() *argparser.ArgParser {
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, dbfactory.AWSCredTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file")
 	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use")
+	ap.SupportsString(dbfactory.S3EndpointParam, "", "url", "Endpoint of the S3 compatible object store of an s3 remote.")
+	ap.SupportsString(dbfactory.S3PathStyleParam, "", "true|false", "Whether the bucket of an s3 remote is addressed in the path of requests instead of the host name. Defaults to true when s3-endpoint is set.")
+	return ap
+}
+
+func CreateStashArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsString(MessageArg, "m", "msg", "Use the given {{.LessThan}}msg{{.GreaterThan}} as the description of the stash entry.")
+	ap.SupportsFlag(UntrackedFlag, "u", "Also stash untracked tables, removing them from the working set.")
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"stash", "A stash entry in the form {{.EmphasisLeft}}stash@{<n>}{{.EmphasisRight}}. Defaults to the most recent entry, {{.EmphasisLeft}}stash@{0}{{.EmphasisRight}}."})
+	return ap
+}
+
+func CreateRebaseArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsFlag(InteractiveFlag, "i", "Create the rebase plan and stop, so that it can be edited in the {{.EmphasisLeft}}dolt_rebase{{.EmphasisRight}} system table before the rebase is started with {{.EmphasisLeft}}--continue{{.EmphasisRight}}.")
+	ap.SupportsFlag(ContinueFlag, "", "Start executing the rebase plan, or resume the rebase after resolving conflicts.")
+	ap.SupportsFlag(AbortParam, "", "Abort the rebase in progress and restore the branch to the state it was in before the rebase started.")
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"upstream", "The commit or branch to rebase the current branch onto."})
+	return ap
+}
+
+func CreateMigrationsArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsString(DirParam, "", "directory", "The directory containing the migration files, relative to the directory of the database. Defaults to {{.EmphasisLeft}}migrations{{.EmphasisRight}}.")
+	ap.SupportsUint(ToParam, "", "version", "Apply the pending migrations up to and including this version, or revert the applied migrations down to, but not including, this version.")
+	ap.SupportsFlag(DryRunFlag, "", "Run the migrations and report their schema changes without committing them.")
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"subcommand", "One of {{.EmphasisLeft}}status{{.EmphasisRight}}, {{.EmphasisLeft}}up{{.EmphasisRight}} or {{.EmphasisLeft}}down{{.EmphasisRight}}."})
+	return ap
+}
+
+func CreateLogArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsInt(MinParentsParam, "", "parent_count", "The minimum number of parents a commit must have to be included in the log.")
+	ap.SupportsFlag(MergesFlag, "", "Equivalent to min-parents == 2, this will limit the log to commits with 2 or more parents.")
+	ap.SupportsFlag(ParentsFlag, "", "Shows all parents of each commit in the log.")
+	ap.SupportsValidatedString(DecorateParam, "", "decorate_fmt", "Shows refs next to commits. Valid options are short, full and no.", argparser.ValidatorFromStrList(DecorateParam, []string{"short", "full", "no"}))
+	ap.SupportsString(NotParam, "", "revision", "Excludes commits reachable from {{.LessThan}}revision{{.GreaterThan}}.")
+	ap.SupportsString(TablesParam, "t", "table", "Restricts the log to commits that changed {{.LessThan}}table{{.GreaterThan}}. Multiple tables may be given, separated by commas.")
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"revision", "The revisions to show the log of. Revisions prefixed with {{.EmphasisLeft}}^{{.EmphasisRight}} are excluded, and ranges may be given as {{.EmphasisLeft}}A..B{{.EmphasisRight}} or {{.EmphasisLeft}}A...B{{.EmphasisRight}}. Defaults to {{.EmphasisLeft}}HEAD{{.EmphasisRight}}."})
+	return ap
+}
+
+func CreateReflogArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsFlag(AllFlag, "", "Also shows the movements of working sets.")
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"ref", "The branch, tag, remote branch or full ref name to show the movements of. If omitted, the movements of all refs are shown."})
 	return ap
 }
 
@@ -260,13 +335,24 @@ func CreateVerifyConstraintsArgParser() *argparser.ArgParser {
 	return ap
 }
 
-var awsParams = []string{dbfactory.AWSRegionParam, dbfactory.AWSCredsTypeParam, dbfactory.AWSCredsFileParam, dbfactory.AWSCredsProfile}
+func CreateCreateDatabaseArgParser() *argparser.ArgParser {
+	ap := argparser.NewArgParser()
+	ap.SupportsString(dbfactory.AWSRegionParam, "", "region", "")
+	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, dbfactory.AWSCredTypes))
+	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file.")
+	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use.")
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"name", "The name of the new database."})
+	ap.ArgListHelp = append(ap.ArgListHelp, [2]string{"url", "The URL of the aws, gs or file storage the data of the database lives in."})
+	return ap
+}
+
+var awsParams = []string{dbfactory.AWSRegionParam, dbfactory.AWSCredsTypeParam, dbfactory.AWSCredsFileParam, dbfactory.AWSCredsProfile, dbfactory.S3EndpointParam, dbfactory.S3PathStyleParam}
 
 func ProcessBackupArgs(apr *argparser.ArgParseResults, scheme, backupUrl string) (map[string]string, error) {
 	params := map[string]string{}
 
 	var err error
-	if scheme == dbfactory.AWSScheme {
+	if scheme == dbfactory.AWSScheme || scheme == dbfactory.S3Scheme {
 		err = AddAWSParams(backupUrl, apr, params)
 	} else {
 		err = VerifyNoAwsParams(apr)
@@ -276,12 +362,12 @@ func ProcessBackupArgs(apr *argparser.ArgParseResults, scheme, backupUrl string)
 }
 
 func AddAWSParams(remoteUrl string, apr *argparser.ArgParseResults, params map[string]string) error {
-	isAWS := strings.HasPrefix(remoteUrl, "aws")
+	isAWS := strings.HasPrefix(remoteUrl, "aws") || strings.HasPrefix(remoteUrl, "s3")
 
 	if !isAWS {
 		for _, p := range awsParams {
 			if _, ok := apr.GetValue(p); ok {
-				return fmt.Errorf("%s param is only valid for aws cloud remotes in the format aws://dynamo-table:s3-bucket/database", p)
+				return fmt.Errorf("%s param is only valid for aws cloud remotes in the format aws://dynamo-table:s3-bucket/database, and s3 remotes in the format s3://s3-bucket/database", p)
 			}
 		}
 	}
@@ -295,6 +381,20 @@ func AddAWSParams(remoteUrl string, apr *argparser.ArgParseResults, params map[s
 	return nil
 }
 
+// AddUserParam adds the user of |apr| to |params|, which authenticates requests to remotes served by the remotesapi
+// endpoint of a sql-server.
+func AddUserParam(scheme string, apr *argparser.ArgParseResults, params map[string]string) error {
+	user, ok := apr.GetValue(UserParam)
+	if !ok {
+		return nil
+	}
+	if scheme != dbfactory.HTTPScheme && scheme != dbfactory.HTTPSScheme {
+		return fmt.Errorf("%s param is only valid for http and https remotes", UserParam)
+	}
+	params[dbfactory.GRPCUsernameAuthParam] = user
+	return nil
+}
+
 func VerifyNoAwsParams(apr *argparser.ArgParseResults) error {
 	if awsParams := apr.GetValues(awsParams...); len(awsParams) > 0 {
 		awsParamKeys := make([]string, 0, len(awsParams))
@@ -303,7 +403,7 @@ func VerifyNoAwsParams(apr *argparser.ArgParseResults) error {
 		}
 
 		keysStr := strings.Join(awsParamKeys, ",")
-		return fmt.Errorf("The parameters %s, are only valid for aws remotes", keysStr)
+		return fmt.Errorf("The parameters %s, are only valid for aws and s3 remotes", keysStr)
 	}
 
 	return nil
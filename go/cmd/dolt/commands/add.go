@@ -32,9 +32,11 @@ This command updates the list of tables using the current content found in the w
 
 This command can be performed multiple times before a commit. It only adds the content of the specified table(s) at the time the add command is run; if you want subsequent changes included in the next commit, then you must run dolt add again to add the new content to the index.
 
-The dolt status command can be used to obtain a summary of which tables have changes that are staged for the next commit.`,
+The dolt status command can be used to obtain a summary of which tables have changes that are staged for the next commit.
+
+Untracked tables whose names match a pattern in the {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}} table are not added by {{.EmphasisLeft}}dolt add -A{{.EmphasisRight}} or {{.EmphasisLeft}}dolt add .{{.EmphasisRight}}, and naming them explicitly is an error unless {{.EmphasisLeft}}-f{{.EmphasisRight}} is given.`,
 	Synopsis: []string{
-		`[{{.LessThan}}table{{.GreaterThan}}...]`,
+		`[-f] [{{.LessThan}}table{{.GreaterThan}}...]`,
 	},
 }
 
@@ -84,7 +86,7 @@ func (cmd AddCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 			return handleStageError(err)
 		}
 	} else {
-		roots, err = actions.StageTables(ctx, roots, apr.Args)
+		roots, err = actions.StageTables(ctx, roots, apr.Args, apr.Contains(cli.ForceFlag))
 		if err != nil {
 			return handleStageError(err)
 		}
@@ -118,6 +120,17 @@ func toAddVErr(err error) errhand.VerboseError {
 
 		return bdr.Build()
 
+	case actions.IsTblIgnored(err):
+		tbls := actions.GetTablesForError(err)
+		bdr := errhand.BuildDError("The following tables are ignored by one of your dolt_ignore patterns:")
+
+		for _, tbl := range tbls {
+			bdr.AddDetails("  %s", tbl)
+		}
+		bdr.AddDetails("Use -f if you really want to add them.")
+
+		return bdr.Build()
+
 	case actions.IsTblInConflict(err) || actions.IsTblViolatesConstraints(err):
 		tbls := actions.GetTablesForError(err)
 		bdr := errhand.BuildDError("error: not all tables merged")
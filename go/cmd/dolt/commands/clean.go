@@ -36,10 +36,12 @@ var cleanDocContent = cli.CommandDocumentationContent{
 		"The {{.EmphasisLeft}}--dry-run{{.EmphasisRight}} flag can be used to test whether the clean can succeed without " +
 		"deleting any tables from the current working set.\n\n" +
 		"{{.EmphasisLeft}}dolt clean [--dry-run] {{.LessThan}}tables{{.GreaterThan}}...{{.EmphasisRight}}\n\n" +
-		"If {{.LessThan}}tables{{.GreaterThan}} is specified, only those table names are considered for deleting.\n\n",
+		"If {{.LessThan}}tables{{.GreaterThan}} is specified, only those table names are considered for deleting.\n\n" +
+		"Untracked tables that are ignored by the {{.EmphasisLeft}}dolt_ignore{{.EmphasisRight}} table are not deleted " +
+		"unless {{.EmphasisLeft}}-x{{.EmphasisRight}} is given.\n\n",
 	Synopsis: []string{
-		"[--dry-run]",
-		"[--dry-run] {{.LessThan}}tables{{.GreaterThan}}...",
+		"[--dry-run] [-x]",
+		"[--dry-run] [-x] {{.LessThan}}tables{{.GreaterThan}}...",
 	},
 }
 
@@ -84,7 +86,7 @@ func (cmd CleanCmd) Exec(ctx context.Context, commandStr string, args []string,
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
 	}
 
-	roots, err = actions.CleanUntracked(ctx, roots, apr.Args, apr.Contains(DryrunCleanParam))
+	roots, err = actions.CleanUntracked(ctx, roots, apr.Args, apr.Contains(DryrunCleanParam), apr.Contains(cli.CleanIgnoredFlag))
 	if err != nil {
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
 	}
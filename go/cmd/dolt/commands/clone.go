@@ -39,9 +39,13 @@ var cloneDocs = cli.CommandDocumentationContent{
 After the clone, a plain {{.EmphasisLeft}}dolt fetch{{.EmphasisRight}} without arguments will update all the remote-tracking branches, and a {{.EmphasisLeft}}dolt pull{{.EmphasisRight}} without arguments will in addition merge the remote branch into the current branch.
 
 This default configuration is achieved by creating references to the remote branch heads under {{.LessThan}}refs/remotes/origin{{.GreaterThan}}  and by creating a remote named 'origin'.
+
+With {{.EmphasisLeft}}--single-branch{{.EmphasisRight}}, only the history of the cloned branch is retrieved. With {{.EmphasisLeft}}--depth{{.EmphasisRight}}, that history is further truncated to the given number of commits, creating a shallow clone. Commands that need the missing history, such as {{.EmphasisLeft}}dolt log{{.EmphasisRight}} reaching past the truncated commits, fail with an error in a shallow clone. {{.EmphasisLeft}}dolt fetch --unshallow{{.EmphasisRight}} retrieves the missing history.
+
+To clone a database from the remotesapi endpoint of a {{.EmphasisLeft}}dolt sql-server{{.EmphasisRight}}, pass one of the server's SQL users with {{.EmphasisLeft}}--user{{.EmphasisRight}} and set their password in the {{.EmphasisLeft}}DOLT_REMOTE_PASSWORD{{.EmphasisRight}} environment variable. The user is saved with the remote, so later fetches, pulls and pushes authenticate as them too.
 `,
 	Synopsis: []string{
-		"[-remote {{.LessThan}}remote{{.GreaterThan}}] [-branch {{.LessThan}}branch{{.GreaterThan}}]  [--aws-region {{.LessThan}}region{{.GreaterThan}}] [--aws-creds-type {{.LessThan}}creds-type{{.GreaterThan}}] [--aws-creds-file {{.LessThan}}file{{.GreaterThan}}] [--aws-creds-profile {{.LessThan}}profile{{.GreaterThan}}] {{.LessThan}}remote-url{{.GreaterThan}} {{.LessThan}}new-dir{{.GreaterThan}}",
+		"[-remote {{.LessThan}}remote{{.GreaterThan}}] [-branch {{.LessThan}}branch{{.GreaterThan}}] [--single-branch] [--depth {{.LessThan}}depth{{.GreaterThan}}] [--aws-region {{.LessThan}}region{{.GreaterThan}}] [--aws-creds-type {{.LessThan}}creds-type{{.GreaterThan}}] [--aws-creds-file {{.LessThan}}file{{.GreaterThan}}] [--aws-creds-profile {{.LessThan}}profile{{.GreaterThan}}] [--user {{.LessThan}}user{{.GreaterThan}}] {{.LessThan}}remote-url{{.GreaterThan}} {{.LessThan}}new-dir{{.GreaterThan}}",
 	},
 }
 
@@ -94,6 +98,10 @@ func (cmd CloneCmd) Exec(ctx context.Context, commandStr string, args []string,
 func clone(ctx context.Context, apr *argparser.ArgParseResults, dEnv *env.DoltEnv) errhand.VerboseError {
 	remoteName := apr.GetValueOrDefault(cli.RemoteParam, "origin")
 	branch := apr.GetValueOrDefault(cli.BranchParam, "")
+	depth, ok := apr.GetInt(cli.DepthParam)
+	if ok && depth < 1 {
+		return errhand.BuildDError("error: depth %d is not a positive number", depth).Build()
+	}
 	dir, urlStr, verr := parseArgs(apr)
 	if verr != nil {
 		return verr
@@ -128,7 +136,7 @@ func clone(ctx context.Context, apr *argparser.ArgParseResults, dEnv *env.DoltEn
 	// Nil out the old Dolt env so we don't accidentally operate on the wrong database
 	dEnv = nil
 
-	err = actions.CloneRemote(ctx, srcDB, remoteName, branch, clonedEnv)
+	err = actions.CloneRemote(ctx, srcDB, remoteName, branch, apr.Contains(cli.SingleBranchFlag), depth, clonedEnv)
 	if err != nil {
 		// If we're cloning into a directory that already exists do not erase it. Otherwise
 		// make best effort to delete the directory we created.
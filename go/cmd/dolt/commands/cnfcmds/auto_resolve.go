@@ -23,6 +23,7 @@ import (
 
 	"github.com/dolthub/go-mysql-server/sql"
 
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
@@ -30,6 +31,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlfmt"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
 	"github.com/dolthub/dolt/go/libraries/utils/set"
 	"github.com/dolthub/dolt/go/store/hash"
 )
@@ -58,18 +60,34 @@ func AutoResolveAll(ctx context.Context, dEnv *env.DoltEnv, strategy AutoResolve
 		return err
 	}
 
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return err
+	}
+	if ws.MergeActive() {
+		tblSet := set.NewStrSet(tbls)
+		tblSet.Add(ws.MergeState().SchemaConflictTables()...)
+		tbls = tblSet.AsSortedSlice()
+	}
+
 	return AutoResolveTables(ctx, dEnv, strategy, tbls)
 }
 
 // AutoResolveTables resolves all conflicts in the given tables according to the
 // given |strategy|.
 func AutoResolveTables(ctx context.Context, dEnv *env.DoltEnv, strategy AutoResolveStrategy, tbls []string) error {
-	root, err := dEnv.WorkingRoot(ctx)
-	if err != nil {
-		return err
-	}
-
 	for _, tblName := range tbls {
+		// resolving the schema conflicts of a table merges its rows, which may conflict in turn
+		err := ResolveSchemaConflicts(ctx, dEnv, tblName, strategy)
+		if err != nil {
+			return err
+		}
+
+		root, err := dEnv.WorkingRoot(ctx)
+		if err != nil {
+			return err
+		}
+
 		err = ResolveTable(ctx, dEnv, root, tblName, strategy)
 		if err != nil {
 			return err
@@ -79,6 +97,62 @@ func AutoResolveTables(ctx context.Context, dEnv *env.DoltEnv, strategy AutoReso
 	return nil
 }
 
+// ResolveSchemaConflicts resolves the schema conflicts that the active merge recorded for the given table, if any,
+// by taking the schema of the side |strategy| takes. The rows of the table are then merged.
+func ResolveSchemaConflicts(ctx context.Context, dEnv *env.DoltEnv, tblName string, strategy AutoResolveStrategy) error {
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return err
+	}
+	if !ws.MergeActive() {
+		return nil
+	}
+
+	var remaining []string
+	for _, t := range ws.MergeState().SchemaConflictTables() {
+		if t != tblName {
+			remaining = append(remaining, t)
+		}
+	}
+	if len(remaining) == len(ws.MergeState().SchemaConflictTables()) {
+		return nil
+	}
+
+	resolution := merge.TakeOurSchema
+	if strategy == AutoResolveStrategyTheirs {
+		resolution = merge.TakeTheirSchema
+	}
+
+	head, err := dEnv.HeadCommit(ctx)
+	if err != nil {
+		return err
+	}
+	theirCm := ws.MergeState().Commit()
+	ancCm, err := doltdb.GetCommitAncestor(ctx, head, theirCm)
+	if err != nil {
+		return err
+	}
+	theirRoot, err := theirCm.GetRootValue(ctx)
+	if err != nil {
+		return err
+	}
+	ancRoot, err := ancCm.GetRootValue(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := editor.Options{Deaf: dEnv.DbEaFactory(), Tempdir: dEnv.TempTableFilesDir()}
+	root, stats, err := merge.ResolveSchemaConflicts(ctx, ws.WorkingRoot(), theirRoot, ancRoot, theirCm, ancCm, tblName, resolution, opts)
+	if err != nil {
+		return err
+	}
+	if stats.ConstraintViolations > 0 {
+		cli.Println("CONSTRAINT VIOLATION (content): Merge created constraint violation in", tblName)
+	}
+
+	return dEnv.UpdateWorkingSet(ctx, ws.WithWorkingRoot(root).WithSchemaConflicts(remaining))
+}
+
 // ResolveTable resolves all conflicts in the given table according to the given
 // |strategy|. It errors if the schema of the conflict version you are choosing
 // differs from the current schema.
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/fatih/color"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/cli"
 	"github.com/dolthub/dolt/go/cmd/dolt/commands"
@@ -29,6 +30,7 @@ import (
 	eventsapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/eventsapi/v1alpha1"
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
 	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped"
@@ -100,7 +102,11 @@ func (cmd CatCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 
 	// If no commit was resolved from the first argument, assume the args are all table names and print the conflicts
 	if cm == nil {
-		if verr := printConflicts(ctx, dEnv, root, args); verr != nil {
+		ancRoot, err := mergeBaseRoot(ctx, dEnv)
+		if err != nil {
+			return exitWithVerr(errhand.BuildDError("unable to get the merge base").AddCause(err).Build())
+		}
+		if verr := printConflicts(ctx, dEnv, root, ancRoot, args); verr != nil {
 			return exitWithVerr(verr)
 		}
 
@@ -119,7 +125,7 @@ func (cmd CatCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		return exitWithVerr(errhand.BuildDError("unable to get the root value").AddCause(err).Build())
 	}
 
-	if verr = printConflicts(ctx, dEnv, root, tblNames); verr != nil {
+	if verr = printConflicts(ctx, dEnv, root, nil, tblNames); verr != nil {
 		return exitWithVerr(verr)
 	}
 
@@ -131,7 +137,30 @@ func exitWithVerr(verr errhand.VerboseError) int {
 	return 1
 }
 
-func printConflicts(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootValue, tblNames []string) errhand.VerboseError {
+// mergeBaseRoot returns the root of the common ancestor of the merge in progress, or nil if no merge is in progress.
+func mergeBaseRoot(ctx context.Context, dEnv *env.DoltEnv) (*doltdb.RootValue, error) {
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !ws.MergeActive() {
+		return nil, nil
+	}
+	headCm, err := dEnv.HeadCommit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ancCm, err := doltdb.GetCommitAncestor(ctx, headCm, ws.MergeState().Commit())
+	if err != nil {
+		return nil, err
+	}
+	return ancCm.GetRootValue(ctx)
+}
+
+// printConflicts prints the conflicts of |tblNames| in |root|. The definitions of the views, triggers, stored
+// procedures and docs in conflict are diffed from their definitions in |ancRoot|, the root of the merge base, if it
+// isn't nil.
+func printConflicts(ctx context.Context, dEnv *env.DoltEnv, root, ancRoot *doltdb.RootValue, tblNames []string) errhand.VerboseError {
 	if len(tblNames) == 1 && tblNames[0] == "." {
 		var err error
 		tblNames, err = root.GetTableNames(ctx)
@@ -184,6 +213,21 @@ func printConflicts(ctx context.Context, dEnv *env.DoltEnv, root *doltdb.RootVal
 				return errhand.BuildDError("failed to fetch conflicts").AddCause(err).Build()
 			}
 
+			if objectCols, defCol, ok := merge.FragmentTableColumns(tblName); ok {
+				var bases map[string]interface{}
+				if ancRoot != nil {
+					bases, err = merge.FragmentDefinitions(ctx, ancRoot, tblName)
+					if err != nil {
+						return errhand.BuildDError("failed to fetch conflicts").AddCause(err).Build()
+					}
+				}
+				err = printFragmentConflicts(sqlCtx, eng, tblName, objectCols, defCol, bases)
+				if err != nil {
+					return errhand.BuildDError("failed to print conflicts").AddCause(err).Build()
+				}
+				return nil
+			}
+
 			confSqlSch, rowItr, err := eng.Query(sqlCtx, buildConflictQuery(baseSch, sch, mergeSch, tblName))
 			if err != nil {
 				return errhand.BuildDError("failed to fetch conflicts").AddCause(err).Build()
@@ -240,6 +284,53 @@ func writeConflictResults(
 	}
 }
 
+// printFragmentConflicts prints the conflicts of |tblName|, one of the system tables holding the definitions of views,
+// triggers, stored procedures or docs, as diffs of the definitions of the objects in conflict. The base definitions
+// of the objects are taken from |bases| when it isn't nil, as the rows of the same key on each side of a merge may
+// define different objects.
+func printFragmentConflicts(sqlCtx *sql.Context, eng *engine.SqlEngine, tblName string, objectCols []string, defCol string, bases map[string]interface{}) error {
+	cols := make([]string, 0, len(objectCols)+1)
+	cols = append(cols, objectCols...)
+	cols = append(cols, defCol)
+	var selected []string
+	for _, prefix := range []string{"base_", "our_", "their_"} {
+		selected = append(selected, withPrefix(cols, prefix)...)
+	}
+	_, rowItr, err := eng.Query(sqlCtx, fmt.Sprintf("SELECT %s FROM dolt_conflicts_%s", strings.Join(selected, ", "), tblName))
+	if err != nil {
+		return err
+	}
+
+	n := len(cols)
+	for {
+		r, err := rowItr.Next(sqlCtx)
+		if err == io.EOF {
+			return rowItr.Close(sqlCtx)
+		} else if err != nil {
+			return err
+		}
+
+		// the object is named by whichever side defines it
+		var names []interface{}
+		for _, side := range []int{1, 2, 0} {
+			if r[side*n] != nil {
+				names = r[side*n : side*n+n-1]
+				break
+			}
+		}
+		base := r[n-1]
+		if bases != nil {
+			base = bases[merge.FragmentObjectKey(tblName, names)]
+		}
+		name := make([]string, len(names))
+		for i, v := range names {
+			name[i] = fmt.Sprint(v)
+		}
+		cli.Println(color.YellowString("conflict in %s: %s", tblName, strings.Join(name, " ")))
+		cli.Print(merge.FragmentConflictDiff(base, r[2*n-1], r[3*n-1]))
+	}
+}
+
 func buildConflictQuery(base, sch, mergeSch schema.Schema, tblName string) string {
 	cols := withPrefix(base.GetAllCols().GetColumnNames(), "base_")
 	cols = append(cols, withPrefix(sch.GetAllCols().GetColumnNames(), "our_")...)
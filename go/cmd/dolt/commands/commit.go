@@ -144,6 +144,9 @@ func (cmd CommitCmd) Exec(ctx context.Context, commandStr string, args []string,
 
 	var mergeParentCommits []*doltdb.Commit
 	if ws.MergeActive() {
+		if ws.MergeState().HasSchemaConflicts() {
+			return handleCommitErr(ctx, dEnv, actions.NewTblSchemaConflictError(ws.MergeState().SchemaConflictTables()), usage)
+		}
 		mergeParentCommits = []*doltdb.Commit{ws.MergeState().Commit()}
 	}
 
@@ -218,6 +221,12 @@ func handleCommitErr(ctx context.Context, dEnv *env.DoltEnv, err error, usage cl
 		return HandleVErrAndExitCode(bdr.Build(), usage)
 	}
 
+	if actions.IsTblInSchemaConflict(err) {
+		inConflict := actions.GetTablesForError(err)
+		bdr := errhand.BuildDError(`tables %v have unresolved schema conflicts from the merge. resolve them with "dolt conflicts resolve --ours|--theirs" before commiting`, inConflict)
+		return HandleVErrAndExitCode(bdr.Build(), usage)
+	}
+
 	verr := errhand.BuildDError("error: Failed to commit changes.").AddCause(err).Build()
 	return HandleVErrAndExitCode(verr, usage)
 }
@@ -16,6 +16,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
@@ -39,10 +40,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlfmt"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/pipeline"
-	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped/sqlexport"
-	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped/tabular"
 	"github.com/dolthub/dolt/go/libraries/utils/argparser"
-	"github.com/dolthub/dolt/go/libraries/utils/iohelp"
 	"github.com/dolthub/dolt/go/libraries/utils/set"
 	"github.com/dolthub/dolt/go/store/atomicerr"
 )
@@ -51,22 +49,28 @@ type diffOutput int
 type diffPart int
 
 const (
-	SchemaOnlyDiff diffPart = 1 // 0b0001
-	DataOnlyDiff   diffPart = 2 // 0b0010
-	Summary        diffPart = 4 // 0b0100
+	SchemaOnlyDiff diffPart = 1  // 0b0001
+	DataOnlyDiff   diffPart = 2  // 0b0010
+	Summary        diffPart = 4  // 0b0100
+	Stat           diffPart = 8  // 0b1000
+	NameOnly       diffPart = 16 // 0b10000
 
 	SchemaAndDataDiff = SchemaOnlyDiff | DataOnlyDiff
 
 	TabularDiffOutput diffOutput = 1
 	SQLDiffOutput     diffOutput = 2
-
-	DataFlag    = "data"
-	SchemaFlag  = "schema"
-	SummaryFlag = "summary"
-	whereParam  = "where"
-	limitParam  = "limit"
-	SQLFlag     = "sql"
-	CachedFlag  = "cached"
+	JSONDiffOutput    diffOutput = 3
+	CSVDiffOutput     diffOutput = 4
+
+	DataFlag     = "data"
+	SchemaFlag   = "schema"
+	SummaryFlag  = "summary"
+	StatFlag     = "stat"
+	NameOnlyFlag = "name-only"
+	whereParam   = "where"
+	limitParam   = "limit"
+	SQLFlag      = "sql"
+	CachedFlag   = "cached"
 )
 
 type DiffSink interface {
@@ -92,6 +96,21 @@ Show changes between the working and staged tables, changes between the working
 The diffs displayed can be limited to show the first N by providing the parameter {{.EmphasisLeft}}--limit N{{.EmphasisRight}} where {{.EmphasisLeft}}N{{.EmphasisRight}} is the number of diffs to display.
 
 To filter which data rows are displayed, use {{.EmphasisLeft}}--where <SQL expression>{{.EmphasisRight}}. Table column names in the filter expression must be prefixed with {{.EmphasisLeft}}from_{{.EmphasisRight}} or {{.EmphasisLeft}}to_{{.EmphasisRight}}, e.g. {{.EmphasisLeft}}to_COLUMN_NAME > 100{{.EmphasisRight}} or {{.EmphasisLeft}}from_COLUMN_NAME + to_COLUMN_NAME = 0{{.EmphasisRight}}.
+
+To list only the names of the tables that changed, use {{.EmphasisLeft}}--name-only{{.EmphasisRight}}. To list the tables that changed along with the number of rows added, deleted and modified in each, use {{.EmphasisLeft}}--stat{{.EmphasisRight}}.
+
+The output format is chosen with {{.EmphasisLeft}}-r{{.EmphasisRight}}, and is one of {{.EmphasisLeft}}tabular{{.EmphasisRight}} (the default), {{.EmphasisLeft}}sql{{.EmphasisRight}}, {{.EmphasisLeft}}json{{.EmphasisRight}} or {{.EmphasisLeft}}csv{{.EmphasisRight}}.
+
+{{.EmphasisLeft}}-r json{{.EmphasisRight}} writes a single object with a {{.EmphasisLeft}}tables{{.EmphasisRight}} array, with one object per changed table. Every table object has the fields:
+   {{.EmphasisLeft}}name{{.EmphasisRight}}: the current name of the table, or its old name if it was dropped.
+   {{.EmphasisLeft}}from_name{{.EmphasisRight}}, {{.EmphasisLeft}}to_name{{.EmphasisRight}}: the name of the table in each revision, or "" if it does not exist there.
+   {{.EmphasisLeft}}diff_type{{.EmphasisRight}}: one of added, dropped, renamed or modified.
+In a full diff, table objects also have the fields:
+   {{.EmphasisLeft}}schema_diff{{.EmphasisRight}}: an array of the SQL statements that change the table's schema, as written by {{.EmphasisLeft}}-r sql{{.EmphasisRight}}.
+   {{.EmphasisLeft}}data_diff{{.EmphasisRight}}: an array of the changed rows, each an object with a {{.EmphasisLeft}}from_row{{.EmphasisRight}} and a {{.EmphasisLeft}}to_row{{.EmphasisRight}} object mapping column names to values. {{.EmphasisLeft}}from_row{{.EmphasisRight}} is empty for added rows and {{.EmphasisLeft}}to_row{{.EmphasisRight}} is empty for deleted rows. NULL values are omitted.
+With {{.EmphasisLeft}}--stat{{.EmphasisRight}}, table objects instead have the fields {{.EmphasisLeft}}rows_unmodified{{.EmphasisRight}}, {{.EmphasisLeft}}rows_added{{.EmphasisRight}}, {{.EmphasisLeft}}rows_deleted{{.EmphasisRight}}, {{.EmphasisLeft}}rows_modified{{.EmphasisRight}}, {{.EmphasisLeft}}cells_modified{{.EmphasisRight}}, {{.EmphasisLeft}}old_row_count{{.EmphasisRight}} and {{.EmphasisLeft}}new_row_count{{.EmphasisRight}}. The unmodified and total row counts are null for keyless tables.
+
+{{.EmphasisLeft}}-r csv{{.EmphasisRight}} writes the data diff of each table as a block with a header line, separated from the next table's block by an empty line. Each line holds a {{.EmphasisLeft}}from_{{.EmphasisRight}} and a {{.EmphasisLeft}}to_{{.EmphasisRight}} column for every column of the table, followed by a {{.EmphasisLeft}}diff_type{{.EmphasisRight}} column of added, removed or modified. Schema diffs are not written in csv. With {{.EmphasisLeft}}--name-only{{.EmphasisRight}} or {{.EmphasisLeft}}--stat{{.EmphasisRight}}, a single block is written with a line per table, with the same columns as the fields of the json output.
 `,
 	Synopsis: []string{
 		`[options] [{{.LessThan}}commit{{.GreaterThan}}] [{{.LessThan}}tables{{.GreaterThan}}...]`,
@@ -138,7 +157,9 @@ func (cmd DiffCmd) ArgParser() *argparser.ArgParser {
 	ap.SupportsFlag(DataFlag, "d", "Show only the data changes, do not show the schema changes (Both shown by default).")
 	ap.SupportsFlag(SchemaFlag, "s", "Show only the schema changes, do not show the data changes (Both shown by default).")
 	ap.SupportsFlag(SummaryFlag, "", "Show summary of data changes")
-	ap.SupportsString(FormatFlag, "r", "result output format", "How to format diff output. Valid values are tabular & sql. Defaults to tabular. ")
+	ap.SupportsFlag(StatFlag, "", "Show the number of rows added, deleted and modified in each changed table.")
+	ap.SupportsFlag(NameOnlyFlag, "", "Show only the names of the changed tables.")
+	ap.SupportsString(FormatFlag, "r", "result output format", "How to format diff output. Valid values are tabular, sql, json & csv. Defaults to tabular. ")
 	ap.SupportsString(whereParam, "", "column", "filters columns based on values in the diff.  See {{.EmphasisLeft}}dolt diff --help{{.EmphasisRight}} for details.")
 	ap.SupportsInt(limitParam, "", "record_count", "limits to the first N diffs.")
 	ap.SupportsFlag(CachedFlag, "c", "Show only the unstaged data changes.")
@@ -169,15 +190,32 @@ func (cmd DiffCmd) Exec(ctx context.Context, commandStr string, args []string, d
 }
 
 func (cmd DiffCmd) validateArgs(apr *argparser.ArgParseResults) errhand.VerboseError {
-	if apr.Contains(SummaryFlag) {
-		if apr.Contains(SchemaFlag) || apr.Contains(DataFlag) {
-			return errhand.BuildDError("invalid Arguments: --summary cannot be combined with --schema or --data").Build()
+	for _, flag := range []string{SummaryFlag, StatFlag, NameOnlyFlag} {
+		if apr.Contains(flag) {
+			if apr.Contains(SchemaFlag) || apr.Contains(DataFlag) {
+				return errhand.BuildDError("invalid Arguments: --%s cannot be combined with --schema or --data", flag).Build()
+			}
 		}
 	}
 
+	if len(apr.ContainsMany(SummaryFlag, StatFlag, NameOnlyFlag)) > 1 {
+		return errhand.BuildDError("invalid Arguments: only one of --summary, --stat and --name-only may be given").Build()
+	}
+
 	f, _ := apr.GetValue(FormatFlag)
 	switch strings.ToLower(f) {
-	case "tabular", "sql", "":
+	case "tabular", "":
+	case "sql":
+		if apr.Contains(StatFlag) || apr.Contains(NameOnlyFlag) {
+			return errhand.BuildDError("invalid Arguments: --stat and --name-only are not supported for sql output").Build()
+		}
+	case "json", "csv":
+		if apr.Contains(SummaryFlag) {
+			return errhand.BuildDError("invalid Arguments: --summary is not supported for %s output, use --stat instead", f).Build()
+		}
+		if strings.ToLower(f) == "csv" && apr.Contains(SchemaFlag) {
+			return errhand.BuildDError("invalid Arguments: schema diffs are not supported for csv output").Build()
+		}
 	default:
 		return errhand.BuildDError("invalid output format: %s", f).Build()
 	}
@@ -195,6 +233,10 @@ func parseDiffArgs(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPar
 		dArgs.diffParts = SchemaOnlyDiff
 	} else if apr.Contains(SummaryFlag) {
 		dArgs.diffParts = Summary
+	} else if apr.Contains(StatFlag) {
+		dArgs.diffParts = Stat
+	} else if apr.Contains(NameOnlyFlag) {
+		dArgs.diffParts = NameOnly
 	}
 
 	f := apr.GetValueOrDefault(FormatFlag, "tabular")
@@ -203,6 +245,14 @@ func parseDiffArgs(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgPar
 		dArgs.diffOutput = TabularDiffOutput
 	case "sql":
 		dArgs.diffOutput = SQLDiffOutput
+	case "json":
+		dArgs.diffOutput = JSONDiffOutput
+	case "csv":
+		dArgs.diffOutput = CSVDiffOutput
+		// schema diffs can't be written as csv, so only the data diff is shown by default
+		if dArgs.diffParts == SchemaAndDataDiff {
+			dArgs.diffParts = DataOnlyDiff
+		}
 	}
 
 	dArgs.limit, _ = apr.GetInt(limitParam)
@@ -282,7 +332,10 @@ func (dArgs *diffArgs) applyDiffRoots(ctx context.Context, dEnv *env.DoltEnv, ar
 	}
 
 	// treat the first arg as a ref spec
-	fromRoot, ok := maybeResolve(ctx, dEnv, args[0])
+	fromRoot, ok, err := maybeResolve(ctx, dEnv, args[0])
+	if err != nil {
+		return nil, err
+	}
 
 	// if it doesn't resolve, treat it as a table name
 	if !ok {
@@ -297,7 +350,10 @@ func (dArgs *diffArgs) applyDiffRoots(ctx context.Context, dEnv *env.DoltEnv, ar
 		return nil, nil
 	}
 
-	toRoot, ok := maybeResolve(ctx, dEnv, args[1])
+	toRoot, ok, err := maybeResolve(ctx, dEnv, args[1])
+	if err != nil {
+		return nil, err
+	}
 
 	if !ok {
 		// `dolt diff from_commit ...tables`
@@ -311,24 +367,28 @@ func (dArgs *diffArgs) applyDiffRoots(ctx context.Context, dEnv *env.DoltEnv, ar
 	return args[2:], nil
 }
 
+// maybeResolve resolves the spec given to the root value of a commit, returning false if it isn't a commit spec. It
+// only returns an error for a commit spec that reaches past the history of a shallow clone.
 // todo: distinguish between non-existent CommitSpec and other errors, don't assume non-existent
-func maybeResolve(ctx context.Context, dEnv *env.DoltEnv, spec string) (*doltdb.RootValue, bool) {
+func maybeResolve(ctx context.Context, dEnv *env.DoltEnv, spec string) (*doltdb.RootValue, bool, error) {
 	cs, err := doltdb.NewCommitSpec(spec)
 	if err != nil {
-		return nil, false
+		return nil, false, nil
 	}
 
 	cm, err := dEnv.DoltDB.Resolve(ctx, cs, dEnv.RepoStateReader().CWBHeadRef())
-	if err != nil {
-		return nil, false
+	if errors.Is(err, doltdb.ErrMissingAncestor) {
+		return nil, false, err
+	} else if err != nil {
+		return nil, false, nil
 	}
 
 	root, err := cm.GetRootValue(ctx)
 	if err != nil {
-		return nil, false
+		return nil, false, nil
 	}
 
-	return root, true
+	return root, true, nil
 }
 
 func diffUserTables(ctx context.Context, dEnv *env.DoltEnv, dArgs *diffArgs) (verr errhand.VerboseError) {
@@ -347,71 +407,121 @@ func diffUserTables(ctx context.Context, dEnv *env.DoltEnv, dArgs *diffArgs) (ve
 	sort.Slice(tableDeltas, func(i, j int) bool {
 		return strings.Compare(tableDeltas[i].ToName, tableDeltas[j].ToName) < 0
 	})
+
+	dw := newDiffWriter(dArgs.diffOutput)
 	for _, td := range tableDeltas {
 		if !dArgs.tableSet.Contains(td.FromName) && !dArgs.tableSet.Contains(td.ToName) {
 			continue
 		}
 
-		tblName := td.ToName
-		fromTable := td.FromTable
-		toTable := td.ToTable
-
-		if fromTable == nil && toTable == nil {
+		if td.FromTable == nil && td.ToTable == nil {
 			return errhand.BuildDError("error: both tables in tableDelta are nil").Build()
 		}
 
-		if dArgs.diffOutput == TabularDiffOutput {
-			printTableDiffSummary(td)
+		switch {
+		case dArgs.diffParts&NameOnly != 0:
+			err = dw.WriteTableName(ctx, td)
+		case dArgs.diffParts&Stat != 0:
+			verr = diffStat(ctx, td, dw)
+		default:
+			verr = diffUserTable(ctx, engine, td, dArgs, dw)
 		}
 
-		fromSch, toSch, err := td.GetSchemas(ctx)
 		if err != nil {
-			return errhand.BuildDError("cannot retrieve schema for table %s", td.ToName).AddCause(err).Build()
+			return errhand.VerboseErrorFromError(err)
 		}
-
-		if dArgs.diffParts&Summary != 0 {
-			numCols := fromSch.GetAllCols().Size()
-			verr = printDiffSummary(ctx, td, numCols)
+		if verr != nil {
+			return verr
 		}
+	}
 
-		if dArgs.diffParts&SchemaOnlyDiff != 0 {
-			verr = diffSchemas(ctx, dArgs.toRoot, td, dArgs)
-		}
+	err = dw.Close(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
 
-		if dArgs.diffParts&DataOnlyDiff != 0 {
-			if td.IsDrop() && dArgs.diffOutput == SQLDiffOutput {
-				continue // don't output DELETE FROM statements after DROP TABLE
-			} else if td.IsAdd() {
-				fromSch = toSch
-			}
+	return nil
+}
 
-			if !schema.ArePrimaryKeySetsDiffable(td.Format(), fromSch, toSch) {
-				cli.PrintErrf("Primary key sets differ between revisions for table %s, skipping data diff\n", tblName)
-				continue
-			}
+func diffUserTable(ctx context.Context, se *engine.SqlEngine, td diff.TableDelta, dArgs *diffArgs, dw diffWriter) errhand.VerboseError {
+	err := dw.BeginTable(ctx, td)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
 
-			verr = diffRows(ctx, engine, td, dArgs)
-		}
+	fromSch, toSch, err := td.GetSchemas(ctx)
+	if err != nil {
+		return errhand.BuildDError("cannot retrieve schema for table %s", td.ToName).AddCause(err).Build()
+	}
 
+	if dArgs.diffParts&Summary != 0 {
+		numCols := fromSch.GetAllCols().Size()
+		verr := printDiffSummary(ctx, td, numCols)
 		if verr != nil {
 			return verr
 		}
 	}
 
+	if dArgs.diffParts&SchemaOnlyDiff != 0 {
+		err = dw.WriteSchemaDiff(ctx, dArgs.toRoot, td)
+		if err != nil {
+			return errhand.VerboseErrorFromError(err)
+		}
+	}
+
+	if dArgs.diffParts&DataOnlyDiff != 0 {
+		if td.IsAdd() {
+			fromSch = toSch
+		}
+
+		if td.IsDrop() && dArgs.diffOutput == SQLDiffOutput {
+			// don't output DELETE FROM statements after DROP TABLE
+		} else if !schema.ArePrimaryKeySetsDiffable(td.Format(), fromSch, toSch) {
+			cli.PrintErrf("Primary key sets differ between revisions for table %s, skipping data diff\n", td.CurName())
+		} else {
+			verr := diffRows(ctx, se, td, dArgs, dw)
+			if verr != nil {
+				return verr
+			}
+		}
+	}
+
+	err = dw.EndTable(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
 	return nil
 }
 
-func diffSchemas(ctx context.Context, toRoot *doltdb.RootValue, td diff.TableDelta, dArgs *diffArgs) errhand.VerboseError {
-	toSchemas, err := toRoot.GetAllSchemas(ctx)
+// diffStat writes the number of rows added, deleted and modified in the table given, for --stat
+func diffStat(ctx context.Context, td diff.TableDelta, dw diffWriter) errhand.VerboseError {
+	fromSch, toSch, err := td.GetSchemas(ctx)
 	if err != nil {
-		return errhand.BuildDError("could not read schemas from toRoot").AddCause(err).Build()
+		return errhand.BuildDError("cannot retrieve schema for table %s", td.ToName).AddCause(err).Build()
 	}
 
-	if dArgs.diffOutput == TabularDiffOutput {
-		return printShowCreateTableDiff(ctx, td)
+	if !schema.ArePrimaryKeySetsDiffable(td.Format(), fromSch, toSch) {
+		cli.PrintErrf("Primary key sets differ between revisions for table %s, skipping row counts\n", td.CurName())
+		return nil
 	}
 
-	return sqlSchemaDiff(ctx, td, toSchemas)
+	keyless, err := td.IsKeyless(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	acc, err := diff.SummaryTotalsForTableDelta(ctx, td)
+	if err != nil {
+		return errhand.BuildDError("cannot compute row counts for table %s", td.CurName()).AddCause(err).Build()
+	}
+
+	err = dw.WriteTableStat(ctx, td, newTableStat(acc, keyless))
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	return nil
 }
 
 func printShowCreateTableDiff(ctx context.Context, td diff.TableDelta) errhand.VerboseError {
@@ -448,31 +558,34 @@ func printShowCreateTableDiff(ctx context.Context, td diff.TableDelta) errhand.V
 	return nil
 }
 
+// sqlSchemaDiff returns the SQL statements that change the schema of the table in |td| from its old schema to its new one
 // TODO: this doesn't handle check constraints or triggers
-func sqlSchemaDiff(ctx context.Context, td diff.TableDelta, toSchemas map[string]schema.Schema) errhand.VerboseError {
+func sqlSchemaDiff(ctx context.Context, td diff.TableDelta, toSchemas map[string]schema.Schema) ([]string, errhand.VerboseError) {
 	fromSch, toSch, err := td.GetSchemas(ctx)
 	if err != nil {
-		return errhand.BuildDError("cannot retrieve schema for table %s", td.ToName).AddCause(err).Build()
+		return nil, errhand.BuildDError("cannot retrieve schema for table %s", td.ToName).AddCause(err).Build()
 	}
 
+	var stmts []string
+
 	if td.IsDrop() {
-		cli.Println(sqlfmt.DropTableStmt(td.FromName))
+		stmts = append(stmts, sqlfmt.DropTableStmt(td.FromName))
 	} else if td.IsAdd() {
 		sqlDb := sqle.NewSingleTableDatabase(td.ToName, toSch, td.ToFks, td.ToFksParentSch)
 		sqlCtx, engine, _ := sqle.PrepareCreateTableStmt(ctx, sqlDb)
 		stmt, err := sqle.GetCreateTableStmt(sqlCtx, engine, td.ToName)
 		if err != nil {
-			return errhand.VerboseErrorFromError(err)
+			return nil, errhand.VerboseErrorFromError(err)
 		}
-		cli.Println(stmt)
+		stmts = append(stmts, stmt)
 	} else {
 		if td.FromName != td.ToName {
-			cli.Println(sqlfmt.RenameTableStmt(td.FromName, td.ToName))
+			stmts = append(stmts, sqlfmt.RenameTableStmt(td.FromName, td.ToName))
 		}
 
 		eq := schema.SchemasAreEqual(fromSch, toSch)
 		if eq && !td.HasFKChanges() {
-			return nil
+			return stmts, nil
 		}
 
 		colDiffs, unionTags := diff.DiffSchColumns(fromSch, toSch)
@@ -481,25 +594,25 @@ func sqlSchemaDiff(ctx context.Context, td diff.TableDelta, toSchemas map[string
 			switch cd.DiffType {
 			case diff.SchDiffNone:
 			case diff.SchDiffAdded:
-				cli.Println(sqlfmt.AlterTableAddColStmt(td.ToName, sqlfmt.FmtCol(0, 0, 0, *cd.New)))
+				stmts = append(stmts, sqlfmt.AlterTableAddColStmt(td.ToName, sqlfmt.FmtCol(0, 0, 0, *cd.New)))
 			case diff.SchDiffRemoved:
-				cli.Println(sqlfmt.AlterTableDropColStmt(td.ToName, cd.Old.Name))
+				stmts = append(stmts, sqlfmt.AlterTableDropColStmt(td.ToName, cd.Old.Name))
 			case diff.SchDiffModified:
 				// Ignore any primary key set changes here
 				if cd.Old.IsPartOfPK != cd.New.IsPartOfPK {
 					continue
 				}
 				if cd.Old.Name != cd.New.Name {
-					cli.Println(sqlfmt.AlterTableRenameColStmt(td.ToName, cd.Old.Name, cd.New.Name))
+					stmts = append(stmts, sqlfmt.AlterTableRenameColStmt(td.ToName, cd.Old.Name, cd.New.Name))
 				}
 			}
 		}
 
 		// Print changes between a primary key set change. It contains an ALTER TABLE DROP and an ALTER TABLE ADD
 		if !schema.ColCollsAreEqual(fromSch.GetPKCols(), toSch.GetPKCols()) {
-			cli.Println(sqlfmt.AlterTableDropPks(td.ToName))
+			stmts = append(stmts, sqlfmt.AlterTableDropPks(td.ToName))
 			if toSch.GetPKCols().Size() > 0 {
-				cli.Println(sqlfmt.AlterTableAddPrimaryKeys(td.ToName, toSch.GetPKCols()))
+				stmts = append(stmts, sqlfmt.AlterTableAddPrimaryKeys(td.ToName, toSch.GetPKCols()))
 			}
 		}
 
@@ -507,12 +620,12 @@ func sqlSchemaDiff(ctx context.Context, td diff.TableDelta, toSchemas map[string
 			switch idxDiff.DiffType {
 			case diff.SchDiffNone:
 			case diff.SchDiffAdded:
-				cli.Println(sqlfmt.AlterTableAddIndexStmt(td.ToName, idxDiff.To))
+				stmts = append(stmts, sqlfmt.AlterTableAddIndexStmt(td.ToName, idxDiff.To))
 			case diff.SchDiffRemoved:
-				cli.Println(sqlfmt.AlterTableDropIndexStmt(td.FromName, idxDiff.From))
+				stmts = append(stmts, sqlfmt.AlterTableDropIndexStmt(td.FromName, idxDiff.From))
 			case diff.SchDiffModified:
-				cli.Println(sqlfmt.AlterTableDropIndexStmt(td.FromName, idxDiff.From))
-				cli.Println(sqlfmt.AlterTableAddIndexStmt(td.ToName, idxDiff.To))
+				stmts = append(stmts, sqlfmt.AlterTableDropIndexStmt(td.FromName, idxDiff.From))
+				stmts = append(stmts, sqlfmt.AlterTableAddIndexStmt(td.ToName, idxDiff.To))
 			}
 		}
 
@@ -521,21 +634,21 @@ func sqlSchemaDiff(ctx context.Context, td diff.TableDelta, toSchemas map[string
 			case diff.SchDiffNone:
 			case diff.SchDiffAdded:
 				parentSch := toSchemas[fkDiff.To.ReferencedTableName]
-				cli.Println(sqlfmt.AlterTableAddForeignKeyStmt(fkDiff.To, toSch, parentSch))
+				stmts = append(stmts, sqlfmt.AlterTableAddForeignKeyStmt(fkDiff.To, toSch, parentSch))
 			case diff.SchDiffRemoved:
-				cli.Println(sqlfmt.AlterTableDropForeignKeyStmt(fkDiff.From))
+				stmts = append(stmts, sqlfmt.AlterTableDropForeignKeyStmt(fkDiff.From))
 			case diff.SchDiffModified:
-				cli.Println(sqlfmt.AlterTableDropForeignKeyStmt(fkDiff.From))
+				stmts = append(stmts, sqlfmt.AlterTableDropForeignKeyStmt(fkDiff.From))
 
 				parentSch := toSchemas[fkDiff.To.ReferencedTableName]
-				cli.Println(sqlfmt.AlterTableAddForeignKeyStmt(fkDiff.To, toSch, parentSch))
+				stmts = append(stmts, sqlfmt.AlterTableAddForeignKeyStmt(fkDiff.To, toSch, parentSch))
 			}
 		}
 	}
-	return nil
+	return stmts, nil
 }
 
-func diffRows(ctx context.Context, se *engine.SqlEngine, td diff.TableDelta, dArgs *diffArgs) errhand.VerboseError {
+func diffRows(ctx context.Context, se *engine.SqlEngine, td diff.TableDelta, dArgs *diffArgs, dw diffWriter) errhand.VerboseError {
 	from, to := dArgs.fromRef, dArgs.toRef
 
 	tableName := td.ToName
@@ -591,17 +704,9 @@ func diffRows(ctx context.Context, se *engine.SqlEngine, td diff.TableDelta, dAr
 		return nil
 	}
 
-	var diffWriter diff.SqlRowDiffWriter
-	switch dArgs.diffOutput {
-	case TabularDiffOutput:
-		// TODO: default sample size
-		diffWriter = tabular.NewFixedWidthDiffTableWriter(unionSch, iohelp.NopWrCloser(cli.CliOut), 100)
-	case SQLDiffOutput:
-		targetSch := td.ToSch
-		if targetSch == nil {
-			targetSch = td.FromSch
-		}
-		diffWriter = sqlexport.NewSqlDiffWriter(tableName, targetSch, iohelp.NopWrCloser(cli.CliOut))
+	diffWriter, err := dw.RowWriter(ctx, td, unionSch)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
 	}
 
 	err = writeDiffResults(sqlCtx, sch, unionSch, rowIter, diffWriter)
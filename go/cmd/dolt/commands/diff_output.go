@@ -0,0 +1,437 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"bufio"
+	"context"
+	ejson "encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
+	"github.com/dolthub/dolt/go/libraries/doltcore/diff"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped/csv"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped/json"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped/sqlexport"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped/tabular"
+	"github.com/dolthub/dolt/go/libraries/utils/iohelp"
+)
+
+// diffWriter is an interface that lets us write diffs in a variety of output formats. For each table, a full diff
+// calls BeginTable, then WriteSchemaDiff and RowWriter as requested by the args, then EndTable. The --name-only and
+// --stat modes instead call WriteTableName or WriteTableStat once per table. Close is called after the last table.
+type diffWriter interface {
+	// BeginTable is called when a new table is about to be written, before any schema or row diffs are written
+	BeginTable(ctx context.Context, td diff.TableDelta) error
+	// WriteSchemaDiff writes the schema diff of the table given
+	WriteSchemaDiff(ctx context.Context, toRoot *doltdb.RootValue, td diff.TableDelta) error
+	// RowWriter returns a row writer for the table delta provided, which will have Close() called on it when rows are
+	// done being written
+	RowWriter(ctx context.Context, td diff.TableDelta, unionSch sql.Schema) (diff.SqlRowDiffWriter, error)
+	// EndTable is called after all schema and row diffs of a table have been written
+	EndTable(ctx context.Context) error
+	// WriteTableName writes the name of a changed table, for --name-only
+	WriteTableName(ctx context.Context, td diff.TableDelta) error
+	// WriteTableStat writes the row and cell counts of a changed table, for --stat
+	WriteTableStat(ctx context.Context, td diff.TableDelta, stat tableStat) error
+	// Close finalizes the work of the writer
+	Close(ctx context.Context) error
+}
+
+// tableStat holds the counts reported for a table by --stat. Keyless tables have no modified rows, and their unmodified
+// and total row counts are not computed, so those are nil.
+type tableStat struct {
+	RowsUnmodified *uint64 `json:"rows_unmodified"`
+	RowsAdded      uint64  `json:"rows_added"`
+	RowsDeleted    uint64  `json:"rows_deleted"`
+	RowsModified   uint64  `json:"rows_modified"`
+	CellsModified  uint64  `json:"cells_modified"`
+	OldRowCount    *uint64 `json:"old_row_count"`
+	NewRowCount    *uint64 `json:"new_row_count"`
+}
+
+func newTableStat(acc diff.DiffSummaryProgress, keyless bool) tableStat {
+	stat := tableStat{
+		RowsAdded:     acc.Adds,
+		RowsDeleted:   acc.Removes,
+		RowsModified:  acc.Changes,
+		CellsModified: acc.CellChanges,
+	}
+	if !keyless {
+		unmodified := acc.OldSize - acc.Changes - acc.Removes
+		oldSize, newSize := acc.OldSize, acc.NewSize
+		stat.RowsUnmodified, stat.OldRowCount, stat.NewRowCount = &unmodified, &oldSize, &newSize
+	}
+	return stat
+}
+
+// tableSummary identifies a changed table in the json and csv outputs. FromName is empty for added tables, and ToName
+// is empty for dropped tables.
+type tableSummary struct {
+	Name     string `json:"name"`
+	FromName string `json:"from_name"`
+	ToName   string `json:"to_name"`
+	DiffType string `json:"diff_type"`
+}
+
+func newTableSummary(td diff.TableDelta) tableSummary {
+	diffType := "modified"
+	if td.IsAdd() {
+		diffType = "added"
+	} else if td.IsDrop() {
+		diffType = "dropped"
+	} else if td.IsRename() {
+		diffType = "renamed"
+	}
+
+	return tableSummary{
+		Name:     td.CurName(),
+		FromName: td.FromName,
+		ToName:   td.ToName,
+		DiffType: diffType,
+	}
+}
+
+func newDiffWriter(diffOutput diffOutput) diffWriter {
+	switch diffOutput {
+	case SQLDiffOutput:
+		return &sqlDiffWriter{}
+	case JSONDiffOutput:
+		return &jsonDiffWriter{}
+	case CSVDiffOutput:
+		return &csvDiffWriter{}
+	default:
+		return &tabularDiffWriter{}
+	}
+}
+
+type tabularDiffWriter struct {
+	tablesWritten int
+	totals        tableStat
+}
+
+var _ diffWriter = (*tabularDiffWriter)(nil)
+
+func (t *tabularDiffWriter) BeginTable(ctx context.Context, td diff.TableDelta) error {
+	printTableDiffSummary(td)
+	return nil
+}
+
+func (t *tabularDiffWriter) WriteSchemaDiff(ctx context.Context, toRoot *doltdb.RootValue, td diff.TableDelta) error {
+	if verr := printShowCreateTableDiff(ctx, td); verr != nil {
+		return verr
+	}
+	return nil
+}
+
+func (t *tabularDiffWriter) RowWriter(ctx context.Context, td diff.TableDelta, unionSch sql.Schema) (diff.SqlRowDiffWriter, error) {
+	// TODO: default sample size
+	return tabular.NewFixedWidthDiffTableWriter(unionSch, iohelp.NopWrCloser(cli.CliOut), 100), nil
+}
+
+func (t *tabularDiffWriter) EndTable(ctx context.Context) error {
+	return nil
+}
+
+func (t *tabularDiffWriter) WriteTableName(ctx context.Context, td diff.TableDelta) error {
+	cli.Println(td.CurName())
+	return nil
+}
+
+func (t *tabularDiffWriter) WriteTableStat(ctx context.Context, td diff.TableDelta, stat tableStat) error {
+	cli.Printf("%s | %s\n", td.CurName(), formatStatCounts(stat))
+
+	t.tablesWritten++
+	t.totals.RowsAdded += stat.RowsAdded
+	t.totals.RowsDeleted += stat.RowsDeleted
+	t.totals.RowsModified += stat.RowsModified
+	t.totals.CellsModified += stat.CellsModified
+	return nil
+}
+
+func (t *tabularDiffWriter) Close(ctx context.Context) error {
+	if t.tablesWritten > 0 {
+		tables := pluralize("table changed", "tables changed", uint64(t.tablesWritten))
+		cli.Printf("%s, %s\n", tables, formatStatCounts(t.totals))
+	}
+	return nil
+}
+
+func formatStatCounts(stat tableStat) string {
+	return pluralize("row added", "rows added", stat.RowsAdded) + ", " +
+		pluralize("row deleted", "rows deleted", stat.RowsDeleted) + ", " +
+		pluralize("row modified", "rows modified", stat.RowsModified) + ", " +
+		pluralize("cell modified", "cells modified", stat.CellsModified)
+}
+
+type sqlDiffWriter struct{}
+
+var _ diffWriter = (*sqlDiffWriter)(nil)
+
+var errSqlSummaryOutput = errors.New("--stat and --name-only are not supported for sql output")
+
+func (s *sqlDiffWriter) BeginTable(ctx context.Context, td diff.TableDelta) error {
+	return nil
+}
+
+func (s *sqlDiffWriter) WriteSchemaDiff(ctx context.Context, toRoot *doltdb.RootValue, td diff.TableDelta) error {
+	toSchemas, err := toRoot.GetAllSchemas(ctx)
+	if err != nil {
+		return errhand.BuildDError("could not read schemas from toRoot").AddCause(err).Build()
+	}
+
+	stmts, verr := sqlSchemaDiff(ctx, td, toSchemas)
+	if verr != nil {
+		return verr
+	}
+	for _, stmt := range stmts {
+		cli.Println(stmt)
+	}
+	return nil
+}
+
+func (s *sqlDiffWriter) RowWriter(ctx context.Context, td diff.TableDelta, unionSch sql.Schema) (diff.SqlRowDiffWriter, error) {
+	targetSch := td.ToSch
+	if targetSch == nil {
+		targetSch = td.FromSch
+	}
+	return sqlexport.NewSqlDiffWriter(td.CurName(), targetSch, iohelp.NopWrCloser(cli.CliOut)), nil
+}
+
+func (s *sqlDiffWriter) EndTable(ctx context.Context) error {
+	return nil
+}
+
+func (s *sqlDiffWriter) WriteTableName(ctx context.Context, td diff.TableDelta) error {
+	return errSqlSummaryOutput
+}
+
+func (s *sqlDiffWriter) WriteTableStat(ctx context.Context, td diff.TableDelta, stat tableStat) error {
+	return errSqlSummaryOutput
+}
+
+func (s *sqlDiffWriter) Close(ctx context.Context) error {
+	return nil
+}
+
+const jsonDiffHeader = `{"tables":[`
+const jsonDiffFooter = `]}`
+
+// jsonDiffWriter writes a single JSON object with a "tables" array, which has one object per changed table. See the
+// diff command's documentation for the fields of these objects.
+type jsonDiffWriter struct {
+	tablesWritten int
+	schemaWritten bool
+	dataWritten   bool
+}
+
+var _ diffWriter = (*jsonDiffWriter)(nil)
+
+func (j *jsonDiffWriter) beginTableObject(v interface{}) error {
+	if j.tablesWritten == 0 {
+		cli.Print(jsonDiffHeader)
+	} else {
+		cli.Print(",")
+	}
+	j.tablesWritten++
+
+	data, err := ejson.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// leave the object open for the schema and data diffs
+	cli.Print(string(data[:len(data)-1]))
+	return nil
+}
+
+func (j *jsonDiffWriter) BeginTable(ctx context.Context, td diff.TableDelta) error {
+	j.schemaWritten, j.dataWritten = false, false
+	return j.beginTableObject(newTableSummary(td))
+}
+
+func (j *jsonDiffWriter) WriteSchemaDiff(ctx context.Context, toRoot *doltdb.RootValue, td diff.TableDelta) error {
+	toSchemas, err := toRoot.GetAllSchemas(ctx)
+	if err != nil {
+		return errhand.BuildDError("could not read schemas from toRoot").AddCause(err).Build()
+	}
+
+	stmts, verr := sqlSchemaDiff(ctx, td, toSchemas)
+	if verr != nil {
+		return verr
+	}
+	if stmts == nil {
+		stmts = []string{}
+	}
+
+	data, err := ejson.Marshal(stmts)
+	if err != nil {
+		return err
+	}
+
+	cli.Print(`,"schema_diff":` + string(data))
+	j.schemaWritten = true
+	return nil
+}
+
+func (j *jsonDiffWriter) RowWriter(ctx context.Context, td diff.TableDelta, unionSch sql.Schema) (diff.SqlRowDiffWriter, error) {
+	cli.Print(",")
+	j.dataWritten = true
+	wr, err := json.NewJSONDiffWriter(iohelp.NopWrCloser(cli.CliOut), unionSch)
+	if err != nil {
+		return nil, err
+	}
+	return diff.NewUntypedRowDiffWriter(wr), nil
+}
+
+func (j *jsonDiffWriter) EndTable(ctx context.Context) error {
+	if !j.schemaWritten {
+		cli.Print(`,"schema_diff":[]`)
+	}
+	if !j.dataWritten {
+		cli.Print(`,"data_diff":[]`)
+	}
+	cli.Print("}")
+	return nil
+}
+
+func (j *jsonDiffWriter) WriteTableName(ctx context.Context, td diff.TableDelta) error {
+	if err := j.beginTableObject(newTableSummary(td)); err != nil {
+		return err
+	}
+	cli.Print("}")
+	return nil
+}
+
+func (j *jsonDiffWriter) WriteTableStat(ctx context.Context, td diff.TableDelta, stat tableStat) error {
+	if err := j.beginTableObject(newTableSummary(td)); err != nil {
+		return err
+	}
+
+	data, err := ejson.Marshal(stat)
+	if err != nil {
+		return err
+	}
+
+	// splice the stat fields into the table object
+	cli.Print("," + string(data[1:]))
+	return nil
+}
+
+func (j *jsonDiffWriter) Close(ctx context.Context) error {
+	if j.tablesWritten == 0 {
+		cli.Print(jsonDiffHeader)
+	}
+	cli.Println(jsonDiffFooter)
+	return nil
+}
+
+var csvTableSummaryHeader = []string{"name", "from_name", "to_name", "diff_type"}
+var csvTableStatHeader = []string{"rows_unmodified", "rows_added", "rows_deleted", "rows_modified", "cells_modified", "old_row_count", "new_row_count"}
+
+// csvDiffWriter writes each table's data diff as a separate block of comma separated values with its own header
+// line, and writes --name-only and --stat output as a single block with one line per table.
+type csvDiffWriter struct {
+	wr            *bufio.Writer
+	blocksWritten int
+}
+
+var _ diffWriter = (*csvDiffWriter)(nil)
+
+func (c *csvDiffWriter) BeginTable(ctx context.Context, td diff.TableDelta) error {
+	return nil
+}
+
+func (c *csvDiffWriter) WriteSchemaDiff(ctx context.Context, toRoot *doltdb.RootValue, td diff.TableDelta) error {
+	return errors.New("schema diffs are not supported for csv output")
+}
+
+func (c *csvDiffWriter) RowWriter(ctx context.Context, td diff.TableDelta, unionSch sql.Schema) (diff.SqlRowDiffWriter, error) {
+	if c.blocksWritten > 0 {
+		cli.Println()
+	}
+	c.blocksWritten++
+	wr, err := csv.NewCSVDiffWriter(iohelp.NopWrCloser(cli.CliOut), unionSch)
+	if err != nil {
+		return nil, err
+	}
+	return diff.NewUntypedRowDiffWriter(wr), nil
+}
+
+func (c *csvDiffWriter) EndTable(ctx context.Context) error {
+	return nil
+}
+
+func (c *csvDiffWriter) WriteTableName(ctx context.Context, td diff.TableDelta) error {
+	if c.wr == nil {
+		c.wr = bufio.NewWriter(cli.CliOut)
+		if err := c.writeRecord(csvTableSummaryHeader); err != nil {
+			return err
+		}
+	}
+
+	ts := newTableSummary(td)
+	return c.writeRecord([]string{ts.Name, ts.FromName, ts.ToName, ts.DiffType})
+}
+
+func (c *csvDiffWriter) WriteTableStat(ctx context.Context, td diff.TableDelta, stat tableStat) error {
+	if c.wr == nil {
+		c.wr = bufio.NewWriter(cli.CliOut)
+		if err := c.writeRecord(append(csvTableSummaryHeader, csvTableStatHeader...)); err != nil {
+			return err
+		}
+	}
+
+	optional := func(n *uint64) string {
+		if n == nil {
+			return ""
+		}
+		return strconv.FormatUint(*n, 10)
+	}
+
+	ts := newTableSummary(td)
+	return c.writeRecord([]string{
+		ts.Name, ts.FromName, ts.ToName, ts.DiffType,
+		optional(stat.RowsUnmodified),
+		strconv.FormatUint(stat.RowsAdded, 10),
+		strconv.FormatUint(stat.RowsDeleted, 10),
+		strconv.FormatUint(stat.RowsModified, 10),
+		strconv.FormatUint(stat.CellsModified, 10),
+		optional(stat.OldRowCount),
+		optional(stat.NewRowCount),
+	})
+}
+
+func (c *csvDiffWriter) writeRecord(fields []string) error {
+	record := make([]*string, len(fields))
+	for i := range fields {
+		if len(fields[i]) > 0 {
+			record[i] = &fields[i]
+		}
+	}
+	return csv.WriteCSVRow(c.wr, record, ",", false)
+}
+
+func (c *csvDiffWriter) Close(ctx context.Context) error {
+	if c.wr != nil {
+		return c.wr.Flush()
+	}
+	return nil
+}
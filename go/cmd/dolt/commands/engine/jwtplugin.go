@@ -37,6 +37,14 @@ type authenticateDoltJWTPlugin struct {
 	jwksConfig []JwksConfig
 }
 
+// AuthPlugins returns the plaintext authentication plugins users of a server can be authenticated with, by the name
+// of the plugin of the users.
+func AuthPlugins(jwksConfig []JwksConfig) map[string]mysql_db.PlaintextAuthPlugin {
+	return map[string]mysql_db.PlaintextAuthPlugin{
+		"authentication_dolt_jwt": NewAuthenticateDoltJWTPlugin(jwksConfig),
+	}
+}
+
 func NewAuthenticateDoltJWTPlugin(jwksConfig []JwksConfig) mysql_db.PlaintextAuthPlugin {
 	return &authenticateDoltJWTPlugin{jwksConfig: jwksConfig}
 }
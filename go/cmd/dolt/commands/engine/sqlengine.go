@@ -29,13 +29,17 @@ import (
 	"github.com/dolthub/vitess/go/vt/sqlparser"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/branch_control"
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
 	dsqle "github.com/dolthub/dolt/go/libraries/doltcore/sqle"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/cluster"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/migrations"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/mysql_file_handler"
 	"github.com/dolthub/dolt/go/libraries/utils/config"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
 )
 
 // SqlEngine packages up the context necessary to run sql queries against dsqle.
@@ -45,20 +49,25 @@ type SqlEngine struct {
 	dsessFactory   func(ctx context.Context, mysqlSess *sql.BaseSession, dbs []sql.Database) (*dsess.DoltSession, error)
 	engine         *gms.Engine
 	resultFormat   PrintResultFormat
+	bcController   *branch_control.Controller
+	provider       dsqle.DoltDatabaseProvider
 }
 
 type SqlEngineConfig struct {
-	InitialDb      string
-	IsReadOnly     bool
-	IsServerLocked bool
-	DoltCfgDirPath string
-	PrivFilePath   string
-	ServerUser     string
-	ServerPass     string
-	ServerHost     string
-	Autocommit     bool
-	Bulk           bool
-	JwksConfig     []JwksConfig
+	InitialDb          string
+	IsReadOnly         bool
+	IsServerLocked     bool
+	DoltCfgDirPath     string
+	PrivFilePath       string
+	BranchCtrlFilePath string
+	ServerUser         string
+	ServerPass         string
+	ServerHost         string
+	Autocommit         bool
+	Bulk               bool
+	JwksConfig         []JwksConfig
+	ClusterController  *cluster.Controller
+	StorageAllowlist   []string
 }
 
 // NewSqlEngine returns a SqlEngine
@@ -86,12 +95,35 @@ func NewSqlEngine(
 		return nil, err
 	}
 
+	if config.ClusterController != nil {
+		err = config.ClusterController.ApplyStandbyReplication(ctx, bThreads, mrEnv)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	infoDB := information_schema.NewInformationSchemaDatabase()
 	all := append(dsqleDBsAsSqlDBs(dbs), infoDB)
 
 	b := env.GetDefaultInitBranch(mrEnv.Config())
 	pro := dsqle.NewDoltDatabaseProvider(b, mrEnv.FileSystem(), all...).WithRemoteDialer(mrEnv.RemoteDialProvider())
 
+	// The provider checks privileges itself before creating a database with a storage, so it shares the engine's
+	// privileges, and the storage allowlist is resolved the same way as the urls it's checked against.
+	mysqlDb := mysql_db.CreateEmptyMySQLDb()
+	allowlist := make([]string, len(config.StorageAllowlist))
+	for i, urlStr := range config.StorageAllowlist {
+		_, allowlist[i], err = env.GetAbsRemoteUrl(mrEnv.FileSystem(), mrEnv.Config(), urlStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage allowlist entry '%s': %w", urlStr, err)
+		}
+	}
+	pro = pro.WithPrivileges(mysqlDb).WithStorageAllowlist(allowlist)
+
+	if config.ClusterController != nil {
+		config.ClusterController.RegisterStoredProcedures(pro, mysqlDb)
+	}
+
 	// Load in privileges from file, if it exists
 	persister := mysql_file_handler.NewPersister(config.PrivFilePath, config.DoltCfgDirPath)
 	data, err := persister.LoadData()
@@ -99,13 +131,29 @@ func NewSqlEngine(
 		return nil, err
 	}
 
+	// Load in branch permissions from file, if a file is configured. Engines without one keep them in memory.
+	bcController, err := branch_control.LoadData(config.BranchCtrlFilePath, config.DoltCfgDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// set host to "%" if empty string or 0.0.0.0, the same as the server's super user
+	superHost := config.ServerHost
+	if superHost == "" || superHost == "0.0.0.0" {
+		superHost = "%"
+	}
+	bcController.SetSuperUser(config.ServerUser, superHost)
+
 	// Set up engine
 	engine := gms.New(analyzer.NewBuilder(pro).WithParallelism(parallelism).Build(), &gms.Config{IsReadOnly: config.IsReadOnly, IsServerLocked: config.IsServerLocked}).WithBackgroundThreads(bThreads)
+	engine.Analyzer.Catalog.MySQLDb = mysqlDb
 	engine.Analyzer.Catalog.MySQLDb.SetPersister(persister)
 
-	engine.Analyzer.Catalog.MySQLDb.SetPlugins(map[string]mysql_db.PlaintextAuthPlugin{
-		"authentication_dolt_jwt": NewAuthenticateDoltJWTPlugin(config.JwksConfig),
-	})
+	engine.Analyzer.Catalog.MySQLDb.SetPlugins(AuthPlugins(config.JwksConfig))
+
+	// dolt_migrate runs the statements of migrations with this engine, and reads them from the directory of the
+	// current database
+	migrations.RegisterStoredProcedures(pro, mysqlDb, engine, databaseFilesys(mrEnv))
 
 	// Load MySQL Db information
 	if err = engine.Analyzer.Catalog.MySQLDb.LoadData(sql.NewEmptyContext(), data); err != nil {
@@ -136,6 +184,9 @@ func NewSqlEngine(
 	if err != nil {
 		return nil, err
 	}
+	sess.SetBranchController(bcController)
+	writeGuard := clusterWriteGuard(config.ClusterController)
+	sess.SetWriteGuard(writeGuard)
 
 	// this is overwritten only for server sessions
 	for _, db := range dbs {
@@ -151,9 +202,11 @@ func NewSqlEngine(
 	return &SqlEngine{
 		dbs:            nameToDB,
 		contextFactory: newSqlContext(sess, config.InitialDb),
-		dsessFactory:   newDoltSession(pro, mrEnv.Config(), config.Autocommit),
+		dsessFactory:   newDoltSession(pro, mrEnv.Config(), config.Autocommit, bcController, writeGuard),
 		engine:         engine,
 		resultFormat:   format,
+		bcController:   bcController,
+		provider:       pro,
 	}, nil
 }
 
@@ -207,6 +260,17 @@ func (se *SqlEngine) NewDoltSession(ctx context.Context, mysqlSess *sql.BaseSess
 	return se.dsessFactory(ctx, mysqlSess, se.engine.Analyzer.Catalog.AllDatabases(tempCtx))
 }
 
+// GetDatabaseProvider returns the provider of the databases of the engine, which doesn't check the privileges of the
+// session databases are looked up with.
+func (se *SqlEngine) GetDatabaseProvider() dsqle.DoltDatabaseProvider {
+	return se.provider
+}
+
+// GetBranchController returns the branch permissions enforced by the sessions of the engine, or nil if there are none.
+func (se *SqlEngine) GetBranchController() *branch_control.Controller {
+	return se.bcController
+}
+
 // GetReturnFormat() returns the printing format the engine is associated with.
 func (se *SqlEngine) GetReturnFormat() PrintResultFormat {
 	return se.resultFormat
@@ -291,7 +355,7 @@ func newSqlContext(sess *dsess.DoltSession, initialDb string) func(ctx context.C
 	}
 }
 
-func newDoltSession(pro dsqle.DoltDatabaseProvider, config config.ReadWriteConfig, autocommit bool) func(ctx context.Context, mysqlSess *sql.BaseSession, dbs []sql.Database) (*dsess.DoltSession, error) {
+func newDoltSession(pro dsqle.DoltDatabaseProvider, config config.ReadWriteConfig, autocommit bool, bcController *branch_control.Controller, writeGuard dsess.WriteGuard) func(ctx context.Context, mysqlSess *sql.BaseSession, dbs []sql.Database) (*dsess.DoltSession, error) {
 	return func(ctx context.Context, mysqlSess *sql.BaseSession, dbs []sql.Database) (*dsess.DoltSession, error) {
 		ddbs := dsqle.DbsAsDSQLDBs(dbs)
 		states, err := getDbStates(ctx, ddbs)
@@ -303,6 +367,8 @@ func newDoltSession(pro dsqle.DoltDatabaseProvider, config config.ReadWriteConfi
 		if err != nil {
 			return nil, err
 		}
+		dsess.SetBranchController(bcController)
+		dsess.SetWriteGuard(writeGuard)
 
 		// TODO: this should just be the session default like it is with MySQL
 		err = dsess.SetSessionVariable(sql.NewContext(ctx), sql.AutoCommitSessionVar, autocommit)
@@ -314,6 +380,33 @@ func newDoltSession(pro dsqle.DoltDatabaseProvider, config config.ReadWriteConfi
 	}
 }
 
+// clusterWriteGuard returns the write guard of the sessions of a server with the cluster controller given, which is
+// nil if the server isn't part of a cluster.
+func clusterWriteGuard(controller *cluster.Controller) dsess.WriteGuard {
+	if controller == nil {
+		return nil
+	}
+	return controller.WriteGuard
+}
+
+// databaseFilesys returns the filesystems of the directories of the databases of |mrEnv|. Databases created after the
+// engine are in a directory of the same name in the data dir, as the provider creates them.
+func databaseFilesys(mrEnv *env.MultiRepoEnv) migrations.DatabaseFilesys {
+	return func(dbName string) (filesys.Filesys, bool) {
+		if dEnv := mrEnv.GetEnv(dbName); dEnv != nil {
+			return dEnv.FS, true
+		}
+		if exists, isDir := mrEnv.FileSystem().Exists(dbName); !exists || !isDir {
+			return nil, false
+		}
+		fs, err := mrEnv.FileSystem().WithWorkingDir(dbName)
+		if err != nil {
+			return nil, false
+		}
+		return fs, true
+	}
+}
+
 func getDbStates(ctx context.Context, dbs []dsqle.SqlDatabase) ([]dsess.InitialDbState, error) {
 	dbStates := make([]dsess.InitialDbState, len(dbs))
 	for i, db := range dbs {
@@ -36,7 +36,7 @@ func CollectDBs(ctx context.Context, mrEnv *env.MultiRepoEnv, useBulkEditor bool
 	var db sqle.SqlDatabase
 
 	err := mrEnv.Iter(func(name string, dEnv *env.DoltEnv) (stop bool, err error) {
-		postCommitHooks, err := GetCommitHooks(ctx, dEnv)
+		postCommitHooks, err := GetCommitHooks(ctx, name, dEnv)
 		if err != nil {
 			return true, err
 		}
@@ -68,10 +68,10 @@ func CollectDBs(ctx context.Context, mrEnv *env.MultiRepoEnv, useBulkEditor bool
 
 // GetCommitHooks creates a list of hooks to execute on database commit. If doltdb.SkipReplicationErrorsKey is set,
 // replace misconfigured hooks with doltdb.LogHook instances that prints a warning when trying to execute.
-func GetCommitHooks(ctx context.Context, dEnv *env.DoltEnv) ([]doltdb.CommitHook, error) {
+func GetCommitHooks(ctx context.Context, name string, dEnv *env.DoltEnv) ([]doltdb.CommitHook, error) {
 	postCommitHooks := make([]doltdb.CommitHook, 0)
 
-	if hook, err := getPushOnWriteHook(ctx, dEnv); err != nil {
+	if hook, err := getPushOnWriteHook(ctx, name, dEnv); err != nil {
 		err = fmt.Errorf("failure loading hook; %w", err)
 		if sqle.SkipReplicationWarnings() {
 			postCommitHooks = append(postCommitHooks, doltdb.NewLogHook([]byte(err.Error()+"\n")))
@@ -120,7 +120,7 @@ func newReplicaDatabase(ctx context.Context, name string, remoteName string, dEn
 	return rrd, nil
 }
 
-func getPushOnWriteHook(ctx context.Context, dEnv *env.DoltEnv) (*doltdb.PushOnWriteHook, error) {
+func getPushOnWriteHook(ctx context.Context, name string, dEnv *env.DoltEnv) (*doltdb.PushOnWriteHook, error) {
 	_, val, ok := sql.SystemVariables.GetGlobal(dsess.ReplicateToRemoteKey)
 	if !ok {
 		return nil, sql.ErrUnknownSystemVariable.New(dsess.ReplicateToRemoteKey)
@@ -148,6 +148,6 @@ func getPushOnWriteHook(ctx context.Context, dEnv *env.DoltEnv) (*doltdb.PushOnW
 		return nil, err
 	}
 
-	pushHook := doltdb.NewPushOnWriteHook(ddb, dEnv.TempTableFilesDir())
+	pushHook := doltdb.NewPushOnWriteHook(name, ddb, dEnv.TempTableFilesDir())
 	return pushHook, nil
 }
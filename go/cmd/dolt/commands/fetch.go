@@ -34,10 +34,12 @@ var fetchDocs = cli.CommandDocumentationContent{
 By default dolt will attempt to fetch from a remote named {{.EmphasisLeft}}origin{{.EmphasisRight}}.  The {{.LessThan}}remote{{.GreaterThan}} parameter allows you to specify the name of a different remote you wish to pull from by the remote's name.
 
 When no refspec(s) are specified on the command line, the fetch_specs for the default remote are used.
+
+In a shallow clone, created with {{.EmphasisLeft}}dolt clone --depth{{.EmphasisRight}}, fetched commits keep the history of the repository truncated where it was. {{.EmphasisLeft}}--depth{{.EmphasisRight}} truncates the history of the fetched branches to the given number of commits, and {{.EmphasisLeft}}--unshallow{{.EmphasisRight}} retrieves all the history missing from a shallow clone.
 `,
 
 	Synopsis: []string{
-		"[{{.LessThan}}remote{{.GreaterThan}}] [{{.LessThan}}refspec{{.GreaterThan}} ...]",
+		"[--depth {{.LessThan}}depth{{.GreaterThan}} | --unshallow] [{{.LessThan}}remote{{.GreaterThan}}] [{{.LessThan}}refspec{{.GreaterThan}} ...]",
 	},
 }
 
@@ -79,12 +81,36 @@ func (cmd FetchCmd) Exec(ctx context.Context, commandStr string, args []string,
 	}
 	updateMode := ref.UpdateMode{Force: apr.Contains(cli.ForceFlag)}
 
+	depth, ok := apr.GetInt(cli.DepthParam)
+	if ok && depth < 1 {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: depth %d is not a positive number", depth).Build(), usage)
+	}
+	unshallow := apr.Contains(cli.UnshallowFlag)
+	if ok && unshallow {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: --%s and --%s cannot be used together", cli.DepthParam, cli.UnshallowFlag).Build(), usage)
+	}
+
 	srcDB, err := r.GetRemoteDBWithoutCaching(ctx, dEnv.DbData().Ddb.ValueReadWriter().Format(), dEnv)
 	if err != nil {
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
 	}
 
-	err = actions.FetchRefSpecs(ctx, dEnv.DbData(), srcDB, refSpecs, r, updateMode, buildProgStarter(downloadLanguage), stopProgFuncs)
+	if unshallow {
+		shallow, err := dEnv.GetShallowCommits()
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
+		}
+		if len(shallow) == 0 {
+			return HandleVErrAndExitCode(errhand.BuildDError("error: --%s on a complete repository does not make sense", cli.UnshallowFlag).Build(), usage)
+		}
+
+		err = actions.Unshallow(ctx, dEnv.DbData(), srcDB, buildProgStarter(downloadLanguage), stopProgFuncs)
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.BuildDError("error: failed to fetch the missing history").AddCause(err).Build(), usage)
+		}
+	}
+
+	err = actions.FetchRefSpecs(ctx, dEnv.DbData(), srcDB, refSpecs, r, updateMode, depth, buildProgStarter(downloadLanguage), stopProgFuncs)
 	switch err {
 	case doltdb.ErrUpToDate:
 		return HandleVErrAndExitCode(nil, usage)
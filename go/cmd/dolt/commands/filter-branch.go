@@ -15,10 +15,15 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	goerrors "errors"
 	"fmt"
 	"io"
+	"path"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 
 	sqle "github.com/dolthub/go-mysql-server"
@@ -35,15 +40,24 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/doltcore/rebase"
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
 	dsqle "github.com/dolthub/dolt/go/libraries/doltcore/sqle"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
 	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/hash"
 )
 
 const (
 	dbName = "filterDB"
+
+	branchesFlag   = "branches"
+	tagsFlag       = "tags"
+	tablesFlag     = "tables"
+	rewriteMapFlag = "rewrite-map"
+	pruneEmptyFlag = "prune-empty"
+	commitMapFlag  = "commit-map"
 )
 
 var filterBranchDocs = cli.CommandDocumentationContent{
@@ -52,11 +66,26 @@ var filterBranchDocs = cli.CommandDocumentationContent{
 
 If a {{.LessThan}}commit-spec{{.GreaterThan}} is provided, the traversal will stop when the commit is reached and rewriting will begin at that commit, or will error if the commit is not found.
 
-If the {{.EmphasisLeft}}--all{{.EmphasisRight}} flag is supplied, the traversal starts with the HEAD commits of all branches.
+If the {{.EmphasisLeft}}--all{{.EmphasisRight}} flag is supplied, the traversal starts with the HEAD commits of all branches. The {{.EmphasisLeft}}--branches{{.EmphasisRight}} and {{.EmphasisLeft}}--tags{{.EmphasisRight}} options take comma separated shell globs, e.g. {{.EmphasisLeft}}--branches 'main,release/*'{{.EmphasisRight}}, and rewrite the branches and tags that match them.
+
+If the {{.EmphasisLeft}}--file{{.EmphasisRight}} option is supplied, the statements of the file are applied to every commit instead of a single query. If the {{.EmphasisLeft}}--tables{{.EmphasisRight}} option is supplied, only the changes the statements make to the tables listed are kept.
+
+The {{.EmphasisLeft}}--rewrite-map{{.EmphasisRight}} option rewrites the metadata of every commit, including the initial commit, with the entries of a mapping file. Each line of the file is an entry of the form {{.LessThan}}kind{{.GreaterThan}} {{.LessThan}}old{{.GreaterThan}} => {{.LessThan}}new{{.GreaterThan}}, and lines starting with # are ignored:
+
+	author Old Name <old@example.com> => New Name <new@example.com>
+	committer <old@example.com> => <new@example.com>
+	message secret token => [redacted]
+
+Author and committer entries match the name and email given on their left and replace the name and email given on their right. Dolt records a single identity for each commit, so both kinds rewrite it. Message entries replace every occurrence of the text on their left in commit messages. To only rewrite metadata, pass an empty query.
+
+If the {{.EmphasisLeft}}--prune-empty{{.EmphasisRight}} flag is supplied, commits that end up with no changes from their parent are dropped.
+
+The heads of the rewritten branches and tags are kept under {{.EmphasisLeft}}refs/original/{{.EmphasisRight}}, e.g. {{.EmphasisLeft}}refs/original/heads/main{{.EmphasisRight}}, so that a rewrite can be undone with {{.EmphasisLeft}}dolt reset --hard refs/original/heads/main{{.EmphasisRight}}. The command refuses to overwrite the heads kept by a previous rewrite unless {{.EmphasisLeft}}--force{{.EmphasisRight}} is supplied. The {{.EmphasisLeft}}--commit-map{{.EmphasisRight}} option writes the hash of every rewritten commit and the hash of the commit that replaces it to a file, one pair per line.
 `,
 
 	Synopsis: []string{
-		"[--all] {{.LessThan}}query{{.GreaterThan}} [{{.LessThan}}commit{{.GreaterThan}}]",
+		"[--all] [--branches {{.LessThan}}globs{{.GreaterThan}}] [--tags {{.LessThan}}globs{{.GreaterThan}}] [--tables {{.LessThan}}tables{{.GreaterThan}}] {{.LessThan}}query{{.GreaterThan}} [{{.LessThan}}commit{{.GreaterThan}}]",
+		"[--all] [--branches {{.LessThan}}globs{{.GreaterThan}}] [--tags {{.LessThan}}globs{{.GreaterThan}}] [--tables {{.LessThan}}tables{{.GreaterThan}}] --file {{.LessThan}}file{{.GreaterThan}} [{{.LessThan}}commit{{.GreaterThan}}]",
 	},
 }
 
@@ -82,6 +111,14 @@ func (cmd FilterBranchCmd) Docs() *cli.CommandDocumentation {
 func (cmd FilterBranchCmd) ArgParser() *argparser.ArgParser {
 	ap := argparser.NewArgParser()
 	ap.SupportsFlag(allFlag, "a", "filter all branches")
+	ap.SupportsString(branchesFlag, "", "globs", "filter the branches that match the comma separated globs given")
+	ap.SupportsString(tagsFlag, "", "globs", "filter the tags that match the comma separated globs given")
+	ap.SupportsString(tablesFlag, "", "tables", "only keep the changes to the comma separated tables given")
+	ap.SupportsString(fileInputFlag, "", "file", "apply the statements of the file given instead of a query")
+	ap.SupportsString(rewriteMapFlag, "", "file", "rewrite the author, committer and message of commits with the mapping file given")
+	ap.SupportsFlag(pruneEmptyFlag, "", "drop the commits that end up with no changes")
+	ap.SupportsString(commitMapFlag, "", "file", "write the hashes of the rewritten commits and of the commits that replace them to the file given")
+	ap.SupportsFlag(forceFlag, "f", "overwrite the heads kept under refs/original/ by a previous filter-branch")
 	return ap
 }
 
@@ -96,9 +133,15 @@ func (cmd FilterBranchCmd) Exec(ctx context.Context, commandStr string, args []s
 	help, usage := cli.HelpAndUsagePrinters(cli.CommandDocsForCommandString(commandStr, filterBranchDocs, ap))
 	apr := cli.ParseArgsOrDie(ap, args, help)
 
-	if apr.NArg() < 1 || apr.NArg() > 2 {
+	minArgs, maxArgs := 1, 2
+	if apr.Contains(fileInputFlag) {
+		minArgs, maxArgs = 0, 1
+	} else if apr.Contains(rewriteMapFlag) {
+		minArgs = 0
+	}
+	if apr.NArg() < minArgs || apr.NArg() > maxArgs {
 		args := strings.Join(apr.Args, ", ")
-		verr := errhand.BuildDError("%s takes 1 or 2 args, %d provided: %s", cmd.Name(), apr.NArg(), args).Build()
+		verr := errhand.BuildDError("%s takes %d or %d args, %d provided: %s", cmd.Name(), minArgs, maxArgs, apr.NArg(), args).Build()
 		return HandleVErrAndExitCode(verr, usage)
 	}
 
@@ -106,23 +149,48 @@ func (cmd FilterBranchCmd) Exec(ctx context.Context, commandStr string, args []s
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(env.ErrActiveServerLock.New(dEnv.LockFile())), help)
 	}
 
-	query := apr.Arg(0)
+	queries, commitSpecStr, err := getFilterQueries(dEnv, apr)
+	if err != nil {
+		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
+	}
+
+	var tables []string
+	if tablesStr, ok := apr.GetValue(tablesFlag); ok {
+		tables = splitCommaList(tablesStr)
+	}
+
+	opts := rebase.RewriteOptions{
+		PruneEmpty:    apr.Contains(pruneEmptyFlag),
+		KeepOriginals: true,
+		Force:         apr.Contains(forceFlag),
+	}
+	if mapFile, ok := apr.GetValue(rewriteMapFlag); ok {
+		opts.RewriteMeta, err = loadRewriteMap(dEnv, mapFile)
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
+		}
+	}
+
 	notFound := make(missingTbls)
 	replay := func(ctx context.Context, commit, _, _ *doltdb.Commit) (*doltdb.RootValue, error) {
-		return processFilterQuery(ctx, dEnv, commit, query, notFound)
+		return processFilterQueries(ctx, dEnv, commit, queries, tables, notFound)
 	}
 
-	nerf, err := getNerf(ctx, dEnv, apr)
+	nerf, err := getNerf(ctx, dEnv, commitSpecStr)
 	if err != nil {
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
 	}
 
-	if apr.Contains(allFlag) {
-		err = rebase.AllBranches(ctx, dEnv, replay, nerf)
-	} else {
-		err = rebase.CurrentBranch(ctx, dEnv, replay, nerf)
+	refs, err := getFilterRefs(ctx, dEnv, apr)
+	if err != nil {
+		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
 	}
+
+	commitMap, err := rebase.RewriteRefs(ctx, dEnv.DbData(), replay, nerf, opts, refs...)
 	if err != nil {
+		if goerrors.Is(err, rebase.ErrOriginalsExist) {
+			err = fmt.Errorf("%w\nforce overwriting the backup with --force", err)
+		}
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
 	}
 
@@ -130,15 +198,129 @@ func (cmd FilterBranchCmd) Exec(ctx context.Context, commandStr string, args []s
 		cli.PrintErrln(color.YellowString("for root value %s: %s", h.String(), e.Error()))
 	}
 
+	if mapFile, ok := apr.GetValue(commitMapFlag); ok {
+		err = writeCommitMap(dEnv, mapFile, commitMap)
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
+		}
+	}
+
 	return 0
 }
 
-func getNerf(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) (rebase.NeedsRebaseFn, error) {
-	if apr.NArg() == 1 {
+// getFilterQueries returns the statements to apply to every commit, and the commit spec to stop at, if any.
+func getFilterQueries(dEnv *env.DoltEnv, apr *argparser.ArgParseResults) ([]string, string, error) {
+	var queries []string
+	args := apr.Args
+	if fileInput, ok := apr.GetValue(fileInputFlag); ok {
+		data, err := dEnv.FS.ReadFile(fileInput)
+		if err != nil {
+			return nil, "", fmt.Errorf("couldn't read file %s: %w", fileInput, err)
+		}
+
+		scanner := NewSqlStatementScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			queries = append(queries, scanner.Text())
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, "", err
+		}
+	} else if len(args) > 0 {
+		queries, args = args[:1], args[1:]
+	}
+
+	var nonEmpty []string
+	for _, query := range queries {
+		if strings.TrimSpace(query) != "" {
+			nonEmpty = append(nonEmpty, query)
+		}
+	}
+
+	if len(args) > 0 {
+		return nonEmpty, args[0], nil
+	}
+	return nonEmpty, "", nil
+}
+
+// getFilterRefs returns the branches and tags to rewrite. The current branch is rewritten unless --all or --branches
+// is given.
+func getFilterRefs(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) ([]ref.DoltRef, error) {
+	var refs []ref.DoltRef
+	if apr.Contains(allFlag) {
+		branches, err := dEnv.DoltDB.GetBranches(ctx)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, branches...)
+	} else if globs, ok := apr.GetValue(branchesFlag); ok {
+		branches, err := dEnv.DoltDB.GetBranches(ctx)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := matchRefs(branches, globs)
+		if err != nil {
+			return nil, err
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no branches match %s", globs)
+		}
+		refs = append(refs, matched...)
+	} else {
+		refs = append(refs, dEnv.RepoStateReader().CWBHeadRef())
+	}
+
+	if globs, ok := apr.GetValue(tagsFlag); ok {
+		tags, err := dEnv.DoltDB.GetTags(ctx)
+		if err != nil {
+			return nil, err
+		}
+		matched, err := matchRefs(tags, globs)
+		if err != nil {
+			return nil, err
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no tags match %s", globs)
+		}
+		refs = append(refs, matched...)
+	}
+
+	return refs, nil
+}
+
+// matchRefs returns the refs whose names match any of the comma separated shell globs given.
+func matchRefs(refs []ref.DoltRef, globs string) ([]ref.DoltRef, error) {
+	var matched []ref.DoltRef
+	for _, r := range refs {
+		for _, glob := range splitCommaList(globs) {
+			ok, err := path.Match(glob, r.GetPath())
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob '%s': %w", glob, err)
+			}
+			if ok {
+				matched = append(matched, r)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
+func splitCommaList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getNerf(ctx context.Context, dEnv *env.DoltEnv, commitSpecStr string) (rebase.NeedsRebaseFn, error) {
+	if commitSpecStr == "" {
 		return rebase.EntireHistory(), nil
 	}
 
-	cs, err := doltdb.NewCommitSpec(apr.Arg(1))
+	cs, err := doltdb.NewCommitSpec(commitSpecStr)
 	if err != nil {
 		return nil, err
 	}
@@ -151,11 +333,121 @@ func getNerf(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResu
 	return rebase.StopAtCommit(cm), nil
 }
 
-func processFilterQuery(ctx context.Context, dEnv *env.DoltEnv, cm *doltdb.Commit, query string, mt missingTbls) (*doltdb.RootValue, error) {
+// identity is the name and email of an author or committer entry of a rewrite map. Either may be empty.
+type identity struct {
+	name  string
+	email string
+}
+
+var identityRegex = regexp.MustCompile(`^([^<>]*?)\s*(?:<([^<>]*)>)?$`)
+
+func parseIdentity(str string) (identity, error) {
+	matches := identityRegex.FindStringSubmatch(strings.TrimSpace(str))
+	if matches == nil || (matches[1] == "" && matches[2] == "") {
+		return identity{}, fmt.Errorf("invalid identity '%s', expected Name <email>", str)
+	}
+	return identity{name: matches[1], email: matches[2]}, nil
+}
+
+func (id identity) matches(meta *datas.CommitMeta) bool {
+	return (id.name == "" || id.name == meta.Name) && (id.email == "" || id.email == meta.Email)
+}
+
+type identityRewrite struct {
+	old, new identity
+}
+
+type messageRewrite struct {
+	old, new string
+}
+
+// loadRewriteMap reads the rewrite map file given and returns a function that rewrites commit metadata with it.
+func loadRewriteMap(dEnv *env.DoltEnv, mapFile string) (rebase.RewriteMetaFn, error) {
+	data, err := dEnv.FS.ReadFile(mapFile)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read file %s: %w", mapFile, err)
+	}
+
+	var identities []identityRewrite
+	var messages []messageRewrite
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		kind, entry, _ := strings.Cut(line, " ")
+		old, new, ok := strings.Cut(entry, "=>")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected an entry of the form <kind> <old> => <new>", mapFile, i+1)
+		}
+
+		switch kind {
+		case "author", "committer":
+			oldId, err := parseIdentity(old)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", mapFile, i+1, err)
+			}
+			newId, err := parseIdentity(new)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", mapFile, i+1, err)
+			}
+			identities = append(identities, identityRewrite{old: oldId, new: newId})
+
+		case "message":
+			old = strings.TrimSpace(old)
+			if old == "" {
+				return nil, fmt.Errorf("%s:%d: message entries need the text to replace", mapFile, i+1)
+			}
+			messages = append(messages, messageRewrite{old: old, new: strings.TrimSpace(new)})
+
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown entry kind '%s', expected author, committer or message", mapFile, i+1, kind)
+		}
+	}
+
+	return func(_ context.Context, meta *datas.CommitMeta) (*datas.CommitMeta, error) {
+		rewritten := *meta
+		for _, r := range identities {
+			if r.old.matches(meta) {
+				if r.new.name != "" {
+					rewritten.Name = r.new.name
+				}
+				if r.new.email != "" {
+					rewritten.Email = r.new.email
+				}
+				break
+			}
+		}
+		for _, r := range messages {
+			rewritten.Description = strings.ReplaceAll(rewritten.Description, r.old, r.new)
+		}
+		return &rewritten, nil
+	}, nil
+}
+
+// writeCommitMap writes the hashes of the rewritten commits and of the commits that replace them to |mapFile|, one
+// pair per line.
+func writeCommitMap(dEnv *env.DoltEnv, mapFile string, commitMap rebase.CommitMap) error {
+	lines := make([]string, 0, len(commitMap))
+	for oldHash, newHash := range commitMap {
+		lines = append(lines, oldHash.String()+" "+newHash.String()+"\n")
+	}
+	sort.Strings(lines)
+
+	return dEnv.FS.WriteFile(mapFile, []byte(strings.Join(lines, "")))
+}
+
+// processFilterQueries applies |queries| to the root of |cm| and returns the resulting root. If |tables| is not empty,
+// only the changes to those tables are kept.
+func processFilterQueries(ctx context.Context, dEnv *env.DoltEnv, cm *doltdb.Commit, queries []string, tables []string, mt missingTbls) (*doltdb.RootValue, error) {
 	root, err := cm.GetRootValue(ctx)
 	if err != nil {
 		return nil, err
 	}
+	if len(queries) == 0 {
+		return root, nil
+	}
 
 	sqlCtx, eng, err := rebaseSqlEngine(ctx, dEnv, cm)
 	if err != nil {
@@ -167,11 +459,59 @@ func processFilterQuery(ctx context.Context, dEnv *env.DoltEnv, cm *doltdb.Commi
 		return nil, err
 	}
 
-	sqlStatement, err := sqlparser.Parse(query)
+	for _, query := range queries {
+		err = processFilterQuery(sqlCtx, eng, query, mt, rh)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	roots, err := eng.GetRoots(sqlCtx)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(tables) == 0 {
+		return roots[dbName], nil
+	}
+	return keepTableChanges(ctx, root, roots[dbName], tables)
+}
+
+// keepTableChanges returns |root| with the tables named in |tables| replaced by their version in |filtered|.
+func keepTableChanges(ctx context.Context, root, filtered *doltdb.RootValue, tables []string) (*doltdb.RootValue, error) {
+	for _, tblName := range tables {
+		tbl, ok, err := filtered.GetTable(ctx, tblName)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			root, err = root.PutTable(ctx, tblName, tbl)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		ok, err = root.HasTable(ctx, tblName)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			root, err = root.RemoveTables(ctx, false, false, tblName)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return root, nil
+}
+
+func processFilterQuery(sqlCtx *sql.Context, eng *engine.SqlEngine, query string, mt missingTbls, rh hash.Hash) error {
+	sqlStatement, err := sqlparser.Parse(query)
+	if err != nil {
+		return err
+	}
+
 	itr := sql.RowsToRowIter() // empty RowIter
 	switch sqlStatement.(type) {
 	case *sqlparser.Insert, *sqlparser.Update:
@@ -184,19 +524,19 @@ func processFilterQuery(ctx context.Context, dEnv *env.DoltEnv, cm *doltdb.Commi
 	case *sqlparser.DDL:
 		_, itr, err = eng.Query(sqlCtx, query)
 	case *sqlparser.Select, *sqlparser.OtherRead, *sqlparser.Show, *sqlparser.Explain, *sqlparser.Union:
-		return nil, fmt.Errorf("filter-branch queries must be write queries: '%s'", query)
+		return fmt.Errorf("filter-branch queries must be write queries: '%s'", query)
 
 	default:
-		return nil, fmt.Errorf("SQL statement not supported for filter-branch: '%s'", query)
+		return fmt.Errorf("SQL statement not supported for filter-branch: '%s'", query)
 	}
 
 	err, ok := captureTblNotFoundErr(err, mt, rh)
 	if ok {
 		// table doesn't exist, save the error and continue
-		return root, nil
+		return nil
 	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	for {
@@ -204,20 +544,10 @@ func processFilterQuery(ctx context.Context, dEnv *env.DoltEnv, cm *doltdb.Commi
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return nil, err
+			return err
 		}
 	}
-	err = itr.Close(sqlCtx)
-	if err != nil {
-		return nil, err
-	}
-
-	roots, err := eng.GetRoots(sqlCtx)
-	if err != nil {
-		return nil, err
-	}
-
-	return roots[dbName], nil
+	return itr.Close(sqlCtx)
 }
 
 // rebaseSqlEngine packages up the context necessary to run sql queries against single root
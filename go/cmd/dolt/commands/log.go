@@ -16,6 +16,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -228,7 +229,10 @@ func logCommits(ctx context.Context, dEnv *env.DoltEnv, cs *doltdb.CommitSpec, o
 	}
 	commits, err := commitwalk.GetTopNTopoOrderedCommitsMatching(ctx, dEnv.DoltDB, h, opts.numLines, matchFunc)
 
-	if err != nil {
+	if errors.Is(err, doltdb.ErrMissingAncestor) {
+		cli.PrintErrln(color.RedString("error: %s", err.Error()))
+		return 1
+	} else if err != nil {
 		cli.PrintErrln("Error retrieving commit.")
 		return 1
 	}
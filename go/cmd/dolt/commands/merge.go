@@ -177,12 +177,12 @@ func (cmd MergeCmd) Exec(ctx context.Context, commandStr string, args []string,
 
 			tblToStats, mergeErr := merge.MergeCommitSpec(ctx, dEnv, spec)
 			hasConflicts, hasConstraintViolations := printSuccessStats(tblToStats)
-			wRoot, err := dEnv.WorkingRoot(ctx)
+			ws, err := dEnv.WorkingSet(ctx)
 			if err != nil {
 				cli.PrintErrln(err.Error())
 				return 1
 			}
-			unmergedCnt, err := getUnmergedTableCount(ctx, wRoot)
+			unmergedCnt, err := getUnmergedTableCount(ctx, ws)
 			if err != nil {
 				cli.PrintErrln(err.Error())
 				return 1
@@ -214,7 +214,8 @@ func (cmd MergeCmd) Exec(ctx context.Context, commandStr string, args []string,
 	return handleCommitErr(ctx, dEnv, verr, usage)
 }
 
-func getUnmergedTableCount(ctx context.Context, root *doltdb.RootValue) (int, error) {
+func getUnmergedTableCount(ctx context.Context, ws *doltdb.WorkingSet) (int, error) {
+	root := ws.WorkingRoot()
 	conflicted, err := root.TablesInConflict(ctx)
 	if err != nil {
 		return 0, err
@@ -230,6 +231,11 @@ func getUnmergedTableCount(ctx context.Context, root *doltdb.RootValue) (int, er
 	for _, t := range cved {
 		uniqued[t] = struct{}{}
 	}
+	if ws.MergeActive() {
+		for _, t := range ws.MergeState().SchemaConflictTables() {
+			uniqued[t] = struct{}{}
+		}
+	}
 	var unmergedTableCount int
 	for range uniqued {
 		unmergedTableCount++
@@ -348,8 +354,12 @@ func printConflictsAndViolations(tblToStats map[string]*merge.MergeStats) (confl
 	hasConflicts := false
 	hasConstraintViolations := false
 	for tblName, stats := range tblToStats {
-		if stats.Operation == merge.TableModified && (stats.Conflicts > 0 || stats.ConstraintViolations > 0) {
+		if stats.Operation == merge.TableModified && (stats.Conflicts > 0 || stats.ConstraintViolations > 0 || stats.SchemaConflicts > 0) {
 			cli.Println("Auto-merging", tblName)
+			if stats.SchemaConflicts > 0 {
+				cli.Println("CONFLICT (schema): Merge conflict in", tblName)
+				hasConflicts = true
+			}
 			if stats.Conflicts > 0 {
 				cli.Println("CONFLICT (content): Merge conflict in", tblName)
 				hasConflicts = true
@@ -372,7 +382,7 @@ func printModifications(tblToStats map[string]*merge.MergeStats) {
 	rowsChanged := 0
 	var tbls []string
 	for tblName, stats := range tblToStats {
-		if stats.Operation == merge.TableModified && stats.Conflicts == 0 && stats.ConstraintViolations == 0 {
+		if stats.Operation == merge.TableModified && stats.Conflicts == 0 && stats.ConstraintViolations == 0 && stats.SchemaConflicts == 0 {
 			tbls = append(tbls, tblName)
 			nameLen := len(tblName)
 			modCount := stats.Adds + stats.Modifications + stats.Deletes + stats.Conflicts
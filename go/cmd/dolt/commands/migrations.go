@@ -0,0 +1,133 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/migrations"
+	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+)
+
+var migrationsDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Apply versioned schema migrations",
+	LongDesc: `Applies the numbered SQL migrations of a directory to the current branch, and records them in the {{.EmphasisLeft}}dolt_migrations{{.EmphasisRight}} system table along with the checksum of each migration.
+
+Each migration is made of the files {{.LessThan}}version{{.GreaterThan}}_{{.LessThan}}name{{.GreaterThan}}.sql, or {{.LessThan}}version{{.GreaterThan}}_{{.LessThan}}name{{.GreaterThan}}.up.sql, which applies it, and the optional {{.LessThan}}version{{.GreaterThan}}_{{.LessThan}}name{{.GreaterThan}}.down.sql, which reverts it. Migrations are applied in order of version, and every migration is applied or reverted in a dolt commit of its own. The working set must be clean before migrations are applied or reverted.
+
+Since the migrations are recorded per branch, merging a branch also merges the migrations that were applied to it.
+
+{{.EmphasisLeft}}status{{.EmphasisRight}}
+Lists the migrations and whether they are applied, pending, modified since they were applied, or missing from the directory.
+
+{{.EmphasisLeft}}up{{.EmphasisRight}}
+Applies the pending migrations, up to and including the version given with {{.EmphasisLeft}}--to{{.EmphasisRight}}.
+
+{{.EmphasisLeft}}down{{.EmphasisRight}}
+Reverts the latest applied migration, or every applied migration with a version higher than the one given with {{.EmphasisLeft}}--to{{.EmphasisRight}}.
+
+With {{.EmphasisLeft}}--dry-run{{.EmphasisRight}}, the migrations are run without being committed, and the tables whose schema they change are reported.
+
+The same can be done in SQL with {{.EmphasisLeft}}CALL dolt_migrate('up', '--dir', 'migrations'){{.EmphasisRight}}.`,
+	Synopsis: []string{
+		"status [--dir {{.LessThan}}directory{{.GreaterThan}}]",
+		"up [--dir {{.LessThan}}directory{{.GreaterThan}}] [--to {{.LessThan}}version{{.GreaterThan}}] [--dry-run]",
+		"down [--dir {{.LessThan}}directory{{.GreaterThan}}] [--to {{.LessThan}}version{{.GreaterThan}}] [--dry-run]",
+	},
+}
+
+type MigrationsCmd struct{}
+
+// Name returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd MigrationsCmd) Name() string {
+	return "migrations"
+}
+
+// Description returns a description of the command
+func (cmd MigrationsCmd) Description() string {
+	return "Apply versioned schema migrations."
+}
+
+func (cmd MigrationsCmd) Docs() *cli.CommandDocumentation {
+	ap := cli.CreateMigrationsArgParser()
+	return cli.NewCommandDocumentation(migrationsDocs, ap)
+}
+
+func (cmd MigrationsCmd) ArgParser() *argparser.ArgParser {
+	return cli.CreateMigrationsArgParser()
+}
+
+// Exec executes the command
+func (cmd MigrationsCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cli.CreateMigrationsArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.CommandDocsForCommandString(commandStr, migrationsDocs, ap))
+	apr := cli.ParseArgsOrDie(ap, args, help)
+
+	if dEnv.IsLocked() {
+		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(env.ErrActiveServerLock.New(dEnv.LockFile())), help)
+	}
+	if apr.NArg() != 1 {
+		verr := errhand.BuildDError("error: exactly one subcommand must be given: status, up or down").SetPrintUsage().Build()
+		return HandleVErrAndExitCode(verr, usage)
+	}
+	// Applying and reverting migrations creates commits, so we need user identity
+	if apr.Arg(0) != "status" && !apr.Contains(cli.DryRunFlag) && !cli.CheckUserNameAndEmail(dEnv) {
+		return 1
+	}
+
+	return HandleVErrAndExitCode(runMigrations(ctx, dEnv, args), usage)
+}
+
+func runMigrations(ctx context.Context, dEnv *env.DoltEnv, args []string) errhand.VerboseError {
+	se, err := engine.NewSqlEngineForEnv(ctx, dEnv)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+	defer se.Close()
+
+	sqlCtx, err := engine.NewLocalSqlContext(ctx, se)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+	}
+	sch, iter, err := se.Query(sqlCtx, fmt.Sprintf("CALL %s(%s)", migrations.ProcedureName, strings.Join(quoted, ", ")))
+	if err != nil {
+		return errhand.BuildDError("error: failed to run migrations").AddCause(err).Build()
+	}
+	err = engine.PrettyPrintResults(sqlCtx, engine.FormatTabular, sch, iter, false)
+	if err != nil {
+		return errhand.BuildDError("error: failed to run migrations").AddCause(err).Build()
+	}
+
+	// the engine doesn't autocommit, so the working set is only written by committing the transaction
+	_, iter, err = se.Query(sqlCtx, "COMMIT")
+	if err == nil {
+		err = iter.Close(sqlCtx)
+	}
+	if err != nil {
+		return errhand.BuildDError("error: failed to commit the transaction").AddCause(err).Build()
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+)
+
+func TestMigrationsCmd(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateEnvWithSeedData(t)
+	require.Equal(t, 0, CommitCmd{}.Exec(ctx, "commit", []string{"-a", "-m", "seed"}, dEnv))
+
+	dir := "migrations"
+	require.NoError(t, dEnv.FS.MkDirs(dir))
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "1_create_pets.sql"), []byte(`
+-- pets of the people
+CREATE TABLE pets (id INT PRIMARY KEY, owner INT);
+INSERT INTO pets VALUES (1, 0), (2, 1);
+`)))
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "1_create_pets.down.sql"), []byte("DROP TABLE pets;")))
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "2_name_pets.up.sql"), []byte("ALTER TABLE pets ADD COLUMN name VARCHAR(20);")))
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "2_name_pets.down.sql"), []byte("ALTER TABLE pets DROP COLUMN name;")))
+
+	migrate := func(args ...string) int {
+		return MigrationsCmd{}.Exec(ctx, "migrations", append(args, "--dir", dir), dEnv)
+	}
+
+	assert.Equal(t, 0, migrate("status"))
+	// migrations are only read from within the directory of the database
+	assert.Equal(t, 1, MigrationsCmd{}.Exec(ctx, "migrations", []string{"status", "--dir", "../migrations"}, dEnv))
+	assert.Equal(t, 0, migrate("up", "--dry-run"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SELECT * FROM dolt_status"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SELECT * FROM dolt_migrations"))
+
+	assert.Equal(t, 0, migrate("up", "--to", "1"))
+	assert.Equal(t, [][]interface{}{{uint64(1), "create_pets"}}, queryRows(t, ctx, dEnv, "SELECT version, name FROM dolt_migrations"))
+	assert.Equal(t, 0, migrate("up"))
+	assert.Equal(t, [][]interface{}{{uint64(1)}, {uint64(2)}}, queryRows(t, ctx, dEnv, "SELECT version FROM dolt_migrations ORDER BY version"))
+	assert.Equal(t, [][]interface{}{{int64(2)}}, queryRows(t, ctx, dEnv, "SELECT COUNT(*) FROM pets WHERE name IS NULL"))
+	assert.Equal(t, [][]interface{}{{"Apply migration 2: name_pets"}, {"Apply migration 1: create_pets"}},
+		queryRows(t, ctx, dEnv, "SELECT message FROM dolt_log LIMIT 2"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SELECT * FROM dolt_status"))
+
+	// a migration that was changed after it was applied can't be skipped over
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "1_create_pets.sql"), []byte("CREATE TABLE pets (id INT PRIMARY KEY);")))
+	assert.Equal(t, 1, migrate("up"))
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "1_create_pets.sql"), []byte(`
+-- pets of the people
+CREATE TABLE pets (id INT PRIMARY KEY, owner INT);
+INSERT INTO pets VALUES (1, 0), (2, 1);
+`)))
+
+	assert.Equal(t, 0, migrate("down"))
+	assert.Equal(t, [][]interface{}{{uint64(1)}}, queryRows(t, ctx, dEnv, "SELECT version FROM dolt_migrations"))
+	assert.Equal(t, 0, migrate("down", "--to", "0"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SELECT version FROM dolt_migrations"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SHOW TABLES LIKE 'pets'"))
+
+	// a failed migration leaves the working set as it was
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "3_broken.sql"), []byte("CREATE TABLE broken (id INT PRIMARY KEY); INSERT INTO nope VALUES (1);")))
+	assert.Equal(t, 1, migrate("up"))
+	assert.Equal(t, [][]interface{}{{uint64(1)}, {uint64(2)}}, queryRows(t, ctx, dEnv, "SELECT version FROM dolt_migrations ORDER BY version"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SELECT * FROM dolt_status"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SHOW TABLES LIKE 'broken'"))
+	require.NoError(t, dEnv.FS.DeleteFile(filepath.Join(dir, "3_broken.sql")))
+
+	// branches that applied the same migrations merge their dolt_migrations tables without conflicts
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "3_create_toys.sql"), []byte("CREATE TABLE toys (id INT PRIMARY KEY);")))
+	require.Equal(t, 0, BranchCmd{}.Exec(ctx, "branch", []string{"feature"}, dEnv))
+	assert.Equal(t, 0, migrate("up"))
+	require.Equal(t, 0, CheckoutCmd{}.Exec(ctx, "checkout", []string{"feature"}, dEnv))
+	require.NoError(t, dEnv.FS.WriteFile(filepath.Join(dir, "4_create_games.sql"), []byte("CREATE TABLE games (id INT PRIMARY KEY);")))
+	assert.Equal(t, 0, migrate("up"))
+	require.Equal(t, 0, CheckoutCmd{}.Exec(ctx, "checkout", []string{"main"}, dEnv))
+	require.Equal(t, 0, MergeCmd{}.Exec(ctx, "merge", []string{"feature"}, dEnv))
+	assert.Equal(t, [][]interface{}{{uint64(1)}, {uint64(2)}, {uint64(3)}, {uint64(4)}},
+		queryRows(t, ctx, dEnv, "SELECT version FROM dolt_migrations ORDER BY version"))
+	assert.Equal(t, [][]interface{}{}, queryRows(t, ctx, dEnv, "SELECT * FROM dolt_conflicts"))
+}
+
+func queryRows(t *testing.T, ctx context.Context, dEnv *env.DoltEnv, query string) [][]interface{} {
+	se, err := engine.NewSqlEngineForEnv(ctx, dEnv)
+	require.NoError(t, err)
+	defer se.Close()
+	sqlCtx, err := engine.NewLocalSqlContext(ctx, se)
+	require.NoError(t, err)
+
+	sch, iter, err := se.Query(sqlCtx, query)
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(sqlCtx, sch, iter)
+	require.NoError(t, err)
+
+	result := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		result[i] = row
+	}
+	return result
+}
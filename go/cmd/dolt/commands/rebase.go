@@ -0,0 +1,241 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+)
+
+var rebaseDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Reapply commits on top of another base commit",
+	LongDesc: `Replays each commit on the current branch that is not on {{.LessThan}}upstream{{.GreaterThan}} on top of {{.LessThan}}upstream{{.GreaterThan}}, and points the current branch at the last replayed commit. Each commit is replayed with the same three-way merge used by {{.EmphasisLeft}}dolt cherry-pick{{.EmphasisRight}}. The working set must be clean before a rebase is started.
+
+The commits are replayed according to a rebase plan, which lists each commit to be replayed along with the action to take for it:
+
+{{.EmphasisLeft}}pick{{.EmphasisRight}}
+Replay the commit as is.
+
+{{.EmphasisLeft}}reword{{.EmphasisRight}}
+Replay the commit, using the commit message given in the plan.
+
+{{.EmphasisLeft}}squash{{.EmphasisRight}}
+Meld the commit into the previous commit, combining both commit messages.
+
+{{.EmphasisLeft}}fixup{{.EmphasisRight}}
+Meld the commit into the previous commit, keeping only the commit message of the previous commit.
+
+{{.EmphasisLeft}}drop{{.EmphasisRight}}
+Remove the commit from the branch.
+
+By default every commit is picked and the rebase is run straight away. With {{.EmphasisLeft}}--interactive{{.EmphasisRight}}, the rebase stops after the plan is created. The plan can then be edited in the {{.EmphasisLeft}}dolt_rebase{{.EmphasisRight}} system table: steps can be reordered by updating their {{.EmphasisLeft}}rebase_order{{.EmphasisRight}}, removed by deleting them, and their {{.EmphasisLeft}}action{{.EmphasisRight}} and {{.EmphasisLeft}}commit_message{{.EmphasisRight}} can be updated. Run {{.EmphasisLeft}}dolt rebase --continue{{.EmphasisRight}} to execute the plan.
+
+If replaying a commit results in conflicts, the rebase stops with the conflicted result in the working set. Resolve the conflicts and run {{.EmphasisLeft}}dolt rebase --continue{{.EmphasisRight}} to commit the result and resume the rebase, or run {{.EmphasisLeft}}dolt rebase --abort{{.EmphasisRight}} to return the branch to the state it was in before the rebase started.`,
+	Synopsis: []string{
+		"[-i | --interactive] {{.LessThan}}upstream{{.GreaterThan}}",
+		"--continue",
+		"--abort",
+	},
+}
+
+type RebaseCmd struct{}
+
+// Name returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd RebaseCmd) Name() string {
+	return "rebase"
+}
+
+// Description returns a description of the command
+func (cmd RebaseCmd) Description() string {
+	return "Reapply commits on top of another base commit."
+}
+
+func (cmd RebaseCmd) Docs() *cli.CommandDocumentation {
+	ap := cli.CreateRebaseArgParser()
+	return cli.NewCommandDocumentation(rebaseDocs, ap)
+}
+
+func (cmd RebaseCmd) ArgParser() *argparser.ArgParser {
+	return cli.CreateRebaseArgParser()
+}
+
+// Exec executes the command
+func (cmd RebaseCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cli.CreateRebaseArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.CommandDocsForCommandString(commandStr, rebaseDocs, ap))
+	apr := cli.ParseArgsOrDie(ap, args, help)
+
+	if dEnv.IsLocked() {
+		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(env.ErrActiveServerLock.New(dEnv.LockFile())), help)
+	}
+
+	var verr errhand.VerboseError
+	switch {
+	case apr.Contains(cli.AbortParam):
+		if apr.NArg() != 0 || apr.Contains(cli.ContinueFlag) {
+			verr = errhand.BuildDError("error: --abort does not accept other arguments").SetPrintUsage().Build()
+			break
+		}
+		verr = rebaseAbort(ctx, dEnv)
+	case apr.Contains(cli.ContinueFlag):
+		if apr.NArg() != 0 {
+			verr = errhand.BuildDError("error: --continue does not accept other arguments").SetPrintUsage().Build()
+			break
+		}
+		verr = rebaseContinue(ctx, dEnv)
+	default:
+		if apr.NArg() != 1 {
+			verr = errhand.BuildDError("error: exactly one upstream commit must be given").SetPrintUsage().Build()
+			break
+		}
+		verr = rebaseStart(ctx, dEnv, apr.Arg(0), apr.Contains(cli.InteractiveFlag))
+	}
+
+	return HandleVErrAndExitCode(verr, usage)
+}
+
+func rebaseStart(ctx context.Context, dEnv *env.DoltEnv, upstreamStr string, interactive bool) errhand.VerboseError {
+	// This command creates commits, so we need user identity
+	if !cli.CheckUserNameAndEmail(dEnv) {
+		return errhand.BuildDError("").Build()
+	}
+
+	headRef := dEnv.RepoStateReader().CWBHeadRef()
+	upstreamSpec, err := doltdb.NewCommitSpec(upstreamStr)
+	if err != nil {
+		return errhand.BuildDError("error: invalid upstream %s", upstreamStr).AddCause(err).Build()
+	}
+	upstream, err := dEnv.DoltDB.Resolve(ctx, upstreamSpec, headRef)
+	if err != nil {
+		return errhand.BuildDError("error: could not resolve %s", upstreamStr).AddCause(err).Build()
+	}
+	headCommit, err := dEnv.HeadCommit(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	ws, err = merge.StartRebase(ctx, dEnv.DoltDB, ws, headCommit, upstream, headRef.GetPath(), interactive)
+	if err == doltdb.ErrUpToDate {
+		cli.Printf("Current branch %s is up to date.\n", headRef.GetPath())
+		return nil
+	} else if err == doltdb.ErrMergeActive {
+		return errhand.BuildDError("error: cannot rebase while a merge is in progress").Build()
+	} else if err == doltdb.ErrRebaseActive {
+		return errhand.BuildDError("error: a rebase is already in progress; use --continue or --abort").Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to start the rebase").AddCause(err).Build()
+	}
+
+	if !interactive {
+		return continueRebase(ctx, dEnv, ws)
+	}
+
+	err = dEnv.UpdateWorkingSet(ctx, ws)
+	if err != nil {
+		return errhand.BuildDError("error: failed to update the working set").AddCause(err).Build()
+	}
+
+	for i, step := range ws.RebaseState().Plan() {
+		cli.Printf("%d\t%s %s %s\n", i+1, step.Action, step.CommitHash, step.CommitMsg)
+	}
+	cli.Println()
+	cli.Println("Edit the rebase plan in the dolt_rebase system table, then run 'dolt rebase --continue' to start the rebase.")
+	cli.Println("To cancel the rebase, run 'dolt rebase --abort'.")
+	return nil
+}
+
+func rebaseContinue(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+	if !ws.RebaseActive() {
+		return errhand.BuildDError("error: no rebase in progress").Build()
+	}
+	return continueRebase(ctx, dEnv, ws)
+}
+
+func continueRebase(ctx context.Context, dEnv *env.DoltEnv, ws *doltdb.WorkingSet) errhand.VerboseError {
+	branch := ws.RebaseState().Branch()
+
+	opts := editor.Options{Deaf: dEnv.DbEaFactory(), Tempdir: dEnv.TempTableFilesDir()}
+	ws, newHead, err := merge.ContinueRebase(ctx, dEnv.DoltDB, ws, opts)
+	if err == merge.ErrRebaseStoppedOnConflicts {
+		if err := dEnv.UpdateWorkingSet(ctx, ws); err != nil {
+			return errhand.BuildDError("error: failed to update the working set").AddCause(err).Build()
+		}
+
+		var unmerged []string
+		tblNames, err := ws.WorkingRoot().TablesInConflict(ctx)
+		if err != nil {
+			return errhand.VerboseErrorFromError(err)
+		}
+		unmerged = append(unmerged, tblNames...)
+		tblNames, err = ws.WorkingRoot().TablesWithConstraintViolations(ctx)
+		if err != nil {
+			return errhand.VerboseErrorFromError(err)
+		}
+		unmerged = append(unmerged, tblNames...)
+		sort.Strings(unmerged)
+		for _, tblName := range unmerged {
+			cli.Println("CONFLICT (content): Merge conflict in", tblName)
+		}
+
+		return errhand.BuildDError("error: %s", merge.ErrRebaseStoppedOnConflicts.Error()).Build()
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to rebase").AddCause(err).Build()
+	}
+
+	err = dEnv.DoltDB.SetHeadToCommit(ctx, ref.NewBranchRef(branch), newHead)
+	if err != nil {
+		return errhand.BuildDError("error: failed to update branch %s", branch).AddCause(err).Build()
+	}
+	err = dEnv.UpdateWorkingSet(ctx, ws)
+	if err != nil {
+		return errhand.BuildDError("error: failed to update the working set").AddCause(err).Build()
+	}
+
+	cli.Printf("Successfully rebased and updated refs/heads/%s.\n", branch)
+	return nil
+}
+
+func rebaseAbort(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+	if !ws.RebaseActive() {
+		return errhand.BuildDError("error: no rebase in progress").Build()
+	}
+
+	err = dEnv.UpdateWorkingSet(ctx, ws.AbortRebase())
+	if err != nil {
+		return errhand.BuildDError("error: failed to abort the rebase").AddCause(err).Build()
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+	"github.com/dolthub/dolt/go/store/datas"
+)
+
+var reflogDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Show the history of the refs of a database",
+	LongDesc: `Shows every movement of the branches, tags and remote branches of the database, most recent first, including the ones made by {{.EmphasisLeft}}dolt reset --hard{{.EmphasisRight}}, {{.EmphasisLeft}}dolt push -f{{.EmphasisRight}} and branch deletions. Each entry lists the commit the ref pointed to after it moved, and the one it pointed to before, so that commits which are no longer reachable from any ref can be found and restored with {{.EmphasisLeft}}dolt branch{{.EmphasisRight}} or {{.EmphasisLeft}}dolt reset{{.EmphasisRight}}.
+
+If {{.LessThan}}ref{{.GreaterThan}} is given, only the movements of that ref are shown. It needs not exist anymore. With {{.EmphasisLeft}}--all{{.EmphasisRight}}, the movements of the working sets are shown as well.
+
+{{.EmphasisLeft}}dolt gc{{.EmphasisRight}} keeps the commits of the entries recorded in the last 90 days. Since every transaction moves a working set, only the last 100 movements of each working set within the last day are kept, along with the values they pointed to.
+
+The same entries can be queried in SQL with the {{.EmphasisLeft}}dolt_reflog(){{.EmphasisRight}} table function.`,
+	Synopsis: []string{
+		"[--all] [{{.LessThan}}ref{{.GreaterThan}}]",
+	},
+}
+
+type ReflogCmd struct{}
+
+// Name returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd ReflogCmd) Name() string {
+	return "reflog"
+}
+
+// Description returns a description of the command
+func (cmd ReflogCmd) Description() string {
+	return "Show the history of the refs of a database."
+}
+
+func (cmd ReflogCmd) Docs() *cli.CommandDocumentation {
+	ap := cli.CreateReflogArgParser()
+	return cli.NewCommandDocumentation(reflogDocs, ap)
+}
+
+func (cmd ReflogCmd) ArgParser() *argparser.ArgParser {
+	return cli.CreateReflogArgParser()
+}
+
+// Exec executes the command
+func (cmd ReflogCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cli.CreateReflogArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.CommandDocsForCommandString(commandStr, reflogDocs, ap))
+	apr := cli.ParseArgsOrDie(ap, args, help)
+
+	if apr.NArg() > 1 {
+		verr := errhand.BuildDError("error: at most one ref may be given").SetPrintUsage().Build()
+		return HandleVErrAndExitCode(verr, usage)
+	}
+
+	refName := ""
+	if apr.NArg() == 1 {
+		refName = apr.Arg(0)
+	}
+
+	entries, err := dEnv.DoltDB.Reflog(ctx, refName, apr.Contains(cli.AllFlag))
+	if err != nil {
+		return HandleVErrAndExitCode(errhand.BuildDError("error: failed to read the reflog").AddCause(err).Build(), usage)
+	}
+
+	for _, entry := range entries {
+		newHash := "(deleted)"
+		if !entry.NewHash.IsEmpty() {
+			newHash = entry.NewHash.String()
+		}
+		line := fmt.Sprintf("\033[33m%s\033[0m %s: %s", newHash, entry.Ref, entry.Action)
+		if !entry.OldHash.IsEmpty() {
+			line += fmt.Sprintf(" (from %s)", entry.OldHash.String())
+		}
+		cli.Println(line + " " + entry.Timestamp.In(datas.CommitLoc).Format(time.RubyDate))
+	}
+
+	return 0
+}
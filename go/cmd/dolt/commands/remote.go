@@ -37,7 +37,7 @@ var remoteDocs = cli.CommandDocumentationContent{
 {{.EmphasisLeft}}add{{.EmphasisRight}}
 Adds a remote named {{.LessThan}}name{{.GreaterThan}} for the repository at {{.LessThan}}url{{.GreaterThan}}. The command dolt fetch {{.LessThan}}name{{.GreaterThan}} can then be used to create and update remote-tracking branches {{.EmphasisLeft}}<name>/<branch>{{.EmphasisRight}}.
 
-The {{.LessThan}}url{{.GreaterThan}} parameter supports url schemes of http, https, aws, gs, and file. The url prefix defaults to https. If the {{.LessThan}}url{{.GreaterThan}} parameter is in the format {{.EmphasisLeft}}<organization>/<repository>{{.EmphasisRight}} then dolt will use the {{.EmphasisLeft}}remotes.default_host{{.EmphasisRight}} from your configuration file (Which will be dolthub.com unless changed).
+The {{.LessThan}}url{{.GreaterThan}} parameter supports url schemes of http, https, aws, s3, gs, and file. The url prefix defaults to https. If the {{.LessThan}}url{{.GreaterThan}} parameter is in the format {{.EmphasisLeft}}<organization>/<repository>{{.EmphasisRight}} then dolt will use the {{.EmphasisLeft}}remotes.default_host{{.EmphasisRight}} from your configuration file (Which will be dolthub.com unless changed).
 
 AWS cloud remote urls should be of the form {{.EmphasisLeft}}aws://[dynamo-table:s3-bucket]/database{{.EmphasisRight}}.  You may configure your aws cloud remote using the optional parameters {{.EmphasisLeft}}aws-region{{.EmphasisRight}}, {{.EmphasisLeft}}aws-creds-type{{.EmphasisRight}}, {{.EmphasisLeft}}aws-creds-file{{.EmphasisRight}}.
 
@@ -47,6 +47,8 @@ aws-creds-type specifies the means by which credentials should be retrieved in o
 	env: Looks for environment variables AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
 	file: Uses the credentials file specified by the parameter aws-creds-file
 	
+S3 remote urls should be of the form {{.EmphasisLeft}}s3://s3-bucket/database{{.EmphasisRight}}. Unlike aws remotes, they don't need a dynamo table, so they can be used with S3 compatible object stores such as MinIO and Ceph. The object store must support conditional writes, and remotes on object stores which ignore the conditions of writes fail to open. They use the same credential parameters as aws remotes, and the endpoint of the object store is set with the parameter {{.EmphasisLeft}}s3-endpoint{{.EmphasisRight}}. Buckets of an object store with an endpoint are addressed in the path of requests unless {{.EmphasisLeft}}s3-path-style{{.EmphasisRight}} is false.
+
 GCP remote urls should be of the form gs://gcs-bucket/database and will use the credentials setup using the gcloud command line available from Google.
 
 The local filesystem can be used as a remote by providing a repository url in the format file://absolute path. See https://en.wikipedia.org/wiki/File_URI_scheme
@@ -94,6 +96,8 @@ func (cmd RemoteCmd) ArgParser() *argparser.ArgParser {
 	ap.SupportsValidatedString(dbfactory.AWSCredsTypeParam, "", "creds-type", "", argparser.ValidatorFromStrList(dbfactory.AWSCredsTypeParam, dbfactory.AWSCredTypes))
 	ap.SupportsString(dbfactory.AWSCredsFileParam, "", "file", "AWS credentials file")
 	ap.SupportsString(dbfactory.AWSCredsProfile, "", "profile", "AWS profile to use")
+	ap.SupportsString(dbfactory.S3EndpointParam, "", "url", "Endpoint of the S3 compatible object store of an s3 remote.")
+	ap.SupportsString(dbfactory.S3PathStyleParam, "", "true|false", "Whether the bucket of an s3 remote is addressed in the path of requests instead of the host name. Defaults to true when s3-endpoint is set.")
 	return ap
 }
 
@@ -191,11 +195,14 @@ func parseRemoteArgs(apr *argparser.ArgParseResults, scheme, remoteUrl string) (
 	params := map[string]string{}
 
 	var err error
-	if scheme == dbfactory.AWSScheme {
+	if scheme == dbfactory.AWSScheme || scheme == dbfactory.S3Scheme {
 		err = cli.AddAWSParams(remoteUrl, apr, params)
 	} else {
 		err = cli.VerifyNoAwsParams(apr)
 	}
+	if err == nil {
+		err = cli.AddUserParam(scheme, apr, params)
+	}
 
 	if err != nil {
 		return nil, errhand.VerboseErrorFromError(err)
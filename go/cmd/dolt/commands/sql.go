@@ -98,6 +98,8 @@ const (
 	DefaultCfgDirName = ".doltcfg"
 	PrivsFilePathFlag = "privilege-file"
 	DefaultPrivsName  = "privileges.db"
+	BranchCtrlFlag    = "branch-control-file"
+	DefaultBranchCtrl = "branch_control.db"
 	continueFlag      = "continue"
 	fileInputFlag     = "file"
 	UserFlag          = "user"
@@ -157,6 +159,7 @@ func (cmd SqlCmd) ArgParser() *argparser.ArgParser {
 	ap.SupportsFlag(continueFlag, "c", "Continue running queries on an error. Used for batch mode only.")
 	ap.SupportsString(fileInputFlag, "", "input file", "Execute statements from the file given.")
 	ap.SupportsString(PrivsFilePathFlag, "", "privilege file", "Path to a file to load and store users and grants. Defaults to `$doltcfg-dir/privileges.db`. Will only be created if there is a change to privileges.")
+	ap.SupportsString(BranchCtrlFlag, "", "branch control file", "Path to a file to load and store branch control permissions. Defaults to `$doltcfg-dir/branch_control.db`. Will only be created if there is a change to branch control permissions.")
 	ap.SupportsString(UserFlag, "u", "user", fmt.Sprintf("Defines the local superuser (defaults to `%v`). If the specified user exists, will take on permissions of that user.", DefaultUser))
 	return ap
 }
@@ -247,6 +250,15 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 		}
 	}
 
+	// If no branch control file path is specified, default to doltcfg directory
+	branchControlFilePath, hasBCFilePath := apr.GetValue(BranchCtrlFlag)
+	if !hasBCFilePath {
+		branchControlFilePath, err = dEnv.FS.Abs(filepath.Join(cfgDirPath, DefaultBranchCtrl))
+		if err != nil {
+			return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
+		}
+	}
+
 	initialRoots, err := mrEnv.GetWorkingRoots(ctx)
 	if err != nil {
 		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(err), usage)
@@ -269,13 +281,14 @@ func (cmd SqlCmd) Exec(ctx context.Context, commandStr string, args []string, dE
 	}
 
 	config := &engine.SqlEngineConfig{
-		InitialDb:      currentDb,
-		IsReadOnly:     false,
-		DoltCfgDirPath: cfgDirPath,
-		PrivFilePath:   privsFp,
-		ServerUser:     username,
-		ServerHost:     DefaultHost,
-		Autocommit:     true,
+		InitialDb:          currentDb,
+		IsReadOnly:         false,
+		DoltCfgDirPath:     cfgDirPath,
+		PrivFilePath:       privsFp,
+		BranchCtrlFilePath: branchControlFilePath,
+		ServerUser:         username,
+		ServerHost:         DefaultHost,
+		Autocommit:         true,
 	}
 
 	if query, queryOK := apr.GetValue(QueryFlag); queryOK {
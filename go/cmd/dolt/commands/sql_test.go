@@ -570,7 +570,7 @@ func TestCommitHooksNoErrors(t *testing.T) {
 	sqle.AddDoltSystemVariables()
 	sql.SystemVariables.SetGlobal(dsess.SkipReplicationErrorsKey, true)
 	sql.SystemVariables.SetGlobal(dsess.ReplicateToRemoteKey, "unknown")
-	hooks, err := engine.GetCommitHooks(context.Background(), dEnv)
+	hooks, err := engine.GetCommitHooks(context.Background(), "dolt", dEnv)
 	assert.NoError(t, err)
 	if len(hooks) < 1 {
 		t.Error("failed to produce noop hook")
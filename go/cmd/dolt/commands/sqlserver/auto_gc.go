@@ -0,0 +1,134 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	dsqle "github.com/dolthub/dolt/go/libraries/doltcore/sqle"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dprocedures"
+	"github.com/dolthub/dolt/go/store/chunks"
+)
+
+const autoGCThreadName = "auto_gc"
+
+// startAutoGC starts a background thread of |se| that periodically collects the garbage of every database whose
+// table files written since its last collection have crossed the thresholds of |config|.
+func startAutoGC(se *engine.SqlEngine, config AutoGCConfig) error {
+	gms := se.GetUnderlyingEngine()
+	gc := &autoGC{se: se, config: config, baselines: make(map[string]newGenStats)}
+	return gms.BackgroundThreads.Add(autoGCThreadName, func(ctx context.Context) {
+		ticker := time.NewTicker(time.Duration(config.CheckIntervalMillis) * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := gc.run(ctx)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					logrus.Errorf("auto_gc: %s", err.Error())
+				}
+			}
+		}
+	})
+}
+
+// newGenStats are the number and total size of the table files in the new generation of a database.
+type newGenStats struct {
+	count int
+	size  uint64
+}
+
+// autoGC collects the garbage of the databases of a server on its own. A collection leaves some table files in the
+// new generation, such as those of uncommitted working sets and of the reflog, so the thresholds apply to the table
+// files written since then: the stats of each database right after its last collection are its baseline.
+type autoGC struct {
+	se        *engine.SqlEngine
+	config    AutoGCConfig
+	baselines map[string]newGenStats
+}
+
+// run checks every database against the thresholds of the config, and collects the garbage of those that have
+// crossed one.
+func (gc *autoGC) run(ctx context.Context) error {
+	sqlCtx, err := gc.se.NewContext(ctx)
+	if err != nil {
+		return err
+	}
+	// dolt_gc() waits for the queries running on the server to finish, so it needs to see them
+	sqlCtx.ProcessList = gc.se.GetUnderlyingEngine().ProcessList
+
+	for _, db := range gc.se.GetUnderlyingEngine().Analyzer.Catalog.AllDatabases(sqlCtx) {
+		sqlDb, ok := db.(dsqle.SqlDatabase)
+		if !ok {
+			continue
+		}
+
+		current, err := readNewGenStats(ctx, sqlDb)
+		if errors.Is(err, chunks.ErrUnsupportedOperation) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		baseline := gc.baselines[sqlDb.Name()]
+		if current.count < baseline.count || current.size < baseline.size {
+			// the database was collected by someone else, such as a call to dolt_gc()
+			gc.baselines[sqlDb.Name()] = current
+			continue
+		}
+
+		count, size := current.count-baseline.count, current.size-baseline.size
+		if !autoGCThresholdCrossed(gc.config, count, size) {
+			continue
+		}
+
+		logrus.Infof("auto_gc: database %s has %d new table files holding %d bytes", sqlDb.Name(), count, size)
+		sqlCtx.SetCurrentDatabase(sqlDb.Name())
+		_, err = dprocedures.DoDoltGC(sqlCtx, nil)
+		if err != nil {
+			return err
+		}
+
+		gc.baselines[sqlDb.Name()], err = readNewGenStats(ctx, sqlDb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readNewGenStats(ctx context.Context, sqlDb dsqle.SqlDatabase) (newGenStats, error) {
+	count, size, err := sqlDb.DbData().Ddb.NewGenTableFileStats(ctx)
+	if err != nil {
+		return newGenStats{}, err
+	}
+	return newGenStats{count: count, size: size}, nil
+}
+
+func autoGCThresholdCrossed(config AutoGCConfig, tableFiles int, size uint64) bool {
+	if config.TableFileThreshold > 0 && tableFiles > config.TableFileThreshold {
+		return true
+	}
+	return config.NewDataThresholdMB > 0 && size > config.NewDataThresholdMB*1024*1024
+}
@@ -0,0 +1,322 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/doltcore/remotestorage"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	dsqle "github.com/dolthub/dolt/go/libraries/doltcore/sqle"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/nbs"
+)
+
+const metricsCollectTimeout = 10 * time.Second
+
+var _ prometheus.Collector = (*metricsCollector)(nil)
+var _ dsess.TransactionStatsRecorder = (*metricsCollector)(nil)
+var _ doltdb.ReplicationStatsRecorder = (*metricsCollector)(nil)
+var _ remotestorage.RequestStatsRecorder = (*metricsCollector)(nil)
+
+// metricsCollector exports the storage, transaction and replication metrics of the databases of a server. Transaction
+// commits, replication pushes and remote requests are recorded as they happen, while the statistics of the chunk
+// stores and the sizes of the working sets are read from the databases when the metrics are collected.
+type metricsCollector struct {
+	se *engine.SqlEngine
+
+	cntCommitRetries     *prometheus.CounterVec
+	cntCommitMerges      *prometheus.CounterVec
+	cntCommitConflicts   *prometheus.CounterVec
+	cntPushes            *prometheus.CounterVec
+	cntPushFailures      *prometheus.CounterVec
+	gaugeReplicationLag  *prometheus.GaugeVec
+	histRemoteRequestDur *prometheus.HistogramVec
+	cntRemoteReqFailures *prometheus.CounterVec
+
+	descChunksRead       *prometheus.Desc
+	descTableFileOpens   *prometheus.Desc
+	descConjoins         *prometheus.Desc
+	descCommits          *prometheus.Desc
+	descBytesRead        *prometheus.Desc
+	descBytesWritten     *prometheus.Desc
+	descChunkCacheHits   *prometheus.Desc
+	descStoreSize        *prometheus.Desc
+	descWorkingSetRows   *prometheus.Desc
+	descWorkingSetTables *prometheus.Desc
+}
+
+func newMetricsCollector(se *engine.SqlEngine, labels prometheus.Labels) *metricsCollector {
+	dbLabels := []string{"database"}
+	branchLabels := []string{"database", "branch"}
+	return &metricsCollector{
+		se: se,
+		cntCommitRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dss_transaction_commit_retries",
+			Help:        "Count of transaction commits retried after losing the race to update the working set",
+			ConstLabels: labels,
+		}, branchLabels),
+		cntCommitMerges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dss_transaction_commit_merges",
+			Help:        "Count of transaction commits merged with the working set of another transaction",
+			ConstLabels: labels,
+		}, branchLabels),
+		cntCommitConflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dss_transaction_merge_conflicts",
+			Help:        "Count of transaction commits rolled back because of merge conflicts",
+			ConstLabels: labels,
+		}, branchLabels),
+		cntPushes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dss_replication_pushes",
+			Help:        "Count of successful pushes to replicas",
+			ConstLabels: labels,
+		}, branchLabels),
+		cntPushFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dss_replication_push_failures",
+			Help:        "Count of failed pushes to replicas",
+			ConstLabels: labels,
+		}, branchLabels),
+		gaugeReplicationLag: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:        "dss_replication_lag_seconds",
+			Help:        "Time from the first write replicated by the last successful push to a replica to the end of the push",
+			ConstLabels: labels,
+		}, branchLabels),
+		histRemoteRequestDur: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:        "dss_remote_request_duration",
+			Help:        "Histogram of the latencies of the requests to remote chunk stores",
+			ConstLabels: labels,
+			Buckets:     []float64{0.001, 0.01, 0.1, 1.0, 10.0, 100.0}, // 1 ms to 1 min 40 secs
+		}, []string{"database", "method"}),
+		cntRemoteReqFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "dss_remote_request_failures",
+			Help:        "Count of failed requests to remote chunk stores",
+			ConstLabels: labels,
+		}, []string{"database", "method"}),
+
+		descChunksRead: prometheus.NewDesc("dss_storage_chunks_read",
+			"Count of chunks read from the chunk store of a database", dbLabels, labels),
+		descTableFileOpens: prometheus.NewDesc("dss_storage_table_file_opens",
+			"Count of table files opened by the chunk store of a database", dbLabels, labels),
+		descConjoins: prometheus.NewDesc("dss_storage_conjoins",
+			"Count of conjoins of the table files of a database", dbLabels, labels),
+		descCommits: prometheus.NewDesc("dss_storage_commits",
+			"Count of root updates of the chunk store of a database", dbLabels, labels),
+		descBytesRead: prometheus.NewDesc("dss_storage_bytes_read",
+			"Bytes read from the table files of a database", dbLabels, labels),
+		descBytesWritten: prometheus.NewDesc("dss_storage_bytes_written",
+			"Bytes of chunk data written to the table files of a database", dbLabels, labels),
+		descChunkCacheHits: prometheus.NewDesc("dss_storage_chunk_cache_hits",
+			"Count of chunks of a remote database read from its chunk cache", dbLabels, labels),
+		descStoreSize: prometheus.NewDesc("dss_storage_size_bytes",
+			"Total size of the table files of a database", dbLabels, labels),
+		descWorkingSetRows: prometheus.NewDesc("dss_working_set_rows",
+			"Number of rows in the tables of the working set of a branch", branchLabels, labels),
+		descWorkingSetTables: prometheus.NewDesc("dss_working_set_tables",
+			"Number of tables in the working set of a branch", branchLabels, labels),
+	}
+}
+
+func (mc *metricsCollector) vecs() []prometheus.Collector {
+	return []prometheus.Collector{
+		mc.cntCommitRetries,
+		mc.cntCommitMerges,
+		mc.cntCommitConflicts,
+		mc.cntPushes,
+		mc.cntPushFailures,
+		mc.gaugeReplicationLag,
+		mc.histRemoteRequestDur,
+		mc.cntRemoteReqFailures,
+	}
+}
+
+// Start registers the metrics of |mc| and starts recording the events of the databases.
+func (mc *metricsCollector) Start() {
+	prometheus.MustRegister(mc)
+	dsess.TransactionStats = mc
+	doltdb.ReplicationStats = mc
+	remotestorage.RequestStats = mc
+}
+
+// Close stops recording and unregisters the metrics of |mc|.
+func (mc *metricsCollector) Close() {
+	dsess.TransactionStats = dsess.NullTransactionStatsRecorder{}
+	doltdb.ReplicationStats = doltdb.NullReplicationStatsRecorder{}
+	remotestorage.RequestStats = remotestorage.NullRequestStatsRecorder{}
+	prometheus.Unregister(mc)
+}
+
+// Describe implements prometheus.Collector
+func (mc *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, v := range mc.vecs() {
+		v.Describe(ch)
+	}
+	ch <- mc.descChunksRead
+	ch <- mc.descTableFileOpens
+	ch <- mc.descConjoins
+	ch <- mc.descCommits
+	ch <- mc.descBytesRead
+	ch <- mc.descBytesWritten
+	ch <- mc.descChunkCacheHits
+	ch <- mc.descStoreSize
+	ch <- mc.descWorkingSetRows
+	ch <- mc.descWorkingSetTables
+}
+
+// Collect implements prometheus.Collector
+func (mc *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, v := range mc.vecs() {
+		v.Collect(ch)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsCollectTimeout)
+	defer cancel()
+	err := mc.collectDatabases(ctx, ch)
+	if err != nil {
+		logrus.Warnf("metrics: failed to collect database metrics: %s", err.Error())
+	}
+}
+
+// collectDatabases sends the storage and working set metrics of every database of the server to |ch|.
+func (mc *metricsCollector) collectDatabases(ctx context.Context, ch chan<- prometheus.Metric) error {
+	sqlCtx, err := mc.se.NewContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, db := range mc.se.GetUnderlyingEngine().Analyzer.Catalog.AllDatabases(sqlCtx) {
+		sqlDb, ok := db.(dsqle.SqlDatabase)
+		if !ok {
+			continue
+		}
+
+		ddb := sqlDb.DbData().Ddb
+		mc.collectStoreStats(ch, sqlDb.Name(), ddb.StoreStats())
+
+		size, err := ddb.StoreSize(ctx)
+		if err == nil {
+			ch <- prometheus.MustNewConstMetric(mc.descStoreSize, prometheus.GaugeValue, float64(size), sqlDb.Name())
+		} else if !errors.Is(err, chunks.ErrUnsupportedOperation) {
+			return err
+		}
+
+		err = mc.collectWorkingSets(ctx, ch, sqlDb.Name(), ddb)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (mc *metricsCollector) collectStoreStats(ch chan<- prometheus.Metric, dbName string, stats interface{}) {
+	counter := func(desc *prometheus.Desc, v uint64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(v), dbName)
+	}
+
+	switch s := stats.(type) {
+	case nbs.Stats:
+		counter(mc.descChunksRead, s.ChunksPerGet.Sum())
+		counter(mc.descTableFileOpens, s.IndexReadLatency.Samples())
+		counter(mc.descConjoins, s.ConjoinLatency.Samples())
+		counter(mc.descCommits, s.CommitLatency.Samples())
+		counter(mc.descBytesRead, s.FileBytesPerRead.Sum()+s.S3BytesPerRead.Sum()+s.DynamoBytesPerRead.Sum())
+		counter(mc.descBytesWritten, s.CompressedChunkBytesPerPersist.Sum())
+	case remotestorage.CacheStats:
+		counter(mc.descChunkCacheHits, uint64(s.CacheHits()))
+	}
+}
+
+// collectWorkingSets sends the number of tables and rows in the working set of every branch of |ddb| to |ch|.
+func (mc *metricsCollector) collectWorkingSets(ctx context.Context, ch chan<- prometheus.Metric, dbName string, ddb *doltdb.DoltDB) error {
+	branches, err := ddb.GetBranches(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, branch := range branches {
+		wsRef, err := ref.WorkingSetRefForHead(branch)
+		if err != nil {
+			return err
+		}
+		ws, err := ddb.ResolveWorkingSet(ctx, wsRef)
+		if err == doltdb.ErrWorkingSetNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		var tables, rows uint64
+		err = ws.WorkingRoot().IterTables(ctx, func(name string, table *doltdb.Table, sch schema.Schema) (stop bool, err error) {
+			rowData, err := table.GetRowData(ctx)
+			if err != nil {
+				return true, err
+			}
+			tables++
+			rows += rowData.Count()
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		ch <- prometheus.MustNewConstMetric(mc.descWorkingSetTables, prometheus.GaugeValue, float64(tables), dbName, branch.GetPath())
+		ch <- prometheus.MustNewConstMetric(mc.descWorkingSetRows, prometheus.GaugeValue, float64(rows), dbName, branch.GetPath())
+	}
+
+	return nil
+}
+
+// RecordCommitRetry implements dsess.TransactionStatsRecorder
+func (mc *metricsCollector) RecordCommitRetry(dbName, branch string) {
+	mc.cntCommitRetries.WithLabelValues(dbName, branch).Inc()
+}
+
+// RecordCommitMerge implements dsess.TransactionStatsRecorder
+func (mc *metricsCollector) RecordCommitMerge(dbName, branch string) {
+	mc.cntCommitMerges.WithLabelValues(dbName, branch).Inc()
+}
+
+// RecordCommitConflict implements dsess.TransactionStatsRecorder
+func (mc *metricsCollector) RecordCommitConflict(dbName, branch string) {
+	mc.cntCommitConflicts.WithLabelValues(dbName, branch).Inc()
+}
+
+// RecordPush implements doltdb.ReplicationStatsRecorder
+func (mc *metricsCollector) RecordPush(dbName, branch string, lag time.Duration) {
+	mc.cntPushes.WithLabelValues(dbName, branch).Inc()
+	mc.gaugeReplicationLag.WithLabelValues(dbName, branch).Set(lag.Seconds())
+}
+
+// RecordPushFailure implements doltdb.ReplicationStatsRecorder
+func (mc *metricsCollector) RecordPushFailure(dbName, branch string) {
+	mc.cntPushFailures.WithLabelValues(dbName, branch).Inc()
+}
+
+// RecordRequest implements remotestorage.RequestStatsRecorder
+func (mc *metricsCollector) RecordRequest(repo, method string, d time.Duration, err error) {
+	mc.histRemoteRequestDur.WithLabelValues(repo, method).Observe(d.Seconds())
+	if err != nil {
+		mc.cntRemoteReqFailures.WithLabelValues(repo, method).Inc()
+	}
+}
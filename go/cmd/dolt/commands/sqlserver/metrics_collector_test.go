@@ -0,0 +1,112 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/dtestutils"
+	"github.com/dolthub/dolt/go/store/nbs"
+)
+
+func TestMetricsCollector(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateEnvWithSeedData(t)
+	se, err := engine.NewSqlEngineForEnv(ctx, dEnv)
+	require.NoError(t, err)
+	defer se.Close()
+
+	mc := newMetricsCollector(se, prometheus.Labels{"server": "test"})
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(mc))
+
+	mc.RecordCommitRetry("db", "main")
+	mc.RecordCommitRetry("db", "main")
+	mc.RecordCommitConflict("db", "feature")
+	mc.RecordPush("db", "main", 2*time.Second)
+	mc.RecordPushFailure("db", "main")
+	mc.RecordRequest("org/db", "GetRepoMetadata", time.Millisecond, nil)
+	mc.RecordRequest("org/db", "GetRepoMetadata", time.Millisecond, errors.New("unavailable"))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(mc.cntCommitRetries.WithLabelValues("db", "main")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(mc.cntCommitConflicts.WithLabelValues("db", "feature")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(mc.gaugeReplicationLag.WithLabelValues("db", "main")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(mc.cntPushFailures.WithLabelValues("db", "main")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(mc.cntRemoteReqFailures.WithLabelValues("org/db", "GetRepoMetadata")))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	byName := make(map[string]*dto.MetricFamily)
+	for _, mf := range families {
+		byName[mf.GetName()] = mf
+	}
+
+	// the working set metrics are read from the databases of the engine
+	require.Contains(t, byName, "dss_working_set_rows")
+	require.Contains(t, byName, "dss_working_set_tables")
+	rows := byName["dss_working_set_rows"].GetMetric()
+	require.Len(t, rows, 1)
+	assert.Equal(t, "main", labelValue(rows[0], "branch"))
+	assert.Equal(t, "test", labelValue(rows[0], "server"))
+	assert.Equal(t, 3.0, rows[0].GetGauge().GetValue())
+	assert.Equal(t, 1.0, byName["dss_working_set_tables"].GetMetric()[0].GetGauge().GetValue())
+}
+
+func TestMetricsCollectorStoreStats(t *testing.T) {
+	mc := newMetricsCollector(nil, nil)
+	stats := nbs.NewStats()
+	stats.ChunksPerGet.Sample(4)
+	stats.ChunksPerGet.Sample(6)
+	stats.IndexReadLatency.Sample(uint64(time.Millisecond))
+	stats.FileBytesPerRead.Sample(1024)
+	stats.CompressedChunkBytesPerPersist.Sample(2048)
+
+	ch := make(chan prometheus.Metric, 16)
+	mc.collectStoreStats(ch, "db", stats.Clone())
+	close(ch)
+
+	values := make(map[*prometheus.Desc]float64)
+	for m := range ch {
+		var out dto.Metric
+		require.NoError(t, m.Write(&out))
+		assert.Equal(t, "db", labelValue(&out, "database"))
+		values[m.Desc()] = out.GetCounter().GetValue()
+	}
+	assert.Equal(t, 10.0, values[mc.descChunksRead])
+	assert.Equal(t, 1.0, values[mc.descTableFileOpens])
+	assert.Equal(t, 0.0, values[mc.descConjoins])
+	assert.Equal(t, 1024.0, values[mc.descBytesRead])
+	assert.Equal(t, 2048.0, values[mc.descBytesWritten])
+	assert.NotContains(t, values, mc.descChunkCacheHits)
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, lp := range m.GetLabel() {
+		if lp.GetName() == name {
+			return lp.GetValue()
+		}
+	}
+	return ""
+}
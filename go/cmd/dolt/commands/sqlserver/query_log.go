@@ -0,0 +1,337 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/go-mysql-server/server"
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+)
+
+const (
+	queryStatusOK    = "ok"
+	queryStatusError = "error"
+
+	// redactedQuery replaces queries whose literals should be redacted, but which can't be parsed.
+	redactedQuery = "<redacted>"
+)
+
+// queryRecord is the record of a query written to the server and slow query logs.
+type queryRecord struct {
+	Time         time.Time `json:"time"`
+	ConnectionID uint32    `json:"connection_id"`
+	User         string    `json:"user"`
+	ClientAddr   string    `json:"client_address"`
+	Database     string    `json:"database"`
+	Branch       string    `json:"branch"`
+	Query        string    `json:"query"`
+	DurationMs   float64   `json:"duration_ms"`
+	RowsReturned int64     `json:"rows_returned"`
+	Status       string    `json:"status"`
+	Error        string    `json:"error,omitempty"`
+}
+
+func (r queryRecord) fields() logrus.Fields {
+	fields := logrus.Fields{
+		"connection_id":  r.ConnectionID,
+		"user":           r.User,
+		"client_address": r.ClientAddr,
+		"database":       r.Database,
+		"branch":         r.Branch,
+		"query":          r.Query,
+		"duration_ms":    r.DurationMs,
+		"rows_returned":  r.RowsReturned,
+		"status":         r.Status,
+	}
+	if r.Error != "" {
+		fields["error"] = r.Error
+	}
+	return fields
+}
+
+// queryLog writes a record of the queries run by the sessions of the server to the server log when it's written as
+// JSON, and of the slow queries to the slow query log.
+type queryLog struct {
+	lgr        *logrus.Logger
+	logRecords bool
+	redact     bool
+	slowLog    SlowQueryLogConfig
+
+	// mu guards slowOut, sample and sessions
+	mu      sync.Mutex
+	slowOut io.WriteCloser
+	sample  func() float64
+	// sessions are the sessions of the connected clients by connection ID
+	sessions map[uint32]*dsess.DoltSession
+}
+
+// newQueryLog returns the query log of the server configured by |config|, which logs to |lgr|. The slow query log
+// file is opened, and should be closed with Close.
+func newQueryLog(config ServerConfig, lgr *logrus.Logger) (*queryLog, error) {
+	ql := &queryLog{
+		lgr:        lgr,
+		logRecords: config.LogFormat() == LogFormat_JSON,
+		redact:     config.RedactLoggedQueries(),
+		slowLog:    config.SlowQueryLog(),
+		sample:     rand.New(rand.NewSource(time.Now().UnixNano())).Float64,
+		sessions:   make(map[uint32]*dsess.DoltSession),
+	}
+
+	if ql.slowLog.Path != "" {
+		f, err := os.OpenFile(ql.slowLog.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open slow query log: %w", err)
+		}
+		ql.slowOut = f
+	}
+
+	return ql, nil
+}
+
+// enabled returns whether the query log has anything to do. When it doesn't, sessions keep logging to the server log
+// directly.
+func (ql *queryLog) enabled() bool {
+	return ql.logRecords || ql.redact || ql.slowOut != nil
+}
+
+// Close closes the slow query log.
+func (ql *queryLog) Close() error {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	if ql.slowOut == nil {
+		return nil
+	}
+	err := ql.slowOut.Close()
+	ql.slowOut = nil
+	return err
+}
+
+// addSession adds |sess|, the session of the client connected with |connID|, to the sessions whose queries are
+// recorded, and returns the logger it should log to. The entries logged to it are forwarded to the server log.
+func (ql *queryLog) addSession(connID uint32, sess *dsess.DoltSession) *logrus.Entry {
+	ql.mu.Lock()
+	ql.sessions[connID] = sess
+	ql.mu.Unlock()
+
+	sessLgr := logrus.New()
+	sessLgr.SetOutput(io.Discard)
+	sessLgr.SetLevel(ql.lgr.GetLevel())
+	sessLgr.AddHook(&queryLogHook{ql: ql})
+
+	return logrus.NewEntry(sessLgr)
+}
+
+// removeSession removes the session of the client connected with |connID| once it disconnects.
+func (ql *queryLog) removeSession(connID uint32) {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	delete(ql.sessions, connID)
+}
+
+func (ql *queryLog) session(connID uint32) *dsess.DoltSession {
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	return ql.sessions[connID]
+}
+
+func (ql *queryLog) redactQuery(query string) string {
+	redacted, err := sqlparser.RedactSQLQuery(query)
+	if err != nil {
+		return redactedQuery
+	}
+	return redacted
+}
+
+// record records |query|, which the client of |c| started running at |start|. It returned |rows| rows, or failed
+// with |err|.
+func (ql *queryLog) record(c *mysql.Conn, query string, start time.Time, rows int64, err error) {
+	end := time.Now()
+	rec := queryRecord{
+		Time:         end,
+		ConnectionID: c.ConnectionID,
+		User:         c.User,
+		ClientAddr:   c.RemoteAddr().String(),
+		Query:        query,
+		DurationMs:   float64(end.Sub(start)) / float64(time.Millisecond),
+		RowsReturned: rows,
+		Status:       queryStatusOK,
+	}
+	if ql.redact {
+		rec.Query = ql.redactQuery(query)
+	}
+	if err != nil {
+		rec.Status = queryStatusError
+		rec.Error = err.Error()
+		var sqlErr *mysql.SQLError
+		if errors.As(err, &sqlErr) {
+			rec.Error = sqlErr.Message
+		}
+	}
+	if sess := ql.session(c.ConnectionID); sess != nil {
+		rec.Database = sess.GetCurrentDatabase()
+		rec.Branch = sessionBranch(sess, rec.Database)
+	}
+
+	ql.write(rec)
+}
+
+func (ql *queryLog) write(rec queryRecord) {
+	if ql.logRecords {
+		ql.lgr.WithTime(rec.Time).WithFields(rec.fields()).Info("Query completed")
+	}
+
+	ql.mu.Lock()
+	defer ql.mu.Unlock()
+	if ql.slowOut == nil || rec.DurationMs < float64(ql.slowLog.ThresholdMillis) {
+		return
+	}
+	if ql.slowLog.SampleRate < 1 && ql.sample() >= ql.slowLog.SampleRate {
+		return
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		ql.lgr.WithError(err).Warn("failed to write slow query log")
+		return
+	}
+	line = append(line, '\n')
+	if _, err = ql.slowOut.Write(line); err != nil {
+		ql.lgr.WithError(err).Warn("failed to write slow query log")
+	}
+}
+
+// queryLogHook is the hook of the logger of a session. It forwards every entry to the server log, redacting the
+// queries of the entries if the query log redacts them.
+type queryLogHook struct {
+	ql *queryLog
+
+	mu       sync.Mutex
+	query    string
+	redacted string
+}
+
+var _ logrus.Hook = (*queryLogHook)(nil)
+
+func (h *queryLogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *queryLogHook) Fire(entry *logrus.Entry) error {
+	if entry.Level == logrus.PanicLevel {
+		return nil
+	}
+
+	fwd := h.ql.lgr.WithTime(entry.Time).WithFields(entry.Data)
+	if query, _ := entry.Data["query"].(string); h.ql.redact && query != "" {
+		fwd = fwd.WithField("query", h.redactQuery(query))
+	}
+	fwd.Log(entry.Level, entry.Message)
+
+	return nil
+}
+
+// redactQuery returns |query| redacted, reusing the redaction of the last query of the session.
+func (h *queryLogHook) redactQuery(query string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if query != h.query {
+		h.query = query
+		h.redacted = h.ql.redactQuery(query)
+	}
+	return h.redacted
+}
+
+// queryLogHandler is the handler of a server that records the queries it runs in the query log.
+type queryLogHandler struct {
+	*server.Handler
+	ql *queryLog
+}
+
+var _ mysql.Handler = (*queryLogHandler)(nil)
+
+func (h *queryLogHandler) ConnectionClosed(c *mysql.Conn) {
+	h.Handler.ConnectionClosed(c)
+	h.ql.removeSession(c.ConnectionID)
+}
+
+func (h *queryLogHandler) ComQuery(c *mysql.Conn, query string, callback func(*sqltypes.Result, bool) error) error {
+	start := time.Now()
+	var rows int64
+	err := h.Handler.ComQuery(c, query, func(res *sqltypes.Result, more bool) error {
+		rows += int64(len(res.Rows))
+		return callback(res, more)
+	})
+	h.ql.record(c, query, start, rows, err)
+	return err
+}
+
+func (h *queryLogHandler) ComMultiQuery(c *mysql.Conn, query string, callback func(*sqltypes.Result, bool) error) (string, error) {
+	start := time.Now()
+	var rows int64
+	remainder, err := h.Handler.ComMultiQuery(c, query, func(res *sqltypes.Result, more bool) error {
+		rows += int64(len(res.Rows))
+		return callback(res, more)
+	})
+	h.ql.record(c, firstStatement(query, remainder), start, rows, err)
+	return remainder, err
+}
+
+func (h *queryLogHandler) ComStmtExecute(c *mysql.Conn, prepare *mysql.PrepareData, callback func(*sqltypes.Result) error) error {
+	start := time.Now()
+	var rows int64
+	err := h.Handler.ComStmtExecute(c, prepare, func(res *sqltypes.Result) error {
+		rows += int64(len(res.Rows))
+		return callback(res)
+	})
+	h.ql.record(c, prepare.PrepareStmt, start, rows, err)
+	return err
+}
+
+// firstStatement returns the statement of |query| that the handler ran, leaving |remainder| to run next.
+func firstStatement(query, remainder string) string {
+	stmt := strings.TrimSuffix(strings.TrimSpace(query), ";")
+	stmt = strings.TrimSpace(strings.TrimSuffix(stmt, remainder))
+	return strings.TrimSuffix(stmt, ";")
+}
+
+// sessionBranch returns the branch of the database named that |sess| has checked out, or "" if it has none.
+func sessionBranch(sess *dsess.DoltSession, dbName string) string {
+	if dbName == "" {
+		return ""
+	}
+	state, ok := sess.GetDbStates()[dbName]
+	if !ok || state.WorkingSet == nil {
+		return ""
+	}
+	headRef, err := state.WorkingSet.Ref().ToHeadRef()
+	if err != nil {
+		return ""
+	}
+	return headRef.GetPath()
+}
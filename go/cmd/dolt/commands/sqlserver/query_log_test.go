@@ -0,0 +1,143 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/server"
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/mysql"
+	"github.com/dolthub/vitess/go/sqltypes"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	"github.com/dolthub/dolt/go/libraries/doltcore/dtestutils"
+)
+
+func TestQueryLog(t *testing.T) {
+	ctx := context.Background()
+	dEnv := dtestutils.CreateEnvWithSeedData(t)
+	se, err := engine.NewSqlEngineForEnv(ctx, dEnv)
+	require.NoError(t, err)
+	defer se.Close()
+
+	sqlCtx, err := se.NewContext(ctx)
+	require.NoError(t, err)
+	dbName := sqlCtx.GetCurrentDatabase()
+	_, iter, err := se.Query(sqlCtx, "select * from people")
+	require.NoError(t, err)
+	people, err := sql.RowIterToRows(sqlCtx, nil, iter)
+	require.NoError(t, err)
+
+	slowPath := filepath.Join(t.TempDir(), "slow.log")
+	var cfg YAMLConfig
+	cfg.LogFormatStr = strPtr(string(LogFormat_JSON))
+	cfg.LogRedactLiterals = boolPtr(true)
+	cfg.SlowQueryLogCfg = &SlowQueryLogYAMLConfig{Path: strPtr(slowPath), ThresholdMillis: uint64Ptr(100)}
+
+	var serverLog bytes.Buffer
+	lgr := logrus.New()
+	lgr.SetOutput(&serverLog)
+	lgr.SetFormatter(&logrus.JSONFormatter{})
+
+	ql, err := newQueryLog(cfg, lgr)
+	require.NoError(t, err)
+	require.True(t, ql.enabled())
+
+	e := se.GetUnderlyingEngine()
+	sm := server.NewSessionManager(newSessionBuilder(se, cfg, ql), sql.NoopTracer, e.Analyzer.Catalog.HasDB, e.MemoryManager, e.ProcessList, "localhost:3306")
+	h := &queryLogHandler{Handler: server.NewHandler(e, sm, 0, false, nil), ql: ql}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	c := &mysql.Conn{ConnectionID: 1, User: "root", Conn: serverConn}
+	h.NewConnection(c)
+	require.NoError(t, h.ComInitDB(c, dbName))
+
+	discard := func(*sqltypes.Result, bool) error { return nil }
+	require.NoError(t, h.ComQuery(c, "select * from people where id = 5", discard))
+	require.NoError(t, h.ComQuery(c, "select * from people", discard))
+	require.NoError(t, h.ComQuery(c, "select sleep(0.2)", discard))
+	require.Error(t, h.ComQuery(c, "select * from nope where id = 1", discard))
+	remainder, err := h.ComMultiQuery(c, "set @x = 10; select 1;", discard)
+	require.NoError(t, err)
+	assert.Equal(t, "select 1", remainder)
+	h.ConnectionClosed(c)
+	require.NoError(t, ql.Close())
+	assert.Empty(t, ql.sessions)
+
+	// every query is recorded in the server log, along with the entries the handler logs to the session
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(&serverLog)
+	for scanner.Scan() {
+		var rec map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		if rec["msg"] == "Query completed" {
+			records = append(records, rec)
+		} else {
+			assert.NotContains(t, rec["query"], "nope where id = 1")
+		}
+	}
+	require.Len(t, records, 5)
+	for _, rec := range records {
+		assert.Equal(t, 1.0, rec["connection_id"])
+		assert.Equal(t, "root", rec["user"])
+		assert.Equal(t, "pipe", rec["client_address"])
+		assert.Equal(t, dbName, rec["database"])
+		assert.Equal(t, "main", rec["branch"])
+	}
+	assert.Equal(t, "select * from people where id = :redacted1", records[0]["query"])
+	assert.Equal(t, queryStatusOK, records[0]["status"])
+	assert.Equal(t, 0.0, records[0]["rows_returned"])
+	assert.Equal(t, "select * from people", records[1]["query"])
+	assert.Equal(t, float64(len(people)), records[1]["rows_returned"])
+	assert.Equal(t, queryStatusError, records[3]["status"])
+	assert.Equal(t, "table not found: nope", records[3]["error"])
+	assert.Equal(t, "set @x = :redacted1", records[4]["query"])
+
+	// only the queries over the threshold are written to the slow query log
+	data, err := os.ReadFile(slowPath)
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 1)
+	var slow queryRecord
+	require.NoError(t, json.Unmarshal(lines[0], &slow))
+	assert.Equal(t, "select sleep(0.2) from dual", slow.Query)
+	assert.GreaterOrEqual(t, slow.DurationMs, 200.0)
+	assert.Equal(t, int64(1), slow.RowsReturned)
+	assert.Equal(t, "main", slow.Branch)
+}
+
+func TestFirstStatement(t *testing.T) {
+	assert.Equal(t, "select 1", firstStatement("select 1; select 2;", "select 2"))
+	assert.Equal(t, "select 1", firstStatement(" select 1 ;\n", ""))
+}
+
+func TestQueryLogDisabled(t *testing.T) {
+	ql, err := newQueryLog(DefaultServerConfig(), logrus.New())
+	require.NoError(t, err)
+	assert.False(t, ql.enabled())
+	assert.NoError(t, ql.Close())
+}
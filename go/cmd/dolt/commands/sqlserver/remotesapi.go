@@ -0,0 +1,386 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
+	remotesapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/dolthub/dolt/go/libraries/doltcore/branch_control"
+	"github.com/dolthub/dolt/go/libraries/doltcore/creds"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/doltcore/remotesrv"
+	dsqle "github.com/dolthub/dolt/go/libraries/doltcore/sqle"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+// remotesapiWriteMethods are the methods of the remotesapi that write to the database they're called on.
+var remotesapiWriteMethods = map[string]bool{
+	"/dolt.services.remotesapi.v1alpha1.ChunkStoreService/GetUploadLocations": true,
+	"/dolt.services.remotesapi.v1alpha1.ChunkStoreService/Commit":             true,
+	"/dolt.services.remotesapi.v1alpha1.ChunkStoreService/AddTableFiles":      true,
+}
+
+// newRemotesapiServer returns the server of the remotesapi endpoint that serves the databases of |se| as remotes on
+// |port|. Its requests are authenticated as the SQL users of |se|, with the JWTs of |jwksConfig| for users that are
+// authenticated with one. Writes to databases that |writeGuard| refuses are refused.
+func newRemotesapiServer(se *engine.SqlEngine, lgr *logrus.Logger, port int, jwksConfig []engine.JwksConfig, writeGuard dsess.WriteGuard) (*remotesrv.Server, error) {
+	sealer, err := remotesrv.NewSingleSymmetricKeySealer()
+	if err != nil {
+		return nil, err
+	}
+
+	auth := remotesapiAuth{
+		mysqlDb:      se.GetUnderlyingEngine().Analyzer.Catalog.MySQLDb,
+		plugins:      engine.AuthPlugins(jwksConfig),
+		writeGuard:   writeGuard,
+		bcController: se.GetBranchController(),
+		dbCache:      sqlEngineDBCache{se},
+	}
+
+	return remotesrv.NewServer(remotesrv.ServerArgs{
+		Logger:   logrus.NewEntry(lgr),
+		HttpHost: fmt.Sprintf(":%d", port),
+		HttpPort: port,
+		GrpcPort: port,
+		DBCache:  auth.dbCache,
+		Sealer:   sealer,
+		Options:  auth.serverOptions(),
+	}), nil
+}
+
+// sqlEngineDBCache serves the chunk stores of the databases of a sql-server to its remotesapi endpoint, including
+// those created after the server started.
+type sqlEngineDBCache struct {
+	se *engine.SqlEngine
+}
+
+var _ remotesrv.DBCache = sqlEngineDBCache{}
+
+func (c sqlEngineDBCache) Get(org, repo, nbfVerStr string) (remotesrv.RemoteSrvStore, error) {
+	if org != "" {
+		return nil, fmt.Errorf("no database named %s/%s", org, repo)
+	}
+
+	ddb, err := c.doltDB(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	cs := datas.ChunkStoreFromDatabase(doltdb.HackDatasDatabaseFromDoltDB(ddb))
+	rss, ok := cs.(remotesrv.RemoteSrvStore)
+	if !ok {
+		return nil, fmt.Errorf("database %s cannot be served as a remote", repo)
+	}
+	return rss, nil
+}
+
+// doltDB returns the DoltDB of the database named |repo|.
+func (c sqlEngineDBCache) doltDB(repo string) (*doltdb.DoltDB, error) {
+	ctx, err := c.se.NewContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	// the requests of the endpoint are authorized by remotesapiAuth, so the databases are looked up without the
+	// privilege checks of the catalog, which would check them for the context's empty user
+	db, err := c.se.GetDatabaseProvider().Database(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	sqlDb, ok := db.(dsqle.SqlDatabase)
+	if !ok {
+		return nil, fmt.Errorf("database %s cannot be served as a remote", repo)
+	}
+	return sqlDb.DbData().Ddb, nil
+}
+
+// remotesapiAuth authenticates the requests made to the remotesapi endpoint of a sql-server as the SQL users of the
+// server, and checks that they have the privileges the requests need on the database they're made against. Reads
+// need SELECT, and writes need INSERT, UPDATE and DELETE. The branches a commit creates, updates or deletes are also
+// checked against the branch permissions of the server.
+type remotesapiAuth struct {
+	mysqlDb      *mysql_db.MySQLDb
+	plugins      map[string]mysql_db.PlaintextAuthPlugin
+	writeGuard   dsess.WriteGuard
+	bcController *branch_control.Controller
+	dbCache      sqlEngineDBCache
+}
+
+func (a remotesapiAuth) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.unary),
+		grpc.ChainStreamInterceptor(a.stream),
+	}
+}
+
+func (a remotesapiAuth) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	client, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = a.authorize(ctx, client, info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a remotesapiAuth) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	client, err := a.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, authorizingServerStream{ServerStream: ss, auth: a, client: client, method: info.FullMethod})
+}
+
+// authorizingServerStream checks that the client of a stream has the privileges that each request received on it
+// needs, since every request names the database it's made against.
+type authorizingServerStream struct {
+	grpc.ServerStream
+	auth   remotesapiAuth
+	client sql.Client
+	method string
+}
+
+func (s authorizingServerStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+	return s.auth.authorize(s.Context(), s.client, s.method, m)
+}
+
+// authenticate returns the SQL user that the credentials of the request of |ctx| authenticate.
+func (a remotesapiAuth) authenticate(ctx context.Context) (sql.Client, error) {
+	var authorization []string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		authorization = md.Get("authorization")
+	}
+	if len(authorization) != 1 {
+		return sql.Client{}, status.Error(codes.Unauthenticated, "a user and password are required")
+	}
+	user, password, ok := creds.ParseBasicAuth(authorization[0])
+	if !ok {
+		return sql.Client{}, status.Error(codes.Unauthenticated, "a user and password are required")
+	}
+
+	host := "localhost"
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil && p.Addr.Network() != "unix" {
+		h, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			return sql.Client{}, status.Error(codes.Internal, err.Error())
+		}
+		host = h
+	}
+
+	client := sql.Client{User: user, Address: host}
+	if !a.mysqlDb.Enabled {
+		return client, nil
+	}
+
+	userEntry := a.mysqlDb.GetUser(user, host, false)
+	if userEntry == nil || userEntry.Locked {
+		return sql.Client{}, status.Errorf(codes.Unauthenticated, "access denied for user '%s'", user)
+	}
+
+	if plugin, ok := a.plugins[userEntry.Plugin]; ok {
+		authed, err := plugin.Authenticate(a.mysqlDb, user, userEntry, password)
+		if err != nil || !authed {
+			return sql.Client{}, status.Errorf(codes.Unauthenticated, "access denied for user '%s'", user)
+		}
+	} else if subtle.ConstantTimeCompare([]byte(nativePasswordHash(password)), []byte(userEntry.Password)) != 1 {
+		return sql.Client{}, status.Errorf(codes.Unauthenticated, "access denied for user '%s'", user)
+	}
+
+	return client, nil
+}
+
+// authorize checks that |client| has the privileges that |method| needs on the database of |req|.
+func (a remotesapiAuth) authorize(ctx context.Context, client sql.Client, method string, req interface{}) error {
+	repoReq, ok := req.(interface{ GetRepoId() *remotesapi.RepoId })
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed", method)
+	}
+	dbName := repoReq.GetRepoId().GetRepoName()
+
+	write := remotesapiWriteMethods[method]
+	privs := []sql.PrivilegeType{sql.PrivilegeType_Select}
+	if write {
+		privs = []sql.PrivilegeType{sql.PrivilegeType_Insert, sql.PrivilegeType_Update, sql.PrivilegeType_Delete}
+	}
+
+	sqlCtx := sql.NewContext(ctx, sql.WithSession(sql.NewBaseSessionWithClientServer("", client, 0)))
+	if !a.mysqlDb.UserHasPrivileges(sqlCtx, sql.NewPrivilegedOperation(dbName, "", "", privs...)) {
+		if write {
+			return status.Errorf(codes.PermissionDenied, "user '%s' does not have write access to database %s", client.User, dbName)
+		}
+		return status.Errorf(codes.PermissionDenied, "user '%s' does not have read access to database %s", client.User, dbName)
+	}
+
+	if write && a.writeGuard != nil {
+		if err := a.writeGuard(dbName); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+	}
+
+	if commitReq, ok := req.(*remotesapi.CommitRequest); ok {
+		return a.authorizeRefUpdates(ctx, client, dbName, commitReq)
+	}
+
+	return nil
+}
+
+// authorizeRefUpdates checks that |client| may create, write to and delete the branches that |req| changes, by
+// diffing the refs of the root it commits against the ones of the root it replaces.
+func (a remotesapiAuth) authorizeRefUpdates(ctx context.Context, client sql.Client, dbName string, req *remotesapi.CommitRequest) error {
+	if a.bcController == nil {
+		return nil
+	}
+
+	ddb, err := a.dbCache.doltDB(dbName)
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	cs := datas.ChunkStoreFromDatabase(doltdb.HackDatasDatabaseFromDoltDB(ddb))
+	rss, ok := cs.(remotesrv.RemoteSrvStore)
+	if !ok {
+		return status.Errorf(codes.Internal, "database %s cannot be served as a remote", dbName)
+	}
+
+	// the root being committed can be in the table files of the commit, which must be in the manifest to be read.
+	// Adding them doesn't change the root, and the commit adds them again.
+	updates := make(map[string]int)
+	for _, cti := range req.ChunkTableInfo {
+		updates[hash.New(cti.Hash).String()] = int(cti.ChunkCount)
+	}
+	if len(updates) > 0 {
+		if err := rss.AddTableFilesToManifest(ctx, updates); err != nil {
+			return status.Errorf(codes.Internal, "manifest update error: %v", err)
+		}
+	}
+
+	db := doltdb.HackDatasDatabaseFromDoltDB(ddb)
+	before, err := datasetAddrs(ctx, db, hash.New(req.Last))
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "cannot read the refs of the current root: %v", err)
+	}
+	after, err := datasetAddrs(ctx, db, hash.New(req.Current))
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "cannot read the refs of the committed root: %v", err)
+	}
+
+	user, host := client.User, client.Address
+	for id, addr := range after {
+		prev, existed := before[id]
+		if existed && prev == addr {
+			continue
+		}
+		branch, isHead, ok := branchOfDataset(id)
+		if !ok {
+			continue
+		}
+		if isHead && !existed {
+			err = a.bcController.CheckCreate(dbName, branch, user, host)
+		} else {
+			err = a.bcController.CheckWrite(dbName, branch, user, host)
+		}
+		if err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; ok {
+			continue
+		}
+		branch, isHead, ok := branchOfDataset(id)
+		if !ok {
+			continue
+		}
+		if isHead {
+			err = a.bcController.CheckDelete(dbName, branch, user, host)
+		} else {
+			err = a.bcController.CheckWrite(dbName, branch, user, host)
+		}
+		if err != nil {
+			return status.Error(codes.PermissionDenied, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// datasetAddrs returns the address of every dataset in the root |root| of |db|.
+func datasetAddrs(ctx context.Context, db datas.Database, root hash.Hash) (map[string]hash.Hash, error) {
+	dss, err := db.GetDatasetsByRootHash(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make(map[string]hash.Hash, dss.Len())
+	err = dss.IterAll(ctx, func(id string, addr hash.Hash) error {
+		addrs[id] = addr
+		return nil
+	})
+	return addrs, err
+}
+
+// branchOfDataset returns the branch the dataset |id| belongs to, and whether it's the head of the branch rather than
+// its working set. It returns false if the dataset doesn't belong to a branch.
+func branchOfDataset(id string) (string, bool, bool) {
+	if ref.IsWorkingSet(id) {
+		headRef, err := ref.NewWorkingSetRef(strings.TrimPrefix(id, ref.WorkingSetRefPrefix+"/")).ToHeadRef()
+		if err != nil || headRef.GetType() != ref.BranchRefType {
+			return "", false, false
+		}
+		return headRef.GetPath(), false, true
+	}
+	if !ref.IsRef(id) {
+		return "", false, false
+	}
+	r, err := ref.Parse(id)
+	if err != nil || r.GetType() != ref.BranchRefType {
+		return "", false, false
+	}
+	return r.GetPath(), true, true
+}
+
+// nativePasswordHash returns |password| hashed the way the passwords of mysql_native_password users are stored.
+func nativePasswordHash(password string) string {
+	if password == "" {
+		return ""
+	}
+	s1 := sha1.Sum([]byte(password))
+	s2 := sha1.Sum(s1[:])
+	return "*" + strings.ToUpper(hex.EncodeToString(s2[:]))
+}
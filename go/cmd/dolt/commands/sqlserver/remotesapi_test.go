@@ -0,0 +1,46 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchOfDataset(t *testing.T) {
+	tests := []struct {
+		id     string
+		branch string
+		isHead bool
+		ok     bool
+	}{
+		{"refs/heads/main", "main", true, true},
+		{"refs/heads/feature/one", "feature/one", true, true},
+		{"workingSets/heads/main", "main", false, true},
+		{"refs/tags/v1", "", false, false},
+		{"refs/remotes/origin/main", "", false, false},
+		{"refs/internal/create", "", false, false},
+		{"workingSets/remotes/origin/main", "", false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			branch, isHead, ok := branchOfDataset(test.id)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.branch, branch)
+			assert.Equal(t, test.isHead, isHead)
+		})
+	}
+}
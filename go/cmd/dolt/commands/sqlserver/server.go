@@ -19,10 +19,12 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"time"
 
+	sqle "github.com/dolthub/go-mysql-server"
 	"github.com/dolthub/go-mysql-server/server"
 	"github.com/dolthub/go-mysql-server/sql"
 	"github.com/dolthub/vitess/go/mysql"
@@ -32,10 +34,18 @@ import (
 	"github.com/dolthub/dolt/go/cmd/dolt/cli"
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/remotesrv"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/cluster"
 	_ "github.com/dolthub/dolt/go/libraries/doltcore/sqle/dfunctions"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqlserver"
+	"github.com/dolthub/dolt/go/libraries/utils/config"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
 )
 
+// clusterRoleFile is the file of the cfg dir that the role of a server in its cluster is persisted in.
+const clusterRoleFile = "cluster_role.json"
+
 // Serve starts a MySQL-compatible server. Returns any errors that were encountered.
 func Serve(
 	ctx context.Context,
@@ -49,7 +59,7 @@ func Serve(
 		serverController = NewServerController()
 	}
 
-	var mySQLServer *server.Server
+	var mySQLServer *mysqlServer
 	// This guarantees unblocking on any routines with a waiting `ServerController`
 	defer func() {
 		if mySQLServer != nil {
@@ -78,7 +88,11 @@ func Serve(
 		}
 		logrus.SetLevel(level)
 	}
-	logrus.SetFormatter(LogFormat{})
+	if serverConfig.LogFormat() == LogFormat_JSON {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	} else {
+		logrus.SetFormatter(LogFormat{})
+	}
 
 	var mrEnv *env.MultiRepoEnv
 	var err error
@@ -127,17 +141,33 @@ func Serve(
 		return sErr, nil
 	}
 
+	var clusterController *cluster.Controller
+	if clusterConfig := serverConfig.ClusterConfig(); clusterConfig != nil {
+		var roleCfg config.ReadWriteConfig
+		roleCfg, err = clusterRoleConfig(dEnv.FS, serverConfig.CfgDir())
+		if err != nil {
+			return err, nil
+		}
+		clusterController, err = cluster.NewController(lgr, clusterConfig, roleCfg)
+		if err != nil {
+			return err, nil
+		}
+	}
+
 	// Create SQL Engine with users
 	config := &engine.SqlEngineConfig{
-		InitialDb:      "",
-		IsReadOnly:     serverConfig.ReadOnly(),
-		PrivFilePath:   serverConfig.PrivilegeFilePath(),
-		DoltCfgDirPath: serverConfig.CfgDir(),
-		ServerUser:     serverConfig.User(),
-		ServerPass:     serverConfig.Password(),
-		ServerHost:     serverConfig.Host(),
-		Autocommit:     serverConfig.AutoCommit(),
-		JwksConfig:     serverConfig.JwksConfig(),
+		InitialDb:          "",
+		IsReadOnly:         serverConfig.ReadOnly(),
+		PrivFilePath:       serverConfig.PrivilegeFilePath(),
+		BranchCtrlFilePath: serverConfig.BranchControlFilePath(),
+		DoltCfgDirPath:     serverConfig.CfgDir(),
+		ServerUser:         serverConfig.User(),
+		ServerPass:         serverConfig.Password(),
+		ServerHost:         serverConfig.Host(),
+		Autocommit:         serverConfig.AutoCommit(),
+		JwksConfig:         serverConfig.JwksConfig(),
+		ClusterController:  clusterController,
+		StorageAllowlist:   serverConfig.StorageAllowlist(),
 	}
 	sqlEngine, err := engine.NewSqlEngine(
 		ctx,
@@ -172,12 +202,22 @@ func Serve(
 	labels := serverConfig.MetricsLabels()
 	listener := newMetricsListener(labels)
 	defer listener.Close()
+	collector := newMetricsCollector(sqlEngine, labels)
+	collector.Start()
+	defer collector.Close()
 
-	mySQLServer, startError = server.NewServer(
+	queryLog, err := newQueryLog(serverConfig, lgr)
+	if err != nil {
+		return err, nil
+	}
+	defer queryLog.Close()
+
+	mySQLServer, startError = newServer(
 		serverConf,
 		sqlEngine.GetUnderlyingEngine(),
-		newSessionBuilder(sqlEngine, serverConfig),
+		newSessionBuilder(sqlEngine, serverConfig, queryLog),
 		listener,
+		queryLog,
 	)
 
 	if startError != nil {
@@ -202,6 +242,12 @@ func Serve(
 		}()
 	}
 
+	if serverConfig.AutoGC().Enabled {
+		if startError = startAutoGC(sqlEngine, serverConfig.AutoGC()); startError != nil {
+			return
+		}
+	}
+
 	if ok, f := mrEnv.IsLocked(); ok {
 		startError = env.ErrActiveServerLock.New(f)
 		return
@@ -211,10 +257,45 @@ func Serve(
 		return
 	}
 
+	var remoteSrvs []*remotesrv.Server
+	var writeGuard dsess.WriteGuard
+	if clusterController != nil {
+		args, err := clusterController.RemoteSrvServerArgs(mrEnv, remotesrv.ServerArgs{Logger: logrus.NewEntry(lgr)})
+		if err != nil {
+			startError = err
+		} else {
+			remoteSrvs = append(remoteSrvs, remotesrv.NewServer(args))
+		}
+		writeGuard = clusterController.WriteGuard
+	}
+	if port := serverConfig.RemotesapiPort(); port != nil && startError == nil {
+		remotesapiSrv, err := newRemotesapiServer(sqlEngine, lgr, *port, serverConfig.JwksConfig(), writeGuard)
+		if err != nil {
+			startError = err
+		} else {
+			remoteSrvs = append(remoteSrvs, remotesapiSrv)
+		}
+	}
+	if startError == nil {
+		startError = startRemoteSrvs(remoteSrvs)
+	}
+	if startError != nil {
+		for _, remoteSrv := range remoteSrvs {
+			remoteSrv.GracefulStop()
+		}
+		if err := mrEnv.Unlock(); err != nil {
+			cli.PrintErr(err)
+		}
+		return
+	}
+
 	serverController.registerCloseFunction(startError, func() error {
 		if metSrv != nil {
 			metSrv.Close()
 		}
+		for _, remoteSrv := range remoteSrvs {
+			remoteSrv.GracefulStop()
+		}
 
 		return mySQLServer.Close()
 	})
@@ -230,6 +311,40 @@ func Serve(
 	return
 }
 
+// startRemoteSrvs opens the listeners of every remotesapi server of |remoteSrvs|, and serves them in the background
+// once they're all open.
+func startRemoteSrvs(remoteSrvs []*remotesrv.Server) error {
+	listeners := make([]remotesrv.Listeners, len(remoteSrvs))
+	for i, remoteSrv := range remoteSrvs {
+		var err error
+		listeners[i], err = remoteSrv.Listeners()
+		if err != nil {
+			for _, l := range listeners[:i] {
+				l.Close()
+			}
+			return fmt.Errorf("error starting remotesapi server: %w", err)
+		}
+	}
+	for i, remoteSrv := range remoteSrvs {
+		go remoteSrv.Serve(listeners[i])
+	}
+	return nil
+}
+
+// clusterRoleConfig returns the config that the role of the server in its cluster is persisted in, which is kept in
+// the file clusterRoleFile of |cfgDir|.
+func clusterRoleConfig(fs filesys.Filesys, cfgDir string) (config.ReadWriteConfig, error) {
+	path := filepath.Join(cfgDir, clusterRoleFile)
+	if exists, _ := fs.Exists(path); exists {
+		return config.FromFile(path, fs)
+	}
+	err := fs.MkDirs(cfgDir)
+	if err != nil {
+		return nil, err
+	}
+	return config.NewFileConfig(path, fs, map[string]string{})
+}
+
 func portInUse(hostPort string) bool {
 	timeout := time.Second
 	conn, _ := net.DialTimeout("tcp", hostPort, timeout)
@@ -240,7 +355,73 @@ func portInUse(hostPort string) bool {
 	return false
 }
 
-func newSessionBuilder(se *engine.SqlEngine, config ServerConfig) server.SessionBuilder {
+// mysqlServer is the MySQL server of sql-server.
+type mysqlServer struct {
+	*server.Server
+	sm *server.SessionManager
+}
+
+var _ sqlserver.RunningServer = (*mysqlServer)(nil)
+
+// SessionManager returns the session manager of the server.
+func (s *mysqlServer) SessionManager() *server.SessionManager {
+	return s.sm
+}
+
+// newServer returns a server for |e| configured by |cfg|, the way server.NewServer does, except that the queries its
+// handler runs are recorded in |queryLog| when it's enabled.
+func newServer(
+	cfg server.Config,
+	e *sqle.Engine,
+	sb server.SessionBuilder,
+	listener server.ServerEventListener,
+	queryLog *queryLog,
+) (*mysqlServer, error) {
+	tracer := cfg.Tracer
+	if tracer == nil {
+		tracer = sql.NoopTracer
+	}
+	if cfg.ConnReadTimeout < 0 {
+		cfg.ConnReadTimeout = 0
+	}
+	if cfg.ConnWriteTimeout < 0 {
+		cfg.ConnWriteTimeout = 0
+	}
+
+	sm := server.NewSessionManager(sb, tracer, e.Analyzer.Catalog.HasDB, e.MemoryManager, e.ProcessList, cfg.Address)
+	h := server.NewHandler(e, sm, cfg.ConnReadTimeout, cfg.DisableClientMultiStatements, listener)
+	var handler mysql.Handler = h
+	if queryLog.enabled() {
+		handler = &queryLogHandler{Handler: h, ql: queryLog}
+	}
+
+	l, err := server.NewListener(cfg.Protocol, cfg.Address, cfg.Socket)
+	if err != nil {
+		return nil, err
+	}
+	vtListener, err := mysql.NewListenerWithConfig(mysql.ListenerConfig{
+		Listener:                 l,
+		AuthServer:               e.Analyzer.Catalog.MySQLDb,
+		Handler:                  handler,
+		ConnReadTimeout:          cfg.ConnReadTimeout,
+		ConnWriteTimeout:         cfg.ConnWriteTimeout,
+		MaxConns:                 cfg.MaxConnections,
+		ConnReadBufferSize:       mysql.DefaultConnBufferSize,
+		AllowClearTextWithoutTLS: cfg.AllowClearTextWithoutTLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Version != "" {
+		vtListener.ServerVersion = cfg.Version
+	}
+	vtListener.TLSConfig = cfg.TLSConfig
+	vtListener.RequireSecureTransport = cfg.RequireSecureTransport
+
+	return &mysqlServer{Server: &server.Server{Listener: vtListener}, sm: sm}, nil
+}
+
+func newSessionBuilder(se *engine.SqlEngine, config ServerConfig, queryLog *queryLog) server.SessionBuilder {
 	userToSessionVars := make(map[string]map[string]string)
 	userVars := config.UserVars()
 	for _, curr := range userVars {
@@ -262,6 +443,10 @@ func newSessionBuilder(se *engine.SqlEngine, config ServerConfig) server.Session
 			return nil, err
 		}
 
+		if queryLog.enabled() {
+			dsess.SetLogger(queryLog.addSession(conn.ConnectionID, dsess))
+		}
+
 		varsForUser := userToSessionVars[conn.User]
 		if len(varsForUser) > 0 {
 			sqlCtx, err := se.NewContext(ctx)
@@ -151,7 +151,7 @@ func TestServerGoodParams(t *testing.T) {
 	env := dtestutils.CreateEnvWithSeedData(t)
 
 	tests := []ServerConfig{
-		DefaultServerConfig(),
+		DefaultServerConfig().WithBranchControlFilePath(""),
 		DefaultServerConfig().WithHost("127.0.0.1").WithPort(15400),
 		DefaultServerConfig().WithHost("localhost").WithPort(15401),
 		//DefaultServerConfig().WithHost("::1").WithPort(15402), // Fails on Jenkins, assuming no IPv6 support
@@ -187,7 +187,7 @@ func TestServerGoodParams(t *testing.T) {
 
 func TestServerSelect(t *testing.T) {
 	env := dtestutils.CreateEnvWithSeedData(t)
-	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).WithPort(15300)
+	serverConfig := DefaultServerConfig().WithBranchControlFilePath("").withLogLevel(LogLevel_Fatal).WithPort(15300)
 
 	sc := NewServerController()
 	defer sc.StopServer()
@@ -262,7 +262,7 @@ func TestServerFailsIfPortInUse(t *testing.T) {
 
 func TestServerSetDefaultBranch(t *testing.T) {
 	dEnv := dtestutils.CreateEnvWithSeedData(t)
-	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).WithPort(15302)
+	serverConfig := DefaultServerConfig().WithBranchControlFilePath("").withLogLevel(LogLevel_Fatal).WithPort(15302)
 
 	sc := NewServerController()
 	defer sc.StopServer()
@@ -412,7 +412,7 @@ func TestReadReplica(t *testing.T) {
 
 	// start server as read replica
 	sc := NewServerController()
-	serverConfig := DefaultServerConfig().withLogLevel(LogLevel_Fatal).WithPort(15303)
+	serverConfig := DefaultServerConfig().WithBranchControlFilePath("").withLogLevel(LogLevel_Fatal).WithPort(15303)
 
 	func() {
 		os.Chdir(multiSetup.DbPaths[readReplicaDbName])
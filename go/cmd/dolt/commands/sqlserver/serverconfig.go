@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"net"
 	"path/filepath"
+	"strings"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/cluster"
 )
 
 // LogLevel defines the available levels of logging for the server.
@@ -36,6 +38,14 @@ const (
 	LogLevel_Fatal   LogLevel = "fatal"
 )
 
+// LogFormatType is the format of the lines of the server log.
+type LogFormatType string
+
+const (
+	LogFormat_Text LogFormatType = "text"
+	LogFormat_JSON LogFormatType = "json"
+)
+
 const (
 	defaultHost                    = "localhost"
 	defaultPort                    = 3306
@@ -44,6 +54,7 @@ const (
 	defaultTimeout                 = 8 * 60 * 60 * 1000 // 8 hours, same as MySQL
 	defaultReadOnly                = false
 	defaultLogLevel                = LogLevel_Info
+	defaultLogFormat               = LogFormat_Text
 	defaultAutoCommit              = true
 	defaultMaxConnections          = 100
 	defaultQueryParallelism        = 2
@@ -51,10 +62,18 @@ const (
 	defaultDataDir                 = "."
 	defaultCfgDir                  = ".doltcfg"
 	defaultPrivilegeFilePath       = "privileges.db"
+	defaultBranchControlFilePath   = "branch_control.db"
 	defaultMetricsHost             = ""
 	defaultMetricsPort             = -1
 	defaultAllowCleartextPasswords = false
 	defaultUnixSocketFilePath      = "/tmp/mysql.sock"
+	defaultAutoGCCheckInterval     = 60 * 1000 // 1 minute
+	defaultAutoGCTableFiles        = 256
+	defaultAutoGCNewDataMB         = 1024
+	defaultSlowQuerySampleRate     = 1.0
+
+	defaultClusterReplicationAck        = cluster.ReplicationAckSync
+	defaultClusterReplicationAckTimeout = 10 * 1000 // 10 seconds
 )
 
 const (
@@ -82,6 +101,37 @@ func (level LogLevel) String() string {
 	}
 }
 
+// IsValid returns whether the log format is one the server can write.
+func (format LogFormatType) IsValid() bool {
+	return format == LogFormat_Text || format == LogFormat_JSON
+}
+
+// SlowQueryLogConfig controls the log of the queries that run longer than a threshold. Each query is written to the
+// file as a line of JSON. The log is disabled when no path is given.
+type SlowQueryLogConfig struct {
+	// Path is the path of the file the slow queries are appended to.
+	Path string
+	// ThresholdMillis is the duration in milliseconds at or above which a query is logged.
+	ThresholdMillis uint64
+	// SampleRate is the fraction of the slow queries that are logged, between 0 and 1.
+	SampleRate float64
+}
+
+// AutoGCConfig controls the garbage collection that the server runs on its own. A database is collected once either
+// of the thresholds is crossed; a zero threshold is never crossed.
+type AutoGCConfig struct {
+	// Enabled is true if the server should collect garbage on its own.
+	Enabled bool
+	// CheckIntervalMillis is how often, in milliseconds, the databases are checked against the thresholds.
+	CheckIntervalMillis uint64
+	// TableFileThreshold is the number of table files written since a database's last collection above which it is
+	// collected.
+	TableFileThreshold int
+	// NewDataThresholdMB is the size in megabytes of the table files written since a database's last collection above
+	// which it is collected.
+	NewDataThresholdMB uint64
+}
+
 // ServerConfig contains all of the configurable options for the MySQL-compatible server.
 type ServerConfig interface {
 	// Host returns the domain that the server will run on. Accepts an IPv4 or IPv6 address, in addition to localhost.
@@ -100,6 +150,13 @@ type ServerConfig interface {
 	ReadOnly() bool
 	// LogLevel returns the level of logging that the server will use.
 	LogLevel() LogLevel
+	// LogFormat returns the format of the server log. In the JSON format, a record of every query is logged as well.
+	LogFormat() LogFormatType
+	// RedactLoggedQueries is true if the literals of the queries written to the server and slow query logs should be
+	// replaced with placeholders.
+	RedactLoggedQueries() bool
+	// SlowQueryLog returns the configuration of the log of the queries that run longer than a threshold
+	SlowQueryLog() SlowQueryLogConfig
 	// Autocommit defines the value of the @@autocommit session variable used on every connection
 	AutoCommit() bool
 	// DatabaseNamesAndPaths returns an array of env.EnvNameAndPathObjects corresponding to the databases to be loaded in
@@ -133,6 +190,8 @@ type ServerConfig interface {
 	// PrivilegeFilePath returns the path to the file which contains all needed privilege information in the form of a
 	// JSON string.
 	PrivilegeFilePath() string
+	// BranchControlFilePath returns the path to the file which contains the branch control permissions.
+	BranchControlFilePath() string
 	// UserVars is an array containing user specific session variables
 	UserVars() []UserSessionVars
 	// JwksConfig is an array containing jwks config
@@ -141,6 +200,15 @@ type ServerConfig interface {
 	AllowCleartextPasswords() bool
 	// Socket is a path to the unix socket file
 	Socket() string
+	// AutoGC returns the configuration of the garbage collection the server runs on its own
+	AutoGC() AutoGCConfig
+	// ClusterConfig returns the cluster configuration of the server, nil if it isn't part of a cluster
+	ClusterConfig() cluster.Config
+	// RemotesapiPort returns the port of the remotesapi endpoint that serves the databases of the server as remotes,
+	// nil if it doesn't serve one
+	RemotesapiPort() *int
+	// StorageAllowlist returns the storage urls under which databases may be created with dolt_create_database
+	StorageAllowlist() []string
 }
 
 type commandLineServerConfig struct {
@@ -162,6 +230,7 @@ type commandLineServerConfig struct {
 	requireSecureTransport  bool
 	persistenceBehavior     string
 	privilegeFilePath       string
+	branchControlFilePath   string
 	allowCleartextPasswords bool
 	socket                  string
 }
@@ -269,6 +338,11 @@ func (cfg *commandLineServerConfig) PrivilegeFilePath() string {
 	return cfg.privilegeFilePath
 }
 
+// BranchControlFilePath returns the path to the file which contains the branch control permissions.
+func (cfg *commandLineServerConfig) BranchControlFilePath() string {
+	return cfg.branchControlFilePath
+}
+
 // UserVars is an array containing user specific session variables.
 func (cfg *commandLineServerConfig) UserVars() []UserSessionVars {
 	return nil
@@ -304,6 +378,46 @@ func (cfg *commandLineServerConfig) Socket() string {
 	return cfg.socket
 }
 
+// AutoGC returns the configuration of the garbage collection the server runs on its own. It can only be enabled in a
+// config file.
+func (cfg *commandLineServerConfig) AutoGC() AutoGCConfig {
+	return AutoGCConfig{}
+}
+
+// LogFormat returns the format of the server log. It can only be changed through a config file.
+func (cfg *commandLineServerConfig) LogFormat() LogFormatType {
+	return defaultLogFormat
+}
+
+// RedactLoggedQueries is true if the literals of logged queries are redacted. It can only be enabled through a config
+// file.
+func (cfg *commandLineServerConfig) RedactLoggedQueries() bool {
+	return false
+}
+
+// SlowQueryLog returns the configuration of the slow query log. It can only be enabled through a config file.
+func (cfg *commandLineServerConfig) SlowQueryLog() SlowQueryLogConfig {
+	return SlowQueryLogConfig{}
+}
+
+// ClusterConfig returns the cluster configuration of the server. A server can only be part of a cluster through a
+// config file.
+func (cfg *commandLineServerConfig) ClusterConfig() cluster.Config {
+	return nil
+}
+
+// RemotesapiPort returns the port of the remotesapi endpoint of the server. It can only be served when it's
+// configured in a config file.
+func (cfg *commandLineServerConfig) RemotesapiPort() *int {
+	return nil
+}
+
+// StorageAllowlist returns the storage urls under which databases may be created with dolt_create_database. It can
+// only be configured in a config file, so no such databases may be created otherwise.
+func (cfg *commandLineServerConfig) StorageAllowlist() []string {
+	return nil
+}
+
 // WithHost updates the host and returns the called `*commandLineServerConfig`, which is useful for chaining calls.
 func (cfg *commandLineServerConfig) WithHost(host string) *commandLineServerConfig {
 	cfg.host = host
@@ -389,6 +503,13 @@ func (cfg *commandLineServerConfig) withPrivilegeFilePath(privFilePath string) *
 	return cfg
 }
 
+// WithBranchControlFilePath updates the path to the file which contains the branch control permissions. An empty
+// path keeps the permissions in memory only.
+func (cfg *commandLineServerConfig) WithBranchControlFilePath(branchControlFilePath string) *commandLineServerConfig {
+	cfg.branchControlFilePath = branchControlFilePath
+	return cfg
+}
+
 func (cfg *commandLineServerConfig) withAllowCleartextPasswords(allow bool) *commandLineServerConfig {
 	cfg.allowCleartextPasswords = allow
 	return cfg
@@ -416,6 +537,7 @@ func DefaultServerConfig() *commandLineServerConfig {
 		dataDir:                 defaultDataDir,
 		cfgDir:                  filepath.Join(defaultDataDir, defaultCfgDir),
 		privilegeFilePath:       filepath.Join(defaultDataDir, defaultCfgDir, defaultPrivilegeFilePath),
+		branchControlFilePath:   filepath.Join(defaultDataDir, defaultCfgDir, defaultBranchControlFilePath),
 		allowCleartextPasswords: defaultAllowCleartextPasswords,
 	}
 }
@@ -434,9 +556,64 @@ func ValidateConfig(config ServerConfig) error {
 	if config.LogLevel().String() == "unknown" {
 		return fmt.Errorf("loglevel is invalid: %v\n", string(config.LogLevel()))
 	}
+	if !config.LogFormat().IsValid() {
+		return fmt.Errorf("log_format is invalid, must be one of text or json: %v", string(config.LogFormat()))
+	}
+	if slowLog := config.SlowQueryLog(); slowLog.Path != "" && (slowLog.SampleRate <= 0 || slowLog.SampleRate > 1) {
+		return fmt.Errorf("slow_query_log sample_rate must be greater than 0 and at most 1: %v", slowLog.SampleRate)
+	}
 	if config.RequireSecureTransport() && config.TLSCert() == "" && config.TLSKey() == "" {
 		return fmt.Errorf("require_secure_transport can only be `true` when a tls_key and tls_cert are provided.")
 	}
+	if autoGC := config.AutoGC(); autoGC.Enabled && autoGC.CheckIntervalMillis == 0 {
+		return fmt.Errorf("auto_gc check_interval_millis must be greater than 0")
+	}
+	if port := config.RemotesapiPort(); port != nil && (*port < 1 || *port > 65535) {
+		return fmt.Errorf("remotesapi port is not in the range between 1-65535: %v", *port)
+	}
+	if clusterConfig := config.ClusterConfig(); clusterConfig != nil {
+		return ValidateClusterConfig(clusterConfig)
+	}
+	return nil
+}
+
+// ValidateClusterConfig returns an error if the cluster configuration given is not valid.
+func ValidateClusterConfig(config cluster.Config) error {
+	remotes := config.StandbyRemotes()
+	if len(remotes) == 0 {
+		return fmt.Errorf("cluster config: must supply standby_remotes")
+	}
+	names := make(map[string]bool)
+	for i, r := range remotes {
+		if r.Name() == "" {
+			return fmt.Errorf("cluster config: standby_remotes[%d]: name is required", i)
+		}
+		if names[r.Name()] {
+			return fmt.Errorf("cluster config: standby_remotes[%d]: duplicate name %s", i, r.Name())
+		}
+		names[r.Name()] = true
+		if !strings.Contains(r.RemoteURLTemplate(), cluster.DatabaseTemplate) {
+			return fmt.Errorf("cluster config: standby_remotes[%d]: remote_url_template must include %s, got %s", i, cluster.DatabaseTemplate, r.RemoteURLTemplate())
+		}
+	}
+	if config.BootstrapRole() != string(cluster.RolePrimary) && config.BootstrapRole() != string(cluster.RoleStandby) {
+		return fmt.Errorf("cluster config: bootstrap_role must be primary or standby, got %s", config.BootstrapRole())
+	}
+	if config.BootstrapEpoch() < 0 {
+		return fmt.Errorf("cluster config: bootstrap_epoch must be 0 or greater, got %d", config.BootstrapEpoch())
+	}
+	if config.ReplicationAck() != cluster.ReplicationAckSync && config.ReplicationAck() != cluster.ReplicationAckAsync {
+		return fmt.Errorf("cluster config: replication_ack must be sync or async, got %s", config.ReplicationAck())
+	}
+	if config.ReplicationAckTimeout() <= 0 {
+		return fmt.Errorf("cluster config: replication_ack_timeout_millis must be greater than 0")
+	}
+	if port := config.RemotesAPIConfig().Port(); port < 1 || port > 65535 {
+		return fmt.Errorf("cluster config: remotesapi port must be between 1 and 65535, got %d", port)
+	}
+	if config.RemotesAPIConfig().SharedSecret() == "" {
+		return fmt.Errorf("cluster config: remotesapi shared_secret is required")
+	}
 	return nil
 }
 
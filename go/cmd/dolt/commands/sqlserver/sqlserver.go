@@ -74,6 +74,16 @@ SUPPORTED CONFIG FILE FIELDS:
 
 {{.EmphasisLeft}}vlog_level{{.EmphasisRight}}: Level of logging provided. Options are: {{.EmphasisLeft}}trace{{.EmphasisRight}}, {{.EmphasisLeft}}debug{{.EmphasisRight}}, {{.EmphasisLeft}}info{{.EmphasisRight}}, {{.EmphasisLeft}}warning{{.EmphasisRight}}, {{.EmphasisLeft}}error{{.EmphasisRight}}, and {{.EmphasisLeft}}fatal{{.EmphasisRight}}.
 
+{{.EmphasisLeft}}log_format{{.EmphasisRight}}: Format of the server log. Options are {{.EmphasisLeft}}text{{.EmphasisRight}} and {{.EmphasisLeft}}json{{.EmphasisRight}}. In the json format, a record of every query with its connection, user, client address, database, branch, duration, rows returned and status is logged as well.
+
+{{.EmphasisLeft}}log_redact_literals{{.EmphasisRight}}: If true the literals of the queries written to the server log and the slow query log are replaced with placeholders.
+
+{{.EmphasisLeft}}slow_query_log.path{{.EmphasisRight}}: The file that queries running longer than the threshold are appended to, one line of JSON per query.
+
+{{.EmphasisLeft}}slow_query_log.threshold_millis{{.EmphasisRight}}: The duration in milliseconds at or above which a query is written to the slow query log.
+
+{{.EmphasisLeft}}slow_query_log.sample_rate{{.EmphasisRight}}: The fraction of the slow queries that are written to the slow query log. Defaults to 1.
+
 {{.EmphasisLeft}}behavior.read_only{{.EmphasisRight}}: If true database modification is disabled
 
 {{.EmphasisLeft}}behavior.autocommit{{.EmphasisRight}}: If true write queries will automatically alter the working set. When working with autocommit enabled it is highly recommended that listener.max_connections be set to 1 as concurrency issues will arise otherwise
@@ -100,6 +110,12 @@ SUPPORTED CONFIG FILE FIELDS:
 
 {{.EmphasisLeft}}databases[i].name{{.EmphasisRight}}: The name that the database corresponding to the given path should be referenced via SQL
 
+{{.EmphasisLeft}}databases[i].storage{{.EmphasisRight}}: The url of an aws, gs or file storage to serve the database from without cloning it. The path then only caches the data read from the storage, and defaults to the name of the database. Databases with a storage may also be created with {{.EmphasisLeft}}CALL dolt_create_database('name', 'url'){{.EmphasisRight}} by users with the global CREATE privilege, if the url is within the {{.EmphasisLeft}}storage_allowlist{{.EmphasisRight}}
+
+{{.EmphasisLeft}}databases[i].storage_params{{.EmphasisRight}}: A map of the parameters used to load the storage, such as {{.EmphasisLeft}}aws-region{{.EmphasisRight}}, {{.EmphasisLeft}}aws-creds-type{{.EmphasisRight}}, {{.EmphasisLeft}}aws-creds-file{{.EmphasisRight}} and {{.EmphasisLeft}}aws-creds-profile{{.EmphasisRight}}, and {{.EmphasisLeft}}chunk-cache-size{{.EmphasisRight}}, the size in bytes that the cache in the path may grow to, which defaults to 1GB
+
+{{.EmphasisLeft}}storage_allowlist{{.EmphasisRight}}: A list of the storage urls under which databases may be created with {{.EmphasisLeft}}CALL dolt_create_database('name', 'url'){{.EmphasisRight}}. A url is allowed if it has the same scheme and host as an entry, and its path is within the entry's path. If it's missing or empty, no databases may be created with a storage
+
 If a config file is not provided many of these settings may be configured on the command line.`,
 	Synopsis: []string{
 		"--config {{.LessThan}}file{{.GreaterThan}}",
@@ -146,6 +162,7 @@ func (cmd SqlServerCmd) ArgParser() *argparser.ArgParser {
 	ap.SupportsInt(maxConnectionsFlag, "", "max-connections", fmt.Sprintf("Set the number of connections handled by the server. Defaults to `%d`.", serverConfig.MaxConnections()))
 	ap.SupportsString(persistenceBehaviorFlag, "", "persistence-behavior", fmt.Sprintf("Indicate whether to `load` or `ignore` persisted global variables. Defaults to `%s`.", serverConfig.PersistenceBehavior()))
 	ap.SupportsString(commands.PrivsFilePathFlag, "", "privilege file", "Path to a file to load and store users and grants. Defaults to `$doltcfg-dir/privileges.db`. Will only be created if there is a change to privileges.")
+	ap.SupportsString(commands.BranchCtrlFlag, "", "branch control file", "Path to a file to load and store branch control permissions. Defaults to `$doltcfg-dir/branch_control.db`. Will only be created if there is a change to branch control permissions.")
 	ap.SupportsString(allowCleartextPasswordsFlag, "", "allow-cleartext-passwords", "Allows use of cleartext passwords. Defaults to false.")
 	ap.SupportsString(socketFlag, "", "socket file", "Path for the unix socket file. Defaults to '/tmp/mysql.sock'.")
 	return ap
@@ -294,6 +311,16 @@ func SetupDoltConfig(dEnv *env.DoltEnv, apr *argparser.ArgParseResults, config S
 		serverConfig.withPrivilegeFilePath(path)
 	}
 
+	if branchControlFilePath, ok := apr.GetValue(commands.BranchCtrlFlag); ok {
+		serverConfig.WithBranchControlFilePath(branchControlFilePath)
+	} else {
+		path, err := dEnv.FS.Abs(filepath.Join(cfgDirPath, commands.DefaultBranchCtrl))
+		if err != nil {
+			return err
+		}
+		serverConfig.WithBranchControlFilePath(path)
+	}
+
 	return nil
 }
 
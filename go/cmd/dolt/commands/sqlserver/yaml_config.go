@@ -17,6 +17,7 @@ package sqlserver
 import (
 	"path/filepath"
 	"strings"
+	"time"
 	"unicode"
 	"unicode/utf8"
 
@@ -24,6 +25,7 @@ import (
 
 	"github.com/dolthub/dolt/go/cmd/dolt/commands/engine"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/cluster"
 )
 
 func strPtr(s string) *string {
@@ -69,6 +71,106 @@ type BehaviorYAMLConfig struct {
 	// (such as a CREATE TRIGGER), then those incoming queries will be
 	// misprocessed.
 	DisableClientMultiStatements *bool `yaml:"disable_client_multi_statements"`
+	// AutoGC configures garbage collection that the server runs on its own.
+	AutoGC *AutoGCYAMLConfig `yaml:"auto_gc,omitempty"`
+}
+
+// AutoGCYAMLConfig contains the thresholds at which the server collects the garbage of a database on its own
+type AutoGCYAMLConfig struct {
+	Enable              *bool   `yaml:"enable"`
+	CheckIntervalMillis *uint64 `yaml:"check_interval_millis"`
+	// TableFileThreshold is the number of table files written since the last collection that triggers a collection.
+	TableFileThreshold *int `yaml:"table_file_threshold"`
+	// NewDataThresholdMB is the size in megabytes of the table files written since the last collection that triggers
+	// a collection.
+	NewDataThresholdMB *uint64 `yaml:"new_data_threshold_mb"`
+}
+
+// SlowQueryLogYAMLConfig contains the path of the slow query log, and the queries that are written to it
+type SlowQueryLogYAMLConfig struct {
+	Path            *string `yaml:"path"`
+	ThresholdMillis *uint64 `yaml:"threshold_millis"`
+	// SampleRate is the fraction of the slow queries that are written to the log.
+	SampleRate *float64 `yaml:"sample_rate"`
+}
+
+// ClusterYAMLConfig names the other servers of the cluster this server is part of, and the role it starts in. Its
+// fields are suffixed with underscores, since the methods implementing cluster.Config have their names.
+type ClusterYAMLConfig struct {
+	StandbyRemotes_ []StandbyRemoteYAMLConfig `yaml:"standby_remotes"`
+	BootstrapRole_  string                    `yaml:"bootstrap_role"`
+	BootstrapEpoch_ int                       `yaml:"bootstrap_epoch"`
+	// ReplicationAck_ is "sync" if writes wait for the standbys to acknowledge them, and "async" if they don't.
+	ReplicationAck_ *string `yaml:"replication_ack"`
+	// ReplicationAckTimeoutMillis_ is how long a write waits for the standbys to acknowledge it. A write that isn't
+	// acknowledged in time is still committed, and the session that wrote it gets a warning.
+	ReplicationAckTimeoutMillis_ *uint64                     `yaml:"replication_ack_timeout_millis"`
+	RemotesAPI                   ClusterRemotesAPIYAMLConfig `yaml:"remotesapi"`
+}
+
+var _ cluster.Config = (*ClusterYAMLConfig)(nil)
+
+func (c *ClusterYAMLConfig) StandbyRemotes() []cluster.StandbyRemoteConfig {
+	ret := make([]cluster.StandbyRemoteConfig, len(c.StandbyRemotes_))
+	for i := range c.StandbyRemotes_ {
+		ret[i] = c.StandbyRemotes_[i]
+	}
+	return ret
+}
+
+func (c *ClusterYAMLConfig) BootstrapRole() string {
+	return c.BootstrapRole_
+}
+
+func (c *ClusterYAMLConfig) BootstrapEpoch() int {
+	return c.BootstrapEpoch_
+}
+
+func (c *ClusterYAMLConfig) ReplicationAck() string {
+	if c.ReplicationAck_ == nil {
+		return defaultClusterReplicationAck
+	}
+	return *c.ReplicationAck_
+}
+
+func (c *ClusterYAMLConfig) ReplicationAckTimeout() time.Duration {
+	if c.ReplicationAckTimeoutMillis_ == nil {
+		return defaultClusterReplicationAckTimeout * time.Millisecond
+	}
+	return time.Duration(*c.ReplicationAckTimeoutMillis_) * time.Millisecond
+}
+
+func (c *ClusterYAMLConfig) RemotesAPIConfig() cluster.RemotesAPIConfig {
+	return c.RemotesAPI
+}
+
+// StandbyRemoteYAMLConfig names another server of the cluster, and the url of its remotesapi endpoint
+type StandbyRemoteYAMLConfig struct {
+	Name_              string `yaml:"name"`
+	RemoteURLTemplate_ string `yaml:"remote_url_template"`
+}
+
+func (c StandbyRemoteYAMLConfig) Name() string {
+	return c.Name_
+}
+
+func (c StandbyRemoteYAMLConfig) RemoteURLTemplate() string {
+	return c.RemoteURLTemplate_
+}
+
+// ClusterRemotesAPIYAMLConfig configures the remotesapi endpoint the primary of the cluster replicates to
+type ClusterRemotesAPIYAMLConfig struct {
+	Port_ int `yaml:"port"`
+	// SharedSecret_ is the secret every server of the cluster is configured with, and authenticates its peers with
+	SharedSecret_ string `yaml:"shared_secret"`
+}
+
+func (c ClusterRemotesAPIYAMLConfig) Port() int {
+	return c.Port_
+}
+
+func (c ClusterRemotesAPIYAMLConfig) SharedSecret() string {
+	return c.SharedSecret_
 }
 
 // UserYAMLConfig contains server configuration regarding the user account clients must use to connect
@@ -81,6 +183,11 @@ type UserYAMLConfig struct {
 type DatabaseYAMLConfig struct {
 	Name string
 	Path string
+	// Storage is the url of the aws, gs or file storage holding the data of the database, for a database served
+	// straight from a remote storage without cloning it. Its path then holds a cache of the chunks read from it.
+	Storage string `yaml:"storage,omitempty"`
+	// StorageParams are the parameters with which Storage is loaded, such as the aws-region of an aws storage
+	StorageParams map[string]string `yaml:"storage_params,omitempty"`
 }
 
 // ListenerYAMLConfig contains information on the network connection that the server will open
@@ -113,6 +220,11 @@ type MetricsYAMLConfig struct {
 	Port   *int              `yaml:"port"`
 }
 
+// RemotesapiYAMLConfig configures the remotesapi endpoint that serves the databases of the server as remotes
+type RemotesapiYAMLConfig struct {
+	Port_ *int `yaml:"port,omitempty"`
+}
+
 type UserSessionVars struct {
 	Name string            `yaml:"name"`
 	Vars map[string]string `yaml:"vars"`
@@ -120,18 +232,25 @@ type UserSessionVars struct {
 
 // YAMLConfig is a ServerConfig implementation which is read from a yaml file
 type YAMLConfig struct {
-	LogLevelStr       *string               `yaml:"log_level"`
-	BehaviorConfig    BehaviorYAMLConfig    `yaml:"behavior"`
-	UserConfig        UserYAMLConfig        `yaml:"user"`
-	ListenerConfig    ListenerYAMLConfig    `yaml:"listener"`
-	DatabaseConfig    []DatabaseYAMLConfig  `yaml:"databases"`
-	PerformanceConfig PerformanceYAMLConfig `yaml:"performance"`
-	DataDirStr        *string               `yaml:"data_dir"`
-	CfgDirStr         *string               `yaml:"cfg_dir"`
-	MetricsConfig     MetricsYAMLConfig     `yaml:"metrics"`
-	PrivilegeFile     *string               `yaml:"privilege_file"`
-	Vars              []UserSessionVars     `yaml:"user_session_vars"`
-	Jwks              []engine.JwksConfig   `yaml:"jwks"`
+	LogLevelStr         *string                 `yaml:"log_level"`
+	LogFormatStr        *string                 `yaml:"log_format,omitempty"`
+	LogRedactLiterals   *bool                   `yaml:"log_redact_literals,omitempty"`
+	SlowQueryLogCfg     *SlowQueryLogYAMLConfig `yaml:"slow_query_log,omitempty"`
+	BehaviorConfig      BehaviorYAMLConfig      `yaml:"behavior"`
+	UserConfig          UserYAMLConfig          `yaml:"user"`
+	ListenerConfig      ListenerYAMLConfig      `yaml:"listener"`
+	DatabaseConfig      []DatabaseYAMLConfig    `yaml:"databases"`
+	PerformanceConfig   PerformanceYAMLConfig   `yaml:"performance"`
+	DataDirStr          *string                 `yaml:"data_dir"`
+	CfgDirStr           *string                 `yaml:"cfg_dir"`
+	MetricsConfig       MetricsYAMLConfig       `yaml:"metrics"`
+	PrivilegeFile       *string                 `yaml:"privilege_file"`
+	BranchControlFile   *string                 `yaml:"branch_control_file"`
+	Vars                []UserSessionVars       `yaml:"user_session_vars"`
+	Jwks                []engine.JwksConfig     `yaml:"jwks"`
+	ClusterCfg          *ClusterYAMLConfig      `yaml:"cluster,omitempty"`
+	RemotesapiConfig    RemotesapiYAMLConfig    `yaml:"remotesapi,omitempty"`
+	StorageAllowlistCfg []string                `yaml:"storage_allowlist,omitempty"`
 }
 
 var _ ServerConfig = YAMLConfig{}
@@ -150,6 +269,7 @@ func serverConfigAsYAMLConfig(cfg ServerConfig) YAMLConfig {
 			boolPtr(cfg.AutoCommit()),
 			strPtr(cfg.PersistenceBehavior()),
 			boolPtr(cfg.DisableClientMultiStatements()),
+			nil,
 		},
 		UserConfig: UserYAMLConfig{strPtr(cfg.User()), strPtr(cfg.Password())},
 		ListenerConfig: ListenerYAMLConfig{
@@ -287,7 +407,17 @@ func (cfg YAMLConfig) LogLevel() LogLevel {
 func (cfg YAMLConfig) DatabaseNamesAndPaths() []env.EnvNameAndPath {
 	var dbNamesAndPaths []env.EnvNameAndPath
 	for _, dbConfig := range cfg.DatabaseConfig {
-		dbNamesAndPaths = append(dbNamesAndPaths, env.EnvNameAndPath{Name: dbConfig.Name, Path: dbConfig.Path})
+		path := dbConfig.Path
+		if path == "" && dbConfig.Storage != "" {
+			path = dbConfig.Name
+		}
+
+		dbNamesAndPaths = append(dbNamesAndPaths, env.EnvNameAndPath{
+			Name:          dbConfig.Name,
+			Path:          path,
+			Storage:       dbConfig.Storage,
+			StorageParams: dbConfig.StorageParams,
+		})
 	}
 
 	return dbNamesAndPaths
@@ -343,6 +473,14 @@ func (cfg YAMLConfig) PrivilegeFilePath() string {
 	return filepath.Join(cfg.CfgDir(), defaultPrivilegeFilePath)
 }
 
+// BranchControlFilePath returns the path to the file which contains the branch control permissions.
+func (cfg YAMLConfig) BranchControlFilePath() string {
+	if cfg.BranchControlFile != nil {
+		return *cfg.BranchControlFile
+	}
+	return filepath.Join(cfg.CfgDir(), defaultBranchControlFilePath)
+}
+
 // UserVars is an array containing user specific session variables
 func (cfg YAMLConfig) UserVars() []UserSessionVars {
 	if cfg.Vars != nil {
@@ -435,3 +573,83 @@ func (cfg YAMLConfig) Socket() string {
 	}
 	return *cfg.ListenerConfig.Socket
 }
+
+// LogFormat returns the format of the server log.
+func (cfg YAMLConfig) LogFormat() LogFormatType {
+	if cfg.LogFormatStr == nil {
+		return defaultLogFormat
+	}
+
+	return LogFormatType(*cfg.LogFormatStr)
+}
+
+// RedactLoggedQueries is true if the literals of the queries written to the server and slow query logs should be
+// replaced with placeholders.
+func (cfg YAMLConfig) RedactLoggedQueries() bool {
+	return cfg.LogRedactLiterals != nil && *cfg.LogRedactLiterals
+}
+
+// SlowQueryLog returns the configuration of the log of the queries that run longer than a threshold
+func (cfg YAMLConfig) SlowQueryLog() SlowQueryLogConfig {
+	slowLog := cfg.SlowQueryLogCfg
+	if slowLog == nil || slowLog.Path == nil {
+		return SlowQueryLogConfig{}
+	}
+
+	result := SlowQueryLogConfig{
+		Path:       *slowLog.Path,
+		SampleRate: defaultSlowQuerySampleRate,
+	}
+	if slowLog.ThresholdMillis != nil {
+		result.ThresholdMillis = *slowLog.ThresholdMillis
+	}
+	if slowLog.SampleRate != nil {
+		result.SampleRate = *slowLog.SampleRate
+	}
+
+	return result
+}
+
+// AutoGC returns the configuration of the garbage collection the server runs on its own
+func (cfg YAMLConfig) AutoGC() AutoGCConfig {
+	autoGC := cfg.BehaviorConfig.AutoGC
+	if autoGC == nil {
+		return AutoGCConfig{}
+	}
+
+	result := AutoGCConfig{
+		Enabled:             autoGC.Enable != nil && *autoGC.Enable,
+		CheckIntervalMillis: defaultAutoGCCheckInterval,
+		TableFileThreshold:  defaultAutoGCTableFiles,
+		NewDataThresholdMB:  defaultAutoGCNewDataMB,
+	}
+	if autoGC.CheckIntervalMillis != nil {
+		result.CheckIntervalMillis = *autoGC.CheckIntervalMillis
+	}
+	if autoGC.TableFileThreshold != nil {
+		result.TableFileThreshold = *autoGC.TableFileThreshold
+	}
+	if autoGC.NewDataThresholdMB != nil {
+		result.NewDataThresholdMB = *autoGC.NewDataThresholdMB
+	}
+
+	return result
+}
+
+// RemotesapiPort returns the port of the remotesapi endpoint of the server, nil if it doesn't serve one
+func (cfg YAMLConfig) RemotesapiPort() *int {
+	return cfg.RemotesapiConfig.Port_
+}
+
+// ClusterConfig returns the cluster configuration of the server, nil if it isn't part of a cluster
+func (cfg YAMLConfig) ClusterConfig() cluster.Config {
+	if cfg.ClusterCfg == nil {
+		return nil
+	}
+	return cfg.ClusterCfg
+}
+
+// StorageAllowlist returns the storage urls under which databases may be created with dolt_create_database
+func (cfg YAMLConfig) StorageAllowlist() []string {
+	return cfg.StorageAllowlistCfg
+}
@@ -16,6 +16,7 @@ package sqlserver
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,6 +51,14 @@ databases:
       path: ./datasets/irs-soi
     - name: noaa
       path: /Users/brian/datasets/noaa
+    - name: census
+      storage: aws://[dynamo_table:s3_bucket]/census
+      storage_params:
+          aws-region: us-west-2
+
+storage_allowlist:
+    - aws://[dynamo_table:s3_bucket]/
+    - file:///var/lib/dolt/storage
 
 data_dir: some nonsense
 
@@ -96,6 +105,13 @@ jwks:
 			Name: "noaa",
 			Path: "/Users/brian/datasets/noaa",
 		},
+		{
+			Name:    "census",
+			Storage: "aws://[dynamo_table:s3_bucket]/census",
+			StorageParams: map[string]string{
+				"aws-region": "us-west-2",
+			},
+		},
 	}
 	expected.MetricsConfig = MetricsYAMLConfig{
 		Host: strPtr("123.45.67.89"),
@@ -106,6 +122,7 @@ jwks:
 			"label3": "true",
 		},
 	}
+	expected.StorageAllowlistCfg = []string{"aws://[dynamo_table:s3_bucket]/", "file:///var/lib/dolt/storage"}
 	expected.DataDirStr = strPtr("some nonsense")
 	expected.Vars = []UserSessionVars{
 		{
@@ -204,6 +221,10 @@ func TestYAMLConfigDefaults(t *testing.T) {
 	assert.Equal(t, defaultMetricsPort, cfg.MetricsPort())
 	assert.Nil(t, cfg.MetricsConfig.Labels)
 	assert.Equal(t, defaultAllowCleartextPasswords, cfg.AllowCleartextPasswords())
+	assert.False(t, cfg.AutoGC().Enabled)
+	assert.Equal(t, defaultLogFormat, cfg.LogFormat())
+	assert.False(t, cfg.RedactLoggedQueries())
+	assert.Equal(t, SlowQueryLogConfig{}, cfg.SlowQueryLog())
 
 	c, err := LoadTLSConfig(cfg)
 	assert.NoError(t, err)
@@ -275,3 +296,189 @@ listener:
 	err = ValidateConfig(cfg)
 	assert.Error(t, err)
 }
+
+func TestYAMLConfigAutoGC(t *testing.T) {
+	var cfg YAMLConfig
+	err := yaml.Unmarshal([]byte(`
+behavior:
+  auto_gc:
+    enable: true
+`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, AutoGCConfig{
+		Enabled:             true,
+		CheckIntervalMillis: defaultAutoGCCheckInterval,
+		TableFileThreshold:  defaultAutoGCTableFiles,
+		NewDataThresholdMB:  defaultAutoGCNewDataMB,
+	}, cfg.AutoGC())
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+behavior:
+  auto_gc:
+    enable: true
+    check_interval_millis: 1000
+    table_file_threshold: 0
+    new_data_threshold_mb: 64
+`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, AutoGCConfig{
+		Enabled:             true,
+		CheckIntervalMillis: 1000,
+		TableFileThreshold:  0,
+		NewDataThresholdMB:  64,
+	}, cfg.AutoGC())
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+behavior:
+  auto_gc:
+    enable: true
+    check_interval_millis: 0
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestYAMLConfigQueryLogs(t *testing.T) {
+	var cfg YAMLConfig
+	err := yaml.Unmarshal([]byte(`
+log_format: json
+log_redact_literals: true
+slow_query_log:
+  path: slow.log
+`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, LogFormat_JSON, cfg.LogFormat())
+	assert.True(t, cfg.RedactLoggedQueries())
+	assert.Equal(t, SlowQueryLogConfig{
+		Path:       "slow.log",
+		SampleRate: defaultSlowQuerySampleRate,
+	}, cfg.SlowQueryLog())
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+slow_query_log:
+  path: slow.log
+  threshold_millis: 500
+  sample_rate: 0.25
+`), &cfg)
+	require.NoError(t, err)
+	assert.Equal(t, SlowQueryLogConfig{
+		Path:            "slow.log",
+		ThresholdMillis: 500,
+		SampleRate:      0.25,
+	}, cfg.SlowQueryLog())
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+slow_query_log:
+  path: slow.log
+  sample_rate: 0
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+log_format: xml
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestYAMLConfigCluster(t *testing.T) {
+	cfg := YAMLConfig{}
+	err := yaml.Unmarshal([]byte(`
+cluster:
+  standby_remotes:
+  - name: standby
+    remote_url_template: http://localhost:50052/{database}
+  bootstrap_role: primary
+  bootstrap_epoch: 1
+  remotesapi:
+    port: 50051
+    shared_secret: s3cr3t
+`), &cfg)
+	require.NoError(t, err)
+	clusterCfg := cfg.ClusterConfig()
+	require.NotNil(t, clusterCfg)
+	require.Len(t, clusterCfg.StandbyRemotes(), 1)
+	assert.Equal(t, "standby", clusterCfg.StandbyRemotes()[0].Name())
+	assert.Equal(t, "http://localhost:50052/{database}", clusterCfg.StandbyRemotes()[0].RemoteURLTemplate())
+	assert.Equal(t, "primary", clusterCfg.BootstrapRole())
+	assert.Equal(t, 1, clusterCfg.BootstrapEpoch())
+	assert.Equal(t, defaultClusterReplicationAck, clusterCfg.ReplicationAck())
+	assert.Equal(t, defaultClusterReplicationAckTimeout*time.Millisecond, clusterCfg.ReplicationAckTimeout())
+	assert.Equal(t, 50051, clusterCfg.RemotesAPIConfig().Port())
+	assert.Equal(t, "s3cr3t", clusterCfg.RemotesAPIConfig().SharedSecret())
+	assert.NoError(t, ValidateConfig(cfg))
+
+	assert.Nil(t, YAMLConfig{}.ClusterConfig())
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+cluster:
+  standby_remotes:
+  - name: standby
+    remote_url_template: http://localhost:50052/db
+  bootstrap_role: primary
+  remotesapi:
+    port: 50051
+    shared_secret: s3cr3t
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+cluster:
+  standby_remotes:
+  - name: standby
+    remote_url_template: http://localhost:50052/{database}
+  bootstrap_role: leader
+  remotesapi:
+    port: 50051
+    shared_secret: s3cr3t
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+cluster:
+  standby_remotes:
+  - name: standby
+    remote_url_template: http://localhost:50052/{database}
+  bootstrap_role: primary
+  remotesapi:
+    port: 50051
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestYAMLConfigRemotesapi(t *testing.T) {
+	cfg := YAMLConfig{}
+	err := yaml.Unmarshal([]byte(`
+remotesapi:
+  port: 50051
+`), &cfg)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.RemotesapiPort())
+	assert.Equal(t, 50051, *cfg.RemotesapiPort())
+	assert.NoError(t, ValidateConfig(cfg))
+
+	assert.Nil(t, YAMLConfig{}.RemotesapiPort())
+
+	cfg = YAMLConfig{}
+	err = yaml.Unmarshal([]byte(`
+remotesapi:
+  port: 0
+`), &cfg)
+	require.NoError(t, err)
+	assert.Error(t, ValidateConfig(cfg))
+}
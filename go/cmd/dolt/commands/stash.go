@@ -0,0 +1,316 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env/actions"
+	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+	"github.com/dolthub/dolt/go/store/datas"
+)
+
+var stashDocs = cli.CommandDocumentationContent{
+	ShortDesc: "Stash the changes in a dirty working set away",
+	LongDesc: `Use {{.EmphasisLeft}}dolt stash{{.EmphasisRight}} when you want to record the current state of the working set and the staged tables, but want to go back to a clean working set. The command saves your local modifications away and reverts the working set to match the HEAD commit.
+
+The modifications stashed away by this command can be listed with {{.EmphasisLeft}}dolt stash list{{.EmphasisRight}}, and restored (potentially on top of a different commit) with {{.EmphasisLeft}}dolt stash apply{{.EmphasisRight}}. Calling {{.EmphasisLeft}}dolt stash{{.EmphasisRight}} without any arguments is equivalent to {{.EmphasisLeft}}dolt stash push{{.EmphasisRight}}.
+
+The latest stash you created is stored as {{.EmphasisLeft}}stash@{0}{{.EmphasisRight}}; the one before it is {{.EmphasisLeft}}stash@{1}{{.EmphasisRight}}, and so on.
+
+{{.EmphasisLeft}}push{{.EmphasisRight}}
+Save your local modifications to a new stash entry and roll them back to HEAD. Untracked tables are left in the working set unless {{.EmphasisLeft}}--include-untracked{{.EmphasisRight}} is given.
+
+{{.EmphasisLeft}}list{{.EmphasisRight}}
+List the stash entries that you currently have.
+
+{{.EmphasisLeft}}pop{{.EmphasisRight}}
+Remove a single stash entry from the stash list and apply it on top of the current working set. Applying the stash is done by way of a three-way merge using the commit the stash was created on as the merge base. If the merge results in conflicts, the conflicts are recorded in the working set as usual and the stash entry is not removed.
+
+{{.EmphasisLeft}}apply{{.EmphasisRight}}
+Like {{.EmphasisLeft}}pop{{.EmphasisRight}}, but do not remove the stash entry from the stash list.
+
+{{.EmphasisLeft}}drop{{.EmphasisRight}}
+Remove a single stash entry from the list of stash entries.
+
+{{.EmphasisLeft}}clear{{.EmphasisRight}}
+Remove all the stash entries.`,
+	Synopsis: []string{
+		"[push] [-u | --include-untracked] [-m {{.LessThan}}message{{.GreaterThan}}]",
+		"list",
+		"pop [{{.LessThan}}stash{{.GreaterThan}}]",
+		"apply [{{.LessThan}}stash{{.GreaterThan}}]",
+		"drop [{{.LessThan}}stash{{.GreaterThan}}]",
+		"clear",
+	},
+}
+
+const (
+	stashPushId  = "push"
+	stashListId  = "list"
+	stashPopId   = "pop"
+	stashApplyId = "apply"
+	stashDropId  = "drop"
+	stashClearId = "clear"
+)
+
+type StashCmd struct{}
+
+// Name returns the name of the Dolt cli command. This is what is used on the command line to invoke the command
+func (cmd StashCmd) Name() string {
+	return "stash"
+}
+
+// Description returns a description of the command
+func (cmd StashCmd) Description() string {
+	return "Stash the changes in a dirty working set away."
+}
+
+func (cmd StashCmd) Docs() *cli.CommandDocumentation {
+	ap := cli.CreateStashArgParser()
+	return cli.NewCommandDocumentation(stashDocs, ap)
+}
+
+func (cmd StashCmd) ArgParser() *argparser.ArgParser {
+	return cli.CreateStashArgParser()
+}
+
+// Exec executes the command
+func (cmd StashCmd) Exec(ctx context.Context, commandStr string, args []string, dEnv *env.DoltEnv) int {
+	ap := cli.CreateStashArgParser()
+	help, usage := cli.HelpAndUsagePrinters(cli.CommandDocsForCommandString(commandStr, stashDocs, ap))
+	apr := cli.ParseArgsOrDie(ap, args, help)
+
+	if dEnv.IsLocked() {
+		return HandleVErrAndExitCode(errhand.VerboseErrorFromError(env.ErrActiveServerLock.New(dEnv.LockFile())), help)
+	}
+
+	subcommand := stashPushId
+	if apr.NArg() > 0 {
+		subcommand = apr.Arg(0)
+	}
+
+	var verr errhand.VerboseError
+	switch subcommand {
+	case stashPushId:
+		if apr.NArg() > 1 {
+			verr = errhand.BuildDError("error: stash push does not accept arguments").SetPrintUsage().Build()
+			break
+		}
+		verr = stashPush(ctx, dEnv, apr)
+	case stashListId:
+		verr = stashList(ctx, dEnv)
+	case stashPopId, stashApplyId:
+		verr = stashApply(ctx, dEnv, apr, subcommand == stashPopId)
+	case stashDropId:
+		verr = stashDrop(ctx, dEnv, apr)
+	case stashClearId:
+		verr = stashClear(ctx, dEnv)
+	default:
+		verr = errhand.BuildDError("error: unknown subcommand '%s'", subcommand).SetPrintUsage().Build()
+	}
+
+	return HandleVErrAndExitCode(verr, usage)
+}
+
+func stashPush(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	// This command creates a commit, so we need user identity
+	if !cli.CheckUserNameAndEmail(dEnv) {
+		return errhand.BuildDError("").Build()
+	}
+	name, email, err := env.GetNameAndEmail(dEnv.Config)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	if mergeActive, err := dEnv.IsMergeActive(ctx); err != nil {
+		return errhand.VerboseErrorFromError(err)
+	} else if mergeActive {
+		return errhand.BuildDError("error: cannot stash changes while a merge is in progress").Build()
+	}
+
+	roots, err := dEnv.Roots(ctx)
+	if err != nil {
+		return errhand.BuildDError("Couldn't get working root").AddCause(err).Build()
+	}
+	headCommit, err := dEnv.HeadCommit(ctx)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	branchName := dEnv.RepoStateReader().CWBHeadRef().GetPath()
+	desc, err := actions.StashDescription(ctx, branchName, headCommit, apr.GetValueOrDefault(cli.MessageArg, ""))
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+	meta, err := datas.NewCommitMeta(name, email, desc)
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+
+	_, roots, err = actions.StashChanges(ctx, dEnv.DoltDB, roots, headCommit, apr.Contains(cli.UntrackedFlag), meta)
+	if err == actions.ErrNoLocalChangesToStash {
+		cli.Println("No local changes to save")
+		return nil
+	} else if err != nil {
+		return errhand.BuildDError("error: failed to stash changes").AddCause(err).Build()
+	}
+
+	err = dEnv.UpdateRoots(ctx, roots)
+	if err != nil {
+		return errhand.BuildDError("error: failed to update the working set").AddCause(err).Build()
+	}
+
+	cli.Printf("Saved working directory and index state %s\n", desc)
+	return nil
+}
+
+func stashList(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	stashes, err := dEnv.DoltDB.GetStashes(ctx)
+	if err != nil {
+		return errhand.BuildDError("error: failed to read stash entries").AddCause(err).Build()
+	}
+
+	for i, stash := range stashes {
+		desc, err := stash.Description(ctx)
+		if err != nil {
+			return errhand.VerboseErrorFromError(err)
+		}
+		cli.Printf("%s: %s\n", doltdb.StashName(i), desc)
+	}
+
+	return nil
+}
+
+func stashApply(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults, pop bool) errhand.VerboseError {
+	stash, idx, verr := resolveStashArg(ctx, dEnv, apr)
+	if verr != nil {
+		return verr
+	}
+
+	if mergeActive, err := dEnv.IsMergeActive(ctx); err != nil {
+		return errhand.VerboseErrorFromError(err)
+	} else if mergeActive {
+		return errhand.BuildDError("error: cannot apply a stash while a merge is in progress").Build()
+	}
+
+	roots, err := dEnv.Roots(ctx)
+	if err != nil {
+		return errhand.BuildDError("Couldn't get working root").AddCause(err).Build()
+	}
+
+	opts := editor.Options{Deaf: dEnv.DbEaFactory(), Tempdir: dEnv.TempTableFilesDir()}
+	roots, tblToStats, err := merge.ApplyStash(ctx, roots, stash, opts)
+	if err != nil {
+		return errhand.BuildDError("error: failed to apply %s", doltdb.StashName(idx)).AddCause(err).Build()
+	}
+
+	err = dEnv.UpdateRoots(ctx, roots)
+	if err != nil {
+		return errhand.BuildDError("error: failed to update the working set").AddCause(err).Build()
+	}
+
+	var unmerged []string
+	for tblName, stats := range tblToStats {
+		if stats.Conflicts > 0 || stats.ConstraintViolations > 0 {
+			unmerged = append(unmerged, tblName)
+		}
+	}
+	if len(unmerged) > 0 {
+		sort.Strings(unmerged)
+		for _, tblName := range unmerged {
+			cli.Println("CONFLICT (content): Merge conflict in", tblName)
+		}
+		if pop {
+			cli.Println("The stash entry is kept in case you need it again.")
+		}
+		return nil
+	}
+
+	if pop {
+		err = dEnv.DoltDB.DropStash(ctx, stash)
+		if err != nil {
+			return errhand.BuildDError("error: failed to drop %s", doltdb.StashName(idx)).AddCause(err).Build()
+		}
+		h, err := stash.Commit.HashOf()
+		if err != nil {
+			return errhand.VerboseErrorFromError(err)
+		}
+		cli.Printf("Dropped %s (%s)\n", doltdb.StashName(idx), h.String())
+	}
+
+	return nil
+}
+
+func stashDrop(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) errhand.VerboseError {
+	stash, idx, verr := resolveStashArg(ctx, dEnv, apr)
+	if verr != nil {
+		return verr
+	}
+
+	err := dEnv.DoltDB.DropStash(ctx, stash)
+	if err != nil {
+		return errhand.BuildDError("error: failed to drop %s", doltdb.StashName(idx)).AddCause(err).Build()
+	}
+
+	h, err := stash.Commit.HashOf()
+	if err != nil {
+		return errhand.VerboseErrorFromError(err)
+	}
+	cli.Printf("Dropped %s (%s)\n", doltdb.StashName(idx), h.String())
+	return nil
+}
+
+func stashClear(ctx context.Context, dEnv *env.DoltEnv) errhand.VerboseError {
+	err := dEnv.DoltDB.ClearStashes(ctx)
+	if err != nil {
+		return errhand.BuildDError("error: failed to clear stash entries").AddCause(err).Build()
+	}
+	return nil
+}
+
+// resolveStashArg returns the stash entry named by the optional second positional argument, defaulting to the most
+// recent entry.
+func resolveStashArg(ctx context.Context, dEnv *env.DoltEnv, apr *argparser.ArgParseResults) (*doltdb.Stash, int, errhand.VerboseError) {
+	if apr.NArg() > 2 {
+		return nil, 0, errhand.BuildDError("error: too many arguments: %s", strings.Join(apr.Args[1:], " ")).SetPrintUsage().Build()
+	}
+
+	idx := 0
+	if apr.NArg() == 2 {
+		var err error
+		idx, err = doltdb.ParseStashName(apr.Arg(1))
+		if err != nil {
+			return nil, 0, errhand.BuildDError("error: %s", err.Error()).Build()
+		}
+	}
+
+	stash, err := dEnv.DoltDB.GetStash(ctx, idx)
+	if err == doltdb.ErrNoStashEntries {
+		return nil, 0, errhand.BuildDError("No stash entries found.").Build()
+	} else if err != nil {
+		return nil, 0, errhand.BuildDError("error: %s is not a valid reference", doltdb.StashName(idx)).AddCause(err).Build()
+	}
+
+	return stash, idx, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
 	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+	"github.com/dolthub/dolt/go/libraries/utils/set"
 )
 
 var statusDocs = cli.CommandDocumentationContent{
@@ -76,11 +77,27 @@ func (cmd StatusCmd) Exec(ctx context.Context, commandStr string, args []string,
 		return handleStatusVErr(err)
 	}
 
+	notStaged, err = diff.FilterIgnoredTableDeltas(ctx, roots, notStaged)
+	if err != nil {
+		return handleStatusVErr(err)
+	}
+
 	workingTblsInConflict, _, _, err := merge.GetTablesInConflict(ctx, roots)
 	if err != nil {
 		return handleStatusVErr(err)
 	}
 
+	// the tables whose schemas could not be merged are listed with the tables in conflict
+	ws, err := dEnv.WorkingSet(ctx)
+	if err != nil {
+		return handleStatusVErr(err)
+	}
+	if ws.MergeActive() && ws.MergeState().HasSchemaConflicts() {
+		tblSet := set.NewStrSet(workingTblsInConflict)
+		tblSet.Add(ws.MergeState().SchemaConflictTables()...)
+		workingTblsInConflict = tblSet.AsSortedSlice()
+	}
+
 	workingTblsWithViolations, _, _, err := merge.GetTablesWithConstraintViolations(ctx, roots)
 	if err != nil {
 		return handleStatusVErr(err)
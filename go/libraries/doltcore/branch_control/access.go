@@ -0,0 +1,119 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch_control
+
+import "strings"
+
+// Permissions are a set of flags that grant access to a branch.
+type Permissions uint64
+
+const (
+	// Permissions_Admin grants write access to a branch, along with the ability to edit the rows of the branch
+	// control tables that refer to it.
+	Permissions_Admin Permissions = 1 << iota
+	// Permissions_Write grants write access to a branch, which includes deleting and renaming it.
+	Permissions_Write
+	// Permissions_Read grants only read access to a branch. It is used to deny writes that less specific rows allow.
+	Permissions_Read
+)
+
+// PermissionNames are the names of each permission, in the order of their flags. These are the values of the SET
+// column of the dolt_branch_control table.
+var PermissionNames = []string{"admin", "write", "read"}
+
+// Has returns whether these permissions include all of the permissions given.
+func (p Permissions) Has(other Permissions) bool {
+	return p&other == other
+}
+
+// CanWrite returns whether these permissions allow writes to a branch, including deleting and renaming it.
+func (p Permissions) CanWrite() bool {
+	return p&(Permissions_Admin|Permissions_Write) != 0
+}
+
+// String returns the names of these permissions, separated by commas.
+func (p Permissions) String() string {
+	var names []string
+	for i, name := range PermissionNames {
+		if p&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Access is a single row of the dolt_branch_control table. The database, branch, user and host are all patterns, and
+// the permissions apply to every branch that matches all four of them.
+type Access struct {
+	Database    string      `json:"database"`
+	Branch      string      `json:"branch"`
+	User        string      `json:"user"`
+	Host        string      `json:"host"`
+	Permissions Permissions `json:"permissions"`
+}
+
+// Matches returns whether this row applies to the database, branch, user and host given. Users are matched
+// case-sensitively, while everything else is case-insensitive.
+func (a Access) Matches(database, branch, user, host string) bool {
+	return matchPattern(a.Database, database, true) &&
+		matchPattern(a.Branch, branch, true) &&
+		matchPattern(a.User, user, false) &&
+		matchHost(a.Host, host)
+}
+
+// SamePatterns returns whether this row has the same patterns as the row given, meaning that both rows have the
+// same primary key in the dolt_branch_control table.
+func (a Access) SamePatterns(other Access) bool {
+	return strings.EqualFold(a.Database, other.Database) &&
+		strings.EqualFold(a.Branch, other.Branch) &&
+		a.User == other.User &&
+		strings.EqualFold(a.Host, other.Host)
+}
+
+func (a Access) specificity() int {
+	return specificity(a.Database) + specificity(a.Branch) + specificity(a.User) + specificity(a.Host)
+}
+
+// DefaultAccess returns the rows of dolt_branch_control used when none have been persisted. Every user may write to
+// every branch, which is the behavior of a server without branch permissions.
+func DefaultAccess() []Access {
+	return []Access{{
+		Database:    "%",
+		Branch:      "%",
+		User:        "%",
+		Host:        "%",
+		Permissions: Permissions_Write,
+	}}
+}
+
+// permissionsFor returns the permissions that |rows| grant on the branch given. Only the most specific of the
+// matching rows apply, and the permissions of equally specific rows are combined.
+func permissionsFor(rows []Access, database, branch, user, host string) Permissions {
+	best := -1
+	var perms Permissions
+	for _, row := range rows {
+		if !row.Matches(database, branch, user, host) {
+			continue
+		}
+		s := row.specificity()
+		if s > best {
+			best = s
+			perms = row.Permissions
+		} else if s == best {
+			perms |= row.Permissions
+		}
+	}
+	return perms
+}
@@ -0,0 +1,280 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package branch_control implements branch permissions for sql-server. Permissions are granted by the rows of the
+// dolt_branch_control table, which control which users may write to, delete and rename branches, and the rows of the
+// dolt_branch_namespace_control table, which control which users may create branches. Both tables are global to the
+// server, and are persisted to a file next to the privilege file.
+package branch_control
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	goerrors "gopkg.in/src-d/go-errors.v1"
+)
+
+var ErrIncorrectPermissions = goerrors.NewKind("`%s`@`%s` does not have the correct permissions on branch `%s`")
+var ErrCannotCreateBranch = goerrors.NewKind("`%s`@`%s` cannot create a branch named `%s`")
+var ErrCannotDeleteBranch = goerrors.NewKind("`%s`@`%s` cannot delete the branch `%s`")
+var ErrCannotModifyRow = goerrors.NewKind("`%s`@`%s` cannot modify the row for database `%s` and branch `%s`")
+
+// Controller holds the rows of the branch control tables, and answers whether a user may perform an operation on a
+// branch. It is safe for concurrent use.
+type Controller struct {
+	mu             *sync.RWMutex
+	access         []Access
+	namespace      []Namespace
+	filePath       string
+	doltCfgDirPath string
+	superUser      string
+	superHost      string
+}
+
+// serialController is the format in which the branch control tables are persisted.
+type serialController struct {
+	Access    []Access    `json:"access"`
+	Namespace []Namespace `json:"namespace"`
+}
+
+// CreateDefaultController returns a Controller with the default rows, which is not persisted.
+func CreateDefaultController() *Controller {
+	return &Controller{
+		mu:     &sync.RWMutex{},
+		access: DefaultAccess(),
+	}
+}
+
+// LoadData returns a Controller with the rows persisted in the file at |filePath|. If the file does not exist, the
+// default rows are used. Changes to the rows are written back to |filePath|, creating |doltCfgDirPath| if needed. If
+// |filePath| is empty, changes are not persisted.
+func LoadData(filePath string, doltCfgDirPath string) (*Controller, error) {
+	c := CreateDefaultController()
+	c.filePath = filePath
+	c.doltCfgDirPath = doltCfgDirPath
+
+	if len(filePath) == 0 {
+		return c, nil
+	}
+
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(buf) == 0 {
+		return c, nil
+	}
+
+	var data serialController
+	if err = json.Unmarshal(buf, &data); err != nil {
+		return nil, err
+	}
+	c.access = data.Access
+	c.namespace = data.Namespace
+
+	return c, nil
+}
+
+// SetSuperUser sets the user and host of the server's super user, who is always allowed every operation. The host
+// is a pattern, as the super user may be allowed to connect from any host.
+func (c *Controller) SetSuperUser(user string, host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.superUser = user
+	c.superHost = host
+}
+
+// IsSuperUser returns whether the user and host given are the server's super user.
+func (c *Controller) IsSuperUser(user string, host string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isSuperUser(user, host)
+}
+
+func (c *Controller) isSuperUser(user string, host string) bool {
+	return len(c.superUser) > 0 && user == c.superUser && matchHost(c.superHost, host)
+}
+
+// Access returns a copy of the rows of the dolt_branch_control table.
+func (c *Controller) Access() []Access {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Access(nil), c.access...)
+}
+
+// Namespace returns a copy of the rows of the dolt_branch_namespace_control table.
+func (c *Controller) Namespace() []Namespace {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]Namespace(nil), c.namespace...)
+}
+
+// SetAccess replaces the rows of the dolt_branch_control table, and persists them.
+func (c *Controller) SetAccess(rows []Access) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.access = append([]Access(nil), rows...)
+	return c.saveData()
+}
+
+// SetNamespace replaces the rows of the dolt_branch_namespace_control table, and persists them.
+func (c *Controller) SetNamespace(rows []Namespace) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.namespace = append([]Namespace(nil), rows...)
+	return c.saveData()
+}
+
+// saveData writes the rows of both tables to the controller's file. The caller must hold the write lock.
+func (c *Controller) saveData() error {
+	if len(c.filePath) == 0 {
+		return nil
+	}
+
+	// Create doltcfg directory if it doesn't already exist
+	if len(c.doltCfgDirPath) != 0 {
+		if _, err := os.Stat(c.doltCfgDirPath); os.IsNotExist(err) {
+			if err := os.Mkdir(c.doltCfgDirPath, 0777); err != nil {
+				return err
+			}
+		}
+	}
+
+	buf, err := json.Marshal(serialController{Access: c.access, Namespace: c.namespace})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.filePath, buf, 0777)
+}
+
+// Permissions returns the permissions that the user and host given have on the branch given.
+func (c *Controller) Permissions(database, branch, user, host string) Permissions {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.isSuperUser(user, host) {
+		return Permissions_Admin | Permissions_Write
+	}
+	return permissionsFor(c.access, database, branch, user, host)
+}
+
+// CheckWrite returns an error if the user and host given may not write to the branch given.
+func (c *Controller) CheckWrite(database, branch, user, host string) error {
+	if !c.Permissions(database, branch, user, host).CanWrite() {
+		return ErrIncorrectPermissions.New(user, host, branch)
+	}
+	return nil
+}
+
+// CheckCreate returns an error if the user and host given may not create the branch given. Users may create a
+// branch that is not reserved for others in dolt_branch_namespace_control, or that they are an admin of.
+func (c *Controller) CheckCreate(database, branch, user, host string) error {
+	if c.Permissions(database, branch, user, host).Has(Permissions_Admin) {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !canCreate(c.namespace, database, branch, user, host) {
+		return ErrCannotCreateBranch.New(user, host, branch)
+	}
+	return nil
+}
+
+// CheckDelete returns an error if the user and host given may not delete the branch given. Deleting a branch requires
+// write access to it.
+func (c *Controller) CheckDelete(database, branch, user, host string) error {
+	if !c.Permissions(database, branch, user, host).CanWrite() {
+		return ErrCannotDeleteBranch.New(user, host, branch)
+	}
+	return nil
+}
+
+// CheckRename returns an error if the user and host given may not rename |oldBranch| to |newBranch|. Renaming a
+// branch requires deleting the old branch and creating the new one.
+func (c *Controller) CheckRename(database, oldBranch, newBranch, user, host string) error {
+	if err := c.CheckDelete(database, oldBranch, user, host); err != nil {
+		return err
+	}
+	return c.CheckCreate(database, newBranch, user, host)
+}
+
+// CheckModify returns an error if the user and host given may not add, edit or remove a row of either branch control
+// table with the database and branch patterns given. Admins may modify rows whose patterns, read as literal strings,
+// match the database and branch of their own admin rows.
+func (c *Controller) CheckModify(database, branch, user, host string) error {
+	if !c.Permissions(database, branch, user, host).Has(Permissions_Admin) {
+		return ErrCannotModifyRow.New(user, host, database, branch)
+	}
+	return nil
+}
+
+// GrantCreator gives the user and host given admin permissions on the branch they created, so that they may write to
+// it and manage who else may. Nothing is added for users who are already admins of the branch.
+func (c *Controller) GrantCreator(database, branch, user, host string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.isSuperUser(user, host) || permissionsFor(c.access, database, branch, user, host).Has(Permissions_Admin) {
+		return nil
+	}
+
+	row := Access{
+		Database:    EscapePattern(database),
+		Branch:      EscapePattern(branch),
+		User:        EscapePattern(user),
+		Host:        EscapePattern(host),
+		Permissions: Permissions_Admin,
+	}
+	for i := range c.access {
+		if c.access[i].SamePatterns(row) {
+			c.access[i].Permissions |= Permissions_Admin
+			return c.saveData()
+		}
+	}
+	c.access = append(c.access, row)
+	return c.saveData()
+}
+
+// RevokeBranch removes the admin permissions that rows naming exactly the branch given hold, such as those added by
+// GrantCreator, so that they do not carry over to a later branch with the same name. It is called once the branch has
+// been deleted or renamed. Rows left without any permissions are removed, while rows with patterns matching other
+// branches are kept as they are.
+func (c *Controller) RevokeBranch(database, branch string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dbPattern, branchPattern := EscapePattern(database), EscapePattern(branch)
+	changed := false
+	access := c.access[:0]
+	for _, row := range c.access {
+		if strings.EqualFold(row.Database, dbPattern) && strings.EqualFold(row.Branch, branchPattern) && row.Permissions.Has(Permissions_Admin) {
+			changed = true
+			row.Permissions &^= Permissions_Admin
+			if row.Permissions == 0 {
+				continue
+			}
+		}
+		access = append(access, row)
+	}
+	c.access = access
+
+	if !changed {
+		return nil
+	}
+	return c.saveData()
+}
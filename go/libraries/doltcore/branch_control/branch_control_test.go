@@ -0,0 +1,169 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch_control
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		s        string
+		foldCase bool
+		matches  bool
+	}{
+		{"%", "", true, true},
+		{"%", "main", true, true},
+		{"main", "main", true, true},
+		{"main", "MAIN", true, true},
+		{"main", "MAIN", false, false},
+		{"main", "mains", true, false},
+		{"feature/%", "feature/abc", true, true},
+		{"feature/%", "feature/", true, true},
+		{"feature/%", "features/abc", true, false},
+		{"%/abc", "feature/abc", true, true},
+		{"f%e%c", "feature/abc", true, true},
+		{"v_", "v1", true, true},
+		{"v_", "v12", true, false},
+		{`50\%`, "50%", true, true},
+		{`50\%`, "500", true, false},
+		{`a\_b`, "a_b", true, true},
+		{`a\_b`, "acb", true, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.s, func(t *testing.T) {
+			assert.Equal(t, test.matches, matchPattern(test.pattern, test.s, test.foldCase))
+		})
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	for _, s := range []string{"main", "feature/%", `a_b\c`} {
+		escaped := EscapePattern(s)
+		assert.True(t, matchPattern(escaped, s, true))
+		assert.Equal(t, len([]rune(s)), specificity(escaped))
+	}
+	assert.False(t, matchPattern(EscapePattern("feature/%"), "feature/abc", true))
+}
+
+func TestPermissions(t *testing.T) {
+	c := CreateDefaultController()
+	c.SetSuperUser("root", "localhost")
+	require.NoError(t, c.SetAccess([]Access{
+		{Database: "%", Branch: "%", User: "%", Host: "%", Permissions: Permissions_Read},
+		{Database: "%", Branch: "main", User: "ci", Host: "%", Permissions: Permissions_Write},
+		{Database: "%", Branch: "feature/%", User: "%", Host: "%", Permissions: Permissions_Write},
+		{Database: "%", Branch: "feature/%", User: "lead", Host: "%", Permissions: Permissions_Admin},
+		{Database: "mydb", Branch: "feature/locked", User: "%", Host: "%", Permissions: Permissions_Read},
+	}))
+
+	assert.NoError(t, c.CheckWrite("mydb", "main", "ci", "10.0.0.1"))
+	assert.Error(t, c.CheckWrite("mydb", "main", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckWrite("mydb", "main", "root", "127.0.0.1"))
+	assert.Error(t, c.CheckWrite("mydb", "main", "root", "10.0.0.1"))
+	assert.NoError(t, c.CheckWrite("mydb", "feature/abc", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckWrite("mydb", "feature/abc", "lead", "10.0.0.1"))
+	assert.Error(t, c.CheckWrite("mydb", "feature/locked", "analyst", "10.0.0.1"))
+	assert.Error(t, c.CheckWrite("mydb", "feature/locked", "lead", "10.0.0.1"))
+	assert.NoError(t, c.CheckWrite("otherdb", "feature/locked", "analyst", "10.0.0.1"))
+	assert.Error(t, c.CheckWrite("mydb", "MAIN", "CI", "10.0.0.1"))
+
+	assert.Error(t, c.CheckDelete("mydb", "main", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckDelete("mydb", "feature/abc", "analyst", "10.0.0.1"))
+	assert.Error(t, c.CheckDelete("mydb", "feature/locked", "lead", "10.0.0.1"))
+	assert.NoError(t, c.CheckDelete("mydb", "main", "root", "localhost"))
+
+	assert.NoError(t, c.CheckModify("%", "feature/%", "lead", "10.0.0.1"))
+	assert.NoError(t, c.CheckModify("mydb", "feature/xyz", "lead", "10.0.0.1"))
+	assert.Error(t, c.CheckModify("%", "%", "lead", "10.0.0.1"))
+	assert.Error(t, c.CheckModify("%", "feature/%", "analyst", "10.0.0.1"))
+}
+
+func TestNamespace(t *testing.T) {
+	c := CreateDefaultController()
+	require.NoError(t, c.SetAccess([]Access{
+		{Database: "%", Branch: "%", User: "%", Host: "%", Permissions: Permissions_Read},
+	}))
+	require.NoError(t, c.SetNamespace([]Namespace{
+		{Database: "%", Branch: "release%", User: "ci", Host: "%"},
+		{Database: "%", Branch: "release/hotfix%", User: "%", Host: "%"},
+	}))
+
+	assert.NoError(t, c.CheckCreate("mydb", "feature/abc", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckCreate("mydb", "release/1.0", "ci", "10.0.0.1"))
+	assert.Error(t, c.CheckCreate("mydb", "release/1.0", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckCreate("mydb", "release/hotfix1", "analyst", "10.0.0.1"))
+
+	assert.Error(t, c.CheckRename("mydb", "feature/abc", "feature/def", "analyst", "10.0.0.1"))
+	require.NoError(t, c.GrantCreator("mydb", "feature/abc", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckRename("mydb", "feature/abc", "feature/def", "analyst", "10.0.0.1"))
+	assert.Error(t, c.CheckRename("mydb", "feature/abc", "release/2.0", "analyst", "10.0.0.1"))
+	assert.Error(t, c.CheckDelete("mydb", "feature/abc", "analyst", "10.0.0.2"))
+	assert.Error(t, c.CheckDelete("mydb", "feature/abcd", "analyst", "10.0.0.1"))
+
+	// granting twice does not add another row
+	require.NoError(t, c.GrantCreator("mydb", "feature/abc", "analyst", "10.0.0.1"))
+	assert.Len(t, c.Access(), 2)
+}
+
+func TestRevokeBranch(t *testing.T) {
+	c := CreateDefaultController()
+	require.NoError(t, c.SetAccess([]Access{
+		{Database: "%", Branch: "%", User: "%", Host: "%", Permissions: Permissions_Read},
+		{Database: "mydb", Branch: "feature", User: "lead", Host: "%", Permissions: Permissions_Admin | Permissions_Write},
+		{Database: "%", Branch: "feature%", User: "lead", Host: "%", Permissions: Permissions_Admin},
+	}))
+	require.NoError(t, c.GrantCreator("mydb", "feature", "analyst", "10.0.0.1"))
+	assert.NoError(t, c.CheckWrite("mydb", "feature", "analyst", "10.0.0.1"))
+
+	require.NoError(t, c.RevokeBranch("mydb", "feature"))
+	assert.Error(t, c.CheckWrite("mydb", "feature", "analyst", "10.0.0.1"))
+	assert.Equal(t, []Access{
+		{Database: "%", Branch: "%", User: "%", Host: "%", Permissions: Permissions_Read},
+		{Database: "mydb", Branch: "feature", User: "lead", Host: "%", Permissions: Permissions_Write},
+		{Database: "%", Branch: "feature%", User: "lead", Host: "%", Permissions: Permissions_Admin},
+	}, c.Access())
+}
+
+func TestLoadAndSaveData(t *testing.T) {
+	dir := t.TempDir()
+	cfgDir := filepath.Join(dir, ".doltcfg")
+	path := filepath.Join(cfgDir, "branch_control.db")
+
+	c, err := LoadData(path, cfgDir)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAccess(), c.Access())
+	assert.Empty(t, c.Namespace())
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+
+	access := []Access{{Database: "mydb", Branch: "main", User: "ci", Host: "%", Permissions: Permissions_Admin | Permissions_Write}}
+	namespace := []Namespace{{Database: "%", Branch: "main", User: "ci", Host: "%"}}
+	require.NoError(t, c.SetAccess(access))
+	require.NoError(t, c.SetNamespace(namespace))
+
+	c, err = LoadData(path, cfgDir)
+	require.NoError(t, err)
+	assert.Equal(t, access, c.Access())
+	assert.Equal(t, namespace, c.Namespace())
+}
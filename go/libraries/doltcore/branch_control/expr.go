@@ -0,0 +1,130 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch_control
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	anyStringWildcard = '%'
+	anyCharWildcard   = '_'
+	escapeChar        = '\\'
+)
+
+// patternToken is a single element of a parsed pattern. A token is either a literal rune, or one of the wildcards.
+type patternToken struct {
+	r        rune
+	wildcard bool
+}
+
+// parsePattern splits the pattern given into tokens. Patterns follow the rules of the LIKE operator: '%' matches any
+// sequence of characters, '_' matches any single character, and '\' escapes the character that follows it.
+func parsePattern(pattern string) []patternToken {
+	runes := []rune(pattern)
+	tokens := make([]patternToken, 0, len(runes))
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case escapeChar:
+			if i+1 < len(runes) {
+				i++
+			}
+			tokens = append(tokens, patternToken{r: runes[i]})
+		case anyStringWildcard, anyCharWildcard:
+			tokens = append(tokens, patternToken{r: runes[i], wildcard: true})
+		default:
+			tokens = append(tokens, patternToken{r: runes[i]})
+		}
+	}
+	return tokens
+}
+
+// matchPattern returns whether |s| matches |pattern|. When |foldCase| is true, the comparison is case-insensitive.
+func matchPattern(pattern string, s string, foldCase bool) bool {
+	tokens := parsePattern(pattern)
+	runes := []rune(s)
+
+	equal := func(a, b rune) bool {
+		if foldCase {
+			return unicode.ToLower(a) == unicode.ToLower(b)
+		}
+		return a == b
+	}
+
+	// Iterative wildcard matching, backtracking to the most recent '%' on a mismatch
+	ti, si := 0, 0
+	starTi, starSi := -1, 0
+	for si < len(runes) {
+		if ti < len(tokens) {
+			tok := tokens[ti]
+			if tok.wildcard && tok.r == anyStringWildcard {
+				starTi, starSi = ti, si
+				ti++
+				continue
+			}
+			if (tok.wildcard && tok.r == anyCharWildcard) || (!tok.wildcard && equal(tok.r, runes[si])) {
+				ti++
+				si++
+				continue
+			}
+		}
+		if starTi == -1 {
+			return false
+		}
+		starSi++
+		ti, si = starTi+1, starSi
+	}
+
+	for ti < len(tokens) && tokens[ti].wildcard && tokens[ti].r == anyStringWildcard {
+		ti++
+	}
+	return ti == len(tokens)
+}
+
+// matchHost returns whether |host| matches |pattern|. Loopback addresses additionally match patterns for localhost.
+func matchHost(pattern string, host string) bool {
+	if matchPattern(pattern, host, true) {
+		return true
+	}
+	if host == "127.0.0.1" || host == "::1" {
+		return matchPattern(pattern, "localhost", true)
+	}
+	return false
+}
+
+// specificity returns the number of literal characters in the pattern given. Patterns with more literal characters
+// match fewer strings, and are therefore more specific.
+func specificity(pattern string) int {
+	count := 0
+	for _, tok := range parsePattern(pattern) {
+		if !tok.wildcard {
+			count++
+		}
+	}
+	return count
+}
+
+// EscapePattern returns a pattern that matches only the exact string given.
+func EscapePattern(s string) string {
+	sb := strings.Builder{}
+	for _, r := range s {
+		if r == anyStringWildcard || r == anyCharWildcard || r == escapeChar {
+			sb.WriteRune(escapeChar)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
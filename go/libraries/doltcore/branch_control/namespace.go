@@ -0,0 +1,67 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package branch_control
+
+import "strings"
+
+// Namespace is a single row of the dolt_branch_namespace_control table. A row reserves the branch names matching its
+// database and branch patterns, so that only the users and hosts matching its user and host patterns may create
+// branches with those names.
+type Namespace struct {
+	Database string `json:"database"`
+	Branch   string `json:"branch"`
+	User     string `json:"user"`
+	Host     string `json:"host"`
+}
+
+// MatchesBranch returns whether this row reserves the branch given.
+func (n Namespace) MatchesBranch(database, branch string) bool {
+	return matchPattern(n.Database, database, true) && matchPattern(n.Branch, branch, true)
+}
+
+// MatchesUser returns whether this row applies to the user and host given.
+func (n Namespace) MatchesUser(user, host string) bool {
+	return matchPattern(n.User, user, false) && matchHost(n.Host, host)
+}
+
+// SamePatterns returns whether this row has the same patterns as the row given, meaning that both rows have the
+// same primary key in the dolt_branch_namespace_control table.
+func (n Namespace) SamePatterns(other Namespace) bool {
+	return strings.EqualFold(n.Database, other.Database) &&
+		strings.EqualFold(n.Branch, other.Branch) &&
+		n.User == other.User &&
+		strings.EqualFold(n.Host, other.Host)
+}
+
+// canCreate returns whether |rows| allow the user and host given to create the branch given. Branches that no row
+// reserves may be created by anyone. Otherwise, only the most specific rows reserving the branch are considered, and
+// the user and host must match at least one of them.
+func canCreate(rows []Namespace, database, branch, user, host string) bool {
+	best := -1
+	allowed := true
+	for _, row := range rows {
+		if !row.MatchesBranch(database, branch) {
+			continue
+		}
+		s := specificity(row.Database) + specificity(row.Branch)
+		if s > best {
+			best = s
+			allowed = row.MatchesUser(user, host)
+		} else if s == best {
+			allowed = allowed || row.MatchesUser(user, host)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+)
+
+// EnvRemotePassword is the environment variable holding the password of the user given to commands that authenticate
+// to a sql-server's remotesapi endpoint as one of its SQL users.
+const EnvRemotePassword = "DOLT_REMOTE_PASSWORD"
+
+const basicAuthPrefix = "Basic "
+
+// UserPasswordCreds authenticates requests to a remotesapi endpoint as the SQL user of a sql-server. The password can
+// also be a JWT for users that are authenticated with one.
+type UserPasswordCreds struct {
+	Username string
+	Password string
+}
+
+func (c UserPasswordCreds) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	userPass := base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+	return map[string]string{
+		"authorization": basicAuthPrefix + userPass,
+	}, nil
+}
+
+func (c UserPasswordCreds) RequireTransportSecurity() bool {
+	return false
+}
+
+// ParseBasicAuth returns the user and password of the value of an authorization header written by UserPasswordCreds.
+func ParseBasicAuth(authorization string) (user, password string, ok bool) {
+	if !strings.HasPrefix(authorization, basicAuthPrefix) {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(authorization[len(basicAuthPrefix):])
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Cut(string(decoded), ":")
+}
@@ -0,0 +1,37 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package creds
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUserPasswordCredsRoundTrip(t *testing.T) {
+	c := UserPasswordCreds{Username: "user", Password: "pass:word"}
+	md, err := c.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatal("Failed to get request metadata", err)
+	}
+
+	user, pass, ok := ParseBasicAuth(md["authorization"])
+	if !ok || user != c.Username || pass != c.Password {
+		t.Errorf("expected %s and %s, got %s and %s", c.Username, c.Password, user, pass)
+	}
+
+	if _, _, ok := ParseBasicAuth("Bearer token"); ok {
+		t.Error("parsed a bearer token as basic auth")
+	}
+}
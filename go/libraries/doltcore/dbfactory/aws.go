@@ -116,6 +116,12 @@ func (fact AWSFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat, u
 		return nil, nil, nil, err
 	}
 
+	cs, err = withChunkCache(ctx, cs, params)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	vrw := types.NewValueStore(cs)
 	ns := tree.NewNodeStore(cs)
 	db = datas.NewTypesDatabase(vrw, ns)
@@ -0,0 +1,69 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbfactory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/nbs"
+)
+
+// ChunkCacheDirParam is a creation parameter holding a local directory in which the chunks read from a remote storage
+// are cached. It's supported by the aws, gs, localbs and file schemes.
+const ChunkCacheDirParam = "chunk-cache-dir"
+
+// ChunkCacheSizeParam is a creation parameter holding the size, in bytes, that the cache in ChunkCacheDirParam may grow
+// to. It defaults to nbs.DefaultChunkCacheSize.
+const ChunkCacheSizeParam = "chunk-cache-size"
+
+// withChunkCache returns |cs| reading through a cache of its chunks in the directory given by ChunkCacheDirParam, or
+// |cs| itself if |params| has no such directory.
+func withChunkCache(ctx context.Context, cs chunks.ChunkStore, params map[string]interface{}) (chunks.ChunkStore, error) {
+	val, ok := params[ChunkCacheDirParam]
+	if !ok {
+		return cs, nil
+	}
+	dir, ok := val.(string)
+	if !ok || dir == "" {
+		return nil, fmt.Errorf("invalid %s: %v", ChunkCacheDirParam, val)
+	}
+
+	maxSize := uint64(nbs.DefaultChunkCacheSize)
+	if val, ok := params[ChunkCacheSizeParam]; ok {
+		str, _ := val.(string)
+		size, err := strconv.ParseUint(str, 10, 64)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("invalid %s: %v", ChunkCacheSizeParam, val)
+		}
+		maxSize = size
+	}
+
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	q := nbs.NewUnlimitedMemQuotaProvider()
+	cache, err := nbs.NewLocalStore(ctx, cs.Version(), dir, defaultMemTableSize, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return nbs.NewCachedChunkStore(cs, cache, maxSize), nil
+}
@@ -33,6 +33,9 @@ const (
 	// GSScheme
 	GSScheme = "gs"
 
+	// S3Scheme
+	S3Scheme = "s3"
+
 	// FileScheme
 	FileScheme = "file"
 
@@ -62,6 +65,7 @@ type DBFactory interface {
 var DBFactories = map[string]DBFactory{
 	AWSScheme:     AWSFactory{},
 	GSScheme:      GSFactory{},
+	S3Scheme:      S3Factory{},
 	FileScheme:    FileFactory{},
 	MemScheme:     MemFactory{},
 	LocalBSScheme: LocalBSFactory{},
@@ -20,7 +20,9 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/dolthub/dolt/go/libraries/utils/earl"
 	"github.com/dolthub/dolt/go/libraries/utils/filesys"
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/nbs"
@@ -45,21 +47,23 @@ type FileFactory struct {
 
 // CreateDB creates a local filesys backed database
 func (fact FileFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]interface{}) (datas.Database, types.ValueReadWriter, tree.NodeStore, error) {
-	path, err := url.PathUnescape(urlObj.Path)
+	path, err := pathForURL(urlObj)
 
 	if err != nil {
 		return nil, nil, nil, err
 	}
 
-	path = filepath.FromSlash(path)
-	path = urlObj.Host + path
-
 	err = validateDir(path)
 	if err != nil {
 		return nil, nil, nil, err
 	}
 	q := nbs.NewUnlimitedMemQuotaProvider()
-	newGenSt, err := nbs.NewLocalStore(ctx, nbf.VersionString(), path, defaultMemTableSize, q)
+	var newGenSt *nbs.NomsBlockStore
+	if nbs.ChunkJournalEnabled() {
+		newGenSt, err = nbs.NewLocalJournalingStore(ctx, nbf.VersionString(), path, defaultMemTableSize, q)
+	} else {
+		newGenSt, err = nbs.NewLocalStore(ctx, nbf.VersionString(), path, defaultMemTableSize, q)
+	}
 
 	if err != nil {
 		return nil, nil, nil, err
@@ -84,15 +88,44 @@ func (fact FileFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat,
 		return nil, nil, nil, err
 	}
 
-	st := nbs.NewGenerationalCS(oldGenSt, newGenSt)
+	st, err := withChunkCache(ctx, nbs.NewGenerationalCS(oldGenSt, newGenSt), params)
 	// metrics?
 
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
 	vrw := types.NewValueStore(st)
 	ns := tree.NewNodeStore(st)
 
 	return datas.NewTypesDatabase(vrw, ns), vrw, ns, nil
 }
 
+// LocalDirForURL returns the directory holding the database of |urlStr|, and whether it's a local filesys backed
+// database.
+func LocalDirForURL(urlStr string) (string, bool) {
+	urlObj, err := earl.Parse(urlStr)
+	if err != nil || strings.ToLower(urlObj.Scheme) != FileScheme {
+		return "", false
+	}
+
+	path, err := pathForURL(urlObj)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+func pathForURL(urlObj *url.URL) (string, error) {
+	path, err := url.PathUnescape(urlObj.Path)
+	if err != nil {
+		return "", err
+	}
+
+	path = filepath.FromSlash(path)
+	return urlObj.Host + path, nil
+}
+
 func validateDir(path string) error {
 	info, err := os.Stat(path)
 
@@ -19,10 +19,12 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 
 	"google.golang.org/grpc"
 
 	remotesapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/dolthub/dolt/go/libraries/doltcore/creds"
 	"github.com/dolthub/dolt/go/libraries/doltcore/grpcendpoint"
 	"github.com/dolthub/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/dolthub/dolt/go/libraries/events"
@@ -34,6 +36,10 @@ import (
 
 var GRPCDialProviderParam = "__DOLT__grpc_dial_provider"
 
+// GRPCUsernameAuthParam is the param holding the SQL user requests to a sql-server's remotesapi endpoint are
+// authenticated as. The user's password is read from the environment variable creds.EnvRemotePassword.
+var GRPCUsernameAuthParam = "__DOLT__grpc_username"
+
 // GRPCDialProvider is an interface for getting a *grpc.ClientConn.
 type GRPCDialProvider interface {
 	GetGRPCDialParams(grpcendpoint.Config) (string, []grpc.DialOption, error)
@@ -80,17 +86,23 @@ func (fact DoltRemoteFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFo
 var NoCachingParameter = "__dolt__NO_CACHING"
 
 func (fact DoltRemoteFactory) newChunkStore(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]interface{}, dp GRPCDialProvider) (chunks.ChunkStore, error) {
-	endpoint, opts, err := dp.GetGRPCDialParams(grpcendpoint.Config{
+	cfg := grpcendpoint.Config{
 		Endpoint:     urlObj.Host,
 		Insecure:     fact.insecure,
 		WithEnvCreds: true,
-	})
+	}
+	if user, ok := params[GRPCUsernameAuthParam]; ok {
+		cfg.Creds = creds.UserPasswordCreds{Username: user.(string), Password: os.Getenv(creds.EnvRemotePassword)}
+	}
+
+	endpoint, opts, err := dp.GetGRPCDialParams(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	opts = append(opts, grpc.WithChainUnaryInterceptor(remotestorage.EventsUnaryClientInterceptor(events.GlobalCollector)))
 	opts = append(opts, grpc.WithChainUnaryInterceptor(remotestorage.RetryingUnaryClientInterceptor))
+	opts = append(opts, grpc.WithChainUnaryInterceptor(remotestorage.StatsUnaryClientInterceptor))
 
 	conn, err := grpc.Dial(endpoint, opts...)
 	if err != nil {
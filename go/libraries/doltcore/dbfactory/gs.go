@@ -49,8 +49,14 @@ func (fact GSFactory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat, ur
 		return nil, nil, nil, err
 	}
 
-	vrw := types.NewValueStore(gcsStore)
-	ns := tree.NewNodeStore(gcsStore)
+	cs, err := withChunkCache(ctx, gcsStore, params)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	vrw := types.NewValueStore(cs)
+	ns := tree.NewNodeStore(cs)
 	db = datas.NewTypesDatabase(vrw, ns)
 
 	return db, vrw, ns, nil
@@ -77,8 +83,14 @@ func (fact LocalBSFactory) CreateDB(ctx context.Context, nbf *types.NomsBinForma
 		return nil, nil, nil, err
 	}
 
-	vrw := types.NewValueStore(bsStore)
-	ns := tree.NewNodeStore(bsStore)
+	cs, err := withChunkCache(ctx, bsStore, params)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	vrw := types.NewValueStore(cs)
+	ns := tree.NewNodeStore(cs)
 	db = datas.NewTypesDatabase(vrw, ns)
 
 	return db, vrw, ns, err
@@ -0,0 +1,136 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbfactory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/dolthub/dolt/go/store/blobstore"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/nbs"
+	"github.com/dolthub/dolt/go/store/prolly/tree"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+const (
+	// S3EndpointParam is a creation parameter that can be used to set the endpoint of the S3 compatible object store
+	// of an s3 url
+	S3EndpointParam = "s3-endpoint"
+
+	// S3PathStyleParam is a creation parameter that can be used to address the bucket of an s3 url in the path of
+	// requests, instead of in the host name. It defaults to true when S3EndpointParam is set.
+	S3PathStyleParam = "s3-path-style"
+
+	// defaultS3CompatRegion is the region used for object stores with a custom endpoint when none is given, since
+	// requests have to be signed for some region.
+	defaultS3CompatRegion = "us-east-1"
+)
+
+// S3Factory is a DBFactory implementation for creating databases backed by an S3 bucket, or by a bucket of an S3
+// compatible object store such as MinIO or Ceph RGW. Unlike the AWSFactory, it doesn't use DynamoDB for the
+// manifest, which is stored in the bucket and updated with conditional writes. Object stores which ignore the
+// conditions of writes are refused.
+type S3Factory struct {
+}
+
+// CreateDB creates an S3 backed database
+func (fact S3Factory) CreateDB(ctx context.Context, nbf *types.NomsBinFormat, urlObj *url.URL, params map[string]interface{}) (datas.Database, types.ValueReadWriter, tree.NodeStore, error) {
+	var db datas.Database
+	if urlObj.Host == "" {
+		return nil, nil, nil, errors.New("s3 url has an invalid format")
+	}
+
+	dbName, err := validatePath(urlObj.Path)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	opts, err := s3ConfigFromParams(params)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	sess, err := session.NewSessionWithOptions(opts)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	bs, err := blobstore.OpenS3Blobstore(ctx, s3.New(sess), urlObj.Host, dbName)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	q := nbs.NewUnlimitedMemQuotaProvider()
+	s3Store, err := nbs.NewBSStore(ctx, nbf.VersionString(), bs, defaultMemTableSize, q)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	vrw := types.NewValueStore(s3Store)
+	ns := tree.NewNodeStore(s3Store)
+	db = datas.NewTypesDatabase(vrw, ns)
+
+	return db, vrw, ns, nil
+}
+
+// s3ConfigFromParams returns the session options for the credentials and region of the aws params of |params|,
+// and for the endpoint and addressing of the S3 params.
+func s3ConfigFromParams(params map[string]interface{}) (session.Options, error) {
+	opts, err := awsConfigFromParams(params)
+
+	if err != nil {
+		return session.Options{}, err
+	}
+
+	endpoint := ""
+	if val, ok := params[S3EndpointParam]; ok {
+		endpoint = val.(string)
+	}
+
+	pathStyle := endpoint != ""
+	if val, ok := params[S3PathStyleParam]; ok {
+		pathStyle, err = strconv.ParseBool(val.(string))
+
+		if err != nil {
+			return session.Options{}, fmt.Errorf("invalid value for %s: '%s'", S3PathStyleParam, val)
+		}
+	}
+
+	s3Config := aws.NewConfig().WithS3ForcePathStyle(pathStyle)
+	if endpoint != "" {
+		s3Config = s3Config.WithEndpoint(endpoint)
+
+		if _, ok := params[AWSRegionParam]; !ok {
+			s3Config = s3Config.WithRegion(defaultS3CompatRegion)
+		}
+	}
+
+	opts.Config.MergeIn(s3Config)
+
+	return opts, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dbfactory
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3ConfigFromParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    map[string]interface{}
+		endpoint  string
+		region    string
+		pathStyle bool
+		expectErr bool
+	}{
+		{
+			name:   "no params",
+			params: map[string]interface{}{},
+		},
+		{
+			name:      "endpoint",
+			params:    map[string]interface{}{S3EndpointParam: "http://localhost:9000"},
+			endpoint:  "http://localhost:9000",
+			region:    defaultS3CompatRegion,
+			pathStyle: true,
+		},
+		{
+			name:      "endpoint and region",
+			params:    map[string]interface{}{S3EndpointParam: "http://localhost:9000", AWSRegionParam: "eu-west-1"},
+			endpoint:  "http://localhost:9000",
+			region:    "eu-west-1",
+			pathStyle: true,
+		},
+		{
+			name:     "virtual hosted endpoint",
+			params:   map[string]interface{}{S3EndpointParam: "https://objects.example.com", S3PathStyleParam: "false"},
+			endpoint: "https://objects.example.com",
+			region:   defaultS3CompatRegion,
+		},
+		{
+			name:      "path style",
+			params:    map[string]interface{}{S3PathStyleParam: "true"},
+			pathStyle: true,
+		},
+		{
+			name:      "invalid path style",
+			params:    map[string]interface{}{S3PathStyleParam: "sometimes"},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := s3ConfigFromParams(test.params)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.endpoint, aws.StringValue(opts.Config.Endpoint))
+			assert.Equal(t, test.region, aws.StringValue(opts.Config.Region))
+			assert.Equal(t, test.pathStyle, aws.BoolValue(opts.Config.S3ForcePathStyle))
+		})
+	}
+}
@@ -16,10 +16,12 @@ package diff
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/dolthub/go-mysql-server/sql"
 
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped"
 	"github.com/dolthub/dolt/go/store/diff"
 	"github.com/dolthub/dolt/go/store/types"
 )
@@ -67,5 +69,35 @@ type SqlRowDiffWriter interface {
 	Close(ctx context.Context) error
 }
 
+// untypedRowDiffWriter is a SqlRowDiffWriter that writes the rows of a diff with an untyped.RowDiffWriter, which
+// writes file formats that only have the changed rows of a diff, without the diff types of their columns.
+type untypedRowDiffWriter struct {
+	wr untyped.RowDiffWriter
+}
+
+// NewUntypedRowDiffWriter returns a SqlRowDiffWriter that writes the rows of a diff with |wr|.
+func NewUntypedRowDiffWriter(wr untyped.RowDiffWriter) SqlRowDiffWriter {
+	return untypedRowDiffWriter{wr: wr}
+}
+
+func (w untypedRowDiffWriter) WriteRow(ctx context.Context, row sql.Row, diffType ChangeType, colDiffTypes []ChangeType) error {
+	switch diffType {
+	case Added:
+		return w.wr.WriteRowDiff(ctx, row, untyped.RowAdded)
+	case Removed:
+		return w.wr.WriteRowDiff(ctx, row, untyped.RowRemoved)
+	case ModifiedOld:
+		return w.wr.WriteRowDiff(ctx, row, untyped.RowModifiedOld)
+	case ModifiedNew:
+		return w.wr.WriteRowDiff(ctx, row, untyped.RowModifiedNew)
+	default:
+		return fmt.Errorf("unexpected row diff type: %v", diffType)
+	}
+}
+
+func (w untypedRowDiffWriter) Close(ctx context.Context) error {
+	return w.wr.Close(ctx)
+}
+
 // ColorFunc is a function that can color a format string
 type ColorFunc func(a ...interface{}) string
@@ -87,6 +87,33 @@ func SummaryForTableDelta(ctx context.Context, ch chan DiffSummaryProgress, td T
 	}
 }
 
+// SummaryTotalsForTableDelta returns the totals of the diff summary progress messages for the table delta given, as
+// pushed by SummaryForTableDelta.
+func SummaryTotalsForTableDelta(ctx context.Context, td TableDelta) (DiffSummaryProgress, error) {
+	var err error
+	ch := make(chan DiffSummaryProgress)
+	go func() {
+		defer close(ch)
+		err = SummaryForTableDelta(ctx, ch, td)
+	}()
+
+	acc := DiffSummaryProgress{}
+	for p := range ch {
+		acc.Adds += p.Adds
+		acc.Removes += p.Removes
+		acc.Changes += p.Changes
+		acc.CellChanges += p.CellChanges
+		acc.NewSize += p.NewSize
+		acc.OldSize += p.OldSize
+	}
+
+	// err is written before ch is closed, so it is safe to read once the channel is drained
+	if err != nil {
+		return DiffSummaryProgress{}, err
+	}
+	return acc, nil
+}
+
 func diffProllyTrees(ctx context.Context, ch chan DiffSummaryProgress, keyless bool, from, to durable.Index, fromSch, toSch schema.Schema) error {
 	_, vMapping, err := MapSchemaBasedOnName(fromSch, toSch)
 	if err != nil {
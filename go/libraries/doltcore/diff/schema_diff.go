@@ -16,6 +16,7 @@ package diff
 
 import (
 	"reflect"
+	"strings"
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
@@ -204,3 +205,66 @@ func DiffForeignKeys(fromFks, toFKs []doltdb.ForeignKey) (diffs []ForeignKeyDiff
 	}
 	return diffs
 }
+
+type CheckDifference struct {
+	DiffType SchemaChangeType
+	From     schema.Check
+	To       schema.Check
+}
+
+// DiffSchChecks matches two sets of check constraints based on their names.
+// It returns matched and unmatched checks as a slice of CheckDifferences.
+func DiffSchChecks(fromSch, toSch schema.Schema) (diffs []CheckDifference) {
+	toChecks := allChecks(toSch)
+	matched := make(map[string]bool)
+
+	for _, from := range allChecks(fromSch) {
+		var to schema.Check
+		for _, chk := range toChecks {
+			if strings.EqualFold(from.Name(), chk.Name()) {
+				to = chk
+				break
+			}
+		}
+
+		if to == nil {
+			diffs = append(diffs, CheckDifference{
+				DiffType: SchDiffRemoved,
+				From:     from,
+			})
+			continue
+		}
+
+		matched[strings.ToLower(to.Name())] = true
+		d := CheckDifference{
+			DiffType: SchDiffModified,
+			From:     from,
+			To:       to,
+		}
+		if from.Expression() == to.Expression() && from.Enforced() == to.Enforced() {
+			d.DiffType = SchDiffNone
+		}
+		diffs = append(diffs, d)
+	}
+
+	for _, to := range toChecks {
+		if matched[strings.ToLower(to.Name())] {
+			continue
+		}
+		diffs = append(diffs, CheckDifference{
+			DiffType: SchDiffAdded,
+			To:       to,
+		})
+	}
+
+	return diffs
+}
+
+// allChecks returns the checks of the schema given, which may have no check collection, as is the case for
+// schema.EmptySchema.
+func allChecks(sch schema.Schema) []schema.Check {
+	if sch.Checks() == nil {
+		return nil
+	}
+	return sch.Checks().AllChecks()
+}
@@ -66,3 +66,52 @@ func TestDiffSchemas(t *testing.T) {
 		t.Error(diffs, "!=", expected)
 	}
 }
+
+func TestDiffSchChecks(t *testing.T) {
+	cols := schema.NewColCollection(schema.NewColumn("pk", 0, types.IntKind, true, schema.NotNullConstraint{}))
+	oldSch, err := schema.SchemaFromCols(cols)
+	require.NoError(t, err)
+	newSch, err := schema.SchemaFromCols(cols)
+	require.NoError(t, err)
+
+	_, err = oldSch.Checks().AddCheck("unchanged", "(pk > 0)", true)
+	require.NoError(t, err)
+	_, err = oldSch.Checks().AddCheck("dropped", "(pk < 100)", true)
+	require.NoError(t, err)
+	_, err = oldSch.Checks().AddCheck("modified", "(pk <> 5)", true)
+	require.NoError(t, err)
+
+	_, err = newSch.Checks().AddCheck("unchanged", "(pk > 0)", true)
+	require.NoError(t, err)
+	_, err = newSch.Checks().AddCheck("MODIFIED", "(pk <> 6)", true)
+	require.NoError(t, err)
+	_, err = newSch.Checks().AddCheck("added", "(pk < 1000)", false)
+	require.NoError(t, err)
+
+	diffs := DiffSchChecks(oldSch, newSch)
+	require.Len(t, diffs, 4)
+
+	expected := []struct {
+		diffType SchemaChangeType
+		from     string
+		to       string
+	}{
+		{SchDiffNone, "unchanged", "unchanged"},
+		{SchDiffRemoved, "dropped", ""},
+		{SchDiffModified, "modified", "MODIFIED"},
+		{SchDiffAdded, "", "added"},
+	}
+	for i, e := range expected {
+		require.Equal(t, e.diffType, diffs[i].DiffType)
+		if e.from == "" {
+			require.Nil(t, diffs[i].From)
+		} else {
+			require.Equal(t, e.from, diffs[i].From.Name())
+		}
+		if e.to == "" {
+			require.Nil(t, diffs[i].To)
+		} else {
+			require.Equal(t, e.to, diffs[i].To.Name())
+		}
+	}
+}
@@ -67,6 +67,34 @@ func GetStagedUnstagedTableDeltas(ctx context.Context, roots doltdb.Roots) (stag
 	return staged, unstaged, nil
 }
 
+// FilterIgnoredTableDeltas returns the unstaged table deltas given without the deltas for newly added tables that are
+// ignored by the dolt_ignore table of the working root in |roots|.
+func FilterIgnoredTableDeltas(ctx context.Context, roots doltdb.Roots, unstaged []TableDelta) ([]TableDelta, error) {
+	patterns, err := doltdb.GetIgnoredTablePatterns(ctx, roots)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return unstaged, nil
+	}
+
+	filtered := make([]TableDelta, 0, len(unstaged))
+	for _, td := range unstaged {
+		if td.IsAdd() {
+			ignored, err := doltdb.IsTableIgnored(ctx, roots, patterns, td.ToName)
+			if err != nil {
+				return nil, err
+			}
+			if ignored {
+				continue
+			}
+		}
+		filtered = append(filtered, td)
+	}
+
+	return filtered, nil
+}
+
 // GetTableDeltas returns a slice of TableDelta objects for each table that changed between fromRoot and toRoot.
 // It matches tables across roots by finding Schemas with Column tags in common.
 func GetTableDeltas(ctx context.Context, fromRoot, toRoot *doltdb.RootValue) (deltas []TableDelta, err error) {
@@ -77,7 +105,7 @@ func GetTableDeltas(ctx context.Context, fromRoot, toRoot *doltdb.RootValue) (de
 			return true, err
 		}
 		fks, _ := c.KeysForTable(name)
-		parentSchs, err := getFkParentSchs(ctx, fromRoot, fks...)
+		parentSchs, err := GetFkParentSchs(ctx, fromRoot, fks...)
 		if err != nil {
 			return false, err
 		}
@@ -103,7 +131,7 @@ func GetTableDeltas(ctx context.Context, fromRoot, toRoot *doltdb.RootValue) (de
 		}
 
 		fks, _ := c.KeysForTable(name)
-		parentSchs, err := getFkParentSchs(ctx, toRoot, fks...)
+		parentSchs, err := GetFkParentSchs(ctx, toRoot, fks...)
 		if err != nil {
 			return false, err
 		}
@@ -124,7 +152,8 @@ func GetTableDeltas(ctx context.Context, fromRoot, toRoot *doltdb.RootValue) (de
 	return matchTableDeltas(fromDeltas, toDeltas)
 }
 
-func getFkParentSchs(ctx context.Context, root *doltdb.RootValue, fks ...doltdb.ForeignKey) (map[string]schema.Schema, error) {
+// GetFkParentSchs returns the schemas of the tables of |root| that |fks| reference, by name. Missing tables are skipped.
+func GetFkParentSchs(ctx context.Context, root *doltdb.RootValue, fks ...doltdb.ForeignKey) (map[string]schema.Schema, error) {
 	schs := make(map[string]schema.Schema)
 	for _, toFk := range fks {
 		toRefTable, _, ok, err := root.GetTableInsensitive(ctx, toFk.ReferencedTableName)
@@ -335,6 +364,13 @@ func (td TableDelta) IsKeyless(ctx context.Context) (bool, error) {
 
 	from, to := schema.IsKeyless(f), schema.IsKeyless(t)
 
+	// an added or dropped table only has the one schema
+	if td.FromTable == nil {
+		return to, nil
+	} else if td.ToTable == nil {
+		return from, nil
+	}
+
 	if from && to {
 		return true, nil
 	} else if !from && !to {
@@ -17,6 +17,8 @@ package doltdb
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
 	"github.com/dolthub/dolt/go/store/datas"
@@ -43,16 +45,78 @@ type Commit struct {
 	ns      tree.NodeStore
 	parents []*datas.Commit
 	dCommit *datas.Commit
+
+	// missingParents holds the addresses of the parents of the oldest commits of a shallow clone, which were not
+	// fetched. It is empty for all other commits.
+	missingParents []hash.Hash
+	// shallow holds the shallow commits of the database the commit was read from.
+	shallow *shallowCommits
 }
 
 var _ Rootish = &Commit{}
 
+// shallowCommits holds the commits of a shallow clone whose parents were not fetched. It's shared by a DoltDB and the
+// commits read from it, so that only these commits may be read without their parents.
+type shallowCommits struct {
+	mu      *sync.RWMutex
+	commits hash.HashSet
+}
+
+func newShallowCommits() *shallowCommits {
+	return &shallowCommits{mu: &sync.RWMutex{}, commits: hash.NewHashSet()}
+}
+
+func (s *shallowCommits) has(h hash.Hash) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.commits.Has(h)
+}
+
+func (s *shallowCommits) empty() bool {
+	if s == nil {
+		return true
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.commits.Size() == 0
+}
+
+func (s *shallowCommits) all() hash.HashSet {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.commits.Copy()
+}
+
+func (s *shallowCommits) set(commits hash.HashSet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commits = commits.Copy()
+}
+
+// NewCommit returns the Commit of |commit|, whose parents must be in |vrw|.
 func NewCommit(ctx context.Context, vrw types.ValueReadWriter, ns tree.NodeStore, commit *datas.Commit) (*Commit, error) {
+	return newCommit(ctx, vrw, ns, nil, commit)
+}
+
+// newCommit returns the Commit of |commit|. Its parents must be in |vrw|, unless it's one of the commits in |shallow|.
+func newCommit(ctx context.Context, vrw types.ValueReadWriter, ns tree.NodeStore, shallow *shallowCommits, commit *datas.Commit) (*Commit, error) {
 	parents, err := datas.GetCommitParents(ctx, vrw, commit.NomsValue())
-	if err != nil {
+	if errors.Is(err, datas.ErrParentNotFound) && shallow.has(commit.Addr()) {
+		missing, err := datas.GetCommitParentAddrs(ctx, vrw, commit.NomsValue())
+		if err != nil {
+			return nil, err
+		}
+		return &Commit{vrw: vrw, ns: ns, dCommit: commit, missingParents: missing, shallow: shallow}, nil
+	} else if err != nil {
 		return nil, err
 	}
-	return &Commit{vrw, ns, parents, commit}, nil
+	return &Commit{vrw: vrw, ns: ns, parents: parents, dCommit: commit, shallow: shallow}, nil
 }
 
 // HashOf returns the hash of the commit
@@ -65,13 +129,17 @@ func (c *Commit) GetCommitMeta(ctx context.Context) (*datas.CommitMeta, error) {
 	return datas.GetCommitMeta(ctx, c.dCommit.NomsValue())
 }
 
-// DatasParents returns the []*datas.Commit of the commit parents.
+// DatasParents returns the []*datas.Commit of the commit parents. It is empty for the oldest commits of a shallow
+// clone, whose parents were not fetched.
 func (c *Commit) DatasParents() []*datas.Commit {
 	return c.parents
 }
 
 // ParentHashes returns the commit hashes for all parent commits.
 func (c *Commit) ParentHashes(ctx context.Context) ([]hash.Hash, error) {
+	if len(c.missingParents) > 0 {
+		return c.missingParents, nil
+	}
 	hashes := make([]hash.Hash, len(c.parents))
 	for i, pr := range c.parents {
 		hashes[i] = pr.Addr()
@@ -81,9 +149,18 @@ func (c *Commit) ParentHashes(ctx context.Context) ([]hash.Hash, error) {
 
 // NumParents gets the number of parents a commit has.
 func (c *Commit) NumParents() int {
+	if len(c.missingParents) > 0 {
+		return len(c.missingParents)
+	}
 	return len(c.parents)
 }
 
+// IsShallow returns whether this is one of the oldest commits of a shallow clone, whose parents are not in the
+// database.
+func (c *Commit) IsShallow() bool {
+	return len(c.missingParents) > 0
+}
+
 func (c *Commit) Height() (uint64, error) {
 	return c.dCommit.Height(), nil
 }
@@ -101,23 +178,33 @@ func (c *Commit) GetRootValue(ctx context.Context) (*RootValue, error) {
 }
 
 func (c *Commit) GetParent(ctx context.Context, idx int) (*Commit, error) {
-	return NewCommit(ctx, c.vrw, c.ns, c.parents[idx])
+	if len(c.missingParents) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAncestor, c.missingParents[idx].String())
+	}
+	return newCommit(ctx, c.vrw, c.ns, c.shallow, c.parents[idx])
 }
 
 var ErrNoCommonAncestor = errors.New("no common ancestor")
 
 func GetCommitAncestor(ctx context.Context, cm1, cm2 *Commit) (*Commit, error) {
+	// the history of a shallow clone ends at its shallow commits; in any other database a missing commit is an error
+	shallow := !cm1.shallow.empty() || !cm2.shallow.empty()
 	addr, err := getCommitAncestorAddr(ctx, cm1.dCommit, cm2.dCommit, cm1.vrw, cm2.vrw, cm1.ns, cm2.ns)
-	if err != nil {
+	if errors.Is(err, datas.ErrParentNotFound) && shallow {
+		return nil, fmt.Errorf("%w: %s", ErrMissingAncestor, err.Error())
+	} else if err != nil {
 		return nil, err
 	}
 
 	targetCommit, err := datas.LoadCommitAddr(ctx, cm1.vrw, addr)
-	if err != nil {
+	if err == datas.ErrCommitNotFound && shallow {
+		// the common ancestor is known from the commit closure, but was not fetched into this shallow clone
+		return nil, fmt.Errorf("%w: %s", ErrMissingAncestor, addr.String())
+	} else if err != nil {
 		return nil, err
 	}
 
-	return NewCommit(ctx, cm1.vrw, cm1.ns, targetCommit)
+	return newCommit(ctx, cm1.vrw, cm1.ns, cm1.shallow, targetCommit)
 }
 
 func getCommitAncestorAddr(ctx context.Context, c1, c2 *datas.Commit, vrw1, vrw2 types.ValueReadWriter, ns1, ns2 tree.NodeStore) (hash.Hash, error) {
@@ -29,7 +29,34 @@ import (
 	"github.com/dolthub/dolt/go/store/types"
 )
 
+// ReplicationStats records the pushes of the commit hooks that replicate databases. It does nothing by default, and
+// is set by servers that export metrics.
+var ReplicationStats ReplicationStatsRecorder = NullReplicationStatsRecorder{}
+
+// ReplicationStatsRecorder records the pushes of replication commit hooks. Every method is called with the name of
+// the database being replicated and the branch that was pushed, or the empty string for hooks that replicate every
+// branch of the database.
+type ReplicationStatsRecorder interface {
+	// RecordPush is called when a push succeeds. |lag| is the time from the first write that the push replicated
+	// to the end of the push.
+	RecordPush(dbName, branch string, lag time.Duration)
+	// RecordPushFailure is called when a push fails.
+	RecordPushFailure(dbName, branch string)
+}
+
+var _ ReplicationStatsRecorder = NullReplicationStatsRecorder{}
+
+type NullReplicationStatsRecorder struct {
+}
+
+func (NullReplicationStatsRecorder) RecordPush(dbName, branch string, lag time.Duration) {
+}
+
+func (NullReplicationStatsRecorder) RecordPushFailure(dbName, branch string) {
+}
+
 type PushOnWriteHook struct {
+	dbName string
 	destDB datas.Database
 	tmpDir string
 	out    io.Writer
@@ -37,15 +64,31 @@ type PushOnWriteHook struct {
 
 var _ CommitHook = (*PushOnWriteHook)(nil)
 
-// NewPushOnWriteHook creates a ReplicateHook, parameterizaed by the backup database
-// and a local tempfile for pushing
-func NewPushOnWriteHook(destDB *DoltDB, tmpDir string) *PushOnWriteHook {
-	return &PushOnWriteHook{destDB: destDB.db, tmpDir: tmpDir}
+// NewPushOnWriteHook creates a ReplicateHook, parameterizaed by the name of the database,
+// the backup database and a local tempfile for pushing
+func NewPushOnWriteHook(dbName string, destDB *DoltDB, tmpDir string) *PushOnWriteHook {
+	return &PushOnWriteHook{dbName: dbName, destDB: destDB.db, tmpDir: tmpDir}
 }
 
 // Execute implements CommitHook, replicates head updates to the destDb field
 func (ph *PushOnWriteHook) Execute(ctx context.Context, ds datas.Dataset, db datas.Database) error {
-	return pushDataset(ctx, ph.destDB, db, ph.tmpDir, ds)
+	start := time.Now()
+	err := pushDataset(ctx, ph.destDB, db, ph.tmpDir, ds)
+	recordPush(ph.dbName, ds.ID(), start, err)
+	return err
+}
+
+// recordPush records the push of dataset |id| that replicated the writes since |start| to ReplicationStats
+func recordPush(dbName, id string, start time.Time, err error) {
+	branch := id
+	if rf, perr := ref.Parse(id); perr == nil {
+		branch = rf.GetPath()
+	}
+	if err != nil {
+		ReplicationStats.RecordPushFailure(dbName, branch)
+	} else {
+		ReplicationStats.RecordPush(dbName, branch, time.Since(start))
+	}
 }
 
 // HandleError implements CommitHook
@@ -62,6 +105,11 @@ func (ph *PushOnWriteHook) SetLogger(ctx context.Context, wr io.Writer) error {
 	return nil
 }
 
+// ExecuteForWorkingSets implements CommitHook
+func (ph *PushOnWriteHook) ExecuteForWorkingSets() bool {
+	return false
+}
+
 // replicate pushes a dataset from srcDB to destDB and force sets the destDB ref to the new dataset value
 func pushDataset(ctx context.Context, destDB, srcDB datas.Database, tempTableDir string, ds datas.Dataset) error {
 	addr, ok := ds.MaybeHeadAddr()
@@ -106,6 +154,8 @@ type PushArg struct {
 	ds   datas.Dataset
 	db   datas.Database
 	hash hash.Hash
+	// written is the time of the write that is being pushed
+	written time.Time
 }
 
 type AsyncPushOnWriteHook struct {
@@ -123,9 +173,9 @@ const (
 var _ CommitHook = (*AsyncPushOnWriteHook)(nil)
 
 // NewAsyncPushOnWriteHook creates a AsyncReplicateHook
-func NewAsyncPushOnWriteHook(bThreads *sql.BackgroundThreads, destDB *DoltDB, tmpDir string, logger io.Writer) (*AsyncPushOnWriteHook, error) {
+func NewAsyncPushOnWriteHook(bThreads *sql.BackgroundThreads, dbName string, destDB *DoltDB, tmpDir string, logger io.Writer) (*AsyncPushOnWriteHook, error) {
 	ch := make(chan PushArg, asyncPushBufferSize)
-	err := RunAsyncReplicationThreads(bThreads, ch, destDB, tmpDir, logger)
+	err := RunAsyncReplicationThreads(bThreads, ch, dbName, destDB, tmpDir, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -135,11 +185,12 @@ func NewAsyncPushOnWriteHook(bThreads *sql.BackgroundThreads, destDB *DoltDB, tm
 // Execute implements CommitHook, replicates head updates to the destDb field
 func (ah *AsyncPushOnWriteHook) Execute(ctx context.Context, ds datas.Dataset, db datas.Database) error {
 	addr, _ := ds.MaybeHeadAddr()
+	p := PushArg{ds: ds, db: db, hash: addr, written: time.Now()}
 
 	select {
-	case ah.ch <- PushArg{ds: ds, db: db, hash: addr}:
+	case ah.ch <- p:
 	case <-ctx.Done():
-		ah.ch <- PushArg{ds: ds, db: db, hash: addr}
+		ah.ch <- p
 		return ctx.Err()
 	}
 	return nil
@@ -159,6 +210,11 @@ func (ah *AsyncPushOnWriteHook) SetLogger(ctx context.Context, wr io.Writer) err
 	return nil
 }
 
+// ExecuteForWorkingSets implements CommitHook
+func (ah *AsyncPushOnWriteHook) ExecuteForWorkingSets() bool {
+	return false
+}
+
 type LogHook struct {
 	msg []byte
 	out io.Writer
@@ -194,12 +250,21 @@ func (lh *LogHook) SetLogger(ctx context.Context, wr io.Writer) error {
 	return nil
 }
 
-func RunAsyncReplicationThreads(bThreads *sql.BackgroundThreads, ch chan PushArg, destDB *DoltDB, tmpDir string, logger io.Writer) error {
+// ExecuteForWorkingSets implements CommitHook
+func (lh *LogHook) ExecuteForWorkingSets() bool {
+	return false
+}
+
+func RunAsyncReplicationThreads(bThreads *sql.BackgroundThreads, ch chan PushArg, dbName string, destDB *DoltDB, tmpDir string, logger io.Writer) error {
 	mu := &sync.Mutex{}
 	var newHeads = make(map[string]PushArg, asyncPushBufferSize)
 
 	updateHead := func(p PushArg) {
 		mu.Lock()
+		// the lag of the next push is measured from the first write it replicates
+		if prev, ok := newHeads[p.ds.ID()]; ok && !prev.written.IsZero() {
+			p.written = prev.written
+		}
 		newHeads[p.ds.ID()] = p
 		mu.Unlock()
 	}
@@ -247,6 +312,16 @@ func RunAsyncReplicationThreads(bThreads *sql.BackgroundThreads, ch chan PushArg
 		return newHeadsCopy
 	}
 
+	// pushed marks the writes to dataset |id| up to |h| as replicated
+	pushed := func(id string, h hash.Hash) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p, ok := newHeads[id]; ok && p.hash == h {
+			p.written = time.Time{}
+			newHeads[id] = p
+		}
+	}
+
 	isNewHeads := func(newHeads map[string]PushArg) bool {
 		defer mu.Unlock()
 		mu.Lock()
@@ -262,9 +337,11 @@ func RunAsyncReplicationThreads(bThreads *sql.BackgroundThreads, ch chan PushArg
 			if latest, ok := latestHeads[id]; !ok || latest != newCm.hash {
 				// use background context to drain after sql context is canceled
 				err := pushDataset(context.Background(), destDB.db, newCm.db, tmpDir, newCm.ds)
+				recordPush(dbName, id, newCm.written, err)
 				if err != nil {
 					logger.Write([]byte("replication failed: " + err.Error()))
 				}
+				pushed(id, newCm.hash)
 				if newCm.hash.IsEmpty() {
 					delete(latestHeads, id)
 				} else {
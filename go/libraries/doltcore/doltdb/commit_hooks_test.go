@@ -125,7 +125,7 @@ func TestPushOnWriteHook(t *testing.T) {
 	}
 
 	// setup hook
-	hook := NewPushOnWriteHook(destDB, tmpDir)
+	hook := NewPushOnWriteHook("dolt", destDB, tmpDir)
 	ddb.SetCommitHooks(ctx, []CommitHook{hook})
 
 	t.Run("replicate to remote", func(t *testing.T) {
@@ -217,7 +217,7 @@ func TestAsyncPushOnWrite(t *testing.T) {
 
 	// setup hook
 	bThreads := sql.NewBackgroundThreads()
-	hook, err := NewAsyncPushOnWriteHook(bThreads, destDB, tmpDir, &buffer.Buffer{})
+	hook, err := NewAsyncPushOnWriteHook(bThreads, "dolt", destDB, tmpDir, &buffer.Buffer{})
 	if err != nil {
 		t.Fatal("Unexpected error creating push hook", err)
 	}
@@ -20,8 +20,6 @@ import (
 
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/hash"
-	"github.com/dolthub/dolt/go/store/prolly/tree"
-	"github.com/dolthub/dolt/go/store/types"
 )
 
 // CommitItr is an interface for iterating over a set of unique commits
@@ -39,6 +37,11 @@ type commitItr struct {
 	rootCommits []*Commit
 	currentRoot int
 
+	// excluded are the commits that are never visited, along with their ancestors
+	excluded map[hash.Hash]bool
+	// prune returns true for commits that are skipped along with their ancestors, unless they are reachable another way
+	prune CommitFilter
+
 	added       map[hash.Hash]bool
 	unprocessed []hash.Hash
 	curr        *Commit
@@ -77,10 +80,41 @@ func CommitItrForRoots(ddb *DoltDB, rootCommits ...*Commit) CommitItr {
 	}
 }
 
+// CommitItrForRange returns a CommitItr which will iterate over the ancestor commits of rootCommits that are not
+// ancestors of excludedCommits, like the revision range excluded..root of dolt log. The iteration doesn't walk past
+// the commits that prune returns true for, which are not returned either. prune may be nil.
+func CommitItrForRange(ctx context.Context, ddb *DoltDB, rootCommits []*Commit, excludedCommits []*Commit, prune CommitFilter) (CommitItr, error) {
+	excluded := make(map[hash.Hash]bool)
+	if len(excludedCommits) > 0 {
+		exItr := CommitItrForRoots(ddb, excludedCommits...)
+		for {
+			h, _, err := exItr.Next(ctx)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			excluded[h] = true
+		}
+	}
+
+	cmItr := &commitItr{
+		ddb:         ddb,
+		rootCommits: rootCommits,
+		excluded:    excluded,
+		prune:       prune,
+		unprocessed: make([]hash.Hash, 0, 4096),
+	}
+	return cmItr, cmItr.Reset(ctx)
+}
+
 func (cmItr *commitItr) Reset(ctx context.Context) error {
 	cmItr.curr = nil
 	cmItr.currentRoot = 0
-	cmItr.added = make(map[hash.Hash]bool, 4096)
+	cmItr.added = make(map[hash.Hash]bool, 4096+len(cmItr.excluded))
+	for h := range cmItr.excluded {
+		cmItr.added[h] = true
+	}
 	cmItr.unprocessed = cmItr.unprocessed[:0]
 
 	return nil
@@ -89,65 +123,72 @@ func (cmItr *commitItr) Reset(ctx context.Context) error {
 // Next returns the hash of the next commit, and a pointer to that commit.  It handles making sure the list of commits
 // returned are unique.  When complete Next will return hash.Hash{}, nil, io.EOF
 func (cmItr *commitItr) Next(ctx context.Context) (hash.Hash, *Commit, error) {
-	for cmItr.curr == nil {
-		if cmItr.currentRoot >= len(cmItr.rootCommits) {
-			return hash.Hash{}, nil, io.EOF
-		}
-
-		cm := cmItr.rootCommits[cmItr.currentRoot]
-		h, err := cm.HashOf()
-
-		if err != nil {
-			return hash.Hash{}, nil, err
+	for {
+		if cmItr.curr != nil {
+			parents, err := cmItr.curr.ParentHashes(ctx)
+
+			if err != nil {
+				return hash.Hash{}, nil, err
+			}
+
+			for _, h := range parents {
+				if !cmItr.added[h] {
+					cmItr.added[h] = true
+					cmItr.unprocessed = append(cmItr.unprocessed, h)
+				}
+			}
+			cmItr.curr = nil
 		}
 
-		if !cmItr.added[h] {
+		var h hash.Hash
+		var cm *Commit
+		var err error
+		if numUnprocessed := len(cmItr.unprocessed); numUnprocessed > 0 {
+			h = cmItr.unprocessed[numUnprocessed-1]
+			cmItr.unprocessed = cmItr.unprocessed[:numUnprocessed-1]
+			cm, err = hashToCommit(ctx, cmItr.ddb, h)
+
+			if err != nil {
+				return hash.Hash{}, nil, err
+			}
+		} else if cmItr.currentRoot < len(cmItr.rootCommits) {
+			cm = cmItr.rootCommits[cmItr.currentRoot]
+			cmItr.currentRoot++
+			h, err = cm.HashOf()
+
+			if err != nil {
+				return hash.Hash{}, nil, err
+			}
+
+			if cmItr.added[h] {
+				continue
+			}
 			cmItr.added[h] = true
-			cmItr.curr = cm
-			return h, cmItr.curr, nil
+		} else {
+			return hash.Hash{}, nil, io.EOF
 		}
 
-		cmItr.currentRoot++
-	}
-
-	parents, err := cmItr.curr.ParentHashes(ctx)
-
-	if err != nil {
-		return hash.Hash{}, nil, err
-	}
+		if cmItr.prune != nil {
+			pruned, err := cmItr.prune(ctx, h, cm)
 
-	for _, h := range parents {
-		if !cmItr.added[h] {
-			cmItr.added[h] = true
-			cmItr.unprocessed = append(cmItr.unprocessed, h)
+			if err != nil {
+				return hash.Hash{}, nil, err
+			} else if pruned {
+				continue
+			}
 		}
-	}
-
-	numUnprocessed := len(cmItr.unprocessed)
-
-	if numUnprocessed == 0 {
-		cmItr.curr = nil
-		cmItr.currentRoot++
-		return cmItr.Next(ctx)
-	}
-
-	next := cmItr.unprocessed[numUnprocessed-1]
-	cmItr.unprocessed = cmItr.unprocessed[:numUnprocessed-1]
-	cmItr.curr, err = hashToCommit(ctx, cmItr.ddb.ValueReadWriter(), cmItr.ddb.ns, next)
 
-	if err != nil {
-		return hash.Hash{}, nil, err
+		cmItr.curr = cm
+		return h, cm, nil
 	}
-
-	return next, cmItr.curr, nil
 }
 
-func hashToCommit(ctx context.Context, vrw types.ValueReadWriter, ns tree.NodeStore, h hash.Hash) (*Commit, error) {
-	dc, err := datas.LoadCommitAddr(ctx, vrw, h)
+func hashToCommit(ctx context.Context, ddb *DoltDB, h hash.Hash) (*Commit, error) {
+	dc, err := datas.LoadCommitAddr(ctx, ddb.vrw, h)
 	if err != nil {
 		return nil, err
 	}
-	return NewCommit(ctx, vrw, ns, dc)
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, dc)
 }
 
 // CommitFilter is a function that returns true if a commit should be filtered out, and false if it should be kept
@@ -30,6 +30,7 @@ import (
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/datas/pull"
 	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/nbs"
 	"github.com/dolthub/dolt/go/store/prolly/tree"
 	"github.com/dolthub/dolt/go/store/types"
 	"github.com/dolthub/dolt/go/store/types/edits"
@@ -65,9 +66,10 @@ var ErrCannotDeleteLastBranch = errors.New("cannot delete the last branch")
 // Additionally the noms codebase uses panics in a way that is non idiomatic and We've opted to recover and return
 // errors in many cases.
 type DoltDB struct {
-	db  hooksDatabase
-	vrw types.ValueReadWriter
-	ns  tree.NodeStore
+	db      hooksDatabase
+	vrw     types.ValueReadWriter
+	ns      tree.NodeStore
+	shallow *shallowCommits
 }
 
 // DoltDBFromCS creates a DoltDB from a noms chunks.ChunkStore
@@ -76,7 +78,7 @@ func DoltDBFromCS(cs chunks.ChunkStore) *DoltDB {
 	ns := tree.NewNodeStore(cs)
 	db := datas.NewTypesDatabase(vrw, ns)
 
-	return &DoltDB{hooksDatabase{Database: db}, vrw, ns}
+	return &DoltDB{hooksDatabase{Database: db, reflog: newMemReflog()}, vrw, ns, newShallowCommits()}
 }
 
 // HackDatasDatabaseFromDoltDB unwraps a DoltDB to a datas.Database.
@@ -116,7 +118,13 @@ func LoadDoltDBWithParams(ctx context.Context, nbf *types.NomsBinFormat, urlStr
 		return nil, err
 	}
 
-	return &DoltDB{hooksDatabase{Database: db}, vrw, ns}, nil
+	// the reflog of a local database is stored along with its noms files, other databases keep it in memory
+	var rl reflog = newMemReflog()
+	if dir, ok := dbfactory.LocalDirForURL(urlStr); ok {
+		rl = newFileReflog(filepath.Join(dir, ReflogFileName))
+	}
+
+	return &DoltDB{hooksDatabase{Database: db, reflog: rl}, vrw, ns, newShallowCommits()}, nil
 }
 
 // NomsRoot returns the hash of the noms dataset map
@@ -327,7 +335,7 @@ func (ddb *DoltDB) Resolve(ctx context.Context, cs *CommitSpec, cwb ref.DoltRef)
 		return nil, err
 	}
 
-	commit, err := NewCommit(ctx, ddb.vrw, ddb.ns, commitVal)
+	commit, err := newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, commitVal)
 	if err != nil {
 		return nil, err
 	}
@@ -341,7 +349,7 @@ func (ddb *DoltDB) ResolveCommitRef(ctx context.Context, ref ref.DoltRef) (*Comm
 	if err != nil {
 		return nil, err
 	}
-	return NewCommit(ctx, ddb.vrw, ddb.ns, commitVal)
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, commitVal)
 }
 
 // ResolveTag takes a TagRef and returns the corresponding Tag object.
@@ -359,7 +367,7 @@ func (ddb *DoltDB) ResolveTag(ctx context.Context, tagRef ref.TagRef) (*Tag, err
 		return nil, fmt.Errorf("tagRef head is not a tag")
 	}
 
-	return NewTag(ctx, tagRef.GetPath(), ds, ddb.vrw, ddb.ns)
+	return newTag(ctx, tagRef.GetPath(), ds, ddb.vrw, ddb.ns, ddb.shallow)
 }
 
 // ResolveWorkingSet takes a WorkingSetRef and returns the corresponding WorkingSet object.
@@ -378,7 +386,7 @@ func (ddb *DoltDB) ResolveWorkingSet(ctx context.Context, workingSetRef ref.Work
 		return nil, fmt.Errorf("workingSetRef head is not a workingSetRef")
 	}
 
-	return NewWorkingSet(ctx, workingSetRef.GetPath(), ddb.vrw, ddb.ns, ds)
+	return newWorkingSet(ctx, workingSetRef.GetPath(), ddb.vrw, ddb.ns, ddb.shallow, ds)
 }
 
 // TODO: convenience method to resolve the head commit of a branch.
@@ -425,7 +433,7 @@ func (ddb *DoltDB) ReadCommit(ctx context.Context, h hash.Hash) (*Commit, error)
 	if err != nil {
 		return nil, err
 	}
-	return NewCommit(ctx, ddb.vrw, ddb.ns, c)
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, c)
 }
 
 // Commit will update a branch's head value to be that of a previously committed root value hash
@@ -569,7 +577,7 @@ func (ddb *DoltDB) CommitValue(ctx context.Context, dref ref.DoltRef, val types.
 		return nil, err
 	}
 
-	return NewCommit(ctx, ddb.vrw, ddb.ns, dc)
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, dc)
 }
 
 // dangling commits are unreferenced by any branch or ref. They are created in the course of programmatic updates
@@ -610,7 +618,32 @@ func (ddb *DoltDB) CommitDangling(ctx context.Context, val types.Value, opts dat
 		return nil, err
 	}
 
-	return NewCommit(ctx, ddb.vrw, ddb.ns, dcommit)
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, dcommit)
+}
+
+// CommitDanglingInitial creates a new Commit with no parents for the root value with the hash |valHash|, which is not
+// referenced by any DoltRef.
+func (ddb *DoltDB) CommitDanglingInitial(ctx context.Context, valHash hash.Hash, cm *datas.CommitMeta) (*Commit, error) {
+	val, err := ddb.vrw.ReadValue(ctx, valHash)
+	if err != nil {
+		return nil, err
+	}
+	if !isRootValue(ddb.vrw.Format(), val) {
+		return nil, errors.New("can't commit a value that is not a valid root value")
+	}
+
+	cs := datas.ChunkStoreFromDatabase(ddb.db)
+	dcommit, err := datas.NewInitialCommitForValue(ctx, cs, ddb.vrw, ddb.ns, val, cm)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ddb.vrw.WriteValue(ctx, dcommit.NomsValue())
+	if err != nil {
+		return nil, err
+	}
+
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, dcommit)
 }
 
 // ValueReadWriter returns the underlying noms database as a types.ValueReadWriter.
@@ -626,6 +659,12 @@ func (ddb *DoltDB) Format() *types.NomsBinFormat {
 	return ddb.vrw.Format()
 }
 
+// SetShallowCommits sets the commits of a shallow clone whose parents were not fetched. These are the only commits of
+// the database that can be read without their parents; any other commit with a missing parent fails to read.
+func (ddb *DoltDB) SetShallowCommits(commits hash.HashSet) {
+	ddb.shallow.set(commits)
+}
+
 func WriteValAndGetRef(ctx context.Context, vrw types.ValueReadWriter, val types.Value) (types.Ref, error) {
 	valRef, err := types.NewRef(val, vrw.Format())
 
@@ -975,7 +1014,7 @@ func (ddb *DoltDB) UpdateWorkingSet(
 
 	// logrus.Tracef("Updating working set with root %s", workingSet.RootValue().DebugString(ctx, true))
 
-	workingRootRef, stagedRef, mergeState, err := workingSet.writeValues(ctx, ddb)
+	workingRootRef, stagedRef, mergeState, rebaseState, err := workingSet.writeValues(ctx, ddb)
 	if err != nil {
 		return err
 	}
@@ -985,6 +1024,7 @@ func (ddb *DoltDB) UpdateWorkingSet(
 		WorkingRoot: workingRootRef,
 		StagedRoot:  stagedRef,
 		MergeState:  mergeState,
+		RebaseState: rebaseState,
 	}, prevHash)
 
 	return err
@@ -1011,7 +1051,7 @@ func (ddb *DoltDB) CommitWithWorkingSet(
 		return nil, err
 	}
 
-	workingRootRef, stagedRef, mergeState, err := workingSet.writeValues(ctx, ddb)
+	workingRootRef, stagedRef, mergeState, rebaseState, err := workingSet.writeValues(ctx, ddb)
 	if err != nil {
 		return nil, err
 	}
@@ -1021,6 +1061,7 @@ func (ddb *DoltDB) CommitWithWorkingSet(
 		WorkingRoot: workingRootRef,
 		StagedRoot:  stagedRef,
 		MergeState:  mergeState,
+		RebaseState: rebaseState,
 	}, prevHash, commit.CommitOptions)
 
 	if err != nil {
@@ -1040,7 +1081,53 @@ func (ddb *DoltDB) CommitWithWorkingSet(
 		return nil, err
 	}
 
-	return NewCommit(ctx, ddb.vrw, ddb.ns, dc)
+	return newCommit(ctx, ddb.vrw, ddb.ns, ddb.shallow, dc)
+}
+
+// SetHeadWithWorkingSet combines the functionality of SetHeadToCommit with UpdateWorkingSet. It's a way to move
+// HEAD to an existing commit and update the working set in the same atomic transaction. It asserts that the working
+// set hash given is still current, and returns ErrHeadMoved unless HEAD is still at |prevHead|.
+func (ddb *DoltDB) SetHeadWithWorkingSet(
+	ctx context.Context,
+	headRef ref.DoltRef, workingSetRef ref.WorkingSetRef,
+	prevHead hash.Hash, head *Commit, workingSet *WorkingSet,
+	prevHash hash.Hash,
+	meta *datas.WorkingSetMeta,
+) error {
+	wsDs, err := ddb.db.GetDataset(ctx, workingSetRef.String())
+	if err != nil {
+		return err
+	}
+
+	headDs, err := ddb.db.GetDataset(ctx, headRef.String())
+	if err != nil {
+		return err
+	}
+	if currHead, _ := headDs.MaybeHeadAddr(); currHead != prevHead {
+		return ErrHeadMoved
+	}
+
+	headAddr, err := head.HashOf()
+	if err != nil {
+		return err
+	}
+
+	workingRootRef, stagedRef, mergeState, rebaseState, err := workingSet.writeValues(ctx, ddb)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = ddb.db.SetHeadWithWorkingSet(ctx, headDs, wsDs, headAddr, datas.WorkingSetSpec{
+		Meta:        meta,
+		WorkingRoot: workingRootRef,
+		StagedRoot:  stagedRef,
+		MergeState:  mergeState,
+		RebaseState: rebaseState,
+	}, prevHash)
+	if err == datas.ErrMergeNeeded {
+		return ErrHeadMoved
+	}
+	return err
 }
 
 // DeleteWorkingSet deletes the working set given
@@ -1113,7 +1200,14 @@ func (ddb *DoltDB) GC(ctx context.Context, uncommitedVals ...hash.Hash) error {
 	if err != nil {
 		return err
 	}
+	// the values that refs and working sets recently pointed to are kept, so that they can be recovered from the reflog
+	reflogVals, err := ddb.trimReflog(ctx)
+	if err != nil {
+		return err
+	}
+
 	newGen := hash.NewHashSet(uncommitedVals...)
+	newGen.InsertAll(hash.NewHashSet(reflogVals...))
 	oldGen := make(hash.HashSet)
 	err = datasets.IterAll(ctx, func(keyStr string, h hash.Hash) error {
 		var isOldGen bool
@@ -1141,7 +1235,112 @@ func (ddb *DoltDB) GC(ctx context.Context, uncommitedVals ...hash.Hash) error {
 		return err
 	}
 
-	return collector.GC(ctx, oldGen, newGen)
+	excluded, err := ddb.shallowMissingAddrs(ctx)
+	if err != nil {
+		return err
+	}
+
+	return collector.GC(ctx, oldGen, newGen, excluded)
+}
+
+// shallowMissingAddrs returns the commits a shallow clone references but never fetched: the parents of its shallow
+// commits and the ancestors recorded in their parents closures. A GC must not follow the references to them.
+func (ddb *DoltDB) shallowMissingAddrs(ctx context.Context) (hash.HashSet, error) {
+	shallow := ddb.shallow.all()
+	if len(shallow) == 0 {
+		return nil, nil
+	}
+
+	referenced := hash.NewHashSet()
+	for h := range shallow {
+		dc, err := datas.LoadCommitAddr(ctx, ddb.vrw, h)
+		if errors.Is(err, datas.ErrCommitNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		ph, err := datas.GetCommitParentAddrs(ctx, ddb.vrw, dc.NomsValue())
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ph {
+			referenced.Insert(p)
+		}
+
+		ancestors, ok, err := datas.GetCommitClosureAddrs(ctx, dc, ddb.vrw, ddb.ns)
+		if err != nil {
+			return nil, err
+		} else if ok {
+			referenced.InsertAll(ancestors)
+		}
+	}
+
+	return datas.ChunkStoreFromDatabase(ddb.db).HasMany(ctx, referenced)
+}
+
+// OnlineGC performs garbage collection on this ddb while it continues to serve reads and writes. Everything written
+// to the ddb while the collection runs is kept. |getRoots| is called once those writes are being tracked, and returns
+// the hashes of the values outside the ddb's datasets, such as the uncommitted roots of open sessions, that must also
+// be kept.
+func (ddb *DoltDB) OnlineGC(ctx context.Context, getRoots func(ctx context.Context) ([]hash.Hash, error)) error {
+	collector, ok := ddb.db.Database.(datas.GarbageCollector)
+	if !ok {
+		return fmt.Errorf("this database does not support garbage collection")
+	}
+
+	err := collector.BeginGC()
+	if err != nil {
+		return err
+	}
+	defer collector.EndGC()
+
+	roots, err := getRoots(ctx)
+	if err != nil {
+		return err
+	}
+
+	return ddb.GC(ctx, roots...)
+}
+
+// StoreSize returns the total size in bytes of the table files holding this ddb's data.
+func (ddb *DoltDB) StoreSize(ctx context.Context) (uint64, error) {
+	tfs, ok := datas.ChunkStoreFromDatabase(ddb.db).(nbs.TableFileStore)
+	if !ok {
+		return 0, chunks.ErrUnsupportedOperation
+	}
+	return tfs.Size(ctx)
+}
+
+// StoreStats returns the statistics collected by the chunk store of this ddb, such as an nbs.Stats, or nil if it
+// doesn't collect any.
+func (ddb *DoltDB) StoreStats() interface{} {
+	return datas.ChunkStoreFromDatabase(ddb.db).Stats()
+}
+
+// NewGenTableFileStats returns the number and total size of the table files written since this ddb's last garbage
+// collection.
+func (ddb *DoltDB) NewGenTableFileStats(ctx context.Context) (count int, size uint64, err error) {
+	cs := datas.ChunkStoreFromDatabase(ddb.db)
+	if gcs, ok := cs.(chunks.GenerationalCS); ok {
+		cs = gcs.NewGen()
+	}
+
+	tfs, ok := cs.(nbs.TableFileStore)
+	if !ok {
+		return 0, 0, chunks.ErrUnsupportedOperation
+	}
+
+	_, tableFiles, _, err := tfs.Sources(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, err = tfs.Size(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return len(tableFiles), size, nil
 }
 
 func (ddb *DoltDB) ShallowGC(ctx context.Context) error {
@@ -1186,9 +1385,15 @@ func (ddb *DoltDB) pruneUnreferencedDatasets(ctx context.Context) error {
 // given, pulling all chunks reachable from the given targetHash. Pull progress
 // is communicated over the provided channel.
 func (ddb *DoltDB) PullChunks(ctx context.Context, tempDir string, srcDB *DoltDB, targetHash hash.Hash, progChan chan pull.PullProgress, statsCh chan pull.Stats) error {
+	return ddb.PullChunksExcluding(ctx, tempDir, srcDB, targetHash, nil, progChan, statsCh)
+}
+
+// PullChunksExcluding is like PullChunks, but never pulls the chunks in |excluded|, nor the chunks only reachable
+// through them. Excluding the commits returned by ShallowBoundary pulls a shallow history.
+func (ddb *DoltDB) PullChunksExcluding(ctx context.Context, tempDir string, srcDB *DoltDB, targetHash hash.Hash, excluded hash.HashSet, progChan chan pull.PullProgress, statsCh chan pull.Stats) error {
 	srcCS := datas.ChunkStoreFromDatabase(srcDB.db)
 	destCS := datas.ChunkStoreFromDatabase(ddb.db)
-	waf := types.WalkAddrsForNBF(srcDB.Format())
+	waf := pull.WalkAddrsExcluding(types.WalkAddrsForNBF(srcDB.Format()), excluded)
 
 	if datas.CanUsePuller(srcDB.db) && datas.CanUsePuller(ddb.db) {
 		puller, err := pull.NewPuller(ctx, tempDir, defaultChunksPerTF, srcCS, destCS, waf, targetHash, statsCh)
@@ -1204,6 +1409,131 @@ func (ddb *DoltDB) PullChunks(ctx context.Context, tempDir string, srcDB *DoltDB
 	}
 }
 
+// ShallowBoundary walks the history of the commits in |heads| up to |depth| commits from the heads. It returns the
+// commits a shallow pull of the heads excludes, and the commits within the depth that have a parent beyond it, which
+// become the shallow commits of the pulled history. Commits that are already shallow in this database are shallow in
+// the result as well. With a |depth| of zero, the heads and their whole history are excluded.
+//
+// The walk stops at the commits |depth| commits from the heads, the boundary, without reading any commit beyond it.
+// A pull stops at the boundary commits, but the parents closures of the commits it keeps reference every ancestor, so
+// the ancestors of the boundary commits are excluded as well. They are read from the parents closures of the boundary
+// commits, and only the history of boundary commits without a parents closure is walked.
+func (ddb *DoltDB) ShallowBoundary(ctx context.Context, heads []hash.Hash, depth int) (excluded, shallow hash.HashSet, err error) {
+	excluded, shallow = hash.NewHashSet(), hash.NewHashSet()
+
+	// a breadth first walk visits every commit at its shortest distance from a head
+	distances := make(map[hash.Hash]int)
+	parents := make(map[hash.Hash][]hash.Hash)
+	var queue, boundary []hash.Hash
+	for _, h := range heads {
+		if _, ok := distances[h]; !ok {
+			distances[h] = 0
+			queue = append(queue, h)
+		}
+	}
+
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		dist := distances[h]
+		if dist >= depth {
+			excluded.Insert(h)
+			boundary = append(boundary, h)
+			continue
+		}
+
+		cm, err := ddb.ReadCommit(ctx, h)
+		if errors.Is(err, datas.ErrCommitNotFound) {
+			// this database is a shallow clone itself, and doesn't have the commit either
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if cm.IsShallow() {
+			shallow.Insert(h)
+			continue
+		}
+
+		ph, err := cm.ParentHashes(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		parents[h] = ph
+
+		for _, p := range ph {
+			if _, ok := distances[p]; !ok {
+				distances[p] = dist + 1
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	for h, ph := range parents {
+		for _, p := range ph {
+			if excluded.Has(p) {
+				shallow.Insert(h)
+				break
+			}
+		}
+	}
+
+	kept := hash.NewHashSet()
+	for h, dist := range distances {
+		if dist < depth {
+			kept.Insert(h)
+		}
+	}
+	err = ddb.excludeAncestors(ctx, boundary, kept, excluded)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return excluded, shallow, nil
+}
+
+// excludeAncestors adds the ancestors of the commits in |commits| to |excluded|, leaving out the commits in |kept|.
+func (ddb *DoltDB) excludeAncestors(ctx context.Context, commits []hash.Hash, kept, excluded hash.HashSet) error {
+	queue := append([]hash.Hash(nil), commits...)
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		dc, err := datas.LoadCommitAddr(ctx, ddb.vrw, h)
+		if errors.Is(err, datas.ErrCommitNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		ancestors, ok, err := datas.GetCommitClosureAddrs(ctx, dc, ddb.vrw, ddb.ns)
+		if err != nil {
+			return err
+		} else if ok {
+			for a := range ancestors {
+				if !kept.Has(a) {
+					excluded.Insert(a)
+				}
+			}
+			continue
+		}
+
+		// without a parents closure, the history is walked one commit at a time
+		ph, err := datas.GetCommitParentAddrs(ctx, ddb.vrw, dc.NomsValue())
+		if err != nil {
+			return err
+		}
+		for _, p := range ph {
+			if !excluded.Has(p) && !kept.Has(p) {
+				excluded.Insert(p)
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	return nil
+}
+
 func (ddb *DoltDB) Clone(ctx context.Context, destDB *DoltDB, eventCh chan<- pull.TableFileEvent) error {
 	return pull.Clone(ctx, datas.ChunkStoreFromDatabase(ddb.db), datas.ChunkStoreFromDatabase(destDB.db), eventCh)
 }
@@ -1213,6 +1543,12 @@ func (ddb *DoltDB) SetCommitHooks(ctx context.Context, postHooks []CommitHook) *
 	return ddb
 }
 
+// PrependCommitHook adds |hook| to the commit hooks of |ddb|, ahead of the hooks it already has.
+func (ddb *DoltDB) PrependCommitHook(ctx context.Context, hook CommitHook) *DoltDB {
+	ddb.db = ddb.db.SetCommitHooks(ctx, append([]CommitHook{hook}, ddb.db.PostCommitHooks()...))
+	return ddb
+}
+
 func (ddb *DoltDB) SetCommitHookLogger(ctx context.Context, wr io.Writer) *DoltDB {
 	if ddb.db.Database != nil {
 		ddb.db = ddb.db.SetCommitHookLogger(ctx, wr)
@@ -1225,7 +1561,7 @@ func (ddb *DoltDB) ExecuteCommitHooks(ctx context.Context, datasetId string) err
 	if err != nil {
 		return err
 	}
-	ddb.db.ExecuteCommitHooks(ctx, ds)
+	ddb.db.ExecuteCommitHooks(ctx, ds, false)
 	return nil
 }
 
@@ -353,3 +353,140 @@ func TestLDNoms(t *testing.T) {
 		}
 	}
 }
+
+func TestShallowBoundary(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_Default, InMemDoltDB, filesys.LocalFS)
+	require.NoError(t, err)
+	err = ddb.WriteEmptyRepo(ctx, "master", "Bill Billerson", "bigbillieb@fake.horse")
+	require.NoError(t, err)
+
+	master := ref.NewBranchRef("master")
+	other := ref.NewBranchRef("other")
+
+	cm, err := ddb.ResolveCommitRef(ctx, master)
+	require.NoError(t, err)
+	root, err := cm.GetRootValue(ctx)
+	require.NoError(t, err)
+	_, valHash, err := ddb.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+
+	commit := func(dref ref.DoltRef, desc string) hash.Hash {
+		meta, err := datas.NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", desc)
+		require.NoError(t, err)
+		cm, err := ddb.Commit(ctx, valHash, dref, meta)
+		require.NoError(t, err)
+		h, err := cm.HashOf()
+		require.NoError(t, err)
+		return h
+	}
+
+	// c0 -- c1 -- c2 -- c3 (master)
+	//         \
+	//          c4 (other)
+	c0, err := cm.HashOf()
+	require.NoError(t, err)
+	c1 := commit(master, "c1")
+	c1Commit, err := ddb.ResolveCommitRef(ctx, master)
+	require.NoError(t, err)
+	require.NoError(t, ddb.NewBranchAtCommit(ctx, other, c1Commit))
+	c2 := commit(master, "c2")
+	c3 := commit(master, "c3")
+	c4 := commit(other, "c4")
+
+	tests := []struct {
+		name     string
+		heads    []hash.Hash
+		depth    int
+		excluded hash.HashSet
+		shallow  hash.HashSet
+	}{
+		{
+			name:     "single head",
+			heads:    []hash.Hash{c3},
+			depth:    2,
+			excluded: hash.NewHashSet(c1, c0),
+			shallow:  hash.NewHashSet(c2),
+		},
+		{
+			name:     "depth beyond history",
+			heads:    []hash.Hash{c3},
+			depth:    10,
+			excluded: hash.NewHashSet(),
+			shallow:  hash.NewHashSet(),
+		},
+		{
+			name:     "commits kept for one head are not excluded for another",
+			heads:    []hash.Hash{c3, c4},
+			depth:    2,
+			excluded: hash.NewHashSet(c0),
+			shallow:  hash.NewHashSet(c1),
+		},
+		{
+			name:     "heads that are ancestors of the boundary are kept",
+			heads:    []hash.Hash{c3, c1},
+			depth:    1,
+			excluded: hash.NewHashSet(c2, c0),
+			shallow:  hash.NewHashSet(c3, c1),
+		},
+		{
+			name:     "zero depth",
+			heads:    []hash.Hash{c4},
+			depth:    0,
+			excluded: hash.NewHashSet(c4, c1, c0),
+			shallow:  hash.NewHashSet(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			excluded, shallow, err := ddb.ShallowBoundary(ctx, test.heads, test.depth)
+			require.NoError(t, err)
+			assert.Equal(t, test.excluded, excluded)
+			assert.Equal(t, test.shallow, shallow)
+		})
+	}
+}
+
+func TestShallowCommits(t *testing.T) {
+	ctx := context.Background()
+	srcDB, err := LoadDoltDB(ctx, types.Format_Default, InMemDoltDB, filesys.LocalFS)
+	require.NoError(t, err)
+	err = srcDB.WriteEmptyRepo(ctx, "master", "Bill Billerson", "bigbillieb@fake.horse")
+	require.NoError(t, err)
+
+	master := ref.NewBranchRef("master")
+	cm, err := srcDB.ResolveCommitRef(ctx, master)
+	require.NoError(t, err)
+	c0, err := cm.HashOf()
+	require.NoError(t, err)
+	root, err := cm.GetRootValue(ctx)
+	require.NoError(t, err)
+	_, valHash, err := srcDB.WriteRootValue(ctx, root)
+	require.NoError(t, err)
+	meta, err := datas.NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", "c1")
+	require.NoError(t, err)
+	cm, err = srcDB.Commit(ctx, valHash, master, meta)
+	require.NoError(t, err)
+	c1, err := cm.HashOf()
+	require.NoError(t, err)
+
+	// pull c1 without its parent c0
+	ddb, err := LoadDoltDB(ctx, types.Format_Default, InMemDoltDB, filesys.LocalFS)
+	require.NoError(t, err)
+	err = ddb.PullChunksExcluding(ctx, t.TempDir(), srcDB, c1, hash.NewHashSet(c0), nil, nil)
+	require.NoError(t, err)
+
+	_, err = ddb.ReadCommit(ctx, c1)
+	assert.ErrorIs(t, err, datas.ErrParentNotFound)
+
+	ddb.SetShallowCommits(hash.NewHashSet(c1))
+	cm, err = ddb.ReadCommit(ctx, c1)
+	require.NoError(t, err)
+	assert.True(t, cm.IsShallow())
+	parents, err := cm.ParentHashes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{c0}, parents)
+	_, err = cm.GetParent(ctx, 0)
+	assert.ErrorIs(t, err, ErrMissingAncestor)
+}
@@ -40,6 +40,10 @@ var ErrTableExists = errors.New("table already exists")
 var ErrAlreadyOnBranch = errors.New("Already on branch")
 var ErrAlreadyOnWorkspace = errors.New("Already on workspace")
 
+// ErrMissingAncestor is returned when walking the history of a commit reaches a commit that is not in the database,
+// which happens beyond the oldest commits of a shallow clone.
+var ErrMissingAncestor = errors.New("commit history is incomplete: an ancestor commit was not fetched into this shallow clone")
+
 var ErrNomsIO = errors.New("error reading from or writing to noms")
 
 var ErrUpToDate = errors.New("up to date")
@@ -49,6 +53,10 @@ var ErrIsBehind = errors.New("cannot reverse from b to a. b is a is behind a alr
 var ErrUnresolvedConflictsOrViolations = errors.New("merge has unresolved conflicts or constraint violations")
 var ErrMergeActive = errors.New("merging is not possible because you have not committed an active merge")
 
+// ErrHeadMoved is returned when a branch is moved along with its working set, and another writer moved the branch
+// since it was read.
+var ErrHeadMoved = errors.New("the branch was moved by another transaction")
+
 type ErrClientOutOfDate struct {
 	RepoVer   FeatureVersion
 	ClientVer FeatureVersion
@@ -17,6 +17,7 @@ package doltdb_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/dolthub/go-mysql-server/sql"
 	"github.com/stretchr/testify/assert"
@@ -37,55 +38,85 @@ type stage struct {
 }
 
 type gcTest struct {
-	name       string
-	stages     []stage
-	query      string
-	expected   []sql.Row
-	postGCFunc func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, prevRes interface{})
+	name   string
+	stages []stage
+	// reflogRetention is how long gc keeps the values of the reflog
+	reflogRetention time.Duration
+	query           string
+	expected        []sql.Row
+	postGCFunc      func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, prevRes interface{})
 }
 
-var gcTests = []gcTest{
-	{
-		name: "gc test",
-		stages: []stage{
-			{
-				preStageFunc: func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, i interface{}) interface{} {
-					return nil
-				},
-				commands: []testCommand{
-					{commands.CheckoutCmd{}, []string{"-b", "temp"}},
-					{commands.SqlCmd{}, []string{"-q", "INSERT INTO test VALUES (0),(1),(2);"}},
-					{commands.AddCmd{}, []string{"."}},
-					{commands.CommitCmd{}, []string{"-m", "commit"}},
-				},
+func deletedBranchStages() []stage {
+	return []stage{
+		{
+			preStageFunc: func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, i interface{}) interface{} {
+				return nil
+			},
+			commands: []testCommand{
+				{commands.CheckoutCmd{}, []string{"-b", "temp"}},
+				{commands.SqlCmd{}, []string{"-q", "INSERT INTO test VALUES (0),(1),(2);"}},
+				{commands.AddCmd{}, []string{"."}},
+				{commands.CommitCmd{}, []string{"-m", "commit"}},
 			},
-			{
-				preStageFunc: func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, i interface{}) interface{} {
-					cm, err := ddb.ResolveCommitRef(ctx, ref.NewBranchRef("temp"))
-					require.NoError(t, err)
-					h, err := cm.HashOf()
-					require.NoError(t, err)
-					cs, err := doltdb.NewCommitSpec(h.String())
-					require.NoError(t, err)
-					_, err = ddb.Resolve(ctx, cs, nil)
-					require.NoError(t, err)
-					return h
-				},
-				commands: []testCommand{
-					{commands.CheckoutCmd{}, []string{env.DefaultInitBranch}},
-					{commands.BranchCmd{}, []string{"-D", "temp"}},
-					{commands.SqlCmd{}, []string{"-q", "INSERT INTO test VALUES (4),(5),(6);"}},
-				},
+		},
+		{
+			preStageFunc: func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, i interface{}) interface{} {
+				cm, err := ddb.ResolveCommitRef(ctx, ref.NewBranchRef("temp"))
+				require.NoError(t, err)
+				h, err := cm.HashOf()
+				require.NoError(t, err)
+				cs, err := doltdb.NewCommitSpec(h.String())
+				require.NoError(t, err)
+				_, err = ddb.Resolve(ctx, cs, nil)
+				require.NoError(t, err)
+				return h
+			},
+			commands: []testCommand{
+				{commands.CheckoutCmd{}, []string{env.DefaultInitBranch}},
+				{commands.BranchCmd{}, []string{"-D", "temp"}},
+				{commands.SqlCmd{}, []string{"-q", "INSERT INTO test VALUES (4),(5),(6);"}},
 			},
 		},
-		query:    "select * from test;",
-		expected: []sql.Row{{int32(4)}, {int32(5)}, {int32(6)}},
+	}
+}
+
+var gcTests = []gcTest{
+	{
+		name:            "gc test",
+		stages:          deletedBranchStages(),
+		reflogRetention: 0,
+		query:           "select * from test;",
+		expected:        []sql.Row{{int32(4)}, {int32(5)}, {int32(6)}},
 		postGCFunc: func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, prevRes interface{}) {
 			h := prevRes.(hash.Hash)
 			cs, err := doltdb.NewCommitSpec(h.String())
 			require.NoError(t, err)
 			_, err = ddb.Resolve(ctx, cs, nil)
 			require.Error(t, err)
+
+			// the entries past the retention period are trimmed from the reflog
+			entries, err := ddb.Reflog(ctx, "", true)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		},
+	},
+	{
+		name:            "gc keeps the commits of recent reflog entries",
+		stages:          deletedBranchStages(),
+		reflogRetention: time.Hour,
+		query:           "select * from test;",
+		expected:        []sql.Row{{int32(4)}, {int32(5)}, {int32(6)}},
+		postGCFunc: func(ctx context.Context, t *testing.T, ddb *doltdb.DoltDB, prevRes interface{}) {
+			h := prevRes.(hash.Hash)
+			cs, err := doltdb.NewCommitSpec(h.String())
+			require.NoError(t, err)
+			_, err = ddb.Resolve(ctx, cs, nil)
+			require.NoError(t, err)
+
+			entries, err := ddb.Reflog(ctx, "temp", false)
+			require.NoError(t, err)
+			assert.NotEmpty(t, entries)
 		},
 	},
 }
@@ -109,6 +140,11 @@ func TestGarbageCollection(t *testing.T) {
 
 func testGarbageCollection(t *testing.T, test gcTest) {
 	ctx := context.Background()
+	defer func(retention time.Duration) {
+		doltdb.ReflogRetention = retention
+	}(doltdb.ReflogRetention)
+	doltdb.ReflogRetention = test.reflogRetention
+
 	dEnv := dtestutils.CreateTestEnv()
 
 	for _, c := range gcSetupCommon {
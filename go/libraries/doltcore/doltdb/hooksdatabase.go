@@ -17,6 +17,9 @@ package doltdb
 import (
 	"context"
 	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
 
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/hash"
@@ -26,6 +29,8 @@ import (
 type hooksDatabase struct {
 	datas.Database
 	postCommitHooks []CommitHook
+	// reflog records the updates of refs and working sets, nil if they are not recorded
+	reflog reflog
 }
 
 // CommitHook is an abstraction for executing arbitrary commands after atomic database commits
@@ -36,6 +41,8 @@ type CommitHook interface {
 	HandleError(ctx context.Context, err error) error
 	// SetLogger lets clients specify an output stream for HandleError
 	SetLogger(ctx context.Context, wr io.Writer) error
+	// ExecuteForWorkingSets returns whether the hook should also be executed for updates of working sets
+	ExecuteForWorkingSets() bool
 }
 
 func (db hooksDatabase) SetCommitHooks(ctx context.Context, postHooks []CommitHook) hooksDatabase {
@@ -54,22 +61,51 @@ func (db hooksDatabase) PostCommitHooks() []CommitHook {
 	return db.postCommitHooks
 }
 
-func (db hooksDatabase) ExecuteCommitHooks(ctx context.Context, ds datas.Dataset) {
+func (db hooksDatabase) ExecuteCommitHooks(ctx context.Context, ds datas.Dataset, onlyWS bool) {
 	var err error
 	for _, hook := range db.postCommitHooks {
-		err = hook.Execute(ctx, ds, db)
-		if err != nil {
-			hook.HandleError(ctx, err)
+		if !onlyWS || hook.ExecuteForWorkingSets() {
+			err = hook.Execute(ctx, ds, db)
+			if err != nil {
+				hook.HandleError(ctx, err)
+			}
 		}
 	}
 }
 
+// recordMove appends an entry for the update of |prev| into |next| to the reflog.
+func (db hooksDatabase) recordMove(ctx context.Context, action string, prev datas.Dataset, next datas.Dataset) {
+	oldHash, _ := prev.MaybeHeadAddr()
+	db.recordMoveFrom(ctx, action, oldHash, next)
+}
+
+// recordMoveFrom appends an entry for the update of |next| from |oldHash| to the reflog. The update has already
+// happened by then, so a failure to record it is logged rather than returned.
+func (db hooksDatabase) recordMoveFrom(ctx context.Context, action string, oldHash hash.Hash, next datas.Dataset) {
+	if db.reflog == nil || !isReflogDataset(next.ID()) {
+		return
+	}
+
+	newHash, _ := next.MaybeHeadAddr()
+	err := db.reflog.append(ctx, ReflogEntry{
+		Ref:       next.ID(),
+		OldHash:   oldHash,
+		NewHash:   newHash,
+		Action:    action,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		logrus.Warnf("failed to record the update of %s in the reflog: %v", next.ID(), err)
+	}
+}
+
 func (db hooksDatabase) CommitWithWorkingSet(
 	ctx context.Context,
 	commitDS, workingSetDS datas.Dataset,
 	val types.Value, workingSetSpec datas.WorkingSetSpec,
 	prevWsHash hash.Hash, opts datas.CommitOptions,
 ) (datas.Dataset, datas.Dataset, error) {
+	prevCommitDS := commitDS
 	commitDS, workingSetDS, err := db.Database.CommitWithWorkingSet(
 		ctx,
 		commitDS,
@@ -79,39 +115,93 @@ func (db hooksDatabase) CommitWithWorkingSet(
 		prevWsHash,
 		opts)
 	if err == nil {
-		db.ExecuteCommitHooks(ctx, commitDS)
+		db.recordMove(ctx, ReflogActionCommit, prevCommitDS, commitDS)
+		db.recordMoveFrom(ctx, ReflogActionCommit, prevWsHash, workingSetDS)
+		db.ExecuteCommitHooks(ctx, commitDS, false)
 	}
 	return commitDS, workingSetDS, err
 }
 
+func (db hooksDatabase) SetHeadWithWorkingSet(
+	ctx context.Context,
+	headDS, workingSetDS datas.Dataset,
+	headAddr hash.Hash, workingSetSpec datas.WorkingSetSpec,
+	prevWsHash hash.Hash,
+) (datas.Dataset, datas.Dataset, error) {
+	prevHeadDS := headDS
+	headDS, workingSetDS, err := db.Database.SetHeadWithWorkingSet(ctx, headDS, workingSetDS, headAddr, workingSetSpec, prevWsHash)
+	if err == nil {
+		db.recordMove(ctx, ReflogActionSetHead, prevHeadDS, headDS)
+		db.recordMoveFrom(ctx, ReflogActionUpdateWorkingSet, prevWsHash, workingSetDS)
+		db.ExecuteCommitHooks(ctx, headDS, false)
+	}
+	return headDS, workingSetDS, err
+}
+
+func (db hooksDatabase) UpdateWorkingSet(ctx context.Context, ds datas.Dataset, workingSet datas.WorkingSetSpec, prevHash hash.Hash) (datas.Dataset, error) {
+	ds, err := db.Database.UpdateWorkingSet(ctx, ds, workingSet, prevHash)
+	if err == nil {
+		db.recordMoveFrom(ctx, ReflogActionUpdateWorkingSet, prevHash, ds)
+		db.ExecuteCommitHooks(ctx, ds, true)
+	}
+	return ds, err
+}
+
 func (db hooksDatabase) Commit(ctx context.Context, ds datas.Dataset, v types.Value, opts datas.CommitOptions) (datas.Dataset, error) {
+	prev := ds
 	ds, err := db.Database.Commit(ctx, ds, v, opts)
 	if err == nil {
-		db.ExecuteCommitHooks(ctx, ds)
+		db.recordMove(ctx, ReflogActionCommit, prev, ds)
+		db.ExecuteCommitHooks(ctx, ds, false)
 	}
 	return ds, err
 }
 
 func (db hooksDatabase) SetHead(ctx context.Context, ds datas.Dataset, newHeadAddr hash.Hash) (datas.Dataset, error) {
+	prev := ds
 	ds, err := db.Database.SetHead(ctx, ds, newHeadAddr)
 	if err == nil {
-		db.ExecuteCommitHooks(ctx, ds)
+		db.recordMove(ctx, ReflogActionSetHead, prev, ds)
+		db.ExecuteCommitHooks(ctx, ds, false)
+	}
+	return ds, err
+}
+
+func (db hooksDatabase) CheckAndSetHead(ctx context.Context, ds datas.Dataset, newHeadAddr hash.Hash) (datas.Dataset, error) {
+	prev := ds
+	ds, err := db.Database.CheckAndSetHead(ctx, ds, newHeadAddr)
+	if err == nil {
+		db.recordMove(ctx, ReflogActionSetHead, prev, ds)
+		db.ExecuteCommitHooks(ctx, ds, false)
 	}
 	return ds, err
 }
 
 func (db hooksDatabase) FastForward(ctx context.Context, ds datas.Dataset, newHeadAddr hash.Hash) (datas.Dataset, error) {
+	prev := ds
 	ds, err := db.Database.FastForward(ctx, ds, newHeadAddr)
 	if err == nil {
-		db.ExecuteCommitHooks(ctx, ds)
+		db.recordMove(ctx, ReflogActionFastForward, prev, ds)
+		db.ExecuteCommitHooks(ctx, ds, false)
 	}
 	return ds, err
 }
 
 func (db hooksDatabase) Delete(ctx context.Context, ds datas.Dataset) (datas.Dataset, error) {
+	prev := ds
 	ds, err := db.Database.Delete(ctx, ds)
 	if err == nil {
-		db.ExecuteCommitHooks(ctx, datas.NewHeadlessDataset(ds.Database(), ds.ID()))
+		db.recordMove(ctx, ReflogActionDelete, prev, ds)
+		db.ExecuteCommitHooks(ctx, datas.NewHeadlessDataset(ds.Database(), ds.ID()), false)
+	}
+	return ds, err
+}
+
+func (db hooksDatabase) Tag(ctx context.Context, ds datas.Dataset, commitAddr hash.Hash, opts datas.TagOptions) (datas.Dataset, error) {
+	prev := ds
+	ds, err := db.Database.Tag(ctx, ds, commitAddr, opts)
+	if err == nil {
+		db.recordMove(ctx, ReflogActionTag, prev, ds)
 	}
 	return ds, err
 }
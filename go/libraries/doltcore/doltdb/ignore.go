@@ -0,0 +1,251 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb/durable"
+	"github.com/dolthub/dolt/go/libraries/doltcore/row"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/dolthub/dolt/go/store/prolly/shim"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+var doltIgnoreColumns = schema.NewColCollection(
+	schema.NewColumn(IgnorePatternCol, schema.DoltIgnorePatternTag, types.StringKind, true, schema.NotNullConstraint{}),
+	mustIgnoredColumn(),
+)
+
+// mustIgnoredColumn returns the ignored column of the dolt_ignore table. It's a BOOLEAN, which is stored as an int8,
+// so that it reads the same in both storage formats.
+func mustIgnoredColumn() schema.Column {
+	col, err := schema.NewColumnWithTypeInfo(IgnoreIgnoredCol, schema.DoltIgnoreIgnoredTag, typeinfo.Int8Type, false, "", false, "", schema.NotNullConstraint{})
+	if err != nil {
+		panic(err)
+	}
+	return col
+}
+
+// IgnoreSchema is the schema of the dolt_ignore table
+var IgnoreSchema = schema.MustSchemaFromCols(doltIgnoreColumns)
+
+var ignoreKd = shim.KeyDescriptorFromSchema(IgnoreSchema)
+var ignoreVd = shim.ValueDescriptorFromSchema(IgnoreSchema)
+
+// IgnorePattern is a single row of the dolt_ignore table. Tables whose names match |Pattern| are ignored if |Ignore|
+// is true. A pattern may contain the wildcards '*', which matches any sequence of characters, and '?', which matches
+// any single character.
+type IgnorePattern struct {
+	Pattern string
+	Ignore  bool
+}
+
+// IgnorePatterns is the set of patterns in the dolt_ignore table of a root value
+type IgnorePatterns []IgnorePattern
+
+// DoltIgnoreConflictError is returned when a table name matches patterns in dolt_ignore that disagree on whether the
+// table should be ignored.
+type DoltIgnoreConflictError struct {
+	Table         string
+	TruePatterns  []string
+	FalsePatterns []string
+}
+
+func (dc DoltIgnoreConflictError) Error() string {
+	return fmt.Sprintf("the table %s matches conflicting patterns in dolt_ignore: ignored: [%s], not ignored: [%s]",
+		dc.Table, strings.Join(dc.TruePatterns, ", "), strings.Join(dc.FalsePatterns, ", "))
+}
+
+// AsDoltIgnoreConflictError returns the error given as a DoltIgnoreConflictError, and whether it is one.
+func AsDoltIgnoreConflictError(err error) (DoltIgnoreConflictError, bool) {
+	dc, ok := err.(DoltIgnoreConflictError)
+	return dc, ok
+}
+
+// GetIgnoredTablePatterns returns the patterns in the dolt_ignore table of the working root in |roots|. If there is no
+// dolt_ignore table, no patterns are returned.
+func GetIgnoredTablePatterns(ctx context.Context, roots Roots) (IgnorePatterns, error) {
+	tbl, ok, err := roots.Working.GetTable(ctx, IgnoreTableName)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	if types.IsFormat_DOLT_1(tbl.Format()) {
+		return getIgnorePatternsProlly(ctx, tbl)
+	}
+	return getIgnorePatternsNoms(ctx, tbl)
+}
+
+func getIgnorePatternsProlly(ctx context.Context, tbl *Table) (IgnorePatterns, error) {
+	idx, err := tbl.GetRowData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := durable.ProllyMapFromIndex(idx)
+
+	iter, err := m.IterAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns IgnorePatterns
+	for {
+		k, v, err := iter.Next(ctx)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		pattern, _ := ignoreKd.GetString(0, k)
+		ignored, _ := ignoreVd.GetInt8(0, v)
+		patterns = append(patterns, IgnorePattern{Pattern: pattern, Ignore: ignored != 0})
+	}
+
+	return patterns, nil
+}
+
+func getIgnorePatternsNoms(ctx context.Context, tbl *Table) (IgnorePatterns, error) {
+	m, err := tbl.GetNomsRowData(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var patterns IgnorePatterns
+	err = m.IterAll(ctx, func(key, value types.Value) error {
+		kv, err := row.ParseTaggedValues(key.(types.Tuple))
+		if err != nil {
+			return err
+		}
+		vv, err := row.ParseTaggedValues(value.(types.Tuple))
+		if err != nil {
+			return err
+		}
+
+		pattern := kv.GetWithDefault(schema.DoltIgnorePatternTag, types.String(""))
+		ignored := vv.GetWithDefault(schema.DoltIgnoreIgnoredTag, types.Int(0))
+		patterns = append(patterns, IgnorePattern{
+			Pattern: string(pattern.(types.String)),
+			Ignore:  ignored.(types.Int) != 0,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return patterns, nil
+}
+
+// IsTableNameIgnored returns whether the table name given is ignored by these patterns. A pattern without wildcards
+// that matches the table name takes precedence over all other patterns. Otherwise, if the table name matches patterns
+// that disagree on whether it should be ignored, a DoltIgnoreConflictError is returned.
+func (ip IgnorePatterns) IsTableNameIgnored(tableName string) (bool, error) {
+	var truePatterns, falsePatterns []string
+	for _, p := range ip {
+		if !strings.ContainsAny(p.Pattern, "*?") {
+			if strings.EqualFold(p.Pattern, tableName) {
+				return p.Ignore, nil
+			}
+			continue
+		}
+
+		re, err := compileIgnorePattern(p.Pattern)
+		if err != nil {
+			return false, err
+		}
+		if !re.MatchString(tableName) {
+			continue
+		}
+
+		if p.Ignore {
+			truePatterns = append(truePatterns, p.Pattern)
+		} else {
+			falsePatterns = append(falsePatterns, p.Pattern)
+		}
+	}
+
+	if len(truePatterns) > 0 && len(falsePatterns) > 0 {
+		return false, DoltIgnoreConflictError{Table: tableName, TruePatterns: truePatterns, FalsePatterns: falsePatterns}
+	}
+	return len(truePatterns) > 0, nil
+}
+
+func compileIgnorePattern(pattern string) (*regexp.Regexp, error) {
+	sb := strings.Builder{}
+	sb.WriteString("(?i)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// IsTableIgnored returns whether the table named is ignored. Only tables that are untracked, that is tables in the
+// working root which are not in the staged root, can be ignored. Tables that are already tracked are never ignored.
+func IsTableIgnored(ctx context.Context, roots Roots, patterns IgnorePatterns, tableName string) (bool, error) {
+	if len(patterns) == 0 || HasDoltPrefix(tableName) {
+		return false, nil
+	}
+
+	if tracked, err := roots.Staged.HasTable(ctx, tableName); err != nil {
+		return false, err
+	} else if tracked {
+		return false, nil
+	}
+
+	return patterns.IsTableNameIgnored(tableName)
+}
+
+// ExcludeIgnoredTables returns the table names given without the tables that are ignored by the dolt_ignore table of
+// the working root in |roots|.
+func ExcludeIgnoredTables(ctx context.Context, roots Roots, tableNames []string) ([]string, error) {
+	patterns, err := GetIgnoredTablePatterns(ctx, roots)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return tableNames, nil
+	}
+
+	var filtered []string
+	for _, name := range tableNames {
+		ignored, err := IsTableIgnored(ctx, roots, patterns, name)
+		if err != nil {
+			return nil, err
+		}
+		if !ignored {
+			filtered = append(filtered, name)
+		}
+	}
+
+	return filtered, nil
+}
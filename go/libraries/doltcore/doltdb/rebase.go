@@ -0,0 +1,158 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrRebaseActive = errors.New("a rebase is in progress; use --continue to resume it or --abort to cancel it")
+var ErrNoRebaseInProgress = errors.New("there is no rebase in progress")
+
+// RebaseAction is the action taken for a single commit of a rebase plan.
+type RebaseAction string
+
+const (
+	// RebaseActionPick replays the commit as is.
+	RebaseActionPick RebaseAction = "pick"
+	// RebaseActionReword replays the commit using the commit message recorded in the plan.
+	RebaseActionReword RebaseAction = "reword"
+	// RebaseActionSquash melds the commit into the previous commit, combining both commit messages.
+	RebaseActionSquash RebaseAction = "squash"
+	// RebaseActionFixup melds the commit into the previous commit, keeping only the previous commit message.
+	RebaseActionFixup RebaseAction = "fixup"
+	// RebaseActionDrop removes the commit.
+	RebaseActionDrop RebaseAction = "drop"
+)
+
+// RebaseActions lists all the valid rebase actions.
+var RebaseActions = []RebaseAction{
+	RebaseActionPick,
+	RebaseActionReword,
+	RebaseActionSquash,
+	RebaseActionFixup,
+	RebaseActionDrop,
+}
+
+// ParseRebaseAction returns the RebaseAction named by |s|, ignoring case.
+func ParseRebaseAction(s string) (RebaseAction, error) {
+	for _, action := range RebaseActions {
+		if strings.EqualFold(s, string(action)) {
+			return action, nil
+		}
+	}
+	return "", fmt.Errorf("invalid rebase action '%s'", s)
+}
+
+// RebasePlanStep is a single entry in a rebase plan.
+type RebasePlanStep struct {
+	// Action is what to do with the commit
+	Action RebaseAction `json:"action"`
+	// CommitHash is the hash of the commit to replay
+	CommitHash string `json:"commit_hash"`
+	// CommitMsg is the message of the commit. For steps with the reword action, this is the message of the rewritten
+	// commit.
+	CommitMsg string `json:"commit_message"`
+}
+
+func encodeRebasePlan(plan []RebasePlanStep) (string, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decodeRebasePlan(s string) ([]RebasePlanStep, error) {
+	var plan []RebasePlanStep
+	err := json.Unmarshal([]byte(s), &plan)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// RebaseState is the state of an in progress rebase, stored in the working set of the branch being rebased. Commits
+// are replayed on top of the commit the branch is rebased onto as dangling commits, and the branch is only moved to the
+// rebased commits once every step of the plan has been executed.
+type RebaseState struct {
+	preRebaseWorking *RootValue
+	ontoCommit       *Commit
+	rebasedHead      *Commit
+	branch           string
+	plan             []RebasePlanStep
+	nextStep         int
+	stopped          bool
+}
+
+// PreRebaseWorkingRoot returns the working root of the branch before the rebase started.
+func (rs RebaseState) PreRebaseWorkingRoot() *RootValue {
+	return rs.preRebaseWorking
+}
+
+// OntoCommit returns the commit that the branch is being rebased onto.
+func (rs RebaseState) OntoCommit() *Commit {
+	return rs.ontoCommit
+}
+
+// RebasedHead returns the last commit replayed so far, or the onto commit if no commit has been replayed yet.
+func (rs RebaseState) RebasedHead() *Commit {
+	return rs.rebasedHead
+}
+
+// Branch returns the name of the branch being rebased.
+func (rs RebaseState) Branch() string {
+	return rs.branch
+}
+
+// Plan returns the steps of the rebase plan.
+func (rs RebaseState) Plan() []RebasePlanStep {
+	return rs.plan
+}
+
+// NextStep returns the index of the next step of the plan to execute.
+func (rs RebaseState) NextStep() int {
+	return rs.nextStep
+}
+
+// Stopped returns whether the rebase stopped on conflicts while executing the step at NextStep. When it did, the
+// working root holds the result of that step.
+func (rs RebaseState) Stopped() bool {
+	return rs.stopped
+}
+
+// Started returns whether any step of the plan has been executed yet.
+func (rs RebaseState) Started() bool {
+	return rs.nextStep > 0 || rs.stopped
+}
+
+func (rs RebaseState) WithPlan(plan []RebasePlanStep) *RebaseState {
+	rs.plan = plan
+	return &rs
+}
+
+func (rs RebaseState) WithRebasedHead(rebasedHead *Commit) *RebaseState {
+	rs.rebasedHead = rebasedHead
+	return &rs
+}
+
+func (rs RebaseState) WithNextStep(nextStep int, stopped bool) *RebaseState {
+	rs.nextStep = nextStep
+	rs.stopped = stopped
+	return &rs
+}
@@ -0,0 +1,429 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+// ReflogFileName is the name of the file holding the reflog of a local database, in the directory of its noms files.
+const ReflogFileName = "reflog"
+
+// ReflogRetention is how long the values that refs pointed to are kept by garbage collection after they were moved
+// away from.
+var ReflogRetention = 90 * 24 * time.Hour
+
+// ReflogWorkingSetRetention is how long the values that working sets pointed to are kept by garbage collection. Every
+// transaction moves a working set, so they are kept for a much shorter period than the values of refs, and never for
+// longer than ReflogRetention.
+var ReflogWorkingSetRetention = 24 * time.Hour
+
+// ReflogWorkingSetLimit is the number of the most recent movements of each working set that are kept in the reflog.
+var ReflogWorkingSetLimit = 100
+
+// reflogCompactionSize is the size of a reflog file past which appending to it removes the entries past their
+// retention, so that the file doesn't grow by an entry per transaction until the next garbage collection.
+var reflogCompactionSize int64 = 1 << 20
+
+const (
+	ReflogActionCommit           = "commit"
+	ReflogActionSetHead          = "set head"
+	ReflogActionFastForward      = "fast forward"
+	ReflogActionTag              = "tag"
+	ReflogActionDelete           = "delete"
+	ReflogActionUpdateWorkingSet = "update working set"
+)
+
+// ReflogEntry records a movement of a ref or working set of a database.
+type ReflogEntry struct {
+	// Ref is the ref or working set that moved, such as refs/heads/main or workingSets/heads/main.
+	Ref string
+	// OldHash is the address the ref pointed to before it moved, empty if it didn't exist.
+	OldHash hash.Hash
+	// NewHash is the address the ref points to after it moved, empty if it was deleted.
+	NewHash hash.Hash
+	// Action is the operation that moved the ref.
+	Action string
+	// Timestamp is when the ref moved.
+	Timestamp time.Time
+}
+
+// reflogEntryJSON is the encoding of a ReflogEntry in a reflog file.
+type reflogEntryJSON struct {
+	Ref       string    `json:"ref"`
+	OldHash   string    `json:"old"`
+	NewHash   string    `json:"new"`
+	Action    string    `json:"action"`
+	Timestamp time.Time `json:"time"`
+}
+
+// reflog is an append-only log of the movements of the refs and working sets of a database.
+type reflog interface {
+	// append records |entry| at the end of the log.
+	append(ctx context.Context, entry ReflogEntry) error
+	// entries returns all the entries of the log, oldest first.
+	entries(ctx context.Context) ([]ReflogEntry, error)
+	// trim removes the entries past their retention at |now| from the log.
+	trim(ctx context.Context, now time.Time) error
+}
+
+// isReflogDataset returns whether updates of the dataset |id| are recorded in the reflog. Datasets that are neither
+// refs nor working sets, such as flushes, are not.
+func isReflogDataset(id string) bool {
+	return ref.IsRef(id) || ref.IsWorkingSet(id)
+}
+
+type memReflog struct {
+	mu  *sync.Mutex
+	log *[]ReflogEntry
+}
+
+func newMemReflog() memReflog {
+	return memReflog{mu: &sync.Mutex{}, log: new([]ReflogEntry)}
+}
+
+func (r memReflog) append(_ context.Context, entry ReflogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.log = append(*r.log, entry)
+	return nil
+}
+
+func (r memReflog) entries(_ context.Context) ([]ReflogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]ReflogEntry(nil), *r.log...), nil
+}
+
+func (r memReflog) trim(_ context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.log = retainedReflogEntries(*r.log, now)
+	return nil
+}
+
+// retainedReflogEntries returns the entries of |entries| that are within their retention at |now|. The movements of
+// refs are retained for ReflogRetention. The movements of working sets are retained for ReflogWorkingSetRetention, and
+// only the last ReflogWorkingSetLimit movements of each working set are.
+func retainedReflogEntries(entries []ReflogEntry, now time.Time) []ReflogEntry {
+	cutoff := now.Add(-ReflogRetention)
+	wsCutoff := now.Add(-ReflogWorkingSetRetention)
+	if wsCutoff.Before(cutoff) {
+		wsCutoff = cutoff
+	}
+
+	// the movements of each working set are counted from the most recent one
+	retained := make([]bool, len(entries))
+	wsCounts := make(map[string]int)
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if !ref.IsWorkingSet(entry.Ref) {
+			retained[i] = !entry.Timestamp.Before(cutoff)
+		} else if !entry.Timestamp.Before(wsCutoff) && wsCounts[entry.Ref] < ReflogWorkingSetLimit {
+			retained[i] = true
+			wsCounts[entry.Ref]++
+		}
+	}
+
+	recent := make([]ReflogEntry, 0, len(entries))
+	for i, entry := range entries {
+		if retained[i] {
+			recent = append(recent, entry)
+		}
+	}
+	return recent
+}
+
+// fileReflog stores a reflog as a file of JSON lines, one per entry.
+type fileReflog struct {
+	mu   *sync.Mutex
+	path string
+	// compactAt is the size of the file past which the next append removes the entries past their retention.
+	compactAt *int64
+}
+
+func newFileReflog(path string) fileReflog {
+	compactAt := reflogCompactionSize
+	return fileReflog{mu: &sync.Mutex{}, path: path, compactAt: &compactAt}
+}
+
+func (r fileReflog) append(_ context.Context, entry ReflogEntry) error {
+	line, err := encodeReflogEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	// a partial last line left by an interrupted write is ended, so that it doesn't run into this entry
+	partial, err := endsWithPartialLine(f)
+	if err == nil {
+		if partial {
+			line = append([]byte{'\n'}, line...)
+		}
+		_, err = f.Write(line)
+	}
+	var size int64
+	if err == nil {
+		var info os.FileInfo
+		info, err = f.Stat()
+		if err == nil {
+			size = info.Size()
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil || size <= *r.compactAt {
+		return err
+	}
+
+	// a compaction that retains most of the file isn't attempted again until the file has doubled in size
+	entries, err := r.readEntries()
+	if err != nil {
+		return err
+	}
+	err = r.rewrite(entries, retainedReflogEntries(entries, entry.Timestamp))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	*r.compactAt = info.Size() * 2
+	if *r.compactAt < reflogCompactionSize {
+		*r.compactAt = reflogCompactionSize
+	}
+	return nil
+}
+
+func (r fileReflog) entries(_ context.Context) ([]ReflogEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.readEntries()
+}
+
+// trim rewrites the reflog file without the entries past their retention at |now|.
+func (r fileReflog) trim(_ context.Context, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := r.readEntries()
+	if err != nil {
+		return err
+	}
+	return r.rewrite(entries, retainedReflogEntries(entries, now))
+}
+
+// rewrite replaces the |entries| of the reflog file with |recent|, unless nothing was removed from them.
+func (r fileReflog) rewrite(entries, recent []ReflogEntry) error {
+	if len(recent) == len(entries) {
+		return nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(r.path), ReflogFileName+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	for _, entry := range recent {
+		line, err := encodeReflogEntry(entry)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err = w.Write(line); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	err = w.Flush()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), r.path)
+}
+
+// readEntries reads the entries of the reflog file. Lines that are not valid entries, such as the partial lines left
+// by interrupted writes, are skipped.
+func (r fileReflog) readEntries() ([]ReflogEntry, error) {
+	f, err := os.Open(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ReflogEntry
+	rd := bufio.NewReader(f)
+	for {
+		line, err := rd.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if entry, ok := decodeReflogEntry(line); ok {
+			entries = append(entries, entry)
+		}
+
+		if err == io.EOF {
+			return entries, nil
+		}
+	}
+}
+
+// endsWithPartialLine returns whether the content of |f| doesn't end with a newline.
+func endsWithPartialLine(f *os.File) (bool, error) {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+
+	last := make([]byte, 1)
+	if _, err = f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// encodeReflogEntry returns the line of a reflog file holding |entry|.
+func encodeReflogEntry(entry ReflogEntry) ([]byte, error) {
+	line, err := json.Marshal(reflogEntryJSON{
+		Ref:       entry.Ref,
+		OldHash:   entry.OldHash.String(),
+		NewHash:   entry.NewHash.String(),
+		Action:    entry.Action,
+		Timestamp: entry.Timestamp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// decodeReflogEntry returns the entry held by |line| of a reflog file, or false if it doesn't hold a valid entry.
+func decodeReflogEntry(line []byte) (ReflogEntry, bool) {
+	var ej reflogEntryJSON
+	if err := json.Unmarshal(line, &ej); err != nil {
+		return ReflogEntry{}, false
+	}
+
+	entry := ReflogEntry{Ref: ej.Ref, Action: ej.Action, Timestamp: ej.Timestamp}
+	var okOld, okNew bool
+	entry.OldHash, okOld = hash.MaybeParse(ej.OldHash)
+	entry.NewHash, okNew = hash.MaybeParse(ej.NewHash)
+	return entry, okOld && okNew
+}
+
+// Reflog returns the movements of the refs of this database, most recent first, along with the movements of its
+// working sets if |includeWorkingSets| is true. If |refName| isn't empty, only the movements of that ref are returned.
+// |refName| is either the full name of a ref or working set, such as refs/heads/main, or the name of a branch, tag or
+// remote branch, which needs not exist anymore.
+func (ddb *DoltDB) Reflog(ctx context.Context, refName string, includeWorkingSets bool) ([]ReflogEntry, error) {
+	if ddb.db.reflog == nil {
+		return nil, nil
+	}
+
+	entries, err := ddb.db.reflog.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if refName != "" {
+		moved := make(map[string]bool)
+		for _, entry := range entries {
+			moved[entry.Ref] = true
+		}
+
+		candidates := []string{
+			refName,
+			ref.PrefixForType(ref.BranchRefType) + refName,
+			ref.PrefixForType(ref.TagRefType) + refName,
+			ref.PrefixForType(ref.RemoteRefType) + refName,
+		}
+		for _, candidate := range candidates {
+			if moved[candidate] {
+				refName = candidate
+				break
+			}
+		}
+	}
+
+	filtered := make([]ReflogEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if refName != "" && entry.Ref != refName {
+			continue
+		} else if refName == "" && !includeWorkingSets && ref.IsWorkingSet(entry.Ref) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered, nil
+}
+
+// trimReflog removes the entries past their retention from the reflog, and returns the addresses that refs and
+// working sets pointed to within it.
+func (ddb *DoltDB) trimReflog(ctx context.Context) ([]hash.Hash, error) {
+	if ddb.db.reflog == nil {
+		return nil, nil
+	}
+
+	err := ddb.db.reflog.trim(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := ddb.db.reflog.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []hash.Hash
+	for _, entry := range entries {
+		if !entry.OldHash.IsEmpty() {
+			hashes = append(hashes, entry.OldHash)
+		}
+		if !entry.NewHash.IsEmpty() {
+			hashes = append(hashes, entry.NewHash)
+		}
+	}
+	return hashes, nil
+}
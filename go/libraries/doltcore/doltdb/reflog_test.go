@@ -0,0 +1,209 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+func TestFileReflog(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), ReflogFileName)
+	rl := newFileReflog(path)
+
+	entries, err := rl.entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	first := ReflogEntry{Ref: "refs/heads/main", NewHash: hash.Of([]byte("a")), Action: ReflogActionCommit, Timestamp: now}
+	second := ReflogEntry{Ref: "refs/heads/main", OldHash: hash.Of([]byte("a")), Action: ReflogActionDelete, Timestamp: now}
+	require.NoError(t, rl.append(ctx, first))
+	require.NoError(t, rl.append(ctx, second))
+
+	// a partial entry left by an interrupted write is ignored
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"ref":"refs/heads/ma`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err = newFileReflog(path).entries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ReflogEntry{first, second}, entries)
+
+	// and the entries appended after it are not
+	third := ReflogEntry{Ref: "refs/heads/other", NewHash: hash.Of([]byte("b")), Action: ReflogActionCommit, Timestamp: now.Add(time.Hour)}
+	require.NoError(t, rl.append(ctx, third))
+	entries, err = rl.entries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ReflogEntry{first, second, third}, entries)
+
+	// trimming rewrites the reflog without the old entries
+	require.NoError(t, rl.trim(ctx, now.Add(ReflogRetention+time.Minute)))
+	entries, err = newFileReflog(path).entries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ReflogEntry{third}, entries)
+	files, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestMemReflogTrim(t *testing.T) {
+	ctx := context.Background()
+	rl := newMemReflog()
+	now := time.Now()
+	for _, ts := range []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour), now} {
+		require.NoError(t, rl.append(ctx, ReflogEntry{Ref: "refs/heads/main", Action: ReflogActionCommit, Timestamp: ts}))
+	}
+
+	require.NoError(t, rl.trim(ctx, now.Add(ReflogRetention-time.Hour)))
+	entries, err := rl.entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, now.Add(-time.Hour), entries[0].Timestamp)
+}
+
+func TestReflogWorkingSetRetention(t *testing.T) {
+	defer func(limit int) {
+		ReflogWorkingSetLimit = limit
+	}(ReflogWorkingSetLimit)
+	ReflogWorkingSetLimit = 2
+
+	now := time.Now()
+	old := now.Add(-ReflogWorkingSetRetention - time.Hour)
+	entries := []ReflogEntry{
+		{Ref: "refs/heads/main", Timestamp: old},
+		{Ref: "workingSets/heads/main", Timestamp: old},
+		{Ref: "workingSets/heads/other", Timestamp: now},
+		{Ref: "workingSets/heads/main", Timestamp: now.Add(1)},
+		{Ref: "workingSets/heads/main", Timestamp: now.Add(2)},
+		{Ref: "workingSets/heads/main", Timestamp: now.Add(3)},
+	}
+
+	// the working sets keep their last movements within their retention, while the refs keep theirs much longer
+	retained := retainedReflogEntries(entries, now)
+	assert.Equal(t, []ReflogEntry{entries[0], entries[2], entries[4], entries[5]}, retained)
+}
+
+func TestFileReflogCompaction(t *testing.T) {
+	defer func(size int64, limit int) {
+		reflogCompactionSize = size
+		ReflogWorkingSetLimit = limit
+	}(reflogCompactionSize, ReflogWorkingSetLimit)
+	reflogCompactionSize = 1024
+	ReflogWorkingSetLimit = 2
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), ReflogFileName)
+	rl := newFileReflog(path)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	branch := ReflogEntry{Ref: "refs/heads/main", NewHash: hash.Of([]byte("a")), Action: ReflogActionCommit, Timestamp: now}
+	require.NoError(t, rl.append(ctx, branch))
+	for i := 0; i < 100; i++ {
+		ws := ReflogEntry{Ref: "workingSets/heads/main", NewHash: hash.Of([]byte{byte(i)}), Action: ReflogActionUpdateWorkingSet, Timestamp: now}
+		require.NoError(t, rl.append(ctx, ws))
+	}
+
+	// appending compacts the file once it passes the compaction size
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, info.Size(), int64(2048))
+	entries, err := rl.entries(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, branch, entries[0])
+	assert.Less(t, len(entries), 10)
+}
+
+func TestReflogFilter(t *testing.T) {
+	ctx := context.Background()
+	rl := newMemReflog()
+	ddb := &DoltDB{db: hooksDatabase{reflog: rl}}
+
+	for _, r := range []string{"refs/heads/main", "workingSets/heads/main", "refs/tags/v1", "refs/heads/v1", "refs/remotes/origin/main"} {
+		require.NoError(t, rl.append(ctx, ReflogEntry{Ref: r, Action: ReflogActionSetHead}))
+	}
+
+	refsOf := func(refName string, includeWorkingSets bool) []string {
+		entries, err := ddb.Reflog(ctx, refName, includeWorkingSets)
+		require.NoError(t, err)
+		refs := make([]string, len(entries))
+		for i, entry := range entries {
+			refs[i] = entry.Ref
+		}
+		return refs
+	}
+
+	assert.Equal(t, []string{"refs/remotes/origin/main", "refs/heads/v1", "refs/tags/v1", "refs/heads/main"}, refsOf("", false))
+	assert.Len(t, refsOf("", true), 5)
+	assert.Equal(t, []string{"refs/heads/main"}, refsOf("main", false))
+	assert.Equal(t, []string{"refs/heads/v1"}, refsOf("v1", false))
+	assert.Equal(t, []string{"refs/tags/v1"}, refsOf("refs/tags/v1", false))
+	assert.Equal(t, []string{"refs/remotes/origin/main"}, refsOf("origin/main", false))
+	assert.Equal(t, []string{"workingSets/heads/main"}, refsOf("workingSets/heads/main", false))
+	assert.Empty(t, refsOf("nope", false))
+}
+
+// failingReflog is a reflog that fails to record any entry.
+type failingReflog struct{}
+
+func (failingReflog) append(context.Context, ReflogEntry) error {
+	return errors.New("no space left on device")
+}
+
+func (failingReflog) entries(context.Context) ([]ReflogEntry, error) {
+	return nil, nil
+}
+
+func (failingReflog) trim(context.Context, time.Time) error {
+	return nil
+}
+
+func TestReflogFailureRunsCommitHooks(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_Default, InMemDoltDB, filesys.LocalFS)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "master", "Bill Billerson", "bigbillieb@fake.horse"))
+
+	var out bytes.Buffer
+	hook := NewLogHook([]byte("executed"))
+	require.NoError(t, hook.SetLogger(ctx, &out))
+	ddb.db.reflog = failingReflog{}
+	ddb.SetCommitHooks(ctx, []CommitHook{hook})
+
+	// the branch is created even though its creation can't be recorded, and the hooks see it
+	cm, err := ddb.ResolveCommitRef(ctx, ref.NewBranchRef("master"))
+	require.NoError(t, err)
+	require.NoError(t, ddb.NewBranchAtCommit(ctx, ref.NewBranchRef("other"), cm))
+	assert.Equal(t, "executed", out.String())
+	has, err := ddb.HasRef(ctx, ref.NewBranchRef("other"))
+	require.NoError(t, err)
+	assert.True(t, has)
+}
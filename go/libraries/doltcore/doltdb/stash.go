@@ -0,0 +1,229 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/store/datas"
+)
+
+var ErrNoStashEntries = errors.New("no stash entries found")
+var ErrStashNotFound = errors.New("stash entry not found")
+
+// Stash is a single entry in the stash list of a database. Each entry is stored as a dangling commit referenced by a
+// StashRef. The root value of the commit holds the stashed working changes, and its first parent is the commit that was
+// HEAD when the changes were stashed. Its second parent is a dangling commit on top of HEAD whose root value holds the
+// stashed staged changes.
+type Stash struct {
+	// Ref is the ref that keeps this stash entry alive
+	Ref ref.StashRef
+	// Commit is the commit holding the stashed changes
+	Commit *Commit
+}
+
+// Description returns the commit message recorded for this stash entry.
+func (s *Stash) Description(ctx context.Context) (string, error) {
+	meta, err := s.Commit.GetCommitMeta(ctx)
+	if err != nil {
+		return "", err
+	}
+	return meta.Description, nil
+}
+
+// HeadCommit returns the commit that the changes of this stash entry were stashed on top of.
+func (s *Stash) HeadCommit(ctx context.Context) (*Commit, error) {
+	return s.Commit.GetParent(ctx, 0)
+}
+
+// StagedRoot returns the staged root recorded for this stash entry. The returned bool is false for entries that do not
+// record their staged changes separately.
+func (s *Stash) StagedRoot(ctx context.Context) (*RootValue, bool, error) {
+	if s.Commit.NumParents() < 2 {
+		return nil, false, nil
+	}
+
+	staged, err := s.Commit.GetParent(ctx, 1)
+	if err != nil {
+		return nil, false, err
+	}
+	root, err := staged.GetRootValue(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return root, true, nil
+}
+
+// StashName returns the user facing name of the stash entry at position |idx| in the stash list.
+func StashName(idx int) string {
+	return fmt.Sprintf("stash@{%d}", idx)
+}
+
+// ParseStashName parses a user supplied stash entry name in the form stash@{N}, or just N, and returns the position of
+// the entry in the stash list.
+func ParseStashName(name string) (int, error) {
+	idxStr := name
+	if strings.HasPrefix(name, "stash@{") && strings.HasSuffix(name, "}") {
+		idxStr = name[len("stash@{") : len(name)-1]
+	}
+
+	idx, err := strconv.Atoi(idxStr)
+	if err != nil || idx < 0 {
+		return 0, fmt.Errorf("%s is not a valid reference", name)
+	}
+
+	return idx, nil
+}
+
+var stashesRefFilter = map[ref.RefType]struct{}{ref.StashRefType: {}}
+
+// GetStashes returns all the stash entries in the database, with the most recently created entry first.
+func (ddb *DoltDB) GetStashes(ctx context.Context) ([]*Stash, error) {
+	refs, err := ddb.GetRefsOfType(ctx, stashesRefFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	stashRefs := make([]ref.StashRef, len(refs))
+	for i, r := range refs {
+		stashRefs[i] = r.(ref.StashRef)
+	}
+	sort.Slice(stashRefs, func(i, j int) bool {
+		return stashRefs[i].Seq() > stashRefs[j].Seq()
+	})
+
+	stashes := make([]*Stash, len(stashRefs))
+	for i, sr := range stashRefs {
+		cm, err := ddb.ResolveCommitRef(ctx, sr)
+		if err != nil {
+			return nil, err
+		}
+		stashes[i] = &Stash{Ref: sr, Commit: cm}
+	}
+
+	return stashes, nil
+}
+
+// GetStash returns the stash entry at position |idx| in the stash list, where 0 is the most recent entry.
+func (ddb *DoltDB) GetStash(ctx context.Context, idx int) (*Stash, error) {
+	stashes, err := ddb.GetStashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stashes) == 0 {
+		return nil, ErrNoStashEntries
+	}
+	if idx < 0 || idx >= len(stashes) {
+		return nil, fmt.Errorf("%w: %s", ErrStashNotFound, StashName(idx))
+	}
+
+	return stashes[idx], nil
+}
+
+// AddStash records |workingRoot| and |stagedRoot| as a new stash entry on top of the commit |head|, making it the most
+// recent entry in the stash list.
+func (ddb *DoltDB) AddStash(ctx context.Context, head *Commit, workingRoot, stagedRoot *RootValue, meta *datas.CommitMeta) (*Stash, error) {
+	_, stagedHash, err := ddb.WriteRootValue(ctx, stagedRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	stagedCm, err := ddb.CommitDanglingWithParentCommits(ctx, stagedHash, []*Commit{head}, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	_, workingHash, err := ddb.WriteRootValue(ctx, workingRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	cm, err := ddb.CommitDanglingWithParentCommits(ctx, workingHash, []*Commit{head, stagedCm}, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, err := cm.HashOf()
+	if err != nil {
+		return nil, err
+	}
+
+	// the ref of the new entry is created only if it still doesn't exist, so that concurrent stashes which read the
+	// same stash list don't overwrite each other. The one that loses the race retries with the next sequence number.
+	for {
+		stashes, err := ddb.GetStashes(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var seq uint64
+		if len(stashes) > 0 {
+			seq = stashes[0].Ref.Seq() + 1
+		}
+
+		stashRef := ref.NewStashRefForSeq(seq)
+		ds, err := ddb.db.GetDataset(ctx, stashRef.String())
+		if err != nil {
+			return nil, err
+		} else if ds.HasHead() {
+			continue
+		}
+
+		_, err = ddb.db.CheckAndSetHead(ctx, ds, addr)
+		if err == datas.ErrOptimisticLockFailed {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return &Stash{Ref: stashRef, Commit: cm}, nil
+	}
+}
+
+// DropStash removes the stash entry given from the stash list.
+func (ddb *DoltDB) DropStash(ctx context.Context, stash *Stash) error {
+	err := ddb.deleteRef(ctx, stash.Ref)
+
+	if err == ErrBranchNotFound {
+		return ErrStashNotFound
+	}
+
+	return err
+}
+
+// ClearStashes removes all entries from the stash list.
+func (ddb *DoltDB) ClearStashes(ctx context.Context) error {
+	stashes, err := ddb.GetStashes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, stash := range stashes {
+		err = ddb.DropStash(ctx, stash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
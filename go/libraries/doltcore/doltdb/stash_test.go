@@ -0,0 +1,75 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package doltdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+func TestConcurrentAddStash(t *testing.T) {
+	ctx := context.Background()
+	ddb, err := LoadDoltDB(ctx, types.Format_Default, InMemDoltDB, filesys.LocalFS)
+	require.NoError(t, err)
+	require.NoError(t, ddb.WriteEmptyRepo(ctx, "master", "Bill Billerson", "bigbillieb@fake.horse"))
+
+	cs, _ := NewCommitSpec("master")
+	head, err := ddb.Resolve(ctx, cs, nil)
+	require.NoError(t, err)
+	root, err := head.GetRootValue(ctx)
+	require.NoError(t, err)
+
+	const numStashes = 16
+	var wg sync.WaitGroup
+	errs := make([]error, numStashes)
+	for i := 0; i < numStashes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			meta, err := datas.NewCommitMeta("Bill Billerson", "bigbillieb@fake.horse", fmt.Sprintf("stash %d", i))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			_, errs[i] = ddb.AddStash(ctx, head, root, root, meta)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	// every entry gets its own sequence number, so none of them are overwritten
+	stashes, err := ddb.GetStashes(ctx)
+	require.NoError(t, err)
+	require.Len(t, stashes, numStashes)
+	descriptions := make(map[string]struct{})
+	for i, stash := range stashes {
+		assert.Equal(t, uint64(numStashes-1-i), stash.Ref.Seq())
+		desc, err := stash.Description(ctx)
+		require.NoError(t, err)
+		descriptions[desc] = struct{}{}
+	}
+	assert.Len(t, descriptions, numStashes)
+}
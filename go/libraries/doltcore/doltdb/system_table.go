@@ -134,6 +134,8 @@ var writeableSystemTables = []string{
 	SchemasTableName,
 	ProceduresTableName,
 	DocTableName,
+	IgnoreTableName,
+	MigrationsTableName,
 }
 
 var persistedSystemTables = []string{
@@ -141,6 +143,8 @@ var persistedSystemTables = []string{
 	DoltQueryCatalogTableName,
 	SchemasTableName,
 	ProceduresTableName,
+	IgnoreTableName,
+	MigrationsTableName,
 }
 
 var generatedSystemTables = []string{
@@ -148,6 +152,7 @@ var generatedSystemTables = []string{
 	LogTableName,
 	TableOfTablesInConflictName,
 	TableOfTablesWithViolationsName,
+	SchemaConflictsTableName,
 	CommitsTableName,
 	CommitAncestorsTableName,
 	StatusTableName,
@@ -189,6 +194,37 @@ const (
 	DocTextColumnName = "doc_text"
 )
 
+const (
+	// IgnoreTableName is the name of the dolt table containing the patterns of table names that should be ignored by
+	// add -A, status and clean
+	IgnoreTableName = "dolt_ignore"
+	// IgnorePatternCol is the name of the pk column of the ignore table, containing a table name pattern
+	IgnorePatternCol = "pattern"
+	// IgnoreIgnoredCol is the name of the column containing whether the tables matching a pattern are ignored
+	IgnoreIgnoredCol = "ignored"
+)
+
+var doltMigrationsColumns = schema.NewColCollection(
+	schema.NewColumn(MigrationsVersionCol, schema.DoltMigrationsVersionTag, types.UintKind, true, schema.NotNullConstraint{}),
+	schema.NewColumn(MigrationsNameCol, schema.DoltMigrationsNameTag, types.StringKind, false, schema.NotNullConstraint{}),
+	schema.NewColumn(MigrationsChecksumCol, schema.DoltMigrationsChecksumTag, types.StringKind, false, schema.NotNullConstraint{}),
+)
+
+// MigrationsSchema is the schema of the dolt_migrations table. It only holds columns that are the same on every branch
+// a migration is applied to, so that branches that applied the same migrations merge without conflicts.
+var MigrationsSchema = schema.MustSchemaFromCols(doltMigrationsColumns)
+
+const (
+	// MigrationsTableName is the name of the dolt table recording the schema migrations applied to a branch
+	MigrationsTableName = "dolt_migrations"
+	// MigrationsVersionCol is the name of the pk column of the migrations table, containing the version of a migration
+	MigrationsVersionCol = "version"
+	// MigrationsNameCol is the name of the column containing the name of a migration
+	MigrationsNameCol = "name"
+	// MigrationsChecksumCol is the name of the column containing the checksum of the statements of a migration
+	MigrationsChecksumCol = "checksum"
+)
+
 const (
 	// DoltQueryCatalogTableName is the name of the query catalog table
 	DoltQueryCatalogTableName = "dolt_query_catalog"
@@ -255,6 +291,9 @@ const (
 	// TableOfTablesWithViolationsName is the constraint violations system table name
 	TableOfTablesWithViolationsName = "dolt_constraint_violations"
 
+	// SchemaConflictsTableName is the name of the system table with the schema conflicts of the active merge
+	SchemaConflictsTableName = "dolt_schema_conflicts"
+
 	// BranchesTableName is the branches system table name
 	BranchesTableName = "dolt_branches"
 
@@ -272,6 +311,19 @@ const (
 
 	// TagsTableName is the tags table name
 	TagsTableName = "dolt_tags"
+
+	// StashesTableName is the stashes table name
+	StashesTableName = "dolt_stashes"
+
+	// RebaseTableName is the rebase plan table name
+	RebaseTableName = "dolt_rebase"
+
+	// BranchControlTableName is the name of the table containing the branch permissions of sql-server users
+	BranchControlTableName = "dolt_branch_control"
+
+	// BranchNamespaceControlTableName is the name of the table containing the branch names reserved for sql-server
+	// users
+	BranchNamespaceControlTableName = "dolt_branch_namespace_control"
 )
 
 const (
@@ -353,7 +353,7 @@ func (t *Table) GetConstraintViolationsSchema(ctx context.Context) (schema.Schem
 	}
 
 	typeType, err := typeinfo.FromSqlType(
-		sql.MustCreateEnumType([]string{"foreign key", "unique index", "check constraint"}, sql.Collation_Default))
+		sql.MustCreateEnumType([]string{"foreign key", "unique index", "check constraint", "schema conversion", "invalid definition"}, sql.Collation_Default))
 	if err != nil {
 		return nil, err
 	}
@@ -27,8 +27,9 @@ import (
 )
 
 type MergeState struct {
-	commit          *Commit
-	preMergeWorking *RootValue
+	commit               *Commit
+	preMergeWorking      *RootValue
+	schemaConflictTables []string
 }
 
 // TodoWorkingSetMeta returns an incomplete WorkingSetMeta, suitable for methods that don't have the means to construct
@@ -56,6 +57,17 @@ func (m MergeState) PreMergeWorkingRoot() *RootValue {
 	return m.preMergeWorking
 }
 
+// SchemaConflictTables returns the names of the tables whose schemas could not be merged. The rows of these tables are
+// merged once their schema conflicts are resolved.
+func (m MergeState) SchemaConflictTables() []string {
+	return m.schemaConflictTables
+}
+
+// HasSchemaConflicts returns whether any table has unresolved schema conflicts.
+func (m MergeState) HasSchemaConflicts() bool {
+	return len(m.schemaConflictTables) > 0
+}
+
 type WorkingSet struct {
 	Name        string
 	meta        *datas.WorkingSetMeta
@@ -63,6 +75,7 @@ type WorkingSet struct {
 	workingRoot *RootValue
 	stagedRoot  *RootValue
 	mergeState  *MergeState
+	rebaseState *RebaseState
 }
 
 var _ Rootish = &WorkingSet{}
@@ -98,6 +111,15 @@ func (ws WorkingSet) StartMerge(commit *Commit) *WorkingSet {
 	return &ws
 }
 
+// WithSchemaConflicts records that the schemas of |tables| could not be merged by the active merge, replacing the tables
+// recorded before.
+func (ws WorkingSet) WithSchemaConflicts(tables []string) *WorkingSet {
+	ms := *ws.mergeState
+	ms.schemaConflictTables = tables
+	ws.mergeState = &ms
+	return &ws
+}
+
 func (ws WorkingSet) AbortMerge() *WorkingSet {
 	ws.workingRoot = ws.mergeState.PreMergeWorkingRoot()
 	ws.stagedRoot = ws.workingRoot
@@ -110,6 +132,37 @@ func (ws WorkingSet) ClearMerge() *WorkingSet {
 	return &ws
 }
 
+func (ws WorkingSet) WithRebaseState(rebaseState *RebaseState) *WorkingSet {
+	ws.rebaseState = rebaseState
+	return &ws
+}
+
+// StartRebase records the start of a rebase of |branch| onto |ontoCommit| following |plan|. No steps of the plan are
+// executed.
+func (ws WorkingSet) StartRebase(ontoCommit *Commit, branch string, plan []RebasePlanStep) *WorkingSet {
+	ws.rebaseState = &RebaseState{
+		preRebaseWorking: ws.workingRoot,
+		ontoCommit:       ontoCommit,
+		rebasedHead:      ontoCommit,
+		branch:           branch,
+		plan:             plan,
+	}
+
+	return &ws
+}
+
+func (ws WorkingSet) AbortRebase() *WorkingSet {
+	ws.workingRoot = ws.rebaseState.PreRebaseWorkingRoot()
+	ws.stagedRoot = ws.workingRoot
+	ws.rebaseState = nil
+	return &ws
+}
+
+func (ws WorkingSet) ClearRebase() *WorkingSet {
+	ws.rebaseState = nil
+	return &ws
+}
+
 func (ws *WorkingSet) WorkingRoot() *RootValue {
 	return ws.workingRoot
 }
@@ -126,12 +179,24 @@ func (ws *WorkingSet) MergeActive() bool {
 	return ws.mergeState != nil
 }
 
+func (ws *WorkingSet) RebaseState() *RebaseState {
+	return ws.rebaseState
+}
+
+func (ws *WorkingSet) RebaseActive() bool {
+	return ws.rebaseState != nil
+}
+
 func (ws WorkingSet) Meta() *datas.WorkingSetMeta {
 	return ws.meta
 }
 
 // NewWorkingSet creates a new WorkingSet object.
 func NewWorkingSet(ctx context.Context, name string, vrw types.ValueReadWriter, ns tree.NodeStore, ds datas.Dataset) (*WorkingSet, error) {
+	return newWorkingSet(ctx, name, vrw, ns, nil, ds)
+}
+
+func newWorkingSet(ctx context.Context, name string, vrw types.ValueReadWriter, ns tree.NodeStore, shallow *shallowCommits, ds datas.Dataset) (*WorkingSet, error) {
 	dsws, err := ds.HeadWorkingSet()
 	if err != nil {
 		return nil, err
@@ -180,7 +245,7 @@ func NewWorkingSet(ctx context.Context, name string, vrw types.ValueReadWriter,
 			return nil, err
 		}
 
-		commit, err := NewCommit(ctx, vrw, ns, fromDCommit)
+		commit, err := newCommit(ctx, vrw, ns, shallow, fromDCommit)
 		if err != nil {
 			return nil, err
 		}
@@ -195,9 +260,23 @@ func NewWorkingSet(ctx context.Context, name string, vrw types.ValueReadWriter,
 			return nil, err
 		}
 
+		schemaConflictTables, err := dsws.MergeState.SchemaConflictTables(ctx, vrw)
+		if err != nil {
+			return nil, err
+		}
+
 		mergeState = &MergeState{
-			commit:          commit,
-			preMergeWorking: preMergeWorkingRoot,
+			commit:               commit,
+			preMergeWorking:      preMergeWorkingRoot,
+			schemaConflictTables: schemaConflictTables,
+		}
+	}
+
+	var rebaseState *RebaseState
+	if dsws.RebaseState != nil {
+		rebaseState, err = newRebaseState(ctx, vrw, ns, shallow, dsws.RebaseState)
+		if err != nil {
+			return nil, err
 		}
 	}
 
@@ -210,6 +289,71 @@ func NewWorkingSet(ctx context.Context, name string, vrw types.ValueReadWriter,
 		workingRoot: workingRoot,
 		stagedRoot:  stagedRoot,
 		mergeState:  mergeState,
+		rebaseState: rebaseState,
+	}, nil
+}
+
+func newRebaseState(ctx context.Context, vrw types.ValueReadWriter, ns tree.NodeStore, shallow *shallowCommits, dsrs *datas.RebaseState) (*RebaseState, error) {
+	preRebaseWorkingAddr, err := dsrs.PreRebaseWorkingAddr(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+	preRebaseWorkingV, err := vrw.ReadValue(ctx, preRebaseWorkingAddr)
+	if err != nil {
+		return nil, err
+	}
+	preRebaseWorkingRoot, err := newRootValue(vrw, ns, preRebaseWorkingV)
+	if err != nil {
+		return nil, err
+	}
+
+	ontoDCommit, err := dsrs.OntoCommit(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+	ontoCommit, err := newCommit(ctx, vrw, ns, shallow, ontoDCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	rebasedHeadDCommit, err := dsrs.RebasedHead(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+	rebasedHead, err := newCommit(ctx, vrw, ns, shallow, rebasedHeadDCommit)
+	if err != nil {
+		return nil, err
+	}
+
+	branch, err := dsrs.Branch(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+	encodedPlan, err := dsrs.Plan(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := decodeRebasePlan(encodedPlan)
+	if err != nil {
+		return nil, err
+	}
+	nextStep, err := dsrs.NextStep(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+	stopped, err := dsrs.Stopped(ctx, vrw)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RebaseState{
+		preRebaseWorking: preRebaseWorkingRoot,
+		ontoCommit:       ontoCommit,
+		rebasedHead:      rebasedHead,
+		branch:           branch,
+		plan:             plan,
+		nextStep:         int(nextStep),
+		stopped:          stopped,
 	}, nil
 }
 
@@ -237,47 +381,84 @@ func (ws *WorkingSet) writeValues(ctx context.Context, db *DoltDB) (
 	workingRoot types.Ref,
 	stagedRoot types.Ref,
 	mergeState *datas.MergeState,
+	rebaseState *datas.RebaseState,
 	err error,
 ) {
 
 	if ws.stagedRoot == nil || ws.workingRoot == nil {
-		return types.Ref{}, types.Ref{}, nil, fmt.Errorf("StagedRoot and workingRoot must be set. This is a bug.")
+		return types.Ref{}, types.Ref{}, nil, nil, fmt.Errorf("StagedRoot and workingRoot must be set. This is a bug.")
 	}
 
 	var r *RootValue
 	r, workingRoot, err = db.writeRootValue(ctx, ws.workingRoot)
 	if err != nil {
-		return types.Ref{}, types.Ref{}, nil, err
+		return types.Ref{}, types.Ref{}, nil, nil, err
 	}
 	ws.workingRoot = r
 
 	r, stagedRoot, err = db.writeRootValue(ctx, ws.stagedRoot)
 	if err != nil {
-		return types.Ref{}, types.Ref{}, nil, err
+		return types.Ref{}, types.Ref{}, nil, nil, err
 	}
 	ws.stagedRoot = r
 
 	if ws.mergeState != nil {
 		r, preMergeWorking, err := db.writeRootValue(ctx, ws.mergeState.preMergeWorking)
 		if err != nil {
-			return types.Ref{}, types.Ref{}, nil, err
+			return types.Ref{}, types.Ref{}, nil, nil, err
 		}
 		ws.mergeState.preMergeWorking = r
 
 		h, err := ws.mergeState.commit.HashOf()
 		if err != nil {
-			return types.Ref{}, types.Ref{}, nil, err
+			return types.Ref{}, types.Ref{}, nil, nil, err
 		}
 		dCommit, err := datas.LoadCommitAddr(ctx, db.vrw, h)
 		if err != nil {
-			return types.Ref{}, types.Ref{}, nil, err
+			return types.Ref{}, types.Ref{}, nil, nil, err
 		}
 
-		mergeState, err = datas.NewMergeState(ctx, db.vrw, preMergeWorking, dCommit)
+		mergeState, err = datas.NewMergeState(ctx, db.vrw, preMergeWorking, dCommit, ws.mergeState.schemaConflictTables)
 		if err != nil {
-			return types.Ref{}, types.Ref{}, nil, err
+			return types.Ref{}, types.Ref{}, nil, nil, err
 		}
 	}
 
-	return workingRoot, stagedRoot, mergeState, nil
+	if ws.rebaseState != nil {
+		r, preRebaseWorking, err := db.writeRootValue(ctx, ws.rebaseState.preRebaseWorking)
+		if err != nil {
+			return types.Ref{}, types.Ref{}, nil, nil, err
+		}
+		ws.rebaseState.preRebaseWorking = r
+
+		ontoCommit, err := loadDatasCommit(ctx, db, ws.rebaseState.ontoCommit)
+		if err != nil {
+			return types.Ref{}, types.Ref{}, nil, nil, err
+		}
+		rebasedHead, err := loadDatasCommit(ctx, db, ws.rebaseState.rebasedHead)
+		if err != nil {
+			return types.Ref{}, types.Ref{}, nil, nil, err
+		}
+
+		plan, err := encodeRebasePlan(ws.rebaseState.plan)
+		if err != nil {
+			return types.Ref{}, types.Ref{}, nil, nil, err
+		}
+
+		rebaseState, err = datas.NewRebaseState(ctx, db.vrw, preRebaseWorking, ontoCommit, rebasedHead,
+			ws.rebaseState.branch, plan, uint32(ws.rebaseState.nextStep), ws.rebaseState.stopped)
+		if err != nil {
+			return types.Ref{}, types.Ref{}, nil, nil, err
+		}
+	}
+
+	return workingRoot, stagedRoot, mergeState, rebaseState, nil
+}
+
+func loadDatasCommit(ctx context.Context, db *DoltDB, cm *Commit) (*datas.Commit, error) {
+	h, err := cm.HashOf()
+	if err != nil {
+		return nil, err
+	}
+	return datas.LoadCommitAddr(ctx, db.vrw, h)
 }
@@ -294,7 +294,7 @@ func (m Merge) Exec(t *testing.T, dEnv *env.DoltEnv) error {
 		require.NoError(t, err)
 	} else {
 		opts := editor.Options{Deaf: dEnv.DbEaFactory(), Tempdir: dEnv.TempTableFilesDir()}
-		mergedRoot, tblToStats, err := merge.MergeCommits(context.Background(), cm1, cm2, opts)
+		mergedRoot, tblToStats, err := merge.MergeCommits(context.Background(), cm1, cm2, opts, merge.MergeOpts{})
 		require.NoError(t, err)
 		for _, stats := range tblToStats {
 			require.True(t, stats.Conflicts == 0)
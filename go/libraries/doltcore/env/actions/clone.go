@@ -34,6 +34,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/utils/strhelp"
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/datas/pull"
+	"github.com/dolthub/dolt/go/store/hash"
 	"github.com/dolthub/dolt/go/store/nbs"
 	"github.com/dolthub/dolt/go/store/types"
 )
@@ -157,35 +158,61 @@ func sortedKeys(m map[string]iohelp.ReadStats) []string {
 	return keys
 }
 
-func CloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch string, dEnv *env.DoltEnv) error {
-	eventCh := make(chan pull.TableFileEvent, 128)
+// CloneRemote clones the remote database given into the env given, and checks out |branch|, or the remote's default
+// branch if it's empty. With |singleBranch|, only the history of that branch is cloned. A |depth| greater than zero
+// implies |singleBranch| and truncates the cloned history to that many commits, recording the commits at the boundary
+// as shallow commits in the repo state.
+func CloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch string, singleBranch bool, depth int, dEnv *env.DoltEnv) error {
+	var branches []ref.DoltRef
+	var err error
+	if singleBranch || depth > 0 {
+		branches, err = srcDB.GetBranches(ctx)
+		if err != nil {
+			return fmt.Errorf("%w; %s", ErrFailedToListBranches, err.Error())
+		}
+		if len(branches) == 0 {
+			return fmt.Errorf("%w; %s", ErrCloneFailed, ErrNoDataAtRemote.Error())
+		}
+
+		if branch == "" {
+			branch = env.GetDefaultBranch(dEnv, branches)
+		}
+		branches = []ref.DoltRef{ref.NewBranchRef(branch)}
 
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		cloneProg(eventCh)
-	}()
+		err = pullRemoteBranches(ctx, srcDB, branches, depth, dEnv)
+		if err != nil {
+			return err
+		}
+	} else {
+		eventCh := make(chan pull.TableFileEvent, 128)
 
-	err := Clone(ctx, srcDB, dEnv.DoltDB, eventCh)
-	close(eventCh)
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cloneProg(eventCh)
+		}()
 
-	wg.Wait()
+		err = Clone(ctx, srcDB, dEnv.DoltDB, eventCh)
+		close(eventCh)
 
-	if err != nil {
-		if err == pull.ErrNoData {
-			err = ErrNoDataAtRemote
+		wg.Wait()
+
+		if err != nil {
+			if err == pull.ErrNoData {
+				err = ErrNoDataAtRemote
+			}
+			return fmt.Errorf("%w; %s", ErrCloneFailed, err.Error())
 		}
-		return fmt.Errorf("%w; %s", ErrCloneFailed, err.Error())
-	}
 
-	branches, err := dEnv.DoltDB.GetBranches(ctx)
-	if err != nil {
-		return fmt.Errorf("%w; %s", ErrFailedToListBranches, err.Error())
-	}
+		branches, err = dEnv.DoltDB.GetBranches(ctx)
+		if err != nil {
+			return fmt.Errorf("%w; %s", ErrFailedToListBranches, err.Error())
+		}
 
-	if branch == "" {
-		branch = env.GetDefaultBranch(dEnv, branches)
+		if branch == "" {
+			branch = env.GetDefaultBranch(dEnv, branches)
+		}
 	}
 
 	// If we couldn't find a branch but the repo cloned successfully, it's empty. Initialize it instead of pulling from
@@ -265,6 +292,58 @@ func CloneRemote(ctx context.Context, srcDB *doltdb.DoltDB, remoteName, branch s
 	return nil
 }
 
+// pullRemoteBranches pulls the history of |branches| from |srcDB| into the env given, and creates a local branch for
+// each of them. With a |depth| greater than zero, only the last |depth| commits of their history are pulled.
+func pullRemoteBranches(ctx context.Context, srcDB *doltdb.DoltDB, branches []ref.DoltRef, depth int, dEnv *env.DoltEnv) error {
+	heads := make([]hash.Hash, len(branches))
+	for i, brnch := range branches {
+		cm, err := srcDB.ResolveCommitRef(ctx, brnch)
+		if err != nil {
+			return fmt.Errorf("%w: %s; %s", ErrFailedToGetBranch, brnch.GetPath(), err.Error())
+		}
+		heads[i], err = cm.HashOf()
+		if err != nil {
+			return err
+		}
+	}
+
+	excluded, shallow := hash.NewHashSet(), hash.NewHashSet()
+	if depth > 0 {
+		var err error
+		excluded, shallow, err = srcDB.ShallowBoundary(ctx, heads, depth)
+		if err != nil {
+			return fmt.Errorf("%w; %s", ErrCloneFailed, err.Error())
+		}
+	}
+	// the shallow commits are pulled without their parents
+	dEnv.DoltDB.SetShallowCommits(shallow)
+
+	for i, brnch := range branches {
+		newCtx, cancelFunc := context.WithCancel(ctx)
+		wg, progChan, statsCh := NoopRunProgFuncs(newCtx)
+		err := dEnv.DoltDB.PullChunksExcluding(ctx, dEnv.TempTableFilesDir(), srcDB, heads[i], excluded, progChan, statsCh)
+		NoopStopProgFuncs(cancelFunc, wg, progChan, statsCh)
+		if err != nil && err != pull.ErrDBUpToDate {
+			return fmt.Errorf("%w; %s", ErrCloneFailed, err.Error())
+		}
+
+		err = dEnv.DoltDB.SetHead(ctx, brnch, heads[i])
+		if err != nil {
+			return fmt.Errorf("%w; %s", ErrCloneFailed, err.Error())
+		}
+	}
+
+	if len(shallow) > 0 {
+		err := dEnv.SetShallowCommits(shallow)
+		if err != nil {
+			return err
+		}
+	}
+
+	// tags pointing at commits beyond the depth are left behind along with their commits
+	return FetchFollowTags(ctx, dEnv.TempTableFilesDir(), srcDB, dEnv.DoltDB, NoopRunProgFuncs, NoopStopProgFuncs)
+}
+
 // Inits an empty, newly cloned repo. This would be unnecessary if we properly initialized the storage for a repository
 // when we created it on dolthub. If we do that, this code can be removed.
 func InitEmptyClonedRepo(ctx context.Context, dEnv *env.DoltEnv) error {
@@ -17,6 +17,7 @@ package commitwalk
 import (
 	"container/heap"
 	"context"
+	"fmt"
 	"io"
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
@@ -110,13 +111,25 @@ func (q *q) SetInvisible(ctx context.Context, ddb *doltdb.DoltDB, id hash.Hash)
 	return nil
 }
 
+// walkedParents returns the parents of |cm| a walk of its history continues with. The history of a shallow clone ends
+// at its shallow commits, whose parents were not fetched.
+func walkedParents(ctx context.Context, cm *doltdb.Commit) ([]hash.Hash, error) {
+	if cm.IsShallow() {
+		return nil, nil
+	}
+	return cm.ParentHashes(ctx)
+}
+
 func load(ctx context.Context, ddb *doltdb.DoltDB, h hash.Hash) (*doltdb.Commit, error) {
 	cs, err := doltdb.NewCommitSpec(h.String())
 	if err != nil {
 		return nil, err
 	}
 	c, err := ddb.Resolve(ctx, cs, nil)
-	if err != nil {
+	if err == datas.ErrCommitNotFound {
+		// the parent of one of the oldest commits of a shallow clone
+		return nil, fmt.Errorf("%w: %s", doltdb.ErrMissingAncestor, h.String())
+	} else if err != nil {
 		return nil, err
 	}
 	return c, nil
@@ -172,7 +185,7 @@ func GetDotDotRevisions(ctx context.Context, includedDB *doltdb.DoltDB, included
 	}
 	for q.NumVisiblePending() > 0 {
 		nextC := q.PopPending()
-		parents, err := nextC.commit.ParentHashes(ctx)
+		parents, err := walkedParents(ctx, nextC.commit)
 		if err != nil {
 			return nil, err
 		}
@@ -235,7 +248,7 @@ func newCommiterator(ctx context.Context, ddb *doltdb.DoltDB, startCommitHash ha
 func (i *commiterator) Next(ctx context.Context) (hash.Hash, *doltdb.Commit, error) {
 	if i.q.NumVisiblePending() > 0 {
 		nextC := i.q.PopPending()
-		parents, err := nextC.commit.ParentHashes(ctx)
+		parents, err := walkedParents(ctx, nextC.commit)
 		if err != nil {
 			return hash.Hash{}, nil, err
 		}
@@ -295,3 +308,153 @@ func GetTopNTopoOrderedCommitsMatching(ctx context.Context, ddb *doltdb.DoltDB,
 
 	return commitList, nil
 }
+
+// GetDotDotRevisionsIterator returns an iterator over the commits reachable from any of the commits at
+// `includedHeads` that are not reachable from any of the commits at `excludedHeads`. Commits are returned in the
+// same order as GetDotDotRevisions. If `matchFn` is not nil, commits for which it returns false are skipped, but
+// their ancestors are still walked.
+//
+// Roughly mimics `git log feature1 feature2 ^main`.
+func GetDotDotRevisionsIterator(ctx context.Context, ddb *doltdb.DoltDB, includedHeads []hash.Hash, excludedHeads []hash.Hash, matchFn func(*doltdb.Commit) (bool, error)) (doltdb.CommitItr, error) {
+	itr := &dotDotCommiterator{
+		ddb:           ddb,
+		includedHeads: includedHeads,
+		excludedHeads: excludedHeads,
+		matchFn:       matchFn,
+	}
+
+	err := itr.Reset(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return itr, nil
+}
+
+// GetCommonAncestorHeads returns commits that every commit reachable from both `left` and `right` is reachable from.
+// Passing them as the excluded heads of GetDotDotRevisionsIterator, with `left` and `right` included, walks the
+// commits reachable from exactly one of them. Unlike a single merge base, this holds for criss-cross histories
+// with several merge bases.
+//
+// Roughly mimics the commits excluded by `git log left...right`.
+func GetCommonAncestorHeads(ctx context.Context, ddb *doltdb.DoltDB, left, right hash.Hash) ([]hash.Hash, error) {
+	leftAncestors := map[hash.Hash]struct{}{left: {}}
+	pending := []hash.Hash{left}
+	for len(pending) > 0 {
+		h := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		cm, err := load(ctx, ddb, h)
+		if err != nil {
+			return nil, err
+		}
+		parents, err := walkedParents(ctx, cm)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			if _, ok := leftAncestors[parent]; !ok {
+				leftAncestors[parent] = struct{}{}
+				pending = append(pending, parent)
+			}
+		}
+	}
+
+	// Every common ancestor is reachable from the first commit of |leftAncestors| on some path down from |right|,
+	// so the walk of |right| stops there.
+	var heads []hash.Hash
+	seen := map[hash.Hash]struct{}{right: {}}
+	pending = []hash.Hash{right}
+	for len(pending) > 0 {
+		h := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := leftAncestors[h]; ok {
+			heads = append(heads, h)
+			continue
+		}
+		cm, err := load(ctx, ddb, h)
+		if err != nil {
+			return nil, err
+		}
+		parents, err := walkedParents(ctx, cm)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			if _, ok := seen[parent]; !ok {
+				seen[parent] = struct{}{}
+				pending = append(pending, parent)
+			}
+		}
+	}
+
+	return heads, nil
+}
+
+type dotDotCommiterator struct {
+	ddb           *doltdb.DoltDB
+	includedHeads []hash.Hash
+	excludedHeads []hash.Hash
+	matchFn       func(*doltdb.Commit) (bool, error)
+	q             *q
+}
+
+var _ doltdb.CommitItr = (*dotDotCommiterator)(nil)
+
+// Next implements doltdb.CommitItr
+func (i *dotDotCommiterator) Next(ctx context.Context) (hash.Hash, *doltdb.Commit, error) {
+	for i.q.NumVisiblePending() > 0 {
+		nextC := i.q.PopPending()
+		parents, err := walkedParents(ctx, nextC.commit)
+		if err != nil {
+			return hash.Hash{}, nil, err
+		}
+
+		for _, parentID := range parents {
+			if nextC.invisible {
+				if err := i.q.SetInvisible(ctx, nextC.ddb, parentID); err != nil {
+					return hash.Hash{}, nil, err
+				}
+			}
+			if err := i.q.AddPendingIfUnseen(ctx, nextC.ddb, parentID); err != nil {
+				return hash.Hash{}, nil, err
+			}
+		}
+
+		if nextC.invisible {
+			continue
+		}
+
+		if i.matchFn != nil {
+			matches, err := i.matchFn(nextC.commit)
+			if err != nil {
+				return hash.Hash{}, nil, err
+			}
+			if !matches {
+				continue
+			}
+		}
+
+		return nextC.hash, nextC.commit, nil
+	}
+
+	return hash.Hash{}, nil, io.EOF
+}
+
+// Reset implements doltdb.CommitItr
+func (i *dotDotCommiterator) Reset(ctx context.Context) error {
+	i.q = newQueue()
+	for _, excluded := range i.excludedHeads {
+		if err := i.q.SetInvisible(ctx, i.ddb, excluded); err != nil {
+			return err
+		}
+		if err := i.q.AddPendingIfUnseen(ctx, i.ddb, excluded); err != nil {
+			return err
+		}
+	}
+	for _, included := range i.includedHeads {
+		if err := i.q.AddPendingIfUnseen(ctx, i.ddb, included); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -16,6 +16,7 @@ package commitwalk
 
 import (
 	"context"
+	"io"
 	"testing"
 	"time"
 
@@ -211,6 +212,96 @@ func TestGetDotDotRevisions(t *testing.T) {
 	assertEqualHashes(t, featureCommits[1], res[2])
 }
 
+func TestGetDotDotRevisionsIterator(t *testing.T) {
+	ctx := context.Background()
+	dEnv := createUninitializedEnv()
+	err := dEnv.InitRepo(ctx, types.Format_Default, "Bill Billerson", "bill@billerson.com", env.DefaultInitBranch)
+	require.NoError(t, err)
+
+	cs, err := doltdb.NewCommitSpec(env.DefaultInitBranch)
+	require.NoError(t, err)
+	commit, err := dEnv.DoltDB.Resolve(ctx, cs, nil)
+	require.NoError(t, err)
+
+	rv, err := commit.GetRootValue(ctx)
+	require.NoError(t, err)
+	_, rvh, err := dEnv.DoltDB.WriteRootValue(ctx, rv)
+	require.NoError(t, err)
+
+	// Create 3 commits on main.
+	mainCommits := []*doltdb.Commit{commit}
+	for i := 1; i < 4; i++ {
+		mainCommits = append(mainCommits, mustCreateCommit(t, dEnv.DoltDB, env.DefaultInitBranch, rvh, mainCommits[i-1]))
+	}
+
+	// Create 2 commits on each of two feature branches.
+	require.NoError(t, dEnv.DoltDB.NewBranchAtCommit(ctx, ref.NewBranchRef("feature1"), mainCommits[3]))
+	require.NoError(t, dEnv.DoltDB.NewBranchAtCommit(ctx, ref.NewBranchRef("feature2"), mainCommits[3]))
+	feature1Commits := []*doltdb.Commit{mainCommits[3]}
+	feature2Commits := []*doltdb.Commit{mainCommits[3]}
+	for i := 1; i < 3; i++ {
+		feature1Commits = append(feature1Commits, mustCreateCommit(t, dEnv.DoltDB, "feature1", rvh, feature1Commits[i-1]))
+		feature2Commits = append(feature2Commits, mustCreateCommit(t, dEnv.DoltDB, "feature2", rvh, feature2Commits[i-1]))
+	}
+
+	// Branches look like this:
+	//
+	//             feature1:  *--*
+	//                       /
+	// main: --*--*--*--*--*
+	//                       \
+	//             feature2:  *--*
+
+	collect := func(itr doltdb.CommitItr) []hash.Hash {
+		var hashes []hash.Hash
+		for {
+			h, _, err := itr.Next(ctx)
+			if err == io.EOF {
+				return hashes
+			}
+			require.NoError(t, err)
+			hashes = append(hashes, h)
+		}
+	}
+
+	mainHash := mustGetHash(t, mainCommits[3])
+	feature1Hash := mustGetHash(t, feature1Commits[2])
+	feature2Hash := mustGetHash(t, feature2Commits[2])
+
+	itr, err := GetDotDotRevisionsIterator(ctx, dEnv.DoltDB, []hash.Hash{feature1Hash, feature2Hash}, []hash.Hash{mainHash}, nil)
+	require.NoError(t, err)
+	res := collect(itr)
+	require.Len(t, res, 4)
+	assert.ElementsMatch(t, []hash.Hash{
+		mustGetHash(t, feature1Commits[2]),
+		mustGetHash(t, feature1Commits[1]),
+		mustGetHash(t, feature2Commits[2]),
+		mustGetHash(t, feature2Commits[1]),
+	}, res)
+
+	itr, err = GetDotDotRevisionsIterator(ctx, dEnv.DoltDB, []hash.Hash{feature1Hash}, []hash.Hash{mainHash, feature2Hash}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{mustGetHash(t, feature1Commits[2]), mustGetHash(t, feature1Commits[1])}, collect(itr))
+
+	// Without exclusions, every ancestor is returned.
+	itr, err = GetDotDotRevisionsIterator(ctx, dEnv.DoltDB, []hash.Hash{feature1Hash}, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, collect(itr), 6)
+
+	// Commits that do not match are skipped, but their ancestors are still walked.
+	skipped := mustGetHash(t, feature1Commits[2])
+	itr, err = GetDotDotRevisionsIterator(ctx, dEnv.DoltDB, []hash.Hash{feature1Hash}, []hash.Hash{mainHash}, func(c *doltdb.Commit) (bool, error) {
+		h, err := c.HashOf()
+		return h != skipped, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []hash.Hash{mustGetHash(t, feature1Commits[1])}, collect(itr))
+
+	// Reset starts the walk over.
+	require.NoError(t, itr.Reset(ctx))
+	assert.Len(t, collect(itr), 1)
+}
+
 func assertEqualHashes(t *testing.T, lc, rc *doltdb.Commit) {
 	assert.Equal(t, mustGetHash(t, lc), mustGetHash(t, rc))
 }
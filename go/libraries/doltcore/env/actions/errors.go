@@ -27,6 +27,8 @@ const (
 	tblErrTypeNotExist   tblErrorType = "do not exist"
 	tblErrTypeInConflict tblErrorType = "are in conflict"
 	tblErrTypeConstViols tblErrorType = "have constraint violations"
+	tblErrTypeIgnored    tblErrorType = "are ignored by dolt_ignore"
+	tblErrTypeSchConf    tblErrorType = "have unresolved schema conflicts"
 )
 
 type TblError struct {
@@ -46,6 +48,14 @@ func NewTblHasConstraintViolations(tbls []string) TblError {
 	return TblError{tbls, tblErrTypeConstViols}
 }
 
+func NewTblIgnoredError(tbls []string) TblError {
+	return TblError{tbls, tblErrTypeIgnored}
+}
+
+func NewTblSchemaConflictError(tbls []string) TblError {
+	return TblError{tbls, tblErrTypeSchConf}
+}
+
 func (te TblError) Error() string {
 	return "error: the table(s) " + strings.Join(te.tables, ", ") + " " + string(te.tblErrType)
 }
@@ -76,6 +86,14 @@ func IsTblViolatesConstraints(err error) bool {
 	return getTblErrType(err) == tblErrTypeConstViols
 }
 
+func IsTblIgnored(err error) bool {
+	return getTblErrType(err) == tblErrTypeIgnored
+}
+
+func IsTblInSchemaConflict(err error) bool {
+	return getTblErrType(err) == tblErrTypeSchConf
+}
+
 func GetTablesForError(err error) []string {
 	te, ok := err.(TblError)
 
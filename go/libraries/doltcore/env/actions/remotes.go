@@ -29,6 +29,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/utils/earl"
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/datas/pull"
+	"github.com/dolthub/dolt/go/store/hash"
 )
 
 var ErrCantFF = errors.New("can't fast forward merge")
@@ -233,13 +234,14 @@ func DeleteRemoteBranch(ctx context.Context, targetRef ref.BranchRef, remoteRef
 }
 
 // FetchCommit takes a fetches a commit and all underlying data from a remote source database to the local destination database.
-func FetchCommit(ctx context.Context, tempTablesDir string, srcDB, destDB *doltdb.DoltDB, srcDBCommit *doltdb.Commit, progChan chan pull.PullProgress, statsCh chan pull.Stats) error {
+// The commits in |excluded| and their history are not fetched.
+func FetchCommit(ctx context.Context, tempTablesDir string, srcDB, destDB *doltdb.DoltDB, srcDBCommit *doltdb.Commit, excluded hash.HashSet, progChan chan pull.PullProgress, statsCh chan pull.Stats) error {
 	h, err := srcDBCommit.HashOf()
 	if err != nil {
 		return err
 	}
 
-	return destDB.PullChunks(ctx, tempTablesDir, srcDB, h, progChan, statsCh)
+	return destDB.PullChunksExcluding(ctx, tempTablesDir, srcDB, h, excluded, progChan, statsCh)
 }
 
 // FetchTag takes a fetches a commit tag and all underlying data from a remote source database to the local destination database.
@@ -326,6 +328,19 @@ func FetchRemoteBranch(
 	srcRef ref.DoltRef,
 	progStarter ProgStarter,
 	progStopper ProgStopper,
+) (*doltdb.Commit, error) {
+	return fetchRemoteBranch(ctx, tempTablesDir, rem, srcDB, destDB, srcRef, nil, progStarter, progStopper)
+}
+
+func fetchRemoteBranch(
+	ctx context.Context,
+	tempTablesDir string,
+	rem env.Remote,
+	srcDB, destDB *doltdb.DoltDB,
+	srcRef ref.DoltRef,
+	excluded hash.HashSet,
+	progStarter ProgStarter,
+	progStopper ProgStopper,
 ) (*doltdb.Commit, error) {
 	evt := events.GetEventFromContext(ctx)
 
@@ -346,7 +361,7 @@ func FetchRemoteBranch(
 
 	newCtx, cancelFunc := context.WithCancel(ctx)
 	wg, progChan, statsCh := progStarter(newCtx)
-	err = FetchCommit(ctx, tempTablesDir, srcDB, destDB, srcDBCommit, progChan, statsCh)
+	err = FetchCommit(ctx, tempTablesDir, srcDB, destDB, srcDBCommit, excluded, progChan, statsCh)
 	progStopper(cancelFunc, wg, progChan, statsCh)
 	if err == pull.ErrDBUpToDate {
 		err = nil
@@ -361,13 +376,30 @@ func FetchRemoteBranch(
 
 // FetchRefSpecs is the common SQL and CLI entrypoint for fetching branches, tags, and heads from a remote.
 // This function takes dbData which is a env.DbData object for handling repoState read and write, and srcDB is
-// a remote *doltdb.DoltDB object that is used to fetch remote branches from.
-func FetchRefSpecs(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB, refSpecs []ref.RemoteRefSpec, remote env.Remote, mode ref.UpdateMode, progStarter ProgStarter, progStopper ProgStopper) error {
+// a remote *doltdb.DoltDB object that is used to fetch remote branches from. A |depth| greater than zero limits the
+// fetch to that many commits of history from each fetched branch, recording the commits at the boundary as shallow
+// commits in the repo state.
+func FetchRefSpecs(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB, refSpecs []ref.RemoteRefSpec, remote env.Remote, mode ref.UpdateMode, depth int, progStarter ProgStarter, progStopper ProgStopper) error {
 	branchRefs, err := srcDB.GetHeadRefs(ctx)
 	if err != nil {
 		return env.ErrFailedToReadDb
 	}
 
+	excluded, shallow, err := shallowFetchBoundary(ctx, dbData, srcDB, refSpecs, branchRefs, depth)
+	if err != nil {
+		return err
+	}
+	if len(shallow) > 0 {
+		// the commits that may become shallow are pulled without their parents
+		candidates, err := dbData.Rsr.GetShallowCommits()
+		if err != nil {
+			return err
+		}
+		candidates = candidates.Copy()
+		candidates.InsertAll(shallow)
+		dbData.Ddb.SetShallowCommits(candidates)
+	}
+
 	for _, rs := range refSpecs {
 		rsSeen := false
 
@@ -376,7 +408,7 @@ func FetchRefSpecs(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB,
 
 			if remoteTrackRef != nil {
 				rsSeen = true
-				srcDBCommit, err := FetchRemoteBranch(ctx, dbData.Rsw.TempTableFilesDir(), remote, srcDB, dbData.Ddb, branchRef, progStarter, progStopper)
+				srcDBCommit, err := fetchRemoteBranch(ctx, dbData.Rsw.TempTableFilesDir(), remote, srcDB, dbData.Ddb, branchRef, excluded, progStarter, progStopper)
 				if err != nil {
 					return err
 				}
@@ -416,6 +448,13 @@ func FetchRefSpecs(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB,
 		}
 	}
 
+	if len(shallow) > 0 {
+		err = recordShallowCommits(ctx, dbData, shallow)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = FetchFollowTags(ctx, dbData.Rsw.TempTableFilesDir(), srcDB, dbData.Ddb, progStarter, progStopper)
 	if err != nil {
 		return err
@@ -424,6 +463,191 @@ func FetchRefSpecs(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB,
 	return nil
 }
 
+// shallowFetchBoundary returns the commits a fetch of |branchRefs| must exclude, along with the commits it leaves
+// shallow. A |depth| greater than zero cuts the history of the fetched branches at that depth. In a repository that
+// is already shallow, the history missing beyond its shallow commits is excluded as well, so that a fetch doesn't
+// pull it in through the commits that reference it. Any fetched commit with an excluded parent is left shallow.
+func shallowFetchBoundary(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB, refSpecs []ref.RemoteRefSpec, branchRefs []ref.DoltRef, depth int) (excluded, shallow hash.HashSet, err error) {
+	excluded, shallow = hash.NewHashSet(), hash.NewHashSet()
+
+	var heads []hash.Hash
+	for _, branchRef := range branchRefs {
+		for _, rs := range refSpecs {
+			if rs.DestRef(branchRef) == nil {
+				continue
+			}
+
+			cm, err := srcDB.ResolveCommitRef(ctx, branchRef)
+			if err != nil {
+				return nil, nil, err
+			}
+			h, err := cm.HashOf()
+			if err != nil {
+				return nil, nil, err
+			}
+			heads = append(heads, h)
+			break
+		}
+	}
+
+	if depth > 0 {
+		excluded, shallow, err = srcDB.ShallowBoundary(ctx, heads, depth)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	current, err := dbData.Rsr.GetShallowCommits()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var missing []hash.Hash
+	for h := range current {
+		cm, err := dbData.Ddb.ReadCommit(ctx, h)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cm.IsShallow() {
+			parents, err := cm.ParentHashes(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			missing = append(missing, parents...)
+		}
+	}
+
+	if len(missing) > 0 {
+		beyond, _, err := srcDB.ShallowBoundary(ctx, missing, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		excluded.InsertAll(beyond)
+
+		// new commits, such as merges, may reference the missing history as well
+		referencing, err := commitsReferencing(ctx, dbData.Ddb, srcDB, heads, excluded)
+		if err != nil {
+			return nil, nil, err
+		}
+		shallow.InsertAll(referencing)
+	}
+
+	return excluded, shallow, nil
+}
+
+// commitsReferencing walks the commits of |srcDB| reachable from |heads| that |destDB| doesn't have yet, and returns
+// those with a parent in |excluded|.
+func commitsReferencing(ctx context.Context, destDB, srcDB *doltdb.DoltDB, heads []hash.Hash, excluded hash.HashSet) (hash.HashSet, error) {
+	referencing := hash.NewHashSet()
+	visited := hash.NewHashSet()
+	queue := append([]hash.Hash(nil), heads...)
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		if visited.Has(h) || excluded.Has(h) {
+			continue
+		}
+		visited.Insert(h)
+
+		if has, err := destDB.Has(ctx, h); err != nil {
+			return nil, err
+		} else if has {
+			continue
+		}
+
+		cm, err := srcDB.ReadCommit(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		if cm.IsShallow() {
+			continue
+		}
+		parents, err := cm.ParentHashes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range parents {
+			if excluded.Has(p) {
+				referencing.Insert(h)
+			} else {
+				queue = append(queue, p)
+			}
+		}
+	}
+
+	return referencing, nil
+}
+
+// recordShallowCommits adds the commits in |shallow| that are missing their parents after a fetch to the shallow
+// commits of the repo state.
+func recordShallowCommits(ctx context.Context, dbData env.DbData, shallow hash.HashSet) error {
+	current, err := dbData.Rsr.GetShallowCommits()
+	if err != nil {
+		return err
+	}
+
+	updated := current.Copy()
+	for h := range shallow {
+		cm, err := dbData.Ddb.ReadCommit(ctx, h)
+		if err != nil {
+			return err
+		}
+		if cm.IsShallow() {
+			updated.Insert(h)
+		}
+	}
+
+	if updated.Size() == current.Size() {
+		dbData.Ddb.SetShallowCommits(current)
+		return nil
+	}
+
+	dbData.Ddb.SetShallowCommits(updated)
+	return dbData.Rsw.SetShallowCommits(updated)
+}
+
+// Unshallow fetches the history missing from a shallow clone: the parents of its shallow commits and everything they
+// reference. Once it has been fetched, the repository no longer has shallow commits.
+func Unshallow(ctx context.Context, dbData env.DbData, srcDB *doltdb.DoltDB, progStarter ProgStarter, progStopper ProgStopper) error {
+	shallow, err := dbData.Rsr.GetShallowCommits()
+	if err != nil {
+		return err
+	}
+
+	for h := range shallow {
+		cm, err := dbData.Ddb.ReadCommit(ctx, h)
+		if err != nil {
+			return err
+		}
+		if !cm.IsShallow() {
+			continue
+		}
+
+		parents, err := cm.ParentHashes(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parents {
+			newCtx, cancelFunc := context.WithCancel(ctx)
+			wg, progChan, statsCh := progStarter(newCtx)
+			err = dbData.Ddb.PullChunks(ctx, dbData.Rsw.TempTableFilesDir(), srcDB, p, progChan, statsCh)
+			progStopper(cancelFunc, wg, progChan, statsCh)
+			if err == pull.ErrDBUpToDate {
+				err = nil
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	dbData.Ddb.SetShallowCommits(hash.NewHashSet())
+	return dbData.Rsw.SetShallowCommits(hash.NewHashSet())
+}
+
 // SyncRoots copies the entire chunkstore from srcDb to destDb and rewrites the remote manifest. Used to
 // streamline database backup and restores.
 // TODO: this should read/write a backup lock file specific to the client who created the backup
@@ -228,7 +228,8 @@ func ValidateIsRef(ctx context.Context, cSpecStr string, ddb *doltdb.DoltDB, rsr
 
 // CleanUntracked deletes untracked tables from the working root.
 // Evaluates untracked tables as: all working tables - all staged tables.
-func CleanUntracked(ctx context.Context, roots doltdb.Roots, tables []string, dryrun bool) (doltdb.Roots, error) {
+// Untracked tables ignored by the dolt_ignore table are not deleted unless |force| is true.
+func CleanUntracked(ctx context.Context, roots doltdb.Roots, tables []string, dryrun bool, force bool) (doltdb.Roots, error) {
 	untrackedTables := make(map[string]struct{})
 
 	var err error
@@ -264,6 +265,13 @@ func CleanUntracked(ctx context.Context, roots doltdb.Roots, tables []string, dr
 		toDelete = append(toDelete, t)
 	}
 
+	if !force {
+		toDelete, err = doltdb.ExcludeIgnoredTables(ctx, roots, toDelete)
+		if err != nil {
+			return doltdb.Roots{}, err
+		}
+	}
+
 	newRoot, err = newRoot.RemoveTables(ctx, false, false, toDelete...)
 	if err != nil {
 		return doltdb.Roots{}, fmt.Errorf("failed to remove tables; %w", err)
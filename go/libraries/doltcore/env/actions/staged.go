@@ -20,19 +20,52 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 )
 
-func StageTables(ctx context.Context, roots doltdb.Roots, tbls []string) (doltdb.Roots, error) {
+// StageTables stages the tables named. Unless |force| is true, an error is returned if any of the tables named is
+// ignored by the dolt_ignore table.
+func StageTables(ctx context.Context, roots doltdb.Roots, tbls []string, force bool) (doltdb.Roots, error) {
+	if !force {
+		notIgnored, err := doltdb.ExcludeIgnoredTables(ctx, roots, tbls)
+		if err != nil {
+			return doltdb.Roots{}, err
+		}
+		if len(notIgnored) < len(tbls) {
+			return doltdb.Roots{}, NewTblIgnoredError(ignoredTables(tbls, notIgnored))
+		}
+	}
+
 	return stageTables(ctx, roots, tbls)
 }
 
+// StageAllTables stages all the tables in the working root, except for untracked tables ignored by the dolt_ignore
+// table.
 func StageAllTables(ctx context.Context, roots doltdb.Roots) (doltdb.Roots, error) {
 	tbls, err := doltdb.UnionTableNames(ctx, roots.Staged, roots.Working)
 	if err != nil {
 		return doltdb.Roots{}, err
 	}
 
+	tbls, err = doltdb.ExcludeIgnoredTables(ctx, roots, tbls)
+	if err != nil {
+		return doltdb.Roots{}, err
+	}
+
 	return stageTables(ctx, roots, tbls)
 }
 
+// ignoredTables returns the tables in |tbls| that are not in |notIgnored|, which must be a subsequence of |tbls|.
+func ignoredTables(tbls, notIgnored []string) []string {
+	var ignored []string
+	i := 0
+	for _, tbl := range tbls {
+		if i < len(notIgnored) && notIgnored[i] == tbl {
+			i++
+			continue
+		}
+		ignored = append(ignored, tbl)
+	}
+	return ignored
+}
+
 func stageTables(
 	ctx context.Context,
 	roots doltdb.Roots,
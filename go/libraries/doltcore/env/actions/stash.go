@@ -0,0 +1,130 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/store/datas"
+)
+
+var ErrNoLocalChangesToStash = errors.New("no local changes to save")
+var ErrCannotStashConflicts = errors.New("cannot stash changes while there are unresolved conflicts or constraint violations")
+
+// StashChanges records the changes in the working and staged roots given as a new stash entry on top of |headCommit|,
+// and returns the roots with those changes removed. Untracked tables, that is tables in the working root that are not
+// in the staged root, are only stashed if |includeUntracked| is true. Otherwise they are left in the working root.
+func StashChanges(ctx context.Context, ddb *doltdb.DoltDB, roots doltdb.Roots, headCommit *doltdb.Commit, includeUntracked bool, meta *datas.CommitMeta) (*doltdb.Stash, doltdb.Roots, error) {
+	if has, err := roots.Working.HasConflicts(ctx); err != nil {
+		return nil, doltdb.Roots{}, err
+	} else if has {
+		return nil, doltdb.Roots{}, ErrCannotStashConflicts
+	}
+	if has, err := roots.Working.HasConstraintViolations(ctx); err != nil {
+		return nil, doltdb.Roots{}, err
+	} else if has {
+		return nil, doltdb.Roots{}, ErrCannotStashConflicts
+	}
+
+	var untracked []string
+	if !includeUntracked {
+		var err error
+		untracked, err = getUntrackedTables(ctx, roots)
+		if err != nil {
+			return nil, doltdb.Roots{}, err
+		}
+	}
+
+	stashRoot, err := roots.Working.RemoveTables(ctx, false, false, untracked...)
+	if err != nil {
+		return nil, doltdb.Roots{}, err
+	}
+
+	stashHash, err := stashRoot.HashOf()
+	if err != nil {
+		return nil, doltdb.Roots{}, err
+	}
+	stagedHash, err := roots.Staged.HashOf()
+	if err != nil {
+		return nil, doltdb.Roots{}, err
+	}
+	headHash, err := roots.Head.HashOf()
+	if err != nil {
+		return nil, doltdb.Roots{}, err
+	}
+	if stashHash == headHash && stagedHash == headHash {
+		return nil, doltdb.Roots{}, ErrNoLocalChangesToStash
+	}
+
+	stash, err := ddb.AddStash(ctx, headCommit, stashRoot, roots.Staged, meta)
+	if err != nil {
+		return nil, doltdb.Roots{}, err
+	}
+
+	working, err := MoveTablesBetweenRoots(ctx, untracked, roots.Working, roots.Head)
+	if err != nil {
+		return nil, doltdb.Roots{}, err
+	}
+
+	roots.Working = working
+	roots.Staged = roots.Head
+
+	return stash, roots, nil
+}
+
+// StashDescription returns the description recorded for a stash entry created on top of |headCommit| on the branch
+// named |branchName|. If |msg| is empty the description summarizes the head commit instead.
+func StashDescription(ctx context.Context, branchName string, headCommit *doltdb.Commit, msg string) (string, error) {
+	if msg != "" {
+		return fmt.Sprintf("On %s: %s", branchName, msg), nil
+	}
+
+	h, err := headCommit.HashOf()
+	if err != nil {
+		return "", err
+	}
+	meta, err := headCommit.GetCommitMeta(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	firstLine := strings.SplitN(meta.Description, "\n", 2)[0]
+	return fmt.Sprintf("WIP on %s: %s %s", branchName, h.String()[:8], firstLine), nil
+}
+
+// getUntrackedTables returns the names of the tables that exist in the working root but not in the staged root.
+func getUntrackedTables(ctx context.Context, roots doltdb.Roots) ([]string, error) {
+	workingTbls, err := roots.Working.GetTableNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var untracked []string
+	for _, tbl := range workingTbls {
+		has, err := roots.Staged.HasTable(ctx, tbl)
+		if err != nil {
+			return nil, err
+		}
+		if !has {
+			untracked = append(untracked, tbl)
+		}
+	}
+
+	return untracked, nil
+}
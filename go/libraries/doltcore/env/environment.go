@@ -36,6 +36,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
 	"github.com/dolthub/dolt/go/libraries/utils/config"
+	"github.com/dolthub/dolt/go/libraries/utils/earl"
 	"github.com/dolthub/dolt/go/libraries/utils/filesys"
 	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/hash"
@@ -108,7 +109,7 @@ func loadWithFormat(ctx context.Context, hdp HomeDirProvider, fs filesys.Filesys
 	config, cfgErr := LoadDoltCliConfig(hdp, fs)
 	repoState, rsErr := LoadRepoState(fs)
 
-	ddb, dbLoadErr := doltdb.LoadDoltDB(ctx, types.Format_Default, urlStr, fs)
+	ddb, dbLoadErr := loadDoltDB(ctx, types.Format_Default, fs, urlStr, repoState)
 
 	dEnv := &DoltEnv{
 		Version:     version,
@@ -192,6 +193,50 @@ func GetDefaultInitBranch(cfg config.ReadableConfig) string {
 	return GetStringOrDefault(cfg, InitBranchName, DefaultInitBranch)
 }
 
+// storageSchemes are the schemes of the remote storages that the database of a repo can live in.
+var storageSchemes = map[string]bool{
+	dbfactory.AWSScheme:     true,
+	dbfactory.GSScheme:      true,
+	dbfactory.FileScheme:    true,
+	dbfactory.LocalBSScheme: true,
+}
+
+// loadDoltDB loads the database at |urlStr|, unless |repoState| is the state of a repo whose database lives in a
+// remote storage, in which case it loads the storage through a cache of its chunks in the noms dir of |fs|.
+func loadDoltDB(ctx context.Context, nbf *types.NomsBinFormat, fs filesys.Filesys, urlStr string, repoState *RepoState) (*doltdb.DoltDB, error) {
+	if repoState == nil {
+		return doltdb.LoadDoltDB(ctx, nbf, urlStr, fs)
+	}
+
+	var ddb *doltdb.DoltDB
+	if repoState.Storage == "" {
+		var err error
+		ddb, err = doltdb.LoadDoltDB(ctx, nbf, urlStr, fs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		cacheDir, err := fs.Abs(dbfactory.DoltDataDir)
+		if err != nil {
+			return nil, err
+		}
+
+		params := make(map[string]interface{})
+		for k, v := range repoState.StorageParams {
+			params[k] = v
+		}
+		params[dbfactory.ChunkCacheDirParam] = cacheDir
+
+		ddb, err = doltdb.LoadDoltDBWithParams(ctx, nbf, repoState.Storage, fs, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ddb.SetShallowCommits(repoState.ShallowCommits())
+	return ddb, nil
+}
+
 // Valid returns whether this environment has been properly initialized. This is useful because although every command
 // gets a DoltEnv, not all of them require it, and we allow invalid dolt envs to be passed around for this reason.
 func (dEnv *DoltEnv) Valid() bool {
@@ -360,6 +405,90 @@ func (dEnv *DoltEnv) InitRepoWithTime(ctx context.Context, nbf *types.NomsBinFor
 	return err
 }
 
+// InitRepoWithStorage inits a repo whose database lives in the remote storage at |storageUrl|, loaded with
+// |storageParams|, rather than in its noms dir, which only caches the chunks read from the storage. A storage without
+// branches is initialized with an empty commit on |branchName|. The repo checks out |branchName| if the storage has
+// it, and its first branch otherwise.
+func (dEnv *DoltEnv) InitRepoWithStorage(ctx context.Context, nbf *types.NomsBinFormat, name, email, branchName, storageUrl string, storageParams map[string]string) error {
+	u, err := earl.Parse(storageUrl)
+	if err != nil {
+		return err
+	}
+	if !storageSchemes[strings.ToLower(u.Scheme)] {
+		return fmt.Errorf("unsupported storage url '%s': the storage of a database must be an aws, gs or file url", storageUrl)
+	}
+
+	doltDir, err := dEnv.createDirectories(".")
+
+	if err != nil {
+		return err
+	}
+
+	err = dEnv.configureRepo(doltDir)
+
+	if err == nil {
+		err = dEnv.initStorageAndRepoState(ctx, nbf, name, email, branchName, storageUrl, storageParams)
+	}
+
+	if err != nil {
+		dEnv.bestEffortDeleteAll(dbfactory.DoltDir)
+	}
+
+	return err
+}
+
+func (dEnv *DoltEnv) initStorageAndRepoState(ctx context.Context, nbf *types.NomsBinFormat, name, email, branchName, storageUrl string, storageParams map[string]string) error {
+	rs := &RepoState{
+		Remotes:       make(map[string]Remote),
+		Branches:      make(map[string]BranchConfig),
+		Backups:       make(map[string]Remote),
+		Storage:       storageUrl,
+		StorageParams: storageParams,
+	}
+
+	var err error
+	dEnv.DoltDB, err = loadDoltDB(ctx, nbf, dEnv.FS, dEnv.urlStr, rs)
+	if err != nil {
+		return err
+	}
+
+	branches, err := dEnv.DoltDB.GetBranches(ctx)
+	if err != nil {
+		return err
+	}
+
+	var head ref.DoltRef = ref.NewBranchRef(branchName)
+	if len(branches) == 0 {
+		err = dEnv.DoltDB.WriteEmptyRepoWithCommitTime(ctx, branchName, name, email, datas.CommitNowFunc())
+		if err != nil {
+			return fmt.Errorf("%w: %v", doltdb.ErrNomsIO, err)
+		}
+	} else {
+		found := false
+		for _, br := range branches {
+			found = found || ref.Equals(br, head)
+		}
+		if !found {
+			head = branches[0]
+		}
+	}
+
+	rs.Head = ref.MarshalableRef{Ref: head}
+	err = rs.Save(dEnv.FS)
+	if err != nil {
+		return ErrStateUpdate
+	}
+	dEnv.RepoState = rs
+	dEnv.RSLoadErr = nil
+
+	_, err = dEnv.WorkingSet(ctx)
+	if err == doltdb.ErrWorkingSetNotFound {
+		return dEnv.initWorkingSetFromRepoState(ctx)
+	}
+
+	return err
+}
+
 func (dEnv *DoltEnv) InitRepoWithNoData(ctx context.Context, nbf *types.NomsBinFormat) error {
 	doltDir, err := dEnv.createDirectories(".")
 
@@ -1041,6 +1170,30 @@ func (dEnv *DoltEnv) UpdateBranch(name string, new BranchConfig) error {
 	return nil
 }
 
+// GetShallowCommits returns the commits of a shallow clone whose parents were not fetched. It's empty for a
+// repository with its full history.
+func (dEnv *DoltEnv) GetShallowCommits() (hash.HashSet, error) {
+	if dEnv.RSLoadErr != nil {
+		return nil, dEnv.RSLoadErr
+	}
+
+	return dEnv.RepoState.ShallowCommits(), nil
+}
+
+// SetShallowCommits records the commits given as the commits whose parents were not fetched, replacing the ones
+// recorded before. An empty set records a repository with its full history.
+func (dEnv *DoltEnv) SetShallowCommits(commits hash.HashSet) error {
+	if dEnv.RSLoadErr != nil {
+		return dEnv.RSLoadErr
+	}
+
+	dEnv.RepoState.SetShallowCommits(commits)
+	if dEnv.DoltDB != nil {
+		dEnv.DoltDB.SetShallowCommits(commits)
+	}
+	return dEnv.RepoState.Save(dEnv.FS)
+}
+
 var ErrNotACred = errors.New("not a valid credential key id or public key")
 
 func (dEnv *DoltEnv) FindCreds(credsDir, pubKeyOrId string) (string, error) {
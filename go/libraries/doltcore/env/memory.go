@@ -211,6 +211,14 @@ func (m MemoryRepoState) RemoveRemote(ctx context.Context, name string) error {
 	return fmt.Errorf("cannot delete a remote from a memory database")
 }
 
+func (m MemoryRepoState) GetShallowCommits() (hash.HashSet, error) {
+	return hash.NewHashSet(), nil
+}
+
+func (m MemoryRepoState) SetShallowCommits(commits hash.HashSet) error {
+	return fmt.Errorf("cannot record shallow commits in a memory database")
+}
+
 func (m MemoryRepoState) TempTableFilesDir() string {
 	return os.TempDir()
 }
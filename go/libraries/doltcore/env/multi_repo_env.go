@@ -41,6 +41,11 @@ type EnvNameAndPath struct {
 	Name string
 	// Path is the path on disk to where the environment lives
 	Path string
+	// Storage is the url of the remote storage the database of the environment lives in, if it's not stored on disk
+	// at Path. The environment is initialized at Path on first load, and then keeps a cache of the storage's chunks.
+	Storage string
+	// StorageParams are the parameters with which Storage is loaded, such as the region of an aws storage
+	StorageParams map[string]string
 }
 
 // MultiRepoEnv is a type used to store multiple environments which can be retrieved by name
@@ -317,19 +322,19 @@ func MultiEnvForPaths(
 	ignoreLockFile bool,
 	envNamesAndPaths ...EnvNameAndPath,
 ) (*MultiRepoEnv, error) {
-	nameToPath := make(map[string]string)
+	nameToPath := make(map[string]EnvNameAndPath)
 	for _, nameAndPath := range envNamesAndPaths {
-		existingPath, ok := nameToPath[nameAndPath.Name]
+		existing, ok := nameToPath[nameAndPath.Name]
 
 		if ok {
-			if existingPath == nameAndPath.Path {
+			if existing.Path == nameAndPath.Path {
 				continue
 			}
 
-			return nil, fmt.Errorf("databases at paths '%s' and '%s' both attempted to load with the name '%s'", existingPath, nameAndPath.Path, nameAndPath.Name)
+			return nil, fmt.Errorf("databases at paths '%s' and '%s' both attempted to load with the name '%s'", existing.Path, nameAndPath.Path, nameAndPath.Name)
 		}
 
-		nameToPath[nameAndPath.Name] = nameAndPath.Path
+		nameToPath[nameAndPath.Name] = nameAndPath
 	}
 
 	mrEnv := &MultiRepoEnv{
@@ -339,13 +344,21 @@ func MultiEnvForPaths(
 		ignoreLockFile: ignoreLockFile,
 	}
 
-	for name, path := range nameToPath {
-		absPath, err := fs.Abs(path)
+	for name, nameAndPath := range nameToPath {
+		absPath, err := fs.Abs(nameAndPath.Path)
 
 		if err != nil {
 			return nil, err
 		}
 
+		if nameAndPath.Storage != "" {
+			err = initEnvWithStorage(ctx, hdp, cfg, fs, absPath, version, nameAndPath)
+
+			if err != nil {
+				return nil, fmt.Errorf("error initializing environment '%s' at path '%s': %s", name, absPath, err.Error())
+			}
+		}
+
 		fsForEnv, err := filesys.LocalFilesysWithWorkingDir(absPath)
 
 		if err != nil {
@@ -369,6 +382,46 @@ func MultiEnvForPaths(
 	return mrEnv, nil
 }
 
+// initEnvWithStorage inits the environment at |absPath| whose database lives in the storage of |nameAndPath|, unless
+// it was initialized by a previous load. An environment that was initialized must use the same storage.
+func initEnvWithStorage(ctx context.Context, hdp HomeDirProvider, cfg config.ReadableConfig, fs filesys.Filesys, absPath, version string, nameAndPath EnvNameAndPath) error {
+	_, storageUrl, err := GetAbsRemoteUrl(fs, cfg, nameAndPath.Storage)
+
+	if err != nil {
+		return err
+	}
+
+	err = fs.MkDirs(absPath)
+
+	if err != nil {
+		return err
+	}
+
+	fsForEnv, err := filesys.LocalFilesysWithWorkingDir(absPath)
+
+	if err != nil {
+		return err
+	}
+
+	if exists, _ := fsForEnv.Exists(dbfactory.DoltDir); exists {
+		rs, err := LoadRepoState(fsForEnv)
+
+		if err != nil {
+			return err
+		} else if rs.Storage != storageUrl {
+			return fmt.Errorf("the database uses the storage '%s', not '%s'", rs.Storage, storageUrl)
+		}
+
+		return nil
+	}
+
+	name := GetStringOrDefault(cfg, UserNameKey, "")
+	email := GetStringOrDefault(cfg, UserEmailKey, "")
+	dEnv := Load(ctx, hdp, fsForEnv, doltdb.LocalDirDoltDB, version)
+
+	return dEnv.InitRepoWithStorage(ctx, types.Format_Default, name, email, GetDefaultInitBranch(cfg), storageUrl, nameAndPath.StorageParams)
+}
+
 func DBNamesAndPathsFromDir(fs filesys.Filesys, path string) ([]EnvNameAndPath, error) {
 	var envNamesAndPaths []EnvNameAndPath
 	err := fs.Iter(path, false, func(path string, size int64, isDir bool) (stop bool) {
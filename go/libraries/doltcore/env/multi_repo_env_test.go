@@ -197,7 +197,7 @@ func TestLoadMultiEnv(t *testing.T) {
 
 	envNamesAndPaths := make([]EnvNameAndPath, len(names))
 	for i, name := range names {
-		envNamesAndPaths[i] = EnvNameAndPath{name, filepath.Join(rootPath, name)}
+		envNamesAndPaths[i] = EnvNameAndPath{Name: name, Path: filepath.Join(rootPath, name)}
 	}
 
 	mrEnv, err := MultiEnvForPaths(context.Background(), hdp, config.NewEmptyMapConfig(), filesys.LocalFS, "test", false, envNamesAndPaths...)
@@ -208,3 +208,34 @@ func TestLoadMultiEnv(t *testing.T) {
 		assert.NotNil(t, e)
 	}
 }
+
+func TestLoadMultiEnvWithStorage(t *testing.T) {
+	rootPath, hdp, envs := initMultiEnv(t, "TestLoadMultiEnvWithStorage", []string{"central"})
+	storageUrl := earl.FileUrlFromPath(filepath.Join(rootPath, "central", ".dolt", "noms"), os.PathSeparator)
+	cfg := config.NewMapConfig(map[string]string{UserNameKey: name, UserEmailKey: email})
+
+	envNameAndPath := EnvNameAndPath{Name: "remote", Path: filepath.Join(rootPath, "remote"), Storage: storageUrl}
+	for i := 0; i < 2; i++ {
+		mrEnv, err := MultiEnvForPaths(context.Background(), hdp, cfg, filesys.LocalFS, "test", false, envNameAndPath)
+		require.NoError(t, err)
+
+		dEnv := mrEnv.GetEnv("remote")
+		require.NotNil(t, dEnv)
+		assert.Equal(t, storageUrl, dEnv.RepoState.Storage)
+		assert.Equal(t, envs["central"].RepoState.CWBHeadRef(), dEnv.RepoState.CWBHeadRef())
+
+		expected, err := envs["central"].HeadCommit(context.Background())
+		require.NoError(t, err)
+		actual, err := dEnv.HeadCommit(context.Background())
+		require.NoError(t, err)
+		expectedHash, err := expected.HashOf()
+		require.NoError(t, err)
+		actualHash, err := actual.HashOf()
+		require.NoError(t, err)
+		assert.Equal(t, expectedHash, actualHash)
+	}
+
+	envNameAndPath.Storage = earl.FileUrlFromPath(filepath.Join(rootPath, "other"), os.PathSeparator)
+	_, err := MultiEnvForPaths(context.Background(), hdp, cfg, filesys.LocalFS, "test", false, envNameAndPath)
+	assert.Error(t, err)
+}
@@ -17,6 +17,7 @@ package env
 import (
 	"context"
 	"encoding/json"
+	"sort"
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
@@ -30,6 +31,7 @@ type RepoStateReader interface {
 	GetRemotes() (map[string]Remote, error)
 	GetBackups() (map[string]Remote, error)
 	GetBranches() (map[string]BranchConfig, error)
+	GetShallowCommits() (hash.HashSet, error)
 }
 
 type RepoStateWriter interface {
@@ -44,6 +46,7 @@ type RepoStateWriter interface {
 	RemoveBackup(ctx context.Context, name string) error
 	TempTableFilesDir() string
 	UpdateBranch(name string, new BranchConfig) error
+	SetShallowCommits(commits hash.HashSet) error
 }
 
 type DbData struct {
@@ -62,6 +65,13 @@ type RepoState struct {
 	Remotes  map[string]Remote       `json:"remotes"`
 	Backups  map[string]Remote       `json:"backups"`
 	Branches map[string]BranchConfig `json:"branches"`
+	// Shallow holds the commits of a shallow clone whose parents were not fetched.
+	Shallow []string `json:"shallow,omitempty"`
+	// Storage is the URL of the remote storage of a repo whose database doesn't live in its noms dir, and
+	// StorageParams are the parameters used to load it. The noms dir of such a repo only caches the chunks read from
+	// the storage.
+	Storage       string            `json:"storage,omitempty"`
+	StorageParams map[string]string `json:"storage_params,omitempty"`
 	// |staged|, |working|, and |merge| are legacy fields left over from when Dolt repos stored this info in the repo
 	// state file, not in the DB directly. They're still here so that we can migrate existing repositories forward to the
 	// new storage format, but they should be used only for this purpose and are no longer written.
@@ -77,9 +87,13 @@ type repoStateLegacy struct {
 	Remotes  map[string]Remote       `json:"remotes"`
 	Backups  map[string]Remote       `json:"backups"`
 	Branches map[string]BranchConfig `json:"branches"`
+	Shallow  []string                `json:"shallow,omitempty"`
 	Staged   string                  `json:"staged,omitempty"`
 	Working  string                  `json:"working,omitempty"`
 	Merge    *mergeState             `json:"merge,omitempty"`
+
+	Storage       string            `json:"storage,omitempty"`
+	StorageParams map[string]string `json:"storage_params,omitempty"`
 }
 
 // repoStateLegacyFromRepoState creates a new repoStateLegacy from a RepoState file. Only for testing.
@@ -89,9 +103,13 @@ func repoStateLegacyFromRepoState(rs *RepoState) *repoStateLegacy {
 		Remotes:  rs.Remotes,
 		Backups:  rs.Backups,
 		Branches: rs.Branches,
+		Shallow:  rs.Shallow,
 		Staged:   rs.staged,
 		Working:  rs.working,
 		Merge:    rs.merge,
+
+		Storage:       rs.Storage,
+		StorageParams: rs.StorageParams,
 	}
 }
 
@@ -106,9 +124,13 @@ func (rs *repoStateLegacy) toRepoState() *RepoState {
 		Remotes:  rs.Remotes,
 		Backups:  rs.Backups,
 		Branches: rs.Branches,
+		Shallow:  rs.Shallow,
 		staged:   rs.Staged,
 		working:  rs.Working,
 		merge:    rs.Merge,
+
+		Storage:       rs.Storage,
+		StorageParams: rs.StorageParams,
 	}
 }
 
@@ -217,3 +239,23 @@ func (rs *RepoState) AddBackup(r Remote) {
 func (rs *RepoState) RemoveBackup(r Remote) {
 	delete(rs.Backups, r.Name)
 }
+
+// ShallowCommits returns the commits of a shallow clone whose parents were not fetched.
+func (rs *RepoState) ShallowCommits() hash.HashSet {
+	shallow := hash.NewHashSet()
+	for _, s := range rs.Shallow {
+		if h, ok := hash.MaybeParse(s); ok {
+			shallow.Insert(h)
+		}
+	}
+	return shallow
+}
+
+// SetShallowCommits replaces the commits of a shallow clone whose parents were not fetched.
+func (rs *RepoState) SetShallowCommits(shallow hash.HashSet) {
+	rs.Shallow = nil
+	for h := range shallow {
+		rs.Shallow = append(rs.Shallow, h.String())
+	}
+	sort.Strings(rs.Shallow)
+}
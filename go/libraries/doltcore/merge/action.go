@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
@@ -222,7 +223,7 @@ func ExecuteFFMerge(
 
 func ExecuteMerge(ctx context.Context, dEnv *env.DoltEnv, spec *MergeSpec) (map[string]*MergeStats, error) {
 	opts := editor.Options{Deaf: dEnv.BulkDbEaFactory(), Tempdir: dEnv.TempTableFilesDir()}
-	mergedRoot, tblToStats, err := MergeCommits(ctx, spec.HeadC, spec.MergeC, opts)
+	mergedRoot, tblToStats, err := MergeCommits(ctx, spec.HeadC, spec.MergeC, opts, MergeOpts{KeepSchemaConflicts: !spec.Squash})
 	if err != nil {
 		switch err {
 		case doltdb.ErrUpToDate:
@@ -263,6 +264,17 @@ func mergedRootToWorking(
 		if err != nil {
 			return actions.ErrFailedToSaveRepoState
 		}
+
+		if tbls := schemaConflicts(tblToStats); len(tbls) > 0 {
+			ws, err := dEnv.WorkingSet(ctx)
+			if err != nil {
+				return err
+			}
+			err = dEnv.UpdateWorkingSet(ctx, ws.WithSchemaConflicts(tbls))
+			if err != nil {
+				return actions.ErrFailedToSaveRepoState
+			}
+		}
 	}
 
 	err = dEnv.UpdateWorkingRoot(context.Background(), workingRoot)
@@ -271,7 +283,7 @@ func mergedRootToWorking(
 	}
 
 	conflicts, constraintViolations := conflictsAndViolations(tblToStats)
-	if len(conflicts) > 0 || len(constraintViolations) > 0 {
+	if len(conflicts) > 0 || len(constraintViolations) > 0 || len(schemaConflicts(tblToStats)) > 0 {
 		return err
 	}
 
@@ -291,3 +303,15 @@ func conflictsAndViolations(tblToStats map[string]*MergeStats) (conflicts []stri
 	}
 	return
 }
+
+// schemaConflicts returns the names of the tables whose schemas could not be merged, in order.
+func schemaConflicts(tblToStats map[string]*MergeStats) []string {
+	var tbls []string
+	for tblName, stats := range tblToStats {
+		if stats.SchemaConflicts > 0 {
+			tbls = append(tbls, tblName)
+		}
+	}
+	sort.Strings(tbls)
+	return tbls
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+)
+
+// ApplyStash three-way merges the changes recorded in |stash| into |roots|. The commit the stash was created on top of
+// is used as the merge base:
+//
+// Base:   stash^
+// Ours:   roots.Working
+// Theirs: stash
+//
+// Any conflicts or constraint violations produced by the merge are stored in the returned working root, just as they
+// are for a regular merge. If the stash records its staged changes and the working merge is clean, those changes are
+// merged into |roots.Staged| the same way. A staged merge that does not apply cleanly leaves |roots.Staged| unchanged,
+// as the changes are still present in the working root.
+func ApplyStash(ctx context.Context, roots doltdb.Roots, stash *doltdb.Stash, opts editor.Options) (doltdb.Roots, map[string]*MergeStats, error) {
+	stashRoot, err := stash.Commit.GetRootValue(ctx)
+	if err != nil {
+		return doltdb.Roots{}, nil, err
+	}
+
+	baseCommit, err := stash.HeadCommit(ctx)
+	if err != nil {
+		return doltdb.Roots{}, nil, err
+	}
+	baseRoot, err := baseCommit.GetRootValue(ctx)
+	if err != nil {
+		return doltdb.Roots{}, nil, err
+	}
+
+	working, tblToStats, err := MergeRoots(ctx, roots.Working, stashRoot, baseRoot, stash.Commit, baseCommit, opts, MergeOpts{IsCherryPick: false})
+	if err != nil {
+		return doltdb.Roots{}, nil, err
+	}
+	roots.Working = working
+	if hasUnmergedTables(tblToStats) {
+		return roots, tblToStats, nil
+	}
+
+	stashStaged, ok, err := stash.StagedRoot(ctx)
+	if err != nil {
+		return doltdb.Roots{}, nil, err
+	} else if !ok {
+		return roots, tblToStats, nil
+	}
+
+	staged, stagedStats, err := MergeRoots(ctx, roots.Staged, stashStaged, baseRoot, stash.Commit, baseCommit, opts, MergeOpts{IsCherryPick: false})
+	if err != nil {
+		return doltdb.Roots{}, nil, err
+	}
+	if !hasUnmergedTables(stagedStats) {
+		roots.Staged = staged
+	}
+
+	return roots, tblToStats, nil
+}
+
+func hasUnmergedTables(tblToStats map[string]*MergeStats) bool {
+	for _, stats := range tblToStats {
+		if stats.Conflicts > 0 || stats.ConstraintViolations > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -42,7 +42,7 @@ var ErrConflictsIncompatible = errors.New("the existing conflicts are of a diffe
 
 var ErrMultipleViolationsForRow = errors.New("multiple violations for row not supported")
 
-func MergeCommits(ctx context.Context, commit, mergeCommit *doltdb.Commit, opts editor.Options) (*doltdb.RootValue, map[string]*MergeStats, error) {
+func MergeCommits(ctx context.Context, commit, mergeCommit *doltdb.Commit, opts editor.Options, mergeOpts MergeOpts) (*doltdb.RootValue, map[string]*MergeStats, error) {
 	ancCommit, err := doltdb.GetCommitAncestor(ctx, commit, mergeCommit)
 	if err != nil {
 		return nil, nil, err
@@ -63,7 +63,7 @@ func MergeCommits(ctx context.Context, commit, mergeCommit *doltdb.Commit, opts
 		return nil, nil, err
 	}
 
-	return MergeRoots(ctx, ourRoot, theirRoot, ancRoot, mergeCommit, ancCommit, opts, MergeOpts{IsCherryPick: false})
+	return MergeRoots(ctx, ourRoot, theirRoot, ancRoot, mergeCommit, ancCommit, opts, mergeOpts)
 }
 
 // MergeRoots three-way merges |ourRoot|, |theirRoot|, and |ancRoot| and returns
@@ -76,6 +76,11 @@ func MergeCommits(ctx context.Context, commit, mergeCommit *doltdb.Commit, opts
 // Constraint violations that exist in ancestor are stashed and merged with the
 // violations we detect when we diff the ancestor and the newly merged root.
 //
+// Schema conflicts abort the merge, unless |mergeOpts| keeps them. The tables
+// whose schemas conflict are then left as they are in |ourRoot|, as are our
+// conflicting foreign keys, and their schema conflicts are counted in their
+// stats. ResolveSchemaConflicts merges them once their conflicts are resolved.
+//
 // |theirRootIsh| is the hash of their's working set or commit. It is used to
 // key any artifacts generated by this merge. |ancRootIsh| is similar and is
 // used to retrieve the base value for a conflict.
@@ -171,7 +176,19 @@ func MergeRoots(
 		return nil, nil, err
 	}
 	if len(conflicts) > 0 {
-		return nil, nil, fmt.Errorf("foreign key conflicts")
+		if !mergeOpts.KeepSchemaConflicts {
+			return nil, nil, fmt.Errorf("foreign key conflicts")
+		}
+		// the merged foreign keys keep our side of the conflicts until they are resolved
+		for _, c := range conflicts {
+			stats, ok := tblToStats[c.Ours.TableName]
+			if !ok {
+				stats = &MergeStats{}
+				tblToStats[c.Ours.TableName] = stats
+			}
+			stats.Operation = TableModified
+			stats.SchemaConflicts++
+		}
 	}
 
 	mergedRoot, err = mergedRoot.PutForeignKeyCollection(ctx, mergedFKColl)
@@ -189,6 +206,11 @@ func MergeRoots(
 		return nil, nil, err
 	}
 
+	mergedRoot, err = addDefinitionViolations(ctx, mergedRoot, ourRoot, theirs)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	if types.IsFormat_DOLT_1(ourRoot.VRW().Format()) {
 		err = getConstraintViolationStats(ctx, mergedRoot, tblToStats)
 		if err != nil {
@@ -0,0 +1,668 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb/durable"
+	"github.com/dolthub/dolt/go/libraries/doltcore/row"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/index"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/store/pool"
+	"github.com/dolthub/dolt/go/store/types"
+	"github.com/dolthub/dolt/go/store/val"
+)
+
+// fragmentTable describes a system table holding the definitions of schema objects: views, triggers, stored
+// procedures or docs. The rows of these tables are merged object by object instead of primary key by primary key.
+type fragmentTable struct {
+	// objectTags are the tags of the columns naming an object, and objectCols their names.
+	objectTags []uint64
+	objectCols []string
+	// defTag is the tag of the column holding the definition of an object, and defCol its name.
+	defTag uint64
+	defCol string
+	// idTag is the tag of the surrogate primary key of the table, when the table isn't keyed by its objects.
+	idTag uint64
+	hasID bool
+	// isSQL is whether the definitions are SQL statements, which are compared by their parsed form. Other definitions
+	// are text, merged line by line.
+	isSQL bool
+}
+
+var fragmentTables = map[string]fragmentTable{
+	doltdb.SchemasTableName: {
+		objectTags: []uint64{schema.DoltSchemasTypeTag, schema.DoltSchemasNameTag},
+		objectCols: []string{doltdb.SchemasTablesTypeCol, doltdb.SchemasTablesNameCol},
+		defTag:     schema.DoltSchemasFragmentTag,
+		defCol:     doltdb.SchemasTablesFragmentCol,
+		idTag:      schema.DoltSchemasIdTag,
+		hasID:      true,
+		isSQL:      true,
+	},
+	doltdb.ProceduresTableName: {
+		objectTags: []uint64{schema.DoltProceduresNameTag},
+		objectCols: []string{doltdb.ProceduresTableNameCol},
+		defTag:     schema.DoltProceduresCreateStmtTag,
+		defCol:     doltdb.ProceduresTableCreateStmtCol,
+		isSQL:      true,
+	},
+	doltdb.DocTableName: {
+		objectTags: []uint64{schema.DocNameTag},
+		objectCols: []string{doltdb.DocPkColumnName},
+		defTag:     schema.DocTextTag,
+		defCol:     doltdb.DocTextColumnName,
+	},
+}
+
+// FragmentTableColumns returns the names of the columns naming the objects defined in the system table |tblName| and
+// the name of the column holding their definitions, if the rows of |tblName| are merged object by object.
+func FragmentTableColumns(tblName string) (objectCols []string, defCol string, ok bool) {
+	ft, ok := fragmentTables[tblName]
+	if !ok {
+		return nil, "", false
+	}
+	return ft.objectCols, ft.defCol, true
+}
+
+// fits returns whether |sch| has the columns of |ft|, and is keyed by its objects or by its surrogate key.
+func (ft fragmentTable) fits(sch schema.Schema) bool {
+	cols := sch.GetAllCols()
+	for _, tag := range append(ft.objectTags, ft.defTag) {
+		if _, ok := cols.GetByTag(tag); !ok {
+			return false
+		}
+	}
+	pkTags := sch.GetPKCols().Tags
+	if ft.hasID {
+		return len(pkTags) == 1 && pkTags[0] == ft.idTag
+	}
+	if len(pkTags) != len(ft.objectTags) {
+		return false
+	}
+	for i, tag := range ft.objectTags {
+		if pkTags[i] != tag {
+			return false
+		}
+	}
+	return true
+}
+
+// objectKey returns the key identifying the object defined by |vals|.
+func (ft fragmentTable) objectKey(vals map[uint64]interface{}) string {
+	names := make([]interface{}, len(ft.objectTags))
+	for i, tag := range ft.objectTags {
+		names[i] = vals[tag]
+	}
+	return ft.keyOf(names)
+}
+
+// keyOf returns the key identifying the object named by the values |names| of its object columns. The names of views
+// and triggers are case-insensitive.
+func (ft fragmentTable) keyOf(names []interface{}) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprint(name)
+		if ft.hasID {
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// FragmentObjectKey returns the key identifying an object of the system table |tblName| from the values |names| of
+// its object columns, as named by FragmentTableColumns.
+func FragmentObjectKey(tblName string, names []interface{}) string {
+	return fragmentTables[tblName].keyOf(names)
+}
+
+// FragmentDefinitions returns the definitions of the objects of the system table |tblName| of |root|, by the keys
+// returned by FragmentObjectKey.
+func FragmentDefinitions(ctx context.Context, root *doltdb.RootValue, tblName string) (map[string]interface{}, error) {
+	ft, ok := fragmentTables[tblName]
+	if !ok {
+		return nil, fmt.Errorf("table '%s' does not hold definitions", tblName)
+	}
+	tm := TableMerger{name: tblName, vrw: root.VRW(), ns: root.NodeStore()}
+	_, rows, _, err := readFragmentTable(ctx, tm, root, ft)
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]interface{}, len(rows))
+	for _, vals := range rows {
+		defs[ft.objectKey(vals)] = vals[ft.defTag]
+	}
+	return defs, nil
+}
+
+// objectName returns the name of the object defined by |vals|, as it is shown to users.
+func (ft fragmentTable) objectName(vals map[uint64]interface{}) string {
+	parts := make([]string, len(ft.objectTags))
+	for i, tag := range ft.objectTags {
+		parts[i] = fmt.Sprint(vals[tag])
+	}
+	return strings.Join(parts, " ")
+}
+
+// definitionsEqual returns whether the objects defined by |a| and |b| have the same definition. SQL definitions are
+// equal when they parse to the same statement, whatever their formatting.
+func (ft fragmentTable) definitionsEqual(a, b map[uint64]interface{}) bool {
+	aDef, aOk := a[ft.defTag].(string)
+	bDef, bOk := b[ft.defTag].(string)
+	if !aOk || !bOk {
+		return aOk == bOk
+	}
+	if aDef == bDef || !ft.isSQL {
+		return aDef == bDef
+	}
+	aNorm, aOk := normalizedDefinition(aDef)
+	bNorm, bOk := normalizedDefinition(bDef)
+	return aOk && bOk && aNorm == bNorm
+}
+
+// rowsEqual returns whether |a| and |b| hold the same values, except for their surrogate keys. A nil row is only
+// equal to another nil row.
+func (ft fragmentTable) rowsEqual(sch schema.Schema, a, b map[uint64]interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	equal := true
+	_ = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+		if ft.hasID && tag == ft.idTag {
+			return false, nil
+		}
+		v, w := a[tag], b[tag]
+		if v == nil || w == nil {
+			equal = v == nil && w == nil
+		} else {
+			cmp, err := col.TypeInfo.ToSqlType().Compare(v, w)
+			equal = err == nil && cmp == 0
+		}
+		return !equal, nil
+	})
+	return equal
+}
+
+// normalizedDefinition returns the canonical form of the SQL statement |def|, or false if it does not parse.
+func normalizedDefinition(def string) (string, bool) {
+	stmt, err := sqlparser.Parse(def)
+	if err != nil {
+		return "", false
+	}
+	return sqlparser.String(stmt), true
+}
+
+// fragmentObject is an object defined in a fragment table, with its rows on each side of a merge. The row of a side
+// where the object doesn't exist is nil.
+type fragmentObject struct {
+	key               string
+	ours, theirs, anc map[uint64]interface{}
+}
+
+// resolve rewrites the rows of |obj| so that merging them row by row takes the changes made to the object on either
+// side. Changes that only reformat a definition are dropped in favor of the other side's, and line edits made to
+// different parts of a doc on each side are merged.
+func (obj *fragmentObject) resolve(ft fragmentTable) {
+	o, t, a := obj.ours, obj.theirs, obj.anc
+	switch {
+	case o != nil && t != nil && ft.definitionsEqual(o, t):
+		// both sides made the same change, ours is kept
+		obj.theirs = copyRow(o)
+	case a != nil && t != nil && ft.definitionsEqual(a, t):
+		// their side didn't change the object
+		obj.theirs = copyRow(a)
+	case a != nil && o != nil && ft.definitionsEqual(a, o):
+		// our side didn't change the object, theirs is taken
+		obj.anc = copyRow(o)
+	case !ft.isSQL && a != nil && o != nil && t != nil:
+		aDef, _ := a[ft.defTag].(string)
+		oDef, _ := o[ft.defTag].(string)
+		tDef, _ := t[ft.defTag].(string)
+		if merged, ok := mergeLines(aDef, oDef, tDef); ok {
+			obj.anc = copyRow(o)
+			obj.theirs = copyRow(o)
+			obj.theirs[ft.defTag] = merged
+		}
+	}
+}
+
+// conflicts returns whether the rows of |obj| are in conflict once resolved.
+func (obj *fragmentObject) conflicts(ft fragmentTable, sch schema.Schema) bool {
+	return obj.theirs != nil &&
+		!ft.rowsEqual(sch, obj.ours, obj.anc) &&
+		!ft.rowsEqual(sch, obj.theirs, obj.anc) &&
+		!ft.rowsEqual(sch, obj.ours, obj.theirs)
+}
+
+func copyRow(vals map[uint64]interface{}) map[uint64]interface{} {
+	cp := make(map[uint64]interface{}, len(vals))
+	for tag, v := range vals {
+		cp[tag] = v
+	}
+	return cp
+}
+
+// mergeFragments rewrites the tables of |tm|, one of the system tables holding the definitions of schema objects, so
+// that merging their rows merges the objects they define. The rows of an object are resolved by |resolve|, and the
+// surrogate keys of the rows of `dolt_schemas`, which are assigned independently on each side, are made to agree on
+// the object they key. The tables are left as they are if their schemas differ from one another.
+func mergeFragments(ctx context.Context, tm TableMerger, ft fragmentTable, opts editor.Options) (TableMerger, error) {
+	if tm.leftTbl == nil || tm.rightTbl == nil || tm.ancTbl == nil {
+		return tm, nil
+	}
+	if !ft.fits(tm.leftSch) ||
+		!schema.ColCollsAreEqual(tm.leftSch.GetAllCols(), tm.rightSch.GetAllCols()) ||
+		!schema.ColCollsAreEqual(tm.leftSch.GetAllCols(), tm.ancSch.GetAllCols()) {
+		return tm, nil
+	}
+	// the conflicts and violations of our side would not survive its table being rewritten
+	if has, err := tm.leftTbl.HasConflicts(ctx); err != nil || has {
+		return tm, err
+	}
+	if n, err := tm.leftTbl.NumConstraintViolations(ctx); err != nil || n > 0 {
+		return tm, err
+	}
+
+	objs := make(map[string]*fragmentObject)
+	sides := []struct {
+		tbl *doltdb.Table
+		set func(obj *fragmentObject, vals map[uint64]interface{})
+	}{
+		{tm.leftTbl, func(obj *fragmentObject, vals map[uint64]interface{}) { obj.ours = vals }},
+		{tm.rightTbl, func(obj *fragmentObject, vals map[uint64]interface{}) { obj.theirs = vals }},
+		{tm.ancTbl, func(obj *fragmentObject, vals map[uint64]interface{}) { obj.anc = vals }},
+	}
+	for i, side := range sides {
+		rows, err := readFragmentRows(ctx, tm, side.tbl, tm.leftSch)
+		if err != nil {
+			return TableMerger{}, err
+		}
+		seen := make(map[string]bool)
+		for _, vals := range rows {
+			key := ft.objectKey(vals)
+			if seen[key] {
+				// an object defined twice can only be merged row by row
+				return tm, nil
+			}
+			seen[key] = true
+			obj, ok := objs[key]
+			if !ok {
+				obj = &fragmentObject{key: key}
+				objs[key] = obj
+			}
+			sides[i].set(obj, vals)
+		}
+	}
+
+	sorted := make([]*fragmentObject, 0, len(objs))
+	for _, obj := range objs {
+		obj.resolve(ft)
+		sorted = append(sorted, obj)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].key < sorted[j].key
+	})
+	if ft.hasID {
+		assignFragmentIDs(ft, tm.leftSch, sorted)
+	}
+
+	var left, right, anc []map[uint64]interface{}
+	for _, obj := range sorted {
+		if obj.ours != nil {
+			left = append(left, obj.ours)
+		}
+		if obj.theirs != nil {
+			right = append(right, obj.theirs)
+		}
+		if obj.anc != nil {
+			anc = append(anc, obj.anc)
+		}
+	}
+
+	var err error
+	if tm.leftTbl, err = writeFragmentRows(ctx, tm, tm.leftSch, left, opts); err != nil {
+		return TableMerger{}, err
+	}
+	if tm.rightTbl, err = writeFragmentRows(ctx, tm, tm.leftSch, right, opts); err != nil {
+		return TableMerger{}, err
+	}
+	if tm.ancTbl, err = writeFragmentRows(ctx, tm, tm.leftSch, anc, opts); err != nil {
+		return TableMerger{}, err
+	}
+	return tm, nil
+}
+
+// assignFragmentIDs gives the rows of each of |objs| the same surrogate key on every side, and a different one than
+// the rows of the other objects. Objects that will be in conflict take the key of their side, from where the values
+// of their conflicts are read. The other ones keep the key of our side, of the ancestor or of their side, in that
+// order, and are given a new key when it is already taken.
+func assignFragmentIDs(ft fragmentTable, sch schema.Schema, objs []*fragmentObject) {
+	var maxID int64
+	for _, obj := range objs {
+		for _, vals := range []map[uint64]interface{}{obj.ours, obj.theirs, obj.anc} {
+			if id, ok := vals[ft.idTag].(int64); ok && id > maxID {
+				maxID = id
+			}
+		}
+	}
+
+	ids := make([]int64, len(objs))
+	assigned := make([]bool, len(objs))
+	used := make(map[int64]bool)
+	assign := func(i int, vals map[uint64]interface{}) {
+		id, ok := vals[ft.idTag].(int64)
+		if assigned[i] || !ok || used[id] {
+			return
+		}
+		ids[i], assigned[i], used[id] = id, true, true
+	}
+
+	for i, obj := range objs {
+		if obj.conflicts(ft, sch) {
+			assign(i, obj.theirs)
+		}
+	}
+	for _, side := range []func(obj *fragmentObject) map[uint64]interface{}{
+		func(obj *fragmentObject) map[uint64]interface{} { return obj.ours },
+		func(obj *fragmentObject) map[uint64]interface{} { return obj.anc },
+		func(obj *fragmentObject) map[uint64]interface{} { return obj.theirs },
+	} {
+		for i, obj := range objs {
+			if vals := side(obj); vals != nil {
+				assign(i, vals)
+			}
+		}
+	}
+
+	for i, obj := range objs {
+		if !assigned[i] {
+			maxID++
+			ids[i] = maxID
+		}
+		for _, vals := range []map[uint64]interface{}{obj.ours, obj.theirs, obj.anc} {
+			if vals != nil {
+				vals[ft.idTag] = ids[i]
+			}
+		}
+	}
+}
+
+// readFragmentRows returns the values of the rows of |tbl|, whose schema is |sch|, by tag.
+func readFragmentRows(ctx context.Context, tm TableMerger, tbl *doltdb.Table, sch schema.Schema) ([]map[uint64]interface{}, error) {
+	var rows []map[uint64]interface{}
+	if types.IsFormat_DOLT_1(tbl.Format()) {
+		idx, err := tbl.GetRowData(ctx)
+		if err != nil {
+			return nil, err
+		}
+		m := durable.ProllyMapFromIndex(idx)
+		kd, vd := m.Descriptors()
+		iter, err := m.IterAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			k, v, err := iter.Next(ctx)
+			if err == io.EOF {
+				return rows, nil
+			} else if err != nil {
+				return nil, err
+			}
+			vals, err := prollyTaggedValues(ctx, tm, sch, kd, vd, k, v)
+			if err != nil {
+				return nil, err
+			}
+			rows = append(rows, vals)
+		}
+	}
+
+	m, err := tbl.GetNomsRowData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = m.IterAll(ctx, func(k, v types.Value) error {
+		r, err := row.FromNoms(sch, k.(types.Tuple), v.(types.Tuple))
+		if err != nil {
+			return err
+		}
+		vals := make(map[uint64]interface{})
+		err = sch.GetAllCols().Iter(func(tag uint64, col schema.Column) (stop bool, err error) {
+			nv, _ := r.GetColVal(tag)
+			if types.IsNull(nv) {
+				return false, nil
+			}
+			vals[tag], err = col.TypeInfo.ConvertNomsValueToValue(nv)
+			return err != nil, err
+		})
+		if err != nil {
+			return err
+		}
+		rows = append(rows, vals)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// writeFragmentRows returns a table of schema |sch| holding |rows|.
+func writeFragmentRows(ctx context.Context, tm TableMerger, sch schema.Schema, rows []map[uint64]interface{}, opts editor.Options) (*doltdb.Table, error) {
+	if types.IsFormat_DOLT_1(tm.vrw.Format()) {
+		empty, err := durable.NewEmptyIndex(ctx, tm.vrw, tm.ns, sch)
+		if err != nil {
+			return nil, err
+		}
+		m := durable.ProllyMapFromIndex(empty)
+		mut := m.Mutate()
+		kd, vd := m.Descriptors()
+		for _, vals := range rows {
+			k, v, err := prollyTuples(ctx, tm, sch, kd, vd, m.Pool(), vals)
+			if err != nil {
+				return nil, err
+			}
+			if err = mut.Put(ctx, k, v); err != nil {
+				return nil, err
+			}
+		}
+		written, err := mut.Map(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newProllyTable(ctx, tm, sch, written)
+	}
+
+	empty, err := doltdb.NewEmptyTable(ctx, tm.vrw, tm.ns, sch)
+	if err != nil {
+		return nil, err
+	}
+	ed, err := editor.NewTableEditor(ctx, empty, sch, tm.name, opts)
+	if err != nil {
+		return nil, err
+	}
+	dupCb := func(keyString, indexName string, _, _ types.Tuple, _ bool) error {
+		return fmt.Errorf("duplicate key '%s' on index '%s' of table '%s'", keyString, indexName, tm.name)
+	}
+	for _, vals := range rows {
+		tv, err := nomsTaggedValues(ctx, tm, sch, vals)
+		if err != nil {
+			return nil, err
+		}
+		r, err := row.New(tm.vrw.Format(), sch, tv)
+		if err != nil {
+			return nil, err
+		}
+		if err = ed.InsertRow(ctx, r, dupCb); err != nil {
+			return nil, err
+		}
+	}
+	return ed.Table(ctx)
+}
+
+// nomsTaggedValues converts the go values |vals| of a row of a table whose schema is |sch| to noms values.
+func nomsTaggedValues(ctx context.Context, tm TableMerger, sch schema.Schema, vals map[uint64]interface{}) (row.TaggedValues, error) {
+	tv := make(row.TaggedValues)
+	for tag, v := range vals {
+		col, ok := sch.GetAllCols().GetByTag(tag)
+		if !ok || v == nil {
+			continue
+		}
+		nv, err := col.TypeInfo.ConvertValueToNomsValue(ctx, tm.vrw, v)
+		if err != nil {
+			return nil, err
+		}
+		tv[tag] = nv
+	}
+	return tv, nil
+}
+
+// prollyTuples builds the key and value tuples of a row of a table whose schema is |sch| from its go values |vals|.
+func prollyTuples(ctx context.Context, tm TableMerger, sch schema.Schema, kd, vd val.TupleDesc, p pool.BuffPool, vals map[uint64]interface{}) (val.Tuple, val.Tuple, error) {
+	kb := val.NewTupleBuilder(kd)
+	for i, col := range sch.GetPKCols().GetColumns() {
+		if err := index.PutField(ctx, tm.ns, kb, i, vals[col.Tag]); err != nil {
+			return nil, nil, err
+		}
+	}
+	vb := val.NewTupleBuilder(vd)
+	for i, col := range sch.GetNonPKCols().GetColumns() {
+		if err := index.PutField(ctx, tm.ns, vb, i, vals[col.Tag]); err != nil {
+			return nil, nil, err
+		}
+	}
+	return kb.Build(p), vb.Build(p), nil
+}
+
+// FragmentConflictDiff returns the changes made to the definition of an object on each side of a merge, as unified
+// diffs from its |base| definition to |ours| and to |theirs|. A nil definition is one of an object that doesn't exist
+// on that side.
+func FragmentConflictDiff(base, ours, theirs interface{}) string {
+	var sb strings.Builder
+	for _, side := range []struct {
+		name string
+		def  interface{}
+	}{{"ours", ours}, {"theirs", theirs}} {
+		fromFile, toFile := "base", side.name
+		if base == nil {
+			fromFile = "/dev/null"
+		}
+		if side.def == nil {
+			toFile = "/dev/null"
+		}
+		diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        definitionLines(base),
+			B:        definitionLines(side.def),
+			FromFile: fromFile,
+			ToFile:   toFile,
+			Context:  3,
+		})
+		sb.WriteString(diff)
+	}
+	return sb.String()
+}
+
+// definitionLines returns the lines of the definition |def|, each ending with a newline.
+func definitionLines(def interface{}) []string {
+	s, ok := def.(string)
+	if !ok {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(s, "\n"))
+}
+
+// splitLines splits |s| into its lines, keeping their line endings.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineHunk replaces the lines [i1, i2) of a text with |lines|.
+type lineHunk struct {
+	i1, i2 int
+	lines  []string
+}
+
+func lineHunks(base, other []string) []lineHunk {
+	var hunks []lineHunk
+	for _, op := range difflib.NewMatcher(base, other).GetOpCodes() {
+		if op.Tag != 'e' {
+			hunks = append(hunks, lineHunk{i1: op.I1, i2: op.I2, lines: other[op.J1:op.J2]})
+		}
+	}
+	return hunks
+}
+
+func (h lineHunk) equals(other lineHunk) bool {
+	if h.i1 != other.i1 || h.i2 != other.i2 || len(h.lines) != len(other.lines) {
+		return false
+	}
+	for i := range h.lines {
+		if h.lines[i] != other.lines[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeLines merges the lines changed from |base| in |ours| and in |theirs|. It returns false if both sides changed
+// the same or adjacent lines differently.
+func mergeLines(base, ours, theirs string) (string, bool) {
+	baseLines := splitLines(base)
+	ourHunks := lineHunks(baseLines, splitLines(ours))
+	theirHunks := lineHunks(baseLines, splitLines(theirs))
+
+	hunks := ourHunks
+	for _, th := range theirHunks {
+		dup := false
+		for _, oh := range ourHunks {
+			if oh.equals(th) {
+				dup = true
+				break
+			}
+			if oh.i1 <= th.i2 && th.i1 <= oh.i2 {
+				return "", false
+			}
+		}
+		if !dup {
+			hunks = append(hunks, th)
+		}
+	}
+	sort.Slice(hunks, func(i, j int) bool {
+		return hunks[i].i1 < hunks[j].i1
+	})
+
+	var sb strings.Builder
+	pos := 0
+	for _, h := range hunks {
+		sb.WriteString(strings.Join(baseLines[pos:h.i1], ""))
+		sb.WriteString(strings.Join(h.lines, ""))
+		pos = h.i2
+	}
+	sb.WriteString(strings.Join(baseLines[pos:], ""))
+	return sb.String(), true
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"testing"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeLines(t *testing.T) {
+	base := "# Title\n\nfirst\nsecond\nthird\nfourth\n"
+
+	tests := []struct {
+		name     string
+		ours     string
+		theirs   string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "unchanged",
+			ours:     base,
+			theirs:   base,
+			expected: base,
+			ok:       true,
+		},
+		{
+			name:     "separate lines",
+			ours:     "# New Title\n\nfirst\nsecond\nthird\nfourth\n",
+			theirs:   "# Title\n\nfirst\nsecond\nthird\nfourth\nfifth\n",
+			expected: "# New Title\n\nfirst\nsecond\nthird\nfourth\nfifth\n",
+			ok:       true,
+		},
+		{
+			name:     "same change",
+			ours:     "# Title\n\nfirst\n2nd\nthird\nfourth\n",
+			theirs:   "# Title\n\nfirst\n2nd\nthird\nfourth\n",
+			expected: "# Title\n\nfirst\n2nd\nthird\nfourth\n",
+			ok:       true,
+		},
+		{
+			name:   "same line",
+			ours:   "# Title\n\nfirst\n2nd\nthird\nfourth\n",
+			theirs: "# Title\n\nfirst\nsecond!\nthird\nfourth\n",
+			ok:     false,
+		},
+		{
+			name:   "adjacent lines",
+			ours:   "# Title\n\nfirst\n2nd\nthird\nfourth\n",
+			theirs: "# Title\n\nfirst\nsecond\n3rd\nfourth\n",
+			ok:     false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			merged, ok := mergeLines(base, test.ours, test.theirs)
+			require.Equal(t, test.ok, ok)
+			if ok {
+				assert.Equal(t, test.expected, merged)
+			}
+		})
+	}
+}
+
+func TestNormalizedDefinition(t *testing.T) {
+	a, ok := normalizedDefinition("CREATE VIEW v AS SELECT * FROM t WHERE pk > 1")
+	require.True(t, ok)
+	b, ok := normalizedDefinition("create view v as\n  select *\n  from t\n  where pk>1")
+	require.True(t, ok)
+	assert.Equal(t, a, b)
+
+	c, ok := normalizedDefinition("CREATE VIEW v AS SELECT * FROM t WHERE pk > 2")
+	require.True(t, ok)
+	assert.NotEqual(t, a, c)
+
+	_, ok = normalizedDefinition("CREATE VIEW v AS SELEKT")
+	assert.False(t, ok)
+}
+
+func TestSelectedTables(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"SELECT 1", nil},
+		{"SELECT 1 FROM dual", nil},
+		{"SELECT * FROM t", []string{"t"}},
+		{"SELECT * FROM t JOIN u ON t.pk = u.pk", []string{"t", "u"}},
+		{"SELECT * FROM db.t", nil},
+		{"SELECT * FROM t WHERE pk IN (SELECT pk FROM u)", []string{"t", "u"}},
+		{"WITH c AS (SELECT pk FROM t) SELECT * FROM c JOIN u ON c.pk = u.pk", []string{"t", "u"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			stmt, err := sqlparser.Parse(test.query)
+			require.NoError(t, err)
+			sel, ok := stmt.(sqlparser.SelectStatement)
+			require.True(t, ok)
+			assert.ElementsMatch(t, test.expected, selectedTables(sel))
+		})
+	}
+}
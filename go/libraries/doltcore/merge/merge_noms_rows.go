@@ -484,6 +484,10 @@ func nomsPkRowMerge(ctx context.Context, nbf *types.NomsBinFormat, sch schema.Sc
 
 		if valutil.NilSafeEqCheck(val, mergeVal) {
 			return val, false
+		} else if baseRow == nil {
+			// the same row was inserted with different values, a NULL
+			// cell on one side is a conflicting value like any other
+			return nil, true
 		} else {
 			modified := !valutil.NilSafeEqCheck(val, baseVal)
 			mergeModified := !valutil.NilSafeEqCheck(mergeVal, baseVal)
@@ -34,8 +34,29 @@ import (
 
 type MergeOpts struct {
 	IsCherryPick bool
+	// KeepSchemaConflicts leaves the tables whose schemas cannot be merged unmerged instead of failing the merge. The
+	// number of schema conflicts of these tables is reported in their MergeStats.
+	KeepSchemaConflicts bool
+	// SchemaConflictResolution is the side whose schema is taken where the schemas of a table conflict.
+	SchemaConflictResolution SchemaConflictResolution
 }
 
+// SchemaConflictResolution is the side whose schema is taken where the schemas of a table conflict.
+type SchemaConflictResolution int
+
+const (
+	// SchemaConflictsUnresolved leaves schema conflicts unresolved.
+	SchemaConflictsUnresolved SchemaConflictResolution = iota
+	// TakeOurSchema resolves schema conflicts with the schema of our side.
+	TakeOurSchema
+	// TakeTheirSchema resolves schema conflicts with the schema of their side.
+	TakeTheirSchema
+)
+
+// maxSchemaResolutionRounds bounds the rounds of schema conflict resolution. SchemaMerge reports the conflicts of the
+// columns, indexes and checks of a table in turn, so each round may reveal the conflicts of the next ones.
+const maxSchemaResolutionRounds = 4
+
 type TableMerger struct {
 	name string
 
@@ -120,12 +141,47 @@ func (rm *RootMerger) MergeTable(ctx context.Context, tblName string, opts edito
 		return nil, nil, errors.New(fmt.Sprintf("schema changes not supported: %s table schema does not match in current HEAD and cherry-pick commit.", tblName))
 	}
 
+	unmerged := tm.leftTbl
+	if ft, ok := fragmentTables[tblName]; ok {
+		tm, err = mergeFragments(ctx, tm, ft, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tm, violations, err := convertSchemaChanges(ctx, tm, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	mergeSch, schConflicts, err := SchemaMerge(tm.vrw.Format(), tm.leftSch, tm.rightSch, tm.ancSch, tblName)
 	if err != nil {
 		return nil, nil, err
 	}
+	for i := 0; schConflicts.Count() != 0 && mergeOpts.SchemaConflictResolution != SchemaConflictsUnresolved && i < maxSchemaResolutionRounds; i++ {
+		var vs []conversionViolation
+		tm, vs, err = resolveSchemaConflicts(ctx, tm, schConflicts, mergeOpts.SchemaConflictResolution, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		violations = append(violations, vs...)
+
+		tm, vs, err = convertSchemaChanges(ctx, tm, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		violations = append(violations, vs...)
+
+		mergeSch, schConflicts, err = SchemaMerge(tm.vrw.Format(), tm.leftSch, tm.rightSch, tm.ancSch, tblName)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
 	if schConflicts.Count() != 0 {
-		// error on schema conflicts for now
+		if mergeOpts.KeepSchemaConflicts {
+			// the table is left as it is on our side until its schema conflicts are resolved
+			return unmerged, &MergeStats{Operation: TableModified, SchemaConflicts: schConflicts.Count()}, nil
+		}
 		return nil, nil, fmt.Errorf("%w.\n%s", ErrSchemaConflict, schConflicts.AsError().Error())
 	}
 
@@ -156,6 +212,11 @@ func (rm *RootMerger) MergeTable(ctx context.Context, tblName string, opts edito
 		if err != nil {
 			return nil, nil, err
 		}
+
+		mergeTbl, err = addConversionViolations(ctx, tm, mergeTbl, violations)
+		if err != nil {
+			return nil, nil, err
+		}
 		return mergeTbl, stats, nil
 	}
 
@@ -216,6 +277,11 @@ func (rm *RootMerger) MergeTable(ctx context.Context, tblName string, opts edito
 		return nil, nil, err
 	}
 
+	resultTbl, err = addConversionViolations(ctx, tm, resultTbl, violations)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	return resultTbl, stats, nil
 }
 
@@ -43,12 +43,38 @@ type SchemaConflict struct {
 	ColConflicts []ColConflict
 	IdxConflicts []IdxConflict
 	ChkConflicts []ChkConflict
+	FKConflicts  []FKConflict
 }
 
 var EmptySchConflicts = SchemaConflict{}
 
 func (sc SchemaConflict) Count() int {
-	return len(sc.ColConflicts) + len(sc.IdxConflicts) + len(sc.ChkConflicts)
+	return len(sc.ColConflicts) + len(sc.IdxConflicts) + len(sc.ChkConflicts) + len(sc.FKConflicts)
+}
+
+// Descriptions returns the distinct descriptions of the conflicts, in the order of AsError.
+func (sc SchemaConflict) Descriptions() []string {
+	var descs []string
+	seen := make(map[string]bool)
+	add := func(desc string) {
+		if !seen[desc] {
+			seen[desc] = true
+			descs = append(descs, desc)
+		}
+	}
+	for _, c := range sc.ColConflicts {
+		add(c.String())
+	}
+	for _, c := range sc.IdxConflicts {
+		add(c.String())
+	}
+	for _, c := range sc.ChkConflicts {
+		add(c.String())
+	}
+	for _, c := range sc.FKConflicts {
+		add(c.String())
+	}
+	return descs
 }
 
 func (sc SchemaConflict) AsError() error {
@@ -63,6 +89,9 @@ func (sc SchemaConflict) AsError() error {
 	for _, c := range sc.ChkConflicts {
 		b.WriteString(fmt.Sprintf("\t%s\n", c.String()))
 	}
+	for _, c := range sc.FKConflicts {
+		b.WriteString(fmt.Sprintf("\t%s\n", c.String()))
+	}
 	return fmt.Errorf(b.String())
 }
 
@@ -87,6 +116,12 @@ type IdxConflict struct {
 }
 
 func (c IdxConflict) String() string {
+	switch c.Kind {
+	case NameCollision:
+		return fmt.Sprintf("two indexes with the name '%s' but different definitions", c.Ours.Name())
+	case TagCollision:
+		return fmt.Sprintf("different index definitions for our index %s and their index %s", c.Ours.Name(), c.Theirs.Name())
+	}
 	return ""
 }
 
@@ -95,6 +130,16 @@ type FKConflict struct {
 	Ours, Theirs doltdb.ForeignKey
 }
 
+func (c FKConflict) String() string {
+	switch c.Kind {
+	case NameCollision:
+		return fmt.Sprintf("two foreign keys with the name '%s' but different definitions", c.Ours.Name)
+	case TagCollision:
+		return fmt.Sprintf("different foreign key definitions for our foreign key %s and their foreign key %s", c.Ours.Name, c.Theirs.Name)
+	}
+	return ""
+}
+
 type ChkConflict struct {
 	Kind         conflictKind
 	Ours, Theirs schema.Check
@@ -261,14 +306,20 @@ func ForeignKeysMerge(ctx context.Context, mergedRoot, ourRoot, theirRoot, ancRo
 	})
 
 	err = ourNewFKs.Iter(func(ourFK doltdb.ForeignKey) (stop bool, err error) {
-		return false, common.AddKeys(ourFK)
+		return false, addForeignKey(common, ourFK)
 	})
 	if err != nil {
 		return nil, nil, err
 	}
 
 	err = theirNewFKs.Iter(func(theirFK doltdb.ForeignKey) (stop bool, err error) {
-		return false, common.AddKeys(theirFK)
+		for _, c := range conflicts {
+			if c.Theirs.DeepEquals(theirFK) {
+				// our side of a conflict is kept until the conflict is resolved
+				return false, nil
+			}
+		}
+		return false, addForeignKey(common, theirFK)
 	})
 	if err != nil {
 		return nil, nil, err
@@ -289,10 +340,29 @@ func mergeColumns(ourCC, theirCC, ancCC *schema.ColCollection) (merged *schema.C
 	ourNewCols := schema.ColCollectionSetDifference(ourCC, ancCC)
 	theirNewCols := schema.ColCollectionSetDifference(theirCC, ancCC)
 
+	// check for conflicts between the changes made to a column on each branch, when they gave it different tags
+	retagged := make(map[uint64]bool)
+	_ = ancCC.Iter(func(tag uint64, ancCol schema.Column) (stop bool, err error) {
+		ourCol, ok := changedColumn(ourCC, ancCC, ancCol)
+		if !ok {
+			return false, nil
+		}
+		theirCol, ok := changedColumn(theirCC, ancCC, ancCol)
+		if ok && ourCol.Tag != theirCol.Tag {
+			conflicts = append(conflicts, ColConflict{
+				Kind:   TagCollision,
+				Ours:   ourCol,
+				Theirs: theirCol,
+			})
+			retagged[ourCol.Tag] = true
+		}
+		return false, nil
+	})
+
 	// check for name conflicts between columns added on each branch since the ancestor
 	_ = ourNewCols.Iter(func(tag uint64, ourCol schema.Column) (stop bool, err error) {
 		theirCol, ok := theirNewCols.GetByNameCaseInsensitive(ourCol.Name)
-		if ok && ourCol.Tag != theirCol.Tag {
+		if ok && ourCol.Tag != theirCol.Tag && !retagged[ourCol.Tag] {
 			conflicts = append(conflicts, ColConflict{
 				Kind:   NameCollision,
 				Ours:   ourCol,
@@ -317,6 +387,23 @@ func mergeColumns(ourCC, theirCC, ancCC *schema.ColCollection) (merged *schema.C
 	return merged, conflicts, nil
 }
 
+// changedColumn returns the column of |cc| that |ancCol| of |ancCC| became, if its tag, type or primary key membership
+// changed. A column whose tag changed is found by its name.
+func changedColumn(cc, ancCC *schema.ColCollection, ancCol schema.Column) (schema.Column, bool) {
+	col, ok := cc.GetByTag(ancCol.Tag)
+	if !ok {
+		col, ok = cc.GetByNameCaseInsensitive(ancCol.Name)
+		if ok {
+			_, tagInAnc := ancCC.GetByTag(col.Tag)
+			ok = !tagInAnc
+		}
+	}
+	if !ok || (col.Tag == ancCol.Tag && col.IsPartOfPK == ancCol.IsPartOfPK && col.TypeInfo.Equals(ancCol.TypeInfo)) {
+		return schema.Column{}, false
+	}
+	return col, true
+}
+
 func columnsInCommon(ourCC, theirCC, ancCC *schema.ColCollection) (common *schema.ColCollection, conflicts []ColConflict) {
 	common = schema.NewColCollection()
 	_ = ourCC.Iter(func(tag uint64, ourCol schema.Column) (stop bool, err error) {
@@ -527,6 +614,7 @@ func foreignKeysInCommon(ourFKs, theirFKs, ancFKs *doltdb.ForeignKeyCollection)
 				Ours:   ours,
 				Theirs: theirs,
 			})
+			return false, common.AddKeys(ours)
 		}
 
 		if theirs.EqualDefs(anc) {
@@ -565,7 +653,7 @@ func foreignKeysInCommon(ourFKs, theirFKs, ancFKs *doltdb.ForeignKeyCollection)
 			Ours:   ours,
 			Theirs: theirs,
 		})
-		return false, nil
+		return false, common.AddKeys(ours)
 	})
 
 	if err != nil {
@@ -575,6 +663,14 @@ func foreignKeysInCommon(ourFKs, theirFKs, ancFKs *doltdb.ForeignKeyCollection)
 	return common, conflicts, nil
 }
 
+// addForeignKey adds |fk| to |fkc|, unless it's in it already.
+func addForeignKey(fkc *doltdb.ForeignKeyCollection, fk doltdb.ForeignKey) error {
+	if existing, ok := fkc.GetByNameCaseInsensitive(fk.Name); ok && existing.DeepEquals(fk) {
+		return nil
+	}
+	return fkc.AddKeys(fk)
+}
+
 // fkCollSetDifference returns a collection of all foreign keys that are in the given collection but not the ancestor
 // collection. This is specifically for finding differences between a descendant and an ancestor, and therefore should
 // not be used in the general case.
@@ -0,0 +1,428 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+)
+
+// TableSchemaConflicts returns the conflicts between the schemas of the table |tblName| of |ourRoot| and |theirRoot|,
+// whose merge base is |ancRoot|, and between the foreign keys of which it is the child table. The conflicts of a table
+// that isn't in all three roots are empty.
+func TableSchemaConflicts(ctx context.Context, ourRoot, theirRoot, ancRoot *doltdb.RootValue, tblName string) (SchemaConflict, error) {
+	sc, err := tableSchemaConflicts(ctx, ourRoot, theirRoot, ancRoot, tblName)
+	if err != nil {
+		return SchemaConflict{}, err
+	}
+
+	sc.FKConflicts, err = tableForeignKeyConflicts(ctx, ourRoot, theirRoot, ancRoot, tblName)
+	if err != nil {
+		return SchemaConflict{}, err
+	}
+	return sc, nil
+}
+
+// tableSchemaConflicts returns the conflicts between the schemas of the table |tblName| of |ourRoot| and |theirRoot|
+// that MergeTable would find.
+func tableSchemaConflicts(ctx context.Context, ourRoot, theirRoot, ancRoot *doltdb.RootValue, tblName string) (SchemaConflict, error) {
+	sc := SchemaConflict{TableName: tblName}
+	schs := make([]schema.Schema, 3)
+	for i, root := range []*doltdb.RootValue{ourRoot, theirRoot, ancRoot} {
+		tbl, ok, err := root.GetTable(ctx, tblName)
+		if err != nil {
+			return SchemaConflict{}, err
+		} else if !ok {
+			return sc, nil
+		}
+		if schs[i], err = tbl.GetSchema(ctx); err != nil {
+			return SchemaConflict{}, err
+		}
+	}
+	ourSch, theirSch, ancSch := schs[0], schs[1], schs[2]
+
+	// the schema changes made on one side only are converted before the schemas are merged
+	ourTarget, theirTarget, ancTarget, err := schemaConversionTargets(ourSch, theirSch, ancSch)
+	if err != nil {
+		return SchemaConflict{}, err
+	}
+	if ourTarget != nil {
+		ourSch = ourTarget
+	}
+	if theirTarget != nil {
+		theirSch = theirTarget
+	}
+	if ancTarget != nil {
+		ancSch = ancTarget
+	}
+
+	_, sc, err = SchemaMerge(ourRoot.VRW().Format(), ourSch, theirSch, ancSch, tblName)
+	if err != nil {
+		return SchemaConflict{}, err
+	}
+	sc.TableName = tblName
+	return sc, nil
+}
+
+// tableForeignKeyConflicts returns the conflicts between the foreign keys of |ourRoot| and |theirRoot| of which
+// |tblName| is the child table.
+func tableForeignKeyConflicts(ctx context.Context, ourRoot, theirRoot, ancRoot *doltdb.RootValue, tblName string) ([]FKConflict, error) {
+	_, conflicts, err := ForeignKeysMerge(ctx, ourRoot, ourRoot, theirRoot, ancRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var tblConflicts []FKConflict
+	for _, c := range conflicts {
+		if c.Ours.TableName == tblName {
+			tblConflicts = append(tblConflicts, c)
+		}
+	}
+	return tblConflicts, nil
+}
+
+// ResolveSchemaConflicts resolves the schema conflicts of the table |tblName| of |ourRoot|, the working root of a merge
+// of |theirRoot| whose merge base is |ancRoot|, with the schema of the side |resolution| takes. The table, which the
+// merge left as it is on our side, is then merged with the resolved schema, and the foreign keys of which it is the
+// child table are resolved alike. Returns the updated root and the stats of the merge of the table.
+func ResolveSchemaConflicts(
+	ctx context.Context,
+	ourRoot, theirRoot, ancRoot *doltdb.RootValue,
+	theirs, ancestor doltdb.Rootish,
+	tblName string,
+	resolution SchemaConflictResolution,
+	opts editor.Options,
+) (*doltdb.RootValue, *MergeStats, error) {
+	sc, err := tableSchemaConflicts(ctx, ourRoot, theirRoot, ancRoot, tblName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := ourRoot
+	stats := &MergeStats{Operation: TableModified}
+	if sc.Count() > 0 {
+		merger, err := NewMerger(ourRoot, theirRoot, ancRoot, theirs, ancestor, ourRoot.VRW(), ourRoot.NodeStore())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var tbl *doltdb.Table
+		tbl, stats, err = merger.MergeTable(ctx, tblName, opts, MergeOpts{SchemaConflictResolution: resolution})
+		if err != nil {
+			return nil, nil, err
+		}
+		if tbl == nil {
+			return nil, nil, fmt.Errorf("cannot resolve the schema conflicts of table '%s': the table was deleted", tblName)
+		}
+
+		root, err = root.PutTable(ctx, tblName, tbl)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	root, err = resolveForeignKeyConflicts(ctx, root, theirRoot, ancRoot, tblName, resolution)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	h, err := theirs.HashOf()
+	if err != nil {
+		return nil, nil, err
+	}
+	root, _, err = AddForeignKeyViolations(ctx, root, ancRoot, nil, h)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = getConstraintViolationStats(ctx, root, map[string]*MergeStats{tblName: stats})
+	if err != nil {
+		return nil, nil, err
+	}
+	return root, stats, nil
+}
+
+// resolveForeignKeyConflicts replaces the foreign keys of |root| of which |tblName| is the child table and that
+// conflict with the ones of |theirRoot| by the ones of the side |resolution| takes.
+func resolveForeignKeyConflicts(ctx context.Context, root, theirRoot, ancRoot *doltdb.RootValue, tblName string, resolution SchemaConflictResolution) (*doltdb.RootValue, error) {
+	conflicts, err := tableForeignKeyConflicts(ctx, root, theirRoot, ancRoot, tblName)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		return root, nil
+	}
+
+	fkc, err := root.GetForeignKeyCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range conflicts {
+		fkc.RemoveKeyByName(c.Ours.Name)
+		fkc.RemoveKeyByName(c.Theirs.Name)
+		winner := c.Ours
+		if resolution == TakeTheirSchema {
+			winner = c.Theirs
+		}
+		if err = fkc.AddKeys(winner); err != nil {
+			return nil, err
+		}
+	}
+	return root.PutForeignKeyCollection(ctx, fkc)
+}
+
+// resolveSchemaConflicts resolves the schema conflicts |sc| between the tables of |tm| with the schema of the side
+// |resolution| takes: the table of the other side is converted to its schema where they conflict. The rows of the
+// converted table that were changed since the ancestor and that cannot be converted are returned as violations.
+func resolveSchemaConflicts(ctx context.Context, tm TableMerger, sc SchemaConflict, resolution SchemaConflictResolution, opts editor.Options) (TableMerger, []conversionViolation, error) {
+	if schema.IsKeyless(tm.leftSch) || schema.IsKeyless(tm.rightSch) {
+		return TableMerger{}, nil, fmt.Errorf("cannot resolve the schema conflicts of keyless table '%s'", tm.name)
+	}
+
+	var invalidChecks []string
+	for _, c := range sc.ChkConflicts {
+		if c.Kind == InvalidCheckCollision {
+			invalidChecks = append(invalidChecks, c.Ours.Name())
+		}
+	}
+	if len(invalidChecks) > 0 {
+		// a check that references a column deleted on one side is dropped, whichever side is taken
+		return dropChecks(ctx, tm, invalidChecks)
+	}
+
+	takeOurs := resolution == TakeOurSchema
+	loserTbl, loserSch := tm.rightTbl, tm.rightSch
+	if !takeOurs {
+		loserTbl, loserSch = tm.leftTbl, tm.leftSch
+	}
+
+	target, err := resolvedSchema(loserSch, sc, takeOurs)
+	if err != nil || target == nil {
+		return tm, nil, err
+	}
+
+	var converted *doltdb.Table
+	var violations []conversionViolation
+	if schema.SchemasAreEqual(target, loserSch) {
+		// only the checks differ, the rows are kept as they are
+		converted, err = loserTbl.UpdateSchema(ctx, target)
+	} else {
+		converted, violations, err = convertTable(ctx, tm, loserTbl, loserSch, target, tm.ancTbl, tm.ancSch, opts)
+	}
+	if err != nil {
+		return TableMerger{}, nil, err
+	}
+
+	resolved := tm
+	if takeOurs {
+		resolved.rightTbl, resolved.rightSch = converted, target
+	} else {
+		resolved.leftTbl, resolved.leftSch = converted, target
+	}
+	return resolved, violations, nil
+}
+
+// resolvedSchema returns |loser|, the schema of the side that isn't taken to resolve the schema conflicts |sc|, with
+// its conflicting columns, indexes and checks replaced by the ones of the side that is taken. It returns nil if
+// |loser| is left unchanged, or if the conflicts cannot be resolved this way.
+func resolvedSchema(loser schema.Schema, sc SchemaConflict, takeOurs bool) (schema.Schema, error) {
+	cols := loser.GetAllCols().GetColumns()
+	newTags := make(map[uint64]uint64)
+	for _, c := range sc.ColConflicts {
+		winner, lost := c.Theirs, c.Ours
+		if takeOurs {
+			winner, lost = c.Ours, c.Theirs
+		}
+		cols = replaceColumn(cols, lost, winner)
+		if lost.Tag != winner.Tag {
+			newTags[lost.Tag] = winner.Tag
+		}
+	}
+
+	pkOrds := make([]int, 0, len(loser.GetPkOrdinals()))
+	for _, ord := range loser.GetPkOrdinals() {
+		tag := loser.GetAllCols().GetByIndex(ord).Tag
+		if newTag, ok := newTags[tag]; ok {
+			tag = newTag
+		}
+		newOrd := columnIndex(cols, tag)
+		if newOrd == -1 {
+			return nil, nil
+		}
+		pkOrds = append(pkOrds, newOrd)
+	}
+	isPk := make(map[int]bool)
+	for _, ord := range pkOrds {
+		isPk[ord] = true
+	}
+	for i, col := range cols {
+		if col.IsPartOfPK != isPk[i] {
+			return nil, nil
+		}
+	}
+
+	indexes := loser.Indexes().AllIndexes()
+	for _, c := range sc.IdxConflicts {
+		winner, lost := c.Theirs, c.Ours
+		if takeOurs {
+			winner, lost = c.Ours, c.Theirs
+		}
+		indexes = removeIndexes(indexes, func(idx schema.Index) bool {
+			return strings.EqualFold(idx.Name(), lost.Name()) || strings.EqualFold(idx.Name(), winner.Name())
+		})
+		indexes = append(indexes, winner)
+	}
+	// the indexes over columns that were replaced by columns of other tags are kept, over the new columns
+	indexes = removeIndexes(indexes, func(idx schema.Index) bool {
+		for _, tag := range idx.IndexedColumnTags() {
+			if newTag, ok := newTags[tag]; ok {
+				tag = newTag
+			}
+			if columnIndex(cols, tag) == -1 {
+				return true
+			}
+		}
+		return false
+	})
+
+	checks := loser.Checks().AllChecks()
+	for _, c := range sc.ChkConflicts {
+		winner, lost := c.Theirs, c.Ours
+		if takeOurs {
+			winner, lost = c.Ours, c.Theirs
+		}
+		if lost != nil {
+			checks = removeCheck(checks, lost.Name())
+		}
+		if winner != nil {
+			checks = append(removeCheck(checks, winner.Name()), winner)
+		}
+	}
+
+	resolved, err := buildSchema(cols, pkOrds, indexes, newTags, checks)
+	if err != nil {
+		return nil, err
+	}
+	if schema.SchemasAreEqual(resolved, loser) && checksEqual(resolved.Checks().AllChecks(), loser.Checks().AllChecks()) {
+		return nil, nil
+	}
+	return resolved, nil
+}
+
+func checksEqual(checks, others []schema.Check) bool {
+	if len(checks) != len(others) {
+		return false
+	}
+	for _, chk := range checks {
+		if !containsCheck(others, chk) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsCheck(checks []schema.Check, chk schema.Check) bool {
+	for _, other := range checks {
+		if other == chk {
+			return true
+		}
+	}
+	return false
+}
+
+// replaceColumn returns |cols| with the column of the tag of |lost| replaced by |winner|. If |cols| has a column of
+// the tag of |winner| already, that column is replaced instead and the one of |lost| is removed.
+func replaceColumn(cols []schema.Column, lost, winner schema.Column) []schema.Column {
+	lostIdx := columnIndex(cols, lost.Tag)
+	winnerIdx := columnIndex(cols, winner.Tag)
+	replaced := make([]schema.Column, 0, len(cols)+1)
+	for i, col := range cols {
+		switch {
+		case i == winnerIdx:
+			replaced = append(replaced, winner)
+		case i == lostIdx && winnerIdx == -1:
+			replaced = append(replaced, winner)
+		case i == lostIdx:
+		default:
+			replaced = append(replaced, col)
+		}
+	}
+	if lostIdx == -1 && winnerIdx == -1 {
+		replaced = append(replaced, winner)
+	}
+	return replaced
+}
+
+func columnIndex(cols []schema.Column, tag uint64) int {
+	for i, col := range cols {
+		if col.Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeIndexes(indexes []schema.Index, remove func(idx schema.Index) bool) []schema.Index {
+	var kept []schema.Index
+	for _, idx := range indexes {
+		if !remove(idx) {
+			kept = append(kept, idx)
+		}
+	}
+	return kept
+}
+
+func removeCheck(checks []schema.Check, name string) []schema.Check {
+	var kept []schema.Check
+	for _, chk := range checks {
+		if !strings.EqualFold(chk.Name(), name) {
+			kept = append(kept, chk)
+		}
+	}
+	return kept
+}
+
+// dropChecks returns |tm| with the checks named |names| dropped from the schemas of its tables.
+func dropChecks(ctx context.Context, tm TableMerger, names []string) (TableMerger, []conversionViolation, error) {
+	dropped := tm
+	tbls := []**doltdb.Table{&dropped.leftTbl, &dropped.rightTbl, &dropped.ancTbl}
+	schs := []*schema.Schema{&dropped.leftSch, &dropped.rightSch, &dropped.ancSch}
+	for i, tbl := range tbls {
+		sch := *schs[i]
+		checks := sch.Checks().AllChecks()
+		for _, name := range names {
+			checks = removeCheck(checks, name)
+		}
+		if len(checks) == sch.Checks().Count() {
+			continue
+		}
+
+		newSch, err := buildSchema(sch.GetAllCols().GetColumns(), sch.GetPkOrdinals(), sch.Indexes().AllIndexes(), nil, checks)
+		if err != nil {
+			return TableMerger{}, nil, err
+		}
+		*tbl, err = (*tbl).UpdateSchema(ctx, newSch)
+		if err != nil {
+			return TableMerger{}, nil, err
+		}
+		*schs[i] = newSch
+	}
+	return dropped, nil, nil
+}
@@ -0,0 +1,960 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb/durable"
+	"github.com/dolthub/dolt/go/libraries/doltcore/row"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema/typeinfo"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/index"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor/creation"
+	"github.com/dolthub/dolt/go/store/prolly"
+	"github.com/dolthub/dolt/go/store/prolly/shim"
+	"github.com/dolthub/dolt/go/store/types"
+	"github.com/dolthub/dolt/go/store/val"
+)
+
+// primaryKeyIndexName is the index name reported for rows that collide on their primary key once converted.
+const primaryKeyIndexName = "PRIMARY"
+
+var errConversionCollision = errors.New("converted row collides with another row")
+
+// conversionViolation is a row of one side of a merge that was left out of its table when the table was converted to
+// the schema changes of the other side, either because some of its values could not be converted or because it
+// collided with another row once converted. Views, triggers and stored procedures whose definitions are no longer
+// valid after a merge are reported the same way.
+type conversionViolation struct {
+	cvType CvType
+	info   interface{}
+	// nomsVals holds the values of the row in the __LD_1__ format, without the values that could not be converted.
+	nomsVals row.TaggedValues
+	// vals holds the values of the row in the __DOLT_1__ format, without the values that could not be converted.
+	vals map[uint64]interface{}
+}
+
+// SchemaConvCVMeta is the violation info of a row whose values could not be converted to the merged schema of its
+// table.
+type SchemaConvCVMeta struct {
+	Columns []string `json:"Columns"`
+	Values  []string `json:"Values"`
+	Types   []string `json:"Types"`
+}
+
+var _ sql.JSONValue = SchemaConvCVMeta{}
+
+func (m SchemaConvCVMeta) Unmarshall(ctx *sql.Context) (val sql.JSONDocument, err error) {
+	return sql.JSONDocument{Val: m}, nil
+}
+
+func (m SchemaConvCVMeta) Compare(ctx *sql.Context, v sql.JSONValue) (cmp int, err error) {
+	ours := sql.JSONDocument{Val: m}
+	return ours.Compare(ctx, v)
+}
+
+func (m SchemaConvCVMeta) ToString(ctx *sql.Context) (string, error) {
+	return m.PrettyPrint(), nil
+}
+
+func (m SchemaConvCVMeta) PrettyPrint() string {
+	jsonStr := fmt.Sprintf(`{`+
+		`"Columns": ["%s"], `+
+		`"Types": ["%s"], `+
+		`"Values": ["%s"]}`,
+		strings.Join(m.Columns, `', '`),
+		strings.Join(m.Types, `', '`),
+		strings.Join(m.Values, `', '`))
+	return jsonStr
+}
+
+// convertSchemaChanges converts the tables of |tm| to the column type and primary key changes that were made on only
+// one side of the merge, so that the schemas of the left, right and ancestor tables agree on them before they are
+// merged. The rows of the left and right tables that were changed since the ancestor and that cannot be converted, or
+// that collide with another row once converted, are left out of the converted tables and returned as violations to
+// add to the merged table. The ones that weren't changed are only left out, as the side that changed the schema must
+// have deleted or updated them.
+func convertSchemaChanges(ctx context.Context, tm TableMerger, opts editor.Options) (TableMerger, []conversionViolation, error) {
+	if tm.leftTbl == nil || tm.rightTbl == nil || tm.ancTbl == nil {
+		return tm, nil, nil
+	}
+
+	leftTarget, rightTarget, ancTarget, err := schemaConversionTargets(tm.leftSch, tm.rightSch, tm.ancSch)
+	if err != nil {
+		return TableMerger{}, nil, err
+	}
+
+	var violations []conversionViolation
+	converted := tm
+	if leftTarget != nil {
+		var vs []conversionViolation
+		converted.leftTbl, vs, err = convertTable(ctx, tm, tm.leftTbl, tm.leftSch, leftTarget, tm.ancTbl, tm.ancSch, opts)
+		if err != nil {
+			return TableMerger{}, nil, err
+		}
+		converted.leftSch = leftTarget
+		violations = append(violations, vs...)
+	}
+	if rightTarget != nil {
+		var vs []conversionViolation
+		converted.rightTbl, vs, err = convertTable(ctx, tm, tm.rightTbl, tm.rightSch, rightTarget, tm.ancTbl, tm.ancSch, opts)
+		if err != nil {
+			return TableMerger{}, nil, err
+		}
+		converted.rightSch = rightTarget
+		violations = append(violations, vs...)
+	}
+	if ancTarget != nil {
+		converted.ancTbl, _, err = convertTable(ctx, tm, tm.ancTbl, tm.ancSch, ancTarget, nil, nil, opts)
+		if err != nil {
+			return TableMerger{}, nil, err
+		}
+		converted.ancSch = ancTarget
+	}
+
+	return converted, violations, nil
+}
+
+// schemaConversionTargets returns the schemas that the left, right and ancestor tables of a merge are converted to
+// before their schemas are merged, or nil for the ones that need no conversion. A column whose tag, type or primary
+// key membership changed on only one side is changed on the other side and on the ancestor, and so is the primary
+// key when it changed on only one side. Changes made on both sides, and changes that cannot be applied to the other
+// side, are left for SchemaMerge to report.
+func schemaConversionTargets(leftSch, rightSch, ancSch schema.Schema) (left, right, anc schema.Schema, err error) {
+	if schema.IsKeyless(leftSch) || schema.IsKeyless(rightSch) || schema.IsKeyless(ancSch) {
+		return nil, nil, nil, nil
+	}
+
+	leftChanges := changedColumns(leftSch, ancSch)
+	rightChanges := changedColumns(rightSch, ancSch)
+
+	leftOnly := make(map[uint64]schema.Column)
+	rightOnly := make(map[uint64]schema.Column)
+	ancChanges := make(map[uint64]schema.Column)
+	for tag, col := range leftChanges {
+		if rightCol, ok := rightChanges[tag]; !ok {
+			leftOnly[tag] = col
+			ancChanges[tag] = col
+		} else if col.Equals(rightCol) {
+			ancChanges[tag] = col
+		}
+	}
+	for tag, col := range rightChanges {
+		if _, ok := leftChanges[tag]; !ok {
+			rightOnly[tag] = col
+			ancChanges[tag] = col
+		}
+	}
+
+	ancPk := ancSch.GetPKCols().GetColumnNames()
+	leftPk := leftSch.GetPKCols().GetColumnNames()
+	rightPk := rightSch.GetPKCols().GetColumnNames()
+	var leftPkChange, rightPkChange, ancPkChange []string
+	switch {
+	case namesEqual(leftPk, ancPk) && !namesEqual(rightPk, ancPk):
+		rightPkChange, ancPkChange = rightPk, rightPk
+	case !namesEqual(leftPk, ancPk) && namesEqual(rightPk, ancPk):
+		leftPkChange, ancPkChange = leftPk, leftPk
+	case !namesEqual(leftPk, ancPk) && namesEqual(leftPk, rightPk):
+		ancPkChange = leftPk
+	}
+
+	if left, err = convertedSchema(leftSch, rightOnly, rightPkChange); err != nil {
+		return nil, nil, nil, err
+	}
+	if right, err = convertedSchema(rightSch, leftOnly, leftPkChange); err != nil {
+		return nil, nil, nil, err
+	}
+	if (rightPkChange != nil && left == nil) || (leftPkChange != nil && right == nil) {
+		// the primary key cannot be changed on the other side
+		return nil, nil, nil, nil
+	}
+	if anc, err = convertedSchema(ancSch, ancChanges, ancPkChange); err != nil {
+		return nil, nil, nil, err
+	}
+	return left, right, anc, nil
+}
+
+// changedColumns returns the columns of |sch| whose tag, type or primary key membership differ from the ones of
+// |ancSch|, keyed by their tag in |ancSch|. A column whose tag changed is found by its name.
+func changedColumns(sch, ancSch schema.Schema) map[uint64]schema.Column {
+	cols := sch.GetAllCols()
+	ancCols := ancSch.GetAllCols()
+	changed := make(map[uint64]schema.Column)
+	_ = ancCols.Iter(func(tag uint64, ancCol schema.Column) (stop bool, err error) {
+		if col, ok := changedColumn(cols, ancCols, ancCol); ok {
+			changed[tag] = col
+		}
+		return false, nil
+	})
+	return changed
+}
+
+// convertedSchema returns |sch| with its columns replaced by the ones of |changes|, keyed by tag, and with the primary
+// key made of the columns named |pkNames|, unless it's nil. It returns nil if |sch| is left unchanged, or if the
+// changes cannot be applied to it.
+func convertedSchema(sch schema.Schema, changes map[uint64]schema.Column, pkNames []string) (schema.Schema, error) {
+	cols := sch.GetAllCols().GetColumns()
+	newCols := make([]schema.Column, len(cols))
+	newTags := make(map[uint64]uint64)
+	for i, col := range cols {
+		newCols[i] = col
+		if changed, ok := changes[col.Tag]; ok {
+			newCols[i] = changed
+			newTags[col.Tag] = changed.Tag
+		}
+	}
+	if len(newTags) == 0 && pkNames == nil {
+		return nil, nil
+	}
+
+	pkOrds := sch.GetPkOrdinals()
+	if pkNames != nil {
+		pkOrds = make([]int, len(pkNames))
+		for i, name := range pkNames {
+			pkOrds[i] = -1
+			for j, col := range newCols {
+				if strings.EqualFold(col.Name, name) {
+					pkOrds[i] = j
+				}
+			}
+			if pkOrds[i] == -1 {
+				return nil, nil
+			}
+		}
+	}
+	isPk := make(map[int]bool)
+	for _, ord := range pkOrds {
+		isPk[ord] = true
+	}
+	for i, col := range newCols {
+		if col.IsPartOfPK != isPk[i] {
+			return nil, nil
+		}
+	}
+
+	return buildSchema(newCols, pkOrds, sch.Indexes().AllIndexes(), newTags, sch.Checks().AllChecks())
+}
+
+// buildSchema returns a new schema made of |cols|, with the primary key columns at |pkOrds|, and with |indexes| and
+// |checks|. The columns of the indexes whose tags are keys of |newTags| are replaced by the columns of the new tags.
+func buildSchema(cols []schema.Column, pkOrds []int, indexes []schema.Index, newTags map[uint64]uint64, checks []schema.Check) (schema.Schema, error) {
+	newSch, err := schema.SchemaFromCols(schema.NewColCollection(cols...))
+	if err != nil {
+		return nil, err
+	}
+	err = newSch.SetPkOrdinals(pkOrds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, idx := range indexes {
+		tags := make([]uint64, len(idx.IndexedColumnTags()))
+		for i, tag := range idx.IndexedColumnTags() {
+			tags[i] = tag
+			if newTag, ok := newTags[tag]; ok {
+				tags[i] = newTag
+			}
+		}
+		_, err = newSch.Indexes().AddIndexByColTags(idx.Name(), tags, schema.IndexProperties{
+			IsUnique:      idx.IsUnique(),
+			IsUserDefined: idx.IsUserDefined(),
+			Comment:       idx.Comment(),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, check := range checks {
+		_, err = newSch.Checks().AddCheck(check.Name(), check.Expression(), check.Enforced())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newSch, nil
+}
+
+func namesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// columnConverter converts the values of column |from| to values of column |to|.
+type columnConverter struct {
+	from, to schema.Column
+	// conv is nil if the type of the column didn't change.
+	conv typeinfo.TypeConverter
+	// added is true if |to| has no matching column, in which case its values are NULL.
+	added bool
+}
+
+// newColumnConverters returns the converters of the columns of |sch| to the columns of |target|. The columns of
+// |target| are matched to the ones of |sch| by tag, and then by name.
+func newColumnConverters(ctx context.Context, sch, target schema.Schema) ([]columnConverter, error) {
+	cols := sch.GetAllCols()
+	targetCols := target.GetAllCols()
+	convs := make([]columnConverter, targetCols.Size())
+	for i := range convs {
+		to := targetCols.GetByIndex(i)
+		from, ok := cols.GetByTag(to.Tag)
+		if !ok {
+			from, ok = cols.GetByNameCaseInsensitive(to.Name)
+		}
+		if !ok {
+			convs[i] = columnConverter{from: to, to: to, added: true}
+			continue
+		}
+		c := columnConverter{from: from, to: to}
+		if !c.from.TypeInfo.Equals(c.to.TypeInfo) {
+			// converters that need no conversion still validate the values
+			conv, _, err := typeinfo.GetTypeConverter(ctx, c.from.TypeInfo, c.to.TypeInfo)
+			if err != nil {
+				return nil, err
+			}
+			c.conv = conv
+		}
+		convs[i] = c
+	}
+	return convs, nil
+}
+
+// convertNoms converts |v|, a value of column |from|, to a value of column |to|. It returns false if |v| cannot be
+// stored in |to|.
+func (c columnConverter) convertNoms(ctx context.Context, vrw types.ValueReadWriter, v types.Value) (types.Value, bool) {
+	if types.IsNull(v) {
+		return types.NullValue, c.added || c.to.IsNullable()
+	}
+	if c.conv == nil {
+		return v, true
+	}
+	converted, err := c.conv(ctx, vrw, v)
+	if err != nil || types.IsNull(converted) {
+		return types.NullValue, false
+	}
+	return converted, true
+}
+
+// convertSql is like convertNoms, for the go values of the __DOLT_1__ format.
+func (c columnConverter) convertSql(ctx context.Context, vrw types.ValueReadWriter, v interface{}) (interface{}, types.Value, bool, error) {
+	if v == nil {
+		return nil, types.NullValue, c.added || c.to.IsNullable(), nil
+	}
+	if c.conv == nil {
+		return v, nil, true, nil
+	}
+	nv, err := c.from.TypeInfo.ConvertValueToNomsValue(ctx, vrw, v)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	converted, ok := c.convertNoms(ctx, vrw, nv)
+	if !ok {
+		return nil, nv, false, nil
+	}
+	out, err := c.to.TypeInfo.ConvertNomsValueToValue(converted)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return out, nil, true, nil
+}
+
+// addFailure records that |v|, a value of the column of |c|, could not be converted.
+func (m *SchemaConvCVMeta) addFailure(c columnConverter, v types.Value) error {
+	str := "NULL"
+	if !types.IsNull(v) {
+		s, err := c.from.TypeInfo.FormatValue(v)
+		if err != nil {
+			return err
+		}
+		if s != nil {
+			str = *s
+		}
+	}
+	m.Columns = append(m.Columns, c.to.Name)
+	m.Values = append(m.Values, str)
+	m.Types = append(m.Types, c.to.TypeInfo.ToSqlType().String())
+	return nil
+}
+
+// checkConvertedKey returns an error if the primary key of a row could not be converted, in which case the row cannot
+// be recorded as a violation either.
+func checkConvertedKey(tblName string, target schema.Schema, meta *SchemaConvCVMeta) error {
+	for i, name := range meta.Columns {
+		if col, ok := target.GetPKCols().GetByName(name); ok {
+			return fmt.Errorf("cannot merge table '%s': value '%s' of primary key column '%s' cannot be converted to %s",
+				tblName, meta.Values[i], col.Name, meta.Types[i])
+		}
+	}
+	return nil
+}
+
+func primaryKeyCollisionMeta(target schema.Schema) UniqCVMeta {
+	return UniqCVMeta{
+		Columns: target.GetPKCols().GetColumnNames(),
+		Name:    primaryKeyIndexName,
+	}
+}
+
+// convertTable returns |tbl|, whose schema is |sch|, converted to |target|. If |ancTbl| is not nil, the rows of |tbl|
+// that were changed since |ancTbl| and that cannot be converted or collide once converted are returned as violations.
+// The other rows that cannot be converted or collide are dropped.
+func convertTable(ctx context.Context, tm TableMerger, tbl *doltdb.Table, sch, target schema.Schema, ancTbl *doltdb.Table, ancSch schema.Schema, opts editor.Options) (*doltdb.Table, []conversionViolation, error) {
+	if has, err := tbl.HasConflicts(ctx); err != nil {
+		return nil, nil, err
+	} else if has {
+		return nil, nil, fmt.Errorf("cannot merge the schema changes of table '%s' into a table with conflicts", tm.name)
+	}
+	if n, err := tbl.NumConstraintViolations(ctx); err != nil {
+		return nil, nil, err
+	} else if n > 0 {
+		return nil, nil, fmt.Errorf("cannot merge the schema changes of table '%s' into a table with constraint violations", tm.name)
+	}
+
+	convs, err := newColumnConverters(ctx, sch, target)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the rows that weren't changed are converted first, so that the changed ones are the ones reported if they collide
+	passes := []bool{false}
+	if ancTbl != nil {
+		passes = append(passes, true)
+	}
+	// rows can only be compared to the ancestor ones if they are keyed alike
+	compare := ancTbl != nil && schema.ArePrimaryKeySetsDiffable(tbl.Format(), sch, ancSch)
+
+	var converted *doltdb.Table
+	var violations []conversionViolation
+	if types.IsFormat_DOLT_1(tbl.Format()) {
+		converted, violations, err = convertProllyTable(ctx, tm, tbl, sch, target, ancTbl, ancSch, convs, passes, compare)
+	} else {
+		converted, violations, err = convertNomsTable(ctx, tm, tbl, sch, target, ancTbl, ancSch, convs, passes, compare, opts)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if schema.HasAutoIncrement(target) {
+		autoInc, err := tbl.GetAutoIncrementValue(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		converted, err = converted.SetAutoIncrementValue(ctx, autoInc)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return converted, violations, nil
+}
+
+func convertNomsTable(ctx context.Context, tm TableMerger, tbl *doltdb.Table, sch, target schema.Schema, ancTbl *doltdb.Table, ancSch schema.Schema, convs []columnConverter, passes []bool, compare bool, opts editor.Options) (*doltdb.Table, []conversionViolation, error) {
+	rows, err := tbl.GetNomsRowData(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ancRows types.Map
+	if compare {
+		ancRows, err = ancTbl.GetNomsRowData(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	empty, err := doltdb.NewEmptyTable(ctx, tm.vrw, tm.ns, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	ed, err := editor.NewTableEditor(ctx, empty, target, tm.name, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	// rows colliding on a unique key are kept, as they are when merging rows
+	dupCb := func(_, _ string, _, _ types.Tuple, isPk bool) error {
+		if isPk {
+			return errConversionCollision
+		}
+		return nil
+	}
+
+	var violations []conversionViolation
+	for _, changedPass := range passes {
+		err = rows.IterAll(ctx, func(k, v types.Value) error {
+			r, err := row.FromNoms(sch, k.(types.Tuple), v.(types.Tuple))
+			if err != nil {
+				return err
+			}
+
+			changed := ancTbl != nil
+			if compare {
+				ancVal, ok, err := ancRows.MaybeGet(ctx, k)
+				if err != nil {
+					return err
+				}
+				if ok {
+					ancRow, err := row.FromNoms(ancSch, k.(types.Tuple), ancVal.(types.Tuple))
+					if err != nil {
+						return err
+					}
+					changed = !nomsRowsEqual(r, ancRow, sch, ancSch)
+				}
+			}
+			if changed != changedPass {
+				return nil
+			}
+
+			vals := make(row.TaggedValues)
+			var meta SchemaConvCVMeta
+			for _, c := range convs {
+				fromVal, _ := r.GetColVal(c.from.Tag)
+				toVal, ok := c.convertNoms(ctx, tm.vrw, fromVal)
+				if !ok {
+					if err = meta.addFailure(c, fromVal); err != nil {
+						return err
+					}
+					continue
+				}
+				if !types.IsNull(toVal) {
+					vals[c.to.Tag] = toVal
+				}
+			}
+
+			if len(meta.Columns) > 0 {
+				if changed {
+					if err = checkConvertedKey(tm.name, target, &meta); err != nil {
+						return err
+					}
+					violations = append(violations, conversionViolation{cvType: CvType_SchemaConversion, info: meta, nomsVals: vals})
+				}
+				return nil
+			}
+
+			newRow, err := row.New(tm.vrw.Format(), target, vals)
+			if err != nil {
+				return err
+			}
+			err = ed.InsertRow(ctx, newRow, dupCb)
+			if errors.Is(err, errConversionCollision) {
+				if changed {
+					violations = append(violations, conversionViolation{cvType: CvType_UniqueIndex, info: primaryKeyCollisionMeta(target), nomsVals: vals})
+				}
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	converted, err := ed.Table(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, violations, nil
+}
+
+// nomsRowsEqual returns whether |r| and |ancRow| have the same values in the columns common to |sch| and |ancSch|. A
+// column whose tag changed is found by its name, and its values are compared in the type of the ancestor column.
+func nomsRowsEqual(r, ancRow row.Row, sch, ancSch schema.Schema) bool {
+	equal := true
+	_ = ancSch.GetAllCols().Iter(func(tag uint64, ancCol schema.Column) (stop bool, err error) {
+		if _, ok := sch.GetAllCols().GetByTag(tag); !ok {
+			col, ok := changedColumn(sch.GetAllCols(), ancSch.GetAllCols(), ancCol)
+			if !ok {
+				return false, nil
+			}
+			v, _ := r.GetColVal(col.Tag)
+			ancV, _ := ancRow.GetColVal(tag)
+			sqlV, err := col.TypeInfo.ConvertNomsValueToValue(v)
+			if err != nil {
+				return true, err
+			}
+			ancSqlV, err := ancCol.TypeInfo.ConvertNomsValueToValue(ancV)
+			if err != nil {
+				return true, err
+			}
+			equal = sqlValuesEqualInType(sqlV, ancSqlV, ancCol)
+			return !equal, nil
+		}
+		v, _ := r.GetColVal(tag)
+		ancV, _ := ancRow.GetColVal(tag)
+		if types.IsNull(v) || types.IsNull(ancV) {
+			equal = types.IsNull(v) && types.IsNull(ancV)
+		} else {
+			equal = v.Equals(ancV)
+		}
+		return !equal, nil
+	})
+	return equal
+}
+
+func convertProllyTable(ctx context.Context, tm TableMerger, tbl *doltdb.Table, sch, target schema.Schema, ancTbl *doltdb.Table, ancSch schema.Schema, convs []columnConverter, passes []bool, compare bool) (*doltdb.Table, []conversionViolation, error) {
+	idx, err := tbl.GetRowData(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := durable.ProllyMapFromIndex(idx)
+	kd, vd := rows.Descriptors()
+
+	var ancRows prolly.Map
+	var ancKd, ancVd val.TupleDesc
+	if compare {
+		ancIdx, err := ancTbl.GetRowData(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		ancRows = durable.ProllyMapFromIndex(ancIdx)
+		ancKd, ancVd = ancRows.Descriptors()
+	}
+
+	empty, err := durable.NewEmptyIndex(ctx, tm.vrw, tm.ns, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	mut := durable.ProllyMapFromIndex(empty).Mutate()
+	targetKd, targetVd := shim.MapDescriptorsFromSchema(target)
+	kb := val.NewTupleBuilder(targetKd)
+	vb := val.NewTupleBuilder(targetVd)
+	p := rows.Pool()
+
+	var violations []conversionViolation
+	for _, changedPass := range passes {
+		iter, err := rows.IterAll(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		for {
+			k, v, err := iter.Next(ctx)
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, nil, err
+			}
+
+			fromVals, err := prollyTaggedValues(ctx, tm, sch, kd, vd, k, v)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			changed := ancTbl != nil
+			if compare {
+				var ancVals map[uint64]interface{}
+				err = ancRows.Get(ctx, k, func(ancKey, ancVal val.Tuple) (err error) {
+					if ancKey != nil {
+						ancVals, err = prollyTaggedValues(ctx, tm, ancSch, ancKd, ancVd, ancKey, ancVal)
+					}
+					return err
+				})
+				if err != nil {
+					return nil, nil, err
+				}
+				if ancVals != nil {
+					changed = !sqlRowsEqual(fromVals, ancVals, sch, ancSch)
+				}
+			}
+			if changed != changedPass {
+				continue
+			}
+
+			vals := make(map[uint64]interface{})
+			var meta SchemaConvCVMeta
+			for _, c := range convs {
+				toVal, failedVal, ok, err := c.convertSql(ctx, tm.vrw, fromVals[c.from.Tag])
+				if err != nil {
+					return nil, nil, err
+				}
+				if !ok {
+					if err = meta.addFailure(c, failedVal); err != nil {
+						return nil, nil, err
+					}
+					continue
+				}
+				vals[c.to.Tag] = toVal
+			}
+
+			if len(meta.Columns) > 0 {
+				if changed {
+					if err = checkConvertedKey(tm.name, target, &meta); err != nil {
+						return nil, nil, err
+					}
+					violations = append(violations, conversionViolation{cvType: CvType_SchemaConversion, info: meta, vals: vals})
+				}
+				continue
+			}
+
+			for i, col := range target.GetPKCols().GetColumns() {
+				if err = index.PutField(ctx, tm.ns, kb, i, vals[col.Tag]); err != nil {
+					return nil, nil, err
+				}
+			}
+			key := kb.Build(p)
+
+			if ok, err := mut.Has(ctx, key); err != nil {
+				return nil, nil, err
+			} else if ok {
+				if changed {
+					violations = append(violations, conversionViolation{cvType: CvType_UniqueIndex, info: primaryKeyCollisionMeta(target), vals: vals})
+				}
+				continue
+			}
+
+			for i, col := range target.GetNonPKCols().GetColumns() {
+				if err = index.PutField(ctx, tm.ns, vb, i, vals[col.Tag]); err != nil {
+					return nil, nil, err
+				}
+			}
+			// the columns that are new to the table hold NULLs, even when they are NOT NULL
+			if err = mut.Put(ctx, key, vb.BuildPermissive(p)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	convertedRows, err := mut.Map(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	converted, err := newProllyTable(ctx, tm, target, convertedRows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return converted, violations, nil
+}
+
+// newProllyTable returns a table of schema |sch| holding |rows|, with its secondary indexes built from them.
+func newProllyTable(ctx context.Context, tm TableMerger, sch schema.Schema, rows prolly.Map) (*doltdb.Table, error) {
+	var err error
+	indexes := durable.NewIndexSet(ctx, tm.vrw, tm.ns)
+	for _, idx := range sch.Indexes().AllIndexes() {
+		var secondary durable.Index
+		if idx.IsUnique() {
+			// rows colliding on a unique key are kept, as they are when merging rows
+			secondary, err = creation.BuildUniqueProllyIndex(ctx, tm.vrw, tm.ns, sch, idx, rows, func(context.Context, val.Tuple, val.Tuple) error {
+				return nil
+			})
+		} else {
+			secondary, err = creation.BuildSecondaryProllyIndex(ctx, tm.vrw, tm.ns, sch, idx, rows)
+		}
+		if err != nil {
+			return nil, err
+		}
+		indexes, err = indexes.PutIndex(ctx, idx.Name(), secondary)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return doltdb.NewTable(ctx, tm.vrw, tm.ns, sch, durable.IndexFromProllyMap(rows), indexes, nil)
+}
+
+// prollyTaggedValues returns the values of the row |k|, |v| of a table whose schema is |sch|, by tag.
+func prollyTaggedValues(ctx context.Context, tm TableMerger, sch schema.Schema, kd, vd val.TupleDesc, k, v val.Tuple) (map[uint64]interface{}, error) {
+	vals := make(map[uint64]interface{})
+	for i, col := range sch.GetPKCols().GetColumns() {
+		fv, err := index.GetField(ctx, kd, i, k, tm.ns)
+		if err != nil {
+			return nil, err
+		}
+		vals[col.Tag] = fv
+	}
+	for i, col := range sch.GetNonPKCols().GetColumns() {
+		fv, err := index.GetField(ctx, vd, i, v, tm.ns)
+		if err != nil {
+			return nil, err
+		}
+		vals[col.Tag] = fv
+	}
+	return vals, nil
+}
+
+// sqlRowsEqual is like nomsRowsEqual, for the go values of the __DOLT_1__ format.
+func sqlRowsEqual(vals, ancVals map[uint64]interface{}, sch, ancSch schema.Schema) bool {
+	equal := true
+	_ = ancSch.GetAllCols().Iter(func(tag uint64, ancCol schema.Column) (stop bool, err error) {
+		col, ok := sch.GetAllCols().GetByTag(tag)
+		if !ok {
+			col, ok = changedColumn(sch.GetAllCols(), ancSch.GetAllCols(), ancCol)
+			if ok {
+				equal = sqlValuesEqualInType(vals[col.Tag], ancVals[tag], ancCol)
+			}
+			return !equal, nil
+		}
+		v, ancV := vals[tag], ancVals[tag]
+		if v == nil || ancV == nil {
+			equal = v == nil && ancV == nil
+		} else {
+			cmp, err := col.TypeInfo.ToSqlType().Compare(v, ancV)
+			equal = err == nil && cmp == 0
+		}
+		return !equal, nil
+	})
+	return equal
+}
+
+// sqlValuesEqualInType returns whether |v| is equal to |ancV| once converted to the type of |ancCol|.
+func sqlValuesEqualInType(v, ancV interface{}, ancCol schema.Column) bool {
+	if v == nil || ancV == nil {
+		return v == nil && ancV == nil
+	}
+	typ := ancCol.TypeInfo.ToSqlType()
+	converted, err := typ.Convert(v)
+	if err != nil {
+		return false
+	}
+	cmp, err := typ.Compare(converted, ancV)
+	return err == nil && cmp == 0
+}
+
+// addConversionViolations adds |violations|, returned by convertSchemaChanges, to the constraint violations of
+// |mergeTbl|.
+func addConversionViolations(ctx context.Context, tm TableMerger, mergeTbl *doltdb.Table, violations []conversionViolation) (*doltdb.Table, error) {
+	if len(violations) == 0 {
+		return mergeTbl, nil
+	}
+
+	sch, err := mergeTbl.GetSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if types.IsFormat_DOLT_1(mergeTbl.Format()) {
+		return addProllyConversionViolations(ctx, tm, mergeTbl, sch, violations)
+	}
+
+	cvMap, err := mergeTbl.GetConstraintViolations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cvEd := cvMap.Edit()
+	for _, cv := range violations {
+		vals := make(row.TaggedValues)
+		for tag, v := range cv.nomsVals {
+			if _, ok := sch.GetAllCols().GetByTag(tag); ok {
+				vals[tag] = v
+			}
+		}
+		r, err := row.New(mergeTbl.Format(), sch, vals)
+		if err != nil {
+			return nil, err
+		}
+		k, err := r.NomsMapKey(sch).Value(ctx)
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.NomsMapValue(sch).Value(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		d, err := json.Marshal(cv.info)
+		if err != nil {
+			return nil, err
+		}
+		vInfo, err := jsonDataToNomsValue(ctx, tm.vrw, d)
+		if err != nil {
+			return nil, err
+		}
+		cvKey, cvVal, err := toConstraintViolationRow(ctx, cv.cvType, vInfo, k.(types.Tuple), v.(types.Tuple))
+		if err != nil {
+			return nil, err
+		}
+		cvEd.Set(cvKey, cvVal)
+	}
+	cvMap, err = cvEd.Map(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mergeTbl.SetConstraintViolations(ctx, cvMap)
+}
+
+func addProllyConversionViolations(ctx context.Context, tm TableMerger, mergeTbl *doltdb.Table, sch schema.Schema, violations []conversionViolation) (*doltdb.Table, error) {
+	arts, err := mergeTbl.GetArtifacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	artM := durable.ProllyMapFromArtifactIndex(arts)
+	artEditor := artM.Editor()
+
+	kd, vd := shim.MapDescriptorsFromSchema(sch)
+	kb := val.NewTupleBuilder(kd)
+	vb := val.NewTupleBuilder(vd)
+	p := artM.Pool()
+
+	theirsHash, err := tm.rightSrc.HashOf()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cv := range violations {
+		for i, col := range sch.GetPKCols().GetColumns() {
+			if err = index.PutField(ctx, tm.ns, kb, i, cv.vals[col.Tag]); err != nil {
+				return nil, err
+			}
+		}
+		key := kb.Build(p)
+		for i, col := range sch.GetNonPKCols().GetColumns() {
+			if err = index.PutField(ctx, tm.ns, vb, i, cv.vals[col.Tag]); err != nil {
+				return nil, err
+			}
+		}
+		// the values that could not be converted are NULL, even in NOT NULL columns
+		value := vb.BuildPermissive(p)
+
+		vInfo, err := json.Marshal(cv.info)
+		if err != nil {
+			return nil, err
+		}
+		artType := prolly.ArtifactTypeUniqueKeyViol
+		switch cv.cvType {
+		case CvType_SchemaConversion:
+			artType = prolly.ArtifactTypeSchemaConvViol
+		case CvType_InvalidDefinition:
+			artType = prolly.ArtifactTypeDefinitionViol
+		}
+		meta := prolly.ConstraintViolationMeta{VInfo: vInfo, Value: value}
+		err = artEditor.ReplaceConstraintViolation(ctx, key, theirsHash, artType, meta)
+		if mv, ok := err.(*prolly.ErrMergeArtifactCollision); ok {
+			return nil, fmt.Errorf("%w: pk %s of table '%s'", ErrMultipleViolationsForRow, kd.Format(mv.Key), tm.name)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	artM, err = artEditor.Flush(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return mergeTbl.SetArtifacts(ctx, durable.ArtifactIndexFromProllyMap(artM))
+}
@@ -0,0 +1,118 @@
+// Copyright 2019 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+func TestSchemaConversionTargets(t *testing.T) {
+	pk := schema.NewColumn("pk", 0, types.IntKind, true)
+	c1 := schema.NewColumn("c1", 1, types.IntKind, false)
+	c1Str := schema.NewColumn("c1", 2, types.StringKind, false)
+	c1Uint := schema.NewColumn("c1", 3, types.UintKind, false)
+	c1Pk := schema.NewColumn("c1", 1, types.IntKind, true)
+	c2 := schema.NewColumn("c2", 4, types.StringKind, false)
+
+	newSch := func(cols ...schema.Column) schema.Schema {
+		sch := schema.MustSchemaFromCols(schema.NewColCollection(cols...))
+		ords := make([]int, 0, len(cols))
+		for i, col := range cols {
+			if col.IsPartOfPK {
+				ords = append(ords, i)
+			}
+		}
+		require.NoError(t, sch.SetPkOrdinals(ords))
+		return sch
+	}
+	anc := newSch(pk, c1, c2)
+
+	tests := []struct {
+		name                      string
+		left, right               schema.Schema
+		expLeft, expRight, expAnc schema.Schema
+	}{
+		{
+			name:    "type changed on the right",
+			left:    newSch(pk, c1, c2),
+			right:   newSch(pk, c1Str, c2),
+			expLeft: newSch(pk, c1Str, c2),
+			expAnc:  newSch(pk, c1Str, c2),
+		},
+		{
+			name:     "type changed on the left",
+			left:     newSch(pk, c1Str, c2),
+			right:    newSch(pk, c1, c2),
+			expRight: newSch(pk, c1Str, c2),
+			expAnc:   newSch(pk, c1Str, c2),
+		},
+		{
+			name:   "same type change on both sides",
+			left:   newSch(pk, c1Str, c2),
+			right:  newSch(pk, c1Str, c2),
+			expAnc: newSch(pk, c1Str, c2),
+		},
+		{
+			name:  "different type changes on both sides",
+			left:  newSch(pk, c1Str, c2),
+			right: newSch(pk, c1Uint, c2),
+		},
+		{
+			name:    "primary key changed on the right",
+			left:    newSch(pk, c1, c2),
+			right:   newSch(pk, c1Pk, c2),
+			expLeft: newSch(pk, c1Pk, c2),
+			expAnc:  newSch(pk, c1Pk, c2),
+		},
+		{
+			name:    "primary key dropped on the right",
+			left:    newSch(pk, c1, c2),
+			right:   newSch(schema.NewColumn("pk", 0, types.IntKind, false), c1Pk, c2),
+			expLeft: newSch(schema.NewColumn("pk", 0, types.IntKind, false), c1Pk, c2),
+			expAnc:  newSch(schema.NewColumn("pk", 0, types.IntKind, false), c1Pk, c2),
+		},
+		{
+			name:  "keyless table",
+			left:  schema.MustSchemaFromCols(schema.NewColCollection(schema.NewColumn("c1", 1, types.IntKind, false))),
+			right: schema.MustSchemaFromCols(schema.NewColCollection(schema.NewColumn("c1", 2, types.StringKind, false))),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			left, right, ancTarget, err := schemaConversionTargets(test.left, test.right, anc)
+			require.NoError(t, err)
+			assertSchemaEqual(t, test.expLeft, left)
+			assertSchemaEqual(t, test.expRight, right)
+			assertSchemaEqual(t, test.expAnc, ancTarget)
+		})
+	}
+}
+
+func assertSchemaEqual(t *testing.T, expected, actual schema.Schema) {
+	if expected == nil {
+		assert.Nil(t, actual)
+		return
+	}
+	require.NotNil(t, actual)
+	assert.True(t, schema.SchemasAreEqual(expected, actual))
+	assert.Equal(t, expected.GetPkOrdinals(), actual.GetPkOrdinals())
+}
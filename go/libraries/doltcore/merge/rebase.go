@@ -0,0 +1,376 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/store/datas"
+)
+
+var ErrRebaseStoppedOnConflicts = errors.New("rebase stopped on conflicts; resolve them and use --continue to resume the rebase, or use --abort to cancel it")
+var ErrRebaseUnresolvedConflicts = errors.New("cannot continue the rebase while there are unresolved conflicts or constraint violations")
+var ErrRebaseUncommittedChanges = errors.New("cannot rebase: you have uncommitted changes; commit or reset them to proceed")
+
+// StartRebase records the start of a rebase of the branch |branch|, whose head is |head|, onto |upstream| in the
+// working set given. The plan of the rebase picks each commit on |head| that is not on |upstream|. No steps of the plan
+// are executed until ContinueRebase is called. An |interactive| rebase is started even if |head| is already based on
+// |upstream|, so that its commits can be reworded, squashed or dropped.
+func StartRebase(ctx context.Context, ddb *doltdb.DoltDB, ws *doltdb.WorkingSet, head, upstream *doltdb.Commit, branch string, interactive bool) (*doltdb.WorkingSet, error) {
+	if ws.MergeActive() {
+		return nil, doltdb.ErrMergeActive
+	}
+	if ws.RebaseActive() {
+		return nil, doltdb.ErrRebaseActive
+	}
+
+	headRoot, err := head.GetRootValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headHash, err := headRoot.HashOf()
+	if err != nil {
+		return nil, err
+	}
+	for _, root := range []*doltdb.RootValue{ws.WorkingRoot(), ws.StagedRoot()} {
+		h, err := root.HashOf()
+		if err != nil {
+			return nil, err
+		}
+		if h != headHash {
+			return nil, ErrRebaseUncommittedChanges
+		}
+	}
+
+	mergeBase, err := doltdb.GetCommitAncestor(ctx, head, upstream)
+	if err != nil {
+		return nil, err
+	}
+	mergeBaseHash, err := mergeBase.HashOf()
+	if err != nil {
+		return nil, err
+	}
+	upstreamHash, err := upstream.HashOf()
+	if err != nil {
+		return nil, err
+	}
+	if mergeBaseHash == upstreamHash && !interactive {
+		return nil, doltdb.ErrUpToDate
+	}
+
+	plan, err := BuildRebasePlan(ctx, ddb, head, upstream)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan) == 0 {
+		return nil, doltdb.ErrUpToDate
+	}
+
+	return ws.StartRebase(upstream, branch, plan), nil
+}
+
+// BuildRebasePlan returns a plan that picks each of the commits on |head| that are not on |upstream|, oldest first.
+// Merge commits cannot be rebased.
+func BuildRebasePlan(ctx context.Context, ddb *doltdb.DoltDB, head, upstream *doltdb.Commit) ([]doltdb.RebasePlanStep, error) {
+	mergeBase, err := doltdb.GetCommitAncestor(ctx, head, upstream)
+	if err != nil {
+		return nil, err
+	}
+	mergeBaseHash, err := mergeBase.HashOf()
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []doltdb.RebasePlanStep
+	for cm := head; ; {
+		h, err := cm.HashOf()
+		if err != nil {
+			return nil, err
+		}
+		if h == mergeBaseHash {
+			break
+		}
+
+		if cm.NumParents() > 1 {
+			return nil, fmt.Errorf("cannot rebase merge commit %s", h.String())
+		} else if cm.NumParents() == 0 {
+			return nil, fmt.Errorf("cannot rebase commit %s without parents", h.String())
+		}
+		meta, err := cm.GetCommitMeta(ctx)
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, doltdb.RebasePlanStep{
+			Action:     doltdb.RebaseActionPick,
+			CommitHash: h.String(),
+			CommitMsg:  meta.Description,
+		})
+
+		cm, err = ddb.ResolveParent(ctx, cm, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the commits were collected newest first
+	for i, j := 0, len(plan)-1; i < j; i, j = i+1, j-1 {
+		plan[i], plan[j] = plan[j], plan[i]
+	}
+
+	return plan, nil
+}
+
+// ValidateRebasePlan returns an error if the steps of |plan| cannot be executed.
+func ValidateRebasePlan(plan []doltdb.RebasePlanStep) error {
+	for i, step := range plan {
+		if _, err := doltdb.ParseRebaseAction(string(step.Action)); err != nil {
+			return err
+		}
+		if step.Action == doltdb.RebaseActionReword && step.CommitMsg == "" {
+			return fmt.Errorf("rebase step %d rewords commit %s with an empty commit message", i+1, step.CommitHash)
+		}
+	}
+
+	for _, step := range plan {
+		if step.Action == doltdb.RebaseActionDrop {
+			continue
+		}
+		if step.Action == doltdb.RebaseActionSquash || step.Action == doltdb.RebaseActionFixup {
+			return fmt.Errorf("cannot %s commit %s without a previous commit", step.Action, step.CommitHash)
+		}
+		break
+	}
+
+	return nil
+}
+
+// ContinueRebase executes the remaining steps of the plan of the rebase in progress in |ws|, replaying each commit on
+// top of the commits replayed so far with the same three-way merge used to cherry-pick commits.
+//
+// If every step is executed, the rebase state is cleared from the returned working set and the last replayed commit,
+// which should become the new head of the rebased branch, is returned. If a step results in conflicts, the returned
+// working set holds the conflicted result of the step along with the updated rebase state, and
+// ErrRebaseStoppedOnConflicts is returned. Once the conflicts are resolved, calling ContinueRebase again commits the
+// working root as the result of that step and resumes the rebase.
+func ContinueRebase(ctx context.Context, ddb *doltdb.DoltDB, ws *doltdb.WorkingSet, opts editor.Options) (*doltdb.WorkingSet, *doltdb.Commit, error) {
+	rs := ws.RebaseState()
+	if rs == nil {
+		return nil, nil, doltdb.ErrNoRebaseInProgress
+	}
+
+	plan := rs.Plan()
+	if !rs.Started() {
+		if err := ValidateRebasePlan(plan); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	head := rs.RebasedHead()
+	i := rs.NextStep()
+
+	if rs.Stopped() {
+		root := ws.WorkingRoot()
+		if has, err := root.HasConflicts(ctx); err != nil {
+			return nil, nil, err
+		} else if has {
+			return nil, nil, ErrRebaseUnresolvedConflicts
+		}
+		if has, err := root.HasConstraintViolations(ctx); err != nil {
+			return nil, nil, err
+		} else if has {
+			return nil, nil, ErrRebaseUnresolvedConflicts
+		}
+
+		cm, err := resolveRebaseStepCommit(ctx, ddb, plan[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		head, err = commitRebaseStep(ctx, ddb, rs.OntoCommit(), head, plan[i], cm, root)
+		if err != nil {
+			return nil, nil, err
+		}
+		i++
+	}
+
+	for ; i < len(plan); i++ {
+		step := plan[i]
+		if step.Action == doltdb.RebaseActionDrop {
+			continue
+		}
+
+		cm, err := resolveRebaseStepCommit(ctx, ddb, step)
+		if err != nil {
+			return nil, nil, err
+		}
+		parent, err := ddb.ResolveParent(ctx, cm, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		headRoot, err := head.GetRootValue(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmRoot, err := cm.GetRootValue(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		parentRoot, err := parent.GetRootValue(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		mergedRoot, mergeStats, err := MergeRoots(ctx, headRoot, cmRoot, parentRoot, cm, parent, opts, MergeOpts{IsCherryPick: true})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, stats := range mergeStats {
+			if stats.Conflicts > 0 || stats.ConstraintViolations > 0 {
+				rs = rs.WithRebasedHead(head).WithNextStep(i, true)
+				ws = ws.WithWorkingRoot(mergedRoot).WithStagedRoot(headRoot).WithRebaseState(rs)
+				return ws, nil, ErrRebaseStoppedOnConflicts
+			}
+		}
+
+		head, err = commitRebaseStep(ctx, ddb, rs.OntoCommit(), head, step, cm, mergedRoot)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	root, err := head.GetRootValue(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ws = ws.WithWorkingRoot(root).WithStagedRoot(root).ClearRebase()
+
+	return ws, head, nil
+}
+
+func resolveRebaseStepCommit(ctx context.Context, ddb *doltdb.DoltDB, step doltdb.RebasePlanStep) (*doltdb.Commit, error) {
+	cs, err := doltdb.NewCommitSpec(step.CommitHash)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := ddb.Resolve(ctx, cs, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cm.NumParents() != 1 {
+		return nil, fmt.Errorf("cannot rebase commit %s: only commits with a single parent can be rebased", step.CommitHash)
+	}
+	return cm, nil
+}
+
+// commitRebaseStep records |root| as the result of replaying the commit |cm| of |step| on top of |head|, and returns
+// the new rebased head. Picked commits that become empty are dropped. A squash or fixup is replayed as a pick while
+// |head| is still the |onto| commit, which happens when every commit before it was dropped or became empty, so that
+// it never folds into a commit that isn't being rebased.
+func commitRebaseStep(ctx context.Context, ddb *doltdb.DoltDB, onto, head *doltdb.Commit, step doltdb.RebasePlanStep, cm *doltdb.Commit, root *doltdb.RootValue) (*doltdb.Commit, error) {
+	cmMeta, err := cm.GetCommitMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	action := step.Action
+	if action == doltdb.RebaseActionSquash || action == doltdb.RebaseActionFixup {
+		replayed, err := hasReplayedCommits(onto, head)
+		if err != nil {
+			return nil, err
+		}
+		if !replayed {
+			action = doltdb.RebaseActionPick
+		}
+	}
+
+	var parents []*doltdb.Commit
+	var meta *datas.CommitMeta
+	switch action {
+	case doltdb.RebaseActionPick, doltdb.RebaseActionReword:
+		headRoot, err := head.GetRootValue(ctx)
+		if err != nil {
+			return nil, err
+		}
+		headHash, err := headRoot.HashOf()
+		if err != nil {
+			return nil, err
+		}
+		rootHash, err := root.HashOf()
+		if err != nil {
+			return nil, err
+		}
+		if headHash == rootHash {
+			return head, nil
+		}
+
+		desc := cmMeta.Description
+		if action == doltdb.RebaseActionReword {
+			desc = step.CommitMsg
+		}
+		parents = []*doltdb.Commit{head}
+		meta, err = datas.NewCommitMetaWithUserTS(cmMeta.Name, cmMeta.Email, desc, cmMeta.Time())
+		if err != nil {
+			return nil, err
+		}
+	case doltdb.RebaseActionSquash, doltdb.RebaseActionFixup:
+		headMeta, err := head.GetCommitMeta(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < head.NumParents(); i++ {
+			parent, err := head.GetParent(ctx, i)
+			if err != nil {
+				return nil, err
+			}
+			parents = append(parents, parent)
+		}
+
+		desc := headMeta.Description
+		if action == doltdb.RebaseActionSquash {
+			desc = headMeta.Description + "\n\n" + cmMeta.Description
+		}
+		meta, err = datas.NewCommitMetaWithUserTS(headMeta.Name, headMeta.Email, desc, headMeta.Time())
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid rebase action '%s'", action)
+	}
+
+	_, valHash, err := ddb.WriteRootValue(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	return ddb.CommitDanglingWithParentCommits(ctx, valHash, parents, meta)
+}
+
+// hasReplayedCommits returns whether any commit was replayed on top of |onto| to make the rebased head |head|.
+func hasReplayedCommits(onto, head *doltdb.Commit) (bool, error) {
+	ontoHash, err := onto.HashOf()
+	if err != nil {
+		return false, err
+	}
+	headHash, err := head.HashOf()
+	if err != nil {
+		return false, err
+	}
+	return ontoHash != headHash, nil
+}
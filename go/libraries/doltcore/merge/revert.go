@@ -36,7 +36,7 @@ func Revert(ctx context.Context, ddb *doltdb.DoltDB, root *doltdb.RootValue, hea
 	revertMessage := "Revert"
 
 	for _, cm := range commits {
-		if len(cm.DatasParents()) == 0 {
+		if cm.NumParents() == 0 {
 			h, err := cm.HashOf()
 			if err != nil {
 				return nil, "", err
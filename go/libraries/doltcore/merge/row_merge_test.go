@@ -114,6 +114,16 @@ var testCases = []testCase{
 		false,
 		true,
 	},
+	{
+		"insert rows where only one holds a NULL cell",
+		build(1, 0),
+		build(1, 2),
+		nil,
+		2, 2, 2,
+		nil,
+		false,
+		true,
+	},
 	{
 		"delete a row in one, and modify it in other",
 		nil,
@@ -134,6 +144,16 @@ var testCases = []testCase{
 		true,
 		false,
 	},
+	{
+		"modify disjoint columns of a wide row",
+		build(2, 1, 1, 1, 3),
+		build(1, 1, 2, 1, 1),
+		build(1, 1, 1, 1, 1),
+		5, 5, 5,
+		build(2, 1, 2, 1, 3),
+		true,
+		false,
+	},
 	{
 		"modify rows with equal overlapping changes",
 		build(2, 2, 255),
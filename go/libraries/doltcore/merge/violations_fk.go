@@ -55,6 +55,8 @@ const (
 	CvType_ForeignKey CvType = iota + 1
 	CvType_UniqueIndex
 	CvType_CheckConstraint
+	CvType_SchemaConversion
+	CvType_InvalidDefinition
 )
 
 // AddForeignKeyViolations adds foreign key constraint violations to each table.
@@ -0,0 +1,496 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package merge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+// DefinitionCVMeta is the violation info of a view, trigger or stored procedure whose definition is no longer valid
+// once merged.
+type DefinitionCVMeta struct {
+	Name   string `json:"Name"`
+	Reason string `json:"Reason"`
+}
+
+var _ sql.JSONValue = DefinitionCVMeta{}
+
+func (m DefinitionCVMeta) Unmarshall(ctx *sql.Context) (val sql.JSONDocument, err error) {
+	return sql.JSONDocument{Val: m}, nil
+}
+
+func (m DefinitionCVMeta) Compare(ctx *sql.Context, v sql.JSONValue) (cmp int, err error) {
+	ours := sql.JSONDocument{Val: m}
+	return ours.Compare(ctx, v)
+}
+
+func (m DefinitionCVMeta) ToString(ctx *sql.Context) (string, error) {
+	return m.PrettyPrint(), nil
+}
+
+func (m DefinitionCVMeta) PrettyPrint() string {
+	jsonStr := fmt.Sprintf(`{`+
+		`"Name": %q, `+
+		`"Reason": %q}`,
+		m.Name,
+		m.Reason)
+	return jsonStr
+}
+
+// addDefinitionViolations validates the definitions of the views, triggers and stored procedures of the merged root
+// |merged| and adds a constraint violation for each of them that does not parse, or that refers to a table or column
+// that doesn't exist in |merged|. Definitions that were already invalid in |ours| are left alone.
+func addDefinitionViolations(ctx context.Context, merged, ours *doltdb.RootValue, theirs doltdb.Rootish) (*doltdb.RootValue, error) {
+	views, err := viewNames(ctx, merged)
+	if err != nil {
+		return nil, err
+	}
+	ourViews, err := viewNames(ctx, ours)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tblName := range []string{doltdb.SchemasTableName, doltdb.ProceduresTableName} {
+		ft := fragmentTables[tblName]
+		tm := TableMerger{name: tblName, rightSrc: theirs, vrw: merged.VRW(), ns: merged.NodeStore()}
+
+		tbl, rows, ok, err := readFragmentTable(ctx, tm, merged, ft)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		_, ourRows, _, err := readFragmentTable(ctx, tm, ours, ft)
+		if err != nil {
+			return nil, err
+		}
+		alreadyInvalid := make(map[string]bool)
+		for _, vals := range ourRows {
+			reason, err := definitionProblem(ctx, ours, ourViews, ft, vals)
+			if err != nil {
+				return nil, err
+			}
+			alreadyInvalid[ft.objectKey(vals)] = reason != ""
+		}
+
+		sch, err := tbl.GetSchema(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var violations []conversionViolation
+		for _, vals := range rows {
+			reason, err := definitionProblem(ctx, merged, views, ft, vals)
+			if err != nil {
+				return nil, err
+			}
+			if reason == "" || alreadyInvalid[ft.objectKey(vals)] {
+				continue
+			}
+			cv := conversionViolation{
+				cvType: CvType_InvalidDefinition,
+				info:   DefinitionCVMeta{Name: ft.objectName(vals), Reason: reason},
+				vals:   vals,
+			}
+			if !types.IsFormat_DOLT_1(merged.VRW().Format()) {
+				if cv.nomsVals, err = nomsTaggedValues(ctx, tm, sch, vals); err != nil {
+					return nil, err
+				}
+			}
+			violations = append(violations, cv)
+		}
+		if len(violations) == 0 {
+			continue
+		}
+
+		tbl, err = addConversionViolations(ctx, tm, tbl, violations)
+		if err != nil {
+			return nil, err
+		}
+		merged, err = merged.PutTable(ctx, tblName, tbl)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// readFragmentTable returns the fragment table |ft| of |root| and its rows, or false if |root| has no such table.
+func readFragmentTable(ctx context.Context, tm TableMerger, root *doltdb.RootValue, ft fragmentTable) (*doltdb.Table, []map[uint64]interface{}, bool, error) {
+	tbl, ok, err := root.GetTable(ctx, tm.name)
+	if err != nil || !ok {
+		return nil, nil, false, err
+	}
+	sch, err := tbl.GetSchema(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !ft.fits(sch) {
+		return nil, nil, false, nil
+	}
+	rows, err := readFragmentRows(ctx, tm, tbl, sch)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return tbl, rows, true, nil
+}
+
+// viewNames returns the lowercased names of the views of |root|.
+func viewNames(ctx context.Context, root *doltdb.RootValue) (map[string]bool, error) {
+	ft := fragmentTables[doltdb.SchemasTableName]
+	tm := TableMerger{name: doltdb.SchemasTableName, vrw: root.VRW(), ns: root.NodeStore()}
+	_, rows, _, err := readFragmentTable(ctx, tm, root, ft)
+	if err != nil {
+		return nil, err
+	}
+	views := make(map[string]bool)
+	for _, vals := range rows {
+		if typ, _ := vals[ft.objectTags[0]].(string); strings.EqualFold(typ, "view") {
+			views[strings.ToLower(fmt.Sprint(vals[ft.objectTags[1]]))] = true
+		}
+	}
+	return views, nil
+}
+
+// definitionProblem returns why the definition of the object defined by |vals| is not valid in |root|, or an empty
+// string if it is. Views are checked for the tables they select from and the columns they reference, and triggers for
+// the table they are defined on and the columns their bodies reference. The bodies of stored procedures are only
+// resolved when they run, and are only checked to parse.
+func definitionProblem(ctx context.Context, root *doltdb.RootValue, views map[string]bool, ft fragmentTable, vals map[uint64]interface{}) (string, error) {
+	def, ok := vals[ft.defTag].(string)
+	if !ok {
+		return "definition is missing", nil
+	}
+	stmt, err := sqlparser.Parse(def)
+	if err != nil {
+		return fmt.Sprintf("definition does not parse: %s", err.Error()), nil
+	}
+
+	var tables []string
+	switch s := stmt.(type) {
+	case *sqlparser.DDL:
+		if s.TriggerSpec != nil && s.Table.Qualifier.IsEmpty() {
+			tables = append(tables, s.Table.Name.String())
+		}
+	case sqlparser.SelectStatement:
+		tables = selectedTables(s)
+	}
+
+	for _, name := range tables {
+		if views[strings.ToLower(name)] || doltdb.HasDoltPrefix(name) {
+			continue
+		}
+		_, ok, err := root.ResolveTableName(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return fmt.Sprintf("table '%s' does not exist", name), nil
+		}
+	}
+
+	ca := columnAnalyzer{ctx: ctx, root: root, views: views}
+	switch s := stmt.(type) {
+	case *sqlparser.DDL:
+		if s.TriggerSpec != nil && s.Table.Qualifier.IsEmpty() {
+			err = ca.analyzeTrigger(s)
+		}
+	case sqlparser.SelectStatement:
+		err = ca.analyzeSelect(s, nil, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return ca.problem, nil
+}
+
+// columnScope holds the tables a statement can reference columns of, by their lowercased names or aliases. A table
+// whose columns are unknown, such as a view or a derived table, has a nil schema.
+type columnScope struct {
+	sources map[string]schema.Schema
+	// aliases are the lowercased aliases of the select expressions of the statement, which its ORDER BY, GROUP BY
+	// and HAVING clauses can reference.
+	aliases map[string]bool
+	// unknown is set if the statement can reference columns other than the ones of |sources|, such as the variables
+	// of a trigger body.
+	unknown bool
+	outer   *columnScope
+}
+
+func newColumnScope(outer *columnScope) *columnScope {
+	return &columnScope{sources: make(map[string]schema.Schema), aliases: make(map[string]bool), outer: outer}
+}
+
+// resolve returns why |col| cannot be referenced in this scope, or an empty string if it can or might be. Only the
+// columns of tables whose schema is known are reported.
+func (s *columnScope) resolve(col *sqlparser.ColName) string {
+	name := col.Name.String()
+	if !col.Qualifier.IsEmpty() {
+		if !col.Qualifier.Qualifier.IsEmpty() {
+			return ""
+		}
+		qualifier := col.Qualifier.Name.String()
+		for sc := s; sc != nil; sc = sc.outer {
+			sch, ok := sc.sources[strings.ToLower(qualifier)]
+			if !ok {
+				continue
+			}
+			if sch == nil {
+				return ""
+			}
+			if _, ok := sch.GetAllCols().GetByNameCaseInsensitive(name); ok {
+				return ""
+			}
+			return fmt.Sprintf("column '%s.%s' does not exist", qualifier, name)
+		}
+		return ""
+	}
+
+	for sc := s; sc != nil; sc = sc.outer {
+		if sc.unknown || sc.aliases[strings.ToLower(name)] {
+			return ""
+		}
+		for _, sch := range sc.sources {
+			if sch == nil {
+				return ""
+			}
+			if _, ok := sch.GetAllCols().GetByNameCaseInsensitive(name); ok {
+				return ""
+			}
+		}
+	}
+	return fmt.Sprintf("column '%s' does not exist", name)
+}
+
+// columnAnalyzer finds the first column referenced by a view or trigger definition that doesn't exist in |root|.
+type columnAnalyzer struct {
+	ctx     context.Context
+	root    *doltdb.RootValue
+	views   map[string]bool
+	problem string
+}
+
+// tableSchema returns the schema of the unqualified table |tn|, or nil if its columns are unknown, because it's a
+// view, a common table expression, a system table or doesn't exist.
+func (ca *columnAnalyzer) tableSchema(tn sqlparser.TableName, ctes map[string]bool) (schema.Schema, error) {
+	name := tn.Name.String()
+	if !tn.Qualifier.IsEmpty() || ctes[strings.ToLower(name)] || ca.views[strings.ToLower(name)] || doltdb.HasDoltPrefix(name) {
+		return nil, nil
+	}
+	tbl, _, ok, err := ca.root.GetTableInsensitive(ca.ctx, name)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return tbl.GetSchema(ca.ctx)
+}
+
+// addSources adds the tables of |te| to |scope|. The derived tables of |te| are analyzed on their own.
+func (ca *columnAnalyzer) addSources(scope *columnScope, te sqlparser.TableExpr, ctes map[string]bool) error {
+	switch t := te.(type) {
+	case *sqlparser.AliasedTableExpr:
+		alias := t.As.String()
+		switch e := t.Expr.(type) {
+		case sqlparser.TableName:
+			sch, err := ca.tableSchema(e, ctes)
+			if err != nil {
+				return err
+			}
+			if alias == "" {
+				alias = e.Name.String()
+			}
+			scope.sources[strings.ToLower(alias)] = sch
+		case *sqlparser.Subquery:
+			if err := ca.analyzeSelect(e.Select, nil, ctes); err != nil {
+				return err
+			}
+			scope.sources[strings.ToLower(alias)] = nil
+		default:
+			scope.unknown = true
+		}
+	case *sqlparser.JoinTableExpr:
+		if err := ca.addSources(scope, t.LeftExpr, ctes); err != nil {
+			return err
+		}
+		return ca.addSources(scope, t.RightExpr, ctes)
+	case *sqlparser.ParenTableExpr:
+		for _, e := range t.Exprs {
+			if err := ca.addSources(scope, e, ctes); err != nil {
+				return err
+			}
+		}
+	default:
+		scope.unknown = true
+	}
+	return nil
+}
+
+// analyzeSelect analyzes the columns referenced by |stmt|, which can reference the columns of |outer| as well.
+func (ca *columnAnalyzer) analyzeSelect(stmt sqlparser.SelectStatement, outer *columnScope, ctes map[string]bool) error {
+	switch s := stmt.(type) {
+	case *sqlparser.Union:
+		if err := ca.analyzeSelect(s.Left, outer, ctes); err != nil {
+			return err
+		}
+		return ca.analyzeSelect(s.Right, outer, ctes)
+	case *sqlparser.ParenSelect:
+		return ca.analyzeSelect(s.Select, outer, ctes)
+	case *sqlparser.Select:
+		if s.With != nil {
+			withCtes := make(map[string]bool)
+			for name := range ctes {
+				withCtes[name] = true
+			}
+			for _, expr := range s.With.Ctes {
+				cte, ok := expr.(*sqlparser.CommonTableExpr)
+				if !ok {
+					continue
+				}
+				// a recursive common table expression references itself
+				withCtes[strings.ToLower(cte.As.String())] = true
+				if sq, ok := cte.Expr.(*sqlparser.Subquery); ok {
+					if err := ca.analyzeSelect(sq.Select, outer, withCtes); err != nil {
+						return err
+					}
+				}
+			}
+			ctes = withCtes
+		}
+
+		scope := newColumnScope(outer)
+		for _, te := range s.From {
+			if err := ca.addSources(scope, te, ctes); err != nil {
+				return err
+			}
+		}
+		for _, se := range s.SelectExprs {
+			if ae, ok := se.(*sqlparser.AliasedExpr); ok && !ae.As.IsEmpty() {
+				scope.aliases[strings.ToLower(ae.As.String())] = true
+			}
+		}
+		return ca.walk(scope, ctes, s.SelectExprs, s.From, s.Where, s.GroupBy, s.Having, s.OrderBy)
+	}
+	return nil
+}
+
+// analyzeTrigger analyzes the columns of the trigger table that the body of the trigger |ddl| references through
+// its new and old rows, and the columns of the other tables its statements reference.
+func (ca *columnAnalyzer) analyzeTrigger(ddl *sqlparser.DDL) error {
+	sch, err := ca.tableSchema(ddl.Table, nil)
+	if err != nil {
+		return err
+	}
+	// the unqualified names of a trigger body can be the names of its variables
+	scope := newColumnScope(nil)
+	scope.sources["new"] = sch
+	scope.sources["old"] = sch
+	scope.unknown = true
+	return ca.walk(scope, nil, ddl.TriggerSpec.Body)
+}
+
+// walk analyzes the columns referenced by |nodes| in |scope|. The subqueries and statements within them are analyzed
+// in scopes of their own.
+func (ca *columnAnalyzer) walk(scope *columnScope, ctes map[string]bool, nodes ...sqlparser.SQLNode) error {
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		if ca.problem != "" {
+			return false, nil
+		}
+		switch n := node.(type) {
+		case *sqlparser.ColName:
+			ca.problem = scope.resolve(n)
+			return false, nil
+		case *sqlparser.AliasedTableExpr, *sqlparser.Into:
+			// tables are resolved by addSources, and the targets of SELECT ... INTO are variables
+			return false, nil
+		case *sqlparser.Subquery:
+			return false, ca.analyzeSelect(n.Select, scope, ctes)
+		case sqlparser.SelectStatement:
+			return false, ca.analyzeSelect(n, scope, ctes)
+		case *sqlparser.Insert:
+			sch, err := ca.tableSchema(n.Table, ctes)
+			if err != nil {
+				return false, err
+			}
+			if sch != nil {
+				for _, col := range n.Columns {
+					if _, ok := sch.GetAllCols().GetByNameCaseInsensitive(col.String()); !ok {
+						ca.problem = fmt.Sprintf("column '%s.%s' does not exist", n.Table.Name.String(), col.String())
+						return false, nil
+					}
+				}
+			}
+			return false, ca.walk(scope, ctes, n.Rows, n.OnDup)
+		case *sqlparser.Update:
+			inner := newColumnScope(scope)
+			for _, te := range n.TableExprs {
+				if err := ca.addSources(inner, te, ctes); err != nil {
+					return false, err
+				}
+			}
+			return false, ca.walk(inner, ctes, n.Exprs, n.Where, n.OrderBy)
+		case *sqlparser.Delete:
+			inner := newColumnScope(scope)
+			for _, te := range n.TableExprs {
+				if err := ca.addSources(inner, te, ctes); err != nil {
+					return false, err
+				}
+			}
+			return false, ca.walk(inner, ctes, n.Where, n.OrderBy)
+		}
+		return true, nil
+	}, nodes...)
+}
+
+// selectedTables returns the names of the unqualified tables that |stmt| selects from, other than the common table
+// expressions it defines.
+func selectedTables(stmt sqlparser.SelectStatement) []string {
+	ctes := map[string]bool{"dual": true}
+	var names []string
+	var visit sqlparser.Visit
+	visit = func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.Select:
+			if n.With != nil {
+				for _, expr := range n.With.Ctes {
+					if cte, ok := expr.(*sqlparser.CommonTableExpr); ok {
+						ctes[strings.ToLower(cte.As.String())] = true
+						_ = sqlparser.Walk(visit, cte.Expr)
+					}
+				}
+			}
+		case *sqlparser.AliasedTableExpr:
+			if tn, ok := n.Expr.(sqlparser.TableName); ok && tn.Qualifier.IsEmpty() {
+				names = append(names, tn.Name.String())
+			}
+		}
+		return true, nil
+	}
+	_ = sqlparser.Walk(visit, stmt)
+
+	var tables []string
+	for _, name := range names {
+		if !ctes[strings.ToLower(name)] {
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
@@ -64,17 +64,20 @@ func addUniqIdxViols(
 		switch diff.Type {
 		case tree.AddedDiff:
 			pre := getPrefix(prefixKB, p, val.Tuple(diff.Key))
-			itr, err := creation.NewPrefixItr(ctx, pre, prefixKD, left)
-			if err != nil {
-				return err
-			}
-			k, _, err := itr.Next(ctx)
-			if err != nil && err != io.EOF {
-				return nil
-			}
-			if err == nil {
-				existingPK := getSuffix(suffixKB, p, k)
-				newPK := getSuffix(suffixKB, p, val.Tuple(diff.Key))
+			newPK := getSuffix(suffixKB, p, val.Tuple(diff.Key))
+
+			// Cell-wise merges revert their rows' entries in |left| and
+			// write the merged entries to |right|, so an added entry can
+			// collide with an entry of either side.
+			for _, idx := range []prolly.Map{left, right} {
+				existingPK, ok, err := findPrefixCollision(ctx, idx, pre, prefixKD, newPK, suffixKB, p)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+
 				err = replaceUniqueKeyViolation(ctx, artEditor, m, existingPK, suffixKD, theirRootIsh, vInfo, tblName)
 				if err != nil {
 					return err
@@ -97,6 +100,28 @@ func addUniqIdxViols(
 	return nil
 }
 
+// findPrefixCollision returns the primary key of an entry of |idx| with the
+// unique key |pre| that belongs to a row other than |newPK|, if there is one.
+func findPrefixCollision(ctx context.Context, idx prolly.Map, pre val.Tuple, prefixKD val.TupleDesc, newPK val.Tuple, suffixKB *val.TupleBuilder, p pool.BuffPool) (val.Tuple, bool, error) {
+	itr, err := creation.NewPrefixItr(ctx, pre, prefixKD, idx)
+	if err != nil {
+		return nil, false, err
+	}
+	for {
+		k, _, err := itr.Next(ctx)
+		if err == io.EOF {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		existingPK := getSuffix(suffixKB, p, k)
+		if suffixKB.Desc.Compare(existingPK, newPK) != 0 {
+			return existingPK, true, nil
+		}
+	}
+}
+
 func makeUniqViolMeta(sch schema.Schema, idx schema.Index) (UniqCVMeta, error) {
 	schCols := sch.GetAllCols()
 	idxTags := idx.IndexedColumnTags()
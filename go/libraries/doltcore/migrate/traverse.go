@@ -62,7 +62,7 @@ func traverseRefHistory(ctx context.Context, r ref.DoltRef, old, new *doltdb.Dol
 	case ref.RemoteRefType:
 		return traverseBranchHistory(ctx, r, old, new, prog)
 
-	case ref.WorkspaceRefType, ref.InternalRefType:
+	case ref.WorkspaceRefType, ref.InternalRefType, ref.StashRefType, ref.OriginalRefType:
 		return nil
 
 	default:
@@ -196,6 +196,88 @@ func filterBranchTests() []filterBranchTest {
 				},
 			},
 		},
+		{
+			name: "filter-branch with table subset",
+			setup: []testCommand{
+				{cmd.SqlCmd{}, args{"-q", "INSERT INTO to_drop VALUES (1,1);"}},
+				{cmd.AddCmd{}, args{"-A"}},
+				{cmd.CommitCmd{}, args{"-m", "added a row to to_drop"}},
+				{cmd.FilterBranchCmd{}, args{"--tables", "to_drop", "DELETE FROM test WHERE pk > 0;"}},
+				{cmd.FilterBranchCmd{}, args{"-f", "--tables", "to_drop", "DELETE FROM to_drop;"}},
+			},
+			asserts: []testAssertion{
+				{
+					query: "SELECT * FROM test",
+					rows: []sql.Row{
+						{int32(0), int32(0)},
+						{int32(1), int32(1)},
+						{int32(2), int32(2)},
+					},
+				},
+				{
+					query: "SELECT count(*) FROM to_drop",
+					rows: []sql.Row{
+						{int64(0)},
+					},
+				},
+			},
+		},
+		{
+			name: "filter-branch with prune-empty",
+			setup: []testCommand{
+				{cmd.SqlCmd{}, args{"-q", "INSERT INTO test VALUES (4,4);"}},
+				{cmd.AddCmd{}, args{"-A"}},
+				{cmd.CommitCmd{}, args{"-m", "added row 4"}},
+				{cmd.SqlCmd{}, args{"-q", "INSERT INTO test VALUES (5,5);"}},
+				{cmd.AddCmd{}, args{"-A"}},
+				{cmd.CommitCmd{}, args{"-m", "added row 5"}},
+				{cmd.FilterBranchCmd{}, args{"--prune-empty", "DELETE FROM test WHERE pk = 4;"}},
+			},
+			asserts: []testAssertion{
+				{
+					query: "SELECT message FROM dolt_log ORDER BY date DESC",
+					rows: []sql.Row{
+						{"added row 5"},
+						{"added test tables"},
+						{"Initialize data repository"},
+					},
+				},
+			},
+		},
+		{
+			name: "filter-branch with branch globs",
+			setup: []testCommand{
+				{cmd.BranchCmd{}, args{"release/one"}},
+				{cmd.BranchCmd{}, args{"other"}},
+				{cmd.FilterBranchCmd{}, args{"--branches", "release/*", "DELETE FROM test WHERE pk > 0;"}},
+			},
+			asserts: []testAssertion{
+				{
+					query: "SELECT count(*) FROM test",
+					rows: []sql.Row{
+						{int64(3)},
+					},
+				},
+				{
+					query: "SELECT count(*) FROM test AS OF 'release/one'",
+					rows: []sql.Row{
+						{int64(1)},
+					},
+				},
+				{
+					query: "SELECT count(*) FROM test AS OF 'other'",
+					rows: []sql.Row{
+						{int64(3)},
+					},
+				},
+				{
+					query: "SELECT count(*) FROM test AS OF 'refs/original/heads/release/one'",
+					rows: []sql.Row{
+						{int64(3)},
+					},
+				},
+			},
+		},
 	}
 }
 
@@ -16,16 +16,40 @@ package rebase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/store/datas"
 	"github.com/dolthub/dolt/go/store/hash"
 )
 
+// ErrOriginalsExist is returned when the originals of a previous rewrite are kept under refs/original/ and would be
+// overwritten by a new rewrite.
+var ErrOriginalsExist = errors.New("a previous backup already exists in refs/original/")
+
 type visitedSet map[hash.Hash]*doltdb.Commit
 
+// CommitMap maps the hashes of rewritten commits to the hashes of the commits that replace them.
+type CommitMap map[hash.Hash]hash.Hash
+
+// RewriteMetaFn returns the metadata of a rewritten commit from the metadata of the original commit.
+type RewriteMetaFn func(ctx context.Context, meta *datas.CommitMeta) (*datas.CommitMeta, error)
+
+// RewriteOptions control how RewriteRefs rewrites history.
+type RewriteOptions struct {
+	// RewriteMeta, if set, rewrites the metadata of every rewritten commit.
+	RewriteMeta RewriteMetaFn
+	// PruneEmpty drops the rewritten commits with a single parent that leave the root value of that parent unchanged.
+	PruneEmpty bool
+	// KeepOriginals keeps the head of every rewritten ref under refs/original/.
+	KeepOriginals bool
+	// Force overwrites the heads kept under refs/original/ by a previous rewrite.
+	Force bool
+}
+
 type NeedsRebaseFn func(ctx context.Context, cm *doltdb.Commit) (bool, error)
 
 // EntireHistory returns a |NeedsRebaseFn| that rebases the entire commit history.
@@ -120,6 +144,13 @@ func CurrentBranchByRoot(ctx context.Context, dEnv *env.DoltEnv, replay ReplayRo
 }
 
 func rebaseRefs(ctx context.Context, dbData env.DbData, replay ReplayCommitFn, nerf NeedsRebaseFn, refs ...ref.DoltRef) error {
+	_, err := RewriteRefs(ctx, dbData, replay, nerf, RewriteOptions{}, refs...)
+	return err
+}
+
+// RewriteRefs rewrites the history of the branches and tags in |refs| using the |replay| function, and returns the
+// hashes of the rewritten commits mapped to the hashes of the commits that replace them.
+func RewriteRefs(ctx context.Context, dbData env.DbData, replay ReplayCommitFn, nerf NeedsRebaseFn, opts RewriteOptions, refs ...ref.DoltRef) (CommitMap, error) {
 	ddb := dbData.Ddb
 	rsr := dbData.Rsr
 	rsw := dbData.Rsw
@@ -127,61 +158,125 @@ func rebaseRefs(ctx context.Context, dbData env.DbData, replay ReplayCommitFn, n
 	cwbRef := rsr.CWBHeadRef()
 
 	heads := make([]*doltdb.Commit, len(refs))
+	tagMetas := make([]*datas.TagMeta, len(refs))
 	for i, dRef := range refs {
-		var err error
-		heads[i], err = ddb.ResolveCommitRef(ctx, dRef)
-		if err != nil {
-			return err
+		switch dRef := dRef.(type) {
+		case ref.BranchRef:
+			var err error
+			heads[i], err = ddb.ResolveCommitRef(ctx, dRef)
+			if err != nil {
+				return nil, err
+			}
+
+		case ref.TagRef:
+			t, err := ddb.ResolveTag(ctx, dRef)
+			if err != nil {
+				return nil, err
+			}
+			heads[i], tagMetas[i] = t.Commit, t.Meta
+
+		default:
+			return nil, fmt.Errorf("cannot rebase ref: %s", ref.String(dRef))
+		}
+
+		if opts.KeepOriginals && !opts.Force {
+			ok, err := ddb.HasRef(ctx, ref.NewOriginalRefForRef(dRef))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return nil, fmt.Errorf("%w: %s", ErrOriginalsExist, ref.NewOriginalRefForRef(dRef).String())
+			}
 		}
 	}
 
-	newHeads, err := rebase(ctx, ddb, replay, nerf, heads...)
+	vs := make(visitedSet)
+	newHeads, err := rebase(ctx, ddb, replay, nerf, opts, vs, heads...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for i, dRef := range refs {
+		if opts.KeepOriginals {
+			err = ddb.SetHeadToCommit(ctx, ref.NewOriginalRefForRef(dRef), heads[i])
+			if err != nil {
+				return nil, err
+			}
+		}
 
 		switch dRef.(type) {
 		case ref.BranchRef:
 			err = ddb.NewBranchAtCommit(ctx, dRef, newHeads[i])
 			if err != nil {
-				return err
+				return nil, err
 			}
 
-		default:
-			return fmt.Errorf("cannot rebase ref: %s", ref.String(dRef))
-		}
-
-		if err != nil {
-			return err
+		case ref.TagRef:
+			err = retag(ctx, ddb, dRef, heads[i], newHeads[i], tagMetas[i])
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 
 	cm, err := ddb.ResolveCommitRef(ctx, cwbRef)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	r, err := cm.GetRootValue(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// TODO: this should be a single update to repo state, not two
 	err = rsw.UpdateStagedRoot(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = rsw.UpdateWorkingRoot(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	commitMap := make(CommitMap, len(vs))
+	for h, rebasedCommit := range vs {
+		commitMap[h], err = rebasedCommit.HashOf()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return commitMap, nil
+}
+
+// retag moves the tag |tagRef| from the commit |oldHead| to the commit |newHead|, keeping the metadata of the tag.
+func retag(ctx context.Context, ddb *doltdb.DoltDB, tagRef ref.DoltRef, oldHead, newHead *doltdb.Commit, meta *datas.TagMeta) error {
+	oldHash, err := oldHead.HashOf()
+	if err != nil {
+		return err
+	}
+	newHash, err := newHead.HashOf()
+	if err != nil {
+		return err
+	}
+	if oldHash == newHash {
+		return nil
+	}
+
+	err = ddb.DeleteTag(ctx, tagRef)
 	if err != nil {
 		return err
 	}
 
-	return rsw.UpdateWorkingRoot(ctx, r)
+	return ddb.NewTagAtCommit(ctx, tagRef, newHead, meta)
 }
 
-func rebase(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommitFn, nerf NeedsRebaseFn, origins ...*doltdb.Commit) ([]*doltdb.Commit, error) {
+func rebase(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommitFn, nerf NeedsRebaseFn, opts RewriteOptions, vs visitedSet, origins ...*doltdb.Commit) ([]*doltdb.Commit, error) {
 	var rebasedCommits []*doltdb.Commit
-	vs := make(visitedSet)
 	for _, cm := range origins {
-		rc, err := rebaseRecursive(ctx, ddb, replay, nerf, vs, cm)
+		rc, err := rebaseRecursive(ctx, ddb, replay, nerf, opts, vs, cm)
 
 		if err != nil {
 			return nil, err
@@ -193,7 +288,7 @@ func rebase(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommitFn, nerf
 	return rebasedCommits, nil
 }
 
-func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommitFn, nerf NeedsRebaseFn, vs visitedSet, commit *doltdb.Commit) (*doltdb.Commit, error) {
+func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommitFn, nerf NeedsRebaseFn, opts RewriteOptions, vs visitedSet, commit *doltdb.Commit) (*doltdb.Commit, error) {
 	commitHash, err := commit.HashOf()
 	if err != nil {
 		return nil, err
@@ -209,7 +304,16 @@ func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommi
 		return nil, err
 	}
 	if !needToRebase {
-		// base case: reached bottom of DFS,
+		// base case: reached bottom of DFS. The initial commit has no parent to replay it onto, but its metadata is
+		// rewritten all the same.
+		if commit.NumParents() == 0 && opts.RewriteMeta != nil {
+			rebasedCommit, err := rewriteInitialCommit(ctx, ddb, opts.RewriteMeta, commit)
+			if err != nil {
+				return nil, err
+			}
+			vs[commitHash] = rebasedCommit
+			return rebasedCommit, nil
+		}
 		return commit, nil
 	}
 
@@ -224,7 +328,7 @@ func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommi
 
 	var allRebasedParents []*doltdb.Commit
 	for _, p := range allParents {
-		rp, err := rebaseRecursive(ctx, ddb, replay, nerf, vs, p)
+		rp, err := rebaseRecursive(ctx, ddb, replay, nerf, opts, vs, p)
 
 		if err != nil {
 			return nil, err
@@ -238,18 +342,39 @@ func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommi
 		return nil, err
 	}
 
-	r, valueHash, err := ddb.WriteRootValue(ctx, rebasedRoot)
+	_, valueHash, err := ddb.WriteRootValue(ctx, rebasedRoot)
 	if err != nil {
 		return nil, err
 	}
-	rebasedRoot = r
 
-	oldMeta, err := commit.GetCommitMeta(ctx)
+	if opts.PruneEmpty && len(allRebasedParents) == 1 {
+		parentRoot, err := allRebasedParents[0].GetRootValue(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parentHash, err := parentRoot.HashOf()
+		if err != nil {
+			return nil, err
+		}
+		if parentHash == valueHash {
+			vs[commitHash] = allRebasedParents[0]
+			return allRebasedParents[0], nil
+		}
+	}
+
+	meta, err := commit.GetCommitMeta(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	rebasedCommit, err := ddb.CommitDanglingWithParentCommits(ctx, valueHash, allRebasedParents, oldMeta)
+	if opts.RewriteMeta != nil {
+		meta, err = opts.RewriteMeta(ctx, meta)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rebasedCommit, err := ddb.CommitDanglingWithParentCommits(ctx, valueHash, allRebasedParents, meta)
 	if err != nil {
 		return nil, err
 	}
@@ -257,3 +382,30 @@ func rebaseRecursive(ctx context.Context, ddb *doltdb.DoltDB, replay ReplayCommi
 	vs[commitHash] = rebasedCommit
 	return rebasedCommit, nil
 }
+
+// rewriteInitialCommit returns a commit with the root value of the initial commit |commit| and its metadata rewritten
+// by |rewriteMeta|, or |commit| itself if its metadata is left unchanged.
+func rewriteInitialCommit(ctx context.Context, ddb *doltdb.DoltDB, rewriteMeta RewriteMetaFn, commit *doltdb.Commit) (*doltdb.Commit, error) {
+	meta, err := commit.GetCommitMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rewritten, err := rewriteMeta(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	if *rewritten == *meta {
+		return commit, nil
+	}
+
+	root, err := commit.GetRootValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, valueHash, err := ddb.WriteRootValue(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+
+	return ddb.CommitDanglingInitial(ctx, valueHash, rewritten)
+}
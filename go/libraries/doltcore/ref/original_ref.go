@@ -0,0 +1,65 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ref
+
+import "strings"
+
+// OriginalRef is a reference to the commit a ref pointed at before its history was rewritten by filter-branch. Its
+// path is the rewritten ref without the refs/ prefix, e.g. refs/original/heads/main keeps the original head of main.
+type OriginalRef struct {
+	path string
+}
+
+var _ DoltRef = OriginalRef{}
+
+// NewOriginalRef creates a reference to an original head from its path or from an original ref e.g. heads/main, or
+// refs/original/heads/main
+func NewOriginalRef(path string) OriginalRef {
+	if IsRef(path) {
+		prefix := PrefixForType(OriginalRefType)
+		if strings.HasPrefix(path, prefix) {
+			path = path[len(prefix):]
+		} else {
+			panic(path + " is a ref that is not of type " + prefix)
+		}
+	}
+
+	return OriginalRef{path}
+}
+
+// NewOriginalRefForRef creates the reference that keeps the original head of |dRef|.
+func NewOriginalRefForRef(dRef DoltRef) OriginalRef {
+	return OriginalRef{strings.TrimPrefix(dRef.String(), refPrefix)}
+}
+
+// GetType will return OriginalRefType
+func (or OriginalRef) GetType() RefType {
+	return OriginalRefType
+}
+
+// GetPath returns the rewritten ref without the refs/ prefix
+func (or OriginalRef) GetPath() string {
+	return or.path
+}
+
+// String returns the fully qualified reference name e.g. refs/original/heads/main
+func (or OriginalRef) String() string {
+	return String(or)
+}
+
+// MarshalJSON serializes an OriginalRef to JSON.
+func (or OriginalRef) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(or)
+}
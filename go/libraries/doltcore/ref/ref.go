@@ -51,6 +51,12 @@ const (
 
 	// WorkspaceRefType is a reference to a workspace
 	WorkspaceRefType RefType = "workspaces"
+
+	// StashRefType is a reference to a stash entry
+	StashRefType RefType = "stashes"
+
+	// OriginalRefType is a reference to the head of a ref before its history was rewritten
+	OriginalRefType RefType = "original"
 )
 
 // HeadRefTypes are the ref types that point to a HEAD and contain a Commit struct. These are the types that are
@@ -62,6 +68,8 @@ var HeadRefTypes = map[RefType]struct{}{
 	InternalRefType:  {},
 	TagRefType:       {},
 	WorkspaceRefType: {},
+	StashRefType:     {},
+	OriginalRefType:  {},
 }
 
 // PrefixForType returns what a reference string for a given type should start with
@@ -155,6 +163,10 @@ func Parse(str string) (DoltRef, error) {
 				return NewTagRef(str), nil
 			case WorkspaceRefType:
 				return NewWorkspaceRef(str), nil
+			case StashRefType:
+				return NewStashRef(str), nil
+			case OriginalRefType:
+				return NewOriginalRef(str), nil
 			default:
 				panic("unknown type " + rType)
 			}
@@ -46,6 +46,14 @@ func TestJsonMarshalAndUnmarshal(t *testing.T) {
 			NewWorkspaceRef("newworkspace"),
 			`{"test":"refs/workspaces/newworkspace"}`,
 		},
+		{
+			NewStashRef("3"),
+			`{"test":"refs/stashes/3"}`,
+		},
+		{
+			NewOriginalRefForRef(NewBranchRef("main")),
+			`{"test":"refs/original/heads/main"}`,
+		},
 	}
 
 	for _, test := range tests {
@@ -160,6 +168,26 @@ func TestEqualsStr(t *testing.T) {
 			"refs/remotes/origin/newworkspace",
 			false,
 		},
+		{
+			NewStashRefForSeq(3),
+			"refs/stashes/3",
+			true,
+		},
+		{
+			NewStashRef("refs/stashes/3"),
+			"refs/stashes/4",
+			false,
+		},
+		{
+			NewOriginalRefForRef(NewTagRef("v1")),
+			"refs/original/tags/v1",
+			true,
+		},
+		{
+			NewOriginalRef("refs/original/heads/main"),
+			"refs/heads/main",
+			false,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,77 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ref
+
+import (
+	"strconv"
+	"strings"
+)
+
+// StashRef is a reference to a single stash entry. Stash entries are numbered in the order they are created, so that
+// the entry with the largest number is the most recent one.
+type StashRef struct {
+	stash string
+}
+
+var _ DoltRef = StashRef{}
+
+// NewStashRef creates a reference to a stash entry from a stash id or a stash ref e.g. 3, or refs/stashes/3
+func NewStashRef(stash string) StashRef {
+	if IsRef(stash) {
+		prefix := PrefixForType(StashRefType)
+		if strings.HasPrefix(stash, prefix) {
+			stash = stash[len(prefix):]
+		} else {
+			panic(stash + " is a ref that is not of type " + prefix)
+		}
+	}
+
+	return StashRef{stash}
+}
+
+// NewStashRefForSeq creates a reference to the stash entry with the sequence number given.
+func NewStashRefForSeq(seq uint64) StashRef {
+	return StashRef{strconv.FormatUint(seq, 10)}
+}
+
+// GetType will return StashRefType
+func (sr StashRef) GetType() RefType {
+	return StashRefType
+}
+
+// GetPath returns the id of the stash entry
+func (sr StashRef) GetPath() string {
+	return sr.stash
+}
+
+// Seq returns the sequence number of this stash entry. Stash entries with paths that are not numeric are sorted
+// before all others.
+func (sr StashRef) Seq() uint64 {
+	seq, err := strconv.ParseUint(sr.stash, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return seq
+}
+
+// String returns the fully qualified reference name e.g. refs/stashes/3
+func (sr StashRef) String() string {
+	return String(sr)
+}
+
+// MarshalJSON serializes a StashRef to JSON.
+func (sr StashRef) MarshalJSON() ([]byte, error) {
+	return MarshalJSON(sr)
+}
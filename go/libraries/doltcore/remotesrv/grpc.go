@@ -0,0 +1,547 @@
+// Copyright 2019 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesrv
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	remotesapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/dolthub/dolt/go/libraries/doltcore/remotestorage"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/nbs"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+// fileDetails holds the details of the table files that clients were given upload locations for, so that their
+// uploads can be validated.
+type fileDetails struct {
+	mu    sync.Mutex
+	files map[string]*remotesapi.TableFileDetails
+}
+
+func newFileDetails() *fileDetails {
+	return &fileDetails{files: make(map[string]*remotesapi.TableFileDetails)}
+}
+
+func (fd *fileDetails) Put(fileId string, tfd *remotesapi.TableFileDetails) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	fd.files[fileId] = tfd
+}
+
+func (fd *fileDetails) Get(fileId string) (*remotesapi.TableFileDetails, bool) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	tfd, ok := fd.files[fileId]
+	return tfd, ok
+}
+
+type RemoteChunkStore struct {
+	HttpHost      string
+	csCache       DBCache
+	bucket        string
+	expectedFiles *fileDetails
+	sealer        URLSealer
+	lgr           *logrus.Entry
+	remotesapi.UnimplementedChunkStoreServiceServer
+}
+
+// NewHttpFSBackedChunkStore returns a RemoteChunkStore serving the stores of |csCache|, whose table files are
+// transferred over http at |httpHost|. If |httpHost| is empty, the host the client addressed the request to is used.
+// The table file urls it hands out are sealed with |sealer|.
+func NewHttpFSBackedChunkStore(lgr *logrus.Entry, httpHost string, csCache DBCache, expectedFiles *fileDetails, sealer URLSealer) *RemoteChunkStore {
+	return &RemoteChunkStore{
+		HttpHost:      httpHost,
+		csCache:       csCache,
+		bucket:        "",
+		expectedFiles: expectedFiles,
+		sealer:        sealer,
+		lgr: lgr.WithFields(logrus.Fields{
+			"service": "dolt.services.remotesapi.v1alpha1.ChunkStoreServiceServer",
+		}),
+	}
+}
+
+func (rs *RemoteChunkStore) HasChunks(ctx context.Context, req *remotesapi.HasChunksRequest) (*remotesapi.HasChunksResponse, error) {
+	logger := getReqLogger(rs.lgr, "HasChunks")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found repo %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	hashes, hashToIndex := remotestorage.ParseByteSlices(req.Hashes)
+
+	absent, err := cs.HasMany(ctx, hashes)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "HasMany failure:"+err.Error())
+	}
+
+	indices := make([]int32, len(absent))
+
+	n := 0
+	for h := range absent {
+		indices[n] = int32(hashToIndex[h])
+		n++
+	}
+
+	resp := &remotesapi.HasChunksResponse{
+		Absent: indices,
+	}
+
+	return resp, nil
+}
+
+func (rs *RemoteChunkStore) GetDownloadLocations(ctx context.Context, req *remotesapi.GetDownloadLocsRequest) (*remotesapi.GetDownloadLocsResponse, error) {
+	logger := getReqLogger(rs.lgr, "GetDownloadLocations")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found repo %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	locs, err := rs.getDownloadLocs(ctx, logger, cs, req.RepoId, req.ChunkHashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remotesapi.GetDownloadLocsResponse{Locs: locs}, nil
+}
+
+func (rs *RemoteChunkStore) StreamDownloadLocations(stream remotesapi.ChunkStoreService_StreamDownloadLocationsServer) error {
+	logger := getReqLogger(rs.lgr, "StreamDownloadLocations")
+	defer func() { logger.Println("finished") }()
+
+	var repoID *remotesapi.RepoId
+	var cs RemoteSrvStore
+	for {
+		req, err := stream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		if !proto.Equal(req.RepoId, repoID) {
+			repoID = req.RepoId
+			cs = rs.getStore(logger, repoID)
+			if cs == nil {
+				return status.Error(codes.Internal, "Could not get chunkstore")
+			}
+			logger.Printf("found repo %s/%s", repoID.Org, repoID.RepoName)
+		}
+
+		locs, err := rs.getDownloadLocs(stream.Context(), logger, cs, req.RepoId, req.ChunkHashes)
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(&remotesapi.GetDownloadLocsResponse{Locs: locs}); err != nil {
+			return err
+		}
+	}
+}
+
+func (rs *RemoteChunkStore) getDownloadLocs(ctx context.Context, logger *logrus.Entry, cs RemoteSrvStore, repoId *remotesapi.RepoId, chunkHashes [][]byte) ([]*remotesapi.DownloadLoc, error) {
+	hashes, _ := remotestorage.ParseByteSlices(chunkHashes)
+	locations, err := cs.GetChunkLocationsWithPaths(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	var locs []*remotesapi.DownloadLoc
+	for loc, hashToRange := range locations {
+		var ranges []*remotesapi.RangeChunk
+		for h, r := range hashToRange {
+			hCpy := h
+			ranges = append(ranges, &remotesapi.RangeChunk{Hash: hCpy[:], Offset: r.Offset, Length: r.Length})
+		}
+
+		url, err := rs.getDownloadUrl(ctx, repoId, loc)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to get download url: "+err.Error())
+		}
+		logger.Println("The URL is " + url)
+
+		getRange := &remotesapi.HttpGetRange{Url: url, Ranges: ranges}
+		locs = append(locs, &remotesapi.DownloadLoc{
+			Location:       &remotesapi.DownloadLoc_HttpGetRange{HttpGetRange: getRange},
+			RefreshAfter:   refreshAfter(),
+			RefreshRequest: &remotesapi.RefreshTableFileUrlRequest{RepoId: repoId, FileId: loc},
+		})
+	}
+
+	return locs, nil
+}
+
+// getHost returns the host table files are transferred at.
+func (rs *RemoteChunkStore) getHost(ctx context.Context) string {
+	if rs.HttpHost != "" && !strings.HasPrefix(rs.HttpHost, ":") {
+		return rs.HttpHost
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		if authority := md.Get(":authority"); len(authority) > 0 {
+			return authority[0]
+		}
+	}
+	return "localhost" + rs.HttpHost
+}
+
+func (rs *RemoteChunkStore) getDownloadUrl(ctx context.Context, repoId *remotesapi.RepoId, fileId string) (string, error) {
+	return rs.sealedUrl(ctx, path.Join(repoPath(repoId), fileId))
+}
+
+// sealedUrl returns the sealed url of the table file at |filePath| of the http endpoint.
+func (rs *RemoteChunkStore) sealedUrl(ctx context.Context, filePath string) (string, error) {
+	u := &url.URL{Scheme: "http", Host: rs.getHost(ctx), Path: "/" + filePath}
+	sealed, err := rs.sealer.Seal(u)
+	if err != nil {
+		return "", err
+	}
+	return sealed.String(), nil
+}
+
+// refreshAfter returns when clients should refresh the table file urls handed out now.
+func refreshAfter() *timestamppb.Timestamp {
+	return timestamppb.New(time.Now().Add(sealedURLTTL / 2))
+}
+
+// repoPath returns the path of the repository |repoId| in the urls of its table files.
+func repoPath(repoId *remotesapi.RepoId) string {
+	if repoId.Org == "" {
+		return repoId.RepoName
+	}
+	return path.Join(repoId.Org, repoId.RepoName)
+}
+
+func parseTableFileDetails(req *remotesapi.GetUploadLocsRequest) []*remotesapi.TableFileDetails {
+	tfd := req.GetTableFileDetails()
+
+	if len(tfd) == 0 {
+		_, hashToIdx := remotestorage.ParseByteSlices(req.TableFileHashes)
+
+		tfd = make([]*remotesapi.TableFileDetails, len(hashToIdx))
+		for h, i := range hashToIdx {
+			tfd[i] = &remotesapi.TableFileDetails{
+				Id:            h[:],
+				ContentLength: 0,
+				ContentHash:   nil,
+			}
+		}
+	}
+
+	return tfd
+}
+
+func (rs *RemoteChunkStore) GetUploadLocations(ctx context.Context, req *remotesapi.GetUploadLocsRequest) (*remotesapi.GetUploadLocsResponse, error) {
+	logger := getReqLogger(rs.lgr, "GetUploadLocations")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found repo %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	tfds := parseTableFileDetails(req)
+
+	var locs []*remotesapi.UploadLoc
+	for _, tfd := range tfds {
+		h := hash.New(tfd.Id)
+		url, err := rs.getUploadUrl(ctx, req.RepoId, tfd)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to get upload url: "+err.Error())
+		}
+
+		loc := &remotesapi.UploadLoc_HttpPost{HttpPost: &remotesapi.HttpPostTableFile{Url: url}}
+		locs = append(locs, &remotesapi.UploadLoc{TableFileHash: h[:], Location: loc})
+
+		logger.Printf("sending upload location for chunk %s: %s", h.String(), url)
+	}
+
+	return &remotesapi.GetUploadLocsResponse{Locs: locs}, nil
+}
+
+func (rs *RemoteChunkStore) getUploadUrl(ctx context.Context, repoId *remotesapi.RepoId, tfd *remotesapi.TableFileDetails) (string, error) {
+	fileID := hash.New(tfd.Id).String()
+	rs.expectedFiles.Put(fileID, tfd)
+	return rs.sealedUrl(ctx, path.Join(repoPath(repoId), fileID))
+}
+
+func (rs *RemoteChunkStore) Rebase(ctx context.Context, req *remotesapi.RebaseRequest) (*remotesapi.RebaseResponse, error) {
+	logger := getReqLogger(rs.lgr, "Rebase")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	err := cs.Rebase(ctx)
+
+	if err != nil {
+		logger.Printf("error occurred during processing of Rebace rpc of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err)
+		return nil, status.Errorf(codes.Internal, "failed to rebase: %v", err)
+	}
+
+	return &remotesapi.RebaseResponse{}, nil
+}
+
+func (rs *RemoteChunkStore) Root(ctx context.Context, req *remotesapi.RootRequest) (*remotesapi.RootResponse, error) {
+	logger := getReqLogger(rs.lgr, "Root")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	h, err := cs.Root(ctx)
+
+	if err != nil {
+		logger.Printf("error occurred during processing of Root rpc of %s/%s details: %v", req.RepoId.Org, req.RepoId.RepoName, err)
+		return nil, status.Error(codes.Internal, "Failed to get root")
+	}
+
+	return &remotesapi.RootResponse{RootHash: h[:]}, nil
+}
+
+func (rs *RemoteChunkStore) Commit(ctx context.Context, req *remotesapi.CommitRequest) (*remotesapi.CommitResponse, error) {
+	logger := getReqLogger(rs.lgr, "Commit")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	//should validate
+	updates := make(map[string]int)
+	for _, cti := range req.ChunkTableInfo {
+		updates[hash.New(cti.Hash).String()] = int(cti.ChunkCount)
+	}
+
+	err := cs.AddTableFilesToManifest(ctx, updates)
+
+	if err != nil {
+		logger.Printf("error occurred updating the manifest: %s", err.Error())
+		return nil, status.Errorf(codes.Internal, "manifest update error: %v", err)
+	}
+
+	currHash := hash.New(req.Current)
+	lastHash := hash.New(req.Last)
+
+	var ok bool
+	ok, err = cs.Commit(ctx, currHash, lastHash)
+
+	if err != nil {
+		logger.Printf("error occurred during processing of Commit of %s/%s last %s curr: %s details: %v", req.RepoId.Org, req.RepoId.RepoName, lastHash.String(), currHash.String(), err)
+		return nil, status.Errorf(codes.Internal, "failed to commit: %v", err)
+	}
+
+	logger.Printf("committed %s/%s moved from %s -> %s", req.RepoId.Org, req.RepoId.RepoName, lastHash.String(), currHash.String())
+	return &remotesapi.CommitResponse{Success: ok}, nil
+}
+
+func (rs *RemoteChunkStore) GetRepoMetadata(ctx context.Context, req *remotesapi.GetRepoMetadataRequest) (*remotesapi.GetRepoMetadataResponse, error) {
+	logger := getReqLogger(rs.lgr, "GetRepoMetadata")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getOrCreateStore(logger, req.RepoId, req.ClientRepoFormat.NbfVersion)
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	size, err := cs.Size(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remotesapi.GetRepoMetadataResponse{
+		NbfVersion:  cs.Version(),
+		NbsVersion:  req.ClientRepoFormat.NbsVersion,
+		StorageSize: size,
+	}, nil
+}
+
+func (rs *RemoteChunkStore) ListTableFiles(ctx context.Context, req *remotesapi.ListTableFilesRequest) (*remotesapi.ListTableFilesResponse, error) {
+	logger := getReqLogger(rs.lgr, "ListTableFiles")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found repo %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	root, tables, appendixTables, err := cs.Sources(ctx)
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get sources")
+	}
+
+	tableFileInfo, err := getTableFileInfo(ctx, rs, tables, req)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get table file urls: "+err.Error())
+	}
+	appendixTableFileInfo, err := getTableFileInfo(ctx, rs, appendixTables, req)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get table file urls: "+err.Error())
+	}
+
+	resp := &remotesapi.ListTableFilesResponse{
+		RootHash:              root[:],
+		TableFileInfo:         tableFileInfo,
+		AppendixTableFileInfo: appendixTableFileInfo,
+	}
+
+	return resp, nil
+}
+
+func getTableFileInfo(ctx context.Context, rs *RemoteChunkStore, tableList []nbs.TableFile, req *remotesapi.ListTableFilesRequest) ([]*remotesapi.TableFileInfo, error) {
+	appendixTableFileInfo := make([]*remotesapi.TableFileInfo, 0)
+	for _, t := range tableList {
+		url, err := rs.getDownloadUrl(ctx, req.RepoId, t.FileID())
+		if err != nil {
+			return nil, err
+		}
+		appendixTableFileInfo = append(appendixTableFileInfo, &remotesapi.TableFileInfo{
+			FileId:         t.FileID(),
+			NumChunks:      uint32(t.NumChunks()),
+			Url:            url,
+			RefreshAfter:   refreshAfter(),
+			RefreshRequest: &remotesapi.RefreshTableFileUrlRequest{RepoId: req.RepoId, FileId: t.FileID()},
+		})
+	}
+	return appendixTableFileInfo, nil
+}
+
+// RefreshTableFileUrl returns a new url for a table file whose url is about to expire.
+func (rs *RemoteChunkStore) RefreshTableFileUrl(ctx context.Context, req *remotesapi.RefreshTableFileUrlRequest) (*remotesapi.RefreshTableFileUrlResponse, error) {
+	logger := getReqLogger(rs.lgr, "RefreshTableFileUrl")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	url, err := rs.getDownloadUrl(ctx, req.RepoId, req.FileId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to get download url: "+err.Error())
+	}
+
+	return &remotesapi.RefreshTableFileUrlResponse{Url: url, RefreshAfter: refreshAfter()}, nil
+}
+
+// AddTableFiles updates the remote manifest with new table files without modifying the root hash.
+func (rs *RemoteChunkStore) AddTableFiles(ctx context.Context, req *remotesapi.AddTableFilesRequest) (*remotesapi.AddTableFilesResponse, error) {
+	logger := getReqLogger(rs.lgr, "AddTableFiles")
+	defer func() { logger.Println("finished") }()
+
+	cs := rs.getStore(logger, req.RepoId)
+
+	if cs == nil {
+		return nil, status.Error(codes.Internal, "Could not get chunkstore")
+	}
+
+	logger.Printf("found %s/%s", req.RepoId.Org, req.RepoId.RepoName)
+
+	// should validate
+	updates := make(map[string]int)
+	for _, cti := range req.ChunkTableInfo {
+		updates[hash.New(cti.Hash).String()] = int(cti.ChunkCount)
+	}
+
+	err := cs.AddTableFilesToManifest(ctx, updates)
+
+	if err != nil {
+		logger.Printf("error occurred updating the manifest: %s", err.Error())
+		return nil, status.Error(codes.Internal, "manifest update error")
+	}
+
+	return &remotesapi.AddTableFilesResponse{Success: true}, nil
+}
+
+func (rs *RemoteChunkStore) getStore(logger *logrus.Entry, repoId *remotesapi.RepoId) RemoteSrvStore {
+	return rs.getOrCreateStore(logger, repoId, types.Format_Default.VersionString())
+}
+
+func (rs *RemoteChunkStore) getOrCreateStore(logger *logrus.Entry, repoId *remotesapi.RepoId, nbfVerStr string) RemoteSrvStore {
+	org := repoId.Org
+	repoName := repoId.RepoName
+
+	cs, err := rs.csCache.Get(org, repoName, nbfVerStr)
+
+	if err != nil {
+		logger.Printf("Failed to retrieve chunkstore for %s/%s: %v", org, repoName, err)
+		return nil
+	}
+
+	return cs
+}
+
+var requestId int32
+
+func incReqId() int32 {
+	return atomic.AddInt32(&requestId, 1)
+}
+
+func getReqLogger(lgr *logrus.Entry, method string) *logrus.Entry {
+	lgr = lgr.WithFields(logrus.Fields{
+		"method":      method,
+		"request_num": fmt.Sprintf("%d", incReqId()),
+	})
+	lgr.Println("starting request")
+	return lgr
+}
@@ -0,0 +1,372 @@
+// Copyright 2019 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesrv
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/nbs"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+var (
+	ErrReadOutOfBounds = errors.New("cannot read file for given length and " +
+		"offset since the read would exceed the size of the file")
+)
+
+// oldGenDir is the directory, relative to the directory of a store, holding the table files of its old generation.
+const oldGenDir = "oldgen"
+
+type filehandler struct {
+	dbCache       DBCache
+	expectedFiles *fileDetails
+	sealer        URLSealer
+	lgr           *logrus.Entry
+}
+
+func newFileHandler(lgr *logrus.Entry, dbCache DBCache, expectedFiles *fileDetails, sealer URLSealer) filehandler {
+	return filehandler{
+		dbCache:       dbCache,
+		expectedFiles: expectedFiles,
+		sealer:        sealer,
+		lgr:           lgr.WithFields(logrus.Fields{"service": "dolt.services.remotesapi.v1alpha1.HttpFileServer"}),
+	}
+}
+
+// parseFilePath splits the path of a table file url into the organization and repository it belongs to, and the
+// path of the table file relative to the directory of the repository's store.
+func parseFilePath(urlPath string) (org, repo, filePath string, ok bool) {
+	tokens := strings.Split(strings.Trim(urlPath, "/"), "/")
+	if len(tokens) < 2 {
+		return "", "", "", false
+	}
+
+	fileId := tokens[len(tokens)-1]
+	tokens = tokens[:len(tokens)-1]
+	filePath = fileId
+	if len(tokens) > 1 && tokens[len(tokens)-1] == oldGenDir {
+		filePath = oldGenDir + "/" + fileId
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	switch len(tokens) {
+	case 1:
+		return "", tokens[0], filePath, true
+	case 2:
+		return tokens[0], tokens[1], filePath, true
+	default:
+		return "", "", "", false
+	}
+}
+
+func (fh filehandler) ServeHTTP(respWr http.ResponseWriter, req *http.Request) {
+	logger := getReqLogger(fh.lgr, req.Method+"_"+req.RequestURI)
+	defer func() { logger.Println("finished") }()
+
+	unsealed, err := fh.sealer.Unseal(req.URL)
+	if err != nil {
+		logger.Printf("response to: %v method: %v http response code: %v", req.RequestURI, req.Method, http.StatusUnauthorized)
+		respWr.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	org, repo, filePath, ok := parseFilePath(unsealed.Path)
+	if !ok {
+		logger.Printf("response to: %v method: %v http response code: %v", req.RequestURI, req.Method, http.StatusNotFound)
+		respWr.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	cs, err := fh.dbCache.Get(org, repo, types.Format_Default.VersionString())
+	if err != nil {
+		logger.Printf("failed to get repo %s/%s: %v", org, repo, err)
+		respWr.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	statusCode := http.StatusMethodNotAllowed
+	switch req.Method {
+	case http.MethodGet:
+		storePath, ok := cs.Path()
+		if !ok {
+			statusCode = http.StatusInternalServerError
+			break
+		}
+		path := filepath.Join(storePath, filepath.FromSlash(filePath))
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && filePath == filepath.Base(filePath) {
+			// table files that aren't on disk, like snapshots of the chunk journal, are read from the store
+			statusCode = readSourceTableFile(logger, cs, filePath, respWr, req)
+			break
+		}
+		statusCode = readTableFile(logger, path, respWr, req)
+
+	case http.MethodPost, http.MethodPut:
+		if filePath != filepath.Base(filePath) {
+			statusCode = http.StatusBadRequest
+			break
+		}
+		statusCode = fh.writeTableFile(logger, cs, filePath, req)
+	}
+
+	if statusCode != -1 {
+		respWr.WriteHeader(statusCode)
+	}
+}
+
+func readTableFile(logger *logrus.Entry, path string, respWr http.ResponseWriter, req *http.Request) int {
+	rangeStr := req.Header.Get("Range")
+
+	var r io.ReadCloser
+	var readSize int64
+	var fileErr error
+	{
+		if rangeStr == "" {
+			logger.Println("going to read entire file")
+			r, readSize, fileErr = getFileReader(path)
+		} else {
+			offset, length, err := offsetAndLenFromRange(rangeStr)
+			if err != nil {
+				logger.Println(err.Error())
+				return http.StatusBadRequest
+			}
+			logger.Printf("going to read file at offset %d, length %d", offset, length)
+			readSize = length
+			r, fileErr = getFileReaderAt(path, offset, length)
+		}
+	}
+	if fileErr != nil {
+		logger.Println(fileErr.Error())
+		if errors.Is(fileErr, os.ErrNotExist) {
+			return http.StatusNotFound
+		} else if errors.Is(fileErr, ErrReadOutOfBounds) {
+			return http.StatusBadRequest
+		}
+		return http.StatusInternalServerError
+	}
+	defer func() {
+		err := r.Close()
+		if err != nil {
+			err = fmt.Errorf("failed to close file at path %s: %w", path, err)
+			logger.Println(err.Error())
+		}
+	}()
+
+	logger.Printf("opened file at path %s, going to read %d bytes", path, readSize)
+
+	n, err := io.Copy(respWr, r)
+	if err != nil {
+		err = fmt.Errorf("failed to write data to response writer: %w", err)
+		logger.Println(err.Error())
+		return http.StatusInternalServerError
+	}
+	if n != readSize {
+		logger.Printf("wanted to write %d bytes from file (%s) but only wrote %d", readSize, path, n)
+		return http.StatusInternalServerError
+	}
+
+	logger.Printf("wrote %d bytes", n)
+
+	return http.StatusOK
+}
+
+// readSourceTableFile writes the table file |fileId| of |cs| to |respWr|, for table files that are listed by the
+// store but aren't in its directory.
+func readSourceTableFile(logger *logrus.Entry, cs RemoteSrvStore, fileId string, respWr http.ResponseWriter, req *http.Request) int {
+	_, tables, appendixTables, err := cs.Sources(req.Context())
+	if err != nil {
+		logger.Println(err.Error())
+		return http.StatusInternalServerError
+	}
+
+	var tf nbs.TableFile
+	for _, t := range append(tables, appendixTables...) {
+		if t.FileID() == fileId {
+			tf = t
+			break
+		}
+	}
+	if tf == nil {
+		logger.Printf("no table file %s", fileId)
+		return http.StatusNotFound
+	}
+
+	rd, size, err := tf.Open(req.Context())
+	if err != nil {
+		logger.Println(err.Error())
+		return http.StatusInternalServerError
+	}
+	defer rd.Close()
+
+	offset, length := int64(0), int64(size)
+	if rangeStr := req.Header.Get("Range"); rangeStr != "" {
+		offset, length, err = offsetAndLenFromRange(rangeStr)
+		if err != nil {
+			logger.Println(err.Error())
+			return http.StatusBadRequest
+		}
+		if uint64(offset+length) > size {
+			logger.Printf("failed to read table file %s at offset %d, length %d: %v", fileId, offset, length, ErrReadOutOfBounds)
+			return http.StatusBadRequest
+		}
+	}
+
+	if _, err = io.CopyN(io.Discard, rd, offset); err != nil {
+		logger.Println(err.Error())
+		return http.StatusInternalServerError
+	}
+	n, err := io.CopyN(respWr, rd, length)
+	if err != nil {
+		err = fmt.Errorf("failed to write data to response writer: %w", err)
+		logger.Println(err.Error())
+		return http.StatusInternalServerError
+	}
+
+	logger.Printf("wrote %d bytes", n)
+
+	return http.StatusOK
+}
+
+func (fh filehandler) writeTableFile(logger *logrus.Entry, cs RemoteSrvStore, fileId string, request *http.Request) int {
+	_, ok := hash.MaybeParse(fileId)
+
+	if !ok {
+		logger.Println(fileId + " is not a valid hash")
+		return http.StatusBadRequest
+	}
+
+	tfd, ok := fh.expectedFiles.Get(fileId)
+
+	if !ok {
+		return http.StatusBadRequest
+	}
+
+	logger.Println(fileId + " is valid")
+	data, err := io.ReadAll(request.Body)
+
+	if err != nil {
+		logger.Println("failed to read body " + err.Error())
+		return http.StatusInternalServerError
+	}
+
+	if tfd.ContentLength != 0 && tfd.ContentLength != uint64(len(data)) {
+		return http.StatusBadRequest
+	}
+
+	if len(tfd.ContentHash) > 0 {
+		actualMD5Bytes := md5.Sum(data)
+		if !bytes.Equal(tfd.ContentHash, actualMD5Bytes[:]) {
+			return http.StatusBadRequest
+		}
+	}
+
+	err = cs.WriteTableFile(request.Context(), fileId, 0, tfd.ContentHash, func() (io.ReadCloser, uint64, error) {
+		return io.NopCloser(bytes.NewReader(data)), uint64(len(data)), nil
+	})
+
+	if err != nil {
+		logger.Printf("failed to write table file %s: %v", fileId, err)
+		return http.StatusInternalServerError
+	}
+
+	logger.Println("Successfully wrote object to storage")
+
+	return http.StatusOK
+}
+
+func offsetAndLenFromRange(rngStr string) (int64, int64, error) {
+	if rngStr == "" {
+		return -1, -1, nil
+	}
+
+	if !strings.HasPrefix(rngStr, "bytes=") {
+		return -1, -1, errors.New("range string does not start with 'bytes=")
+	}
+
+	tokens := strings.Split(rngStr[6:], "-")
+
+	if len(tokens) != 2 {
+		return -1, -1, errors.New("invalid range format. should be bytes=#-#")
+	}
+
+	start, err := strconv.ParseUint(strings.TrimSpace(tokens[0]), 10, 64)
+
+	if err != nil {
+		return -1, -1, errors.New("invalid offset is not a number. should be bytes=#-#")
+	}
+
+	end, err := strconv.ParseUint(strings.TrimSpace(tokens[1]), 10, 64)
+
+	if err != nil {
+		return -1, -1, errors.New("invalid length is not a number. should be bytes=#-#")
+	}
+
+	return int64(start), int64(end-start) + 1, nil
+}
+
+// getFileReader opens a file at the given path and returns an io.ReadCloser,
+// the corresponding file's filesize, and a http status.
+func getFileReader(path string) (io.ReadCloser, int64, error) {
+	return openFile(path)
+}
+
+func openFile(path string) (*os.File, int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get stats for file at path %s: %w", path, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file at path %s: %w", path, err)
+	}
+
+	return f, info.Size(), nil
+}
+
+type closerReaderWrapper struct {
+	io.Reader
+	io.Closer
+}
+
+func getFileReaderAt(path string, offset int64, length int64) (io.ReadCloser, error) {
+	f, fSize, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if fSize < int64(offset+length) {
+		return nil, fmt.Errorf("failed to read file %s at offset %d, length %d: %w", path, offset, length, ErrReadOutOfBounds)
+	}
+
+	_, err = f.Seek(int64(offset), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seek file at path %s to offset %d: %w", path, offset, err)
+	}
+
+	r := closerReaderWrapper{io.LimitReader(f, length), f}
+	return r, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesrv
+
+import (
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/nbs"
+)
+
+// RemoteSrvStore is a chunk store that can be served by the remotesapi. Its table files are read and written in the
+// local directory returned by Path.
+type RemoteSrvStore interface {
+	chunks.ChunkStore
+	nbs.TableFileStore
+
+	Path() (string, bool)
+	GetChunkLocationsWithPaths(hashes hash.HashSet) (map[string]map[hash.Hash]nbs.Range, error)
+}
+
+var _ RemoteSrvStore = (*nbs.NomsBlockStore)(nil)
+var _ RemoteSrvStore = (*nbs.GenerationalNBS)(nil)
+
+// DBCache returns the store of the repository |repo| of the organization |org|. |org| is empty for servers that
+// address their repositories by name only, such as a sql-server serving its databases.
+type DBCache interface {
+	Get(org, repo, nbfVerStr string) (RemoteSrvStore, error)
+}
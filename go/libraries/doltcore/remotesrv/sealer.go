@@ -0,0 +1,115 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesrv
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ErrInvalidSealedURL is returned when a url given to the http endpoint wasn't sealed by the server, or has expired.
+var ErrInvalidSealedURL = errors.New("invalid or expired table file url")
+
+// sealedURLTTL is how long a sealed url can be used for. Clients are told to refresh urls halfway through it.
+const sealedURLTTL = 15 * time.Minute
+
+const (
+	sealExpiresParam   = "exp"
+	sealSignatureParam = "sig"
+)
+
+// URLSealer seals the table file urls handed out by the grpc endpoint, so that the http endpoint of servers that
+// authenticate their clients only serves the urls that were handed out to authenticated requests.
+type URLSealer interface {
+	// Seal returns |u| sealed so that it can be unsealed until it expires.
+	Seal(u *url.URL) (*url.URL, error)
+	// Unseal returns the url that was sealed into |u|, or ErrInvalidSealedURL.
+	Unseal(u *url.URL) (*url.URL, error)
+}
+
+// identitySealer is the URLSealer of servers that don't authenticate their clients. It hands out urls as they are.
+type identitySealer struct{}
+
+func (identitySealer) Seal(u *url.URL) (*url.URL, error) {
+	return u, nil
+}
+
+func (identitySealer) Unseal(u *url.URL) (*url.URL, error) {
+	return u, nil
+}
+
+// singleSymmetricKeySealer signs the path and expiry of urls with a key that's generated for the lifetime of the
+// server, so sealed urls stop working when the server restarts.
+type singleSymmetricKeySealer struct {
+	key []byte
+}
+
+// NewSingleSymmetricKeySealer returns a URLSealer that signs urls with a random key.
+func NewSingleSymmetricKeySealer() (URLSealer, error) {
+	key := make([]byte, sha256.Size)
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	return singleSymmetricKeySealer{key: key}, nil
+}
+
+func (s singleSymmetricKeySealer) Seal(u *url.URL) (*url.URL, error) {
+	exp := strconv.FormatInt(time.Now().Add(sealedURLTTL).Unix(), 10)
+
+	q := url.Values{}
+	q.Set(sealExpiresParam, exp)
+	q.Set(sealSignatureParam, base64.RawURLEncoding.EncodeToString(s.sign(u.Path, exp)))
+
+	sealed := *u
+	sealed.RawQuery = q.Encode()
+	return &sealed, nil
+}
+
+func (s singleSymmetricKeySealer) Unseal(u *url.URL) (*url.URL, error) {
+	q := u.Query()
+	exp := q.Get(sealExpiresParam)
+	sig, err := base64.RawURLEncoding.DecodeString(q.Get(sealSignatureParam))
+	if err != nil || exp == "" {
+		return nil, ErrInvalidSealedURL
+	}
+
+	if !hmac.Equal(sig, s.sign(u.Path, exp)) {
+		return nil, ErrInvalidSealedURL
+	}
+
+	expSecs, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().After(time.Unix(expSecs, 0)) {
+		return nil, ErrInvalidSealedURL
+	}
+
+	unsealed := *u
+	unsealed.RawQuery = ""
+	return &unsealed, nil
+}
+
+func (s singleSymmetricKeySealer) sign(path, exp string) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{0})
+	mac.Write([]byte(exp))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,173 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotesrv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+
+	remotesapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+)
+
+type Server struct {
+	wg       sync.WaitGroup
+	stopChan chan struct{}
+
+	grpcPort int
+	grpcSrv  *grpc.Server
+
+	httpPort int
+	httpSrv  http.Server
+}
+
+type ServerArgs struct {
+	Logger *logrus.Entry
+	// HttpHost is the host clients are told to transfer table files at. If it's empty or only holds a port, such as
+	// ":50051", the host clients addressed their request to is used.
+	HttpHost string
+	HttpPort int
+	GrpcPort int
+	DBCache  DBCache
+	// Sealer seals the table file urls handed out to clients. Servers that authenticate their grpc requests need one
+	// so that their http endpoint only serves the urls handed out to authenticated requests. If it's nil, urls aren't
+	// sealed.
+	Sealer  URLSealer
+	Options []grpc.ServerOption
+}
+
+// NewServer returns a remotesapi server for the stores of |args.DBCache|. If the http and grpc ports of |args| are the
+// same, both are served from a single listener.
+func NewServer(args ServerArgs) *Server {
+	if args.Logger == nil {
+		args.Logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	if args.Sealer == nil {
+		args.Sealer = identitySealer{}
+	}
+
+	s := new(Server)
+	s.stopChan = make(chan struct{})
+
+	expectedFiles := newFileDetails()
+
+	s.grpcPort = args.GrpcPort
+	s.grpcSrv = grpc.NewServer(append([]grpc.ServerOption{grpc.MaxRecvMsgSize(128 * 1024 * 1024)}, args.Options...)...)
+	var chnkSt remotesapi.ChunkStoreServiceServer = NewHttpFSBackedChunkStore(args.Logger, args.HttpHost, args.DBCache, expectedFiles, args.Sealer)
+	remotesapi.RegisterChunkStoreServiceServer(s.grpcSrv, chnkSt)
+
+	var handler http.Handler = newFileHandler(args.Logger, args.DBCache, expectedFiles, args.Sealer)
+	if args.HttpPort == args.GrpcPort {
+		handler = grpcMultiplexHandler(s.grpcSrv, handler)
+	}
+
+	s.httpPort = args.HttpPort
+	s.httpSrv = http.Server{
+		Addr:    fmt.Sprintf(":%d", args.HttpPort),
+		Handler: handler,
+	}
+
+	return s
+}
+
+// grpcMultiplexHandler returns a handler that serves grpc requests with |grpcSrv| and all other requests with
+// |handler|, over both http/1.1 and cleartext http/2.
+func grpcMultiplexHandler(grpcSrv *grpc.Server, handler http.Handler) http.Handler {
+	h2s := &http2.Server{}
+	newHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcSrv.ServeHTTP(w, r)
+		} else {
+			handler.ServeHTTP(w, r)
+		}
+	})
+	return h2c.NewHandler(newHandler, h2s)
+}
+
+type Listeners struct {
+	http net.Listener
+	grpc net.Listener
+}
+
+// Listeners opens the listeners of the server. It's separate from Serve so that callers can report a port that is
+// already in use before starting the server in the background.
+func (s *Server) Listeners() (Listeners, error) {
+	httpListener, err := net.Listen("tcp", s.httpSrv.Addr)
+	if err != nil {
+		return Listeners{}, err
+	}
+	if s.httpPort == s.grpcPort {
+		return Listeners{http: httpListener}, nil
+	}
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.grpcPort))
+	if err != nil {
+		httpListener.Close()
+		return Listeners{}, err
+	}
+	return Listeners{http: httpListener, grpc: grpcListener}, nil
+}
+
+// Close closes the listeners, for callers that end up not serving them.
+func (l Listeners) Close() error {
+	err := l.http.Close()
+	if l.grpc != nil {
+		if gerr := l.grpc.Close(); err == nil {
+			err = gerr
+		}
+	}
+	return err
+}
+
+// Serve serves requests on |listeners| until GracefulStop is called.
+func (s *Server) Serve(listeners Listeners) {
+	if listeners.grpc != nil {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			logrus.Println("Starting grpc server on port", s.grpcPort)
+			err := s.grpcSrv.Serve(listeners.grpc)
+			logrus.Println("grpc server exited. error:", err)
+		}()
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		logrus.Println("Starting http server on port", s.httpPort)
+		err := s.httpSrv.Serve(listeners.http)
+		if !errors.Is(err, http.ErrServerClosed) {
+			logrus.Println("http server exited. exit error:", err)
+		}
+	}()
+
+	<-s.stopChan
+	s.httpSrv.Shutdown(context.Background())
+	s.grpcSrv.GracefulStop()
+	s.wg.Wait()
+}
+
+// GracefulStop stops the server, waiting for the requests in flight to finish.
+func (s *Server) GracefulStop() {
+	close(s.stopChan)
+}
@@ -118,14 +118,23 @@ type DoltChunkStore struct {
 
 func NewDoltChunkStoreFromPath(ctx context.Context, nbf *types.NomsBinFormat, path, host string, csClient remotesapi.ChunkStoreServiceClient) (*DoltChunkStore, error) {
 	tokens := strings.Split(strings.Trim(path, "/"), "/")
-	if len(tokens) != 2 {
-		return nil, ErrInvalidDoltSpecPath
-	}
 
 	// todo:
 	// this may just be a dolthub thing.  Need to revisit how we do this.
-	org := tokens[0]
-	repoName := tokens[1]
+	var org, repoName string
+	switch len(tokens) {
+	case 1:
+		if tokens[0] == "" {
+			return nil, ErrInvalidDoltSpecPath
+		}
+		// servers that address their repositories by name only, such as a sql-server serving its databases
+		repoName = tokens[0]
+	case 2:
+		org = tokens[0]
+		repoName = tokens[1]
+	default:
+		return nil, ErrInvalidDoltSpecPath
+	}
 
 	return NewDoltChunkStore(ctx, nbf, org, repoName, host, csClient)
 }
@@ -403,7 +412,7 @@ func sortRangesBySize(ranges []*GetRange) {
 
 type resourcePathToUrlFunc func(ctx context.Context, lastError error, resourcePath string) (url string, err error)
 
-func (gr *GetRange) GetDownloadFunc(ctx context.Context, stats StatsRecorder, fetcher HTTPFetcher, chunkChan chan nbs.CompressedChunk, pathToUrl resourcePathToUrlFunc) func() error {
+func (gr *GetRange) GetDownloadFunc(ctx context.Context, stats StatsRecorder, fetcher HTTPFetcher, chunkChan chan nbs.CompressedChunk, pathToUrl resourcePathToUrlFunc, dicts *tableFileDictionaries) func() error {
 	if len(gr.Ranges) == 0 {
 		return func() error { return nil }
 	}
@@ -429,10 +438,11 @@ func (gr *GetRange) GetDownloadFunc(ctx context.Context, stats StatsRecorder, fe
 		if err != nil {
 			return err
 		}
+		resolve := dicts.resolver(ctx, stats, fetcher, gr.ResourcePath(), urlF)
 		// Send the chunk for each range included in GetRange.
 		for i := 0; i < len(gr.Ranges); i++ {
 			s, e := gr.ChunkByteRange(i)
-			cmpChnk, err := nbs.NewCompressedChunk(hash.New(gr.Ranges[i].Hash), comprData[s:e])
+			cmpChnk, err := nbs.NewCompressedChunkWithDictionary(hash.New(gr.Ranges[i].Hash), comprData[s:e], resolve)
 			if err != nil {
 				return err
 			}
@@ -491,21 +501,104 @@ func (r *locationRefresh) GetURL(ctx context.Context, lastError error, client re
 	return r.URL, nil
 }
 
+// tableFileDictionaries downloads the dictionaries of the table files that chunks are downloaded from. Each one is
+// downloaded once, when the first chunk that's compressed with it is downloaded.
+type tableFileDictionaries struct {
+	ranges map[string]map[hash.Hash]*remotesapi.RangeChunk
+
+	mu        sync.Mutex
+	downloads map[string]map[uint32]*dictionaryDownload
+}
+
+type dictionaryDownload struct {
+	once sync.Once
+	dict *nbs.Dictionary
+	err  error
+}
+
+func newTableFileDictionaries() *tableFileDictionaries {
+	return &tableFileDictionaries{
+		ranges:    make(map[string]map[hash.Hash]*remotesapi.RangeChunk),
+		downloads: make(map[string]map[uint32]*dictionaryDownload),
+	}
+}
+
+func (d *tableFileDictionaries) add(resourcePath string, r *remotesapi.RangeChunk) {
+	if d.ranges[resourcePath] == nil {
+		d.ranges[resourcePath] = make(map[hash.Hash]*remotesapi.RangeChunk)
+	}
+	d.ranges[resourcePath][hash.New(r.Hash)] = r
+}
+
+func (d *tableFileDictionaries) download(resourcePath string, id uint32) *dictionaryDownload {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.downloads[resourcePath] == nil {
+		d.downloads[resourcePath] = make(map[uint32]*dictionaryDownload)
+	}
+	dl, ok := d.downloads[resourcePath][id]
+	if !ok {
+		dl = &dictionaryDownload{}
+		d.downloads[resourcePath][id] = dl
+	}
+	return dl
+}
+
+// resolver returns the DictionaryResolver of the chunks downloaded from the table file at |resourcePath|.
+func (d *tableFileDictionaries) resolver(ctx context.Context, stats StatsRecorder, fetcher HTTPFetcher, resourcePath string, urlF urlFactoryFunc) nbs.DictionaryResolver {
+	return func(id uint32) (*nbs.Dictionary, error) {
+		var r *remotesapi.RangeChunk
+		for h, rng := range d.ranges[resourcePath] {
+			if nbs.DictionaryID(h) == id {
+				r = rng
+				break
+			}
+		}
+		if r == nil {
+			return nil, nbs.ErrMissingDictionary
+		}
+
+		dl := d.download(resourcePath, id)
+		dl.once.Do(func() {
+			var data []byte
+			data, dl.err = rangeDownloadWithRetries(ctx, stats, fetcher, r.Offset, uint64(r.Length), 1, urlF)
+			if dl.err != nil {
+				return
+			}
+			dl.dict, dl.err = nbs.NewDictionary(hash.New(r.Hash), data)
+		})
+		return dl.dict, dl.err
+	}
+}
+
 type dlLocations struct {
 	ranges    map[string]*GetRange
 	refreshes map[string]*locationRefresh
+	dicts     *tableFileDictionaries
 }
 
 func newDlLocations() dlLocations {
 	return dlLocations{
 		ranges:    make(map[string]*GetRange),
 		refreshes: make(map[string]*locationRefresh),
+		dicts:     newTableFileDictionaries(),
 	}
 }
 
 func (l *dlLocations) Add(resp *remotesapi.DownloadLoc) {
 	gr := (*GetRange)(resp.Location.(*remotesapi.DownloadLoc_HttpGetRange).HttpGetRange)
 	path := gr.ResourcePath()
+
+	// the ranges of dictionaries are only downloaded if the chunks downloaded from their table file need them
+	var chunkRanges []*remotesapi.RangeChunk
+	for _, r := range gr.Ranges {
+		if nbs.IsDictionary(hash.New(r.Hash)) {
+			l.dicts.add(path, r)
+		} else {
+			chunkRanges = append(chunkRanges, r)
+		}
+	}
+	gr = &GetRange{Url: gr.Url, Ranges: chunkRanges}
 	if v, ok := l.ranges[path]; ok {
 		v.Append(gr)
 		l.refreshes[path].Add(resp)
@@ -891,9 +984,16 @@ func (dcs *DoltChunkStore) uploadChunks(ctx context.Context) (map[hash.Hash]int,
 			return map[hash.Hash]int{}, err
 		}
 
+		// the table file can hold dictionary records along with the chunks
+		count, _, err := nbs.ReadTableFooter(bytes.NewReader(data))
+
+		if err != nil {
+			return map[hash.Hash]int{}, err
+		}
+
 		h := hash.Parse(name)
 		hashToData[h] = data
-		hashToCount[h] = len(chnks)
+		hashToCount[h] = int(count)
 
 		md5Bytes := md5.Sum(data)
 		hashToContentHash[h] = md5Bytes[:]
@@ -1067,7 +1167,7 @@ func (dcs *DoltChunkStore) downloadChunks(ctx context.Context, dlLocs dlLocation
 		return dlLocs.refreshes[resourcePath].GetURL(ctx, lastError, dcs.csClient)
 	}
 
-	stats := StatsFactory()
+	stats := requestStatsRecorder{StatsFactory(), repoLabel(dcs.getRepoId())}
 
 	eg, ctx := errgroup.WithContext(ctx)
 
@@ -1075,7 +1175,7 @@ func (dcs *DoltChunkStore) downloadChunks(ctx context.Context, dlLocs dlLocation
 	work := make([]func() error, len(gets))
 	largeCutoff := -1
 	for i, get := range gets {
-		work[i] = get.GetDownloadFunc(ctx, stats, dcs.httpFetcher, chunkChan, toUrl)
+		work[i] = get.GetDownloadFunc(ctx, stats, dcs.httpFetcher, chunkChan, toUrl, dlLocs.dicts)
 		if get.RangeLen() >= uint64(dcs.concurrency.LargeFetchSize) {
 			largeCutoff = i
 		}
@@ -47,6 +47,38 @@ func init() {
 	}
 }
 
+// RequestStats records the latency of the requests of DoltChunkStores to their remotes. It does nothing by default,
+// and is set by servers that export metrics.
+var RequestStats RequestStatsRecorder = NullRequestStatsRecorder{}
+
+// downloadMethod is the method RequestStats records the downloads of table file ranges with.
+const downloadMethod = "Download"
+
+type RequestStatsRecorder interface {
+	// RecordRequest is called with the latency of a request to the remote repository |repo|. |method| is the
+	// ChunkStoreService method of the request, or "Download" for the download of a range of a table file.
+	RecordRequest(repo, method string, d time.Duration, err error)
+}
+
+var _ RequestStatsRecorder = NullRequestStatsRecorder{}
+
+type NullRequestStatsRecorder struct {
+}
+
+func (NullRequestStatsRecorder) RecordRequest(repo, method string, d time.Duration, err error) {
+}
+
+// requestStatsRecorder is a StatsRecorder that also records the completed downloads of |repo| to RequestStats.
+type requestStatsRecorder struct {
+	StatsRecorder
+	repo string
+}
+
+func (r requestStatsRecorder) RecordDownloadComplete(hedge, retry int, size uint64, d time.Duration) {
+	r.StatsRecorder.RecordDownloadComplete(hedge, retry, size, d)
+	RequestStats.RecordRequest(r.repo, downloadMethod, d, nil)
+}
+
 type StatsRecorder interface {
 	RecordTimeToFirstByte(hedge, retry int, size uint64, d time.Duration)
 	RecordDownloadAttemptStart(hedge, retry int, offset, size uint64)
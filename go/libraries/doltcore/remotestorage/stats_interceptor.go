@@ -0,0 +1,48 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remotestorage
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"google.golang.org/grpc"
+
+	remotesapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+)
+
+// StatsUnaryClientInterceptor records the latency of every ChunkStoreService request to RequestStats, labeled by the
+// repository of the request.
+func StatsUnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+
+	repo := ""
+	if r, ok := req.(interface{ GetRepoId() *remotesapi.RepoId }); ok {
+		repo = repoLabel(r.GetRepoId())
+	}
+	RequestStats.RecordRequest(repo, path.Base(method), time.Since(start), err)
+
+	return err
+}
+
+// repoLabel returns the name of the repository |id| used to label its stats
+func repoLabel(id *remotesapi.RepoId) string {
+	if id.GetOrg() == "" {
+		return id.GetRepoName()
+	}
+	return id.GetOrg() + "/" + id.GetRepoName()
+}
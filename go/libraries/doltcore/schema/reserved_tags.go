@@ -108,3 +108,16 @@ const (
 	DoltConflictsOurCardinalityTag
 	DoltConflictsTheirCardinalityTag
 )
+
+// Tags for the dolt_ignore table
+const (
+	DoltIgnorePatternTag = iota + SystemTableReservedMin + uint64(8000)
+	DoltIgnoreIgnoredTag
+)
+
+// Tags for the dolt_migrations table
+const (
+	DoltMigrationsVersionTag = iota + SystemTableReservedMin + uint64(9000)
+	DoltMigrationsNameTag
+	DoltMigrationsChecksumTag
+)
@@ -0,0 +1,51 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dprocedures"
+)
+
+// RegisterStoredProcedures adds the procedures that manage the role of the server to the procedures of the engine of
+// |registry|. They may only be called by users that have the global SUPER privilege in |privileges|.
+func (c *Controller) RegisterStoredProcedures(registry dprocedures.ProcedureRegistry, privileges *mysql_db.MySQLDb) {
+	registry.RegisterExternalStoredProcedure(sql.ExternalStoredProcedureDetails{
+		Name:   "dolt_assume_cluster_role",
+		Schema: sql.Schema{{Name: "status", Type: sql.Int64, Nullable: false}},
+		Function: func(ctx *sql.Context, role string, epoch int) (sql.RowIter, error) {
+			return c.assumeClusterRole(ctx, privileges, role, epoch)
+		},
+	})
+}
+
+// assumeClusterRole is the implementation of dolt_assume_cluster_role(role, epoch). The epoch must be higher than
+// the current epoch of the server, unless the server already has the role at that epoch. A primary that becomes a
+// standby first waits for its standbys to acknowledge every write it accepted, and fails if they don't.
+func (c *Controller) assumeClusterRole(ctx *sql.Context, privileges *mysql_db.MySQLDb, role string, epoch int) (sql.RowIter, error) {
+	// changing the role fails the cluster over, so it's limited to the administrators of the server
+	err := dprocedures.CheckGlobalPrivilege(ctx, privileges, sql.PrivilegeType_Super)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.setRoleAndEpoch(role, epoch, true)
+	if err != nil {
+		return nil, err
+	}
+	return sql.RowsToRowIter(sql.Row{int64(0)}), nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testProcedureRegistry map[string]sql.ExternalStoredProcedureDetails
+
+func (r testProcedureRegistry) RegisterExternalStoredProcedure(proc sql.ExternalStoredProcedureDetails) {
+	r[proc.Name] = proc
+}
+
+func TestAssumeClusterRolePrivileges(t *testing.T) {
+	privileges := mysql_db.CreateEmptyMySQLDb()
+	privileges.AddRootAccount()
+	privSet := mysql_db.NewPrivilegeSet()
+	privSet.AddGlobalStatic(sql.PrivilegeType_Select, sql.PrivilegeType_Create, sql.PrivilegeType_Alter)
+	require.NoError(t, privileges.LoadPrivilegeData(sql.NewEmptyContext(), []*mysql_db.User{
+		{User: "dba", Host: "localhost", PrivilegeSet: privSet},
+	}, nil))
+
+	c := newTestController(t, RolePrimary, 1)
+	registry := make(testProcedureRegistry)
+	c.RegisterStoredProcedures(registry, privileges)
+	assumeRole := registry["dolt_assume_cluster_role"].Function.(func(*sql.Context, string, int) (sql.RowIter, error))
+	ctxFor := func(userName string) *sql.Context {
+		sess := sql.NewBaseSessionWithClientServer("", sql.Client{User: userName, Address: "localhost"}, 1)
+		return sql.NewContext(context.Background(), sql.WithSession(sess))
+	}
+
+	for _, userName := range []string{"dba", "nobody"} {
+		_, err := assumeRole(ctxFor(userName), string(RoleStandby), 2)
+		assert.True(t, sql.ErrPrivilegeCheckFailed.Is(err), userName)
+		role, epoch := c.roleAndEpoch()
+		assert.Equal(t, RolePrimary, role)
+		assert.Equal(t, 1, epoch)
+	}
+
+	_, err := assumeRole(ctxFor("root"), string(RoleStandby), 2)
+	require.NoError(t, err)
+	role, epoch := c.roleAndEpoch()
+	assert.Equal(t, RoleStandby, role)
+	assert.Equal(t, 2, epoch)
+}
@@ -0,0 +1,294 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/sirupsen/logrus"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/datas/pull"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+var ErrReplicationAckTimeout = errors.New("cluster: timed out waiting for a standby to acknowledge a write")
+
+// ReplicationAckTimeoutWarningCode is the code of the warning a session gets when a standby doesn't acknowledge its
+// write in time. Since this our own custom warning we'll use 1105, the code for an unknown error.
+const ReplicationAckTimeoutWarningCode int = 1105
+
+const (
+	// replicationChunksPerTF is the number of chunks written to each table file pushed to a standby.
+	replicationChunksPerTF = 256 * 1024
+	// replicationRetryInterval is how long the replication of a database waits after failing to push to a standby.
+	replicationRetryInterval = time.Second
+)
+
+// commithook replicates a database to one standby. Unlike the push-on-write hooks, which push the branch that was
+// written, it replicates the root of the database's chunk store, so that the standby holds all of the primary's
+// branches, tags and working sets. Every write bumps the generation the standby needs to catch up to, and a
+// background thread pushes the latest root of the database until the standby acknowledges the latest generation.
+type commithook struct {
+	lgr        *logrus.Entry
+	remotename string
+	dbname     string
+	tempDir    string
+
+	srcDB  datas.Database
+	destDB func(ctx context.Context) (*doltdb.DoltDB, error)
+
+	ackSync    bool
+	ackTimeout time.Duration
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// role is the role of this server. Writes are only replicated while it's the primary.
+	role Role
+	// wantGen is the generation of the latest write to the database, and ackedGen the latest generation the standby
+	// acknowledged.
+	wantGen  uint64
+	ackedGen uint64
+	// pendingSince is the time of the first write the standby hasn't acknowledged, zero if it's caught up.
+	pendingSince time.Time
+	// lastErr is the error of the last push to the standby, nil if it succeeded.
+	lastErr error
+}
+
+var _ doltdb.CommitHook = (*commithook)(nil)
+
+func newCommitHook(lgr *logrus.Entry, remotename, dbname string, role Role, tempDir string, srcDB datas.Database, destDB func(ctx context.Context) (*doltdb.DoltDB, error), ackSync bool, ackTimeout time.Duration) *commithook {
+	h := &commithook{
+		lgr:        lgr.WithField("database", dbname).WithField("standby", remotename),
+		remotename: remotename,
+		dbname:     dbname,
+		tempDir:    tempDir,
+		srcDB:      srcDB,
+		destDB:     destDB,
+		ackSync:    ackSync,
+		ackTimeout: ackTimeout,
+		role:       role,
+		// the standby is pushed to once on startup, catching it up with writes that happened while it was unreachable
+		wantGen:      1,
+		pendingSince: time.Now(),
+	}
+	h.cond = sync.NewCond(&h.mu)
+	return h
+}
+
+// Run replicates the database to the standby until |ctx| is done.
+func (h *commithook) Run(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.cond.Broadcast()
+	}()
+
+	for {
+		h.mu.Lock()
+		for ctx.Err() == nil && (h.role != RolePrimary || h.ackedGen >= h.wantGen) {
+			h.cond.Wait()
+		}
+		if ctx.Err() != nil {
+			h.mu.Unlock()
+			return
+		}
+		gen := h.wantGen
+		start := time.Now()
+		h.mu.Unlock()
+
+		err := h.replicate(ctx)
+
+		h.mu.Lock()
+		h.lastErr = err
+		if err == nil && gen > h.ackedGen {
+			h.ackedGen = gen
+		}
+		if err == nil {
+			doltdb.ReplicationStats.RecordPush(h.dbname, "", time.Since(h.pendingSince))
+			// writes after |gen| happened after the push started, at the earliest
+			h.pendingSince = time.Time{}
+			if h.ackedGen < h.wantGen {
+				h.pendingSince = start
+			}
+		}
+		h.cond.Broadcast()
+		h.mu.Unlock()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			doltdb.ReplicationStats.RecordPushFailure(h.dbname, "")
+			h.lgr.Warnf("cluster: failed to replicate to standby: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(replicationRetryInterval):
+			}
+		}
+	}
+}
+
+// replicate pushes the current root of the database, and every chunk reachable from it, to the standby.
+func (h *commithook) replicate(ctx context.Context) error {
+	destDB, err := h.destDB(ctx)
+	if err != nil {
+		return err
+	}
+
+	srcCS := datas.ChunkStoreFromDatabase(h.srcDB)
+	destCS := datas.ChunkStoreFromDatabase(doltdb.HackDatasDatabaseFromDoltDB(destDB))
+
+	err = destCS.Rebase(ctx)
+	if err != nil {
+		return err
+	}
+	destRoot, err := destCS.Root(ctx)
+	if err != nil {
+		return err
+	}
+	srcRoot, err := srcCS.Root(ctx)
+	if err != nil {
+		return err
+	}
+	if srcRoot == destRoot {
+		return nil
+	}
+
+	waf, err := types.WalkAddrsForChunkStore(srcCS)
+	if err != nil {
+		return err
+	}
+	puller, err := pull.NewPuller(ctx, h.tempDir, replicationChunksPerTF, srcCS, destCS, waf, srcRoot, nil)
+	if err != nil && err != pull.ErrDBUpToDate {
+		return err
+	}
+	if err != pull.ErrDBUpToDate {
+		err = puller.Pull(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	ok, err := destCS.Commit(ctx, srcRoot, destRoot)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("the root of the standby changed from %s while replicating to it", destRoot.String())
+	}
+
+	h.lgr.Tracef("cluster: replicated root %s to standby", srcRoot.String())
+	return nil
+}
+
+// setRole sets the role of this server. While it's the primary, the standby is caught up with every write.
+func (h *commithook) setRole(role Role) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if role == RolePrimary && h.role != RolePrimary {
+		h.bumpGen()
+	}
+	h.role = role
+	h.cond.Broadcast()
+}
+
+// waitForCaughtUp blocks until the standby has acknowledged every write to the database, returning an error if it
+// hasn't within |timeout|.
+func (h *commithook) waitForCaughtUp(timeout time.Duration) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.bumpGen()
+	h.cond.Broadcast()
+	return h.waitForAck(h.wantGen, timeout)
+}
+
+// bumpGen records a write the standby needs to catch up to. It must be called with |h.mu| held.
+func (h *commithook) bumpGen() {
+	h.wantGen++
+	if h.pendingSince.IsZero() {
+		h.pendingSince = time.Now()
+	}
+}
+
+// waitForAck blocks until the standby has acknowledged generation |gen|, returning an error if it hasn't within
+// |timeout|. It must be called with |h.mu| held.
+func (h *commithook) waitForAck(gen uint64, timeout time.Duration) error {
+	timer := time.AfterFunc(timeout, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.cond.Broadcast()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+	for h.ackedGen < gen && h.role == RolePrimary {
+		if !time.Now().Before(deadline) {
+			if h.lastErr != nil {
+				return fmt.Errorf("%w: standby %s: %v", ErrReplicationAckTimeout, h.remotename, h.lastErr)
+			}
+			return fmt.Errorf("%w: standby %s", ErrReplicationAckTimeout, h.remotename)
+		}
+		h.cond.Wait()
+	}
+	return nil
+}
+
+// Execute implements doltdb.CommitHook. With synchronous replication, it blocks until the standby acknowledges the
+// write or the replication timeout passes, in which case it returns ErrReplicationAckTimeout. The write is committed
+// on this server either way, and keeps being replicated to the standby in the background.
+func (h *commithook) Execute(ctx context.Context, ds datas.Dataset, db datas.Database) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.role != RolePrimary {
+		return nil
+	}
+	h.bumpGen()
+	h.cond.Broadcast()
+	if !h.ackSync {
+		return nil
+	}
+	return h.waitForAck(h.wantGen, h.ackTimeout)
+}
+
+// HandleError implements doltdb.CommitHook. Since the write is already committed, a standby failing to acknowledge
+// it doesn't fail the transaction. Instead, the session that committed it gets a warning that it may not have been
+// replicated.
+func (h *commithook) HandleError(ctx context.Context, err error) error {
+	h.lgr.Warn(err.Error())
+	if sqlCtx, ok := ctx.(*sql.Context); ok && errors.Is(err, ErrReplicationAckTimeout) {
+		sqlCtx.Warn(ReplicationAckTimeoutWarningCode, "the write was committed, but may not have been replicated: %s", err.Error())
+	}
+	return nil
+}
+
+// SetLogger implements doltdb.CommitHook
+func (h *commithook) SetLogger(ctx context.Context, wr io.Writer) error {
+	return nil
+}
+
+// ExecuteForWorkingSets implements doltdb.CommitHook. Working sets are replicated so that uncommitted writes
+// survive a failover.
+func (h *commithook) ExecuteForWorkingSets() bool {
+	return true
+}
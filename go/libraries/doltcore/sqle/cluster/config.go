@@ -0,0 +1,67 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"time"
+)
+
+// Config is the cluster configuration of a sql-server. Every server of a cluster names the other servers as its
+// standby remotes, and only the server holding the primary role replicates its writes to them.
+type Config interface {
+	// StandbyRemotes returns the servers the writes of this server are replicated to while it's the primary.
+	StandbyRemotes() []StandbyRemoteConfig
+	// BootstrapRole is the role of this server the first time it's started, "primary" or "standby".
+	BootstrapRole() string
+	// BootstrapEpoch is the epoch of the role of this server the first time it's started.
+	BootstrapEpoch() int
+	// ReplicationAck is how long writes wait for the standbys, "sync" or "async".
+	ReplicationAck() string
+	// ReplicationAckTimeout is how long a write waits for the standbys to acknowledge it when ReplicationAck is "sync".
+	ReplicationAckTimeout() time.Duration
+	// RemotesAPIConfig is the configuration of the remotesapi endpoint the standbys are replicated to through.
+	RemotesAPIConfig() RemotesAPIConfig
+}
+
+// StandbyRemoteConfig names another server of a cluster.
+type StandbyRemoteConfig interface {
+	Name() string
+	// RemoteURLTemplate is the url of the server's remotesapi endpoint, in which "{database}" is replaced with the
+	// name of each database that's replicated, such as "http://standby:50051/{database}".
+	RemoteURLTemplate() string
+}
+
+// RemotesAPIConfig is the configuration of the remotesapi endpoint of a server of a cluster.
+type RemotesAPIConfig interface {
+	Port() int
+	// SharedSecret is the secret every server of the cluster is configured with. Servers only trust the role and
+	// epoch of peers that send it, and only serve the requests of those peers.
+	SharedSecret() string
+}
+
+type Role string
+
+const (
+	RolePrimary Role = "primary"
+	RoleStandby Role = "standby"
+)
+
+const (
+	ReplicationAckSync  = "sync"
+	ReplicationAckAsync = "async"
+)
+
+// DatabaseTemplate is the placeholder of RemoteURLTemplate that is replaced with the name of the database.
+const DatabaseTemplate = "{database}"
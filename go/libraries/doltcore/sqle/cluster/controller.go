@@ -0,0 +1,351 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/grpcendpoint"
+	"github.com/dolthub/dolt/go/libraries/doltcore/remotesrv"
+	"github.com/dolthub/dolt/go/libraries/utils/config"
+	"github.com/dolthub/dolt/go/store/datas"
+	"github.com/dolthub/dolt/go/store/types"
+)
+
+const (
+	DoltClusterRoleVariable      = "dolt_cluster_role"
+	DoltClusterRoleEpochVariable = "dolt_cluster_role_epoch"
+
+	persistentRoleKey  = "cluster_role"
+	persistentEpochKey = "cluster_role_epoch"
+
+	// roleTransitionTimeout is how long a primary that's becoming a standby waits for its standbys to catch up.
+	roleTransitionTimeout = 5 * time.Second
+)
+
+var ErrWriteToStandby = errors.New("this server is a standby in a cluster and does not accept writes")
+
+// Controller manages the role of a sql-server in a cluster. While it's the primary, it replicates every write of its
+// databases to the standbys, and while it's a standby, it refuses writes and accepts the replicated writes of the
+// primary through its remotesapi endpoint. Every role is held at an epoch, which only increases, and a server
+// never follows a primary of a lower epoch than its own.
+type Controller struct {
+	cfg           Config
+	persistentCfg config.ReadWriteConfig
+	lgr           *logrus.Entry
+
+	// transitionMu serializes changes of the role, which release |mu| while waiting for the standbys.
+	transitionMu sync.Mutex
+	mu           sync.Mutex
+	role         Role
+	epoch        int
+	commithooks  []*commithook
+
+	cinterceptor clientinterceptor
+	sinterceptor serverinterceptor
+}
+
+// NewController returns the controller of a server with the cluster configuration |cfg|, or nil if |cfg| is nil.
+// The role and epoch of the server are persisted in |pCfg|, and only taken from |cfg| the first time the server is
+// started.
+func NewController(lgr *logrus.Logger, cfg Config, pCfg config.ReadWriteConfig) (*Controller, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	role, epoch, err := applyBootstrapClusterConfig(cfg, pCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Controller{
+		cfg:           cfg,
+		persistentCfg: pCfg,
+		lgr:           logrus.NewEntry(lgr).WithField("component", "cluster"),
+		role:          role,
+		epoch:         epoch,
+	}
+	c.cinterceptor = clientinterceptor{lgr: c.lgr, controller: c}
+	c.sinterceptor = serverinterceptor{lgr: c.lgr, controller: c}
+	c.setSystemVariables()
+	return c, nil
+}
+
+// applyBootstrapClusterConfig returns the persisted role and epoch of the server, persisting the bootstrap role and
+// epoch of |cfg| if there are none.
+func applyBootstrapClusterConfig(cfg Config, pCfg config.ReadWriteConfig) (Role, int, error) {
+	toset := make(map[string]string)
+	persistentRole := pCfg.GetStringOrDefault(persistentRoleKey, "")
+	persistentEpoch := pCfg.GetStringOrDefault(persistentEpochKey, "")
+	if persistentRole == "" {
+		persistentRole = cfg.BootstrapRole()
+		toset[persistentRoleKey] = persistentRole
+	}
+	if persistentEpoch == "" {
+		persistentEpoch = strconv.Itoa(cfg.BootstrapEpoch())
+		toset[persistentEpochKey] = persistentEpoch
+	}
+	if len(toset) > 0 {
+		err := pCfg.SetStrings(toset)
+		if err != nil {
+			return "", 0, err
+		}
+	}
+
+	role := Role(persistentRole)
+	if role != RolePrimary && role != RoleStandby {
+		return "", 0, fmt.Errorf("persisted cluster role %s is not primary or standby", persistentRole)
+	}
+	epoch, err := strconv.Atoi(persistentEpoch)
+	if err != nil {
+		return "", 0, fmt.Errorf("persisted cluster role epoch %s is not a number: %w", persistentEpoch, err)
+	}
+	return role, epoch, nil
+}
+
+// setSystemVariables defines the read-only system variables holding the role and epoch of the server.
+func (c *Controller) setSystemVariables() {
+	sql.SystemVariables.AddSystemVariables([]sql.SystemVariable{
+		{
+			Name:              DoltClusterRoleVariable,
+			Scope:             sql.SystemVariableScope_Global,
+			Dynamic:           false,
+			SetVarHintApplies: false,
+			Type:              sql.NewSystemStringType(DoltClusterRoleVariable),
+			Default:           string(c.role),
+		},
+		{
+			Name:              DoltClusterRoleEpochVariable,
+			Scope:             sql.SystemVariableScope_Global,
+			Dynamic:           false,
+			SetVarHintApplies: false,
+			Type:              sql.NewSystemIntType(DoltClusterRoleEpochVariable, 0, math.MaxInt64, false),
+			Default:           int64(c.epoch),
+		},
+	})
+}
+
+// roleAndEpoch returns the current role of the server and its epoch.
+func (c *Controller) roleAndEpoch() (Role, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.role, c.epoch
+}
+
+// secret returns the shared secret the servers of the cluster authenticate each other with.
+func (c *Controller) secret() string {
+	return c.cfg.RemotesAPIConfig().SharedSecret()
+}
+
+// WriteGuard returns an error if the server is a standby, which doesn't accept writes to any of its databases.
+func (c *Controller) WriteGuard(dbName string) error {
+	role, _ := c.roleAndEpoch()
+	if role == RoleStandby {
+		return fmt.Errorf("cannot write to database %s: %w", dbName, ErrWriteToStandby)
+	}
+	return nil
+}
+
+// ApplyStandbyReplication adds a commit hook replicating every database of |mrEnv| to each standby remote of the
+// cluster, and starts the threads pushing the writes in |bThreads|.
+func (c *Controller) ApplyStandbyReplication(ctx context.Context, bThreads *sql.BackgroundThreads, mrEnv *env.MultiRepoEnv) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dialer := &grpcDialProvider{orig: mrEnv.RemoteDialProvider(), ci: &c.cinterceptor}
+	ackSync := c.cfg.ReplicationAck() != ReplicationAckAsync
+
+	return mrEnv.Iter(func(name string, dEnv *env.DoltEnv) (stop bool, err error) {
+		ddb := dEnv.DoltDB
+		for _, r := range c.cfg.StandbyRemotes() {
+			remote := env.NewRemote(r.Name(), strings.Replace(r.RemoteURLTemplate(), DatabaseTemplate, name, -1), nil)
+			hook := newCommitHook(c.lgr, r.Name(), name, c.role, dEnv.TempTableFilesDir(), doltdb.HackDatasDatabaseFromDoltDB(ddb), lazyRemoteDB(remote, dialer), ackSync, c.cfg.ReplicationAckTimeout())
+			ddb.PrependCommitHook(ctx, hook)
+			err = bThreads.Add(fmt.Sprintf("cluster_replication_%s_%s", name, r.Name()), hook.Run)
+			if err != nil {
+				return true, err
+			}
+			c.commithooks = append(c.commithooks, hook)
+		}
+		return false, nil
+	})
+}
+
+// lazyRemoteDB returns a function opening the database of |remote| the first time it's called, so that a server
+// can be started while its standbys are unreachable.
+func lazyRemoteDB(remote env.Remote, dialer dbfactory.GRPCDialProvider) func(ctx context.Context) (*doltdb.DoltDB, error) {
+	var mu sync.Mutex
+	var ddb *doltdb.DoltDB
+	return func(ctx context.Context) (*doltdb.DoltDB, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ddb != nil {
+			return ddb, nil
+		}
+		var err error
+		ddb, err = remote.GetRemoteDBWithoutCaching(ctx, types.Format_Default, dialer)
+		return ddb, err
+	}
+}
+
+// grpcDialProvider adds the client interceptor of the cluster to the connections made by another dial provider.
+type grpcDialProvider struct {
+	orig dbfactory.GRPCDialProvider
+	ci   *clientinterceptor
+}
+
+func (p *grpcDialProvider) GetGRPCDialParams(config grpcendpoint.Config) (string, []grpc.DialOption, error) {
+	endpoint, opts, err := p.orig.GetGRPCDialParams(config)
+	if err != nil {
+		return "", nil, err
+	}
+	return endpoint, append(opts, p.ci.Options()...), nil
+}
+
+// RemoteSrvServerArgs returns |args| completed with the port, stores and interceptors of the remotesapi endpoint the
+// primary replicates to. The endpoint serves the databases of |mrEnv|. Its http endpoint only serves the table file
+// urls sealed for the grpc requests of authenticated peers.
+func (c *Controller) RemoteSrvServerArgs(mrEnv *env.MultiRepoEnv, args remotesrv.ServerArgs) (remotesrv.ServerArgs, error) {
+	sealer, err := remotesrv.NewSingleSymmetricKeySealer()
+	if err != nil {
+		return remotesrv.ServerArgs{}, err
+	}
+	args.HttpPort = c.cfg.RemotesAPIConfig().Port()
+	args.GrpcPort = c.cfg.RemotesAPIConfig().Port()
+	args.HttpHost = fmt.Sprintf(":%d", args.HttpPort)
+	args.DBCache = remotesrvStoreCache{mrEnv}
+	args.Sealer = sealer
+	args.Options = append(args.Options, c.sinterceptor.Options()...)
+	return args, nil
+}
+
+// remotesrvStoreCache serves the chunk stores of the databases of a server to its remotesapi endpoint.
+type remotesrvStoreCache struct {
+	mrEnv *env.MultiRepoEnv
+}
+
+func (s remotesrvStoreCache) Get(org, repo, nbfVerStr string) (remotesrv.RemoteSrvStore, error) {
+	dEnv := s.mrEnv.GetEnv(repo)
+	if org != "" || dEnv == nil {
+		return nil, fmt.Errorf("cluster: no database named %s", repo)
+	}
+	cs := datas.ChunkStoreFromDatabase(doltdb.HackDatasDatabaseFromDoltDB(dEnv.DoltDB))
+	rss, ok := cs.(remotesrv.RemoteSrvStore)
+	if !ok {
+		return nil, fmt.Errorf("cluster: database %s cannot be served to the primary", repo)
+	}
+	return rss, nil
+}
+
+// setRoleAndEpoch changes the role of the server to |role| at |epoch|. Assuming the role and epoch the server already
+// has is a no-op, and every other change must be to a higher epoch. If |graceful|, a primary that becomes a standby
+// first stops accepting writes and waits for its standbys to catch up, and stays the primary if they don't.
+func (c *Controller) setRoleAndEpoch(role string, epoch int, graceful bool) error {
+	c.transitionMu.Lock()
+	defer c.transitionMu.Unlock()
+
+	newRole := Role(role)
+	if newRole != RolePrimary && newRole != RoleStandby {
+		return fmt.Errorf("error assuming cluster role: %s is not a valid role; expected primary or standby", role)
+	}
+
+	c.mu.Lock()
+	if epoch == c.epoch && newRole == c.role {
+		c.mu.Unlock()
+		return nil
+	}
+	if epoch <= c.epoch {
+		defer c.mu.Unlock()
+		return fmt.Errorf("error assuming cluster role %s at epoch %d: the epoch must be higher than the current epoch %d", role, epoch, c.epoch)
+	}
+
+	oldRole := c.role
+	if graceful && oldRole == RolePrimary && newRole == RoleStandby {
+		// refuse new writes while the standbys catch up with the ones that were accepted
+		c.role = RoleStandby
+		c.mu.Unlock()
+		err := c.waitForHooksToCatchUp(roleTransitionTimeout)
+		c.mu.Lock()
+		if err != nil {
+			c.role = oldRole
+			c.mu.Unlock()
+			return fmt.Errorf("error assuming cluster role standby at epoch %d: %w", epoch, err)
+		}
+	}
+	defer c.mu.Unlock()
+
+	err := c.persistentCfg.SetStrings(map[string]string{
+		persistentRoleKey:  string(newRole),
+		persistentEpochKey: strconv.Itoa(epoch),
+	})
+	if err != nil {
+		c.role = oldRole
+		return err
+	}
+
+	c.role = newRole
+	c.epoch = epoch
+	for _, h := range c.commithooks {
+		h.setRole(newRole)
+	}
+	err = sql.SystemVariables.AssignValues(map[string]interface{}{
+		DoltClusterRoleVariable:      string(newRole),
+		DoltClusterRoleEpochVariable: int64(epoch),
+	})
+	if err != nil {
+		return err
+	}
+
+	c.lgr.Infof("cluster: assumed role %s at epoch %d", newRole, epoch)
+	return nil
+}
+
+// demote makes the server a standby at |epoch|, after a peer reported that it's the primary at that epoch.
+func (c *Controller) demote(epoch int) {
+	err := c.setRoleAndEpoch(string(RoleStandby), epoch, false)
+	if err != nil {
+		c.lgr.Errorf("cluster: failed to become a standby: %v", err)
+	}
+}
+
+// waitForHooksToCatchUp blocks until every standby has acknowledged every write to every database.
+func (c *Controller) waitForHooksToCatchUp(timeout time.Duration) error {
+	c.mu.Lock()
+	hooks := c.commithooks
+	c.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for _, h := range hooks {
+		err := h.waitForCaughtUp(time.Until(deadline))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	clusterRoleHeader      = "x-dolt-cluster-role"
+	clusterRoleEpochHeader = "x-dolt-cluster-role-epoch"
+	clusterSecretHeader    = "x-dolt-cluster-secret"
+)
+
+// roleAndEpochMD returns the grpc metadata telling a peer the role and epoch of this server. The metadata carries
+// the shared secret of the cluster, without which peers don't trust the role and epoch.
+func roleAndEpochMD(role Role, epoch int, secret string) metadata.MD {
+	return metadata.Pairs(clusterRoleHeader, string(role), clusterRoleEpochHeader, strconv.Itoa(epoch), clusterSecretHeader, secret)
+}
+
+// authenticated returns true if |md| carries the shared secret |secret| of the cluster. An empty secret never
+// authenticates a peer.
+func authenticated(md metadata.MD, secret string) bool {
+	secrets := md.Get(clusterSecretHeader)
+	if secret == "" || len(secrets) != 1 {
+		return false
+	}
+	// compare digests so that the time the comparison takes doesn't depend on the length of the secret either
+	want := sha256.Sum256([]byte(secret))
+	got := sha256.Sum256([]byte(secrets[0]))
+	return subtle.ConstantTimeCompare(want[:], got[:]) == 1
+}
+
+// parseRoleAndEpoch returns the role and epoch of the peer that sent |md|, and false if it holds neither.
+func parseRoleAndEpoch(md metadata.MD) (Role, int, bool) {
+	roles := md.Get(clusterRoleHeader)
+	epochs := md.Get(clusterRoleEpochHeader)
+	if len(roles) != 1 || len(epochs) != 1 {
+		return "", 0, false
+	}
+	epoch, err := strconv.Atoi(epochs[0])
+	if err != nil {
+		return "", 0, false
+	}
+	return Role(roles[0]), epoch, true
+}
+
+// clientinterceptor is installed on the connections a primary replicates to its standbys over. It sends the role
+// and epoch of this server with every request, and demotes this server to a standby if an authenticated peer reports
+// that it's the primary at a higher epoch.
+type clientinterceptor struct {
+	lgr        *logrus.Entry
+	controller *Controller
+}
+
+func (ci *clientinterceptor) Stream() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		role, epoch := ci.controller.roleAndEpoch()
+		if role == RoleStandby {
+			return nil, status.Error(codes.FailedPrecondition, "cluster: this server is a standby and does not replicate to other servers")
+		}
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Join(outgoingMD(ctx), roleAndEpochMD(role, epoch, ci.controller.secret())))
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
+
+func (ci *clientinterceptor) Unary() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		role, epoch := ci.controller.roleAndEpoch()
+		if role == RoleStandby {
+			return status.Error(codes.FailedPrecondition, "cluster: this server is a standby and does not replicate to other servers")
+		}
+		ctx = metadata.NewOutgoingContext(ctx, metadata.Join(outgoingMD(ctx), roleAndEpochMD(role, epoch, ci.controller.secret())))
+		var header metadata.MD
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		ci.handleResponseHeaders(header, epoch)
+		return err
+	}
+}
+
+func (ci *clientinterceptor) handleResponseHeaders(header metadata.MD, epoch int) {
+	if !authenticated(header, ci.controller.secret()) {
+		return
+	}
+	peerRole, peerEpoch, ok := parseRoleAndEpoch(header)
+	if !ok || peerRole != RolePrimary {
+		return
+	}
+	if peerEpoch > epoch {
+		ci.lgr.Warnf("cluster: a standby reported that it is the primary at epoch %d, higher than the epoch %d of this server; becoming a standby", peerEpoch, epoch)
+		ci.controller.demote(peerEpoch)
+	} else if peerEpoch == epoch {
+		ci.lgr.Errorf("cluster: a standby reported that it is also the primary at epoch %d; the cluster is misconfigured", epoch)
+	}
+}
+
+func (ci *clientinterceptor) Options() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(ci.Unary()),
+		grpc.WithChainStreamInterceptor(ci.Stream()),
+	}
+}
+
+func outgoingMD(ctx context.Context) metadata.MD {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return md
+}
+
+// serverinterceptor is installed on the remotesapi endpoint of a server of a cluster. It only serves requests that
+// carry the shared secret of the cluster, from a primary at an epoch no lower than the one of this server, and returns the role and epoch of this server with every
+// response. A primary that receives a request from the primary of a higher epoch becomes a standby.
+type serverinterceptor struct {
+	lgr        *logrus.Entry
+	controller *Controller
+}
+
+func (si *serverinterceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		role, epoch := si.controller.roleAndEpoch()
+		if err := si.authenticate(ss.Context()); err != nil {
+			return err
+		}
+		if err := ss.SetHeader(roleAndEpochMD(role, epoch, si.controller.secret())); err != nil {
+			return err
+		}
+		if err := si.check(ss.Context(), role, epoch); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (si *serverinterceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		role, epoch := si.controller.roleAndEpoch()
+		if err := si.authenticate(ctx); err != nil {
+			return nil, err
+		}
+		if err := grpc.SetHeader(ctx, roleAndEpochMD(role, epoch, si.controller.secret())); err != nil {
+			return nil, err
+		}
+		if err := si.check(ctx, role, epoch); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authenticate returns an error if the incoming metadata of |ctx| doesn't carry the shared secret of the cluster. The
+// role and epoch of a request are only trusted, and the ones of this server only revealed, once it's authenticated.
+func (si *serverinterceptor) authenticate(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if !authenticated(md, si.controller.secret()) {
+		return status.Error(codes.Unauthenticated, "cluster: this endpoint only serves the servers of its cluster")
+	}
+	return nil
+}
+
+// check returns an error if an authenticated request with the incoming metadata of |ctx| may not be served by this
+// server, which has the role |role| at |epoch|.
+func (si *serverinterceptor) check(ctx context.Context, role Role, epoch int) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	peerRole, peerEpoch, ok := parseRoleAndEpoch(md)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "cluster: this endpoint only serves the servers of its cluster")
+	}
+	if peerRole != RolePrimary {
+		return status.Errorf(codes.FailedPrecondition, "cluster: this endpoint only serves the primary of its cluster, not a %s", peerRole)
+	}
+
+	if role == RolePrimary {
+		if peerEpoch > epoch {
+			si.lgr.Warnf("cluster: received a request from the primary at epoch %d, higher than the epoch %d of this server; becoming a standby", peerEpoch, epoch)
+			si.controller.demote(peerEpoch)
+			return status.Errorf(codes.Unavailable, "cluster: this server was the primary at epoch %d and is becoming a standby; retry the request", epoch)
+		} else if peerEpoch == epoch {
+			si.lgr.Errorf("cluster: received a request from another primary at epoch %d; the cluster is misconfigured", epoch)
+		}
+		return status.Errorf(codes.FailedPrecondition, "cluster: this server is the primary at epoch %d", epoch)
+	}
+
+	if peerEpoch < epoch {
+		return status.Errorf(codes.FailedPrecondition, "cluster: this server is a standby at epoch %d, higher than the epoch %d of the request", epoch, peerEpoch)
+	} else if peerEpoch > epoch {
+		si.controller.demote(peerEpoch)
+	}
+	return nil
+}
+
+func (si *serverinterceptor) Options() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(si.Unary()),
+		grpc.ChainStreamInterceptor(si.Stream()),
+	}
+}
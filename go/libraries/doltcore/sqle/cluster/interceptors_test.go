@@ -0,0 +1,203 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/dolthub/dolt/go/libraries/utils/config"
+)
+
+const testSecret = "s3cr3t"
+
+type testConfig struct {
+	role  Role
+	epoch int
+}
+
+func (c testConfig) StandbyRemotes() []StandbyRemoteConfig { return nil }
+func (c testConfig) BootstrapRole() string                 { return string(c.role) }
+func (c testConfig) BootstrapEpoch() int                   { return c.epoch }
+func (c testConfig) ReplicationAck() string                { return ReplicationAckAsync }
+func (c testConfig) ReplicationAckTimeout() time.Duration  { return time.Second }
+func (c testConfig) RemotesAPIConfig() RemotesAPIConfig    { return testRemotesAPIConfig{} }
+
+type testRemotesAPIConfig struct{}
+
+func (testRemotesAPIConfig) Port() int            { return 50051 }
+func (testRemotesAPIConfig) SharedSecret() string { return testSecret }
+
+func newTestController(t *testing.T, role Role, epoch int) *Controller {
+	c, err := NewController(logrus.New(), testConfig{role: role, epoch: epoch}, config.NewEmptyMapConfig())
+	require.NoError(t, err)
+	return c
+}
+
+// testServerStream is the server transport stream of a unary call, which holds the headers set by the interceptor.
+type testServerStream struct {
+	header metadata.MD
+}
+
+func (s *testServerStream) Method() string { return "/test" }
+
+func (s *testServerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *testServerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+func (s *testServerStream) SetTrailer(md metadata.MD) error { return nil }
+
+// callUnary calls the unary server interceptor of |c| with the incoming metadata |md|, and returns whether the
+// handler was called, the headers the interceptor set and the error it returned.
+func callUnary(c *Controller, md metadata.MD) (bool, metadata.MD, error) {
+	stream := &testServerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(metadata.NewIncomingContext(context.Background(), md), stream)
+	called := false
+	_, err := c.sinterceptor.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	return called, stream.header, err
+}
+
+func TestAuthenticated(t *testing.T) {
+	assert.True(t, authenticated(roleAndEpochMD(RolePrimary, 1, testSecret), testSecret))
+	assert.False(t, authenticated(roleAndEpochMD(RolePrimary, 1, "wrong"), testSecret))
+	assert.False(t, authenticated(roleAndEpochMD(RolePrimary, 1, ""), ""))
+	assert.False(t, authenticated(metadata.Pairs(clusterRoleHeader, string(RolePrimary), clusterRoleEpochHeader, "1"), testSecret))
+}
+
+func TestServerInterceptor(t *testing.T) {
+	t.Run("requests without the shared secret are refused", func(t *testing.T) {
+		c := newTestController(t, RoleStandby, 1)
+		md := metadata.Pairs(clusterRoleHeader, string(RolePrimary), clusterRoleEpochHeader, "5")
+		called, header, err := callUnary(c, md)
+		assert.False(t, called)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Empty(t, header.Get(clusterRoleHeader))
+		role, epoch := c.roleAndEpoch()
+		assert.Equal(t, RoleStandby, role)
+		assert.Equal(t, 1, epoch)
+
+		called, _, err = callUnary(c, roleAndEpochMD(RolePrimary, 5, "wrong"))
+		assert.False(t, called)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		_, epoch = c.roleAndEpoch()
+		assert.Equal(t, 1, epoch)
+	})
+	t.Run("standby serves the primary of its epoch", func(t *testing.T) {
+		c := newTestController(t, RoleStandby, 1)
+		called, header, err := callUnary(c, roleAndEpochMD(RolePrimary, 1, testSecret))
+		require.NoError(t, err)
+		assert.True(t, called)
+		assert.Equal(t, []string{string(RoleStandby)}, header.Get(clusterRoleHeader))
+		assert.True(t, authenticated(header, testSecret))
+	})
+	t.Run("standby refuses a primary of a lower epoch", func(t *testing.T) {
+		c := newTestController(t, RoleStandby, 2)
+		called, _, err := callUnary(c, roleAndEpochMD(RolePrimary, 1, testSecret))
+		assert.False(t, called)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+	t.Run("standby refuses other standbys", func(t *testing.T) {
+		c := newTestController(t, RoleStandby, 1)
+		called, _, err := callUnary(c, roleAndEpochMD(RoleStandby, 1, testSecret))
+		assert.False(t, called)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+	t.Run("standby follows a primary of a higher epoch", func(t *testing.T) {
+		c := newTestController(t, RoleStandby, 1)
+		called, _, err := callUnary(c, roleAndEpochMD(RolePrimary, 3, testSecret))
+		require.NoError(t, err)
+		assert.True(t, called)
+		role, epoch := c.roleAndEpoch()
+		assert.Equal(t, RoleStandby, role)
+		assert.Equal(t, 3, epoch)
+	})
+	t.Run("primary becomes a standby for a primary of a higher epoch", func(t *testing.T) {
+		c := newTestController(t, RolePrimary, 1)
+		called, _, err := callUnary(c, roleAndEpochMD(RolePrimary, 2, testSecret))
+		assert.False(t, called)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		role, epoch := c.roleAndEpoch()
+		assert.Equal(t, RoleStandby, role)
+		assert.Equal(t, 2, epoch)
+	})
+	t.Run("primary refuses a primary of a lower epoch", func(t *testing.T) {
+		c := newTestController(t, RolePrimary, 2)
+		called, header, err := callUnary(c, roleAndEpochMD(RolePrimary, 1, testSecret))
+		assert.False(t, called)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Equal(t, []string{string(RolePrimary)}, header.Get(clusterRoleHeader))
+		role, _ := c.roleAndEpoch()
+		assert.Equal(t, RolePrimary, role)
+	})
+}
+
+func TestClientInterceptorResponseHeaders(t *testing.T) {
+	t.Run("unauthenticated headers are ignored", func(t *testing.T) {
+		c := newTestController(t, RolePrimary, 1)
+		c.cinterceptor.handleResponseHeaders(roleAndEpochMD(RolePrimary, 2, "wrong"), 1)
+		role, epoch := c.roleAndEpoch()
+		assert.Equal(t, RolePrimary, role)
+		assert.Equal(t, 1, epoch)
+	})
+	t.Run("primary of a higher epoch demotes the client", func(t *testing.T) {
+		c := newTestController(t, RolePrimary, 1)
+		c.cinterceptor.handleResponseHeaders(roleAndEpochMD(RolePrimary, 2, testSecret), 1)
+		role, epoch := c.roleAndEpoch()
+		assert.Equal(t, RoleStandby, role)
+		assert.Equal(t, 2, epoch)
+	})
+	t.Run("standby does not demote the client", func(t *testing.T) {
+		c := newTestController(t, RolePrimary, 1)
+		c.cinterceptor.handleResponseHeaders(roleAndEpochMD(RoleStandby, 2, testSecret), 1)
+		role, _ := c.roleAndEpoch()
+		assert.Equal(t, RolePrimary, role)
+	})
+}
+
+func TestClientInterceptorSendsSecret(t *testing.T) {
+	c := newTestController(t, RolePrimary, 1)
+	var sent metadata.MD
+	err := c.cinterceptor.Unary()(context.Background(), "/test", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		sent, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, authenticated(sent, testSecret))
+	peerRole, peerEpoch, ok := parseRoleAndEpoch(sent)
+	require.True(t, ok)
+	assert.Equal(t, RolePrimary, peerRole)
+	assert.Equal(t, 1, peerEpoch)
+
+	c = newTestController(t, RoleStandby, 1)
+	err = c.cinterceptor.Unary()(context.Background(), "/test", nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		t.Fatal("a standby must not replicate")
+		return nil
+	})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/globalstate"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/store/hash"
 )
 
 var ErrInvalidTableName = errors.NewKind("Invalid table name %s. Table names must match the regular expression " + doltdb.TableNameRegexStr)
@@ -294,6 +295,11 @@ func (db Database) GetTableInsensitive(ctx *sql.Context, tblName string) (sql.Ta
 
 // GetTableInsensitiveAsOf implements sql.VersionedDatabase
 func (db Database) GetTableInsensitiveAsOf(ctx *sql.Context, tableName string, asOf interface{}) (sql.Table, bool, error) {
+	if commitRange, ok := asOf.(string); ok && strings.Contains(commitRange, "..") &&
+		strings.HasPrefix(strings.ToLower(tableName), doltdb.DoltHistoryTablePrefix) {
+		return db.getHistoryTableForRange(ctx, tableName, commitRange)
+	}
+
 	head, root, err := resolveAsOf(ctx, db, asOf)
 	if err != nil {
 		return nil, false, err
@@ -435,8 +441,10 @@ func (db Database) getTableInsensitive(ctx *sql.Context, head *doltdb.Commit, ds
 		dt, found = dtables.NewTableOfTablesInConflict(ctx, db.name, db.ddb), true
 	case doltdb.TableOfTablesWithViolationsName:
 		dt, found = dtables.NewTableOfTablesConstraintViolations(ctx, root), true
+	case doltdb.SchemaConflictsTableName:
+		dt, found = newSchemaConflictsTable(db.name), true
 	case doltdb.BranchesTableName:
-		dt, found = dtables.NewBranchesTable(ctx, db.ddb), true
+		dt, found = dtables.NewBranchesTable(ctx, db.name, db.ddb), true
 	case doltdb.RemotesTableName:
 		dt, found = dtables.NewRemotesTable(ctx, db.ddb), true
 	case doltdb.CommitsTableName:
@@ -453,12 +461,71 @@ func (db Database) getTableInsensitive(ctx *sql.Context, head *doltdb.Commit, ds
 		dt, found = dtables.NewStatusTable(ctx, db.name, db.ddb, adapter), true
 	case doltdb.TagsTableName:
 		dt, found = dtables.NewTagsTable(ctx, db.ddb), true
+	case doltdb.StashesTableName:
+		dt, found = dtables.NewStashesTable(ctx, db.ddb), true
+	case doltdb.RebaseTableName:
+		dt, found = dtables.NewRebaseTable(ctx, db.name), true
+	case doltdb.BranchControlTableName:
+		if controller := ds.BranchController(); controller != nil {
+			dt, found = dtables.NewBranchControlTable(ctx, controller), true
+		}
+	case doltdb.BranchNamespaceControlTableName:
+		if controller := ds.BranchController(); controller != nil {
+			dt, found = dtables.NewBranchNamespaceControlTable(ctx, controller), true
+		}
 	}
 	if found {
 		return dt, found, nil
 	}
 
-	return db.getTable(ctx, root, tblName)
+	tbl, found, err := db.getTable(ctx, root, tblName)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found && head == nil {
+		// dolt_ignore and dolt_migrations can be written to before they exist in the working root
+		switch lwrName {
+		case doltdb.IgnoreTableName:
+			return newEmptySystemTable(db, doltdb.IgnoreTableName, doltdb.IgnoreSchema), true, nil
+		case doltdb.MigrationsTableName:
+			return newEmptySystemTable(db, doltdb.MigrationsTableName, doltdb.MigrationsSchema), true, nil
+		}
+	}
+
+	return tbl, found, nil
+}
+
+// getHistoryTableForRange returns the history table named |tableName| for the revision range |commitRange|, which
+// is made of two revisions separated by "..", like the revision ranges of dolt log. The history only has the commits
+// that are ancestors of the second revision, which defaults to HEAD, but not of the first.
+func (db Database) getHistoryTableForRange(ctx *sql.Context, tableName string, commitRange string) (sql.Table, bool, error) {
+	from, to, _ := strings.Cut(commitRange, "..")
+	if from == "" || strings.Contains(to, "..") {
+		return nil, false, fmt.Errorf("invalid revision range '%s', must be <from>..<to>", commitRange)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	head := db.rsr.CWBHeadRef()
+	excluded, _, err := resolveAsOfCommitRef(ctx, db.ddb, head, from)
+	if err != nil {
+		return nil, false, err
+	}
+	cm, root, err := resolveAsOfCommitRef(ctx, db.ddb, head, to)
+	if err != nil {
+		return nil, false, err
+	}
+
+	table, ok, err := db.getTableInsensitive(ctx, cm, dsess.DSessFromSess(ctx.Session), root, tableName)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	historyTable, ok := table.(*HistoryTable)
+	if !ok {
+		return nil, false, fmt.Errorf("revision range '%s' can only be used with dolt_history tables, not %s", commitRange, tableName)
+	}
+	return historyTable.WithExcludedAncestors(excluded), true, nil
 }
 
 // resolveAsOf resolves given expression to a commit, if one exists.
@@ -1090,8 +1157,21 @@ func (db Database) DropStoredProcedure(ctx *sql.Context, name string) error {
 }
 
 // GetExternalStoredProcedures implements sql.ExternalStoredProcedureDatabase.
+// The procedures registered with the engine of the session's provider come after the dolt procedures.
 func (db Database) GetExternalStoredProcedures(ctx *sql.Context) ([]sql.ExternalStoredProcedureDetails, error) {
-	return dprocedures.DoltProcedures, nil
+	sess, ok := ctx.Session.(*dsess.DoltSession)
+	if !ok {
+		return dprocedures.DoltProcedures, nil
+	}
+
+	registered := sess.Provider().ExternalStoredProcedures()
+	if len(registered) == 0 {
+		return dprocedures.DoltProcedures, nil
+	}
+
+	procs := make([]sql.ExternalStoredProcedureDetails, 0, len(dprocedures.DoltProcedures)+len(registered))
+	procs = append(procs, dprocedures.DoltProcedures...)
+	return append(procs, registered...), nil
 }
 
 func (db Database) addFragToSchemasTable(ctx *sql.Context, fragType, name, definition string, created time.Time, existingErr error) (err error) {
@@ -1227,3 +1307,7 @@ func (n noopRepoStateWriter) TempTableFilesDir() string {
 func (n noopRepoStateWriter) UpdateBranch(name string, new env.BranchConfig) error {
 	return nil
 }
+
+func (n noopRepoStateWriter) SetShallowCommits(commits hash.HashSet) error {
+	return nil
+}
@@ -17,10 +17,12 @@ package sqle
 import (
 	"context"
 	"fmt"
+	"path"
 	"strings"
 	"sync"
 
 	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
 	"github.com/dolthub/dolt/go/libraries/doltcore/dbfactory"
@@ -30,6 +32,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
 	"github.com/dolthub/dolt/go/libraries/doltcore/remotestorage"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dfunctions"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dprocedures"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
 	"github.com/dolthub/dolt/go/libraries/utils/earl"
@@ -52,12 +55,26 @@ type DoltDatabaseProvider struct {
 	remoteDialer  dbfactory.GRPCDialProvider
 
 	dbFactoryUrl string
+
+	privileges       *mysql_db.MySQLDb
+	storageAllowlist []string
+
+	// procedures are shared by all copies of this provider, so that procedures registered after the engine is built
+	// are seen by its sessions
+	procedures *procedureRegistry
+}
+
+// procedureRegistry holds the procedures registered with the engine of a provider.
+type procedureRegistry struct {
+	mu    sync.RWMutex
+	procs []sql.ExternalStoredProcedureDetails
 }
 
 var _ sql.DatabaseProvider = (*DoltDatabaseProvider)(nil)
 var _ sql.FunctionProvider = (*DoltDatabaseProvider)(nil)
 var _ sql.MutableDatabaseProvider = (*DoltDatabaseProvider)(nil)
 var _ dsess.DoltDatabaseProvider = (*DoltDatabaseProvider)(nil)
+var _ dprocedures.ProcedureRegistry = DoltDatabaseProvider{}
 
 // NewDoltDatabaseProvider returns a provider for the databases given
 func NewDoltDatabaseProvider(defaultBranch string, fs filesys.Filesys, databases ...sql.Database) DoltDatabaseProvider {
@@ -78,6 +95,7 @@ func NewDoltDatabaseProvider(defaultBranch string, fs filesys.Filesys, databases
 		fs:            fs,
 		defaultBranch: defaultBranch,
 		dbFactoryUrl:  doltdb.LocalDirDoltDB,
+		procedures:    &procedureRegistry{},
 	}
 }
 
@@ -106,6 +124,41 @@ func (p DoltDatabaseProvider) WithRemoteDialer(provider dbfactory.GRPCDialProvid
 	return p
 }
 
+// WithPrivileges returns a copy of this provider which checks the privileges in |privileges| before creating a database
+// with a storage.
+func (p DoltDatabaseProvider) WithPrivileges(privileges *mysql_db.MySQLDb) DoltDatabaseProvider {
+	p.privileges = privileges
+	return p
+}
+
+// WithStorageAllowlist returns a copy of this provider with the storage urls given as its storage allowlist. Databases
+// with a storage may only be created under one of these urls.
+func (p DoltDatabaseProvider) WithStorageAllowlist(allowlist []string) DoltDatabaseProvider {
+	p.storageAllowlist = allowlist
+	return p
+}
+
+// RegisterExternalStoredProcedure implements dprocedures.ProcedureRegistry. The procedure is available to the databases
+// of every session of this provider's engine.
+func (p DoltDatabaseProvider) RegisterExternalStoredProcedure(proc sql.ExternalStoredProcedureDetails) {
+	p.procedures.mu.Lock()
+	defer p.procedures.mu.Unlock()
+	for i, existing := range p.procedures.procs {
+		if existing.Name == proc.Name {
+			p.procedures.procs[i] = proc
+			return
+		}
+	}
+	p.procedures.procs = append(p.procedures.procs, proc)
+}
+
+// ExternalStoredProcedures implements dsess.DoltDatabaseProvider.
+func (p DoltDatabaseProvider) ExternalStoredProcedures() []sql.ExternalStoredProcedureDetails {
+	p.procedures.mu.RLock()
+	defer p.procedures.mu.RUnlock()
+	return append([]sql.ExternalStoredProcedureDetails(nil), p.procedures.procs...)
+}
+
 func (p DoltDatabaseProvider) FileSystem() filesys.Filesys {
 	return p.fs
 }
@@ -186,25 +239,110 @@ func (p DoltDatabaseProvider) CreateDatabase(ctx *sql.Context, name string) erro
 	}
 
 	// TODO: fill in version appropriately
-	dsess := dsess.DSessFromSess(ctx.Session)
+	sess := dsess.DSessFromSess(ctx.Session)
 	newEnv := env.Load(ctx, env.GetCurrentUserHomeDir, newFs, p.dbFactoryUrl, "TODO")
-	err = newEnv.InitRepo(ctx, types.Format_Default, dsess.Username(), dsess.Email(), p.defaultBranch)
+	err = newEnv.InitRepo(ctx, types.Format_Default, sess.Username(), sess.Email(), p.defaultBranch)
 	if err != nil {
 		return err
 	}
 
+	return p.addDatabase(ctx, name, newEnv)
+}
+
+// CreateDatabaseWithStorage implements DoltDatabaseProvider interface
+func (p DoltDatabaseProvider) CreateDatabaseWithStorage(ctx *sql.Context, name, storageUrl string, storageParams map[string]string) error {
+	// the database is read and written with the credentials of the server, so creating one takes the same privilege
+	// as CREATE DATABASE, and is limited to the storages the server allows
+	err := p.checkCreatePrivilege(ctx)
+	if err != nil {
+		return err
+	}
+	if !storageAllowed(p.storageAllowlist, storageUrl) {
+		return fmt.Errorf("cannot create DB, storage '%s' is not in the storage allowlist of the server", storageUrl)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.databases[formatDbMapKeyName(name)]; ok {
+		return sql.ErrDatabaseExists.New(name)
+	}
+	exists, isDir := p.fs.Exists(name)
+	if exists && isDir {
+		return sql.ErrDatabaseExists.New(name)
+	} else if exists {
+		return fmt.Errorf("cannot create DB, file exists at %s", name)
+	}
+
+	err = p.fs.MkDirs(name)
+	if err != nil {
+		return err
+	}
+
+	newFs, err := p.fs.WithWorkingDir(name)
+	if err != nil {
+		return err
+	}
+
+	sess := dsess.DSessFromSess(ctx.Session)
+	newEnv := env.Load(ctx, env.GetCurrentUserHomeDir, newFs, doltdb.LocalDirDoltDB, "TODO")
+	err = newEnv.InitRepoWithStorage(ctx, types.Format_Default, sess.Username(), sess.Email(), p.defaultBranch, storageUrl, storageParams)
+	if err != nil {
+		_ = p.fs.Delete(name, true)
+		return err
+	}
+
+	return p.addDatabase(ctx, name, newEnv)
+}
+
+// checkCreatePrivilege returns an error unless the user of |ctx| has the global CREATE privilege. Privileges are only
+// checked once they're enabled, as the analyzer does.
+func (p DoltDatabaseProvider) checkCreatePrivilege(ctx *sql.Context) error {
+	return dprocedures.CheckGlobalPrivilege(ctx, p.privileges, sql.PrivilegeType_Create)
+}
+
+// storageAllowed returns whether |storageUrl| is under one of the urls in |allowlist|, that is whether it has the same
+// scheme and host as the url, and a path within the url's path.
+func storageAllowed(allowlist []string, storageUrl string) bool {
+	u, err := earl.Parse(storageUrl)
+	if err != nil {
+		return false
+	}
+	storagePath := path.Clean("/" + u.Path)
+
+	for _, entry := range allowlist {
+		allowed, err := earl.Parse(entry)
+		if err != nil {
+			continue
+		}
+		if !strings.EqualFold(u.Scheme, allowed.Scheme) || u.Host != allowed.Host {
+			continue
+		}
+
+		allowedPath := path.Clean("/" + allowed.Path)
+		if storagePath == allowedPath || strings.HasPrefix(storagePath, strings.TrimSuffix(allowedPath, "/")+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addDatabase adds the database of |dEnv| to this provider and to the session of |ctx|, under the name given. Callers
+// must hold the lock of this provider.
+func (p DoltDatabaseProvider) addDatabase(ctx *sql.Context, name string, dEnv *env.DoltEnv) error {
 	fkChecks, err := ctx.GetSessionVariable(ctx, "foreign_key_checks")
 	if err != nil {
 		return err
 	}
 
 	opts := editor.Options{
-		Deaf: newEnv.DbEaFactory(),
+		Deaf: dEnv.DbEaFactory(),
 		// TODO: this doesn't seem right, why is this getting set in the constructor to the DB
 		ForeignKeyChecksDisabled: fkChecks.(int8) == 0,
 	}
 
-	db := NewDatabase(name, newEnv.DbData(), opts)
+	db := NewDatabase(name, dEnv.DbData(), opts)
 	p.databases[formatDbMapKeyName(db.Name())] = db
 
 	dbstate, err := GetInitialDBState(ctx, db)
@@ -212,11 +350,11 @@ func (p DoltDatabaseProvider) CreateDatabase(ctx *sql.Context, name string) erro
 		return err
 	}
 
-	return dsess.AddDB(ctx, dbstate)
+	return dsess.DSessFromSess(ctx.Session).AddDB(ctx, dbstate)
 }
 
 // CloneDatabaseFromRemote implements DoltDatabaseProvider interface
-func (p DoltDatabaseProvider) CloneDatabaseFromRemote(ctx *sql.Context, dbName, branch, remoteName, remoteUrl string, remoteParams map[string]string) error {
+func (p DoltDatabaseProvider) CloneDatabaseFromRemote(ctx *sql.Context, dbName, branch, remoteName, remoteUrl string, singleBranch bool, depth int, remoteParams map[string]string) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -243,7 +381,7 @@ func (p DoltDatabaseProvider) CloneDatabaseFromRemote(ctx *sql.Context, dbName,
 		return err
 	}
 
-	err = actions.CloneRemote(ctx, srcDB, remoteName, branch, dEnv)
+	err = actions.CloneRemote(ctx, srcDB, remoteName, branch, singleBranch, depth, dEnv)
 	if err != nil {
 		return err
 	}
@@ -253,27 +391,7 @@ func (p DoltDatabaseProvider) CloneDatabaseFromRemote(ctx *sql.Context, dbName,
 		Remote: remoteName,
 	})
 
-	sess := dsess.DSessFromSess(ctx.Session)
-	fkChecks, err := ctx.GetSessionVariable(ctx, "foreign_key_checks")
-	if err != nil {
-		return err
-	}
-
-	opts := editor.Options{
-		Deaf: dEnv.DbEaFactory(),
-		// TODO: this doesn't seem right, why is this getting set in the constructor to the DB
-		ForeignKeyChecksDisabled: fkChecks.(int8) == 0,
-	}
-
-	db := NewDatabase(dbName, dEnv.DbData(), opts)
-	p.databases[formatDbMapKeyName(db.Name())] = db
-
-	dbstate, err := GetInitialDBState(ctx, db)
-	if err != nil {
-		return err
-	}
-
-	return sess.AddDB(ctx, dbstate)
+	return p.addDatabase(ctx, dbName, dEnv)
 }
 
 // TODO: extract a shared library for this functionality
@@ -443,11 +561,20 @@ func (p DoltDatabaseProvider) Function(_ *sql.Context, name string) (sql.Functio
 
 // TableFunction implements the TableFunctionProvider interface
 func (p DoltDatabaseProvider) TableFunction(ctx *sql.Context, name string) (sql.TableFunction, error) {
-	// currently, only one table function is supported, if we extend this, we should clean this up
-	// and store table functions in a map, similar to regular functions.
-	if strings.ToLower(name) == "dolt_diff" {
+	// TODO: if we add more table functions, we should store them in a map, similar to regular functions.
+	switch strings.ToLower(name) {
+	case "dolt_diff":
 		dtf := &DiffTableFunction{}
 		return dtf, nil
+	case "dolt_schema_diff":
+		sdtf := &SchemaDiffTableFunction{}
+		return sdtf, nil
+	case "dolt_log":
+		ltf := &LogTableFunction{}
+		return ltf, nil
+	case "dolt_reflog":
+		rtf := &ReflogTableFunction{}
+		return rtf, nil
 	}
 
 	return nil, sql.ErrTableFunctionNotFound.New(name)
@@ -0,0 +1,110 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+func TestStorageAllowed(t *testing.T) {
+	allowlist := []string{"file:///var/lib/dolt/storage", "gs://bucket/dbs/"}
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{"file:///var/lib/dolt/storage", true},
+		{"file:///var/lib/dolt/storage/db", true},
+		{"file:///var/lib/dolt/storage/a/b/", true},
+		{"file:///var/lib/dolt/storage/../db", false},
+		{"file:///var/lib/dolt/storage2", false},
+		{"file:///var/lib/dolt", false},
+		{"gs://bucket/dbs/db", true},
+		{"gs://bucket/db", false},
+		{"gs://other/dbs/db", false},
+		{"file:///dbs/db", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			assert.Equal(t, test.allowed, storageAllowed(allowlist, test.url))
+		})
+	}
+
+	assert.False(t, storageAllowed(nil, "file:///var/lib/dolt/storage/db"))
+}
+
+func TestCreateDatabaseWithStorageChecksPrivileges(t *testing.T) {
+	fs := filesys.NewInMemFS(nil, nil, "/")
+	privileges := mysql_db.CreateEmptyMySQLDb()
+	privileges.AddRootAccount()
+	pro := NewDoltDatabaseProvider("main", fs).WithPrivileges(privileges).WithStorageAllowlist([]string{"file:///storage"})
+
+	ctx := sql.NewContext(context.Background(), sql.WithSession(sql.NewBaseSessionWithClientServer("", sql.Client{User: "tester", Address: "localhost"}, 1)))
+	err := pro.CreateDatabaseWithStorage(ctx, "db", "file:///storage/db", nil)
+	require.Error(t, err)
+	assert.True(t, sql.ErrPrivilegeCheckFailed.Is(err))
+
+	ctx = sql.NewContext(context.Background(), sql.WithSession(sql.NewBaseSessionWithClientServer("", sql.Client{User: "root", Address: "localhost"}, 1)))
+	err = pro.CreateDatabaseWithStorage(ctx, "db", "file:///elsewhere/db", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "storage allowlist")
+
+	exists, _ := fs.Exists("db")
+	assert.False(t, exists)
+}
+
+func TestRegisterExternalStoredProcedure(t *testing.T) {
+	fs := filesys.NewInMemFS(nil, nil, "/")
+	pro := NewDoltDatabaseProvider("main", fs)
+	other := NewDoltDatabaseProvider("main", fs)
+
+	proc := func(name string, status int64) sql.ExternalStoredProcedureDetails {
+		return sql.ExternalStoredProcedureDetails{
+			Name:   name,
+			Schema: sql.Schema{{Name: "status", Type: sql.Int64}},
+			Function: func(ctx *sql.Context) (sql.RowIter, error) {
+				return sql.RowsToRowIter(sql.Row{status}), nil
+			},
+		}
+	}
+
+	// procedures registered after a copy of the provider was made are seen by the copy
+	cpy := pro.WithStorageAllowlist(nil)
+	pro.RegisterExternalStoredProcedure(proc("dolt_test_proc", 1))
+	cpy.RegisterExternalStoredProcedure(proc("dolt_test_proc", 2))
+	pro.RegisterExternalStoredProcedure(proc("dolt_other_proc", 3))
+
+	procs := pro.ExternalStoredProcedures()
+	require.Len(t, procs, 2)
+	assert.Equal(t, "dolt_test_proc", procs[0].Name)
+	assert.Equal(t, "dolt_other_proc", procs[1].Name)
+	iter, err := procs[0].Function.(func(*sql.Context) (sql.RowIter, error))(sql.NewEmptyContext())
+	require.NoError(t, err)
+	rows, err := sql.RowIterToRows(sql.NewEmptyContext(), nil, iter)
+	require.NoError(t, err)
+	assert.Equal(t, []sql.Row{{int64(2)}}, rows)
+
+	// but not by other providers
+	assert.Empty(t, other.ExternalStoredProcedures())
+}
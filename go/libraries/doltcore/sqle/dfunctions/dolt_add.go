@@ -73,7 +73,7 @@ func DoDoltAdd(ctx *sql.Context, args []string) (int, error) {
 			return 1, err
 		}
 	} else {
-		roots, err = actions.StageTables(ctx, roots, apr.Args)
+		roots, err = actions.StageTables(ctx, roots, apr.Args, apr.Contains(cli.ForceFlag))
 		if err != nil {
 			return 1, err
 		}
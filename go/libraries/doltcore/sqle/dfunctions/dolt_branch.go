@@ -120,6 +120,11 @@ func renameBranch(ctx *sql.Context, dbData env.DbData, apr *argparser.ArgParseRe
 	}
 	force := apr.Contains(cli.ForceFlag)
 
+	dSess := dsess.DSessFromSess(ctx.Session)
+	if err := dSess.CheckBranchRename(ctx.GetCurrentDatabase(), oldBranchName, newBranchName); err != nil {
+		return err
+	}
+
 	if !force {
 		err := validateBranchNotActiveInAnySession(ctx, oldBranchName)
 		if err != nil {
@@ -132,6 +137,15 @@ func renameBranch(ctx *sql.Context, dbData env.DbData, apr *argparser.ArgParseRe
 		return err
 	}
 
+	err = dSess.BranchDeleted(ctx.GetCurrentDatabase(), oldBranchName)
+	if err != nil {
+		return err
+	}
+	err = dSess.BranchCreated(ctx.GetCurrentDatabase(), newBranchName)
+	if err != nil {
+		return err
+	}
+
 	dbName, _ := parseRevisionDatabaseName(ctx.GetCurrentDatabase())
 	return removeBranchRevisionDatabase(ctx, fmt.Sprintf("%s/%s", dbName, oldBranchName))
 }
@@ -141,12 +155,17 @@ func deleteBranches(ctx *sql.Context, apr *argparser.ArgParseResults, dbData env
 		return InvalidArgErr
 	}
 
+	dSess := dsess.DSessFromSess(ctx.Session)
 	for _, branchName := range apr.Args {
 		if len(branchName) == 0 {
 			return EmptyBranchNameErr
 		}
 		force := apr.Contains(cli.DeleteForceFlag) || apr.Contains(cli.ForceFlag)
 
+		if err := dSess.CheckBranchDelete(ctx.GetCurrentDatabase(), branchName); err != nil {
+			return err
+		}
+
 		if !force {
 			err := validateBranchNotActiveInAnySession(ctx, branchName)
 			if err != nil {
@@ -159,6 +178,10 @@ func deleteBranches(ctx *sql.Context, apr *argparser.ArgParseResults, dbData env
 		if err != nil {
 			return err
 		}
+		err = dSess.BranchDeleted(ctx.GetCurrentDatabase(), branchName)
+		if err != nil {
+			return err
+		}
 
 		dbName, _ := parseRevisionDatabaseName(ctx.GetCurrentDatabase())
 		err = removeBranchRevisionDatabase(ctx, fmt.Sprintf("%s/%s", dbName, branchName))
@@ -267,7 +290,33 @@ func createNewBranch(ctx *sql.Context, dbData env.DbData, apr *argparser.ArgPars
 		return EmptyBranchNameErr
 	}
 
-	return actions.CreateBranchWithStartPt(ctx, dbData, branchName, "HEAD", apr.Contains(cli.ForceFlag))
+	force := apr.Contains(cli.ForceFlag)
+	if err := checkBranchCreate(ctx, dbData, branchName, force); err != nil {
+		return err
+	}
+
+	err := actions.CreateBranchWithStartPt(ctx, dbData, branchName, "HEAD", force)
+	if err != nil {
+		return err
+	}
+
+	return dsess.DSessFromSess(ctx.Session).BranchCreated(ctx.GetCurrentDatabase(), branchName)
+}
+
+// checkBranchCreate returns an error if the session may not create the branch given. When |force| is set and the
+// branch already exists, it is overwritten, which requires the same permissions as deleting it.
+func checkBranchCreate(ctx *sql.Context, dbData env.DbData, branchName string, force bool) error {
+	dSess := dsess.DSessFromSess(ctx.Session)
+	if force {
+		exists, err := actions.IsBranch(ctx, dbData.Ddb, branchName)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return dSess.CheckBranchDelete(ctx.GetCurrentDatabase(), branchName)
+		}
+	}
+	return dSess.CheckBranchCreate(ctx.GetCurrentDatabase(), branchName)
 }
 
 func copyBranch(ctx *sql.Context, dbData env.DbData, apr *argparser.ArgParseResults) error {
@@ -290,6 +339,10 @@ func copyBranch(ctx *sql.Context, dbData env.DbData, apr *argparser.ArgParseResu
 }
 
 func copyABranch(ctx *sql.Context, dbData env.DbData, srcBr string, destBr string, force bool) error {
+	if err := checkBranchCreate(ctx, dbData, destBr, force); err != nil {
+		return err
+	}
+
 	err := actions.CopyBranchOnDB(ctx, dbData.Ddb, srcBr, destBr, force)
 	if err != nil {
 		if err == doltdb.ErrBranchNotFound {
@@ -303,5 +356,5 @@ func copyABranch(ctx *sql.Context, dbData env.DbData, srcBr string, destBr strin
 		}
 	}
 
-	return nil
+	return dsess.DSessFromSess(ctx.Session).BranchCreated(ctx.GetCurrentDatabase(), destBr)
 }
@@ -168,11 +168,21 @@ func checkoutNewBranch(ctx *sql.Context, dbName string, dbData env.DbData, roots
 		startPt = "head"
 	}
 
+	dSess := dsess.DSessFromSess(ctx.Session)
+	if err := dSess.CheckBranchCreate(dbName, branchName); err != nil {
+		return err
+	}
+
 	err := actions.CreateBranchWithStartPt(ctx, dbData, branchName, startPt, false)
 	if err != nil {
 		return err
 	}
 
+	err = dSess.BranchCreated(dbName, branchName)
+	if err != nil {
+		return err
+	}
+
 	return checkoutBranch(ctx, dbName, roots, dbData, branchName)
 }
 
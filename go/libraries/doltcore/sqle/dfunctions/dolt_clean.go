@@ -60,7 +60,7 @@ func DoDoltClean(ctx *sql.Context, args []string) (int, error) {
 		return 1, fmt.Errorf("Could not load database %s", dbName)
 	}
 
-	roots, err = actions.CleanUntracked(ctx, roots, apr.Args, apr.ContainsAll(cli.DryRunFlag))
+	roots, err = actions.CleanUntracked(ctx, roots, apr.Args, apr.ContainsAll(cli.DryRunFlag), apr.Contains(cli.CleanIgnoredFlag))
 	if err != nil {
 		return 1, fmt.Errorf("failed to clean; %w", err)
 	}
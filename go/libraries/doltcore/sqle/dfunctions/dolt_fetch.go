@@ -90,6 +90,10 @@ func DoDoltFetch(ctx *sql.Context, args []string) (int, error) {
 		return cmdFailure, err
 	}
 
+	if apr.Contains(cli.DepthParam) || apr.Contains(cli.UnshallowFlag) {
+		return cmdFailure, fmt.Errorf("shallow fetches are not supported in SQL; use the dolt fetch command")
+	}
+
 	remote, refSpecs, err := env.NewFetchOpts(apr.Args, dbData.Rsr)
 	if err != nil {
 		return cmdFailure, err
@@ -102,7 +106,7 @@ func DoDoltFetch(ctx *sql.Context, args []string) (int, error) {
 		return 1, err
 	}
 
-	err = actions.FetchRefSpecs(ctx, dbData, srcDB, refSpecs, remote, updateMode, runProgFuncs, stopProgFuncs)
+	err = actions.FetchRefSpecs(ctx, dbData, srcDB, refSpecs, remote, updateMode, 0, runProgFuncs, stopProgFuncs)
 	if err != nil {
 		return cmdFailure, fmt.Errorf("fetch failed: %w", err)
 	}
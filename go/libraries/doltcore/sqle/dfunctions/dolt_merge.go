@@ -17,6 +17,7 @@ package dfunctions
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/dolthub/go-mysql-server/sql"
@@ -233,7 +234,7 @@ func abortMerge(ctx *sql.Context, workingSet *doltdb.WorkingSet, roots doltdb.Ro
 }
 
 func executeMerge(ctx *sql.Context, squash bool, head, cm *doltdb.Commit, ws *doltdb.WorkingSet, opts editor.Options) (*doltdb.WorkingSet, error) {
-	mergeRoot, mergeStats, err := merge.MergeCommits(ctx, head, cm, opts)
+	mergeRoot, mergeStats, err := merge.MergeCommits(ctx, head, cm, opts, merge.MergeOpts{KeepSchemaConflicts: !squash})
 
 	if err != nil {
 		switch err {
@@ -258,6 +259,11 @@ func executeFFMerge(ctx *sql.Context, dbName string, squash bool, ws *doltdb.Wor
 	// TODO: This is all incredibly suspect, needs to be replaced with library code that is functional instead of
 	//  altering global state
 	if !squash {
+		err = dsess.DSessFromSess(ctx.Session).CheckBranchWrite(dbName, dbData.Rsr.CWBHeadRef().GetPath())
+		if err != nil {
+			return ws, err
+		}
+
 		err = dbData.Ddb.FastForward(ctx, dbData.Rsr.CWBHeadRef(), cm2)
 		if err != nil {
 			return ws, err
@@ -395,6 +401,9 @@ func mergeRootToWorking(
 	workingRoot := mergedRoot
 	if !squash {
 		ws = ws.StartMerge(cm2)
+		if tbls := schemaConflictTables(mergeStats); len(tbls) > 0 {
+			ws = ws.WithSchemaConflicts(tbls)
+		}
 	}
 
 	ws = ws.WithWorkingRoot(workingRoot).WithStagedRoot(workingRoot)
@@ -427,7 +436,7 @@ func checkForUncommittedChanges(ctx *sql.Context, root *doltdb.RootValue, headRo
 
 func checkForConflicts(tblToStats map[string]*merge.MergeStats) bool {
 	for _, stats := range tblToStats {
-		if stats.Operation == merge.TableModified && stats.Conflicts > 0 {
+		if stats.Operation == merge.TableModified && (stats.Conflicts > 0 || stats.SchemaConflicts > 0) {
 			return true
 		}
 	}
@@ -435,6 +444,18 @@ func checkForConflicts(tblToStats map[string]*merge.MergeStats) bool {
 	return false
 }
 
+// schemaConflictTables returns the names of the tables whose schemas could not be merged, in order.
+func schemaConflictTables(tblToStats map[string]*merge.MergeStats) []string {
+	var tbls []string
+	for tblName, stats := range tblToStats {
+		if stats.SchemaConflicts > 0 {
+			tbls = append(tbls, tblName)
+		}
+	}
+	sort.Strings(tbls)
+	return tbls
+}
+
 func checkForViolations(tblToStats map[string]*merge.MergeStats) bool {
 	for _, stats := range tblToStats {
 		if stats.ConstraintViolations > 0 {
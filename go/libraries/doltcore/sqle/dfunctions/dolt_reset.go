@@ -88,6 +88,9 @@ func DoDoltReset(ctx *sql.Context, args []string) (int, error) {
 
 		// TODO: this overrides the transaction setting, needs to happen at commit, not here
 		if newHead != nil {
+			if err := dSess.CheckBranchWrite(dbName, dbData.Rsr.CWBHeadRef().GetPath()); err != nil {
+				return 1, err
+			}
 			if err := dbData.Ddb.SetHeadToCommit(ctx, dbData.Rsr.CWBHeadRef(), newHead); err != nil {
 				return 1, err
 			}
@@ -0,0 +1,482 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env/actions/commitwalk"
+	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+var _ sql.TableFunction = (*LogTableFunction)(nil)
+
+// LogTableFunction implements the dolt_log table function, which returns the commit log for the revisions given,
+// using the same revision syntax as `git log`.
+type LogTableFunction struct {
+	ctx       *sql.Context
+	argExprs  []sql.Expression
+	database  sql.Database
+	sqlSch    sql.Schema
+	revisions []string
+	notRev    string
+	tables    []string
+
+	minParents  int
+	showParents bool
+	decoration  string
+}
+
+var logTableFunctionSchema = sql.Schema{
+	&sql.Column{Name: "commit_hash", Type: sql.Text},
+	&sql.Column{Name: "committer", Type: sql.Text},
+	&sql.Column{Name: "email", Type: sql.Text},
+	&sql.Column{Name: "date", Type: sql.Datetime},
+	&sql.Column{Name: "message", Type: sql.Text},
+}
+
+// NewInstance implements the TableFunction interface
+func (ltf *LogTableFunction) NewInstance(ctx *sql.Context, database sql.Database, expressions []sql.Expression) (sql.Node, error) {
+	newInstance := &LogTableFunction{
+		ctx:      ctx,
+		database: database,
+	}
+
+	node, err := newInstance.WithExpressions(expressions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// Database implements the sql.Databaser interface
+func (ltf *LogTableFunction) Database() sql.Database {
+	return ltf.database
+}
+
+// WithDatabase implements the sql.Databaser interface
+func (ltf *LogTableFunction) WithDatabase(database sql.Database) (sql.Node, error) {
+	ltf.database = database
+
+	return ltf, nil
+}
+
+// Expressions implements the sql.Expressioner interface
+func (ltf *LogTableFunction) Expressions() []sql.Expression {
+	return ltf.argExprs
+}
+
+// WithExpressions implements the sql.Expressioner interface
+func (ltf *LogTableFunction) WithExpressions(expression ...sql.Expression) (sql.Node, error) {
+	// As with dolt_diff, only literal / fully-resolved arguments are supported for now.
+	for _, expr := range expression {
+		if !expr.Resolved() {
+			return nil, ErrInvalidNonLiteralArgument.New(ltf.FunctionName(), expr.String())
+		}
+	}
+
+	ltf.argExprs = expression
+
+	if err := ltf.evaluateArguments(); err != nil {
+		return nil, err
+	}
+
+	ltf.sqlSch = append(sql.Schema{}, logTableFunctionSchema...)
+	if ltf.showParents {
+		ltf.sqlSch = append(ltf.sqlSch, &sql.Column{Name: "parents", Type: sql.Text})
+	}
+	if ltf.decoration != "no" {
+		ltf.sqlSch = append(ltf.sqlSch, &sql.Column{Name: "refs", Type: sql.Text})
+	}
+
+	return ltf, nil
+}
+
+// evaluateArguments evaluates the argument expressions and parses them as the arguments of `dolt log`.
+func (ltf *LogTableFunction) evaluateArguments() error {
+	args := make([]string, len(ltf.argExprs))
+	for i, expr := range ltf.argExprs {
+		if !sql.IsText(expr.Type()) {
+			return sql.ErrInvalidArgumentDetails.New(ltf.FunctionName(), expr.String())
+		}
+
+		val, err := expr.Eval(ltf.ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		arg, ok := val.(string)
+		if !ok {
+			return sql.ErrInvalidArgumentDetails.New(ltf.FunctionName(), expr.String())
+		}
+		args[i] = arg
+	}
+
+	apr, err := cli.CreateLogArgParser().Parse(args)
+	if err != nil {
+		return sql.ErrInvalidArgumentDetails.New(ltf.FunctionName(), err.Error())
+	}
+
+	ltf.revisions = apr.Args
+	ltf.notRev = apr.GetValueOrDefault(cli.NotParam, "")
+	ltf.showParents = apr.Contains(cli.ParentsFlag)
+	ltf.decoration = apr.GetValueOrDefault(cli.DecorateParam, "no")
+
+	ltf.minParents = apr.GetIntOrDefault(cli.MinParentsParam, 0)
+	if apr.Contains(cli.MergesFlag) {
+		ltf.minParents = 2
+	}
+
+	ltf.tables = nil
+	if tables, ok := apr.GetValue(cli.TablesParam); ok {
+		for _, table := range strings.Split(tables, ",") {
+			if table = strings.TrimSpace(table); len(table) > 0 {
+				ltf.tables = append(ltf.tables, table)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Children implements the sql.Node interface
+func (ltf *LogTableFunction) Children() []sql.Node {
+	return nil
+}
+
+// WithChildren implements the sql.Node interface
+func (ltf *LogTableFunction) WithChildren(node ...sql.Node) (sql.Node, error) {
+	if len(node) != 0 {
+		panic("unexpected children")
+	}
+	return ltf, nil
+}
+
+// CheckPrivileges implements the sql.Node interface
+func (ltf *LogTableFunction) CheckPrivileges(ctx *sql.Context, opChecker sql.PrivilegedOperationChecker) bool {
+	return opChecker.UserHasPrivileges(ctx,
+		sql.NewPrivilegedOperation(ltf.database.Name(), "", "", sql.PrivilegeType_Select))
+}
+
+// Schema implements the sql.Node interface
+func (ltf *LogTableFunction) Schema() sql.Schema {
+	return ltf.sqlSch
+}
+
+// Resolved implements the sql.Resolvable interface
+func (ltf *LogTableFunction) Resolved() bool {
+	for _, expr := range ltf.argExprs {
+		if !expr.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements the Stringer interface
+func (ltf *LogTableFunction) String() string {
+	args := make([]string, len(ltf.argExprs))
+	for i, expr := range ltf.argExprs {
+		args[i] = expr.String()
+	}
+	return fmt.Sprintf("DOLT_LOG(%s)", strings.Join(args, ", "))
+}
+
+// FunctionName implements the sql.TableFunction interface
+func (ltf *LogTableFunction) FunctionName() string {
+	return "dolt_log"
+}
+
+// RowIter implements the sql.Node interface
+func (ltf *LogTableFunction) RowIter(ctx *sql.Context, _ sql.Row) (sql.RowIter, error) {
+	sqledb, ok := ltf.database.(Database)
+	if !ok {
+		return nil, fmt.Errorf("unexpected database type: %T", ltf.database)
+	}
+	ddb := sqledb.GetDoltDB()
+
+	sess := dsess.DSessFromSess(ctx.Session)
+	headRef, err := sess.CWBHeadRef(ctx, sqledb.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	resolve := func(spec string) (*doltdb.Commit, error) {
+		if len(spec) == 0 {
+			spec = "HEAD"
+		}
+		cs, err := doltdb.NewCommitSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		return ddb.Resolve(ctx, cs, headRef)
+	}
+
+	var included, excluded []hash.Hash
+	addRevision := func(spec string, exclude bool) error {
+		cm, err := resolve(spec)
+		if err != nil {
+			return err
+		}
+		h, err := cm.HashOf()
+		if err != nil {
+			return err
+		}
+		if exclude {
+			excluded = append(excluded, h)
+		} else {
+			included = append(included, h)
+		}
+		return nil
+	}
+
+	for _, rev := range ltf.revisions {
+		if strings.Contains(rev, "...") {
+			// A...B includes the commits reachable from either revision, but not from both
+			parts := strings.SplitN(rev, "...", 2)
+			left, err := resolve(parts[0])
+			if err != nil {
+				return nil, err
+			}
+			right, err := resolve(parts[1])
+			if err != nil {
+				return nil, err
+			}
+
+			leftHash, err := left.HashOf()
+			if err != nil {
+				return nil, err
+			}
+			rightHash, err := right.HashOf()
+			if err != nil {
+				return nil, err
+			}
+			common, err := commitwalk.GetCommonAncestorHeads(ctx, ddb, leftHash, rightHash)
+			if err != nil {
+				return nil, err
+			}
+
+			included = append(included, leftHash, rightHash)
+			excluded = append(excluded, common...)
+		} else if strings.Contains(rev, "..") {
+			// A..B includes the commits reachable from B, but not from A
+			parts := strings.SplitN(rev, "..", 2)
+			if err = addRevision(parts[0], true); err != nil {
+				return nil, err
+			}
+			if err = addRevision(parts[1], false); err != nil {
+				return nil, err
+			}
+		} else if strings.HasPrefix(rev, "^") {
+			if err = addRevision(rev[1:], true); err != nil {
+				return nil, err
+			}
+		} else {
+			if err = addRevision(rev, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(ltf.notRev) > 0 {
+		if err = addRevision(ltf.notRev, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(included) == 0 {
+		if err = addRevision("HEAD", false); err != nil {
+			return nil, err
+		}
+	}
+
+	matchFn := func(cm *doltdb.Commit) (bool, error) {
+		if cm.NumParents() < ltf.minParents {
+			return false, nil
+		}
+		if len(ltf.tables) == 0 {
+			return true, nil
+		}
+		return didTablesChange(ctx, cm, ltf.tables)
+	}
+
+	child, err := commitwalk.GetDotDotRevisionsIterator(ctx, ddb, included, excluded, matchFn)
+	if err != nil {
+		return nil, err
+	}
+
+	var decorations map[hash.Hash][]string
+	if ltf.decoration != "no" {
+		decorations, err = getCommitDecorations(ctx, ddb, ltf.decoration == "full")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &logTableFunctionRowIter{
+		child:       child,
+		showParents: ltf.showParents,
+		decorations: decorations,
+	}, nil
+}
+
+// didTablesChange returns whether any of |tables| differ between the commit given and its first parent. Creating
+// and dropping a table count as changes. For the initial commit, tables that exist are considered changed.
+func didTablesChange(ctx *sql.Context, cm *doltdb.Commit, tables []string) (bool, error) {
+	root, err := cm.GetRootValue(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var parentRoot *doltdb.RootValue
+	if cm.NumParents() > 0 {
+		parent, err := cm.GetParent(ctx, 0)
+		if err != nil {
+			return false, err
+		}
+		parentRoot, err = parent.GetRootValue(ctx)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	for _, table := range tables {
+		h, ok, err := getTableHashInsensitive(ctx, root, table)
+		if err != nil {
+			return false, err
+		}
+
+		if parentRoot == nil {
+			if ok {
+				return true, nil
+			}
+			continue
+		}
+
+		parentH, parentOk, err := getTableHashInsensitive(ctx, parentRoot, table)
+		if err != nil {
+			return false, err
+		}
+		if ok != parentOk || h != parentH {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func getTableHashInsensitive(ctx *sql.Context, root *doltdb.RootValue, table string) (hash.Hash, bool, error) {
+	name, ok, err := root.ResolveTableName(ctx, table)
+	if err != nil || !ok {
+		return hash.Hash{}, false, err
+	}
+	return root.GetTableHash(ctx, name)
+}
+
+// getCommitDecorations returns the names of the branches, remote branches and tags that point to each commit, in
+// the format that `dolt log --decorate` uses.
+func getCommitDecorations(ctx *sql.Context, ddb *doltdb.DoltDB, full bool) (map[hash.Hash][]string, error) {
+	decorations := make(map[hash.Hash][]string)
+	refName := func(r ref.DoltRef) string {
+		if full {
+			return r.String()
+		}
+		return r.GetPath()
+	}
+
+	branches, err := ddb.GetBranchesWithHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range branches {
+		decorations[b.Hash] = append(decorations[b.Hash], refName(b.Ref))
+	}
+
+	remotes, err := ddb.GetRemotesWithHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range remotes {
+		decorations[r.Hash] = append(decorations[r.Hash], refName(r.Ref))
+	}
+
+	tags, err := ddb.GetTagsWithHashes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		decorations[t.Hash] = append(decorations[t.Hash], "tag: "+refName(t.Tag.GetDoltRef()))
+	}
+
+	return decorations, nil
+}
+
+//------------------------------------
+// logTableFunctionRowIter
+//------------------------------------
+
+var _ sql.RowIter = (*logTableFunctionRowIter)(nil)
+
+type logTableFunctionRowIter struct {
+	child       doltdb.CommitItr
+	showParents bool
+	decorations map[hash.Hash][]string
+}
+
+// Next implements the sql.RowIter interface
+func (itr *logTableFunctionRowIter) Next(ctx *sql.Context) (sql.Row, error) {
+	h, cm, err := itr.child.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := cm.GetCommitMeta(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	row := sql.NewRow(h.String(), meta.Name, meta.Email, meta.Time(), meta.Description)
+
+	if itr.showParents {
+		parents, err := cm.ParentHashes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		parentStrs := make([]string, len(parents))
+		for i, p := range parents {
+			parentStrs[i] = p.String()
+		}
+		row = append(row, strings.Join(parentStrs, ", "))
+	}
+
+	if itr.decorations != nil {
+		row = append(row, strings.Join(itr.decorations[h], ", "))
+	}
+
+	return row, nil
+}
+
+// Close implements the sql.RowIter interface
+func (itr *logTableFunctionRowIter) Close(_ *sql.Context) error {
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+var _ sql.TableFunction = (*ReflogTableFunction)(nil)
+
+// ReflogTableFunction implements the dolt_reflog table function, which returns the movements of the refs of a
+// database, most recent first.
+type ReflogTableFunction struct {
+	ctx      *sql.Context
+	argExprs []sql.Expression
+	database sql.Database
+
+	refName            string
+	includeWorkingSets bool
+}
+
+var reflogTableFunctionSchema = sql.Schema{
+	&sql.Column{Name: "ref", Type: sql.Text},
+	&sql.Column{Name: "action", Type: sql.Text},
+	&sql.Column{Name: "old_hash", Type: sql.Text, Nullable: true},
+	&sql.Column{Name: "new_hash", Type: sql.Text, Nullable: true},
+	&sql.Column{Name: "date", Type: sql.Datetime},
+}
+
+// NewInstance implements the TableFunction interface
+func (rtf *ReflogTableFunction) NewInstance(ctx *sql.Context, database sql.Database, expressions []sql.Expression) (sql.Node, error) {
+	newInstance := &ReflogTableFunction{
+		ctx:      ctx,
+		database: database,
+	}
+
+	node, err := newInstance.WithExpressions(expressions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// Database implements the sql.Databaser interface
+func (rtf *ReflogTableFunction) Database() sql.Database {
+	return rtf.database
+}
+
+// WithDatabase implements the sql.Databaser interface
+func (rtf *ReflogTableFunction) WithDatabase(database sql.Database) (sql.Node, error) {
+	rtf.database = database
+
+	return rtf, nil
+}
+
+// Expressions implements the sql.Expressioner interface
+func (rtf *ReflogTableFunction) Expressions() []sql.Expression {
+	return rtf.argExprs
+}
+
+// WithExpressions implements the sql.Expressioner interface
+func (rtf *ReflogTableFunction) WithExpressions(expression ...sql.Expression) (sql.Node, error) {
+	for _, expr := range expression {
+		if !expr.Resolved() {
+			return nil, ErrInvalidNonLiteralArgument.New(rtf.FunctionName(), expr.String())
+		}
+	}
+
+	rtf.argExprs = expression
+
+	if err := rtf.evaluateArguments(); err != nil {
+		return nil, err
+	}
+
+	return rtf, nil
+}
+
+// evaluateArguments evaluates the argument expressions and parses them as the arguments of `dolt reflog`.
+func (rtf *ReflogTableFunction) evaluateArguments() error {
+	args := make([]string, len(rtf.argExprs))
+	for i, expr := range rtf.argExprs {
+		if !sql.IsText(expr.Type()) {
+			return sql.ErrInvalidArgumentDetails.New(rtf.FunctionName(), expr.String())
+		}
+
+		val, err := expr.Eval(rtf.ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		arg, ok := val.(string)
+		if !ok {
+			return sql.ErrInvalidArgumentDetails.New(rtf.FunctionName(), expr.String())
+		}
+		args[i] = arg
+	}
+
+	apr, err := cli.CreateReflogArgParser().Parse(args)
+	if err != nil {
+		return sql.ErrInvalidArgumentDetails.New(rtf.FunctionName(), err.Error())
+	}
+	if apr.NArg() > 1 {
+		return sql.ErrInvalidArgumentNumber.New(rtf.FunctionName(), "0 to 1", apr.NArg())
+	}
+
+	rtf.refName = ""
+	if apr.NArg() == 1 {
+		rtf.refName = apr.Arg(0)
+	}
+	rtf.includeWorkingSets = apr.Contains(cli.AllFlag)
+
+	return nil
+}
+
+// Children implements the sql.Node interface
+func (rtf *ReflogTableFunction) Children() []sql.Node {
+	return nil
+}
+
+// WithChildren implements the sql.Node interface
+func (rtf *ReflogTableFunction) WithChildren(node ...sql.Node) (sql.Node, error) {
+	if len(node) != 0 {
+		panic("unexpected children")
+	}
+	return rtf, nil
+}
+
+// CheckPrivileges implements the sql.Node interface
+func (rtf *ReflogTableFunction) CheckPrivileges(ctx *sql.Context, opChecker sql.PrivilegedOperationChecker) bool {
+	return opChecker.UserHasPrivileges(ctx,
+		sql.NewPrivilegedOperation(rtf.database.Name(), "", "", sql.PrivilegeType_Select))
+}
+
+// Schema implements the sql.Node interface
+func (rtf *ReflogTableFunction) Schema() sql.Schema {
+	return reflogTableFunctionSchema
+}
+
+// Resolved implements the sql.Resolvable interface
+func (rtf *ReflogTableFunction) Resolved() bool {
+	for _, expr := range rtf.argExprs {
+		if !expr.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements the Stringer interface
+func (rtf *ReflogTableFunction) String() string {
+	args := make([]string, len(rtf.argExprs))
+	for i, expr := range rtf.argExprs {
+		args[i] = expr.String()
+	}
+	return fmt.Sprintf("DOLT_REFLOG(%s)", strings.Join(args, ", "))
+}
+
+// FunctionName implements the sql.TableFunction interface
+func (rtf *ReflogTableFunction) FunctionName() string {
+	return "dolt_reflog"
+}
+
+// RowIter implements the sql.Node interface
+func (rtf *ReflogTableFunction) RowIter(ctx *sql.Context, _ sql.Row) (sql.RowIter, error) {
+	sqledb, ok := rtf.database.(Database)
+	if !ok {
+		return nil, fmt.Errorf("unexpected database type: %T", rtf.database)
+	}
+
+	entries, err := sqledb.GetDoltDB().Reflog(ctx, rtf.refName, rtf.includeWorkingSets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reflogTableFunctionRowIter{entries: entries}, nil
+}
+
+//------------------------------------
+// reflogTableFunctionRowIter
+//------------------------------------
+
+var _ sql.RowIter = (*reflogTableFunctionRowIter)(nil)
+
+type reflogTableFunctionRowIter struct {
+	entries []doltdb.ReflogEntry
+	idx     int
+}
+
+// Next implements the sql.RowIter interface
+func (itr *reflogTableFunctionRowIter) Next(ctx *sql.Context) (sql.Row, error) {
+	if itr.idx >= len(itr.entries) {
+		return nil, io.EOF
+	}
+	entry := itr.entries[itr.idx]
+	itr.idx++
+
+	return sql.NewRow(entry.Ref, entry.Action, hashOrNil(entry.OldHash), hashOrNil(entry.NewHash), entry.Timestamp), nil
+}
+
+// Close implements the sql.RowIter interface
+func (itr *reflogTableFunctionRowIter) Close(_ *sql.Context) error {
+	return nil
+}
+
+func hashOrNil(h hash.Hash) interface{} {
+	if h.IsEmpty() {
+		return nil
+	}
+	return h.String()
+}
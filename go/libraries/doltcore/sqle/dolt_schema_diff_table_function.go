@@ -0,0 +1,405 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/diff"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+)
+
+var _ sql.TableFunction = (*SchemaDiffTableFunction)(nil)
+
+// SchemaDiffTableFunction implements the dolt_schema_diff table function, which returns a row for every table whose
+// schema differs between two revisions.
+type SchemaDiffTableFunction struct {
+	ctx            *sql.Context
+	fromCommitExpr sql.Expression
+	toCommitExpr   sql.Expression
+	tableNameExpr  sql.Expression
+	database       sql.Database
+}
+
+var schemaDiffTableSchema = sql.Schema{
+	&sql.Column{Name: "from_table_name", Type: sql.LongText, Nullable: true},
+	&sql.Column{Name: "to_table_name", Type: sql.LongText, Nullable: true},
+	&sql.Column{Name: "from_create_statement", Type: sql.LongText, Nullable: true},
+	&sql.Column{Name: "to_create_statement", Type: sql.LongText, Nullable: true},
+	&sql.Column{Name: "added_columns", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "dropped_columns", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "modified_columns", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "added_indexes", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "dropped_indexes", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "modified_indexes", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "added_foreign_keys", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "dropped_foreign_keys", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "modified_foreign_keys", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "added_checks", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "dropped_checks", Type: sql.JSON, Nullable: false},
+	&sql.Column{Name: "modified_checks", Type: sql.JSON, Nullable: false},
+}
+
+// NewInstance implements the TableFunction interface
+func (sdtf *SchemaDiffTableFunction) NewInstance(ctx *sql.Context, database sql.Database, expressions []sql.Expression) (sql.Node, error) {
+	newInstance := &SchemaDiffTableFunction{
+		ctx:      ctx,
+		database: database,
+	}
+
+	node, err := newInstance.WithExpressions(expressions...)
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// Database implements the sql.Databaser interface
+func (sdtf *SchemaDiffTableFunction) Database() sql.Database {
+	return sdtf.database
+}
+
+// WithDatabase implements the sql.Databaser interface
+func (sdtf *SchemaDiffTableFunction) WithDatabase(database sql.Database) (sql.Node, error) {
+	sdtf.database = database
+
+	return sdtf, nil
+}
+
+// Expressions implements the sql.Expressioner interface
+func (sdtf *SchemaDiffTableFunction) Expressions() []sql.Expression {
+	exprs := []sql.Expression{sdtf.fromCommitExpr, sdtf.toCommitExpr}
+	if sdtf.tableNameExpr != nil {
+		exprs = append(exprs, sdtf.tableNameExpr)
+	}
+	return exprs
+}
+
+// WithExpressions implements the sql.Expressioner interface
+func (sdtf *SchemaDiffTableFunction) WithExpressions(expression ...sql.Expression) (sql.Node, error) {
+	if len(expression) < 2 || len(expression) > 3 {
+		return nil, sql.ErrInvalidArgumentNumber.New(sdtf.FunctionName(), "2 to 3", len(expression))
+	}
+
+	// As with dolt_diff, only literal / fully-resolved arguments are supported for now.
+	for _, expr := range expression {
+		if !expr.Resolved() {
+			return nil, ErrInvalidNonLiteralArgument.New(sdtf.FunctionName(), expr.String())
+		}
+	}
+
+	sdtf.fromCommitExpr = expression[0]
+	sdtf.toCommitExpr = expression[1]
+	if len(expression) == 3 {
+		sdtf.tableNameExpr = expression[2]
+	}
+
+	// Evaluate the arguments eagerly, so that invalid arguments are reported during analysis
+	if _, _, _, err := sdtf.evaluateArguments(); err != nil {
+		return nil, err
+	}
+
+	return sdtf, nil
+}
+
+// Children implements the sql.Node interface
+func (sdtf *SchemaDiffTableFunction) Children() []sql.Node {
+	return nil
+}
+
+// WithChildren implements the sql.Node interface
+func (sdtf *SchemaDiffTableFunction) WithChildren(node ...sql.Node) (sql.Node, error) {
+	if len(node) != 0 {
+		panic("unexpected children")
+	}
+	return sdtf, nil
+}
+
+// CheckPrivileges implements the sql.Node interface
+func (sdtf *SchemaDiffTableFunction) CheckPrivileges(ctx *sql.Context, opChecker sql.PrivilegedOperationChecker) bool {
+	_, _, tableName, err := sdtf.evaluateArguments()
+	if err != nil {
+		return false
+	}
+
+	// Without a table name, the schemas of every table are returned, which requires access to the whole database
+	return opChecker.UserHasPrivileges(ctx,
+		sql.NewPrivilegedOperation(sdtf.database.Name(), tableName, "", sql.PrivilegeType_Select))
+}
+
+// Schema implements the sql.Node interface
+func (sdtf *SchemaDiffTableFunction) Schema() sql.Schema {
+	return schemaDiffTableSchema
+}
+
+// Resolved implements the sql.Resolvable interface
+func (sdtf *SchemaDiffTableFunction) Resolved() bool {
+	for _, expr := range sdtf.Expressions() {
+		if !expr.Resolved() {
+			return false
+		}
+	}
+	return true
+}
+
+// String implements the Stringer interface
+func (sdtf *SchemaDiffTableFunction) String() string {
+	args := make([]string, 0, 3)
+	for _, expr := range sdtf.Expressions() {
+		args = append(args, expr.String())
+	}
+	return fmt.Sprintf("DOLT_SCHEMA_DIFF(%s)", strings.Join(args, ", "))
+}
+
+// FunctionName implements the sql.TableFunction interface
+func (sdtf *SchemaDiffTableFunction) FunctionName() string {
+	return "dolt_schema_diff"
+}
+
+// evaluateArguments evaluates the argument expressions to turn them into values this SchemaDiffTableFunction
+// can use. The table name is empty when it was not given.
+func (sdtf *SchemaDiffTableFunction) evaluateArguments() (interface{}, interface{}, string, error) {
+	if !sdtf.Resolved() {
+		return nil, nil, "", nil
+	}
+
+	if !sql.IsText(sdtf.fromCommitExpr.Type()) {
+		return nil, nil, "", sql.ErrInvalidArgumentDetails.New(sdtf.FunctionName(), sdtf.fromCommitExpr.String())
+	}
+
+	if !sql.IsText(sdtf.toCommitExpr.Type()) {
+		return nil, nil, "", sql.ErrInvalidArgumentDetails.New(sdtf.FunctionName(), sdtf.toCommitExpr.String())
+	}
+
+	fromCommitVal, err := sdtf.fromCommitExpr.Eval(sdtf.ctx, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	toCommitVal, err := sdtf.toCommitExpr.Eval(sdtf.ctx, nil)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var tableName string
+	if sdtf.tableNameExpr != nil {
+		if !sql.IsText(sdtf.tableNameExpr.Type()) {
+			return nil, nil, "", sql.ErrInvalidArgumentDetails.New(sdtf.FunctionName(), sdtf.tableNameExpr.String())
+		}
+
+		tableNameVal, err := sdtf.tableNameExpr.Eval(sdtf.ctx, nil)
+		if err != nil {
+			return nil, nil, "", err
+		}
+
+		var ok bool
+		tableName, ok = tableNameVal.(string)
+		if !ok {
+			return nil, nil, "", ErrInvalidTableName.New(sdtf.tableNameExpr.String())
+		}
+	}
+
+	return fromCommitVal, toCommitVal, tableName, nil
+}
+
+// RowIter implements the sql.Node interface
+func (sdtf *SchemaDiffTableFunction) RowIter(ctx *sql.Context, _ sql.Row) (sql.RowIter, error) {
+	fromCommitVal, toCommitVal, tableName, err := sdtf.evaluateArguments()
+	if err != nil {
+		return nil, err
+	}
+
+	sqledb, ok := sdtf.database.(Database)
+	if !ok {
+		return nil, fmt.Errorf("unexpected database type: %T", sdtf.database)
+	}
+
+	fromRoot, _, _, err := loadDetailsForRef(ctx, fromCommitVal, sqledb)
+	if err != nil {
+		return nil, err
+	}
+
+	toRoot, _, _, err := loadDetailsForRef(ctx, toCommitVal, sqledb)
+	if err != nil {
+		return nil, err
+	}
+
+	deltas, err := diff.GetTableDeltas(ctx, fromRoot, toRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tableName) > 0 {
+		delta := findMatchingDelta(deltas, tableName)
+		if delta.FromTable == nil && delta.ToTable == nil {
+			return nil, sql.ErrTableNotFound.New(tableName)
+		}
+		deltas = []diff.TableDelta{delta}
+	}
+
+	rows := make([]sql.Row, 0, len(deltas))
+	for _, delta := range deltas {
+		changed, err := delta.HasSchemaChanged(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !changed && !delta.IsRename() && !delta.HasFKChanges() {
+			continue
+		}
+
+		row, err := schemaDiffRow(ctx, delta)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return schemaDiffRowName(rows[i]) < schemaDiffRowName(rows[j])
+	})
+
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// schemaDiffRowName returns the name used to order the rows of dolt_schema_diff, which is the table's name in the
+// to revision, or its name in the from revision when it was dropped.
+func schemaDiffRowName(row sql.Row) string {
+	if row[1] != nil {
+		return row[1].(string)
+	}
+	return row[0].(string)
+}
+
+// schemaDiffRow returns the row of dolt_schema_diff for the table delta given.
+func schemaDiffRow(ctx *sql.Context, delta diff.TableDelta) (sql.Row, error) {
+	fromSch, toSch := schema.Schema(schema.EmptySchema), schema.Schema(schema.EmptySchema)
+	var fromName, toName, fromCreateStmt, toCreateStmt interface{}
+	var err error
+
+	if delta.FromTable != nil {
+		fromSch = delta.FromSch
+		fromName = delta.FromName
+		fromCreateStmt, err = schemaDiffCreateStmt(ctx, delta.FromName, delta.FromSch, delta.FromFks, delta.FromFksParentSch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if delta.ToTable != nil {
+		toSch = delta.ToSch
+		toName = delta.ToName
+		toCreateStmt, err = schemaDiffCreateStmt(ctx, delta.ToName, delta.ToSch, delta.ToFks, delta.ToFksParentSch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var cols, idxs, fks, checks schemaDiffNames
+
+	colDiffs, unionTags := diff.DiffSchColumns(fromSch, toSch)
+	for _, tag := range unionTags {
+		cd := colDiffs[tag]
+		switch cd.DiffType {
+		case diff.SchDiffAdded:
+			cols.add(cd.DiffType, "", cd.New.Name)
+		case diff.SchDiffRemoved:
+			cols.add(cd.DiffType, cd.Old.Name, "")
+		default:
+			cols.add(cd.DiffType, cd.Old.Name, cd.New.Name)
+		}
+	}
+
+	for _, idxDiff := range diff.DiffSchIndexes(fromSch, toSch) {
+		switch idxDiff.DiffType {
+		case diff.SchDiffAdded:
+			idxs.add(idxDiff.DiffType, "", idxDiff.To.Name())
+		case diff.SchDiffRemoved:
+			idxs.add(idxDiff.DiffType, idxDiff.From.Name(), "")
+		default:
+			idxs.add(idxDiff.DiffType, idxDiff.From.Name(), idxDiff.To.Name())
+		}
+	}
+
+	for _, fkDiff := range diff.DiffForeignKeys(delta.FromFks, delta.ToFks) {
+		fks.add(fkDiff.DiffType, fkDiff.From.Name, fkDiff.To.Name)
+	}
+
+	for _, chkDiff := range diff.DiffSchChecks(fromSch, toSch) {
+		switch chkDiff.DiffType {
+		case diff.SchDiffAdded:
+			checks.add(chkDiff.DiffType, "", chkDiff.To.Name())
+		case diff.SchDiffRemoved:
+			checks.add(chkDiff.DiffType, chkDiff.From.Name(), "")
+		default:
+			checks.add(chkDiff.DiffType, chkDiff.From.Name(), chkDiff.To.Name())
+		}
+	}
+
+	row := sql.Row{fromName, toName, fromCreateStmt, toCreateStmt}
+	for _, names := range []schemaDiffNames{cols, idxs, fks, checks} {
+		for _, list := range [][]string{names.added, names.dropped, names.modified} {
+			if list == nil {
+				list = []string{}
+			}
+			doc, err := sql.JSON.Convert(list)
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, doc)
+		}
+	}
+
+	return row, nil
+}
+
+// schemaDiffNames collects the names of the added, dropped and modified elements of a table's schema. Modified
+// elements are listed by their name in the to revision.
+type schemaDiffNames struct {
+	added    []string
+	dropped  []string
+	modified []string
+}
+
+func (n *schemaDiffNames) add(diffType diff.SchemaChangeType, fromName, toName string) {
+	switch diffType {
+	case diff.SchDiffAdded:
+		n.added = append(n.added, toName)
+	case diff.SchDiffRemoved:
+		n.dropped = append(n.dropped, fromName)
+	case diff.SchDiffModified:
+		n.modified = append(n.modified, toName)
+	}
+}
+
+// schemaDiffCreateStmt returns the CREATE TABLE statement for the table given, as SHOW CREATE TABLE would.
+func schemaDiffCreateStmt(ctx *sql.Context, tableName string, sch schema.Schema, fks []doltdb.ForeignKey, parentSchs map[string]schema.Schema) (string, error) {
+	sqlDb := NewSingleTableDatabase(tableName, sch, fks, parentSchs)
+	sqlCtx, engine, _ := PrepareCreateTableStmt(ctx, sqlDb)
+	if sqlCtx == nil {
+		return "", fmt.Errorf("unable to generate the CREATE TABLE statement for table %s", tableName)
+	}
+
+	stmt, err := GetCreateTableStmt(sqlCtx, engine, tableName)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(stmt, ";"), nil
+}
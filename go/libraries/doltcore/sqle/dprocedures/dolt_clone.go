@@ -15,6 +15,7 @@
 package dprocedures
 
 import (
+	"fmt"
 	"path"
 
 	"github.com/dolthub/go-mysql-server/sql"
@@ -38,6 +39,10 @@ func doltClone(ctx *sql.Context, args ...string) (sql.RowIter, error) {
 
 	remoteName := apr.GetValueOrDefault(cli.RemoteParam, "origin")
 	branch := apr.GetValueOrDefault(cli.BranchParam, "")
+	depth, ok := apr.GetInt(cli.DepthParam)
+	if ok && depth < 1 {
+		return nil, fmt.Errorf("error: depth %d is not a positive number", depth)
+	}
 	dir, urlStr, err := getDirectoryAndUrlString(apr)
 	if err != nil {
 		return nil, err
@@ -54,7 +59,7 @@ func doltClone(ctx *sql.Context, args ...string) (sql.RowIter, error) {
 		return nil, err
 	}
 
-	err = sess.Provider().CloneDatabaseFromRemote(ctx, dir, branch, remoteName, remoteUrl, params)
+	err = sess.Provider().CloneDatabaseFromRemote(ctx, dir, branch, remoteName, remoteUrl, apr.Contains(cli.SingleBranchFlag), depth, params)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,58 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dprocedures
+
+import (
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/cmd/dolt/errhand"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+)
+
+// doltCreateDatabase is a stored procedure to create a database whose data lives in a remote storage, rather than in
+// the data directory of the server. It stands in for CREATE DATABASE name USING 'url', which the SQL parser doesn't
+// support. A storage that already holds a database is served as it is, without cloning it.
+func doltCreateDatabase(ctx *sql.Context, args ...string) (sql.RowIter, error) {
+	ap := cli.CreateCreateDatabaseArgParser()
+	apr, err := ap.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if apr.NArg() != 2 {
+		return nil, errhand.BuildDError("error: invalid number of arguments: the name of the database and the URL of its storage must be specified").Build()
+	}
+	name, urlStr := apr.Arg(0), apr.Arg(1)
+
+	sess := dsess.DSessFromSess(ctx.Session)
+	scheme, storageUrl, err := env.GetAbsRemoteUrl(sess.Provider().FileSystem(), emptyConfig(), urlStr)
+	if err != nil {
+		return nil, errhand.BuildDError("error: '%s' is not valid.", urlStr).Build()
+	}
+
+	params, err := remoteParams(apr, scheme, storageUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sess.Provider().CreateDatabaseWithStorage(ctx, name, storageUrl, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return rowToIter(int64(0)), nil
+}
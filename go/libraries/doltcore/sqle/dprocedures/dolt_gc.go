@@ -0,0 +1,158 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dprocedures
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqlserver"
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+// gcPollInterval is how often dolt_gc() checks whether the queries that were running when it started have finished.
+const gcPollInterval = 10 * time.Millisecond
+
+// doltGC is the stored procedure version of the CLI command `dolt gc`. Unlike the CLI command, it collects the garbage
+// of the current database while the server continues to serve reads and writes. It returns the number of bytes freed.
+func doltGC(ctx *sql.Context, args ...string) (sql.RowIter, error) {
+	freed, err := DoDoltGC(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return rowToIter(int64(0), int64(freed)), nil
+}
+
+// DoDoltGC collects the garbage of the current database of |ctx| while it continues to be used by other sessions, and
+// returns the number of bytes freed.
+func DoDoltGC(ctx *sql.Context, args []string) (uint64, error) {
+	if len(args) > 0 {
+		return 0, fmt.Errorf("error: dolt_gc does not take arguments")
+	}
+
+	dbName := ctx.GetCurrentDatabase()
+	if len(dbName) == 0 {
+		return 0, fmt.Errorf("Empty database name.")
+	}
+
+	dSess := dsess.DSessFromSess(ctx.Session)
+	ddb, ok := dSess.GetDoltDB(ctx, dbName)
+	if !ok {
+		return 0, fmt.Errorf("Could not load database %s", dbName)
+	}
+
+	sizeBefore, err := ddb.StoreSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	ctx.GetLogger().Infof("dolt_gc: collecting garbage in database %s", dbName)
+
+	err = ddb.OnlineGC(ctx, func(_ context.Context) ([]hash.Hash, error) {
+		return gcKeepers(ctx, ddb)
+	})
+	if errors.Is(err, chunks.ErrNothingToCollect) {
+		ctx.GetLogger().Infof("dolt_gc: nothing to collect in database %s", dbName)
+		return 0, nil
+	} else if errors.Is(err, chunks.ErrUnsupportedOperation) {
+		return 0, fmt.Errorf("database %s does not support online garbage collection", dbName)
+	} else if err != nil {
+		return 0, err
+	}
+
+	sizeAfter, err := ddb.StoreSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var freed uint64
+	if sizeBefore > sizeAfter {
+		freed = sizeBefore - sizeAfter
+	}
+
+	ctx.GetLogger().Infof("dolt_gc: collected garbage in database %s in %s, freeing %d bytes", dbName, time.Since(start), freed)
+
+	return freed, nil
+}
+
+// gcKeepers returns the values of |ddb| that the open sessions of the server depend on. It's called once the chunks
+// written to |ddb| are being kept, and first waits for the queries that were already running to finish, since the
+// values they write before completing may only be reachable from their session's state.
+func gcKeepers(ctx *sql.Context, ddb *doltdb.DoltDB) ([]hash.Hash, error) {
+	err := waitForRunningQueries(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if !sqlserver.RunningInServerMode() || sqlserver.GetRunningServer() == nil {
+		return dsess.DSessFromSess(ctx.Session).GCKeepers(ctx, ddb)
+	}
+
+	var keepers []hash.Hash
+	err = sqlserver.GetRunningServer().SessionManager().Iter(func(session sql.Session) (bool, error) {
+		dSess, ok := session.(*dsess.DoltSession)
+		if !ok {
+			return false, fmt.Errorf("unexpected session type: %T", session)
+		}
+
+		sessKeepers, err := dSess.GCKeepers(ctx, ddb)
+		if err != nil {
+			return true, err
+		}
+		keepers = append(keepers, sessKeepers...)
+
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keepers, nil
+}
+
+// waitForRunningQueries blocks until every query of another connection that was started before |started| has
+// finished.
+func waitForRunningQueries(ctx *sql.Context, started time.Time) error {
+	if ctx.ProcessList == nil {
+		return nil
+	}
+
+	for {
+		running := false
+		for _, proc := range ctx.ProcessList.Processes() {
+			if proc.Connection != ctx.Session.ID() && proc.StartedAt.Before(started) {
+				running = true
+				break
+			}
+		}
+		if !running {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(gcPollInterval):
+		}
+	}
+}
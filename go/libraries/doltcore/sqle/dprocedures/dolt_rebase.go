@@ -0,0 +1,133 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dprocedures
+
+import (
+	"fmt"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+)
+
+// doltRebase is the stored procedure version of the CLI `dolt rebase` command
+func doltRebase(ctx *sql.Context, args ...string) (sql.RowIter, error) {
+	res, err := doDoltRebase(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return rowToIter(int64(res)), nil
+}
+
+// doDoltRebase starts, continues or aborts a rebase of the current branch. It returns 1 if the rebase stopped because
+// of conflicts, which must be resolved before calling dolt_rebase('--continue'), and 0 otherwise. The plan of an
+// interactive rebase is edited through the dolt_rebase system table.
+func doDoltRebase(ctx *sql.Context, args []string) (int, error) {
+	dbName := ctx.GetCurrentDatabase()
+	if len(dbName) == 0 {
+		return 1, fmt.Errorf("Empty database name.")
+	}
+	dSess := dsess.DSessFromSess(ctx.Session)
+
+	apr, err := cli.CreateRebaseArgParser().Parse(args)
+	if err != nil {
+		return 1, err
+	}
+
+	ws, err := dSess.WorkingSet(ctx, dbName)
+	if err != nil {
+		return 1, err
+	}
+
+	switch {
+	case apr.Contains(cli.AbortParam):
+		if apr.NArg() != 0 || apr.Contains(cli.ContinueFlag) {
+			return 1, fmt.Errorf("error: --abort does not accept other arguments")
+		}
+		if !ws.RebaseActive() {
+			return 1, doltdb.ErrNoRebaseInProgress
+		}
+		return 0, dSess.SetWorkingSet(ctx, dbName, ws.AbortRebase())
+	case apr.Contains(cli.ContinueFlag):
+		if apr.NArg() != 0 {
+			return 1, fmt.Errorf("error: --continue does not accept other arguments")
+		}
+		if !ws.RebaseActive() {
+			return 1, doltdb.ErrNoRebaseInProgress
+		}
+		return continueRebase(ctx, dSess, dbName, ws)
+	}
+
+	if apr.NArg() != 1 {
+		return 1, fmt.Errorf("error: exactly one upstream commit must be given")
+	}
+
+	dbData, ok := dSess.GetDbData(ctx, dbName)
+	if !ok {
+		return 1, fmt.Errorf("Could not load database %s", dbName)
+	}
+	headRef := dbData.Rsr.CWBHeadRef()
+
+	upstreamSpec, err := doltdb.NewCommitSpec(apr.Arg(0))
+	if err != nil {
+		return 1, err
+	}
+	upstream, err := dbData.Ddb.Resolve(ctx, upstreamSpec, headRef)
+	if err != nil {
+		return 1, err
+	}
+	headCommit, err := dSess.GetHeadCommit(ctx, dbName)
+	if err != nil {
+		return 1, err
+	}
+
+	ws, err = merge.StartRebase(ctx, dbData.Ddb, ws, headCommit, upstream, headRef.GetPath(), apr.Contains(cli.InteractiveFlag))
+	if err == doltdb.ErrUpToDate {
+		return 0, nil
+	} else if err != nil {
+		return 1, err
+	}
+
+	if apr.Contains(cli.InteractiveFlag) {
+		return 0, dSess.SetWorkingSet(ctx, dbName, ws)
+	}
+	return continueRebase(ctx, dSess, dbName, ws)
+}
+
+func continueRebase(ctx *sql.Context, dSess *dsess.DoltSession, dbName string, ws *doltdb.WorkingSet) (int, error) {
+	dbData, ok := dSess.GetDbData(ctx, dbName)
+	if !ok {
+		return 1, fmt.Errorf("Could not load database %s", dbName)
+	}
+	dbState, ok, err := dSess.LookupDbState(ctx, dbName)
+	if err != nil {
+		return 1, err
+	} else if !ok {
+		return 1, fmt.Errorf("Could not load database %s", dbName)
+	}
+
+	ws, newHead, err := merge.ContinueRebase(ctx, dbData.Ddb, ws, dbState.EditOpts())
+	if err == merge.ErrRebaseStoppedOnConflicts {
+		return 1, dSess.SetWorkingSet(ctx, dbName, ws)
+	} else if err != nil {
+		return 1, err
+	}
+
+	// the branch is moved to the rebased commits when the transaction commits, along with the working set
+	return 0, dSess.SetWorkingSetAndHead(ctx, dbName, ws, newHead)
+}
@@ -139,12 +139,15 @@ func remoteParams(apr *argparser.ArgParseResults, scheme, remoteUrl string) (map
 	params := map[string]string{}
 
 	var err error
-	if scheme == dbfactory.AWSScheme {
+	if scheme == dbfactory.AWSScheme || scheme == dbfactory.S3Scheme {
 		// TODO: get AWS params from session
 		err = cli.AddAWSParams(remoteUrl, apr, params)
 	} else {
 		err = cli.VerifyNoAwsParams(apr)
 	}
+	if err == nil {
+		err = cli.AddUserParam(scheme, apr, params)
+	}
 
 	if err != nil {
 		return nil, errhand.VerboseErrorFromError(err)
@@ -0,0 +1,222 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dprocedures
+
+import (
+	"fmt"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/env/actions"
+	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/utils/argparser"
+	"github.com/dolthub/dolt/go/store/datas"
+)
+
+// doltStash is the stored procedure version of the CLI `dolt stash` command
+func doltStash(ctx *sql.Context, args ...string) (sql.RowIter, error) {
+	res, err := doDoltStash(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	return rowToIter(int64(res)), nil
+}
+
+// doDoltStash is used as sql dolt_stash command for pushing, applying and dropping stash entries, not listing.
+// To list stash entries, dolt_stashes system table is used.
+func doDoltStash(ctx *sql.Context, args []string) (int, error) {
+	dbName := ctx.GetCurrentDatabase()
+	if len(dbName) == 0 {
+		return 1, fmt.Errorf("Empty database name.")
+	}
+	dSess := dsess.DSessFromSess(ctx.Session)
+
+	apr, err := cli.CreateStashArgParser().Parse(args)
+	if err != nil {
+		return 1, err
+	}
+
+	subcommand := "push"
+	if apr.NArg() > 0 {
+		subcommand = apr.Arg(0)
+	}
+
+	switch subcommand {
+	case "push":
+		err = stashPush(ctx, dSess, dbName, apr)
+	case "pop", "apply":
+		err = stashApply(ctx, dSess, dbName, apr, subcommand == "pop")
+	case "drop":
+		err = stashDrop(ctx, dSess, dbName, apr)
+	case "clear":
+		err = stashClear(ctx, dSess, dbName, apr)
+	case "list":
+		err = fmt.Errorf("error: invalid argument, use 'dolt_stashes' system table to list stash entries")
+	default:
+		err = fmt.Errorf("error: unknown subcommand '%s'", subcommand)
+	}
+
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+func stashPush(ctx *sql.Context, dSess *dsess.DoltSession, dbName string, apr *argparser.ArgParseResults) error {
+	if apr.NArg() > 1 {
+		return fmt.Errorf("error: stash push does not accept arguments")
+	}
+
+	ws, err := dSess.WorkingSet(ctx, dbName)
+	if err != nil {
+		return err
+	}
+	if ws.MergeActive() {
+		return fmt.Errorf("error: cannot stash changes while a merge is in progress")
+	}
+
+	ddb, ok := dSess.GetDoltDB(ctx, dbName)
+	if !ok {
+		return fmt.Errorf("Could not load database %s", dbName)
+	}
+	roots, ok := dSess.GetRoots(ctx, dbName)
+	if !ok {
+		return fmt.Errorf("Could not load database %s", dbName)
+	}
+	headCommit, err := dSess.GetHeadCommit(ctx, dbName)
+	if err != nil {
+		return err
+	}
+	headRef, err := dSess.CWBHeadRef(ctx, dbName)
+	if err != nil {
+		return err
+	}
+
+	desc, err := actions.StashDescription(ctx, headRef.GetPath(), headCommit, apr.GetValueOrDefault(cli.MessageArg, ""))
+	if err != nil {
+		return err
+	}
+	meta, err := datas.NewCommitMeta(dSess.Username(), dSess.Email(), desc)
+	if err != nil {
+		return err
+	}
+
+	_, roots, err = actions.StashChanges(ctx, ddb, roots, headCommit, apr.Contains(cli.UntrackedFlag), meta)
+	if err != nil {
+		return err
+	}
+
+	return dSess.SetRoots(ctx, dbName, roots)
+}
+
+func stashApply(ctx *sql.Context, dSess *dsess.DoltSession, dbName string, apr *argparser.ArgParseResults, pop bool) error {
+	ddb, stash, err := resolveStash(ctx, dSess, dbName, apr)
+	if err != nil {
+		return err
+	}
+
+	ws, err := dSess.WorkingSet(ctx, dbName)
+	if err != nil {
+		return err
+	}
+	if ws.MergeActive() {
+		return fmt.Errorf("error: cannot apply a stash while a merge is in progress")
+	}
+
+	dbState, ok, err := dSess.LookupDbState(ctx, dbName)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("Could not load database %s", dbName)
+	}
+
+	roots, ok := dSess.GetRoots(ctx, dbName)
+	if !ok {
+		return fmt.Errorf("Could not load database %s", dbName)
+	}
+
+	roots, tblToStats, err := merge.ApplyStash(ctx, roots, stash, dbState.EditOpts())
+	if err != nil {
+		return err
+	}
+
+	err = dSess.SetRoots(ctx, dbName, roots)
+	if err != nil {
+		return err
+	}
+
+	for _, stats := range tblToStats {
+		if stats.Conflicts > 0 || stats.ConstraintViolations > 0 {
+			// the stash entry is kept around until the conflicts are resolved
+			return nil
+		}
+	}
+
+	if pop {
+		return ddb.DropStash(ctx, stash)
+	}
+	return nil
+}
+
+func stashDrop(ctx *sql.Context, dSess *dsess.DoltSession, dbName string, apr *argparser.ArgParseResults) error {
+	ddb, stash, err := resolveStash(ctx, dSess, dbName, apr)
+	if err != nil {
+		return err
+	}
+	return ddb.DropStash(ctx, stash)
+}
+
+func stashClear(ctx *sql.Context, dSess *dsess.DoltSession, dbName string, apr *argparser.ArgParseResults) error {
+	if apr.NArg() > 1 {
+		return fmt.Errorf("error: stash clear does not accept arguments")
+	}
+
+	ddb, ok := dSess.GetDoltDB(ctx, dbName)
+	if !ok {
+		return fmt.Errorf("Could not load database %s", dbName)
+	}
+	return ddb.ClearStashes(ctx)
+}
+
+// resolveStash returns the stash entry named by the optional second argument, defaulting to the most recent entry.
+func resolveStash(ctx *sql.Context, dSess *dsess.DoltSession, dbName string, apr *argparser.ArgParseResults) (*doltdb.DoltDB, *doltdb.Stash, error) {
+	if apr.NArg() > 2 {
+		return nil, nil, fmt.Errorf("error: too many arguments")
+	}
+
+	ddb, ok := dSess.GetDoltDB(ctx, dbName)
+	if !ok {
+		return nil, nil, fmt.Errorf("Could not load database %s", dbName)
+	}
+
+	idx := 0
+	if apr.NArg() == 2 {
+		var err error
+		idx, err = doltdb.ParseStashName(apr.Arg(1))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stash, err := ddb.GetStash(ctx, idx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ddb, stash, nil
+}
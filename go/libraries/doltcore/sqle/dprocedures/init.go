@@ -24,13 +24,17 @@ var DoltProcedures = []sql.ExternalStoredProcedureDetails{
 	{Name: "dolt_clean", Schema: int64Schema("status"), Function: doltClean},
 	{Name: "dolt_clone", Schema: int64Schema("status"), Function: doltClone},
 	{Name: "dolt_commit", Schema: stringSchema("hash"), Function: doltCommit},
+	{Name: "dolt_create_database", Schema: int64Schema("status"), Function: doltCreateDatabase},
 	{Name: "dolt_fetch", Schema: int64Schema("success"), Function: doltFetch},
+	{Name: "dolt_gc", Schema: int64Schema("status", "freed_bytes"), Function: doltGC},
 	{Name: "dolt_merge", Schema: int64Schema("fast_forward", "conflicts"), Function: doltMerge},
 	{Name: "dolt_pull", Schema: int64Schema("fast_forward", "conflicts"), Function: doltPull},
 	{Name: "dolt_push", Schema: int64Schema("success"), Function: doltPush},
+	{Name: "dolt_rebase", Schema: int64Schema("status"), Function: doltRebase},
 	{Name: "dolt_remote", Schema: int64Schema("status"), Function: doltRemote},
 	{Name: "dolt_reset", Schema: int64Schema("status"), Function: doltReset},
 	{Name: "dolt_revert", Schema: int64Schema("status"), Function: doltRevert},
+	{Name: "dolt_stash", Schema: int64Schema("status"), Function: doltStash},
 	{Name: "dolt_tag", Schema: int64Schema("status"), Function: doltTag},
 	{Name: "dolt_verify_constraints", Schema: int64Schema("violations"), Function: doltVerifyConstraints},
 	{Name: "dadd", Schema: int64Schema("status"), Function: doltAdd},
@@ -42,13 +46,24 @@ var DoltProcedures = []sql.ExternalStoredProcedureDetails{
 	{Name: "dmerge", Schema: int64Schema("fast_forward", "conflicts"), Function: doltMerge},
 	{Name: "dpull", Schema: int64Schema("fast_forward", "conflicts"), Function: doltPull},
 	{Name: "dpush", Schema: int64Schema("success"), Function: doltPush},
+	{Name: "drebase", Schema: int64Schema("status"), Function: doltRebase},
 	{Name: "dremote", Schema: int64Schema("status"), Function: doltRemote},
 	{Name: "dreset", Schema: int64Schema("status"), Function: doltReset},
 	{Name: "drevert", Schema: int64Schema("status"), Function: doltRevert},
+	{Name: "dstash", Schema: int64Schema("status"), Function: doltStash},
 	{Name: "dtag", Schema: int64Schema("status"), Function: doltTag},
 	{Name: "dverify_constraints", Schema: int64Schema("violations"), Function: doltVerifyConstraints},
 }
 
+// ProcedureRegistry holds the procedures of an engine in addition to DoltProcedures. It's used for the procedures
+// that are only available in some configurations of an engine, such as the procedures of a cluster, which are bound to
+// the state of that engine.
+type ProcedureRegistry interface {
+	// RegisterExternalStoredProcedure adds |proc| to the procedures of the engine, replacing the procedure of the same
+	// name if there is one.
+	RegisterExternalStoredProcedure(proc sql.ExternalStoredProcedureDetails)
+}
+
 // stringSchema returns a non-nullable schema with all columns as LONGTEXT.
 func stringSchema(columnNames ...string) sql.Schema {
 	sch := make(sql.Schema, len(columnNames))
@@ -0,0 +1,44 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dprocedures
+
+import (
+	"fmt"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+)
+
+// CheckGlobalPrivilege returns an error unless the user of |ctx| has at least one of the global privileges |privs| in
+// |privileges|. Privileges are only checked once they're enabled, as the analyzer does. Procedures have to check
+// their privileges themselves, since the analyzer doesn't check any for them.
+func CheckGlobalPrivilege(ctx *sql.Context, privileges *mysql_db.MySQLDb, privs ...sql.PrivilegeType) error {
+	if privileges == nil || !privileges.Enabled {
+		return nil
+	}
+
+	client := ctx.Session.Client()
+	user := privileges.GetUser(client.User, client.Address, false)
+	if user == nil {
+		return sql.ErrPrivilegeCheckFailed.New(fmt.Sprintf("'%s'@'%s'", client.User, client.Address))
+	}
+	privSet := privileges.UserActivePrivilegeSet(ctx)
+	for _, priv := range privs {
+		if privSet.Has(priv) {
+			return nil
+		}
+	}
+	return sql.ErrPrivilegeCheckFailed.New(user.UserHostToString("'"))
+}
@@ -0,0 +1,157 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsess
+
+import (
+	"net"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/branch_control"
+)
+
+// SetBranchController sets the branch permissions enforced for this session. Sessions without a controller, such as
+// those of most CLI commands, may write to and create, rename and delete any branch.
+func (d *DoltSession) SetBranchController(controller *branch_control.Controller) {
+	d.branchController = controller
+}
+
+// WriteGuard returns an error if the database named may not be written to at all, regardless of the branch, such as
+// when the server is a standby in a cluster.
+type WriteGuard func(dbName string) error
+
+// SetWriteGuard sets the guard that every write of this session is checked against before its branch permissions.
+func (d *DoltSession) SetWriteGuard(guard WriteGuard) {
+	d.writeGuard = guard
+}
+
+// checkWriteGuard returns the error of the write guard of this session for the database named, if it has one.
+func (d *DoltSession) checkWriteGuard(dbName string) error {
+	if d.writeGuard == nil {
+		return nil
+	}
+	return d.writeGuard(baseDatabaseName(dbName))
+}
+
+// BranchController returns the branch permissions enforced for this session, or nil if there are none.
+func (d *DoltSession) BranchController() *branch_control.Controller {
+	return d.branchController
+}
+
+// BranchControlClient returns the user and host of this session's client, as used to match the rows of the branch
+// control tables.
+func (d *DoltSession) BranchControlClient() (string, string) {
+	client := d.Session.Client()
+	host := client.Address
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return client.User, host
+}
+
+// baseDatabaseName returns the name of the database given, without any revision qualifier.
+func baseDatabaseName(dbName string) string {
+	return strings.SplitN(dbName, "/", 2)[0]
+}
+
+// CheckBranchWrite returns an error if this session may not write to the branch given of the database named.
+func (d *DoltSession) CheckBranchWrite(dbName, branch string) error {
+	if err := d.checkWriteGuard(dbName); err != nil {
+		return err
+	}
+	if d.branchController == nil {
+		return nil
+	}
+	user, host := d.BranchControlClient()
+	return d.branchController.CheckWrite(baseDatabaseName(dbName), branch, user, host)
+}
+
+// CheckBranchCreate returns an error if this session may not create the branch given in the database named.
+func (d *DoltSession) CheckBranchCreate(dbName, branch string) error {
+	if err := d.checkWriteGuard(dbName); err != nil {
+		return err
+	}
+	if d.branchController == nil {
+		return nil
+	}
+	user, host := d.BranchControlClient()
+	return d.branchController.CheckCreate(baseDatabaseName(dbName), branch, user, host)
+}
+
+// CheckBranchDelete returns an error if this session may not delete the branch given in the database named.
+func (d *DoltSession) CheckBranchDelete(dbName, branch string) error {
+	if err := d.checkWriteGuard(dbName); err != nil {
+		return err
+	}
+	if d.branchController == nil {
+		return nil
+	}
+	user, host := d.BranchControlClient()
+	return d.branchController.CheckDelete(baseDatabaseName(dbName), branch, user, host)
+}
+
+// CheckBranchRename returns an error if this session may not rename |oldBranch| to |newBranch| in the database named.
+func (d *DoltSession) CheckBranchRename(dbName, oldBranch, newBranch string) error {
+	if err := d.checkWriteGuard(dbName); err != nil {
+		return err
+	}
+	if d.branchController == nil {
+		return nil
+	}
+	user, host := d.BranchControlClient()
+	return d.branchController.CheckRename(baseDatabaseName(dbName), oldBranch, newBranch, user, host)
+}
+
+// BranchCreated records that this session created the branch given in the database named, making its client an
+// admin of the branch.
+func (d *DoltSession) BranchCreated(dbName, branch string) error {
+	if d.branchController == nil {
+		return nil
+	}
+	user, host := d.BranchControlClient()
+	return d.branchController.GrantCreator(baseDatabaseName(dbName), branch, user, host)
+}
+
+// BranchDeleted records that the branch given in the database named was deleted or renamed, revoking the admin
+// permissions that were granted on its name.
+func (d *DoltSession) BranchDeleted(dbName, branch string) error {
+	if d.branchController == nil {
+		return nil
+	}
+	return d.branchController.RevokeBranch(baseDatabaseName(dbName), branch)
+}
+
+// checkWorkingSetWrite returns an error if this session may not write to the branch of the working set of the
+// database named.
+func (d *DoltSession) checkWorkingSetWrite(ctx *sql.Context, dbName string) error {
+	if err := d.checkWriteGuard(dbName); err != nil {
+		return err
+	}
+	if d.branchController == nil {
+		return nil
+	}
+
+	sessionState, ok, err := d.LookupDbState(ctx, dbName)
+	if err != nil || !ok || sessionState.WorkingSet == nil {
+		return err
+	}
+
+	headRef, err := sessionState.WorkingSet.Ref().ToHeadRef()
+	if err != nil {
+		return err
+	}
+	return d.CheckBranchWrite(dbName, headRef.GetPath())
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/globalstate"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/writer"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
+	"github.com/dolthub/dolt/go/store/hash"
 )
 
 type InitialDbState struct {
@@ -55,6 +56,12 @@ type DatabaseSessionState struct {
 	readReplica  *env.Remote
 	tmpFileDir   string
 
+	// pendingHead is the commit the session moved the branch of its working set to, which is written along with the
+	// working set when the transaction is committed, or nil if the branch wasn't moved. pendingHeadFrom is the
+	// commit the branch was at before, which it must still be at when the transaction is committed.
+	pendingHead     *doltdb.Commit
+	pendingHeadFrom hash.Hash
+
 	TblStats map[string]sql.TableStatistics
 
 	sessionCache *SessionCache
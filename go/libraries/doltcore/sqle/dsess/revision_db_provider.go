@@ -50,7 +50,17 @@ type DoltDatabaseProvider interface {
 	// dbName is the name for the new database, branch is an optional parameter indicating which branch to clone
 	// (otherwise all branches are cloned), remoteName is the name for the remote created in the new database, and
 	// remoteUrl is a URL (e.g. "file:///dbs/db1") or an <org>/<database> path indicating a database hosted on DoltHub.
-	CloneDatabaseFromRemote(ctx *sql.Context, dbName, branch, remoteName, remoteUrl string, remoteParams map[string]string) error
+	// singleBranch limits the clone to the history of a single branch, and a depth greater than zero truncates that
+	// history to the given number of commits.
+	CloneDatabaseFromRemote(ctx *sql.Context, dbName, branch, remoteName, remoteUrl string, singleBranch bool, depth int, remoteParams map[string]string) error
+	// CreateDatabaseWithStorage creates a new database in this provider whose data lives in the remote storage at
+	// storageUrl (e.g. "gs://bucket/path/db"), loaded with storageParams, instead of in the data directory, which only
+	// holds its repo state and a cache of the chunks read from the storage. A storage without branches is initialized
+	// as a new database.
+	CreateDatabaseWithStorage(ctx *sql.Context, dbName, storageUrl string, storageParams map[string]string) error
+	// ExternalStoredProcedures returns the procedures registered with the engine of this provider, which its databases
+	// have in addition to the dolt procedures.
+	ExternalStoredProcedures() []sql.ExternalStoredProcedureDetails
 }
 
 func EmptyDatabaseProvider() DoltDatabaseProvider {
@@ -67,7 +77,15 @@ func (e emptyRevisionDatabaseProvider) FileSystem() filesys.Filesys {
 	return nil
 }
 
-func (e emptyRevisionDatabaseProvider) CloneDatabaseFromRemote(ctx *sql.Context, dbName, branch, remoteName, remoteUrl string, remoteParams map[string]string) error {
+func (e emptyRevisionDatabaseProvider) CloneDatabaseFromRemote(ctx *sql.Context, dbName, branch, remoteName, remoteUrl string, singleBranch bool, depth int, remoteParams map[string]string) error {
+	return nil
+}
+
+func (e emptyRevisionDatabaseProvider) CreateDatabaseWithStorage(ctx *sql.Context, dbName, storageUrl string, storageParams map[string]string) error {
+	return nil
+}
+
+func (e emptyRevisionDatabaseProvider) ExternalStoredProcedures() []sql.ExternalStoredProcedureDetails {
 	return nil
 }
 
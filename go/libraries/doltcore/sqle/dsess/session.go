@@ -15,6 +15,7 @@
 package dsess
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -26,6 +27,7 @@ import (
 	goerrors "gopkg.in/src-d/go-errors.v1"
 
 	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/branch_control"
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env/actions"
@@ -34,6 +36,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/writer"
 	"github.com/dolthub/dolt/go/libraries/doltcore/table/editor"
 	"github.com/dolthub/dolt/go/libraries/utils/config"
+	"github.com/dolthub/dolt/go/store/hash"
 	"github.com/dolthub/dolt/go/store/types"
 )
 
@@ -58,19 +61,23 @@ const (
 
 var ErrWorkingSetChanges = goerrors.NewKind("Cannot switch working set, session state is dirty. " +
 	"Rollback or commit changes before changing working sets.")
+var ErrPendingHead = goerrors.NewKind("Cannot create a commit in the transaction that moved the branch. " +
+	"Commit the transaction before committing new changes.")
 var ErrSessionNotPeristable = errors.New("session is not persistable")
 
 // DoltSession is the sql.Session implementation used by dolt. It is accessible through a *sql.Context instance
 type DoltSession struct {
 	sql.Session
-	batchMode   batchMode
-	username    string
-	email       string
-	dbStates    map[string]*DatabaseSessionState
-	provider    DoltDatabaseProvider
-	tempTables  map[string][]sql.Table
-	globalsConf config.ReadWriteConfig
-	mu          *sync.Mutex
+	batchMode        batchMode
+	username         string
+	email            string
+	dbStates         map[string]*DatabaseSessionState
+	provider         DoltDatabaseProvider
+	tempTables       map[string][]sql.Table
+	globalsConf      config.ReadWriteConfig
+	branchController *branch_control.Controller
+	writeGuard       WriteGuard
+	mu               *sync.Mutex
 }
 
 var _ sql.Session = (*DoltSession)(nil)
@@ -226,7 +233,8 @@ func (d *DoltSession) StartTransaction(ctx *sql.Context, dbName string, tCharact
 	// logrus.Tracef("starting transaction with working root %s", ws.WorkingRoot().DebugString(ctx, true))
 
 	// TODO: this is going to do 2 resolves to get the head root, not ideal
-	err = d.SetWorkingSet(ctx, dbName, ws)
+	sessionState.pendingHead = nil
+	err = d.setWorkingSet(ctx, dbName, ws)
 
 	// SetWorkingSet always sets the dirty bit, but by definition we are clean at transaction start
 	sessionState.dirty = false
@@ -286,7 +294,13 @@ func (d *DoltSession) CommitTransaction(ctx *sql.Context, dbName string, tx sql.
 		return fmt.Errorf(fmt.Sprintf("Unexpected type for var %s: %T", DoltCommitOnTransactionCommit, performDoltCommitVar))
 	}
 
-	if peformDoltCommitInt == 1 {
+	// a branch the session moved is committed along with the working set, without a new commit on top
+	dbState, _, err := d.LookupDbState(ctx, dbName)
+	if err != nil {
+		return err
+	}
+
+	if peformDoltCommitInt == 1 && dbState.pendingHead == nil {
 		pendingCommit, err := d.PendingCommitAllStaged(ctx, dbName, actions.CommitStagedProps{
 			Message:    "Transaction commit",
 			Date:       ctx.QueryTime(),
@@ -324,6 +338,10 @@ func (d *DoltSession) CommitWorkingSet(ctx *sql.Context, dbName string, tx sql.T
 	}
 
 	commitFunc := func(ctx *sql.Context, dtx *DoltTransaction, workingSet *doltdb.WorkingSet) (*doltdb.WorkingSet, *doltdb.Commit, error) {
+		if dbState.pendingHead != nil {
+			ws, err := dtx.CommitWithHead(ctx, workingSet, dbState.pendingHeadFrom, dbState.pendingHead)
+			return ws, nil, err
+		}
 		ws, err := dtx.Commit(ctx, workingSet)
 		return ws, nil, err
 	}
@@ -340,6 +358,14 @@ func (d *DoltSession) DoltCommit(
 	tx sql.Transaction,
 	commit *doltdb.PendingCommit,
 ) (*doltdb.Commit, error) {
+	dbState, _, err := d.LookupDbState(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if dbState.pendingHead != nil {
+		return nil, ErrPendingHead.New()
+	}
+
 	commitFunc := func(ctx *sql.Context, dtx *DoltTransaction, workingSet *doltdb.WorkingSet) (*doltdb.WorkingSet, *doltdb.Commit, error) {
 		ws, commit, err := dtx.DoltCommit(
 			ctx,
@@ -382,12 +408,17 @@ func (d *DoltSession) doCommit(ctx *sql.Context, dbName string, tx sql.Transacti
 		return nil, fmt.Errorf("expected a DoltTransaction")
 	}
 
+	if err = d.checkWorkingSetWrite(ctx, dbName); err != nil {
+		return nil, err
+	}
+
 	mergedWorkingSet, newCommit, err := commitFunc(ctx, dtx, dbState.WorkingSet)
 	if err != nil {
 		return nil, err
 	}
 
-	err = d.SetWorkingSet(ctx, dbName, mergedWorkingSet)
+	dbState.pendingHead = nil
+	err = d.setWorkingSet(ctx, dbName, mergedWorkingSet)
 	if err != nil {
 		return nil, err
 	}
@@ -423,6 +454,9 @@ func (d *DoltSession) NewPendingCommit(ctx *sql.Context, dbName string, roots do
 
 	var mergeParentCommits []*doltdb.Commit
 	if sessionState.WorkingSet.MergeActive() {
+		if mergeState := sessionState.WorkingSet.MergeState(); mergeState.HasSchemaConflicts() {
+			return nil, actions.NewTblSchemaConflictError(mergeState.SchemaConflictTables())
+		}
 		mergeParentCommits = []*doltdb.Commit{sessionState.WorkingSet.MergeState().Commit()}
 	}
 
@@ -457,6 +491,7 @@ func (d *DoltSession) RollbackTransaction(ctx *sql.Context, dbName string, tx sq
 	// This operation usually doesn't matter, because the engine will process a `rollback` statement by first calling
 	// this logic, then discarding any current transaction. So the next statement will get a fresh transaction regardless,
 	// and this is throwaway work. It only matters if this method is used outside a standalone `rollback` statement.
+	dbState.pendingHead = nil
 	err = d.SetRoot(ctx, dbName, dtx.startState.WorkingRoot())
 	if err != nil {
 		return err
@@ -570,6 +605,77 @@ func (d *DoltSession) GetRoots(ctx *sql.Context, dbName string) (doltdb.Roots, b
 	return dbState.GetRoots(), true
 }
 
+// GCKeepers returns the hashes of the values in |ddb| that this session depends on: the head commit, the working and
+// staged roots and any merge state of every database of the session stored in |ddb|. The roots are written to |ddb|
+// first, since they may not have been persisted yet. It's used to keep the uncommitted changes of open sessions during
+// an online garbage collection, and may be called from outside the session's goroutine: it snapshots the session
+// states under the session lock, which every write of these states takes as well.
+func (d *DoltSession) GCKeepers(ctx context.Context, ddb *doltdb.DoltDB) ([]hash.Hash, error) {
+	type gcState struct {
+		headCommit *doltdb.Commit
+		roots      doltdb.Roots
+		workingSet *doltdb.WorkingSet
+	}
+
+	var states []gcState
+	func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for _, dbState := range d.dbStates {
+			if dbState.Err == nil && dbState.dbData.Ddb == ddb {
+				states = append(states, gcState{
+					headCommit: dbState.headCommit,
+					roots:      dbState.GetRoots(),
+					workingSet: dbState.WorkingSet,
+				})
+			}
+		}
+	}()
+
+	var keepers []hash.Hash
+	writeRoot := func(root *doltdb.RootValue) error {
+		if root == nil {
+			return nil
+		}
+		_, h, err := ddb.WriteRootValue(ctx, root)
+		if err != nil {
+			return err
+		}
+		keepers = append(keepers, h)
+		return nil
+	}
+
+	for _, dbState := range states {
+		if dbState.headCommit != nil {
+			h, err := dbState.headCommit.HashOf()
+			if err != nil {
+				return nil, err
+			}
+			keepers = append(keepers, h)
+		}
+
+		for _, root := range []*doltdb.RootValue{dbState.roots.Working, dbState.roots.Staged} {
+			if err := writeRoot(root); err != nil {
+				return nil, err
+			}
+		}
+
+		if ws := dbState.workingSet; ws != nil && ws.MergeActive() {
+			h, err := ws.MergeState().Commit().HashOf()
+			if err != nil {
+				return nil, err
+			}
+			keepers = append(keepers, h)
+
+			if err := writeRoot(ws.MergeState().PreMergeWorkingRoot()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return keepers, nil
+}
+
 // ResolveRootForRef returns the root value for the ref given, which refers to either a commit spec or is one of the
 // special identifiers |WORKING| or |STAGED|
 // Returns the root value associated with the identifier given and its commit time
@@ -644,9 +750,8 @@ func (d *DoltSession) SetRoot(ctx *sql.Context, dbName string, newRoot *doltdb.R
 		// TODO: Return an error here?
 		return nil
 	}
-	sessionState.WorkingSet = sessionState.WorkingSet.WithWorkingRoot(newRoot)
 
-	return d.SetWorkingSet(ctx, dbName, sessionState.WorkingSet)
+	return d.SetWorkingSet(ctx, dbName, sessionState.WorkingSet.WithWorkingRoot(newRoot))
 }
 
 // SetRoots sets new roots for the session for the database named. Typically clients should only set the working root,
@@ -664,42 +769,76 @@ func (d *DoltSession) SetRoots(ctx *sql.Context, dbName string, roots doltdb.Roo
 }
 
 // SetWorkingSet sets the working set for this session.
-// Unlike setting the working root alone, this method always marks the session dirty.
+// Unlike setting the working root alone, this method always marks the session dirty. It returns an error if this
+// session may not write to the branch of the working set.
 func (d *DoltSession) SetWorkingSet(ctx *sql.Context, dbName string, ws *doltdb.WorkingSet) error {
-	if ws == nil {
-		panic("attempted to set a nil working set for the session")
+	if err := d.checkWorkingSetWrite(ctx, dbName); err != nil {
+		return err
+	}
+	return d.setWorkingSet(ctx, dbName, ws)
+}
+
+// SetWorkingSetAndHead sets the working set for this session like SetWorkingSet, and moves the branch of the working
+// set to |head|. The branch is moved along with the working set when the transaction is committed, and only if no
+// other transaction moved it since.
+func (d *DoltSession) SetWorkingSetAndHead(ctx *sql.Context, dbName string, ws *doltdb.WorkingSet, head *doltdb.Commit) error {
+	if err := d.checkWorkingSetWrite(ctx, dbName); err != nil {
+		return err
 	}
 
 	sessionState, _, err := d.LookupDbState(ctx, dbName)
 	if err != nil {
 		return err
 	}
-	if ws.Ref() != sessionState.WorkingSet.Ref() {
-		return fmt.Errorf("must switch working sets with SwitchWorkingSet")
+	if sessionState.pendingHead == nil {
+		sessionState.pendingHeadFrom, err = sessionState.headCommit.HashOf()
+		if err != nil {
+			return err
+		}
 	}
-	sessionState.WorkingSet = ws
+	sessionState.pendingHead = head
 
-	cs, err := doltdb.NewCommitSpec(ws.Ref().GetPath())
-	if err != nil {
-		return err
+	return d.setWorkingSet(ctx, dbName, ws)
+}
+
+// setWorkingSet sets the working set for this session without checking the session's branch permissions. It is used
+// when loading the working set into the session, and by callers that have already checked them.
+func (d *DoltSession) setWorkingSet(ctx *sql.Context, dbName string, ws *doltdb.WorkingSet) error {
+	if ws == nil {
+		panic("attempted to set a nil working set for the session")
 	}
 
-	branchRef, err := ws.Ref().ToHeadRef()
+	sessionState, _, err := d.LookupDbState(ctx, dbName)
 	if err != nil {
 		return err
 	}
+	if ws.Ref() != sessionState.WorkingSet.Ref() {
+		return fmt.Errorf("must switch working sets with SwitchWorkingSet")
+	}
 
-	cm, err := sessionState.dbData.Ddb.Resolve(ctx, cs, branchRef)
-	if err != nil {
-		return err
+	cm := sessionState.pendingHead
+	if cm == nil {
+		cs, err := doltdb.NewCommitSpec(ws.Ref().GetPath())
+		if err != nil {
+			return err
+		}
+
+		branchRef, err := ws.Ref().ToHeadRef()
+		if err != nil {
+			return err
+		}
+
+		cm, err = sessionState.dbData.Ddb.Resolve(ctx, cs, branchRef)
+		if err != nil {
+			return err
+		}
 	}
-	sessionState.headCommit = cm
 
 	headRoot, err := cm.GetRootValue(ctx)
 	if err != nil {
 		return err
 	}
-	sessionState.headRoot = headRoot
+	d.setWorkingSetAndHead(sessionState, ws, cm, headRoot)
 
 	err = d.setSessionVarsForDb(ctx, dbName)
 	if err != nil {
@@ -716,6 +855,16 @@ func (d *DoltSession) SetWorkingSet(ctx *sql.Context, dbName string, ws *doltdb.
 	return nil
 }
 
+// setWorkingSetAndHead sets the working set, head commit and head root of |sessionState|. These are written under the
+// session lock, since GCKeepers reads them from outside the session's goroutine.
+func (d *DoltSession) setWorkingSetAndHead(sessionState *DatabaseSessionState, ws *doltdb.WorkingSet, headCommit *doltdb.Commit, headRoot *doltdb.RootValue) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	sessionState.WorkingSet = ws
+	sessionState.headCommit = headCommit
+	sessionState.headRoot = headRoot
+}
+
 // SwitchWorkingSet switches to a new working set for this session. Unlike SetWorkingSet, this method expresses no
 // intention to eventually persist any uncommitted changes. Rather, this method only changes the in memory state of
 // this session. It's equivalent to starting a new session with the working set reference provided. If the current
@@ -741,8 +890,6 @@ func (d *DoltSession) SwitchWorkingSet(
 	}
 
 	// TODO: just call SetWorkingSet?
-	sessionState.WorkingSet = ws
-
 	cs, err := doltdb.NewCommitSpec(ws.Ref().GetPath())
 	if err != nil {
 		return err
@@ -758,11 +905,11 @@ func (d *DoltSession) SwitchWorkingSet(
 		return err
 	}
 
-	sessionState.headCommit = cm
-	sessionState.headRoot, err = cm.GetRootValue(ctx)
+	headRoot, err := cm.GetRootValue(ctx)
 	if err != nil {
 		return err
 	}
+	d.setWorkingSetAndHead(sessionState, ws, cm, headRoot)
 
 	err = d.setSessionVarsForDb(ctx, dbName)
 	if err != nil {
@@ -912,7 +1059,6 @@ func (d *DoltSession) AddDB(ctx *sql.Context, dbState InitialDbState) error {
 	DefineSystemVariablesForDB(db.Name())
 
 	sessionState := NewEmptyDatabaseSessionState()
-	d.dbStates[db.Name()] = sessionState
 	sessionState.dbName = db.Name()
 	// TODO: get rid of all repo state reader / writer stuff. Until we do, swap out the reader with one of our own, and
 	//  the writer with one that errors out
@@ -923,6 +1069,12 @@ func (d *DoltSession) AddDB(ctx *sql.Context, dbState InitialDbState) error {
 	sessionState.dbData.Rsw = adapter
 	sessionState.readOnly, sessionState.readReplica = dbState.ReadOnly, dbState.ReadReplica
 
+	// the state is in the session from here on, even if it fails to load below. The fields GCKeepers reads are only
+	// written under the session lock.
+	d.mu.Lock()
+	d.dbStates[db.Name()] = sessionState
+	d.mu.Unlock()
+
 	// TODO: figure out how to cast this to dsqle.SqlDatabase without creating import cycles
 	nbf := types.Format_Default
 	if sessionState.dbData.Ddb != nil {
@@ -937,31 +1089,42 @@ func (d *DoltSession) AddDB(ctx *sql.Context, dbState InitialDbState) error {
 	sessionState.globalState = stateProvider.GetGlobalState()
 
 	// WorkingSet is nil in the case of a read only, detached head DB
-	if dbState.Err != nil {
-		sessionState.Err = dbState.Err
-
-	} else if dbState.WorkingSet != nil {
-		sessionState.WorkingSet = dbState.WorkingSet
-		tracker, err := sessionState.globalState.GetAutoIncrementTracker(ctx, sessionState.WorkingSet)
+	var workingSet *doltdb.WorkingSet
+	var writeSession writer.WriteSession
+	var headRoot *doltdb.RootValue
+	if dbState.Err == nil && dbState.WorkingSet != nil {
+		workingSet = dbState.WorkingSet
+		tracker, err := sessionState.globalState.GetAutoIncrementTracker(ctx, workingSet)
 		if err != nil {
 			return err
 		}
-		sessionState.WriteSession = writer.NewWriteSession(nbf, sessionState.WorkingSet, tracker, editOpts)
-		if err = d.SetWorkingSet(ctx, db.Name(), dbState.WorkingSet); err != nil {
-			return err
-		}
+		writeSession = writer.NewWriteSession(nbf, workingSet, tracker, editOpts)
 
-	} else {
-		headRoot, err := dbState.HeadCommit.GetRootValue(ctx)
+	} else if dbState.Err == nil {
+		var err error
+		headRoot, err = dbState.HeadCommit.GetRootValue(ctx)
 		if err != nil {
 			return err
 		}
-		sessionState.headRoot = headRoot
 	}
 
-	// This has to happen after SetRoot above, since it does a stale check before its work
-	// TODO: this needs to be kept up to date as the working set ref changes
+	d.mu.Lock()
+	sessionState.Err = dbState.Err
 	sessionState.headCommit = dbState.HeadCommit
+	sessionState.WorkingSet = workingSet
+	sessionState.WriteSession = writeSession
+	sessionState.headRoot = headRoot
+	d.mu.Unlock()
+
+	if dbState.Err == nil && dbState.WorkingSet != nil {
+		if err := d.setWorkingSet(ctx, db.Name(), dbState.WorkingSet); err != nil {
+			return err
+		}
+
+		// This has to happen after SetWorkingSet above, since it does a stale check before its work
+		// TODO: this needs to be kept up to date as the working set ref changes
+		d.setWorkingSetAndHead(sessionState, sessionState.WorkingSet, dbState.HeadCommit, sessionState.headRoot)
+	}
 
 	// After setting the initial root we have no state to commit
 	sessionState.dirty = false
@@ -23,6 +23,7 @@ import (
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/env"
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/store/hash"
 )
 
 // SessionStateAdapter is an adapter for env.RepoStateReader in SQL contexts, getting information about the repo state
@@ -167,6 +168,14 @@ func (s SessionStateAdapter) RemoveBackup(ctx context.Context, name string) erro
 	return fmt.Errorf("cannot delete remote in an SQL session")
 }
 
+func (s SessionStateAdapter) GetShallowCommits() (hash.HashSet, error) {
+	return hash.NewHashSet(), nil
+}
+
+func (s SessionStateAdapter) SetShallowCommits(commits hash.HashSet) error {
+	return fmt.Errorf("cannot record shallow commits in an SQL session")
+}
+
 func (s SessionStateAdapter) TempTableFilesDir() string {
 	return s.session.GetDbStates()[s.dbName].tmpFileDir
 }
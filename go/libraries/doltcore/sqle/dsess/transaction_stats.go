@@ -0,0 +1,44 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dsess
+
+// TransactionStats records the outcome of transaction commits. It does nothing by default, and is set by servers
+// that export metrics.
+var TransactionStats TransactionStatsRecorder = NullTransactionStatsRecorder{}
+
+// TransactionStatsRecorder records the retries and merges of transaction commits. Every method is called with the
+// name of the database of the transaction and the branch of its working set.
+type TransactionStatsRecorder interface {
+	// RecordCommitRetry is called when a commit lost the race to update the working set and is retried.
+	RecordCommitRetry(dbName, branch string)
+	// RecordCommitMerge is called when a commit is merged with a working set written by another transaction.
+	RecordCommitMerge(dbName, branch string)
+	// RecordCommitConflict is called when a commit is rolled back because its working set has merge conflicts.
+	RecordCommitConflict(dbName, branch string)
+}
+
+var _ TransactionStatsRecorder = NullTransactionStatsRecorder{}
+
+type NullTransactionStatsRecorder struct {
+}
+
+func (NullTransactionStatsRecorder) RecordCommitRetry(dbName, branch string) {
+}
+
+func (NullTransactionStatsRecorder) RecordCommitMerge(dbName, branch string) {
+}
+
+func (NullTransactionStatsRecorder) RecordCommitConflict(dbName, branch string) {
+}
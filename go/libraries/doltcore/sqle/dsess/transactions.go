@@ -128,6 +128,21 @@ func (tx *DoltTransaction) Commit(ctx *sql.Context, workingSet *doltdb.WorkingSe
 	return ws, err
 }
 
+// CommitWithHead commits the working set like Commit, and moves the branch of the working set from |prevHead| to
+// |head| in the same atomic write. It fails with doltdb.ErrHeadMoved if the branch isn't at |prevHead| anymore.
+func (tx *DoltTransaction) CommitWithHead(ctx *sql.Context, workingSet *doltdb.WorkingSet, prevHead hash.Hash, head *doltdb.Commit) (*doltdb.WorkingSet, error) {
+	writeFn := func(ctx *sql.Context, tx *DoltTransaction, _ *doltdb.PendingCommit, workingSet *doltdb.WorkingSet, hash hash.Hash) (*doltdb.WorkingSet, *doltdb.Commit, error) {
+		headRef, err := workingSet.Ref().ToHeadRef()
+		if err != nil {
+			return nil, nil, err
+		}
+		err = tx.dbData.Ddb.SetHeadWithWorkingSet(ctx, headRef, tx.workingSetRef, prevHead, head, workingSet, hash, tx.getWorkingSetMeta(ctx))
+		return workingSet, nil, err
+	}
+	ws, _, err := tx.doCommit(ctx, workingSet, nil, writeFn)
+	return ws, err
+}
+
 // transactionWrite is the logic to write an updated working set (and optionally a commit) to the database
 type transactionWrite func(ctx *sql.Context,
 	tx *DoltTransaction, // the transaction being written
@@ -230,6 +245,7 @@ func (tx *DoltTransaction) doCommit(
 				return nil, nil, err
 			}
 			logrus.Tracef("merge took %s", time.Since(start))
+			TransactionStats.RecordCommitMerge(tx.sourceDbName, tx.branchName())
 
 			err = tx.validateWorkingSetForCommit(ctx, mergedWorkingSet, notFfMerge)
 			if err != nil {
@@ -253,12 +269,22 @@ func (tx *DoltTransaction) doCommit(
 		} else if updatedWs != nil {
 			return updatedWs, newCommit, nil
 		}
+		TransactionStats.RecordCommitRetry(tx.sourceDbName, tx.branchName())
 	}
 
 	// TODO: different error type for retries exhausted
 	return nil, nil, datas.ErrOptimisticLockFailed
 }
 
+// branchName returns the name of the branch of the working set of this transaction
+func (tx *DoltTransaction) branchName() string {
+	headRef, err := tx.workingSetRef.ToHeadRef()
+	if err != nil {
+		return tx.workingSetRef.GetPath()
+	}
+	return headRef.GetPath()
+}
+
 // mergeRoots merges the roots in the existing working set with the one being committed and returns the resulting
 // working set. Conflicts are automatically resolved with "accept ours" if the session settings dictate it.
 func (tx *DoltTransaction) mergeRoots(
@@ -363,6 +389,7 @@ func (tx *DoltTransaction) validateWorkingSetForCommit(ctx *sql.Context, working
 				return rollbackErr
 			}
 
+			TransactionStats.RecordCommitConflict(tx.sourceDbName, tx.branchName())
 			return sql.ErrLockDeadlock.New(ErrRetryTransaction.Error())
 		}
 
@@ -374,6 +401,7 @@ func (tx *DoltTransaction) validateWorkingSetForCommit(ctx *sql.Context, working
 				return rollbackErr
 			}
 
+			TransactionStats.RecordCommitConflict(tx.sourceDbName, tx.branchName())
 			return ErrUnresolvedConflictsCommit
 		}
 	}
@@ -0,0 +1,425 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dtables
+
+import (
+	"fmt"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/vitess/go/sqltypes"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/branch_control"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/index"
+)
+
+var branchControlPatternType = sql.MustCreateString(sqltypes.VarChar, 16383, sql.Collation_utf8mb4_0900_ai_ci)
+var branchControlUserType = sql.MustCreateString(sqltypes.VarChar, 16383, sql.Collation_utf8mb4_0900_bin)
+var branchControlPermissionsType = sql.MustCreateSetType(branch_control.PermissionNames, sql.Collation_utf8mb4_0900_ai_ci)
+
+var _ sql.Table = (*BranchControlTable)(nil)
+var _ sql.InsertableTable = (*BranchControlTable)(nil)
+var _ sql.ReplaceableTable = (*BranchControlTable)(nil)
+var _ sql.UpdatableTable = (*BranchControlTable)(nil)
+var _ sql.DeletableTable = (*BranchControlTable)(nil)
+
+// BranchControlTable is a sql.Table implementation that implements a system table which shows and edits the branch
+// permissions of sql-server users. The table is shared by every database on the server.
+type BranchControlTable struct {
+	controller *branch_control.Controller
+}
+
+// NewBranchControlTable creates a BranchControlTable
+func NewBranchControlTable(_ *sql.Context, controller *branch_control.Controller) sql.Table {
+	return &BranchControlTable{controller: controller}
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// BranchControlTableName
+func (bt *BranchControlTable) Name() string {
+	return doltdb.BranchControlTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// BranchControlTableName
+func (bt *BranchControlTable) String() string {
+	return doltdb.BranchControlTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the branch control system table.
+func (bt *BranchControlTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "database", Type: branchControlPatternType, Source: doltdb.BranchControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "branch", Type: branchControlPatternType, Source: doltdb.BranchControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "user", Type: branchControlUserType, Source: doltdb.BranchControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "host", Type: branchControlPatternType, Source: doltdb.BranchControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "permissions", Type: branchControlPermissionsType, Source: doltdb.BranchControlTableName, PrimaryKey: false, Nullable: false},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data. Currently, the data is unpartitioned.
+func (bt *BranchControlTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return index.SinglePartitionIterFromNomsMap(nil), nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition
+func (bt *BranchControlTable) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	access := bt.controller.Access()
+	rows := make([]sql.Row, len(access))
+	for i, a := range access {
+		rows[i] = sql.NewRow(a.Database, a.Branch, a.User, a.Host, uint64(a.Permissions))
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// Inserter implements sql.InsertableTable
+func (bt *BranchControlTable) Inserter(*sql.Context) sql.RowInserter {
+	return &branchControlWriter{controller: bt.controller}
+}
+
+// Replacer implements sql.ReplaceableTable
+func (bt *BranchControlTable) Replacer(*sql.Context) sql.RowReplacer {
+	return &branchControlWriter{controller: bt.controller}
+}
+
+// Updater implements sql.UpdatableTable
+func (bt *BranchControlTable) Updater(*sql.Context) sql.RowUpdater {
+	return &branchControlWriter{controller: bt.controller}
+}
+
+// Deleter implements sql.DeletableTable
+func (bt *BranchControlTable) Deleter(*sql.Context) sql.RowDeleter {
+	return &branchControlWriter{controller: bt.controller}
+}
+
+// branchControlWriter collects the edits made to the dolt_branch_control table during a statement, and applies them
+// to the controller when it is closed. Every edited row is checked against the permissions of the session's client.
+type branchControlWriter struct {
+	controller *branch_control.Controller
+	rows       []branch_control.Access
+	loaded     bool
+}
+
+var _ sql.RowReplacer = (*branchControlWriter)(nil)
+var _ sql.RowUpdater = (*branchControlWriter)(nil)
+
+func (w *branchControlWriter) load() {
+	if !w.loaded {
+		w.rows = w.controller.Access()
+		w.loaded = true
+	}
+}
+
+func (w *branchControlWriter) indexOf(a branch_control.Access) int {
+	for i := range w.rows {
+		if w.rows[i].SamePatterns(a) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert inserts the row given, returning an error if it cannot. Inserting a row whose patterns already exist is an
+// error.
+func (w *branchControlWriter) Insert(ctx *sql.Context, r sql.Row) error {
+	a, err := accessFromRow(r)
+	if err != nil {
+		return err
+	}
+	if err = checkBranchControlModify(ctx, w.controller, a.Database, a.Branch); err != nil {
+		return err
+	}
+
+	w.load()
+	if w.indexOf(a) >= 0 {
+		return sql.NewUniqueKeyErr(fmt.Sprintf("[%s, %s, %s, %s]", a.Database, a.Branch, a.User, a.Host), true, r)
+	}
+	w.rows = append(w.rows, a)
+	return nil
+}
+
+// Update the given row. Provides both the old and new rows.
+func (w *branchControlWriter) Update(ctx *sql.Context, old sql.Row, new sql.Row) error {
+	if err := w.Delete(ctx, old); err != nil {
+		return err
+	}
+	return w.Insert(ctx, new)
+}
+
+// Delete deletes the given row. Returns ErrDeleteRowNotFound if the row was not found.
+func (w *branchControlWriter) Delete(ctx *sql.Context, r sql.Row) error {
+	a, err := accessFromRow(r)
+	if err != nil {
+		return err
+	}
+	if err = checkBranchControlModify(ctx, w.controller, a.Database, a.Branch); err != nil {
+		return err
+	}
+
+	w.load()
+	idx := w.indexOf(a)
+	if idx < 0 {
+		return sql.ErrDeleteRowNotFound.New()
+	}
+	w.rows = append(w.rows[:idx], w.rows[idx+1:]...)
+	return nil
+}
+
+// StatementBegin implements the interface sql.TableEditor. Currently a no-op.
+func (w *branchControlWriter) StatementBegin(ctx *sql.Context) {}
+
+// DiscardChanges implements the interface sql.TableEditor.
+func (w *branchControlWriter) DiscardChanges(ctx *sql.Context, errorEncountered error) error {
+	w.rows = nil
+	w.loaded = false
+	return nil
+}
+
+// StatementComplete implements the interface sql.TableEditor. Currently a no-op.
+func (w *branchControlWriter) StatementComplete(ctx *sql.Context) error {
+	return nil
+}
+
+// Close applies the edited rows to the controller, which persists them.
+func (w *branchControlWriter) Close(ctx *sql.Context) error {
+	if !w.loaded {
+		return nil
+	}
+	return w.controller.SetAccess(w.rows)
+}
+
+func accessFromRow(r sql.Row) (branch_control.Access, error) {
+	strs, err := branchControlStrings(r[:4])
+	if err != nil {
+		return branch_control.Access{}, err
+	}
+	perms, err := branchControlPermissionsType.Convert(r[4])
+	if err != nil {
+		return branch_control.Access{}, err
+	}
+	return branch_control.Access{
+		Database:    strs[0],
+		Branch:      strs[1],
+		User:        strs[2],
+		Host:        strs[3],
+		Permissions: branch_control.Permissions(perms.(uint64)),
+	}, nil
+}
+
+var _ sql.Table = (*BranchNamespaceControlTable)(nil)
+var _ sql.InsertableTable = (*BranchNamespaceControlTable)(nil)
+var _ sql.ReplaceableTable = (*BranchNamespaceControlTable)(nil)
+var _ sql.UpdatableTable = (*BranchNamespaceControlTable)(nil)
+var _ sql.DeletableTable = (*BranchNamespaceControlTable)(nil)
+
+// BranchNamespaceControlTable is a sql.Table implementation that implements a system table which shows and edits
+// which sql-server users may create branches with particular names. The table is shared by every database on the
+// server.
+type BranchNamespaceControlTable struct {
+	controller *branch_control.Controller
+}
+
+// NewBranchNamespaceControlTable creates a BranchNamespaceControlTable
+func NewBranchNamespaceControlTable(_ *sql.Context, controller *branch_control.Controller) sql.Table {
+	return &BranchNamespaceControlTable{controller: controller}
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// BranchNamespaceControlTableName
+func (nt *BranchNamespaceControlTable) Name() string {
+	return doltdb.BranchNamespaceControlTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// BranchNamespaceControlTableName
+func (nt *BranchNamespaceControlTable) String() string {
+	return doltdb.BranchNamespaceControlTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the branch namespace control system table.
+func (nt *BranchNamespaceControlTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "database", Type: branchControlPatternType, Source: doltdb.BranchNamespaceControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "branch", Type: branchControlPatternType, Source: doltdb.BranchNamespaceControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "user", Type: branchControlUserType, Source: doltdb.BranchNamespaceControlTableName, PrimaryKey: true, Nullable: false},
+		{Name: "host", Type: branchControlPatternType, Source: doltdb.BranchNamespaceControlTableName, PrimaryKey: true, Nullable: false},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data. Currently, the data is unpartitioned.
+func (nt *BranchNamespaceControlTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return index.SinglePartitionIterFromNomsMap(nil), nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition
+func (nt *BranchNamespaceControlTable) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	namespace := nt.controller.Namespace()
+	rows := make([]sql.Row, len(namespace))
+	for i, n := range namespace {
+		rows[i] = sql.NewRow(n.Database, n.Branch, n.User, n.Host)
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// Inserter implements sql.InsertableTable
+func (nt *BranchNamespaceControlTable) Inserter(*sql.Context) sql.RowInserter {
+	return &branchNamespaceControlWriter{controller: nt.controller}
+}
+
+// Replacer implements sql.ReplaceableTable
+func (nt *BranchNamespaceControlTable) Replacer(*sql.Context) sql.RowReplacer {
+	return &branchNamespaceControlWriter{controller: nt.controller}
+}
+
+// Updater implements sql.UpdatableTable
+func (nt *BranchNamespaceControlTable) Updater(*sql.Context) sql.RowUpdater {
+	return &branchNamespaceControlWriter{controller: nt.controller}
+}
+
+// Deleter implements sql.DeletableTable
+func (nt *BranchNamespaceControlTable) Deleter(*sql.Context) sql.RowDeleter {
+	return &branchNamespaceControlWriter{controller: nt.controller}
+}
+
+// branchNamespaceControlWriter collects the edits made to the dolt_branch_namespace_control table during a
+// statement, and applies them to the controller when it is closed. Every edited row is checked against the
+// permissions of the session's client.
+type branchNamespaceControlWriter struct {
+	controller *branch_control.Controller
+	rows       []branch_control.Namespace
+	loaded     bool
+}
+
+var _ sql.RowReplacer = (*branchNamespaceControlWriter)(nil)
+var _ sql.RowUpdater = (*branchNamespaceControlWriter)(nil)
+
+func (w *branchNamespaceControlWriter) load() {
+	if !w.loaded {
+		w.rows = w.controller.Namespace()
+		w.loaded = true
+	}
+}
+
+func (w *branchNamespaceControlWriter) indexOf(n branch_control.Namespace) int {
+	for i := range w.rows {
+		if w.rows[i].SamePatterns(n) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Insert inserts the row given, returning an error if it cannot. Inserting a row whose patterns already exist is an
+// error.
+func (w *branchNamespaceControlWriter) Insert(ctx *sql.Context, r sql.Row) error {
+	n, err := namespaceFromRow(r)
+	if err != nil {
+		return err
+	}
+	if err = checkBranchControlModify(ctx, w.controller, n.Database, n.Branch); err != nil {
+		return err
+	}
+
+	w.load()
+	if w.indexOf(n) >= 0 {
+		return sql.NewUniqueKeyErr(fmt.Sprintf("[%s, %s, %s, %s]", n.Database, n.Branch, n.User, n.Host), true, r)
+	}
+	w.rows = append(w.rows, n)
+	return nil
+}
+
+// Update the given row. Provides both the old and new rows.
+func (w *branchNamespaceControlWriter) Update(ctx *sql.Context, old sql.Row, new sql.Row) error {
+	if err := w.Delete(ctx, old); err != nil {
+		return err
+	}
+	return w.Insert(ctx, new)
+}
+
+// Delete deletes the given row. Returns ErrDeleteRowNotFound if the row was not found.
+func (w *branchNamespaceControlWriter) Delete(ctx *sql.Context, r sql.Row) error {
+	n, err := namespaceFromRow(r)
+	if err != nil {
+		return err
+	}
+	if err = checkBranchControlModify(ctx, w.controller, n.Database, n.Branch); err != nil {
+		return err
+	}
+
+	w.load()
+	idx := w.indexOf(n)
+	if idx < 0 {
+		return sql.ErrDeleteRowNotFound.New()
+	}
+	w.rows = append(w.rows[:idx], w.rows[idx+1:]...)
+	return nil
+}
+
+// StatementBegin implements the interface sql.TableEditor. Currently a no-op.
+func (w *branchNamespaceControlWriter) StatementBegin(ctx *sql.Context) {}
+
+// DiscardChanges implements the interface sql.TableEditor.
+func (w *branchNamespaceControlWriter) DiscardChanges(ctx *sql.Context, errorEncountered error) error {
+	w.rows = nil
+	w.loaded = false
+	return nil
+}
+
+// StatementComplete implements the interface sql.TableEditor. Currently a no-op.
+func (w *branchNamespaceControlWriter) StatementComplete(ctx *sql.Context) error {
+	return nil
+}
+
+// Close applies the edited rows to the controller, which persists them.
+func (w *branchNamespaceControlWriter) Close(ctx *sql.Context) error {
+	if !w.loaded {
+		return nil
+	}
+	return w.controller.SetNamespace(w.rows)
+}
+
+func namespaceFromRow(r sql.Row) (branch_control.Namespace, error) {
+	strs, err := branchControlStrings(r[:4])
+	if err != nil {
+		return branch_control.Namespace{}, err
+	}
+	return branch_control.Namespace{
+		Database: strs[0],
+		Branch:   strs[1],
+		User:     strs[2],
+		Host:     strs[3],
+	}, nil
+}
+
+// branchControlStrings returns the pattern columns of a row of either branch control table as strings.
+func branchControlStrings(vals []interface{}) ([]string, error) {
+	strs := make([]string, len(vals))
+	for i, v := range vals {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value for a branch control pattern: %v", v)
+		}
+		strs[i] = s
+	}
+	return strs, nil
+}
+
+// checkBranchControlModify returns an error if the session's client may not edit a row of either branch control
+// table with the database and branch patterns given.
+func checkBranchControlModify(ctx *sql.Context, controller *branch_control.Controller, database, branch string) error {
+	user, host := dsess.DSessFromSess(ctx.Session).BranchControlClient()
+	return controller.CheckModify(database, branch, user, host)
+}
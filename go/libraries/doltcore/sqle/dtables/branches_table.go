@@ -22,6 +22,7 @@ import (
 
 	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/ref"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/index"
 )
 
@@ -33,12 +34,13 @@ var _ sql.ReplaceableTable = (*BranchesTable)(nil)
 
 // BranchesTable is a sql.Table implementation that implements a system table which shows the dolt branches
 type BranchesTable struct {
-	ddb *doltdb.DoltDB
+	dbName string
+	ddb    *doltdb.DoltDB
 }
 
 // NewBranchesTable creates a BranchesTable
-func NewBranchesTable(_ *sql.Context, ddb *doltdb.DoltDB) sql.Table {
-	return &BranchesTable{ddb}
+func NewBranchesTable(_ *sql.Context, dbName string, ddb *doltdb.DoltDB) sql.Table {
+	return &BranchesTable{dbName: dbName, ddb: ddb}
 }
 
 // Name is a sql.Table interface function which returns the name of the table which is defined by the constant
@@ -215,6 +217,20 @@ func (bWr branchWriter) Insert(ctx *sql.Context, r sql.Row) error {
 
 	branchRef := ref.NewBranchRef(branchName)
 
+	dSess := dsess.DSessFromSess(ctx.Session)
+	exists, err := ddb.HasRef(ctx, branchRef)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = dSess.CheckBranchWrite(bWr.bt.dbName, branchName)
+	} else {
+		err = dSess.CheckBranchCreate(bWr.bt.dbName, branchName)
+	}
+	if err != nil {
+		return err
+	}
+
 	// TODO: this isn't safe in a SQL context, since we have to update the working set of the new branch and it's a
 	//  race. It needs to be able to retry the same as committing a transaction.
 	err = ddb.NewBranchAtCommit(ctx, branchRef, cm)
@@ -222,6 +238,13 @@ func (bWr branchWriter) Insert(ctx *sql.Context, r sql.Row) error {
 		return err
 	}
 
+	if !exists {
+		err = dSess.BranchCreated(bWr.bt.dbName, branchName)
+		if err != nil {
+			return err
+		}
+	}
+
 	return bWr.bt.ddb.ExecuteCommitHooks(ctx, branchRef.String())
 }
 
@@ -251,7 +274,18 @@ func (bWr branchWriter) Delete(ctx *sql.Context, r sql.Row) error {
 		return sql.ErrDeleteRowNotFound.New()
 	}
 
-	return bWr.bt.ddb.DeleteBranch(ctx, brRef)
+	dSess := dsess.DSessFromSess(ctx.Session)
+	err = dSess.CheckBranchDelete(bWr.bt.dbName, branchName)
+	if err != nil {
+		return err
+	}
+
+	err = bWr.bt.ddb.DeleteBranch(ctx, brRef)
+	if err != nil {
+		return err
+	}
+
+	return dSess.BranchDeleted(bWr.bt.dbName, branchName)
 }
 
 // StatementBegin implements the interface sql.TableEditor. Currently a no-op.
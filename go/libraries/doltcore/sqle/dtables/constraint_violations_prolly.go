@@ -40,6 +40,10 @@ func newProllyCVTable(ctx *sql.Context, tblName string, root *doltdb.RootValue,
 	} else if !ok {
 		return nil, sql.ErrTableNotFound.New(tblName)
 	}
+	sch, err := tbl.GetSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
 	cvSch, err := tbl.GetConstraintViolationsSchema(ctx)
 	if err != nil {
 		return nil, err
@@ -58,6 +62,7 @@ func newProllyCVTable(ctx *sql.Context, tblName string, root *doltdb.RootValue,
 		tblName: tblName,
 		root:    root,
 		sqlSch:  sqlSch,
+		sch:     sch,
 		tbl:     tbl,
 		rs:      rs,
 		artM:    m,
@@ -70,6 +75,7 @@ type prollyConstraintViolationsTable struct {
 	tblName string
 	root    *doltdb.RootValue
 	sqlSch  sql.PrimaryKeySchema
+	sch     schema.Schema
 	tbl     *doltdb.Table
 	rs      RootSetter
 	artM    prolly.ArtifactMap
@@ -113,6 +119,14 @@ func (cvt *prollyConstraintViolationsTable) PartitionRows(ctx *sql.Context, part
 		return nil, err
 	}
 	kd, vd := shim.MapDescriptorsFromSchema(sch)
+	// the values of schema conversion violations hold NULLs in place of
+	// the values that could not be converted, even in NOT NULL columns
+	nullableTypes := make([]val.Type, len(vd.Types))
+	for i, typ := range vd.Types {
+		typ.Nullable = true
+		nullableTypes[i] = typ
+	}
+	vd = val.NewTupleDescriptor(nullableTypes...)
 	return prollyCVIter{
 		itr: itr,
 		sch: sch,
@@ -162,21 +176,23 @@ func (itr prollyCVIter) Next(ctx *sql.Context) (sql.Row, error) {
 
 	o := 2
 	if !schema.IsKeyless(itr.sch) {
-		for i := 0; i < itr.kd.Count(); i++ {
-			r[o+i], err = index.GetField(ctx, itr.kd, i, art.Key, itr.ns)
+		// key and value fields are placed by their position in the schema, the
+		// primary key columns need not come first
+		for i, ord := range itr.sch.GetPkOrdinals() {
+			r[o+ord], err = index.GetField(ctx, itr.kd, i, art.Key, itr.ns)
 			if err != nil {
 				return nil, err
 			}
 		}
-		o += itr.kd.Count()
 
-		for i := 0; i < itr.vd.Count(); i++ {
-			r[o+i], err = index.GetField(ctx, itr.vd, i, meta.Value, itr.ns)
+		allCols := itr.sch.GetAllCols()
+		for i, col := range itr.sch.GetNonPKCols().GetColumns() {
+			r[o+allCols.TagToIdx[col.Tag]], err = index.GetField(ctx, itr.vd, i, meta.Value, itr.ns)
 			if err != nil {
 				return nil, err
 			}
 		}
-		o += itr.vd.Count()
+		o += allCols.Size()
 	} else {
 		for i := 0; i < itr.vd.Count()-1; i++ {
 			r[o+i], err = index.GetField(ctx, itr.vd, i+1, meta.Value, itr.ns)
@@ -202,6 +218,20 @@ func (itr prollyCVIter) Next(ctx *sql.Context) (sql.Row, error) {
 			return nil, err
 		}
 		r[o] = m
+	case prolly.ArtifactTypeSchemaConvViol:
+		var m merge.SchemaConvCVMeta
+		err = json.Unmarshal(meta.VInfo, &m)
+		if err != nil {
+			return nil, err
+		}
+		r[o] = m
+	case prolly.ArtifactTypeDefinitionViol:
+		var m merge.DefinitionCVMeta
+		err = json.Unmarshal(meta.VInfo, &m)
+		if err != nil {
+			return nil, err
+		}
+		r[o] = m
 	default:
 		panic("json not implemented for artifact type")
 	}
@@ -222,8 +252,13 @@ var _ sql.RowDeleter = (*prollyCVDeleter)(nil)
 // Delete implements the interface sql.RowDeleter.
 func (d *prollyCVDeleter) Delete(ctx *sql.Context, r sql.Row) error {
 	// first part of the artifact key is the keys of the source table
+	ords := d.cvt.sch.GetPkOrdinals()
 	for i := 0; i < d.kd.Count()-2; i++ {
-		err := index.PutField(ctx, d.cvt.artM.NodeStore(), d.kb, i, r[i+2])
+		o := i
+		if i < len(ords) {
+			o = ords[i]
+		}
+		err := index.PutField(ctx, d.cvt.artM.NodeStore(), d.kb, i, r[o+2])
 		if err != nil {
 			return err
 		}
@@ -291,6 +326,10 @@ func mapCVType(artifactType prolly.ArtifactType) (outType uint64) {
 		outType = uint64(merge.CvType_UniqueIndex)
 	case prolly.ArtifactTypeChkConsViol:
 		outType = uint64(merge.CvType_CheckConstraint)
+	case prolly.ArtifactTypeSchemaConvViol:
+		outType = uint64(merge.CvType_SchemaConversion)
+	case prolly.ArtifactTypeDefinitionViol:
+		outType = uint64(merge.CvType_InvalidDefinition)
 	default:
 		panic("unhandled cv type")
 	}
@@ -305,6 +344,10 @@ func unmapCVType(in merge.CvType) (out prolly.ArtifactType) {
 		out = prolly.ArtifactTypeUniqueKeyViol
 	case merge.CvType_CheckConstraint:
 		out = prolly.ArtifactTypeChkConsViol
+	case merge.CvType_SchemaConversion:
+		out = prolly.ArtifactTypeSchemaConvViol
+	case merge.CvType_InvalidDefinition:
+		out = prolly.ArtifactTypeDefinitionViol
 	default:
 		panic("unhandled cv type")
 	}
@@ -0,0 +1,289 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dtables
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/index"
+)
+
+var _ sql.Table = (*RebaseTable)(nil)
+var _ sql.UpdatableTable = (*RebaseTable)(nil)
+var _ sql.DeletableTable = (*RebaseTable)(nil)
+
+var errRebasePlanStarted = errors.New("the rebase plan cannot be edited once the rebase has started")
+
+// RebaseTable is a sql.Table implementation that implements a system table which shows the plan of the rebase in
+// progress. Before the rebase starts, the action and commit message of each step can be updated and steps can be
+// reordered or deleted.
+type RebaseTable struct {
+	dbName string
+}
+
+// NewRebaseTable creates a RebaseTable
+func NewRebaseTable(_ *sql.Context, dbName string) sql.Table {
+	return &RebaseTable{dbName: dbName}
+}
+
+// Name is a sql.Table interface function which returns the name of the table which is defined by the constant
+// RebaseTableName
+func (rt *RebaseTable) Name() string {
+	return doltdb.RebaseTableName
+}
+
+// String is a sql.Table interface function which returns the name of the table which is defined by the constant
+// RebaseTableName
+func (rt *RebaseTable) String() string {
+	return doltdb.RebaseTableName
+}
+
+// Schema is a sql.Table interface function that gets the sql.Schema of the rebase system table.
+func (rt *RebaseTable) Schema() sql.Schema {
+	return []*sql.Column{
+		{Name: "rebase_order", Type: sql.Int64, Source: doltdb.RebaseTableName, PrimaryKey: true, Nullable: false},
+		{Name: "action", Type: sql.Text, Source: doltdb.RebaseTableName, PrimaryKey: false, Nullable: false},
+		{Name: "commit_hash", Type: sql.Text, Source: doltdb.RebaseTableName, PrimaryKey: false, Nullable: false},
+		{Name: "commit_message", Type: sql.Text, Source: doltdb.RebaseTableName, PrimaryKey: false, Nullable: false},
+	}
+}
+
+// Partitions is a sql.Table interface function that returns a partition of the data. Currently, the data is unpartitioned.
+func (rt *RebaseTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return index.SinglePartitionIterFromNomsMap(nil), nil
+}
+
+// PartitionRows is a sql.Table interface function that gets a row iterator for a partition
+func (rt *RebaseTable) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
+	rs, err := rt.rebaseState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan []doltdb.RebasePlanStep
+	if rs != nil {
+		plan = rs.Plan()
+	}
+
+	return &rebaseItr{plan: plan}, nil
+}
+
+// Updater returns a RowUpdater for this table. The RowUpdater will have Update called once for each row to be
+// updated, followed by a call to Close() when all rows have been processed.
+func (rt *RebaseTable) Updater(*sql.Context) sql.RowUpdater {
+	return &rebaseWriter{rt: rt}
+}
+
+// Deleter returns a RowDeleter for this table. The RowDeleter will get one call to Delete for each row to be deleted,
+// and will end with a call to Close() to finalize the delete operation.
+func (rt *RebaseTable) Deleter(*sql.Context) sql.RowDeleter {
+	return &rebaseWriter{rt: rt}
+}
+
+func (rt *RebaseTable) rebaseState(ctx *sql.Context) (*doltdb.RebaseState, error) {
+	ws, err := dsess.DSessFromSess(ctx.Session).WorkingSet(ctx, rt.dbName)
+	if err != nil {
+		return nil, err
+	}
+	return ws.RebaseState(), nil
+}
+
+// rebaseItr is a sql.RowItr implementation which iterates over each step of a rebase plan as if it's a row in the table.
+type rebaseItr struct {
+	plan []doltdb.RebasePlanStep
+	idx  int
+}
+
+// Next retrieves the next row. It will return io.EOF if it's the last row.
+// After retrieving the last row, Close will be automatically closed.
+func (itr *rebaseItr) Next(*sql.Context) (sql.Row, error) {
+	if itr.idx >= len(itr.plan) {
+		return nil, io.EOF
+	}
+
+	defer func() {
+		itr.idx++
+	}()
+
+	step := itr.plan[itr.idx]
+	return sql.NewRow(int64(itr.idx+1), string(step.Action), step.CommitHash, step.CommitMsg), nil
+}
+
+// Close closes the iterator.
+func (itr *rebaseItr) Close(*sql.Context) error {
+	return nil
+}
+
+var _ sql.RowUpdater = (*rebaseWriter)(nil)
+var _ sql.RowDeleter = (*rebaseWriter)(nil)
+
+// rebaseWriter collects the edits made to the rebase plan during a statement, and writes the new plan to the working
+// set when it is closed.
+type rebaseWriter struct {
+	rt      *RebaseTable
+	steps   []doltdb.RebasePlanStep
+	orders  []int64
+	deleted []bool
+}
+
+func (w *rebaseWriter) loadPlan(ctx *sql.Context) error {
+	if w.steps != nil {
+		return nil
+	}
+
+	rs, err := w.rt.rebaseState(ctx)
+	if err != nil {
+		return err
+	}
+	if rs == nil {
+		return doltdb.ErrNoRebaseInProgress
+	}
+	if rs.Started() {
+		return errRebasePlanStarted
+	}
+
+	plan := rs.Plan()
+	w.steps = make([]doltdb.RebasePlanStep, len(plan))
+	w.orders = make([]int64, len(plan))
+	w.deleted = make([]bool, len(plan))
+	copy(w.steps, plan)
+	for i := range plan {
+		w.orders[i] = int64(i + 1)
+	}
+
+	return nil
+}
+
+// stepIndex returns the index in the plan of the step for the row given
+func (w *rebaseWriter) stepIndex(r sql.Row) (int, error) {
+	order, err := sql.Int64.Convert(r[0])
+	if err != nil {
+		return 0, err
+	}
+	idx := int(order.(int64)) - 1
+	if idx < 0 || idx >= len(w.steps) {
+		return 0, fmt.Errorf("invalid rebase_order %v", r[0])
+	}
+	return idx, nil
+}
+
+// Update the given row. Provides both the old and new rows.
+func (w *rebaseWriter) Update(ctx *sql.Context, old sql.Row, new sql.Row) error {
+	if err := w.loadPlan(ctx); err != nil {
+		return err
+	}
+
+	idx, err := w.stepIndex(old)
+	if err != nil {
+		return err
+	}
+
+	if new[2] != old[2] {
+		return fmt.Errorf("the commit_hash of a rebase step cannot be changed")
+	}
+
+	order, err := sql.Int64.Convert(new[0])
+	if err != nil {
+		return err
+	}
+	actionStr, ok := new[1].(string)
+	if !ok {
+		return fmt.Errorf("invalid value for action")
+	}
+	action, err := doltdb.ParseRebaseAction(actionStr)
+	if err != nil {
+		return err
+	}
+	msg, ok := new[3].(string)
+	if !ok {
+		return fmt.Errorf("invalid value for commit_message")
+	}
+
+	w.orders[idx] = order.(int64)
+	w.steps[idx].Action = action
+	w.steps[idx].CommitMsg = msg
+
+	return nil
+}
+
+// Delete deletes the given row. Deleting a step removes its commit from the rebased branch.
+func (w *rebaseWriter) Delete(ctx *sql.Context, r sql.Row) error {
+	if err := w.loadPlan(ctx); err != nil {
+		return err
+	}
+
+	idx, err := w.stepIndex(r)
+	if err != nil {
+		return err
+	}
+	w.deleted[idx] = true
+
+	return nil
+}
+
+// StatementBegin implements the interface sql.TableEditor. Currently a no-op.
+func (w *rebaseWriter) StatementBegin(ctx *sql.Context) {}
+
+// DiscardChanges implements the interface sql.TableEditor.
+func (w *rebaseWriter) DiscardChanges(ctx *sql.Context, errorEncountered error) error {
+	w.steps = nil
+	return nil
+}
+
+// StatementComplete implements the interface sql.TableEditor. Currently a no-op.
+func (w *rebaseWriter) StatementComplete(ctx *sql.Context) error {
+	return nil
+}
+
+// Close writes the edited rebase plan to the working set, ordering the steps by their rebase_order.
+func (w *rebaseWriter) Close(ctx *sql.Context) error {
+	if w.steps == nil {
+		return nil
+	}
+
+	var idxs []int
+	for i := range w.steps {
+		if !w.deleted[i] {
+			idxs = append(idxs, i)
+		}
+	}
+	sort.SliceStable(idxs, func(i, j int) bool {
+		return w.orders[idxs[i]] < w.orders[idxs[j]]
+	})
+
+	plan := make([]doltdb.RebasePlanStep, len(idxs))
+	for i, idx := range idxs {
+		plan[i] = w.steps[idx]
+	}
+
+	sess := dsess.DSessFromSess(ctx.Session)
+	ws, err := sess.WorkingSet(ctx, w.rt.dbName)
+	if err != nil {
+		return err
+	}
+	rs := ws.RebaseState()
+	if rs == nil {
+		return doltdb.ErrNoRebaseInProgress
+	}
+
+	return sess.SetWorkingSet(ctx, w.rt.dbName, ws.WithRebaseState(rs.WithPlan(plan)))
+}
@@ -88,6 +88,11 @@ func newStatusItr(ctx *sql.Context, st *StatusTable) (*StatusItr, error) {
 		return nil, err
 	}
 
+	unstagedTables, err = diff.FilterIgnoredTableDeltas(ctx, roots, unstagedTables)
+	if err != nil {
+		return nil, err
+	}
+
 	workingTblsInConflict, _, _, err := merge.GetTablesInConflict(ctx, roots)
 	if err != nil {
 		return nil, err
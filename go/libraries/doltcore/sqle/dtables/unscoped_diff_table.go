@@ -276,7 +276,7 @@ func (itr *doltDiffCommitHistoryRowItr) loadTableChanges(ctx context.Context) er
 // calculateTableChanges calculates the tables that changed in the specified commit, by comparing that
 // commit with its immediate ancestor commit.
 func (itr *doltDiffCommitHistoryRowItr) calculateTableChanges(ctx context.Context, commit *doltdb.Commit) ([]tableChange, error) {
-	if len(commit.DatasParents()) == 0 {
+	if commit.NumParents() == 0 {
 		return nil, nil
 	}
 
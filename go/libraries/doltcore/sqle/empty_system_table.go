@@ -0,0 +1,127 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/schema"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
+)
+
+// emptySystemTable stands in for a persisted system table, such as `dolt_ignore`, when it does not exist in the
+// working root. It has no rows, and creates the real table the first time rows are written to it.
+type emptySystemTable struct {
+	db   Database
+	name string
+	sch  schema.Schema
+}
+
+var _ sql.Table = emptySystemTable{}
+var _ sql.InsertableTable = emptySystemTable{}
+var _ sql.ReplaceableTable = emptySystemTable{}
+
+func newEmptySystemTable(db Database, name string, sch schema.Schema) sql.Table {
+	return emptySystemTable{db: db, name: name, sch: sch}
+}
+
+// Name implements sql.Table
+func (t emptySystemTable) Name() string {
+	return t.name
+}
+
+// String implements sql.Table
+func (t emptySystemTable) String() string {
+	return t.name
+}
+
+// Schema implements sql.Table
+func (t emptySystemTable) Schema() sql.Schema {
+	sch, err := sqlutil.FromDoltSchema(t.name, t.sch)
+	if err != nil {
+		panic(err) // should never happen
+	}
+	return sch.Schema
+}
+
+// Partitions implements sql.Table
+func (t emptySystemTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return sql.PartitionsToPartitionIter(), nil
+}
+
+// PartitionRows implements sql.Table
+func (t emptySystemTable) PartitionRows(*sql.Context, sql.Partition) (sql.RowIter, error) {
+	return sql.RowsToRowIter(), nil
+}
+
+// Inserter implements sql.InsertableTable
+func (t emptySystemTable) Inserter(ctx *sql.Context) sql.RowInserter {
+	tbl, err := getOrCreateDoltSystemTable(ctx, t.db, t.name, t.sch)
+	if err != nil {
+		return sqlutil.NewStaticErrorEditor(err)
+	}
+	return tbl.Inserter(ctx)
+}
+
+// Replacer implements sql.ReplaceableTable
+func (t emptySystemTable) Replacer(ctx *sql.Context) sql.RowReplacer {
+	tbl, err := getOrCreateDoltSystemTable(ctx, t.db, t.name, t.sch)
+	if err != nil {
+		return sqlutil.NewStaticErrorEditor(err)
+	}
+	return tbl.Replacer(ctx)
+}
+
+// GetOrCreateDoltIgnoreTable returns the `dolt_ignore` table in `db`, creating it if it does not already exist.
+func GetOrCreateDoltIgnoreTable(ctx *sql.Context, db Database) (*WritableDoltTable, error) {
+	return getOrCreateDoltSystemTable(ctx, db, doltdb.IgnoreTableName, doltdb.IgnoreSchema)
+}
+
+// getOrCreateDoltSystemTable returns the system table named in `db`, creating it with the schema given if it does not
+// already exist.
+func getOrCreateDoltSystemTable(ctx *sql.Context, db Database, name string, sch schema.Schema) (*WritableDoltTable, error) {
+	root, err := db.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tbl, found, err := db.getTable(ctx, root, name)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return tbl.(*WritableDoltTable), nil
+	}
+
+	err = db.createDoltTable(ctx, name, root, sch)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err = db.GetRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tbl, found, err = db.getTable(ctx, root, name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, sql.ErrTableNotFound.New(name)
+	}
+
+	return tbl.(*WritableDoltTable), nil
+}
@@ -744,6 +744,18 @@ func TestDoltMerge(t *testing.T) {
 		enginetest.TestScript(t, newDoltHarness(t), script)
 	}
 
+	for _, script := range SchemaConversionMergeScripts {
+		enginetest.TestScript(t, newDoltHarness(t), script)
+	}
+
+	for _, script := range SchemaConflictMergeScripts {
+		enginetest.TestScript(t, newDoltHarness(t), script)
+	}
+
+	for _, script := range FragmentMergeScripts {
+		enginetest.TestScript(t, newDoltHarness(t), script)
+	}
+
 	if types.IsFormat_DOLT_1(types.Format_Default) {
 		for _, script := range Dolt1MergeScripts {
 			enginetest.TestScript(t, newDoltHarness(t), script)
@@ -813,6 +825,24 @@ func TestDoltRemote(t *testing.T) {
 	}
 }
 
+func TestDoltStash(t *testing.T) {
+	for _, script := range DoltStashTestScripts {
+		enginetest.TestScript(t, newDoltHarness(t), script)
+	}
+}
+
+func TestDoltRebase(t *testing.T) {
+	for _, script := range DoltRebaseTestScripts {
+		enginetest.TestScript(t, newDoltHarness(t), script)
+	}
+}
+
+func TestDoltIgnore(t *testing.T) {
+	for _, script := range DoltIgnoreTestScripts {
+		enginetest.TestScript(t, newDoltHarness(t), script)
+	}
+}
+
 // TestSingleTransactionScript is a convenience method for debugging a single transaction test. Unskip and set to the
 // desired test.
 func TestSingleTransactionScript(t *testing.T) {
@@ -946,6 +976,39 @@ func TestDiffTableFunction(t *testing.T) {
 	}
 }
 
+func TestSchemaDiffTableFunction(t *testing.T) {
+	harness := newDoltHarness(t)
+	harness.Setup(setup.MydbData)
+	for _, test := range SchemaDiffTableFunctionScriptTests {
+		harness.engine = nil
+		t.Run(test.Name, func(t *testing.T) {
+			enginetest.TestScript(t, harness, test)
+		})
+	}
+}
+
+func TestLogTableFunction(t *testing.T) {
+	harness := newDoltHarness(t)
+	harness.Setup(setup.MydbData)
+	for _, test := range LogTableFunctionScriptTests {
+		harness.engine = nil
+		t.Run(test.Name, func(t *testing.T) {
+			enginetest.TestScript(t, harness, test)
+		})
+	}
+}
+
+func TestReflogTableFunction(t *testing.T) {
+	harness := newDoltHarness(t)
+	harness.Setup(setup.MydbData)
+	for _, test := range ReflogTableFunctionScriptTests {
+		harness.engine = nil
+		t.Run(test.Name, func(t *testing.T) {
+			enginetest.TestScript(t, harness, test)
+		})
+	}
+}
+
 func TestCommitDiffSystemTable(t *testing.T) {
 	harness := newDoltHarness(t)
 	harness.Setup(setup.MydbData)
@@ -864,6 +864,116 @@ var HistorySystemTableScriptTests = []queries.ScriptTest{
 			},
 		},
 	},
+	{
+		Name: "dolt_history table with unchanged table versions",
+		SetUpScript: []string{
+			"create table t (pk int primary key, c int);",
+			"insert into t values (1, 2), (3, 4);",
+			"set @Commit1 = dolt_commit('-am', 'creating table t');",
+			"create table other (pk int primary key);",
+			"set @Commit2 = dolt_commit('-am', 'creating another table');",
+			"insert into other values (1);",
+			"set @Commit3 = dolt_commit('-am', 'inserting into the other table');",
+			"insert into t values (5, 6);",
+			"set @Commit4 = dolt_commit('-am', 'inserting into t');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "select count(*) from dolt_history_t;",
+				Expected: []sql.Row{{9}},
+			},
+			{
+				Query: "select pk, c, commit_hash = @Commit1, commit_hash = @Commit2, commit_hash = @Commit3 from dolt_history_t where pk = 3 order by commit_date",
+				Expected: []sql.Row{
+					{3, 4, true, false, false},
+					{3, 4, false, true, false},
+					{3, 4, false, false, true},
+					{3, 4, false, false, false},
+				},
+			},
+			{
+				Query:    "select pk, c from dolt_history_t where commit_hash = @Commit3 order by pk;",
+				Expected: []sql.Row{{1, 2}, {3, 4}},
+			},
+			{
+				Query:    "select committer, count(*) from dolt_history_t where pk > 1 group by committer;",
+				Expected: []sql.Row{{"billy bob", 5}},
+			},
+		},
+	},
+	{
+		Name: "dolt_history table with commit ranges",
+		SetUpScript: []string{
+			"create table t (pk int primary key, c int);",
+			"call dolt_add('-A');",
+			"call dolt_commit('-m', 'creating table t', '--date', '2022-01-01T12:00:00');",
+			"insert into t values (1, 1);",
+			"call dolt_commit('-am', 'inserting 1', '--date', '2022-02-01T12:00:00');",
+			"call dolt_branch('v1');",
+			"update t set c = 2 where pk = 1;",
+			"call dolt_commit('-am', 'updating 1', '--date', '2022-03-01T12:00:00');",
+			"insert into t values (2, 2);",
+			"call dolt_commit('-am', 'inserting 2', '--date', '2022-04-01T12:00:00');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "select pk, c, date_format(commit_date, '%Y-%m') from dolt_history_t where commit_date >= '2022-03-01' order by commit_date, pk;",
+				Expected: []sql.Row{{1, 2, "2022-03"}, {1, 2, "2022-04"}, {2, 2, "2022-04"}},
+			},
+			{
+				Query:    "select pk, c from dolt_history_t where commit_date between '2022-01-15' and '2022-03-15' order by commit_date, pk;",
+				Expected: []sql.Row{{1, 1}, {1, 2}},
+			},
+			{
+				Query:    "select pk, c from dolt_history_t where '2022-02-01T12:00:00' < commit_date and pk = 1 order by commit_date;",
+				Expected: []sql.Row{{1, 2}, {1, 2}},
+			},
+			{
+				Query:    "select pk, c from dolt_history_t as of 'v1..main' order by commit_date, pk;",
+				Expected: []sql.Row{{1, 2}, {1, 2}, {2, 2}},
+			},
+			{
+				Query:    "select pk, c from dolt_history_t as of 'v1..head~1' order by commit_date, pk;",
+				Expected: []sql.Row{{1, 2}},
+			},
+			{
+				Query:    "select count(*) from dolt_history_t as of 'head~1..';",
+				Expected: []sql.Row{{2}},
+			},
+			{
+				Query:    "select count(*) from dolt_history_t as of 'main..v1';",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:          "select count(*) from dolt_history_t as of '..main';",
+				ExpectedErrStr: "invalid revision range '..main', must be <from>..<to>",
+			},
+		},
+	},
+	{
+		Name: "dolt_history table with commit dates older than their parents",
+		SetUpScript: []string{
+			"create table t (pk int primary key, c int);",
+			"call dolt_add('-A');",
+			"call dolt_commit('-m', 'creating table t', '--date', '2022-01-01T12:00:00');",
+			"insert into t values (1, 1);",
+			"call dolt_commit('-am', 'inserting 1', '--date', '2022-06-01T12:00:00');",
+			"update t set c = 2 where pk = 1;",
+			"call dolt_commit('-am', 'updating 1', '--date', '2022-02-01T12:00:00');",
+			"insert into t values (2, 2);",
+			"call dolt_commit('-am', 'inserting 2', '--date', '2022-07-01T12:00:00');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "select pk, c, date_format(commit_date, '%Y-%m') from dolt_history_t where commit_date >= '2022-05-01' order by commit_date, pk;",
+				Expected: []sql.Row{{1, 1, "2022-06"}, {1, 2, "2022-07"}, {2, 2, "2022-07"}},
+			},
+			{
+				Query:    "select pk, c from dolt_history_t where commit_date > '2022-01-15' and commit_date < '2022-06-15' order by commit_date, pk;",
+				Expected: []sql.Row{{1, 2}, {1, 1}},
+			},
+		},
+	},
 }
 
 var MergeScripts = []queries.ScriptTest{
@@ -1541,6 +1651,79 @@ var MergeScripts = []queries.ScriptTest{
 			},
 		},
 	},
+	{
+		Name: "cell-wise merges of a unique key from the left can collide with rows from the right",
+		SetUpScript: []string{
+			"SET dolt_force_transaction_commit = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, col1 int UNIQUE, col2 int);",
+			"INSERT INTO t VALUES (1, 1, 1);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"UPDATE t SET col2 = 2 where pk = 1;",
+			"INSERT INTO t VALUES (2, 5, 5);",
+			"CALL DOLT_COMMIT('-am', 'right edit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"UPDATE t SET col1 = 5 where pk = 1;",
+			"CALL DOLT_COMMIT('-am', 'left edit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT * from t;",
+				Expected: []sql.Row{{1, 5, 2}, {2, 5, 5}},
+			},
+			{
+				Query:    "SELECT violation_type, pk, col1, col2 from dolt_constraint_violations_t;",
+				Expected: []sql.Row{{uint64(merge.CvType_UniqueIndex), 1, 5, 2}, {uint64(merge.CvType_UniqueIndex), 2, 5, 5}},
+			},
+		},
+	},
+	{
+		Name: "cell-wise merges combine changes to different columns of the same row",
+		SetUpScript: []string{
+			"SET dolt_allow_commit_conflicts = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, col1 int, col2 varchar(20), col3 int, INDEX col3_idx (col3));",
+			"INSERT INTO t VALUES (1, 1, 'one', 1), (2, 2, 'two', 2);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"UPDATE t SET col2 = 'uno', col3 = 10 where pk = 1;",
+			"UPDATE t SET col1 = 20 where pk = 2;",
+			"CALL DOLT_COMMIT('-am', 'right edit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"UPDATE t SET col1 = 10 where pk = 1;",
+			"UPDATE t SET col1 = 30 where pk = 2;",
+			"CALL DOLT_COMMIT('-am', 'left edit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT * from t where pk = 1;",
+				Expected: []sql.Row{{1, 10, "uno", 10}},
+			},
+			{
+				Query:    "SELECT pk from t where col3 = 10;",
+				Expected: []sql.Row{{1}},
+			},
+			{
+				Query:    "SELECT count(*) from t where col3 = 1;",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT base_col1, our_col1, their_col1 from dolt_conflicts_t;",
+				Expected: []sql.Row{{2, 30, 20}},
+			},
+		},
+	},
 	// Behavior between new and old format diverges in the case where right adds
 	// a unique key constraint and resolves existing violations.
 	// In the old format, because the violations exist on the left the merge is aborted.
@@ -1673,13 +1856,12 @@ var MergeScripts = []queries.ScriptTest{
 		Assertions: []queries.ScriptTestAssertion{
 			{
 				Query:    "CALL DOLT_MERGE('other');",
-				Expected: []sql.Row{{0, 1}},
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "select count(*) from dolt_schemas where type = 'trigger';",
+				Expected: []sql.Row{{4}},
 			},
-			// todo: merge triggers correctly
-			//{
-			//	Query:    "select count(*) from dolt_schemas where type = 'trigger';",
-			//	Expected: []sql.Row{{4}},
-			//},
 		},
 	},
 	{
@@ -1774,76 +1956,503 @@ var MergeScripts = []queries.ScriptTest{
 				Expected: []sql.Row{{sql.OkResult{RowsAffected: 2, InsertID: 3}}},
 			},
 			{
-				Query: "SELECT * FROM t ORDER BY pk;",
-				Expected: []sql.Row{
-					{1, 1},
-					{2, 2},
-					{3, 3},
-					{4, 4},
-					{5, 5},
-					{6, 6},
-				},
+				Query: "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{
+					{1, 1},
+					{2, 2},
+					{3, 3},
+					{4, 4},
+					{5, 5},
+					{6, 6},
+				},
+			},
+		},
+	},
+	{
+		Name: "dolt_merge() (3way) with a gap in an auto increment key",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY AUTO_INCREMENT, c0 int);",
+			"INSERT INTO t (c0) VALUES (1);",
+			"CALL dolt_add('-A');",
+			"CALL dolt_commit('-am', 'cm1');",
+			"CALL dolt_checkout('-b', 'test');",
+			"INSERT INTO t VALUES (4,4), (5,5);",
+			"CALL dolt_commit('-am', 'cm2');",
+			"CALL dolt_checkout('main');",
+			"INSERT INTO t (c0) VALUES (2);",
+			"CALL dolt_commit('-am', 'cm3');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL dolt_merge('test');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "INSERT INTO t VALUES (3,3),(NULL,6);",
+				Expected: []sql.Row{{sql.OkResult{RowsAffected: 2, InsertID: 3}}},
+			},
+			{
+				Query: "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{
+					{1, 1},
+					{2, 2},
+					{3, 3},
+					{4, 4},
+					{5, 5},
+					{6, 6},
+				},
+			},
+		},
+	},
+}
+
+var Dolt1MergeScripts = []queries.ScriptTest{
+	{
+		Name: "Merge converts the rows of the other side if the primary key types have changed (even if the new type has the same NomsKind)",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk1 bigint, pk2 bigint, PRIMARY KEY (pk1, pk2));",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"ALTER TABLE t MODIFY COLUMN pk2 tinyint",
+			"INSERT INTO t VALUES (2, 2);",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"INSERT INTO t VALUES (1, 1);",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk1;",
+				Expected: []sql.Row{{1, int8(1)}, {2, int8(2)}},
+			},
+		},
+	},
+}
+
+// SchemaConversionMergeScripts test merges of tables whose column types or primary key changed on one side only
+var SchemaConversionMergeScripts = []queries.ScriptTest{
+	{
+		Name: "merge widens the column type of the other side",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int, c2 varchar(20));",
+			"INSERT INTO t VALUES (1, 1, 'a'), (2, 2, 'b');",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"ALTER TABLE t MODIFY COLUMN c1 bigint;",
+			"UPDATE t SET c1 = 20 WHERE pk = 2;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"INSERT INTO t VALUES (3, 3, 'c');",
+			"UPDATE t SET c2 = 'aa' WHERE pk = 1;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{{1, int64(1), "aa"}, {2, int64(20), "b"}, {3, int64(3), "c"}},
+			},
+			{
+				Query:    "SELECT column_name, data_type FROM information_schema.columns WHERE table_name = 't' AND column_name = 'c1';",
+				Expected: []sql.Row{{"c1", "bigint"}},
+			},
+		},
+	},
+	{
+		Name: "merge converts the rows of the other side to a column type of another kind",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int);",
+			"INSERT INTO t VALUES (1, 1), (2, 2);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"INSERT INTO t VALUES (3, 3);",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"ALTER TABLE t MODIFY COLUMN c1 varchar(20);",
+			"UPDATE t SET c1 = 'one' WHERE pk = 1;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{{1, "one"}, {2, "2"}, {3, "3"}},
+			},
+		},
+	},
+	{
+		Name: "merge applies a primary key change to the rows of the other side",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int NOT NULL);",
+			"INSERT INTO t VALUES (1, 1), (2, 2);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"ALTER TABLE t DROP PRIMARY KEY, ADD PRIMARY KEY (pk, c1);",
+			"INSERT INTO t VALUES (1, 10);",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"INSERT INTO t VALUES (3, 3);",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk, c1;",
+				Expected: []sql.Row{{1, 1}, {1, 10}, {2, 2}, {3, 3}},
+			},
+			{
+				Query:    "SELECT column_name FROM information_schema.key_column_usage WHERE table_name = 't' AND constraint_name = 'PRIMARY' ORDER BY ordinal_position;",
+				Expected: []sql.Row{{"pk"}, {"c1"}},
+			},
+		},
+	},
+	{
+		Name: "rows of the other side that don't fit the narrowed column type are constraint violations",
+		SetUpScript: []string{
+			"SET dolt_force_transaction_commit = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int NOT NULL);",
+			"INSERT INTO t VALUES (1, 1), (2, 2);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"ALTER TABLE t MODIFY COLUMN c1 tinyint NOT NULL;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"INSERT INTO t VALUES (3, 300), (4, 4);",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{{1, int8(1)}, {2, int8(2)}, {4, int8(4)}},
+			},
+			{
+				Query:    "SELECT pk, c1 FROM dolt_constraint_violations_t WHERE violation_type = 'schema conversion';",
+				Expected: []sql.Row{{3, nil}},
+			},
+		},
+	},
+	{
+		Name: "rows of the other side that collide under the changed primary key are constraint violations",
+		SetUpScript: []string{
+			"SET dolt_force_transaction_commit = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int NOT NULL);",
+			"INSERT INTO t VALUES (1, 1), (2, 2);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"ALTER TABLE t DROP PRIMARY KEY, ADD PRIMARY KEY (c1);",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"INSERT INTO t VALUES (3, 1);",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{{1, 1}, {2, 2}},
+			},
+			{
+				Query:    "SELECT pk, c1 FROM dolt_constraint_violations_t WHERE violation_type = 'unique index';",
+				Expected: []sql.Row{{3, 1}},
+			},
+		},
+	},
+}
+
+var SchemaConflictMergeScripts = []queries.ScriptTest{
+	{
+		Name: "merge records a schema conflict when both sides change the type of a column",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int);",
+			"INSERT INTO t VALUES (1, 1), (2, 2);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"ALTER TABLE t MODIFY COLUMN c1 varchar(20);",
+			"UPDATE t SET c1 = 'one' WHERE pk = 1;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"ALTER TABLE t MODIFY COLUMN c1 bigint;",
+			"INSERT INTO t VALUES (3, 3);",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT table_name, description FROM dolt_schema_conflicts;",
+				Expected: []sql.Row{{"t", "different column definitions for our column c1 and their column c1"}},
+			},
+			{
+				Query:    "SELECT * FROM t ORDER BY pk;",
+				Expected: []sql.Row{{1, int64(1)}, {2, int64(2)}, {3, int64(3)}},
+			},
+			{
+				Query:          "CALL DOLT_COMMIT('-am', 'merge');",
+				ExpectedErrStr: "error: the table(s) t have unresolved schema conflicts",
+			},
+			{
+				Query:    "CALL DOLT_MERGE('--abort');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT count(*) FROM dolt_schema_conflicts;",
+				Expected: []sql.Row{{0}},
+			},
+		},
+	},
+	{
+		Name: "merge records a schema conflict when both sides add an index with the same name",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int, c2 int);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"CREATE INDEX idx ON t (c2);",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"CREATE INDEX idx ON t (c1);",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT table_name, description FROM dolt_schema_conflicts;",
+				Expected: []sql.Row{{"t", "two indexes with the name 'idx' but different definitions"}},
+			},
+		},
+	},
+}
+
+// FragmentMergeScripts test merges of the views, triggers and stored procedures defined in dolt_schemas and
+// dolt_procedures, which are merged object by object
+var FragmentMergeScripts = []queries.ScriptTest{
+	{
+		Name: "merge takes the triggers added on each side",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int, c2 int);",
+			"CREATE VIEW v AS SELECT pk FROM t;",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"CREATE TRIGGER trg2 BEFORE INSERT ON t FOR EACH ROW SET new.c2 = 2;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"CREATE TRIGGER trg1 BEFORE INSERT ON t FOR EACH ROW SET new.c1 = 1;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT type, name, id FROM dolt_schemas ORDER BY id;",
+				Expected: []sql.Row{{"view", "v", int64(1)}, {"trigger", "trg1", int64(2)}, {"trigger", "trg2", int64(3)}},
+			},
+			{
+				Query:    "INSERT INTO t (pk) VALUES (1);",
+				Expected: []sql.Row{{sql.NewOkResult(1)}},
+			},
+			{
+				Query:    "SELECT * FROM t;",
+				Expected: []sql.Row{{1, 1, 2}},
+			},
+		},
+	},
+	{
+		Name: "merge takes the stored procedures added on each side",
+		SetUpScript: []string{
+			"CREATE PROCEDURE p0() SELECT 0;",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"CREATE PROCEDURE p2() SELECT 2;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"CREATE PROCEDURE p1() SELECT 1;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT name FROM dolt_procedures ORDER BY name;",
+				Expected: []sql.Row{{"p0"}, {"p1"}, {"p2"}},
+			},
+		},
+	},
+	{
+		Name: "merge of a view redefined alike on both sides is not a conflict",
+		SetUpScript: []string{
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int);",
+			"CREATE VIEW v AS SELECT pk FROM t;",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"CREATE OR REPLACE VIEW v AS SELECT   pk,c1 FROM t;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW SET new.c1 = 1;",
+			"CREATE OR REPLACE VIEW v AS select pk, c1 from t;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 0}},
+			},
+			{
+				Query:    "SELECT type, name, fragment FROM dolt_schemas ORDER BY name;",
+				Expected: []sql.Row{{"trigger", "trg", "CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW SET new.c1 = 1"}, {"view", "v", "select pk, c1 from t"}},
+			},
+		},
+	},
+	{
+		Name: "merge of a view redefined differently on both sides is a conflict of that view",
+		SetUpScript: []string{
+			"SET dolt_allow_commit_conflicts = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int);",
+			"CREATE VIEW v AS SELECT pk FROM t;",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"CREATE OR REPLACE VIEW v AS SELECT pk, c1 * 2 FROM t;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"CREATE TRIGGER trg BEFORE INSERT ON t FOR EACH ROW SET new.c1 = 1;",
+			"CREATE OR REPLACE VIEW v AS SELECT pk, c1 FROM t;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query:    "SELECT our_name, our_fragment, their_name, their_fragment FROM dolt_conflicts_dolt_schemas;",
+				Expected: []sql.Row{{"v", "SELECT pk, c1 FROM t", "v", "SELECT pk, c1 * 2 FROM t"}},
+			},
+			{
+				Query:    "SELECT type, name FROM dolt_schemas ORDER BY name;",
+				Expected: []sql.Row{{"trigger", "trg"}, {"view", "v"}},
 			},
 		},
 	},
 	{
-		Name: "dolt_merge() (3way) with a gap in an auto increment key",
+		Name: "merge flags the triggers and views whose tables were dropped on the other side",
 		SetUpScript: []string{
-			"CREATE TABLE t (pk int PRIMARY KEY AUTO_INCREMENT, c0 int);",
-			"INSERT INTO t (c0) VALUES (1);",
-			"CALL dolt_add('-A');",
-			"CALL dolt_commit('-am', 'cm1');",
-			"CALL dolt_checkout('-b', 'test');",
-			"INSERT INTO t VALUES (4,4), (5,5);",
-			"CALL dolt_commit('-am', 'cm2');",
-			"CALL dolt_checkout('main');",
-			"INSERT INTO t (c0) VALUES (2);",
-			"CALL dolt_commit('-am', 'cm3');",
+			"SET dolt_force_transaction_commit = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, c1 int);",
+			"CREATE TABLE u (pk int PRIMARY KEY);",
+			"CALL DOLT_COMMIT('-am', 'setup');",
+
+			"CALL DOLT_CHECKOUT('-b', 'right');",
+			"DROP TABLE u;",
+			"CALL DOLT_COMMIT('-am', 'right commit');",
+
+			"CALL DOLT_CHECKOUT('main');",
+			"CREATE TRIGGER trg BEFORE INSERT ON u FOR EACH ROW SET new.pk = 1;",
+			"CREATE VIEW v AS WITH c AS (SELECT pk FROM t) SELECT * FROM c JOIN u ON c.pk = u.pk;",
+			"CREATE VIEW w AS SELECT * FROM v;",
+			"CALL DOLT_COMMIT('-am', 'left commit');",
 		},
 		Assertions: []queries.ScriptTestAssertion{
 			{
-				Query:    "CALL dolt_merge('test');",
-				Expected: []sql.Row{{0, 0}},
-			},
-			{
-				Query:    "INSERT INTO t VALUES (3,3),(NULL,6);",
-				Expected: []sql.Row{{sql.OkResult{RowsAffected: 2, InsertID: 3}}},
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
 			},
 			{
-				Query: "SELECT * FROM t ORDER BY pk;",
+				Query: "SELECT violation_type, type, name FROM dolt_constraint_violations_dolt_schemas ORDER BY name;",
 				Expected: []sql.Row{
-					{1, 1},
-					{2, 2},
-					{3, 3},
-					{4, 4},
-					{5, 5},
-					{6, 6},
+					{uint64(merge.CvType_InvalidDefinition), "trigger", "trg"},
+					{uint64(merge.CvType_InvalidDefinition), "view", "v"},
 				},
 			},
 		},
-	},
-}
-
-var Dolt1MergeScripts = []queries.ScriptTest{
-	{
-		Name: "Merge errors if the primary key types have changed (even if the new type has the same NomsKind)",
+	}, {
+		Name: "merge flags the triggers and views whose columns were dropped on the other side",
 		SetUpScript: []string{
-			"CREATE TABLE t (pk1 bigint, pk2 bigint, PRIMARY KEY (pk1, pk2));",
+			"SET dolt_force_transaction_commit = on;",
+			"CREATE TABLE t (pk int PRIMARY KEY, c int, d int);",
+			"CREATE TABLE log (pk int PRIMARY KEY, c int);",
 			"CALL DOLT_COMMIT('-am', 'setup');",
 
 			"CALL DOLT_CHECKOUT('-b', 'right');",
-			"ALTER TABLE t MODIFY COLUMN pk2 tinyint",
-			"INSERT INTO t VALUES (2, 2);",
+			"ALTER TABLE t DROP COLUMN c;",
+			"ALTER TABLE log DROP COLUMN c;",
 			"CALL DOLT_COMMIT('-am', 'right commit');",
 
 			"CALL DOLT_CHECKOUT('main');",
-			"INSERT INTO t VALUES (1, 1);",
+			"CREATE VIEW v1 AS SELECT pk, d AS x FROM t ORDER BY x;",
+			"CREATE VIEW v2 AS SELECT c FROM t;",
+			"CREATE VIEW v3 AS SELECT t1.pk FROM t t1 JOIN t t2 ON t1.c = t2.pk;",
+			"CREATE VIEW v4 AS SELECT pk FROM t WHERE d IN (SELECT c FROM log);",
+			"CREATE VIEW v5 AS SELECT c FROM (SELECT pk AS c FROM t) sq;",
+			"CREATE TRIGGER tr1 BEFORE INSERT ON t FOR EACH ROW SET new.c = 1;",
+			"CREATE TRIGGER tr2 BEFORE INSERT ON t FOR EACH ROW SET new.d = new.pk;",
+			"CREATE TRIGGER tr3 AFTER INSERT ON t FOR EACH ROW INSERT INTO log (pk, c) VALUES (new.pk, new.d);",
 			"CALL DOLT_COMMIT('-am', 'left commit');",
 		},
 		Assertions: []queries.ScriptTestAssertion{
 			{
-				Query:          "CALL DOLT_MERGE('right');",
-				ExpectedErrStr: "error: cannot merge two tables with different primary key sets",
+				Query:    "CALL DOLT_MERGE('right');",
+				Expected: []sql.Row{{0, 1}},
+			},
+			{
+				Query: "SELECT violation_type, type, name FROM dolt_constraint_violations_dolt_schemas ORDER BY name;",
+				Expected: []sql.Row{
+					{uint64(merge.CvType_InvalidDefinition), "trigger", "tr1"},
+					{uint64(merge.CvType_InvalidDefinition), "trigger", "tr3"},
+					{uint64(merge.CvType_InvalidDefinition), "view", "v2"},
+					{uint64(merge.CvType_InvalidDefinition), "view", "v3"},
+					{uint64(merge.CvType_InvalidDefinition), "view", "v4"},
+				},
 			},
 		},
 	},
@@ -4081,13 +4690,367 @@ var DiffTableFunctionScriptTests = []queries.ScriptTest{
 		},
 		Assertions: []queries.ScriptTestAssertion{
 			{
-				Query:    "select to_a, to_b, from_commit, to_commit, diff_type from dolt_diff('t2', 'HEAD~', 'HEAD')",
-				Expected: []sql.Row{{3, 4, "HEAD~", "HEAD", "added"}},
+				Query:    "select to_a, to_b, from_commit, to_commit, diff_type from dolt_diff('t2', 'HEAD~', 'HEAD')",
+				Expected: []sql.Row{{3, 4, "HEAD~", "HEAD", "added"}},
+			},
+			{
+				// Maybe confusing? We match the old table name as well
+				Query:    "select to_a, to_b, from_commit, to_commit, diff_type from dolt_diff('t1', 'HEAD~', 'HEAD')",
+				Expected: []sql.Row{{3, 4, "HEAD~", "HEAD", "added"}},
+			},
+		},
+	},
+}
+
+var SchemaDiffTableFunctionScriptTests = []queries.ScriptTest{
+	{
+		Name: "invalid arguments",
+		SetUpScript: []string{
+			"create table t (pk int primary key, c1 varchar(20));",
+			"set @Commit1 = dolt_commit('-am', 'creating table t');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:       "SELECT * from dolt_schema_diff('main');",
+				ExpectedErr: sql.ErrInvalidArgumentNumber,
+			},
+			{
+				Query:       "SELECT * from dolt_schema_diff('main', 'main', 't', 'extra');",
+				ExpectedErr: sql.ErrInvalidArgumentNumber,
+			},
+			{
+				Query:       "SELECT * from dolt_schema_diff(123, 'main');",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+			{
+				Query:       "SELECT * from dolt_schema_diff('main', 'main', 123);",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+			{
+				Query:       "SELECT * from dolt_schema_diff(hashof('main'), 'main');",
+				ExpectedErr: sqle.ErrInvalidNonLiteralArgument,
+			},
+			{
+				Query:       "SELECT * from dolt_schema_diff(@Commit1, 'main', 'doesnotexist');",
+				ExpectedErr: sql.ErrTableNotFound,
+			},
+			{
+				Query:          "SELECT * from dolt_schema_diff(@Commit1, 'fake-branch');",
+				ExpectedErrStr: "branch not found: fake-branch",
+			},
+		},
+	},
+	{
+		Name: "basic case",
+		SetUpScript: []string{
+			"create table t (pk int primary key, c1 int, c2 varchar(20), c3 int, c5 varchar(10));",
+			"create index idx_c1 on t(c1);",
+			"alter table t add constraint chk_c3 check (c3 > 0);",
+			"create table data_only (pk int primary key);",
+			"set @Commit1 = dolt_commit('-am', 'creating tables');",
+
+			"alter table t drop column c2;",
+			"alter table t add column c4 int;",
+			"alter table t modify column c5 varchar(20);",
+			"create index idx_c4 on t(c4);",
+			"alter table t drop constraint chk_c3;",
+			"insert into data_only values (1);",
+			"create table t2 (pk int primary key);",
+			"create table parent (pk int primary key);",
+			"create table child (pk int primary key, parent_pk int, constraint fk_parent foreign key (parent_pk) references parent(pk));",
+			"set @Commit2 = dolt_commit('-am', 'changing schemas');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query: "SELECT from_table_name, to_table_name, added_columns, dropped_columns, modified_columns, added_indexes, dropped_indexes, modified_indexes, added_checks, dropped_checks " +
+					"from dolt_schema_diff(@Commit1, @Commit2) where to_table_name in ('t', 't2');",
+				Expected: []sql.Row{
+					{"t", "t", sql.MustJSON(`["c4"]`), sql.MustJSON(`["c2"]`), sql.MustJSON(`["c5"]`), sql.MustJSON(`["idx_c4"]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`["chk_c3"]`)},
+					{nil, "t2", sql.MustJSON(`["pk"]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`), sql.MustJSON(`[]`)},
+				},
+			},
+			{
+				Query:    "SELECT to_table_name, added_columns, added_foreign_keys from dolt_schema_diff(@Commit1, @Commit2, 'child');",
+				Expected: []sql.Row{{"child", sql.MustJSON(`["pk", "parent_pk"]`), sql.MustJSON(`["fk_parent"]`)}},
+			},
+			{
+				Query: "SELECT from_create_statement, to_create_statement from dolt_schema_diff(@Commit1, @Commit2, 't2');",
+				Expected: []sql.Row{{nil, "CREATE TABLE `t2` (\n" +
+					"  `pk` int NOT NULL,\n" +
+					"  PRIMARY KEY (`pk`)\n" +
+					") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_bin"}},
+			},
+			{
+				Query:    "SELECT from_table_name, to_table_name, dropped_columns from dolt_schema_diff(@Commit2, @Commit1, 't2');",
+				Expected: []sql.Row{{"t2", nil, sql.MustJSON(`["pk"]`)}},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_schema_diff(@Commit1, @Commit2, 'data_only');",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_schema_diff(@Commit2, 'main');",
+				Expected: []sql.Row{{0}},
+			},
+		},
+	},
+	{
+		Name: "renamed table",
+		SetUpScript: []string{
+			"create table t1 (pk int primary key, c1 int);",
+			"set @Commit1 = dolt_commit('-am', 'creating table t1');",
+			"rename table t1 to t2;",
+			"set @Commit2 = dolt_commit('-am', 'renaming table t1');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "SELECT from_table_name, to_table_name, added_columns, dropped_columns from dolt_schema_diff(@Commit1, @Commit2);",
+				Expected: []sql.Row{{"t1", "t2", sql.MustJSON(`[]`), sql.MustJSON(`[]`)}},
+			},
+			{
+				Query:    "SELECT from_table_name, to_table_name from dolt_schema_diff(@Commit1, @Commit2, 't1');",
+				Expected: []sql.Row{{"t1", "t2"}},
+			},
+		},
+	},
+}
+
+var LogTableFunctionScriptTests = []queries.ScriptTest{
+	{
+		Name: "invalid arguments",
+		SetUpScript: []string{
+			"create table t (pk int primary key);",
+			"set @Commit1 = dolt_commit('-am', 'creating table t');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:       "SELECT * from dolt_log(123);",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+			{
+				Query:       "SELECT * from dolt_log('--decorate', 'invalid');",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+			{
+				Query:       "SELECT * from dolt_log('--unknown');",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+			{
+				Query:       "SELECT * from dolt_log(hashof('main'));",
+				ExpectedErr: sqle.ErrInvalidNonLiteralArgument,
+			},
+			{
+				Query:          "SELECT * from dolt_log('main..fake-branch');",
+				ExpectedErrStr: "branch not found: fake-branch",
+			},
+		},
+	},
+	{
+		Name: "revision ranges",
+		SetUpScript: []string{
+			"create table t (pk int primary key);",
+			"set @Commit1 = dolt_commit('-am', 'creating table t');",
+			"create table t2 (pk int primary key);",
+			"set @Commit2 = dolt_commit('-am', 'creating table t2');",
+			"call dolt_branch('feature');",
+			"insert into t values (1);",
+			"set @Commit3 = dolt_commit('-am', 'inserting into t on main');",
+			"call dolt_checkout('feature');",
+			"insert into t2 values (1);",
+			"set @Commit4 = dolt_commit('-am', 'inserting into t2 on feature');",
+			"insert into t2 values (2);",
+			"set @Commit5 = dolt_commit('-am', 'inserting into t2 on feature again');",
+			"call dolt_checkout('main');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "SELECT commit_hash = @Commit3 from dolt_log() limit 1;",
+				Expected: []sql.Row{{true}},
+			},
+			{
+				Query:    "SELECT message from dolt_log('main..feature');",
+				Expected: []sql.Row{{"inserting into t2 on feature again"}, {"inserting into t2 on feature"}},
+			},
+			{
+				Query:    "SELECT message from dolt_log('feature', '^main');",
+				Expected: []sql.Row{{"inserting into t2 on feature again"}, {"inserting into t2 on feature"}},
+			},
+			{
+				Query:    "SELECT message from dolt_log('feature', '--not', 'main');",
+				Expected: []sql.Row{{"inserting into t2 on feature again"}, {"inserting into t2 on feature"}},
+			},
+			{
+				Query:    "SELECT message from dolt_log('feature..main');",
+				Expected: []sql.Row{{"inserting into t on main"}},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_log('main...feature');",
+				Expected: []sql.Row{{3}},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_log('feature..feature');",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT message from dolt_log('--tables', 't');",
+				Expected: []sql.Row{{"inserting into t on main"}, {"creating table t"}},
+			},
+			{
+				Query:    "SELECT message from dolt_log('main', 'feature', '--tables', 't2');",
+				Expected: []sql.Row{{"inserting into t2 on feature again"}, {"inserting into t2 on feature"}, {"creating table t2"}},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_log('main', 'feature', '-t', 'T, t2') where commit_hash in (@Commit1, @Commit2, @Commit3, @Commit4, @Commit5);",
+				Expected: []sql.Row{{5}},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_log('--merges');",
+				Expected: []sql.Row{{0}},
+			},
+		},
+	},
+	{
+		Name: "symmetric difference with several merge bases",
+		SetUpScript: []string{
+			"create table t1 (pk int primary key);",
+			"create table t2 (pk int primary key);",
+			"call dolt_add('.');",
+			"call dolt_commit('-m', 'creating tables');",
+			"call dolt_branch('b2');",
+			"call dolt_checkout('-b', 'b1');",
+			"insert into t1 values (1);",
+			"call dolt_commit('-am', 'inserting into t1 on b1');",
+			"call dolt_branch('a1');",
+			"call dolt_checkout('b2');",
+			"insert into t2 values (1);",
+			"call dolt_commit('-am', 'inserting into t2 on b2');",
+			"call dolt_checkout('b1');",
+			"call dolt_merge('b2');",
+			"call dolt_commit('-am', 'merging b2 into b1');",
+			"insert into t1 values (2);",
+			"call dolt_commit('-am', 'inserting into t1 on b1 again');",
+			"call dolt_checkout('b2');",
+			"call dolt_merge('a1');",
+			"call dolt_commit('-am', 'merging b1 into b2');",
+			"insert into t2 values (2);",
+			"call dolt_commit('-am', 'inserting into t2 on b2 again');",
+			"call dolt_checkout('main');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query: "SELECT message from dolt_log('b1...b2') order by message;",
+				Expected: []sql.Row{
+					{"inserting into t1 on b1 again"},
+					{"inserting into t2 on b2 again"},
+					{"merging b1 into b2"},
+					{"merging b2 into b1"},
+				},
+			},
+			{
+				Query:    "SELECT COUNT(*) from dolt_log('b2...b1');",
+				Expected: []sql.Row{{4}},
+			},
+		},
+	},
+	{
+		Name: "parents and decorations",
+		SetUpScript: []string{
+			"create table t (pk int primary key);",
+			"set @Commit1 = dolt_commit('-am', 'creating table t');",
+			"call dolt_checkout('-b', 'feature');",
+			"insert into t values (1);",
+			"set @Commit2 = dolt_commit('-am', 'inserting into t on feature');",
+			"call dolt_checkout('main');",
+			"insert into t values (2);",
+			"set @Commit3 = dolt_commit('-am', 'inserting into t on main');",
+			"call dolt_merge('feature');",
+			"set @Commit4 = dolt_commit('-am', 'merging feature');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "SELECT parents = @Commit1 from dolt_log('--parents') where commit_hash = @Commit2;",
+				Expected: []sql.Row{{true}},
+			},
+			{
+				Query:    "SELECT parents = concat(@Commit3, ', ', @Commit2) from dolt_log('--parents', '--merges');",
+				Expected: []sql.Row{{true}},
+			},
+			{
+				Query:    "SELECT refs from dolt_log('--decorate', 'short') where commit_hash = @Commit2;",
+				Expected: []sql.Row{{"feature"}},
+			},
+			{
+				Query:    "SELECT refs from dolt_log('--decorate=full') where commit_hash = @Commit2;",
+				Expected: []sql.Row{{"refs/heads/feature"}},
+			},
+			{
+				Query:    "SELECT refs from dolt_log('--decorate', 'short') where commit_hash = @Commit1;",
+				Expected: []sql.Row{{""}},
+			},
+		},
+	},
+}
+
+var ReflogTableFunctionScriptTests = []queries.ScriptTest{
+	{
+		Name: "invalid arguments",
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:       "SELECT * from dolt_reflog(123);",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+			{
+				Query:       "SELECT * from dolt_reflog('main', 'other');",
+				ExpectedErr: sql.ErrInvalidArgumentNumber,
+			},
+			{
+				Query:       "SELECT * from dolt_reflog('--unknown');",
+				ExpectedErr: sql.ErrInvalidArgumentDetails,
+			},
+		},
+	},
+	{
+		Name: "movements of branches",
+		SetUpScript: []string{
+			"create table t (pk int primary key);",
+			"call dolt_add('.');",
+			"call dolt_commit('-m', 'creating table t');",
+			"set @Commit1 = hashof('HEAD');",
+			"insert into t values (1);",
+			"call dolt_commit('-am', 'inserting into t');",
+			"set @Commit2 = hashof('HEAD');",
+			"call dolt_branch('b1');",
+			"call dolt_reset('--hard', @Commit1);",
+			"call dolt_branch('-D', 'b1');",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "SELECT action, old_hash = @Commit2, new_hash is null from dolt_reflog('b1');",
+				Expected: []sql.Row{{"delete", true, true}, {"set head", nil, false}},
+			},
+			{
+				Query:    "SELECT count(*) from dolt_reflog('refs/heads/b1');",
+				Expected: []sql.Row{{2}},
+			},
+			{
+				Query:    "SELECT action, old_hash = @Commit2, new_hash = @Commit1 from dolt_reflog('main') limit 1;",
+				Expected: []sql.Row{{"set head", true, true}},
+			},
+			{
+				Query:    "SELECT count(*) from dolt_reflog('main') where action = 'commit' and new_hash = @Commit2;",
+				Expected: []sql.Row{{1}},
+			},
+			{
+				Query:    "SELECT count(*) from dolt_reflog() where ref like 'workingSets/%';",
+				Expected: []sql.Row{{0}},
 			},
 			{
-				// Maybe confusing? We match the old table name as well
-				Query:    "select to_a, to_b, from_commit, to_commit, diff_type from dolt_diff('t1', 'HEAD~', 'HEAD')",
-				Expected: []sql.Row{{3, 4, "HEAD~", "HEAD", "added"}},
+				Query:    "SELECT count(*) > 0 from dolt_reflog('--all') where ref = 'workingSets/heads/main';",
+				Expected: []sql.Row{{true}},
+			},
+			{
+				Query:    "SELECT count(*) from dolt_reflog('nope');",
+				Expected: []sql.Row{{0}},
 			},
 		},
 	},
@@ -5205,3 +6168,417 @@ var DoltRemoteTestScripts = []queries.ScriptTest{
 		},
 	},
 }
+
+var DoltStashTestScripts = []queries.ScriptTest{
+	{
+		Name: "dolt-stash: SQL push and pop stash entries",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0),(1,1),(2,2);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"UPDATE test SET c1 = 10 WHERE pk = 1",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_STASH('push', '-m', 'update row 1')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 1}, {2, 2}},
+			},
+			{
+				Query:    "SELECT name, message FROM dolt_stashes",
+				Expected: []sql.Row{{"stash@{0}", "On main: update row 1"}},
+			},
+			{
+				Query:    "CALL DOLT_STASH('pop')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 10}, {2, 2}},
+			},
+			{
+				Query:    "SELECT name FROM dolt_stashes",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:          "CALL DOLT_STASH('pop')",
+				ExpectedErrStr: "no stash entries found",
+			},
+		},
+	},
+	{
+		Name: "dolt-stash: SQL pop restores staged and working changes separately",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"CREATE TABLE other(pk int primary key);",
+			"CALL DOLT_ADD('.')",
+			"CALL DOLT_COMMIT('-m','created tables')",
+			"INSERT INTO test VALUES (1,1)",
+			"CALL DOLT_ADD('test')",
+			"INSERT INTO other VALUES (1)",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_STASH('push')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM dolt_status",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "CALL DOLT_STASH('pop')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM dolt_status ORDER BY table_name",
+				Expected: []sql.Row{{"other", false, "modified"}, {"test", true, "modified"}},
+			},
+		},
+	},
+	{
+		Name: "dolt-stash: SQL apply, drop and clear stash entries",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0),(1,1),(2,2);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"INSERT INTO test VALUES (3,3)",
+			"CALL DOLT_STASH('-m', 'first')",
+			"INSERT INTO test VALUES (4,4)",
+			"CALL DOLT_STASH('-m', 'second')",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "SELECT name, message FROM dolt_stashes",
+				Expected: []sql.Row{{"stash@{0}", "On main: second"}, {"stash@{1}", "On main: first"}},
+			},
+			{
+				Query:    "CALL DOLT_STASH('apply', 'stash@{1}')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 1}, {2, 2}, {3, 3}},
+			},
+			{
+				Query:    "SELECT count(*) FROM dolt_stashes",
+				Expected: []sql.Row{{2}},
+			},
+			{
+				Query:    "CALL DOLT_STASH('drop', 'stash@{0}')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT name, message FROM dolt_stashes",
+				Expected: []sql.Row{{"stash@{0}", "On main: first"}},
+			},
+			{
+				Query:    "CALL DOLT_STASH('clear')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT name FROM dolt_stashes",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:          "CALL DOLT_STASH('list')",
+				ExpectedErrStr: "error: invalid argument, use 'dolt_stashes' system table to list stash entries",
+			},
+		},
+	},
+	{
+		Name: "dolt-stash: SQL pop with conflicts keeps the stash entry",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0),(1,1),(2,2);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"UPDATE test SET c1 = 10 WHERE pk = 1",
+			"CALL DOLT_STASH()",
+			"UPDATE test SET c1 = 20 WHERE pk = 1",
+			"CALL DOLT_COMMIT('-am','conflicting change')",
+			"SET dolt_allow_commit_conflicts = on",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_STASH('pop')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT COUNT(*) FROM dolt_conflicts",
+				Expected: []sql.Row{{1}},
+			},
+			{
+				Query:    "SELECT count(*) FROM dolt_stashes",
+				Expected: []sql.Row{{1}},
+			},
+		},
+	},
+}
+
+var DoltRebaseTestScripts = []queries.ScriptTest{
+	{
+		Name: "dolt-rebase: SQL rebase branch onto main",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"CALL DOLT_CHECKOUT('-b', 'feature')",
+			"INSERT INTO test VALUES (1,1);",
+			"CALL DOLT_COMMIT('-am','insert row 1')",
+			"INSERT INTO test VALUES (2,2);",
+			"CALL DOLT_COMMIT('-am','insert row 2')",
+			"CALL DOLT_CHECKOUT('main')",
+			"INSERT INTO test VALUES (10,10);",
+			"CALL DOLT_COMMIT('-am','insert row 10')",
+			"CALL DOLT_CHECKOUT('feature')",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_REBASE('main')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 1}, {2, 2}, {10, 10}},
+			},
+			{
+				Query:    "SELECT message FROM dolt_log",
+				Expected: []sql.Row{{"insert row 2"}, {"insert row 1"}, {"insert row 10"}, {"created table test"}, {"checkpoint enginetest database mydb"}, {"Initialize data repository"}},
+			},
+			{
+				Query:    "SELECT count(*) FROM dolt_rebase",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "CALL DOLT_REBASE('main')",
+				Expected: []sql.Row{{0}},
+			},
+		},
+	},
+	{
+		Name: "dolt-rebase: SQL interactive rebase with an edited plan",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"CALL DOLT_CHECKOUT('-b', 'feature')",
+			"INSERT INTO test VALUES (1,1);",
+			"CALL DOLT_COMMIT('-am','insert row 1')",
+			"INSERT INTO test VALUES (2,2);",
+			"CALL DOLT_COMMIT('-am','insert row 2')",
+			"INSERT INTO test VALUES (3,3);",
+			"CALL DOLT_COMMIT('-am','insert row 3')",
+			"INSERT INTO test VALUES (4,4);",
+			"CALL DOLT_COMMIT('-am','insert row 4')",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_REBASE('-i', 'main')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT rebase_order, action, commit_message FROM dolt_rebase",
+				Expected: []sql.Row{{1, "pick", "insert row 1"}, {2, "pick", "insert row 2"}, {3, "pick", "insert row 3"}, {4, "pick", "insert row 4"}},
+			},
+			{
+				Query:    "UPDATE dolt_rebase SET action = 'squash' WHERE rebase_order = 2",
+				Expected: []sql.Row{{sql.OkResult{RowsAffected: 1, Info: plan.UpdateInfo{Matched: 1, Updated: 1}}}},
+			},
+			{
+				Query:    "UPDATE dolt_rebase SET action = 'reword', commit_message = 'insert row three' WHERE rebase_order = 3",
+				Expected: []sql.Row{{sql.OkResult{RowsAffected: 1, Info: plan.UpdateInfo{Matched: 1, Updated: 1}}}},
+			},
+			{
+				Query:    "DELETE FROM dolt_rebase WHERE rebase_order = 4",
+				Expected: []sql.Row{{sql.NewOkResult(1)}},
+			},
+			{
+				Query:          "UPDATE dolt_rebase SET action = 'rewind' WHERE rebase_order = 1",
+				ExpectedErrStr: "invalid rebase action 'rewind'",
+			},
+			{
+				Query:    "CALL DOLT_REBASE('--continue')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 1}, {2, 2}, {3, 3}},
+			},
+			{
+				Query:    "SELECT message FROM dolt_log",
+				Expected: []sql.Row{{"insert row three"}, {"insert row 1\n\ninsert row 2"}, {"created table test"}, {"checkpoint enginetest database mydb"}, {"Initialize data repository"}},
+			},
+			{
+				Query:          "CALL DOLT_REBASE('--continue')",
+				ExpectedErrStr: "there is no rebase in progress",
+			},
+		},
+	},
+	{
+		Name: "dolt-rebase: SQL squash after a commit that became empty",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"CALL DOLT_CHECKOUT('-b', 'feature')",
+			"INSERT INTO test VALUES (1,1);",
+			"CALL DOLT_COMMIT('-am','insert row 1')",
+			"INSERT INTO test VALUES (2,2);",
+			"CALL DOLT_COMMIT('-am','insert row 2')",
+			"CALL DOLT_CHECKOUT('main')",
+			"INSERT INTO test VALUES (1,1);",
+			"CALL DOLT_COMMIT('-am','insert row 1 on main')",
+			"CALL DOLT_CHECKOUT('feature')",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_REBASE('-i', 'main')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "UPDATE dolt_rebase SET action = 'squash' WHERE rebase_order = 2",
+				Expected: []sql.Row{{sql.OkResult{RowsAffected: 1, Info: plan.UpdateInfo{Matched: 1, Updated: 1}}}},
+			},
+			{
+				Query:    "CALL DOLT_REBASE('--continue')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 1}, {2, 2}},
+			},
+			{
+				Query:    "SELECT message FROM dolt_log",
+				Expected: []sql.Row{{"insert row 2"}, {"insert row 1 on main"}, {"created table test"}, {"checkpoint enginetest database mydb"}, {"Initialize data repository"}},
+			},
+		},
+	},
+	{
+		Name: "dolt-rebase: SQL rebase stops on conflicts",
+		SetUpScript: []string{
+			"CREATE TABLE test(pk int primary key, c1 int);",
+			"INSERT INTO test VALUES (0,0),(1,1);",
+			"CALL DOLT_COMMIT('-am','created table test')",
+			"CALL DOLT_CHECKOUT('-b', 'feature')",
+			"UPDATE test SET c1 = 10 WHERE pk = 1",
+			"CALL DOLT_COMMIT('-am','update row 1 on feature')",
+			"CALL DOLT_CHECKOUT('main')",
+			"UPDATE test SET c1 = 20 WHERE pk = 1",
+			"CALL DOLT_COMMIT('-am','update row 1 on main')",
+			"CALL DOLT_CHECKOUT('feature')",
+			"SET dolt_allow_commit_conflicts = on",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_REBASE('main')",
+				Expected: []sql.Row{{1}},
+			},
+			{
+				Query:    "SELECT COUNT(*) FROM dolt_conflicts",
+				Expected: []sql.Row{{1}},
+			},
+			{
+				Query:          "CALL DOLT_REBASE('--continue')",
+				ExpectedErrStr: "cannot continue the rebase while there are unresolved conflicts or constraint violations",
+			},
+			{
+				Query:    "CALL DOLT_REBASE('--abort')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT * FROM test",
+				Expected: []sql.Row{{0, 0}, {1, 10}},
+			},
+			{
+				Query:    "SELECT COUNT(*) FROM dolt_conflicts",
+				Expected: []sql.Row{{0}},
+			},
+		},
+	},
+}
+
+var DoltIgnoreTestScripts = []queries.ScriptTest{
+	{
+		Name: "dolt-ignore: ignored tables are not staged or listed in dolt_status",
+		SetUpScript: []string{
+			"INSERT INTO dolt_ignore VALUES ('tmp_*', true), ('tmp_keep', false);",
+			"CREATE TABLE tmp_scratch (pk int primary key);",
+			"CREATE TABLE tmp_keep (pk int primary key);",
+			"CREATE TABLE users (pk int primary key);",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "SELECT * FROM dolt_ignore ORDER BY pattern",
+				Expected: []sql.Row{{"tmp_*", int8(1)}, {"tmp_keep", int8(0)}},
+			},
+			{
+				Query:    "SELECT table_name, staged, status FROM dolt_status ORDER BY table_name",
+				Expected: []sql.Row{{"dolt_ignore", false, "new table"}, {"tmp_keep", false, "new table"}, {"users", false, "new table"}},
+			},
+			{
+				Query:    "CALL DOLT_ADD('-A')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT table_name, staged FROM dolt_status ORDER BY table_name",
+				Expected: []sql.Row{{"dolt_ignore", true}, {"tmp_keep", true}, {"users", true}},
+			},
+			{
+				Query:          "CALL DOLT_ADD('tmp_scratch')",
+				ExpectedErrStr: "error: the table(s) tmp_scratch are ignored by dolt_ignore",
+			},
+			{
+				Query:    "CALL DOLT_ADD('-f', 'tmp_scratch')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SELECT table_name, staged FROM dolt_status WHERE table_name = 'tmp_scratch'",
+				Expected: []sql.Row{{"tmp_scratch", true}},
+			},
+		},
+	},
+	{
+		Name: "dolt-ignore: dolt_clean skips ignored tables",
+		SetUpScript: []string{
+			"INSERT INTO dolt_ignore VALUES ('staging_*', true);",
+			"CALL DOLT_ADD('-A')",
+			"CALL DOLT_COMMIT('-m', 'add dolt_ignore')",
+			"CREATE TABLE staging_orders (pk int primary key);",
+			"CREATE TABLE orders (pk int primary key);",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:    "CALL DOLT_CLEAN()",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SHOW TABLES",
+				Expected: []sql.Row{{"myview"}, {"staging_orders"}},
+			},
+			{
+				Query:    "CALL DOLT_CLEAN('-x')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "SHOW TABLES",
+				Expected: []sql.Row{{"myview"}},
+			},
+		},
+	},
+	{
+		Name: "dolt-ignore: conflicting patterns",
+		SetUpScript: []string{
+			"INSERT INTO dolt_ignore VALUES ('data_*', false), ('*_scratch', true);",
+			"CREATE TABLE data_scratch (pk int primary key);",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:          "CALL DOLT_ADD('-A')",
+				ExpectedErrStr: "the table data_scratch matches conflicting patterns in dolt_ignore: ignored: [*_scratch], not ignored: [data_*]",
+			},
+		},
+	},
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/dolthub/go-mysql-server/sql"
 	"github.com/dolthub/go-mysql-server/sql/plan"
 
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
 	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
 )
 
@@ -1426,6 +1427,118 @@ var DoltSqlFuncTransactionTests = []queries.TransactionTest{
 			},
 		},
 	},
+	{
+		Name: "dolt_rebase moves the branch when the transaction commits",
+		SetUpScript: []string{
+			"CREATE TABLE test (pk int primary key, val int)",
+			"INSERT INTO test VALUES (0, 0)",
+			"CALL DOLT_COMMIT('-am', 'created table test')",
+			"CALL DOLT_BRANCH('feature')",
+			"INSERT INTO test VALUES (10, 10)",
+			"CALL DOLT_COMMIT('-am', 'insert row 10')",
+			"CALL DOLT_CHECKOUT('feature')",
+			"INSERT INTO test VALUES (1, 1)",
+			"CALL DOLT_COMMIT('-am', 'insert row 1')",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:            "/* client a */ CALL DOLT_CHECKOUT('feature')",
+				SkipResultsCheck: true,
+			},
+			{
+				Query:    "/* client a */ start transaction",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "/* client a */ CALL DOLT_REBASE('main')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "/* client a */ SELECT message FROM dolt_log LIMIT 2",
+				Expected: []sql.Row{{"insert row 1"}, {"insert row 10"}},
+			},
+			{
+				Query:    "/* client b */ SELECT message FROM dolt_log('feature') LIMIT 2",
+				Expected: []sql.Row{{"insert row 1"}, {"created table test"}},
+			},
+			{
+				Query:    "/* client a */ rollback",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "/* client a */ SELECT message FROM dolt_log LIMIT 2",
+				Expected: []sql.Row{{"insert row 1"}, {"created table test"}},
+			},
+			{
+				Query:    "/* client a */ start transaction",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "/* client a */ CALL DOLT_REBASE('main')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "/* client a */ commit",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "/* client b */ SELECT message FROM dolt_log('feature') LIMIT 2",
+				Expected: []sql.Row{{"insert row 1"}, {"insert row 10"}},
+			},
+		},
+	},
+	{
+		Name: "dolt_rebase fails to commit if the branch was moved by another transaction",
+		SetUpScript: []string{
+			"CREATE TABLE test (pk int primary key, val int)",
+			"INSERT INTO test VALUES (0, 0)",
+			"CALL DOLT_COMMIT('-am', 'created table test')",
+			"CALL DOLT_BRANCH('feature')",
+			"INSERT INTO test VALUES (10, 10)",
+			"CALL DOLT_COMMIT('-am', 'insert row 10')",
+			"CALL DOLT_CHECKOUT('feature')",
+			"INSERT INTO test VALUES (1, 1)",
+			"CALL DOLT_COMMIT('-am', 'insert row 1')",
+		},
+		Assertions: []queries.ScriptTestAssertion{
+			{
+				Query:            "/* client a */ CALL DOLT_CHECKOUT('feature')",
+				SkipResultsCheck: true,
+			},
+			{
+				Query:            "/* client b */ CALL DOLT_CHECKOUT('feature')",
+				SkipResultsCheck: true,
+			},
+			{
+				Query:    "/* client a */ start transaction",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "/* client a */ CALL DOLT_REBASE('main')",
+				Expected: []sql.Row{{0}},
+			},
+			{
+				Query:    "/* client b */ INSERT INTO test VALUES (2, 2)",
+				Expected: []sql.Row{{sql.NewOkResult(1)}},
+			},
+			{
+				Query:            "/* client b */ CALL DOLT_COMMIT('-am', 'insert row 2')",
+				SkipResultsCheck: true,
+			},
+			{
+				Query:          "/* client a */ commit",
+				ExpectedErrStr: doltdb.ErrHeadMoved.Error(),
+			},
+			{
+				Query:    "/* client a */ rollback",
+				Expected: []sql.Row{},
+			},
+			{
+				Query:    "/* client a */ SELECT message FROM dolt_log LIMIT 3",
+				Expected: []sql.Row{{"insert row 2"}, {"insert row 1"}, {"created table test"}},
+			},
+		},
+	},
 }
 
 var DoltConstraintViolationTransactionTests = []queries.TransactionTest{
@@ -18,6 +18,8 @@ import (
 	"context"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/dolthub/go-mysql-server/sql"
 	"github.com/dolthub/go-mysql-server/sql/expression"
@@ -61,9 +63,16 @@ var _ sql.IndexedTable = (*HistoryTable)(nil)
 
 // HistoryTable is a system table that shows the history of rows over time
 type HistoryTable struct {
-	doltTable     *DoltTable
+	doltTable *DoltTable
+	ddb       *doltdb.DoltDB
+	head      *doltdb.Commit
+	// excluded is the commit whose ancestors are left out of the history, nil for the whole history of head
+	excluded      *doltdb.Commit
 	commitFilters []sql.Expression
-	cmItr         doltdb.CommitItr
+	commitCheck   doltdb.CommitFilter
+	// minCommitDate is the lower bound of commit_date in the commit filters, if any. The commit walk doesn't go past
+	// commits which, along with all of their ancestors, are older than it.
+	minCommitDate *time.Time
 	indexLookup   sql.IndexLookup
 	projectedCols []uint64
 }
@@ -90,14 +99,21 @@ func (ht HistoryTable) WithIndexLookup(lookup sql.IndexLookup) sql.Table {
 
 // NewHistoryTable creates a history table
 func NewHistoryTable(table *DoltTable, ddb *doltdb.DoltDB, head *doltdb.Commit) sql.Table {
-	cmItr := doltdb.CommitItrForRoots(ddb, head)
 	h := &HistoryTable{
 		doltTable: table,
-		cmItr:     cmItr,
+		ddb:       ddb,
+		head:      head,
 	}
 	return h
 }
 
+// WithExcludedAncestors returns a copy of this history table that leaves out the ancestors of |cm|, like the revision
+// range cm..head of dolt log.
+func (ht HistoryTable) WithExcludedAncestors(cm *doltdb.Commit) *HistoryTable {
+	ht.excluded = cm
+	return &ht
+}
+
 // History table schema returns the corresponding history table schema for the base table given, which consists of
 // the table's schema with 3 additional columns
 func historyTableSchema(tableName string, table *DoltTable) sql.Schema {
@@ -148,17 +164,196 @@ func (ht HistoryTable) WithFilters(ctx *sql.Context, filters []sql.Expression) s
 	}
 
 	if len(ht.commitFilters) > 0 {
-		commitCheck, err := commitFilterForExprs(ctx, ht.commitFilters)
+		minCommitDate, err := commitDateLowerBound(ht.commitFilters)
 		if err != nil {
 			return sqlutil.NewStaticErrorTable(&ht, err)
 		}
+		ht.minCommitDate = minCommitDate
 
-		ht.cmItr = doltdb.NewFilteringCommitItr(ht.cmItr, commitCheck)
+		ht.commitCheck, err = commitFilterForExprs(ctx, ht.commitFilters)
+		if err != nil {
+			return sqlutil.NewStaticErrorTable(&ht, err)
+		}
 	}
 
 	return &ht
 }
 
+// commitItr returns an iterator over the commits of the history of this table which may match its commit filters.
+func (ht *HistoryTable) commitItr(ctx *sql.Context) (doltdb.CommitItr, error) {
+	var excluded []*doltdb.Commit
+	if ht.excluded != nil {
+		excluded = append(excluded, ht.excluded)
+	}
+
+	var prune doltdb.CommitFilter
+	if ht.minCommitDate != nil {
+		minCommitDate := *ht.minCommitDate
+		prune = func(ctx context.Context, h hash.Hash, cm *doltdb.Commit) (bool, error) {
+			meta, err := cm.GetCommitMeta(ctx)
+			if err != nil || !meta.Time().Before(minCommitDate) {
+				return false, err
+			}
+
+			// commit dates can be set freely, so an ancestor may be newer than its descendants
+			newest, err := newestCommitDate(ctx, ht.ddb, h, cm)
+			if err != nil {
+				return false, err
+			}
+			return newest.Before(minCommitDate), nil
+		}
+	}
+
+	cmItr, err := doltdb.CommitItrForRange(ctx, ht.ddb, []*doltdb.Commit{ht.head}, excluded, prune)
+	if err != nil {
+		return nil, err
+	}
+
+	if ht.commitCheck != nil {
+		cmItr = doltdb.NewFilteringCommitItr(cmItr, ht.commitCheck)
+	}
+	return cmItr, nil
+}
+
+// commitDateLowerBound returns the lower bound of the commit date in |filters|, or nil if they have none.
+func commitDateLowerBound(filters []sql.Expression) (*time.Time, error) {
+	var lowerBound *time.Time
+	for _, filter := range filters {
+		var bound sql.Expression
+		switch f := filter.(type) {
+		case *expression.GreaterThan:
+			if isCommitDateField(f.Left()) {
+				bound = f.Right()
+			}
+		case *expression.GreaterThanOrEqual:
+			if isCommitDateField(f.Left()) {
+				bound = f.Right()
+			}
+		case *expression.LessThan:
+			if isCommitDateField(f.Right()) {
+				bound = f.Left()
+			}
+		case *expression.LessThanOrEqual:
+			if isCommitDateField(f.Right()) {
+				bound = f.Left()
+			}
+		case *expression.Equals:
+			if isCommitDateField(f.Left()) {
+				bound = f.Right()
+			} else if isCommitDateField(f.Right()) {
+				bound = f.Left()
+			}
+		case *expression.Between:
+			if isCommitDateField(f.Val) {
+				bound = f.Lower
+			}
+		}
+
+		lit, ok := bound.(*expression.Literal)
+		if !ok || lit.Value() == nil {
+			continue
+		}
+		val, err := sql.Datetime.Convert(lit.Value())
+		if err != nil {
+			return nil, err
+		}
+		if t := val.(time.Time); lowerBound == nil || t.After(*lowerBound) {
+			lowerBound = &t
+		}
+	}
+	return lowerBound, nil
+}
+
+func isCommitDateField(e sql.Expression) bool {
+	gf, ok := e.(*expression.GetField)
+	return ok && strings.ToLower(gf.Name()) == CommitDateCol
+}
+
+// maxNewestCommitDates is the number of commits whose newest commit date is cached.
+const maxNewestCommitDates = 1 << 18
+
+// newestCommitDates caches the newest commit date among each commit and its ancestors. Commits never change, so the
+// cached dates never go stale. The cache is emptied when it's full.
+var newestCommitDates = struct {
+	mu    *sync.Mutex
+	dates map[hash.Hash]time.Time
+}{mu: &sync.Mutex{}, dates: make(map[hash.Hash]time.Time)}
+
+// newestCommitDate returns the newest commit date among the commit |cm|, with hash |h|, and its ancestors. The
+// ancestors of shallow commits are missing, so the history is taken to end at them.
+func newestCommitDate(ctx context.Context, ddb *doltdb.DoltDB, h hash.Hash, cm *doltdb.Commit) (time.Time, error) {
+	newest := make(map[hash.Hash]time.Time)
+	lookup := func(h hash.Hash) (time.Time, bool) {
+		if t, ok := newest[h]; ok {
+			return t, true
+		}
+		newestCommitDates.mu.Lock()
+		defer newestCommitDates.mu.Unlock()
+		t, ok := newestCommitDates.dates[h]
+		return t, ok
+	}
+	if t, ok := lookup(h); ok {
+		return t, nil
+	}
+
+	// a commit stays on the stack until the newest dates of all of its parents are known
+	hashes, commits := []hash.Hash{h}, []*doltdb.Commit{cm}
+	for len(hashes) > 0 {
+		top := len(hashes) - 1
+		h, cm := hashes[top], commits[top]
+		if t, ok := lookup(h); ok {
+			newest[h] = t
+			hashes, commits = hashes[:top], commits[:top]
+			continue
+		}
+
+		meta, err := cm.GetCommitMeta(ctx)
+		if err != nil {
+			return time.Time{}, err
+		}
+		date := meta.Time()
+
+		var parents []hash.Hash
+		if !cm.IsShallow() {
+			parents, err = cm.ParentHashes(ctx)
+			if err != nil {
+				return time.Time{}, err
+			}
+		}
+
+		pending := false
+		for _, ph := range parents {
+			t, ok := lookup(ph)
+			if !ok {
+				parent, err := ddb.ReadCommit(ctx, ph)
+				if err != nil {
+					return time.Time{}, err
+				}
+				hashes, commits = append(hashes, ph), append(commits, parent)
+				pending = true
+			} else if t.After(date) {
+				date = t
+			}
+		}
+
+		if !pending {
+			newest[h] = date
+			hashes, commits = hashes[:top], commits[:top]
+		}
+	}
+
+	newestCommitDates.mu.Lock()
+	defer newestCommitDates.mu.Unlock()
+	if len(newestCommitDates.dates)+len(newest) > maxNewestCommitDates {
+		newestCommitDates.dates = make(map[hash.Hash]time.Time)
+	}
+	for h, t := range newest {
+		newestCommitDates.dates[h] = t
+	}
+
+	return newest[h], nil
+}
+
 var historyTableCommitMetaCols = set.NewStrSet([]string{CommitHashCol, CommitDateCol, CommitterCol})
 
 func commitFilterForExprs(ctx *sql.Context, filters []sql.Expression) (doltdb.CommitFilter, error) {
@@ -310,65 +505,121 @@ func (ht *HistoryTable) Schema() sql.Schema {
 
 // Partitions returns a PartitionIter which will be used in getting partitions each of which is used to create RowIter.
 func (ht *HistoryTable) Partitions(ctx *sql.Context) (sql.PartitionIter, error) {
-	return &commitPartitioner{ht.cmItr}, nil
+	cmItr, err := ht.commitItr(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &commitPartitioner{cmItr: cmItr, tableName: ht.doltTable.Name()}, nil
 }
 
 // PartitionRows takes a partition and returns a row iterator for that partition
 func (ht *HistoryTable) PartitionRows(ctx *sql.Context, part sql.Partition) (sql.RowIter, error) {
 	cp := part.(*commitPartition)
 
-	return newRowItrForTableAtCommit(ctx, ht.Name(), ht.doltTable, cp.h, cp.cm, ht.indexLookup, ht.projectedCols)
+	return newRowItrForTableAtCommits(ctx, ht.Name(), ht.doltTable, cp.commits, ht.indexLookup, ht.projectedCols)
 }
 
-// commitPartition is a single commit
+// commitPartition is a run of commits of the commit walk that have the same version of the table, so that the rows
+// of that version are only read once for all of them.
 type commitPartition struct {
+	commits []historyCommit
+}
+
+// historyCommit is a commit of the history of the table
+type historyCommit struct {
 	h  hash.Hash
 	cm *doltdb.Commit
 }
 
-// Key returns the hash of the commit for this partition which is used as the partition key
+// Key returns the hash of the first commit for this partition which is used as the partition key
 func (cp *commitPartition) Key() []byte {
-	return cp.h[:]
+	return cp.commits[0].h[:]
 }
 
 // commitPartitioner creates partitions from a CommitItr
 type commitPartitioner struct {
-	cmItr doltdb.CommitItr
+	cmItr     doltdb.CommitItr
+	tableName string
+	// next is the first commit of the next partition, and nextTblHash the hash of the table at that commit
+	next        *historyCommit
+	nextTblHash hash.Hash
 }
 
 // Next returns the next partition and nil, io.EOF when complete
-func (cp commitPartitioner) Next(ctx *sql.Context) (sql.Partition, error) {
-	h, cm, err := cp.cmItr.Next(ctx)
-
-	if err != nil {
-		return nil, err
+func (cp *commitPartitioner) Next(ctx *sql.Context) (sql.Partition, error) {
+	if cp.next == nil {
+		h, cm, err := cp.cmItr.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cp.nextTblHash, err = tableHashAtCommit(ctx, cm, cp.tableName)
+		if err != nil {
+			return nil, err
+		}
+		cp.next = &historyCommit{h, cm}
 	}
 
-	return &commitPartition{h, cm}, nil
+	part := &commitPartition{commits: []historyCommit{*cp.next}}
+	tblHash := cp.nextTblHash
+	cp.next = nil
+
+	for {
+		h, cm, err := cp.cmItr.Next(ctx)
+		if err == io.EOF {
+			return part, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		nextTblHash, err := tableHashAtCommit(ctx, cm, cp.tableName)
+		if err != nil {
+			return nil, err
+		}
+		if nextTblHash != tblHash {
+			cp.next, cp.nextTblHash = &historyCommit{h, cm}, nextTblHash
+			return part, nil
+		}
+		part.commits = append(part.commits, historyCommit{h, cm})
+	}
 }
 
 // Close closes the partitioner
-func (cp commitPartitioner) Close(*sql.Context) error {
+func (cp *commitPartitioner) Close(*sql.Context) error {
 	return nil
 }
 
+// tableHashAtCommit returns the hash of the table named |tableName| at |cm|, or an empty hash if it doesn't exist
+func tableHashAtCommit(ctx *sql.Context, cm *doltdb.Commit, tableName string) (hash.Hash, error) {
+	root, err := cm.GetRootValue(ctx)
+	if err != nil {
+		return hash.Hash{}, err
+	}
+
+	tableName, ok, err := root.ResolveTableName(ctx, tableName)
+	if err != nil || !ok {
+		return hash.Hash{}, err
+	}
+
+	h, _, err := root.GetTableHash(ctx, tableName)
+	return h, err
+}
+
 type historyIter struct {
-	table            sql.Table
-	tablePartitions  sql.PartitionIter
-	currPart         sql.RowIter
-	rowConverter     func(row sql.Row) sql.Row
+	table           sql.Table
+	tablePartitions sql.PartitionIter
+	currPart        sql.RowIter
+	// rowConverters convert the rows of the table into the rows of each commit that has this version of the table
+	rowConverters    []func(row sql.Row) sql.Row
+	currRow          sql.Row
+	currCommit       int
 	nonExistentTable bool
 }
 
-func newRowItrForTableAtCommit(ctx *sql.Context, tableName string, table *DoltTable, h hash.Hash, cm *doltdb.Commit, lookup sql.IndexLookup, projections []uint64) (*historyIter, error) {
+func newRowItrForTableAtCommits(ctx *sql.Context, tableName string, table *DoltTable, commits []historyCommit, lookup sql.IndexLookup, projections []uint64) (*historyIter, error) {
 	targetSchema := table.Schema().Copy()
 
-	root, err := cm.GetRootValue(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	meta, err := cm.GetCommitMeta(ctx)
+	// all the commits have the same version of the table
+	root, err := commits[0].cm.GetRootValue(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -413,12 +664,19 @@ func newRowItrForTableAtCommit(ctx *sql.Context, tableName string, table *DoltTa
 		return nil, err
 	}
 
-	converter := rowConverter(sqlTable.Schema(), targetSchema, h, meta, projections)
+	converters := make([]func(row sql.Row) sql.Row, len(commits))
+	for i, c := range commits {
+		meta, err := c.cm.GetCommitMeta(ctx)
+		if err != nil {
+			return nil, err
+		}
+		converters[i] = rowConverter(sqlTable.Schema(), targetSchema, c.h, meta, projections)
+	}
 
 	return &historyIter{
 		table:           sqlTable,
 		tablePartitions: tablePartitions,
-		rowConverter:    converter,
+		rowConverters:   converters,
 	}, nil
 }
 
@@ -429,6 +687,12 @@ func (i *historyIter) Next(ctx *sql.Context) (sql.Row, error) {
 		return nil, io.EOF
 	}
 
+	if i.currRow != nil && i.currCommit < len(i.rowConverters) {
+		r := i.rowConverters[i.currCommit](i.currRow)
+		i.currCommit++
+		return r, nil
+	}
+
 	if i.currPart == nil {
 		nextPart, err := i.tablePartitions.Next(ctx)
 		if err != nil {
@@ -452,7 +716,8 @@ func (i *historyIter) Next(ctx *sql.Context) (sql.Row, error) {
 		return nil, err
 	}
 
-	return i.rowConverter(r), nil
+	i.currRow, i.currCommit = r, 0
+	return i.Next(ctx)
 }
 
 func (i *historyIter) Close(ctx *sql.Context) error {
@@ -0,0 +1,153 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+// migrationFileRegex matches the names of migration files, which are made of the version of the migration, its name,
+// and whether the file migrates up or down. Files without a direction migrate up.
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(.+?)(\.up|\.down)?\.sql$`)
+
+// Migration is a numbered schema migration, read from the files of a migrations directory.
+type Migration struct {
+	// Version orders the migrations. It's the number the names of the files of the migration start with.
+	Version uint64
+	// Name is the name of the migration, following its version in the names of its files.
+	Name string
+	// Up is the script that applies the migration.
+	Up string
+	// Down is the script that reverts the migration, "" if it can't be reverted.
+	Down string
+}
+
+// Checksum returns the checksum of the script that applies |m|, which is recorded when |m| is applied to detect
+// migrations that were changed afterwards.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadMigrations reads the migrations of the .sql files of |dir|, ordered by version. The up script of a migration
+// is read from <version>_<name>.sql or <version>_<name>.up.sql, and its down script from <version>_<name>.down.sql.
+func LoadMigrations(fs filesys.Filesys, dir string) ([]Migration, error) {
+	if exists, isDir := fs.Exists(dir); !exists || !isDir {
+		return nil, fmt.Errorf("migrations directory '%s' does not exist", dir)
+	}
+
+	var paths []string
+	err := fs.Iter(dir, false, func(path string, size int64, isDir bool) (stop bool) {
+		if !isDir && strings.HasSuffix(path, ".sql") {
+			paths = append(paths, path)
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	byVersion := make(map[uint64]*Migration)
+	hasUp := make(map[uint64]bool)
+	for _, path := range paths {
+		fileName := filepath.Base(path)
+		matches := migrationFileRegex.FindStringSubmatch(fileName)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name '%s', must be <version>_<name>.sql", fileName)
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name '%s': %w", fileName, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, fmt.Errorf("migrations '%s' and '%s' have the same version %d", m.Name, matches[2], version)
+		}
+
+		script, err := fs.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches[3] == ".down" {
+			m.Down = string(script)
+		} else if hasUp[version] {
+			return nil, fmt.Errorf("migration %d has more than one up script", version)
+		} else {
+			m.Up = string(script)
+			hasUp[version] = true
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, m := range byVersion {
+		if !hasUp[version] {
+			return nil, fmt.Errorf("migration %d has a down script but no up script", version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// splitStatements splits |script| into the statements it's made of.
+func splitStatements(script string) ([]string, error) {
+	var stmts []string
+	remainder := strings.TrimSpace(script)
+	for remainder != "" {
+		_, pos, err := sqlparser.ParseOne(remainder)
+		if err == sqlparser.ErrEmpty {
+			// a statement that's only made of comments
+			if pos <= 0 || pos >= len(remainder) {
+				break
+			}
+			remainder = strings.TrimSpace(remainder[pos:])
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		stmt := remainder
+		remainder = ""
+		if pos > 0 && pos < len(stmt) {
+			stmt, remainder = stmt[:pos], strings.TrimSpace(stmt[pos:])
+		}
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string][]byte
+		expected []Migration
+		err      string
+	}{
+		{
+			name: "ordered by version",
+			files: map[string][]byte{
+				"/m/10_b.up.sql":   []byte("up b"),
+				"/m/10_b.down.sql": []byte("down b"),
+				"/m/2_a.sql":       []byte("up a"),
+				"/m/README.md":     []byte("not a migration"),
+			},
+			expected: []Migration{
+				{Version: 2, Name: "a", Up: "up a"},
+				{Version: 10, Name: "b", Up: "up b", Down: "down b"},
+			},
+		},
+		{
+			name:  "invalid name",
+			files: map[string][]byte{"/m/create_table.sql": []byte("")},
+			err:   "invalid migration file name 'create_table.sql', must be <version>_<name>.sql",
+		},
+		{
+			name: "duplicate version",
+			files: map[string][]byte{
+				"/m/1_a.sql": []byte(""),
+				"/m/1_b.sql": []byte(""),
+			},
+			err: "migrations 'a' and 'b' have the same version 1",
+		},
+		{
+			name: "two up scripts",
+			files: map[string][]byte{
+				"/m/1_a.sql":    []byte(""),
+				"/m/1_a.up.sql": []byte(""),
+			},
+			err: "migration 1 has more than one up script",
+		},
+		{
+			name:  "no up script",
+			files: map[string][]byte{"/m/1_a.down.sql": []byte("")},
+			err:   "migration 1 has a down script but no up script",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := filesys.NewInMemFS([]string{"/m"}, test.files, "/")
+			migrations, err := LoadMigrations(fs, "/m")
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, migrations)
+		})
+	}
+
+	_, err := LoadMigrations(filesys.NewInMemFS(nil, nil, "/"), "/m")
+	assert.EqualError(t, err, "migrations directory '/m' does not exist")
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts, err := splitStatements(`
+-- create the table
+CREATE TABLE t (pk INT PRIMARY KEY, c VARCHAR(10));
+
+INSERT INTO t VALUES (1, 'a;b');
+/* done */`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"-- create the table\nCREATE TABLE t (pk INT PRIMARY KEY, c VARCHAR(10))",
+		"INSERT INTO t VALUES (1, 'a;b')",
+	}, stmts)
+
+	_, err = splitStatements("CREATE TABLE;")
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+
+	"github.com/dolthub/dolt/go/cmd/dolt/cli"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dprocedures"
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+const (
+	// ProcedureName is the name of the procedure that runs migrations.
+	ProcedureName = "dolt_migrate"
+
+	// DefaultDir is the directory migrations are read from when none is given.
+	DefaultDir = "migrations"
+)
+
+// ResultSchema is the schema of the rows returned by dolt_migrate.
+var ResultSchema = sql.Schema{
+	{Name: "version", Type: sql.Uint64, Nullable: false},
+	{Name: "name", Type: sql.LongText, Nullable: false},
+	{Name: "status", Type: sql.LongText, Nullable: false},
+	{Name: "message", Type: sql.LongText, Nullable: false},
+}
+
+// DatabaseFilesys returns the filesystem rooted at the directory of the database |dbName|, or false if the database
+// has no directory.
+type DatabaseFilesys func(dbName string) (filesys.Filesys, bool)
+
+// RegisterStoredProcedures adds dolt_migrate to the procedures of an engine. Its statements are run with |queryist|,
+// the migrations directory is read from the directory of the current database, found with |dbFs|, and it may only be
+// called by users that have the global SUPER, CREATE or ALTER privilege in |privileges|.
+func RegisterStoredProcedures(registry dprocedures.ProcedureRegistry, privileges *mysql_db.MySQLDb, queryist Queryist, dbFs DatabaseFilesys) {
+	registry.RegisterExternalStoredProcedure(sql.ExternalStoredProcedureDetails{
+		Name:   ProcedureName,
+		Schema: ResultSchema,
+		Function: func(ctx *sql.Context, args ...string) (sql.RowIter, error) {
+			return doltMigrate(ctx, privileges, queryist, dbFs, args)
+		},
+	})
+}
+
+// doltMigrate is the implementation of dolt_migrate(subcommand, [--dir directory], [--to version], [--dry-run]).
+func doltMigrate(ctx *sql.Context, privileges *mysql_db.MySQLDb, queryist Queryist, dbFs DatabaseFilesys, args []string) (sql.RowIter, error) {
+	// migrations run arbitrary statements, so they're limited to the users that can change the schema of any database
+	err := dprocedures.CheckGlobalPrivilege(ctx, privileges, sql.PrivilegeType_Super, sql.PrivilegeType_Create, sql.PrivilegeType_Alter)
+	if err != nil {
+		return nil, err
+	}
+
+	apr, err := cli.CreateMigrationsArgParser().Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	if apr.NArg() != 1 {
+		return nil, fmt.Errorf("%s takes exactly one subcommand: status, up or down", ProcedureName)
+	}
+
+	dir, err := validateDir(apr.GetValueOrDefault(cli.DirParam, DefaultDir))
+	if err != nil {
+		return nil, err
+	}
+	dbName := ctx.GetCurrentDatabase()
+	if dbName == "" {
+		return nil, sql.ErrNoDatabaseSelected.New()
+	}
+	// revision databases read the migrations of the database they're a revision of
+	dbName, _, _ = strings.Cut(dbName, "/")
+	fs, ok := dbFs(dbName)
+	if !ok {
+		return nil, fmt.Errorf("database '%s' has no directory to read migrations from", dbName)
+	}
+
+	migrations, err := LoadMigrations(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	runner := NewRunner(queryist, migrations)
+
+	var to *uint64
+	if v, ok := apr.GetUint(cli.ToParam); ok {
+		to = &v
+	}
+	dryRun := apr.Contains(cli.DryRunFlag)
+
+	var results []Result
+	switch subcommand := apr.Arg(0); subcommand {
+	case "status":
+		if to != nil || dryRun {
+			return nil, fmt.Errorf("status does not accept --%s or --%s", cli.ToParam, cli.DryRunFlag)
+		}
+		results, err = runner.Status(ctx)
+	case "up":
+		results, err = runner.Up(ctx, to, dryRun)
+	case "down":
+		results, err = runner.Down(ctx, to, dryRun)
+	default:
+		return nil, fmt.Errorf("unknown subcommand '%s', must be one of status, up or down", subcommand)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]sql.Row, len(results))
+	for i, res := range results {
+		rows[i] = sql.Row{res.Version, res.Name, res.Status, res.Message}
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// validateDir returns |dir| cleaned, or an error if it's absolute or leaves the directory it's relative to.
+func validateDir(dir string) (string, error) {
+	cleaned := filepath.Clean(dir)
+	if filepath.IsAbs(cleaned) || filepath.VolumeName(cleaned) != "" {
+		return "", fmt.Errorf("migrations directory '%s' must be relative to the directory of the database", dir)
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("migrations directory '%s' must be within the directory of the database", dir)
+	}
+	return cleaned, nil
+}
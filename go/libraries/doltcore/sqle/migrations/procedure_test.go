@@ -0,0 +1,103 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/dolthub/go-mysql-server/sql/mysql_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/utils/filesys"
+)
+
+func TestValidateDir(t *testing.T) {
+	tests := []struct {
+		dir      string
+		expected string
+		err      bool
+	}{
+		{dir: "migrations", expected: "migrations"},
+		{dir: "./db/migrations/", expected: "db/migrations"},
+		{dir: "a/../migrations", expected: "migrations"},
+		{dir: "..migrations", expected: "..migrations"},
+		{dir: "/etc", err: true},
+		{dir: "..", err: true},
+		{dir: "../other/migrations", err: true},
+		{dir: "migrations/../../other", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dir, func(t *testing.T) {
+			dir, err := validateDir(test.dir)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, dir)
+		})
+	}
+}
+
+func TestDoltMigratePrivileges(t *testing.T) {
+	privileges := mysql_db.CreateEmptyMySQLDb()
+	privileges.AddRootAccount()
+	user := func(name string, privs ...sql.PrivilegeType) *mysql_db.User {
+		privSet := mysql_db.NewPrivilegeSet()
+		privSet.AddGlobalStatic(privs...)
+		return &mysql_db.User{User: name, Host: "localhost", PrivilegeSet: privSet}
+	}
+	require.NoError(t, privileges.LoadPrivilegeData(sql.NewEmptyContext(), []*mysql_db.User{
+		user("creator", sql.PrivilegeType_Create),
+		user("alterer", sql.PrivilegeType_Alter),
+		user("reader", sql.PrivilegeType_Select),
+	}, nil))
+
+	var dbNames []string
+	dbFs := func(dbName string) (filesys.Filesys, bool) {
+		dbNames = append(dbNames, dbName)
+		return filesys.EmptyInMemFS("/" + dbName), true
+	}
+	migrate := func(userName, dbName string, args ...string) error {
+		sess := sql.NewBaseSessionWithClientServer("", sql.Client{User: userName, Address: "localhost"}, 1)
+		ctx := sql.NewContext(context.Background(), sql.WithSession(sess))
+		ctx.SetCurrentDatabase(dbName)
+		_, err := doltMigrate(ctx, privileges, nil, dbFs, args)
+		return err
+	}
+
+	for _, userName := range []string{"root", "creator", "alterer"} {
+		err := migrate(userName, "db", "status", "--dir", "../other")
+		require.Error(t, err)
+		assert.False(t, sql.ErrPrivilegeCheckFailed.Is(err), userName)
+	}
+	for _, userName := range []string{"reader", "nobody"} {
+		err := migrate(userName, "db", "status", "--dir", "../other")
+		assert.True(t, sql.ErrPrivilegeCheckFailed.Is(err), userName)
+	}
+	assert.Empty(t, dbNames)
+
+	// the migrations of a revision database are read from the directory of its database
+	err := migrate("root", "db/feature", "status")
+	assert.EqualError(t, err, "migrations directory 'migrations' does not exist")
+	assert.Equal(t, []string{"db"}, dbNames)
+
+	err = migrate("root", "", "status")
+	assert.True(t, sql.ErrNoDatabaseSelected.Is(err))
+}
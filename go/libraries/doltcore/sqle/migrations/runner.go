@@ -0,0 +1,392 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+)
+
+const (
+	// StatusApplied is the status of a migration that has been applied to the branch.
+	StatusApplied = "applied"
+	// StatusPending is the status of a migration that hasn't been applied to the branch.
+	StatusPending = "pending"
+	// StatusModified is the status of a migration whose up script changed after it was applied to the branch.
+	StatusModified = "modified"
+	// StatusMissing is the status of a migration that has been applied to the branch, but has no files.
+	StatusMissing = "missing"
+	// StatusReverted is the status of a migration that has been reverted.
+	StatusReverted = "reverted"
+	// StatusWouldApply is the status of a migration that a dry run would apply.
+	StatusWouldApply = "would apply"
+	// StatusWouldRevert is the status of a migration that a dry run would revert.
+	StatusWouldRevert = "would revert"
+)
+
+var ErrUncommittedChanges = errors.New("cannot run migrations with uncommitted changes, commit or reset them first")
+
+// Queryist runs queries. The statements of migrations are run by the engine of the caller, so that they are checked
+// against the privileges of its user like any other statement.
+type Queryist interface {
+	Query(ctx *sql.Context, query string) (sql.Schema, sql.RowIter, error)
+}
+
+// Result is the status of a migration on the current branch, or the outcome of applying or reverting it.
+type Result struct {
+	Version uint64
+	Name    string
+	Status  string
+	// Message is the checksum of an applied migration, the hash of the commit of a migration that was applied or
+	// reverted, or the schema changes of a dry run.
+	Message string
+}
+
+// appliedMigration is a row of the dolt_migrations table.
+type appliedMigration struct {
+	name     string
+	checksum string
+}
+
+// Runner applies and reverts migrations on the current branch of a session. Every migration is applied or reverted
+// in a dolt commit of its own, which also updates the dolt_migrations table.
+type Runner struct {
+	queryist   Queryist
+	migrations []Migration
+}
+
+// NewRunner returns a Runner of |migrations|, which runs queries with |queryist|.
+func NewRunner(queryist Queryist, migrations []Migration) *Runner {
+	return &Runner{queryist: queryist, migrations: migrations}
+}
+
+// Status returns the status of every migration, and of the applied migrations that no longer have files.
+func (r *Runner) Status(ctx *sql.Context) ([]Result, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, m := range r.migrations {
+		am, ok := applied[m.Version]
+		delete(applied, m.Version)
+		switch {
+		case !ok:
+			results = append(results, Result{Version: m.Version, Name: m.Name, Status: StatusPending})
+		case am.checksum != m.Checksum():
+			results = append(results, Result{Version: m.Version, Name: m.Name, Status: StatusModified, Message: am.checksum})
+		default:
+			results = append(results, Result{Version: m.Version, Name: m.Name, Status: StatusApplied, Message: am.checksum})
+		}
+	}
+	for version, am := range applied {
+		results = append(results, Result{Version: version, Name: am.name, Status: StatusMissing, Message: am.checksum})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Version < results[j].Version
+	})
+
+	return results, nil
+}
+
+// Up applies the pending migrations in order of version, up to and including the version |to| if it isn't nil.
+// Migrations that were merged from another branch may have a lower version than migrations that were already applied.
+func (r *Runner) Up(ctx *sql.Context, to *uint64, dryRun bool) ([]Result, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range r.migrations {
+		if am, ok := applied[m.Version]; ok {
+			if am.checksum != m.Checksum() {
+				return nil, fmt.Errorf("migration %d (%s) was modified after it was applied", m.Version, m.Name)
+			}
+			continue
+		}
+		if to == nil || m.Version <= *to {
+			pending = append(pending, m)
+		}
+	}
+
+	return r.run(ctx, pending, true, dryRun)
+}
+
+// Down reverts the applied migrations in reverse order of version. If |to| is nil, only the latest migration is
+// reverted, otherwise every migration with a version higher than |to| is.
+func (r *Runner) Down(ctx *sql.Context, to *uint64, dryRun bool) ([]Result, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]Migration)
+	for _, m := range r.migrations {
+		byVersion[m.Version] = m
+	}
+
+	versions := make([]uint64, 0, len(applied))
+	for version := range applied {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] > versions[j]
+	})
+	if to == nil && len(versions) > 1 {
+		versions = versions[:1]
+	}
+
+	var reverting []Migration
+	for _, version := range versions {
+		if to != nil && version <= *to {
+			break
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			return nil, fmt.Errorf("migration %d (%s) can't be reverted, its files are missing", version, applied[version].name)
+		} else if strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %d (%s) can't be reverted, it has no down script", version, m.Name)
+		}
+		reverting = append(reverting, m)
+	}
+
+	return r.run(ctx, reverting, false, dryRun)
+}
+
+// run applies or reverts |migrations| in order. A dry run applies or reverts them without committing, reports the
+// schema changes of each, and then resets the working set. The working set is also reset when a migration fails.
+func (r *Runner) run(ctx *sql.Context, migrations []Migration, up bool, dryRun bool) (results []Result, err error) {
+	if len(migrations) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.query(ctx, "SELECT COUNT(*) FROM dolt_status")
+	if err != nil {
+		return nil, err
+	}
+	if rows[0][0].(int64) != 0 {
+		return nil, ErrUncommittedChanges
+	}
+
+	// The statements of the migrations are run in the transaction of the caller, so that their changes are only
+	// written by the dolt commits of the migrations, and never by a dry run.
+	if !ctx.GetIgnoreAutoCommit() {
+		ctx.SetIgnoreAutoCommit(true)
+		defer ctx.SetIgnoreAutoCommit(false)
+	}
+	defer func() {
+		if err != nil || dryRun {
+			if rerr := r.reset(ctx); rerr != nil && err == nil {
+				err = rerr
+			}
+		}
+	}()
+
+	var before schemaSnapshot
+	if dryRun {
+		if before, err = r.schemaSnapshot(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, m := range migrations {
+		script, action := m.Up, "apply"
+		if !up {
+			script, action = m.Down, "revert"
+		}
+		if err = r.exec(ctx, script); err != nil {
+			return nil, fmt.Errorf("failed to %s migration %d (%s): %w", action, m.Version, m.Name, err)
+		}
+
+		if dryRun {
+			after, err := r.schemaSnapshot(ctx)
+			if err != nil {
+				return nil, err
+			}
+			status := StatusWouldApply
+			if !up {
+				status = StatusWouldRevert
+			}
+			results = append(results, Result{Version: m.Version, Name: m.Name, Status: status, Message: before.changes(after)})
+			before = after
+			continue
+		}
+
+		var record, msg, status string
+		if up {
+			record = fmt.Sprintf("REPLACE INTO %s (%s, %s, %s) VALUES (%d, %s, %s)", doltdb.MigrationsTableName,
+				doltdb.MigrationsVersionCol, doltdb.MigrationsNameCol, doltdb.MigrationsChecksumCol,
+				m.Version, quote(m.Name), quote(m.Checksum()))
+			msg, status = fmt.Sprintf("Apply migration %d: %s", m.Version, m.Name), StatusApplied
+		} else {
+			record = fmt.Sprintf("DELETE FROM %s WHERE %s = %d", doltdb.MigrationsTableName, doltdb.MigrationsVersionCol, m.Version)
+			msg, status = fmt.Sprintf("Revert migration %d: %s", m.Version, m.Name), StatusReverted
+		}
+		if _, err = r.query(ctx, record); err != nil {
+			return nil, err
+		}
+
+		if _, err = r.query(ctx, "CALL dolt_add('.')"); err != nil {
+			return nil, err
+		}
+		rows, err = r.query(ctx, fmt.Sprintf("CALL dolt_commit('-m', %s)", quote(msg)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		results = append(results, Result{Version: m.Version, Name: m.Name, Status: status, Message: rows[0][0].(string)})
+	}
+
+	return results, nil
+}
+
+// applied returns the migrations recorded in the dolt_migrations table, by version.
+func (r *Runner) applied(ctx *sql.Context) (map[uint64]appliedMigration, error) {
+	rows, err := r.query(ctx, fmt.Sprintf("SELECT %s, %s, %s FROM %s", doltdb.MigrationsVersionCol,
+		doltdb.MigrationsNameCol, doltdb.MigrationsChecksumCol, doltdb.MigrationsTableName))
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint64]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row[0].(uint64)] = appliedMigration{name: row[1].(string), checksum: row[2].(string)}
+	}
+	return applied, nil
+}
+
+// reset discards the changes of the migrations that weren't committed. The tables they created are untracked, so
+// they're removed by cleaning the working set rather than by resetting it.
+func (r *Runner) reset(ctx *sql.Context) error {
+	if _, err := r.query(ctx, "CALL dolt_reset('--hard')"); err != nil {
+		return err
+	}
+	_, err := r.query(ctx, "CALL dolt_clean()")
+	return err
+}
+
+// exec runs the statements of |script|.
+func (r *Runner) exec(ctx *sql.Context, script string) error {
+	stmts, err := splitStatements(script)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err = r.query(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Runner) query(ctx *sql.Context, query string) ([]sql.Row, error) {
+	sch, iter, err := r.queryist.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return sql.RowIterToRows(ctx, sch, iter)
+}
+
+// tableSchema is the schema of a table in the working set, compared to its schema at HEAD.
+type tableSchema struct {
+	inHead bool
+	// create is the CREATE TABLE statement of the table, "" if it was dropped.
+	create string
+}
+
+// schemaSnapshot holds the tables whose schema differs between HEAD and the working set, by name.
+type schemaSnapshot map[string]tableSchema
+
+func (r *Runner) schemaSnapshot(ctx *sql.Context) (schemaSnapshot, error) {
+	rows, err := r.query(ctx, "SELECT from_table_name, to_table_name, to_create_statement FROM dolt_schema_diff('HEAD', 'WORKING')")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(schemaSnapshot, len(rows))
+	for _, row := range rows {
+		from, _ := row[0].(string)
+		to, _ := row[1].(string)
+		create, _ := row[2].(string)
+		if from != "" && from != to {
+			snapshot[from] = tableSchema{inHead: true}
+		}
+		if to != "" {
+			snapshot[to] = tableSchema{inHead: from == to, create: create}
+		}
+	}
+	return snapshot, nil
+}
+
+// changes describes the schema changes between |s| and the later snapshot |after|.
+func (s schemaSnapshot) changes(after schemaSnapshot) string {
+	names := make(map[string]struct{})
+	for name := range s {
+		names[name] = struct{}{}
+	}
+	for name := range after {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var changes []string
+	for _, name := range sorted {
+		prev, prevOk := s[name]
+		cur, curOk := after[name]
+		if prevOk == curOk && prev == cur {
+			continue
+		}
+
+		inHead := prev.inHead || cur.inHead
+		prevExists, curExists := inHead, inHead
+		if prevOk {
+			prevExists = prev.create != ""
+		}
+		if curOk {
+			curExists = cur.create != ""
+		}
+
+		switch {
+		case !prevExists && curExists:
+			changes = append(changes, "created table "+name)
+		case prevExists && !curExists:
+			changes = append(changes, "dropped table "+name)
+		case curExists:
+			changes = append(changes, "altered table "+name)
+		}
+	}
+
+	if len(changes) == 0 {
+		return "no schema changes"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// quote returns |s| as a quoted SQL string literal.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
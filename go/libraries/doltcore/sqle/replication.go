@@ -29,7 +29,7 @@ import (
 	"github.com/dolthub/dolt/go/store/types"
 )
 
-func getPushOnWriteHook(ctx context.Context, bThreads *sql.BackgroundThreads, dEnv *env.DoltEnv, logger io.Writer) (doltdb.CommitHook, error) {
+func getPushOnWriteHook(ctx context.Context, bThreads *sql.BackgroundThreads, name string, dEnv *env.DoltEnv, logger io.Writer) (doltdb.CommitHook, error) {
 	_, val, ok := sql.SystemVariables.GetGlobal(dsess.ReplicateToRemoteKey)
 	if !ok {
 		return nil, sql.ErrUnknownSystemVariable.New(dsess.ReplicateToRemoteKey)
@@ -59,18 +59,18 @@ func getPushOnWriteHook(ctx context.Context, bThreads *sql.BackgroundThreads, dE
 
 	_, val, ok = sql.SystemVariables.GetGlobal(dsess.AsyncReplicationKey)
 	if _, val, ok = sql.SystemVariables.GetGlobal(dsess.AsyncReplicationKey); ok && val == SysVarTrue {
-		return doltdb.NewAsyncPushOnWriteHook(bThreads, ddb, dEnv.TempTableFilesDir(), logger)
+		return doltdb.NewAsyncPushOnWriteHook(bThreads, name, ddb, dEnv.TempTableFilesDir(), logger)
 	}
 
-	return doltdb.NewPushOnWriteHook(ddb, dEnv.TempTableFilesDir()), nil
+	return doltdb.NewPushOnWriteHook(name, ddb, dEnv.TempTableFilesDir()), nil
 }
 
 // GetCommitHooks creates a list of hooks to execute on database commit. If doltdb.SkipReplicationErrorsKey is set,
 // replace misconfigured hooks with doltdb.LogHook instances that prints a warning when trying to execute.
-func GetCommitHooks(ctx context.Context, bThreads *sql.BackgroundThreads, dEnv *env.DoltEnv, logger io.Writer) ([]doltdb.CommitHook, error) {
+func GetCommitHooks(ctx context.Context, bThreads *sql.BackgroundThreads, name string, dEnv *env.DoltEnv, logger io.Writer) ([]doltdb.CommitHook, error) {
 	postCommitHooks := make([]doltdb.CommitHook, 0)
 
-	if hook, err := getPushOnWriteHook(ctx, bThreads, dEnv, logger); err != nil {
+	if hook, err := getPushOnWriteHook(ctx, bThreads, name, dEnv, logger); err != nil {
 		err = fmt.Errorf("failure loading hook; %w", err)
 		if SkipReplicationWarnings() {
 			postCommitHooks = append(postCommitHooks, doltdb.NewLogHook([]byte(err.Error()+"\n")))
@@ -117,7 +117,7 @@ func ApplyReplicationConfig(ctx context.Context, bThreads *sql.BackgroundThreads
 			outputDbs = append(outputDbs, db)
 			continue
 		}
-		postCommitHooks, err := GetCommitHooks(ctx, bThreads, dEnv, logger)
+		postCommitHooks, err := GetCommitHooks(ctx, bThreads, db.Name(), dEnv, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -34,7 +34,7 @@ func TestCommitHooksNoErrors(t *testing.T) {
 	sql.SystemVariables.SetGlobal(dsess.SkipReplicationErrorsKey, true)
 	sql.SystemVariables.SetGlobal(dsess.ReplicateToRemoteKey, "unknown")
 	bThreads := sql.NewBackgroundThreads()
-	hooks, err := GetCommitHooks(context.Background(), bThreads, dEnv, &buffer.Buffer{})
+	hooks, err := GetCommitHooks(context.Background(), bThreads, "dolt", dEnv, &buffer.Buffer{})
 	assert.NoError(t, err)
 	if len(hooks) < 1 {
 		t.Error("failed to produce noop hook")
@@ -0,0 +1,139 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqle
+
+import (
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/diff"
+	"github.com/dolthub/dolt/go/libraries/doltcore/doltdb"
+	"github.com/dolthub/dolt/go/libraries/doltcore/merge"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/dsess"
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/index"
+)
+
+// schemaConflictsTable is the dolt_schema_conflicts system table. It has a row for each schema conflict of the tables
+// that the active merge could not merge, with the CREATE TABLE statements of the table in the merge base, on our side
+// and on their side.
+type schemaConflictsTable struct {
+	dbName string
+}
+
+var _ sql.Table = schemaConflictsTable{}
+
+func newSchemaConflictsTable(dbName string) sql.Table {
+	return schemaConflictsTable{dbName: dbName}
+}
+
+// Name implements sql.Table
+func (t schemaConflictsTable) Name() string {
+	return doltdb.SchemaConflictsTableName
+}
+
+// String implements sql.Table
+func (t schemaConflictsTable) String() string {
+	return doltdb.SchemaConflictsTableName
+}
+
+// Schema implements sql.Table
+func (t schemaConflictsTable) Schema() sql.Schema {
+	return sql.Schema{
+		{Name: "table_name", Type: sql.Text, Source: doltdb.SchemaConflictsTableName, PrimaryKey: true},
+		{Name: "base_schema", Type: sql.Text, Source: doltdb.SchemaConflictsTableName, Nullable: true},
+		{Name: "our_schema", Type: sql.Text, Source: doltdb.SchemaConflictsTableName, Nullable: true},
+		{Name: "their_schema", Type: sql.Text, Source: doltdb.SchemaConflictsTableName, Nullable: true},
+		{Name: "description", Type: sql.Text, Source: doltdb.SchemaConflictsTableName, PrimaryKey: true},
+	}
+}
+
+// Partitions implements sql.Table
+func (t schemaConflictsTable) Partitions(*sql.Context) (sql.PartitionIter, error) {
+	return index.SinglePartitionIterFromNomsMap(nil), nil
+}
+
+// PartitionRows implements sql.Table
+func (t schemaConflictsTable) PartitionRows(ctx *sql.Context, _ sql.Partition) (sql.RowIter, error) {
+	sess := dsess.DSessFromSess(ctx.Session)
+	ws, err := sess.WorkingSet(ctx, t.dbName)
+	if err != nil {
+		return nil, err
+	}
+	if !ws.MergeActive() || !ws.MergeState().HasSchemaConflicts() {
+		return sql.RowsToRowIter(), nil
+	}
+
+	head, err := sess.GetHeadCommit(ctx, t.dbName)
+	if err != nil {
+		return nil, err
+	}
+	theirCm := ws.MergeState().Commit()
+	ancCm, err := doltdb.GetCommitAncestor(ctx, head, theirCm)
+	if err != nil {
+		return nil, err
+	}
+	theirRoot, err := theirCm.GetRootValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ancRoot, err := ancCm.GetRootValue(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ourRoot := ws.WorkingRoot()
+
+	var rows []sql.Row
+	for _, tblName := range ws.MergeState().SchemaConflictTables() {
+		sc, err := merge.TableSchemaConflicts(ctx, ourRoot, theirRoot, ancRoot, tblName)
+		if err != nil {
+			return nil, err
+		}
+
+		stmts := make([]interface{}, 3)
+		for i, root := range []*doltdb.RootValue{ancRoot, ourRoot, theirRoot} {
+			stmts[i], err = createStmtInRoot(ctx, root, tblName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, desc := range sc.Descriptions() {
+			rows = append(rows, sql.NewRow(tblName, stmts[0], stmts[1], stmts[2], desc))
+		}
+	}
+	return sql.RowsToRowIter(rows...), nil
+}
+
+// createStmtInRoot returns the CREATE TABLE statement of the table named in |root|, or nil if it has no such table.
+func createStmtInRoot(ctx *sql.Context, root *doltdb.RootValue, tblName string) (interface{}, error) {
+	tbl, ok, err := root.GetTable(ctx, tblName)
+	if err != nil || !ok {
+		return nil, err
+	}
+	sch, err := tbl.GetSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	fkc, err := root.GetForeignKeyCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fks, _ := fkc.KeysForTable(tblName)
+	parentSchs, err := diff.GetFkParentSchs(ctx, root, fks...)
+	if err != nil {
+		return nil, err
+	}
+	return schemaDiffCreateStmt(ctx, tblName, sch, fks, parentSchs)
+}
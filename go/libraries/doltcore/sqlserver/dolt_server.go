@@ -20,7 +20,13 @@ import (
 	"github.com/dolthub/go-mysql-server/server"
 )
 
-var mySQLServer *server.Server
+// RunningServer is a SQL server running in this process.
+type RunningServer interface {
+	// SessionManager returns the manager of the sessions of the clients connected to the server.
+	SessionManager() *server.SessionManager
+}
+
+var mySQLServer RunningServer
 var mySQLServerMutex sync.Mutex
 
 // RunningInServerMode returns true if the current process is running a SQL server.
@@ -31,14 +37,14 @@ func RunningInServerMode() bool {
 }
 
 // GetRunningServer returns the Server instance running in this process, or nil if no SQL server is running.
-func GetRunningServer() *server.Server {
+func GetRunningServer() RunningServer {
 	mySQLServerMutex.Lock()
 	defer mySQLServerMutex.Unlock()
 	return mySQLServer
 }
 
 // SetRunningServer sets the specified Server as the running SQL server for this process.
-func SetRunningServer(server *server.Server) {
+func SetRunningServer(server RunningServer) {
 	mySQLServerMutex.Lock()
 	defer mySQLServerMutex.Unlock()
 	mySQLServer = server
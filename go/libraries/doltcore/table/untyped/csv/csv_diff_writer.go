@@ -0,0 +1,157 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csv
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped"
+)
+
+const (
+	diffTypeColName = "diff_type"
+	fromColPrefix   = "from_"
+	toColPrefix     = "to_"
+
+	diffTypeAdded    = "added"
+	diffTypeRemoved  = "removed"
+	diffTypeModified = "modified"
+)
+
+// CSVDiffWriter implements untyped.RowDiffWriter. Each line it writes holds the from and to values of a single
+// changed row, followed by the row's diff type, in the same layout as the dolt_diff table function: a from_ and a
+// to_ column for every column of the schema, then a diff_type column with the value added, removed or modified.
+type CSVDiffWriter struct {
+	wr     *bufio.Writer
+	closer io.Closer
+	sch    sql.Schema
+	oldRow sql.Row
+}
+
+var _ untyped.RowDiffWriter = (*CSVDiffWriter)(nil)
+
+// NewCSVDiffWriter returns a CSVDiffWriter that writes the rows of the schema given to |wr|, starting with a header
+// line.
+func NewCSVDiffWriter(wr io.WriteCloser, outSch sql.Schema) (*CSVDiffWriter, error) {
+	csvw := &CSVDiffWriter{
+		wr:     bufio.NewWriterSize(wr, writeBufSize),
+		closer: wr,
+		sch:    outSch,
+	}
+
+	header := make([]*string, 0, 2*len(outSch)+1)
+	for _, prefix := range []string{fromColPrefix, toColPrefix} {
+		for _, col := range outSch {
+			nm := prefix + col.Name
+			header = append(header, &nm)
+		}
+	}
+	dt := diffTypeColName
+	header = append(header, &dt)
+
+	err := csvw.write(header)
+	if err != nil {
+		wr.Close()
+		return nil, err
+	}
+
+	return csvw, nil
+}
+
+// WriteRowDiff implements untyped.RowDiffWriter. The old half of a modified row is held until its new half is written.
+func (csvw *CSVDiffWriter) WriteRowDiff(ctx context.Context, row sql.Row, rowDiffType untyped.RowDiffType) error {
+	switch rowDiffType {
+	case untyped.RowAdded:
+		return csvw.writeRowDiff(nil, row, diffTypeAdded)
+	case untyped.RowRemoved:
+		return csvw.writeRowDiff(row, nil, diffTypeRemoved)
+	case untyped.RowModifiedOld:
+		csvw.oldRow = row
+		return nil
+	case untyped.RowModifiedNew:
+		if csvw.oldRow == nil {
+			return errors.New("modified row written without its previous value")
+		}
+		oldRow := csvw.oldRow
+		csvw.oldRow = nil
+		return csvw.writeRowDiff(oldRow, row, diffTypeModified)
+	default:
+		return fmt.Errorf("unexpected row diff type: %v", rowDiffType)
+	}
+}
+
+func (csvw *CSVDiffWriter) writeRowDiff(from, to sql.Row, diffType string) error {
+	colValStrs := make([]*string, 2*len(csvw.sch)+1)
+
+	err := csvw.formatRow(from, colValStrs[:len(csvw.sch)])
+	if err != nil {
+		return err
+	}
+	err = csvw.formatRow(to, colValStrs[len(csvw.sch):2*len(csvw.sch)])
+	if err != nil {
+		return err
+	}
+	colValStrs[2*len(csvw.sch)] = &diffType
+
+	return csvw.write(colValStrs)
+}
+
+// formatRow writes the string values of the row given to |dest|. A nil row leaves every value NULL.
+func (csvw *CSVDiffWriter) formatRow(r sql.Row, dest []*string) error {
+	for i, val := range r {
+		if val == nil {
+			continue
+		}
+
+		var v string
+		var err error
+		colType := csvw.sch[i].Type
+		// Due to BIT's unique output, we special-case writing the integer specifically for CSV
+		if _, ok := colType.(sql.BitType); ok {
+			v = strconv.FormatUint(val.(uint64), 10)
+		} else {
+			v, err = sqlutil.SqlColToStr(colType, val)
+			if err != nil {
+				return err
+			}
+		}
+		dest[i] = &v
+	}
+	return nil
+}
+
+// Close should flush all writes, release resources being held
+func (csvw *CSVDiffWriter) Close(ctx context.Context) error {
+	if csvw.wr != nil {
+		_ = csvw.wr.Flush()
+		errCl := csvw.closer.Close()
+		csvw.wr = nil
+		return errCl
+	} else {
+		return errors.New("Already closed.")
+	}
+}
+
+func (csvw *CSVDiffWriter) write(record []*string) error {
+	return WriteCSVRow(csvw.wr, record, ",", false)
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csv
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/dolthub/go-mysql-server/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/dolthub/dolt/go/libraries/utils/iohelp"
+)
+
+func TestCSVDiffWriter(t *testing.T) {
+	const expected = `from_pk,from_name,to_pk,to_name,diff_type
+,,1,"a, b",added
+2,,,,removed
+3,c,3,"",modified
+`
+	ctx := context.Background()
+	sch := sql.Schema{
+		{Name: "pk", Type: sql.Int64, PrimaryKey: true},
+		{Name: "name", Type: sql.Text, Nullable: true},
+	}
+
+	buf := &bytes.Buffer{}
+	wr, err := NewCSVDiffWriter(iohelp.NopWrCloser(buf), sch)
+	require.NoError(t, err)
+
+	require.NoError(t, wr.WriteRowDiff(ctx, sql.Row{int64(1), "a, b"}, untyped.RowAdded))
+	require.NoError(t, wr.WriteRowDiff(ctx, sql.Row{int64(2), nil}, untyped.RowRemoved))
+	require.NoError(t, wr.WriteRowDiff(ctx, sql.Row{int64(3), "c"}, untyped.RowModifiedOld))
+	require.NoError(t, wr.WriteRowDiff(ctx, sql.Row{int64(3), ""}, untyped.RowModifiedNew))
+	require.NoError(t, wr.Close(ctx))
+
+	assert.Equal(t, expected, buf.String())
+}
@@ -0,0 +1,160 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package json
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dolthub/go-mysql-server/sql"
+
+	"github.com/dolthub/dolt/go/libraries/doltcore/sqle/sqlutil"
+	"github.com/dolthub/dolt/go/libraries/doltcore/table/untyped"
+	"github.com/dolthub/dolt/go/libraries/utils/iohelp"
+)
+
+// writeBufSize is the size of the buffer used by JSONDiffWriter.
+const writeBufSize = 256 * 1024
+
+const jsonDataDiffHeader = `"data_diff":[`
+const jsonDataDiffFooter = `]`
+
+// RowDiff is a single element of the data_diff array written by JSONDiffWriter. FromRow is empty for added rows, and
+// ToRow is empty for removed rows. NULL values are omitted from both.
+type RowDiff struct {
+	FromRow map[string]interface{} `json:"from_row"`
+	ToRow   map[string]interface{} `json:"to_row"`
+}
+
+// JSONDiffWriter implements untyped.RowDiffWriter. It writes the rows of a table's data diff as the members of a
+// "data_diff" JSON array, which is meant to be embedded in an enclosing JSON object.
+type JSONDiffWriter struct {
+	closer      io.Closer
+	bWr         *bufio.Writer
+	sch         sql.Schema
+	oldRow      sql.Row
+	rowsWritten int
+}
+
+var _ untyped.RowDiffWriter = (*JSONDiffWriter)(nil)
+
+// NewJSONDiffWriter returns a JSONDiffWriter that writes the rows of the schema given to |wr|.
+func NewJSONDiffWriter(wr io.WriteCloser, outSch sql.Schema) (*JSONDiffWriter, error) {
+	bwr := bufio.NewWriterSize(wr, writeBufSize)
+	err := iohelp.WriteAll(bwr, []byte(jsonDataDiffHeader))
+	if err != nil {
+		return nil, err
+	}
+	return &JSONDiffWriter{closer: wr, bWr: bwr, sch: outSch}, nil
+}
+
+// WriteRowDiff implements untyped.RowDiffWriter. The old half of a modified row is held until its new half is written.
+func (j *JSONDiffWriter) WriteRowDiff(ctx context.Context, row sql.Row, rowDiffType untyped.RowDiffType) error {
+	switch rowDiffType {
+	case untyped.RowAdded:
+		return j.writeRowDiff(nil, row)
+	case untyped.RowRemoved:
+		return j.writeRowDiff(row, nil)
+	case untyped.RowModifiedOld:
+		j.oldRow = row
+		return nil
+	case untyped.RowModifiedNew:
+		if j.oldRow == nil {
+			return errors.New("modified row written without its previous value")
+		}
+		oldRow := j.oldRow
+		j.oldRow = nil
+		return j.writeRowDiff(oldRow, row)
+	default:
+		return fmt.Errorf("unexpected row diff type: %v", rowDiffType)
+	}
+}
+
+func (j *JSONDiffWriter) writeRowDiff(from, to sql.Row) error {
+	fromRow, err := j.rowToMap(from)
+	if err != nil {
+		return err
+	}
+	toRow, err := j.rowToMap(to)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(RowDiff{FromRow: fromRow, ToRow: toRow})
+	if err != nil {
+		return err
+	}
+
+	if j.rowsWritten != 0 {
+		if _, err = j.bWr.WriteRune(','); err != nil {
+			return err
+		}
+	}
+
+	if err = iohelp.WriteAll(j.bWr, data); err != nil {
+		return err
+	}
+	j.rowsWritten++
+
+	return nil
+}
+
+func (j *JSONDiffWriter) rowToMap(r sql.Row) (map[string]interface{}, error) {
+	colValMap := make(map[string]interface{}, len(r))
+	for i, val := range r {
+		if val == nil {
+			continue
+		}
+
+		switch val.(type) {
+		case bool, string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			// use primitive type
+		default:
+			str, err := sqlutil.SqlColToStr(j.sch[i].Type, val)
+			if err != nil {
+				return nil, err
+			}
+			val = str
+		}
+
+		colValMap[j.sch[i].Name] = val
+	}
+	return colValMap, nil
+}
+
+// Close implements untyped.RowDiffWriter. It ends the data_diff array and flushes all writes.
+func (j *JSONDiffWriter) Close(ctx context.Context) error {
+	if j.closer != nil {
+		err := iohelp.WriteAll(j.bWr, []byte(jsonDataDiffFooter))
+		if err != nil {
+			return err
+		}
+
+		errFl := j.bWr.Flush()
+		errCl := j.closer.Close()
+		j.closer = nil
+
+		if errCl != nil {
+			return errCl
+		}
+
+		return errFl
+	}
+	return errors.New("already closed")
+}
@@ -0,0 +1,45 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package untyped
+
+import (
+	"context"
+
+	"github.com/dolthub/go-mysql-server/sql"
+)
+
+// RowDiffType is the change of a row written to a RowDiffWriter.
+type RowDiffType int
+
+const (
+	// RowAdded is a row that was added.
+	RowAdded RowDiffType = iota
+	// RowRemoved is a row that was removed.
+	RowRemoved
+	// RowModifiedOld is the value of a modified row before it was changed. It's followed by the RowModifiedNew value
+	// of the same row.
+	RowModifiedOld
+	// RowModifiedNew is the value of a modified row after it was changed.
+	RowModifiedNew
+)
+
+// RowDiffWriter writes the changed rows of a table's data diff to a file format.
+type RowDiffWriter interface {
+	// WriteRowDiff writes |row|, which changed as |diffType| gives.
+	WriteRowDiff(ctx context.Context, row sql.Row, diffType RowDiffType) error
+
+	// Close finalizes the work of this writer.
+	Close(ctx context.Context) error
+}
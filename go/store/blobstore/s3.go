@@ -0,0 +1,221 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// S3Blobstore provides an S3 implementation of the Blobstore interface. It only uses the S3 object API, so it works
+// with S3 compatible object stores such as MinIO and Ceph RGW. The version of a blob is its ETag, and CheckAndPut is
+// implemented with conditional writes, which the object store must support.
+type S3Blobstore struct {
+	s3         s3iface.S3API
+	bucketName string
+	prefix     string
+}
+
+// NewS3Blobstore creates a new instance of a S3Blobstore
+func NewS3Blobstore(s3 s3iface.S3API, bucketName, prefix string) *S3Blobstore {
+	for len(prefix) > 0 && prefix[0] == '/' {
+		prefix = prefix[1:]
+	}
+
+	return &S3Blobstore{s3, bucketName, prefix}
+}
+
+// conditionalWriteProbeKey is the key of the blob written by OpenS3Blobstore to check for conditional write support
+const conditionalWriteProbeKey = ".conditional_write_probe"
+
+// OpenS3Blobstore creates a new instance of a S3Blobstore after checking that the object store honors the conditional
+// writes CheckAndPut is built on. Some S3 compatible object stores ignore the conditions, which would silently turn
+// CheckAndPut into an unconditional put and let concurrent writers lose updates, so those are refused.
+func OpenS3Blobstore(ctx context.Context, s3 s3iface.S3API, bucketName, prefix string) (*S3Blobstore, error) {
+	bs := NewS3Blobstore(s3, bucketName, prefix)
+
+	if err := bs.checkConditionalWrites(ctx); err != nil {
+		return nil, err
+	}
+
+	return bs, nil
+}
+
+// checkConditionalWrites writes a probe blob, and then writes it again with conditions that don't hold. Both
+// conditional writes have to be rejected.
+func (bs *S3Blobstore) checkConditionalWrites(ctx context.Context) error {
+	probe := []byte("conditional write probe")
+	_, err := bs.put(ctx, conditionalWriteProbeKey, bytes.NewReader(probe))
+
+	if err != nil {
+		return err
+	}
+
+	// the probe exists, so a write that expects it not to exist must fail, and so must a write that expects a
+	// version of it which no object store will ever assign
+	for _, expectedVersion := range []string{"", `"00000000000000000000000000000000"`} {
+		_, err = bs.put(ctx, conditionalWriteProbeKey, bytes.NewReader(probe), s3PutCondition(expectedVersion))
+
+		if err == nil {
+			return fmt.Errorf("the object store for s3://%s/%s does not support conditional writes, which are required to update a database safely", bs.bucketName, bs.prefix)
+		} else if !isS3StatusCode(err, http.StatusPreconditionFailed) && !isS3StatusCode(err, http.StatusConflict) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bs *S3Blobstore) absKey(key string) string {
+	return path.Join(bs.prefix, key)
+}
+
+// Exists returns true if a blob exists for the given key, and false if it does not.
+func (bs *S3Blobstore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := bs.s3.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bs.bucketName),
+		Key:    aws.String(bs.absKey(key)),
+	})
+
+	if isS3StatusCode(err, http.StatusNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Get retrieves an io.reader for the portion of a blob specified by br along with
+// its version
+func (bs *S3Blobstore) Get(ctx context.Context, key string, br BlobRange) (io.ReadCloser, string, error) {
+	absKey := bs.absKey(key)
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(bs.bucketName),
+		Key:    aws.String(absKey),
+	}
+
+	// a negative offset with a length is read as the suffix starting at the offset, which is then limited to the length
+	var limit int64
+	if !br.isAllRange() {
+		input.Range = aws.String(s3RangeHeader(br))
+		if br.offset < 0 && br.length != 0 {
+			limit = br.length
+		}
+	}
+
+	result, err := bs.s3.GetObjectWithContext(ctx, input)
+
+	if isS3StatusCode(err, http.StatusNotFound) {
+		return nil, "", NotFound{"s3://" + path.Join(bs.bucketName, absKey)}
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	rc := result.Body
+	if limit > 0 {
+		rc = limitedReadCloser{io.LimitReader(rc, limit), rc}
+	}
+
+	return rc, aws.StringValue(result.ETag), nil
+}
+
+func s3RangeHeader(br BlobRange) string {
+	if br.offset < 0 {
+		return "bytes=" + strconv.FormatInt(br.offset, 10)
+	} else if br.length == 0 {
+		return "bytes=" + strconv.FormatInt(br.offset, 10) + "-"
+	}
+
+	return "bytes=" + strconv.FormatInt(br.offset, 10) + "-" + strconv.FormatInt(br.offset+br.length-1, 10)
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Put sets the blob and the version for a key
+func (bs *S3Blobstore) Put(ctx context.Context, key string, reader io.Reader) (string, error) {
+	return bs.put(ctx, key, reader)
+}
+
+// CheckAndPut will check the current version of a blob against an expectedVersion, and if the
+// versions match it will update the data and version associated with the key
+func (bs *S3Blobstore) CheckAndPut(ctx context.Context, expectedVersion, key string, reader io.Reader) (string, error) {
+	ver, err := bs.put(ctx, key, reader, s3PutCondition(expectedVersion))
+
+	// S3 responds with 409 to a conditional write that raced with another write of the same key
+	if isS3StatusCode(err, http.StatusPreconditionFailed) || isS3StatusCode(err, http.StatusConflict) {
+		return "", CheckAndPutError{key, expectedVersion, "unknown (Not supported in S3 implementation)"}
+	}
+
+	return ver, err
+}
+
+// s3PutCondition returns a request option which makes a put succeed only if the current version of the blob is
+// expectedVersion, or if the blob doesn't exist when expectedVersion is empty.
+func s3PutCondition(expectedVersion string) request.Option {
+	return func(r *request.Request) {
+		if expectedVersion != "" {
+			r.HTTPRequest.Header.Set("If-Match", expectedVersion)
+		} else {
+			r.HTTPRequest.Header.Set("If-None-Match", "*")
+		}
+	}
+}
+
+func (bs *S3Blobstore) put(ctx context.Context, key string, reader io.Reader, opts ...request.Option) (string, error) {
+	// requests are signed with the hash of their body, so it has to be seekable
+	body, ok := reader.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(reader)
+
+		if err != nil {
+			return "", err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	result, err := bs.s3.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(bs.bucketName),
+		Key:    aws.String(bs.absKey(key)),
+		Body:   body,
+	}, opts...)
+
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(result.ETag), nil
+}
+
+func isS3StatusCode(err error, code int) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() == code
+	}
+
+	return false
+}
@@ -0,0 +1,228 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobstore
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3Server is an in-process S3 compatible object store that serves path-style requests for objects, with
+// conditional writes. Like some S3 compatible object stores, it can be made to ignore the conditions.
+type fakeS3Server struct {
+	mu               sync.Mutex
+	objects          map[string]fakeS3Object
+	ignoreConditions bool
+}
+
+type fakeS3Object struct {
+	data []byte
+	etag string
+}
+
+func newFakeS3Server() *httptest.Server {
+	return httptest.NewServer(&fakeS3Server{objects: make(map[string]fakeS3Object)})
+}
+
+func newFakeS3ServerIgnoringConditions() *httptest.Server {
+	return httptest.NewServer(&fakeS3Server{objects: make(map[string]fakeS3Object), ignoreConditions: true})
+}
+
+func (s *fakeS3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	obj, ok := s.objects[key]
+
+	switch r.Method {
+	case http.MethodHead, http.MethodGet:
+		if !ok {
+			writeFakeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		data, status := obj.data, http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			start, end, err := parseFakeS3Range(rng, int64(len(data)))
+			if err != nil {
+				writeFakeS3Error(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(data)))
+			data, status = data[start:end], http.StatusPartialContent
+		}
+		w.Header().Set("ETag", obj.etag)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+
+	case http.MethodPut:
+		if match := r.Header.Get("If-Match"); match != "" && (!ok || match != obj.etag) && !s.ignoreConditions {
+			writeFakeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && ok && !s.ignoreConditions {
+			writeFakeS3Error(w, http.StatusPreconditionFailed, "PreconditionFailed")
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeFakeS3Error(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		// ETags of single part uploads are the MD5 of the object, but every write gets a new version here
+		sum := md5.Sum(append([]byte(uuid.New().String()), data...))
+		obj = fakeS3Object{data: data, etag: `"` + hex.EncodeToString(sum[:]) + `"`}
+		s.objects[key] = obj
+		w.Header().Set("ETag", obj.etag)
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		writeFakeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+func parseFakeS3Range(rng string, size int64) (start, end int64, err error) {
+	spec := strings.TrimPrefix(rng, "bytes=")
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid range %s", rng)
+	}
+	if parts[0] == "" {
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size, nil
+	}
+	start, err = strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	end = size
+	if parts[1] != "" {
+		last, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if last+1 < end {
+			end = last + 1
+		}
+	}
+	if start >= end {
+		return 0, 0, fmt.Errorf("invalid range %s", rng)
+	}
+	return start, end, nil
+}
+
+func writeFakeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func newFakeS3Client(endpoint string) *s3.S3 {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String("us-east-1"),
+		S3ForcePathStyle: aws.Bool(true),
+		Credentials:      credentials.NewStaticCredentials("access", "secret", ""),
+	}))
+	return s3.New(sess)
+}
+
+func appendS3Test(tests []BlobstoreTest) []BlobstoreTest {
+	srv := newFakeS3Server()
+	bs := NewS3Blobstore(newFakeS3Client(srv.URL), "bucket", uuid.New().String()+"/")
+	return append(tests, BlobstoreTest{"s3", bs, 10, 20})
+}
+
+func TestS3BlobstoreMissingKey(t *testing.T) {
+	srv := newFakeS3Server()
+	defer srv.Close()
+	bs := NewS3Blobstore(newFakeS3Client(srv.URL), "bucket", "/db")
+
+	ctx := context.Background()
+	ok, err := bs.Exists(ctx, "missing")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, _, err = bs.Get(ctx, "missing", AllRange)
+	assert.True(t, IsNotFoundError(err))
+	assert.Equal(t, "Blob not found: s3://bucket/db/missing", err.Error())
+
+	_, err = PutBytes(ctx, bs, "missing", []byte("data"))
+	require.NoError(t, err)
+	ok, err = bs.Exists(ctx, "missing")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// a key that already exists can't be created by CheckAndPut
+	_, err = CheckAndPutBytes(ctx, bs, "", "missing", []byte("more data"))
+	assert.True(t, IsCheckAndPutError(err))
+}
+
+func TestOpenS3Blobstore(t *testing.T) {
+	ctx := context.Background()
+
+	srv := newFakeS3Server()
+	defer srv.Close()
+	bs, err := OpenS3Blobstore(ctx, newFakeS3Client(srv.URL), "bucket", "/db")
+	require.NoError(t, err)
+
+	// the probe leaves the blobs of the database alone
+	_, err = CheckAndPutBytes(ctx, bs, "", "manifest", []byte("data"))
+	require.NoError(t, err)
+	_, err = OpenS3Blobstore(ctx, newFakeS3Client(srv.URL), "bucket", "/db")
+	require.NoError(t, err)
+	data, _, err := GetBytes(ctx, bs, "manifest", AllRange)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	ignoringSrv := newFakeS3ServerIgnoringConditions()
+	defer ignoringSrv.Close()
+	_, err = OpenS3Blobstore(ctx, newFakeS3Client(ignoringSrv.URL), "bucket", "/db")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support conditional writes")
+}
+
+func TestS3RangeHeader(t *testing.T) {
+	assert.Equal(t, "bytes=0-2047", s3RangeHeader(NewBlobRange(0, 2048)))
+	assert.Equal(t, "bytes=2048-", s3RangeHeader(NewBlobRange(2048, 0)))
+	assert.Equal(t, "bytes=-2048", s3RangeHeader(NewBlobRange(-2048, 0)))
+	assert.Equal(t, "bytes=-2048", s3RangeHeader(NewBlobRange(-2048, 512)))
+}
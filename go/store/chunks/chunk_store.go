@@ -111,6 +111,18 @@ type ChunkStoreGarbageCollector interface {
 	MarkAndSweepChunks(ctx context.Context, last hash.Hash, keepChunks <-chan []hash.Hash, dest ChunkStore) error
 }
 
+// OnlineGarbageCollector is a chunk store that can be garbage collected while it continues to serve reads and writes.
+// Between BeginGC and EndGC, every chunk written to the store is kept by MarkAndSweepChunks in addition to the chunks
+// sent on |keepChunks|, and the root of the store may move while chunks are being marked.
+type OnlineGarbageCollector interface {
+	// BeginGC starts tracking the chunks written to the store. It returns ErrGCInProgress if another collection has
+	// already begun.
+	BeginGC() error
+
+	// EndGC stops tracking the chunks written to the store.
+	EndGC()
+}
+
 type PrefixChunkStore interface {
 	ChunkStore
 
@@ -125,4 +137,6 @@ type GenerationalCS interface {
 
 var ErrUnsupportedOperation = errors.New("operation not supported")
 
+var ErrGCInProgress = errors.New("garbage collection already in progress")
+
 var ErrGCGenerationExpired = errors.New("garbage collection generation expired")
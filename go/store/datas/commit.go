@@ -136,6 +136,12 @@ func NewCommitForValue(ctx context.Context, cs chunks.ChunkStore, vrw types.Valu
 	return newCommitForValue(ctx, cs, vrw, ns, v, opts)
 }
 
+// NewInitialCommitForValue returns a commit of |v| with no parents and the metadata |meta|, such as the first commit of
+// a database, or a rewrite of one.
+func NewInitialCommitForValue(ctx context.Context, cs chunks.ChunkStore, vrw types.ValueReadWriter, ns tree.NodeStore, v types.Value, meta *CommitMeta) (*Commit, error) {
+	return newCommitForValue(ctx, cs, vrw, ns, v, CommitOptions{Meta: meta})
+}
+
 func commit_flatbuffer(vaddr hash.Hash, opts CommitOptions, heights []uint64, parentsClosureAddr hash.Hash) (serial.Message, uint64) {
 	builder := flatbuffers.NewBuilder(1024)
 	vaddroff := builder.CreateByteVector(vaddr[:])
@@ -297,13 +303,20 @@ func commitFromValue(nbf *types.NomsBinFormat, v types.Value) (*Commit, error) {
 	return commitPtr(nbf, v, nil)
 }
 
+// ErrCommitNotFound is returned when a commit being loaded is not in the database.
+var ErrCommitNotFound = errors.New("target commit not found")
+
+// ErrParentNotFound is returned when the parent of a commit is not in the database. This is the case for the oldest
+// commits of a shallow clone, whose parents were not fetched.
+var ErrParentNotFound = errors.New("Did not find parent Commit in ValueReader")
+
 func LoadCommitRef(ctx context.Context, vr types.ValueReader, r types.Ref) (*Commit, error) {
 	v, err := vr.ReadValue(ctx, r.TargetHash())
 	if err != nil {
 		return nil, err
 	}
 	if v == nil {
-		return nil, errors.New("target commit not found")
+		return nil, ErrCommitNotFound
 	}
 	return commitPtr(vr.Format(), v, &r)
 }
@@ -314,7 +327,7 @@ func LoadCommitAddr(ctx context.Context, vr types.ValueReader, addr hash.Hash) (
 		return nil, err
 	}
 	if v == nil {
-		return nil, errors.New("target commit not found")
+		return nil, ErrCommitNotFound
 	}
 	return commitFromValue(vr.Format(), v)
 }
@@ -435,6 +448,9 @@ func GetCommitParents(ctx context.Context, vr types.ValueReader, cv types.Value)
 			return nil, errors.New("GetCommitParents: provided value is not a commit.")
 		}
 		addrs, err := types.SerialCommitParentAddrs(vr.Format(), sm)
+		if err != nil {
+			return nil, err
+		}
 		vals, err := vr.ReadManyValues(ctx, addrs)
 		if err != nil {
 			return nil, err
@@ -442,7 +458,7 @@ func GetCommitParents(ctx context.Context, vr types.ValueReader, cv types.Value)
 		res := make([]*Commit, len(vals))
 		for i, v := range vals {
 			if v == nil {
-				return nil, fmt.Errorf("GetCommitParents: Did not find parent Commit in ValueReader: %s", addrs[i].String())
+				return nil, fmt.Errorf("GetCommitParents: %w: %s", ErrParentNotFound, addrs[i].String())
 			}
 			csm := serial.GetRootAsCommit([]byte(v.(types.SerialMessage)), serial.MessagePrefixSz)
 			res[i] = &Commit{
@@ -453,6 +469,55 @@ func GetCommitParents(ctx context.Context, vr types.ValueReader, cv types.Value)
 		}
 		return res, nil
 	}
+	refs, err := commitParentRefs(ctx, cv)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]hash.Hash, len(refs))
+	for i, r := range refs {
+		hashes[i] = r.TargetHash()
+	}
+	vals, err := vr.ReadManyValues(ctx, hashes)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*Commit, len(refs))
+	for i, val := range vals {
+		if val == nil {
+			return nil, fmt.Errorf("GetCommitParents: %w: %s", ErrParentNotFound, hashes[i].String())
+		}
+		res[i] = &Commit{
+			val:    val,
+			height: refs[i].Height(),
+			addr:   refs[i].TargetHash(),
+		}
+	}
+	return res, nil
+}
+
+// GetCommitParentAddrs returns the addresses of the parents of the commit. Unlike GetCommitParents, it does not load
+// the parents, and so succeeds for commits whose parents are not in the database.
+func GetCommitParentAddrs(ctx context.Context, vr types.ValueReader, cv types.Value) ([]hash.Hash, error) {
+	if sm, ok := cv.(types.SerialMessage); ok {
+		data := []byte(sm)
+		if serial.GetFileID(data) != serial.CommitFileID {
+			return nil, errors.New("GetCommitParentAddrs: provided value is not a commit.")
+		}
+		return types.SerialCommitParentAddrs(vr.Format(), sm)
+	}
+	refs, err := commitParentRefs(ctx, cv)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]hash.Hash, len(refs))
+	for i, r := range refs {
+		hashes[i] = r.TargetHash()
+	}
+	return hashes, nil
+}
+
+// commitParentRefs returns the refs to the parents of a commit in the old storage format.
+func commitParentRefs(ctx context.Context, cv types.Value) ([]types.Ref, error) {
 	c, ok := cv.(types.Struct)
 	if !ok {
 		return nil, errors.New("GetCommitParents: provided value is not a commit.")
@@ -484,26 +549,10 @@ func GetCommitParents(ctx context.Context, vr types.ValueReader, cv types.Value)
 			})
 		}
 	}
-	hashes := make([]hash.Hash, len(refs))
-	for i, r := range refs {
-		hashes[i] = r.TargetHash()
-	}
-	vals, err := vr.ReadManyValues(ctx, hashes)
 	if err != nil {
 		return nil, err
 	}
-	res := make([]*Commit, len(refs))
-	for i, val := range vals {
-		if val == nil {
-			return nil, fmt.Errorf("GetCommitParents: Did not find parent Commit in ValueReader: %s", hashes[i].String())
-		}
-		res[i] = &Commit{
-			val:    val,
-			height: refs[i].Height(),
-			addr:   refs[i].TargetHash(),
-		}
-	}
-	return res, nil
+	return refs, nil
 }
 
 // GetCommitMeta extracts the CommitMeta field from a commit. Returns |nil,
@@ -102,6 +102,31 @@ func newParentsClosureIterator(ctx context.Context, c *Commit, vr types.ValueRea
 	return &parentsClosureIterator{mi, nil, initialCurr}, nil
 }
 
+// GetCommitClosureAddrs returns the addresses of all the ancestors of |c| recorded in its parents closure, without
+// reading the ancestors themselves. The returned bool is false if |c| does not have a materialized parents closure.
+func GetCommitClosureAddrs(ctx context.Context, c *Commit, vr types.ValueReader, ns tree.NodeStore) (hash.HashSet, bool, error) {
+	pi, err := newParentsClosureIterator(ctx, c, vr, ns)
+	if err != nil {
+		return nil, false, err
+	}
+	if pi == nil {
+		return nil, false, nil
+	}
+
+	addrs := hash.NewHashSet()
+	for pi.Next(ctx) {
+		if pi.Err() != nil {
+			break
+		}
+		addrs.Insert(pi.Hash())
+	}
+	if err := pi.Err(); err != nil {
+		return nil, false, err
+	}
+
+	return addrs, true, nil
+}
+
 func commitToMapKeyTuple(f *types.NomsBinFormat, c *Commit) (types.Tuple, error) {
 	h := c.Addr()
 	ib := make([]byte, len(hash.Hash{}))
@@ -100,6 +100,13 @@ type Database interface {
 	// updated in the new root, or neither of them are.
 	CommitWithWorkingSet(ctx context.Context, commitDS, workingSetDS Dataset, val types.Value, workingSetSpec WorkingSetSpec, prevWsHash hash.Hash, opts CommitOptions) (Dataset, Dataset, error)
 
+	// SetHeadWithWorkingSet combines SetHead and UpdateWorkingSet. Like CommitWithWorkingSet, it asserts that the
+	// hash |prevWsHash| given is still the current one and that |headDS| still has the head it was read with, and
+	// returns ErrOptimisticLockFailed or ErrMergeNeeded otherwise. |headAddr| must be the address of a commit. After
+	// this method runs, the two datasets given in |headDS| and |workingSetDS| are both updated in the new root, or
+	// neither of them are.
+	SetHeadWithWorkingSet(ctx context.Context, headDS, workingSetDS Dataset, headAddr hash.Hash, workingSetSpec WorkingSetSpec, prevWsHash hash.Hash) (Dataset, Dataset, error)
+
 	// Delete removes the Dataset named ds.ID() from the map at the root of
 	// the Database. If the Dataset is already not present in the map,
 	// returns success.
@@ -119,6 +126,12 @@ type Database interface {
 	// error will be non-nil.
 	SetHead(ctx context.Context, ds Dataset, newHeadAddr hash.Hash) (Dataset, error)
 
+	// CheckAndSetHead is like SetHead, but it only updates the dataset if
+	// its head is still the head of |ds|, or if it still doesn't exist
+	// when |ds| has no head. Otherwise it returns ErrOptimisticLockFailed
+	// and the caller must retry with a fresh Dataset.
+	CheckAndSetHead(ctx context.Context, ds Dataset, newHeadAddr hash.Hash) (Dataset, error)
+
 	// FastForward takes a types.Ref to a Commit object and makes it the new
 	// Head of ds iff it is a descendant of the current Head. Intended to be
 	// used e.g. after a call to Pull(). If the update cannot be performed,
@@ -158,8 +171,18 @@ type GarbageCollector interface {
 	types.ValueReadWriter
 
 	// GC traverses the database starting at the Root and removes
-	// all unreferenced data from persistent storage.
-	GC(ctx context.Context, oldGenRefs, newGenRefs hash.HashSet) error
+	// all unreferenced data from persistent storage. The chunks in
+	// |excludedRefs| are referenced, but missing from the store, and
+	// are not traversed.
+	GC(ctx context.Context, oldGenRefs, newGenRefs, excludedRefs hash.HashSet) error
+
+	// BeginGC allows a following call to GC to run while the database
+	// continues to be written to. Everything written after BeginGC
+	// returns survives the collection.
+	BeginGC() error
+
+	// EndGC ends a collection started with BeginGC.
+	EndGC()
 }
 
 // CanUsePuller returns true if a datas.Puller can be used to pull data from one Database into another.  Not all
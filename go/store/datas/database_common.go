@@ -249,10 +249,16 @@ func (db *database) Close() error {
 }
 
 func (db *database) SetHead(ctx context.Context, ds Dataset, newHeadAddr hash.Hash) (Dataset, error) {
-	return db.doHeadUpdate(ctx, ds, func(ds Dataset) error { return db.doSetHead(ctx, ds, newHeadAddr) })
+	return db.doHeadUpdate(ctx, ds, func(ds Dataset) error { return db.doSetHead(ctx, ds, newHeadAddr, false) })
 }
 
-func (db *database) doSetHead(ctx context.Context, ds Dataset, addr hash.Hash) error {
+func (db *database) CheckAndSetHead(ctx context.Context, ds Dataset, newHeadAddr hash.Hash) (Dataset, error) {
+	return db.doHeadUpdate(ctx, ds, func(ds Dataset) error { return db.doSetHead(ctx, ds, newHeadAddr, true) })
+}
+
+// doSetHead points the dataset |ds| at |addr|. If |checkCurrent| is true, the dataset must still be at the head of
+// |ds| or the update fails with ErrOptimisticLockFailed.
+func (db *database) doSetHead(ctx context.Context, ds Dataset, addr hash.Hash, checkCurrent bool) error {
 	newHead, err := db.readHead(ctx, addr)
 	if err != nil {
 		return err
@@ -298,8 +304,19 @@ func (db *database) doSetHead(ctx context.Context, ds Dataset, addr hash.Hash) e
 	}
 
 	key := types.String(ds.ID())
+	prevHeadAddr, _ := ds.MaybeHeadAddr()
 
 	return db.update(ctx, func(ctx context.Context, datasets types.Map) (types.Map, error) {
+		if checkCurrent {
+			success, err := assertDatasetHash(ctx, datasets, ds.ID(), prevHeadAddr)
+			if err != nil {
+				return types.Map{}, err
+			}
+			if !success {
+				return types.Map{}, ErrOptimisticLockFailed
+			}
+		}
+
 		currRef, ok, err := datasets.MaybeGet(ctx, key)
 		if err != nil {
 			return types.Map{}, err
@@ -331,6 +348,9 @@ func (db *database) doSetHead(ctx context.Context, ds Dataset, addr hash.Hash) e
 		if err != nil {
 			return prolly.AddressMap{}, err
 		}
+		if checkCurrent && curr != prevHeadAddr {
+			return prolly.AddressMap{}, ErrOptimisticLockFailed
+		}
 		if curr != (hash.Hash{}) {
 			currHead, err := db.readHead(ctx, curr)
 			if err != nil {
@@ -544,7 +564,7 @@ func (db *database) UpdateWorkingSet(ctx context.Context, ds Dataset, workingSet
 		ctx,
 		ds,
 		func(ds Dataset) error {
-			addr, ref, err := newWorkingSet(ctx, db, workingSet.Meta, workingSet.WorkingRoot, workingSet.StagedRoot, workingSet.MergeState)
+			addr, ref, err := newWorkingSet(ctx, db, workingSet.Meta, workingSet.WorkingRoot, workingSet.StagedRoot, workingSet.MergeState, workingSet.RebaseState)
 			if err != nil {
 				return err
 			}
@@ -613,7 +633,7 @@ func (db *database) CommitWithWorkingSet(
 	val types.Value, workingSetSpec WorkingSetSpec,
 	prevWsHash hash.Hash, opts CommitOptions,
 ) (Dataset, Dataset, error) {
-	wsAddr, wsValRef, err := newWorkingSet(ctx, db, workingSetSpec.Meta, workingSetSpec.WorkingRoot, workingSetSpec.StagedRoot, workingSetSpec.MergeState)
+	wsAddr, wsValRef, err := newWorkingSet(ctx, db, workingSetSpec.Meta, workingSetSpec.WorkingRoot, workingSetSpec.StagedRoot, workingSetSpec.MergeState, workingSetSpec.RebaseState)
 	if err != nil {
 		return Dataset{}, Dataset{}, err
 	}
@@ -710,6 +730,107 @@ func (db *database) CommitWithWorkingSet(
 	return commitDS, workingSetDS, nil
 }
 
+// SetHeadWithWorkingSet updates two Datasets atomically: it points |headDS| at the existing commit |headAddr|, and
+// updates the working set of |workingSetDS|. Uses the same locking as CommitWithWorkingSet.
+func (db *database) SetHeadWithWorkingSet(
+	ctx context.Context,
+	headDS, workingSetDS Dataset,
+	headAddr hash.Hash, workingSetSpec WorkingSetSpec,
+	prevWsHash hash.Hash,
+) (Dataset, Dataset, error) {
+	wsAddr, wsValRef, err := newWorkingSet(ctx, db, workingSetSpec.Meta, workingSetSpec.WorkingRoot, workingSetSpec.StagedRoot, workingSetSpec.MergeState, workingSetSpec.RebaseState)
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+
+	newHead, err := db.readHead(ctx, headAddr)
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+	if newHead == nil || newHead.TypeName() != commitName {
+		return Dataset{}, Dataset{}, fmt.Errorf("SetHeadWithWorkingSet failed: referred to value is not a commit")
+	}
+	headValRef, err := types.NewRef(newHead.value(), db.Format())
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+	headValRef, err = types.ToRefOfValue(headValRef, db.Format())
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+
+	currDSHash, _ := headDS.MaybeHeadAddr()
+
+	err = db.update(ctx, func(ctx context.Context, datasets types.Map) (types.Map, error) {
+		success, err := assertDatasetHash(ctx, datasets, workingSetDS.ID(), prevWsHash)
+		if err != nil {
+			return types.Map{}, err
+		}
+		if !success {
+			return types.Map{}, ErrOptimisticLockFailed
+		}
+
+		success, err = assertDatasetHash(ctx, datasets, headDS.ID(), currDSHash)
+		if err != nil {
+			return types.Map{}, err
+		}
+		if !success {
+			return types.Map{}, ErrMergeNeeded
+		}
+
+		return datasets.Edit().
+			Set(types.String(workingSetDS.ID()), wsValRef).
+			Set(types.String(headDS.ID()), headValRef).
+			Map(ctx)
+	}, func(ctx context.Context, am prolly.AddressMap) (prolly.AddressMap, error) {
+		currWS, err := am.Get(ctx, workingSetDS.ID())
+		if err != nil {
+			return prolly.AddressMap{}, err
+		}
+		if currWS != prevWsHash {
+			return prolly.AddressMap{}, ErrOptimisticLockFailed
+		}
+		currDS, err := am.Get(ctx, headDS.ID())
+		if err != nil {
+			return prolly.AddressMap{}, err
+		}
+		if currDS != currDSHash {
+			return prolly.AddressMap{}, ErrMergeNeeded
+		}
+		ae := am.Editor()
+		err = ae.Update(ctx, headDS.ID(), headAddr)
+		if err != nil {
+			return prolly.AddressMap{}, err
+		}
+		err = ae.Update(ctx, workingSetDS.ID(), wsAddr)
+		if err != nil {
+			return prolly.AddressMap{}, err
+		}
+		return ae.Flush(ctx)
+	})
+
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+
+	currentDatasets, err := db.Datasets(ctx)
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+
+	headDS, err = db.datasetFromMap(ctx, headDS.ID(), currentDatasets)
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+
+	workingSetDS, err = db.datasetFromMap(ctx, workingSetDS.ID(), currentDatasets)
+	if err != nil {
+		return Dataset{}, Dataset{}, err
+	}
+
+	return headDS, workingSetDS, nil
+}
+
 func (db *database) Delete(ctx context.Context, ds Dataset) (Dataset, error) {
 	return db.doHeadUpdate(ctx, ds, func(ds Dataset) error { return db.doDelete(ctx, ds.ID()) })
 }
@@ -816,8 +937,8 @@ func (db *database) doDelete(ctx context.Context, datasetIDstr string) error {
 }
 
 // GC traverses the database starting at the Root and removes all unreferenced data from persistent storage.
-func (db *database) GC(ctx context.Context, oldGenRefs, newGenRefs hash.HashSet) error {
-	return db.ValueStore.GC(ctx, oldGenRefs, newGenRefs)
+func (db *database) GC(ctx context.Context, oldGenRefs, newGenRefs, excludedRefs hash.HashSet) error {
+	return db.ValueStore.GC(ctx, oldGenRefs, newGenRefs, excludedRefs)
 }
 
 func (db *database) tryCommitChunks(ctx context.Context, newRootHash hash.Hash, currentRootHash hash.Hash) error {
@@ -532,6 +532,44 @@ func (suite *DatabaseSuite) TestSetHead() {
 	suite.True(mustHeadValue(ds).Equals(b))
 }
 
+func (suite *DatabaseSuite) TestCheckAndSetHead() {
+	ctx := context.Background()
+	datasetID := "ds1"
+
+	// |a| <- |b|
+	ds, err := suite.db.GetDataset(ctx, datasetID)
+	suite.NoError(err)
+	a := types.String("a")
+	ds, err = CommitValue(ctx, suite.db, ds, a)
+	suite.NoError(err)
+	aCommitAddr := mustHeadAddr(ds)
+
+	b := types.String("b")
+	ds, err = CommitValue(ctx, suite.db, ds, b)
+	suite.NoError(err)
+	bCommitAddr := mustHeadAddr(ds)
+
+	// a dataset which doesn't exist anymore, or which has moved, is not updated
+	_, err = suite.db.CheckAndSetHead(ctx, NewHeadlessDataset(suite.db, datasetID), aCommitAddr)
+	suite.Equal(ErrOptimisticLockFailed, err)
+
+	stale := ds
+	ds, err = suite.db.CheckAndSetHead(ctx, ds, aCommitAddr)
+	suite.NoError(err)
+	suite.True(mustHeadValue(ds).Equals(a))
+
+	_, err = suite.db.CheckAndSetHead(ctx, stale, bCommitAddr)
+	suite.Equal(ErrOptimisticLockFailed, err)
+	ds, err = suite.db.GetDataset(ctx, datasetID)
+	suite.NoError(err)
+	suite.True(mustHeadValue(ds).Equals(a))
+
+	// a dataset which doesn't exist yet is created
+	ds, err = suite.db.CheckAndSetHead(ctx, NewHeadlessDataset(suite.db, "ds2"), bCommitAddr)
+	suite.NoError(err)
+	suite.True(mustHeadValue(ds).Equals(b))
+}
+
 func (suite *DatabaseSuite) TestFastForward() {
 	datasetID := "ds1"
 
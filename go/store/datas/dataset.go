@@ -45,11 +45,13 @@ type WorkingSetHead struct {
 	WorkingAddr hash.Hash
 	StagedAddr  *hash.Hash
 	MergeState  *MergeState
+	RebaseState *RebaseState
 }
 
 type MergeState struct {
-	preMergeWorkingAddr *hash.Hash
-	fromCommitAddr      *hash.Hash
+	preMergeWorkingAddr  *hash.Hash
+	fromCommitAddr       *hash.Hash
+	schemaConflictTables []string
 
 	nomsMergeStateRef *types.Ref
 	nomsMergeState    *types.Struct
@@ -116,6 +118,156 @@ func (ms *MergeState) FromCommit(ctx context.Context, vr types.ValueReader) (*Co
 	return commitFromValue(vr.Format(), commitV)
 }
 
+// SchemaConflictTables returns the names of the tables whose schemas could not be merged.
+func (ms *MergeState) SchemaConflictTables(ctx context.Context, vr types.ValueReader) ([]string, error) {
+	if ms.preMergeWorkingAddr != nil {
+		return ms.schemaConflictTables, nil
+	}
+	if ms.nomsMergeState == nil {
+		err := ms.loadIfNeeded(ctx, vr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tablesV, ok, err := ms.nomsMergeState.MaybeGet(mergeStateSchemaConflictTablesField)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// merge states written before schema conflicts were recorded have no such field
+		return nil, nil
+	}
+	tablesL, ok := tablesV.(types.List)
+	if !ok {
+		return nil, fmt.Errorf("corrupted MergeState struct")
+	}
+
+	var tables []string
+	err = tablesL.IterAll(ctx, func(v types.Value, _ uint64) error {
+		tables = append(tables, string(v.(types.String)))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+type RebaseState struct {
+	preRebaseWorkingAddr *hash.Hash
+	ontoCommitAddr       *hash.Hash
+	rebasedHeadAddr      *hash.Hash
+	branch               string
+	plan                 string
+	nextStep             uint32
+	stopped              bool
+
+	nomsRebaseStateRef *types.Ref
+}
+
+// loadIfNeeded reads the fields of a rebase state stored as a noms struct. Rebase states stored as flatbuffers are
+// fully populated when the working set is read.
+func (rs *RebaseState) loadIfNeeded(ctx context.Context, vr types.ValueReader) error {
+	if rs.preRebaseWorkingAddr != nil {
+		return nil
+	}
+
+	v, err := rs.nomsRebaseStateRef.TargetValue(ctx, vr)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return errors.New("dangling reference to rebase state")
+	}
+	st, ok := v.(types.Struct)
+	if !ok {
+		return fmt.Errorf("corrupted RebaseState struct")
+	}
+
+	fields := make(map[string]types.Value)
+	for _, name := range []string{
+		rebaseStateWorkingPreRebaseField,
+		rebaseStateOntoField,
+		rebaseStateRebasedHeadField,
+		rebaseStateBranchField,
+		rebaseStatePlanField,
+		rebaseStateNextStepField,
+		rebaseStateStoppedField,
+	} {
+		fv, ok, err := st.MaybeGet(name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("corrupted RebaseState struct")
+		}
+		fields[name] = fv
+	}
+
+	preRebaseWorkingAddr := fields[rebaseStateWorkingPreRebaseField].(types.Ref).TargetHash()
+	ontoCommitAddr := fields[rebaseStateOntoField].(types.Ref).TargetHash()
+	rebasedHeadAddr := fields[rebaseStateRebasedHeadField].(types.Ref).TargetHash()
+	rs.ontoCommitAddr = &ontoCommitAddr
+	rs.rebasedHeadAddr = &rebasedHeadAddr
+	rs.branch = string(fields[rebaseStateBranchField].(types.String))
+	rs.plan = string(fields[rebaseStatePlanField].(types.String))
+	rs.nextStep = uint32(fields[rebaseStateNextStepField].(types.Uint))
+	rs.stopped = bool(fields[rebaseStateStoppedField].(types.Bool))
+	rs.preRebaseWorkingAddr = &preRebaseWorkingAddr
+
+	return nil
+}
+
+func (rs *RebaseState) PreRebaseWorkingAddr(ctx context.Context, vr types.ValueReader) (hash.Hash, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return hash.Hash{}, err
+	}
+	return *rs.preRebaseWorkingAddr, nil
+}
+
+func (rs *RebaseState) OntoCommit(ctx context.Context, vr types.ValueReader) (*Commit, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return nil, err
+	}
+	return LoadCommitAddr(ctx, vr, *rs.ontoCommitAddr)
+}
+
+func (rs *RebaseState) RebasedHead(ctx context.Context, vr types.ValueReader) (*Commit, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return nil, err
+	}
+	return LoadCommitAddr(ctx, vr, *rs.rebasedHeadAddr)
+}
+
+func (rs *RebaseState) Branch(ctx context.Context, vr types.ValueReader) (string, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return "", err
+	}
+	return rs.branch, nil
+}
+
+func (rs *RebaseState) Plan(ctx context.Context, vr types.ValueReader) (string, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return "", err
+	}
+	return rs.plan, nil
+}
+
+func (rs *RebaseState) NextStep(ctx context.Context, vr types.ValueReader) (uint32, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return 0, err
+	}
+	return rs.nextStep, nil
+}
+
+func (rs *RebaseState) Stopped(ctx context.Context, vr types.ValueReader) (bool, error) {
+	if err := rs.loadIfNeeded(ctx, vr); err != nil {
+		return false, err
+	}
+	return rs.stopped, nil
+}
+
 type dsHead interface {
 	TypeName() string
 	Addr() hash.Hash
@@ -225,6 +377,24 @@ func (h serialWorkingSetHead) HeadWorkingSet() (*WorkingSetHead, error) {
 		}
 		*ret.MergeState.preMergeWorkingAddr = hash.New(mergeState.PreWorkingRootAddrBytes())
 		*ret.MergeState.fromCommitAddr = hash.New(mergeState.FromCommitAddrBytes())
+		for i := 0; i < mergeState.SchemaConflictTablesLength(); i++ {
+			ret.MergeState.schemaConflictTables = append(ret.MergeState.schemaConflictTables, string(mergeState.SchemaConflictTables(i)))
+		}
+	}
+	rebaseState := h.msg.RebaseState(nil)
+	if rebaseState != nil {
+		ret.RebaseState = &RebaseState{
+			preRebaseWorkingAddr: new(hash.Hash),
+			ontoCommitAddr:       new(hash.Hash),
+			rebasedHeadAddr:      new(hash.Hash),
+			branch:               string(rebaseState.Branch()),
+			plan:                 string(rebaseState.Plan()),
+			nextStep:             rebaseState.NextStep(),
+			stopped:              rebaseState.Stopped(),
+		}
+		*ret.RebaseState.preRebaseWorkingAddr = hash.New(rebaseState.PreWorkingRootAddrBytes())
+		*ret.RebaseState.ontoCommitAddr = hash.New(rebaseState.OntoCommitAddrBytes())
+		*ret.RebaseState.rebasedHeadAddr = hash.New(rebaseState.RebasedHeadAddrBytes())
 	}
 	return &ret, nil
 }
@@ -499,6 +669,17 @@ func (h nomsHead) HeadWorkingSet() (*WorkingSetHead, error) {
 		}
 	}
 
+	rebaseStateRef, ok, err := st.MaybeGet(rebaseStateField)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		r := rebaseStateRef.(types.Ref)
+		ret.RebaseState = &RebaseState{
+			nomsRebaseStateRef: &r,
+		}
+	}
+
 	return &ret, nil
 }
 
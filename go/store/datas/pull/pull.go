@@ -189,6 +189,23 @@ func getChunks(ctx context.Context, srcCS chunks.ChunkStore, batch hash.HashSlic
 
 type WalkAddrs func(chunks.Chunk, func(hash.Hash, bool) error) error
 
+// WalkAddrsExcluding returns a WalkAddrs that walks the same addresses as |waf|, except for the ones in |excluded|.
+// A pull using the returned WalkAddrs never fetches the excluded chunks, nor anything reachable only through them.
+// It is used by shallow clones to stop the pull at the commits beyond the requested depth.
+func WalkAddrsExcluding(waf WalkAddrs, excluded hash.HashSet) WalkAddrs {
+	if len(excluded) == 0 {
+		return waf
+	}
+	return func(c chunks.Chunk, cb func(hash.Hash, bool) error) error {
+		return waf(c, func(h hash.Hash, isLeaf bool) error {
+			if excluded.Has(h) {
+				return nil
+			}
+			return cb(h, isLeaf)
+		})
+	}
+}
+
 // put the chunks that were downloaded into the sink IN ORDER and at the same time gather up an ordered, uniquified list
 // of all the children of the chunks and add them to the list of the next level tree chunks.
 func putChunks(ctx context.Context, wah WalkAddrs, sinkCS chunks.ChunkStore, hashes hash.HashSlice, neededChunks map[hash.Hash]*chunks.Chunk, nextLevel hash.HashSet, uniqueOrdered hash.HashSlice) (hash.HashSlice, error) {
@@ -247,6 +247,40 @@ func (suite *PullSuite) TestPullMultiGeneration() {
 	suite.True(srcL.Equals(mustGetCommittedValue(suite.sinkVRW, v)))
 }
 
+// Source: C3(L5) -> C2(L4) -> C1(L2)
+//
+// Pulling C3 while excluding C1 brings over C3 and C2, but not C1 nor the values only it references.
+func (suite *PullSuite) TestPullExcludingCommits() {
+	srcL := buildListOfHeight(2, suite.sourceVRW)
+	c1 := suite.commitToSource(srcL, nil)
+	srcL = buildListOfHeight(4, suite.sourceVRW)
+	c2 := suite.commitToSource(srcL, []hash.Hash{c1})
+	srcL = buildListOfHeight(5, suite.sourceVRW)
+	c3 := suite.commitToSource(srcL, []hash.Hash{c2})
+
+	pt := startProgressTracker()
+
+	waf, err := types.WalkAddrsForChunkStore(suite.sourceCS)
+	suite.NoError(err)
+	waf = WalkAddrsExcluding(waf, hash.NewHashSet(c1))
+	err = Pull(context.Background(), suite.sourceCS, suite.sinkCS, waf, c3, pt.Ch)
+	suite.NoError(err)
+	pt.Validate(suite)
+
+	for _, h := range []hash.Hash{c3, c2} {
+		has, err := suite.sinkCS.Has(context.Background(), h)
+		suite.NoError(err)
+		suite.True(has)
+	}
+	has, err := suite.sinkCS.Has(context.Background(), c1)
+	suite.NoError(err)
+	suite.False(has)
+
+	v, err := suite.sinkVRW.ReadValue(context.Background(), c3)
+	suite.NoError(err)
+	suite.True(srcL.Equals(mustGetCommittedValue(suite.sinkVRW, v)))
+}
+
 // Source: -6-> C2(L5) -1-> N
 //               .  \  -5-> L4 -1-> N
 //                .          \ -4-> L3 -1-> N
@@ -30,12 +30,25 @@ const (
 	workingRootRefField = "workingRootRef"
 	stagedRootRefField  = "stagedRootRef"
 	mergeStateField     = "mergeState"
+	rebaseStateField    = "rebaseState"
 )
 
 const (
-	mergeStateName                 = "MergeState"
-	mergeStateCommitField          = "commit"
-	mergeStateWorkingPreMergeField = "workingPreMerge"
+	mergeStateName                      = "MergeState"
+	mergeStateCommitField               = "commit"
+	mergeStateWorkingPreMergeField      = "workingPreMerge"
+	mergeStateSchemaConflictTablesField = "schemaConflictTables"
+)
+
+const (
+	rebaseStateName                  = "RebaseState"
+	rebaseStateWorkingPreRebaseField = "workingPreRebase"
+	rebaseStateOntoField             = "onto"
+	rebaseStateRebasedHeadField      = "rebasedHead"
+	rebaseStateBranchField           = "branch"
+	rebaseStatePlanField             = "plan"
+	rebaseStateNextStepField         = "nextStep"
+	rebaseStateStoppedField          = "stopped"
 )
 
 const (
@@ -76,11 +89,22 @@ func workingSetMetaFromWorkingSetSt(workingSetSt types.Struct) (*WorkingSetMeta,
 
 var mergeStateTemplate = types.MakeStructTemplate(mergeStateName, []string{mergeStateCommitField, mergeStateWorkingPreMergeField})
 
+var rebaseStateTemplate = types.MakeStructTemplate(rebaseStateName, []string{
+	rebaseStateBranchField,
+	rebaseStateNextStepField,
+	rebaseStateOntoField,
+	rebaseStatePlanField,
+	rebaseStateRebasedHeadField,
+	rebaseStateStoppedField,
+	rebaseStateWorkingPreRebaseField,
+})
+
 type WorkingSetSpec struct {
 	Meta        *WorkingSetMeta
 	WorkingRoot types.Ref
 	StagedRoot  types.Ref
 	MergeState  *MergeState
+	RebaseState *RebaseState
 }
 
 // NewWorkingSet creates a new working set object.
@@ -95,13 +119,14 @@ type WorkingSetSpec struct {
 //   workingRootRef: R,
 //   stagedRootRef: R,
 //   mergeState: R,
+//   rebaseState: R,
 // }
 // ```
 // where M is a struct type and R is a ref type.
-func newWorkingSet(ctx context.Context, db *database, meta *WorkingSetMeta, workingRef, stagedRef types.Ref, mergeState *MergeState) (hash.Hash, types.Ref, error) {
+func newWorkingSet(ctx context.Context, db *database, meta *WorkingSetMeta, workingRef, stagedRef types.Ref, mergeState *MergeState, rebaseState *RebaseState) (hash.Hash, types.Ref, error) {
 	if db.Format().UsesFlatbuffers() {
 		stagedAddr := stagedRef.TargetHash()
-		data := workingset_flatbuffer(workingRef.TargetHash(), &stagedAddr, mergeState, meta)
+		data := workingset_flatbuffer(workingRef.TargetHash(), &stagedAddr, mergeState, rebaseState, meta)
 
 		r, err := db.WriteValue(ctx, types.SerialMessage(data))
 		if err != nil {
@@ -130,6 +155,10 @@ func newWorkingSet(ctx context.Context, db *database, meta *WorkingSetMeta, work
 		fields[mergeStateField] = *mergeState.nomsMergeStateRef
 	}
 
+	if rebaseState != nil {
+		fields[rebaseStateField] = *rebaseState.nomsRebaseStateRef
+	}
+
 	st, err := types.NewStruct(workingRef.Format(), workingSetName, fields)
 	if err != nil {
 		return hash.Hash{}, types.Ref{}, err
@@ -148,21 +177,52 @@ func newWorkingSet(ctx context.Context, db *database, meta *WorkingSetMeta, work
 	return ref.TargetHash(), ref, nil
 }
 
-func workingset_flatbuffer(working hash.Hash, staged *hash.Hash, mergeState *MergeState, meta *WorkingSetMeta) serial.Message {
+func workingset_flatbuffer(working hash.Hash, staged *hash.Hash, mergeState *MergeState, rebaseState *RebaseState, meta *WorkingSetMeta) serial.Message {
 	builder := flatbuffers.NewBuilder(1024)
 	workingoff := builder.CreateByteVector(working[:])
-	var stagedOff, mergeStateOff flatbuffers.UOffsetT
+	var stagedOff, mergeStateOff, rebaseStateOff flatbuffers.UOffsetT
 	if staged != nil {
 		stagedOff = builder.CreateByteVector((*staged)[:])
 	}
 	if mergeState != nil {
 		prerootaddroff := builder.CreateByteVector((*mergeState.preMergeWorkingAddr)[:])
 		fromaddroff := builder.CreateByteVector((*mergeState.fromCommitAddr)[:])
+		var tablesoff flatbuffers.UOffsetT
+		if len(mergeState.schemaConflictTables) > 0 {
+			offs := make([]flatbuffers.UOffsetT, len(mergeState.schemaConflictTables))
+			for i, name := range mergeState.schemaConflictTables {
+				offs[i] = builder.CreateString(name)
+			}
+			serial.MergeStateStartSchemaConflictTablesVector(builder, len(offs))
+			for i := len(offs) - 1; i >= 0; i-- {
+				builder.PrependUOffsetT(offs[i])
+			}
+			tablesoff = builder.EndVector(len(offs))
+		}
 		serial.MergeStateStart(builder)
 		serial.MergeStateAddPreWorkingRootAddr(builder, prerootaddroff)
 		serial.MergeStateAddFromCommitAddr(builder, fromaddroff)
+		if tablesoff != 0 {
+			serial.MergeStateAddSchemaConflictTables(builder, tablesoff)
+		}
 		mergeStateOff = serial.MergeStateEnd(builder)
 	}
+	if rebaseState != nil {
+		prerootaddroff := builder.CreateByteVector((*rebaseState.preRebaseWorkingAddr)[:])
+		branchoff := builder.CreateString(rebaseState.branch)
+		ontoaddroff := builder.CreateByteVector((*rebaseState.ontoCommitAddr)[:])
+		headaddroff := builder.CreateByteVector((*rebaseState.rebasedHeadAddr)[:])
+		planoff := builder.CreateString(rebaseState.plan)
+		serial.RebaseStateStart(builder)
+		serial.RebaseStateAddPreWorkingRootAddr(builder, prerootaddroff)
+		serial.RebaseStateAddBranch(builder, branchoff)
+		serial.RebaseStateAddOntoCommitAddr(builder, ontoaddroff)
+		serial.RebaseStateAddRebasedHeadAddr(builder, headaddroff)
+		serial.RebaseStateAddPlan(builder, planoff)
+		serial.RebaseStateAddNextStep(builder, rebaseState.nextStep)
+		serial.RebaseStateAddStopped(builder, rebaseState.stopped)
+		rebaseStateOff = serial.RebaseStateEnd(builder)
+	}
 
 	var nameOff, emailOff, descOff flatbuffers.UOffsetT
 	if meta != nil {
@@ -179,6 +239,9 @@ func workingset_flatbuffer(working hash.Hash, staged *hash.Hash, mergeState *Mer
 	if mergeStateOff != 0 {
 		serial.WorkingSetAddMergeState(builder, mergeStateOff)
 	}
+	if rebaseStateOff != 0 {
+		serial.WorkingSetAddRebaseState(builder, rebaseStateOff)
+	}
 	if meta != nil {
 		serial.WorkingSetAddName(builder, nameOff)
 		serial.WorkingSetAddEmail(builder, emailOff)
@@ -188,17 +251,40 @@ func workingset_flatbuffer(working hash.Hash, staged *hash.Hash, mergeState *Mer
 	return serial.FinishMessage(builder, serial.WorkingSetEnd(builder), []byte(serial.WorkingSetFileID))
 }
 
-func NewMergeState(ctx context.Context, vrw types.ValueReadWriter, preMergeWorking types.Ref, commit *Commit) (*MergeState, error) {
+// NewMergeState returns a new MergeState for a merge of |commit| into the working root |preMergeWorking|, whose tables
+// named |schemaConflictTables| have schemas that could not be merged.
+func NewMergeState(ctx context.Context, vrw types.ValueReadWriter, preMergeWorking types.Ref, commit *Commit, schemaConflictTables []string) (*MergeState, error) {
 	if vrw.Format().UsesFlatbuffers() {
 		ms := &MergeState{
-			preMergeWorkingAddr: new(hash.Hash),
-			fromCommitAddr:      new(hash.Hash),
+			preMergeWorkingAddr:  new(hash.Hash),
+			fromCommitAddr:       new(hash.Hash),
+			schemaConflictTables: schemaConflictTables,
 		}
 		*ms.preMergeWorkingAddr = preMergeWorking.TargetHash()
 		*ms.fromCommitAddr = commit.Addr()
 		return ms, nil
 	} else {
-		v, err := mergeStateTemplate.NewStruct(preMergeWorking.Format(), []types.Value{commit.NomsValue(), preMergeWorking})
+		var v types.Struct
+		var err error
+		if len(schemaConflictTables) == 0 {
+			v, err = mergeStateTemplate.NewStruct(preMergeWorking.Format(), []types.Value{commit.NomsValue(), preMergeWorking})
+		} else {
+			// the field is only written when there are schema conflicts, so that other merge states are unchanged
+			names := make([]types.Value, len(schemaConflictTables))
+			for i, name := range schemaConflictTables {
+				names[i] = types.String(name)
+			}
+			var tables types.List
+			tables, err = types.NewList(ctx, vrw, names...)
+			if err != nil {
+				return nil, err
+			}
+			v, err = types.NewStruct(preMergeWorking.Format(), mergeStateName, types.StructData{
+				mergeStateCommitField:               commit.NomsValue(),
+				mergeStateWorkingPreMergeField:      preMergeWorking,
+				mergeStateSchemaConflictTablesField: tables,
+			})
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -268,3 +354,61 @@ func workingSetMetaFromNomsSt(st types.Struct) (*WorkingSetMeta, error) {
 		Description: string(description.(types.String)),
 	}, nil
 }
+
+// NewRebaseState returns a new RebaseState for a rebase of |branch| onto the commit |onto|, which has replayed the steps
+// of |plan| before |nextStep| so far, with |rebasedHead| as the last replayed commit.
+func NewRebaseState(
+	ctx context.Context,
+	vrw types.ValueReadWriter,
+	preRebaseWorking types.Ref,
+	onto, rebasedHead *Commit,
+	branch, plan string,
+	nextStep uint32,
+	stopped bool,
+) (*RebaseState, error) {
+	if vrw.Format().UsesFlatbuffers() {
+		rs := &RebaseState{
+			preRebaseWorkingAddr: new(hash.Hash),
+			ontoCommitAddr:       new(hash.Hash),
+			rebasedHeadAddr:      new(hash.Hash),
+			branch:               branch,
+			plan:                 plan,
+			nextStep:             nextStep,
+			stopped:              stopped,
+		}
+		*rs.preRebaseWorkingAddr = preRebaseWorking.TargetHash()
+		*rs.ontoCommitAddr = onto.Addr()
+		*rs.rebasedHeadAddr = rebasedHead.Addr()
+		return rs, nil
+	}
+
+	ontoRef, err := types.NewRef(onto.NomsValue(), vrw.Format())
+	if err != nil {
+		return nil, err
+	}
+	rebasedHeadRef, err := types.NewRef(rebasedHead.NomsValue(), vrw.Format())
+	if err != nil {
+		return nil, err
+	}
+
+	// fields must be in the same order as the field names of the template, which are sorted
+	v, err := rebaseStateTemplate.NewStruct(preRebaseWorking.Format(), []types.Value{
+		types.String(branch),
+		types.Uint(nextStep),
+		ontoRef,
+		types.String(plan),
+		rebasedHeadRef,
+		types.Bool(stopped),
+		preRebaseWorking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ref, err := vrw.WriteValue(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	return &RebaseState{
+		nomsRebaseStateRef: &ref,
+	}, nil
+}
@@ -0,0 +1,262 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+// cacheFlushChunkCount is the number of chunks read from the remote store after which the chunks are persisted in the
+// cache.
+const cacheFlushChunkCount = 1 << 12
+
+// DefaultChunkCacheSize is the default size, in bytes, that the cache of a CachedChunkStore may grow to.
+const DefaultChunkCacheSize = 1 << 30
+
+var _ chunks.ChunkStore = (*CachedChunkStore)(nil)
+
+// CachedChunkStore is a ChunkStore whose chunks and root live in a remote ChunkStore, and which keeps the chunks it
+// reads from it in a local NomsBlockStore. Chunks are addressed by their content, so the cache never goes stale: it
+// only saves reading the same chunk from the remote store twice. The root, and whether the store has a chunk, are
+// always read from the remote store.
+//
+// The cache is emptied whenever it's persisted at a size over its limit, after which it fills up again with the
+// chunks that are read.
+type CachedChunkStore struct {
+	remote  chunks.ChunkStore
+	cache   *NomsBlockStore
+	maxSize uint64
+
+	mu      *sync.Mutex
+	pending int
+}
+
+// NewCachedChunkStore returns a ChunkStore over |remote| which caches the chunks it reads in |cache|, keeping the
+// size of |cache| around |maxSize| bytes.
+func NewCachedChunkStore(remote chunks.ChunkStore, cache *NomsBlockStore, maxSize uint64) *CachedChunkStore {
+	return &CachedChunkStore{
+		remote:  remote,
+		cache:   cache,
+		maxSize: maxSize,
+		mu:      &sync.Mutex{},
+	}
+}
+
+// Get the Chunk for the value of the hash in the store. If the hash is absent from the store EmptyChunk is returned.
+func (ccs *CachedChunkStore) Get(ctx context.Context, h hash.Hash) (chunks.Chunk, error) {
+	c, err := ccs.cache.Get(ctx, h)
+	if err != nil {
+		return chunks.EmptyChunk, err
+	}
+	if !c.IsEmpty() {
+		return c, nil
+	}
+
+	c, err = ccs.remote.Get(ctx, h)
+	if err != nil || c.IsEmpty() {
+		return c, err
+	}
+
+	err = ccs.fill(ctx, []chunks.Chunk{c})
+	if err != nil {
+		return chunks.EmptyChunk, err
+	}
+	return c, nil
+}
+
+// GetMany gets the Chunks with |hashes| from the store. On return, |foundChunks| will have been fully sent all chunks
+// which have been found. Any non-present chunks will silently be ignored.
+func (ccs *CachedChunkStore) GetMany(ctx context.Context, hashes hash.HashSet, found func(context.Context, *chunks.Chunk)) error {
+	mu := &sync.Mutex{}
+	notCached := hashes.Copy()
+	err := ccs.cache.GetMany(ctx, hashes, func(ctx context.Context, c *chunks.Chunk) {
+		func() {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(notCached, c.Hash())
+		}()
+
+		found(ctx, c)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(notCached) == 0 {
+		return nil
+	}
+
+	var read []chunks.Chunk
+	err = ccs.remote.GetMany(ctx, notCached, func(ctx context.Context, c *chunks.Chunk) {
+		func() {
+			mu.Lock()
+			defer mu.Unlock()
+			read = append(read, *c)
+		}()
+
+		found(ctx, c)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return ccs.fill(ctx, read)
+}
+
+// fill adds the chunks |read| from the remote store to the cache, and persists the cache once enough chunks were
+// added to it since it was last persisted.
+func (ccs *CachedChunkStore) fill(ctx context.Context, read []chunks.Chunk) error {
+	for _, c := range read {
+		err := ccs.cache.Put(ctx, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	ccs.mu.Lock()
+	ccs.pending += len(read)
+	flush := ccs.pending >= cacheFlushChunkCount
+	ccs.mu.Unlock()
+
+	if !flush {
+		return nil
+	}
+	return ccs.flush(ctx)
+}
+
+// flush persists the chunks added to the cache. The cache has no root of its own, so it's committed to the root it
+// already has. If that leaves the cache over its size limit, it's emptied.
+func (ccs *CachedChunkStore) flush(ctx context.Context) error {
+	ccs.mu.Lock()
+	ccs.pending = 0
+	ccs.mu.Unlock()
+
+	root, err := ccs.cache.Root(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = ccs.cache.Commit(ctx, root, root)
+	if err != nil {
+		return err
+	}
+
+	size, err := ccs.cache.Size(ctx)
+	if err != nil {
+		return err
+	}
+	if size <= ccs.maxSize {
+		return nil
+	}
+	return ccs.evict(ctx)
+}
+
+// evict empties the cache, dropping all of its table files.
+func (ccs *CachedChunkStore) evict(ctx context.Context) error {
+	err := ccs.cache.swapTables(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+	return ccs.cache.PruneTableFiles(ctx)
+}
+
+// Returns true iff the value at the address |h| is contained in the
+// store. The cache may hold chunks that the remote store doesn't, such as chunks that were put but never committed,
+// so only the remote store is asked.
+func (ccs *CachedChunkStore) Has(ctx context.Context, h hash.Hash) (bool, error) {
+	return ccs.remote.Has(ctx, h)
+}
+
+// Returns a new HashSet containing any members of |hashes| that are
+// absent from the store. Like Has, it only asks the remote store.
+func (ccs *CachedChunkStore) HasMany(ctx context.Context, hashes hash.HashSet) (absent hash.HashSet, err error) {
+	return ccs.remote.HasMany(ctx, hashes)
+}
+
+// Put writes |c| to the remote store, where it persists on the next call to Commit. It's cached right away, since
+// it's likely to be read again by the writer.
+func (ccs *CachedChunkStore) Put(ctx context.Context, c chunks.Chunk) error {
+	err := ccs.remote.Put(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	return ccs.fill(ctx, []chunks.Chunk{c})
+}
+
+// Returns the NomsVersion with which this ChunkSource is compatible.
+func (ccs *CachedChunkStore) Version() string {
+	return ccs.remote.Version()
+}
+
+// Rebase brings this ChunkStore into sync with the persistent storage's
+// current root.
+func (ccs *CachedChunkStore) Rebase(ctx context.Context) error {
+	return ccs.remote.Rebase(ctx)
+}
+
+// Root returns the root of the database as of the time the ChunkStore
+// was opened or the most recent call to Rebase.
+func (ccs *CachedChunkStore) Root(ctx context.Context) (hash.Hash, error) {
+	return ccs.remote.Root(ctx)
+}
+
+// Commit atomically attempts to persist all novel Chunks and update the
+// persisted root hash from last to current (or keeps it the same).
+// If last doesn't match the root in persistent storage, returns false.
+func (ccs *CachedChunkStore) Commit(ctx context.Context, current, last hash.Hash) (bool, error) {
+	success, err := ccs.remote.Commit(ctx, current, last)
+	if err != nil || !success {
+		return success, err
+	}
+
+	return true, ccs.flush(ctx)
+}
+
+// Stats may return some kind of struct that reports statistics about the
+// ChunkStore instance. The type is implementation-dependent, and impls
+// may return nil
+func (ccs *CachedChunkStore) Stats() interface{} {
+	return ccs.remote.Stats()
+}
+
+// StatsSummary may return a string containing summarized statistics for
+// this ChunkStore. It must return "Unsupported" if this operation is not
+// supported.
+func (ccs *CachedChunkStore) StatsSummary() string {
+	return ccs.remote.StatsSummary()
+}
+
+// Close tears down any resources in use by the implementation. After
+// Close(), the ChunkStore may not be used again. The chunks read since the
+// cache was last persisted are persisted first.
+func (ccs *CachedChunkStore) Close() error {
+	fErr := ccs.flush(context.Background())
+	cErr := ccs.cache.Close()
+	rErr := ccs.remote.Close()
+
+	if fErr != nil {
+		return fErr
+	} else if cErr != nil {
+		return cErr
+	}
+
+	return rErr
+}
@@ -0,0 +1,187 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/constants"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+func TestCachedChunkStore(t *testing.T) {
+	ctx := context.Background()
+	remoteDir, cacheDir := makeTempDir(t), makeTempDir(t)
+
+	remote, err := NewLocalStore(ctx, constants.FormatDefaultString, remoteDir, testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	chnks := make([]chunks.Chunk, 100)
+	for i := range chnks {
+		chnks[i] = chunks.NewChunk([]byte(fmt.Sprintf("chunk %d", i)))
+	}
+	for _, c := range chnks {
+		require.NoError(t, remote.Put(ctx, c))
+	}
+	success, err := remote.Commit(ctx, chnks[0].Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	cache, err := NewLocalStore(ctx, constants.FormatDefaultString, cacheDir, testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	ccs := NewCachedChunkStore(remote, cache, DefaultChunkCacheSize)
+
+	root, err := ccs.Root(ctx)
+	require.NoError(t, err)
+	require.Equal(t, chnks[0].Hash(), root)
+
+	// read the first half of the chunks, some one by one and some at once
+	for _, c := range chnks[:10] {
+		read, err := ccs.Get(ctx, c.Hash())
+		require.NoError(t, err)
+		require.Equal(t, c.Data(), read.Data())
+	}
+	expected := hashesForChunks(chnks, indexRange(0, 50))
+	received := foundHashes{}
+	require.NoError(t, ccs.GetMany(ctx, expected, received.found))
+	require.Equal(t, expected, hash.HashSet(received))
+
+	absent, err := ccs.HasMany(ctx, hashesForChunks(chnks, indexRange(0, 100)))
+	require.NoError(t, err)
+	require.Len(t, absent, 0)
+
+	// chunks written through the store reach the remote store on commit
+	written := chunks.NewChunk([]byte("written through the cache"))
+	require.NoError(t, ccs.Put(ctx, written))
+	success, err = ccs.Commit(ctx, written.Hash(), root)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.NoError(t, ccs.Close())
+
+	cache, err = NewLocalStore(ctx, constants.FormatDefaultString, cacheDir, testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	defer cache.Close()
+	absent, err = cache.HasMany(ctx, hashesForChunks(chnks, indexRange(0, 100)))
+	require.NoError(t, err)
+	require.Equal(t, hashesForChunks(chnks, indexRange(50, 100)), absent)
+	has, err := cache.Has(ctx, written.Hash())
+	require.NoError(t, err)
+	require.True(t, has)
+
+	remote, err = NewLocalStore(ctx, constants.FormatDefaultString, remoteDir, testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	defer remote.Close()
+	root, err = remote.Root(ctx)
+	require.NoError(t, err)
+	require.Equal(t, written.Hash(), root)
+	has, err = remote.Has(ctx, written.Hash())
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func TestCachedChunkStoreHasAsksRemote(t *testing.T) {
+	ctx := context.Background()
+
+	remote, err := NewLocalStore(ctx, constants.FormatDefaultString, makeTempDir(t), testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	cache, err := NewLocalStore(ctx, constants.FormatDefaultString, makeTempDir(t), testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	ccs := NewCachedChunkStore(remote, cache, DefaultChunkCacheSize)
+	defer ccs.Close()
+
+	// the cache holds a chunk that the remote store lacks, such as one it no longer has after a garbage collection
+	c := chunks.NewChunk([]byte("only in the cache"))
+	require.NoError(t, cache.Put(ctx, c))
+
+	has, err := ccs.Has(ctx, c.Hash())
+	require.NoError(t, err)
+	require.False(t, has)
+	absent, err := ccs.HasMany(ctx, hash.NewHashSet(c.Hash()))
+	require.NoError(t, err)
+	require.Equal(t, hash.NewHashSet(c.Hash()), absent)
+
+	// reads are still served from the cache
+	read, err := ccs.Get(ctx, c.Hash())
+	require.NoError(t, err)
+	require.Equal(t, c.Data(), read.Data())
+}
+
+func TestCachedChunkStoreSizeLimit(t *testing.T) {
+	ctx := context.Background()
+
+	remote, err := NewLocalStore(ctx, constants.FormatDefaultString, makeTempDir(t), testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	chnks := make([]chunks.Chunk, 100)
+	for i := range chnks {
+		chnks[i] = chunks.NewChunk([]byte(fmt.Sprintf("chunk %d", i)))
+		require.NoError(t, remote.Put(ctx, chnks[i]))
+	}
+	success, err := remote.Commit(ctx, chnks[0].Hash(), hash.Hash{})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	cache, err := NewLocalStore(ctx, constants.FormatDefaultString, makeTempDir(t), testMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	ccs := NewCachedChunkStore(remote, cache, 1024)
+	defer ccs.Close()
+
+	// a cache under its limit keeps the chunks read
+	for _, c := range chnks[:10] {
+		_, err = ccs.Get(ctx, c.Hash())
+		require.NoError(t, err)
+	}
+	require.NoError(t, ccs.flush(ctx))
+	size, err := cache.Size(ctx)
+	require.NoError(t, err)
+	require.NotZero(t, size)
+	absent, err := cache.HasMany(ctx, hashesForChunks(chnks, indexRange(0, 10)))
+	require.NoError(t, err)
+	require.Len(t, absent, 0)
+
+	// persisting it over the limit empties it
+	expected := hashesForChunks(chnks, indexRange(0, 100))
+	received := foundHashes{}
+	require.NoError(t, ccs.GetMany(ctx, expected, received.found))
+	require.Equal(t, expected, hash.HashSet(received))
+	require.NoError(t, ccs.flush(ctx))
+	size, err = cache.Size(ctx)
+	require.NoError(t, err)
+	require.Zero(t, size)
+	absent, err = cache.HasMany(ctx, expected)
+	require.NoError(t, err)
+	require.Equal(t, expected, absent)
+
+	// and it fills up again with the chunks read after that
+	read, err := ccs.Get(ctx, chnks[0].Hash())
+	require.NoError(t, err)
+	require.Equal(t, chnks[0].Data(), read.Data())
+	require.NoError(t, ccs.flush(ctx))
+	has, err := cache.Has(ctx, chnks[0].Hash())
+	require.NoError(t, err)
+	require.True(t, has)
+}
+
+func indexRange(start, end int) map[int]bool {
+	indexes := make(map[int]bool)
+	for i := start; i < end; i++ {
+		indexes[i] = true
+	}
+
+	return indexes
+}
@@ -22,8 +22,7 @@ import (
 	"io"
 	"sort"
 
-	"github.com/golang/snappy"
-
+	"github.com/dolthub/dolt/go/store/chunks"
 	nomshash "github.com/dolthub/dolt/go/store/hash"
 )
 
@@ -38,7 +37,8 @@ var ErrAlreadyFinished = errors.New("already Finished")
 
 var ErrChunkAlreadyWritten = errors.New("chunk already written")
 
-// CmpChunkTableWriter writes CompressedChunks to a table file
+// CmpChunkTableWriter writes CompressedChunks to a table file. Chunks that aren't compressed the way the table is are
+// recompressed as they're added.
 type CmpChunkTableWriter struct {
 	sink                  *HashingByteSink
 	totalCompressedData   uint64
@@ -46,26 +46,43 @@ type CmpChunkTableWriter struct {
 	prefixes              prefixIndexSlice // TODO: This is in danger of exploding memory
 	blockAddr             *addr
 	chunkHashes           nomshash.HashSet
+
+	codec tableCodec
+	// dict is the dictionary that the chunks of zstd tables are compressed with, once it has been trained.
+	dict    *Dictionary
+	trained bool
+	// pending holds the first chunks added to a zstd table, which its dictionary is trained on before they're written.
+	pending      []chunks.Chunk
+	pendingBytes int
 }
 
 // NewCmpChunkTableWriter creates a new CmpChunkTableWriter instance with a default ByteSink
 func NewCmpChunkTableWriter(tempDir string) (*CmpChunkTableWriter, error) {
+	return newCmpChunkTableWriter(tempDir, defaultTableCodec)
+}
+
+func newCmpChunkTableWriter(tempDir string, codec tableCodec) (*CmpChunkTableWriter, error) {
 	s, err := NewBufferedFileByteSink(tempDir, defaultTableSinkBlockSize, defaultChBufferSize)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &CmpChunkTableWriter{NewHashingByteSink(s), 0, 0, nil, nil, nomshash.NewHashSet()}, nil
+	return &CmpChunkTableWriter{
+		sink:        NewHashingByteSink(s),
+		chunkHashes: nomshash.NewHashSet(),
+		codec:       codec,
+	}, nil
 }
 
-// Size returns the number of compressed chunks that have been added
+// Size returns the number of records that have been added, which includes the dictionary of a zstd table once it
+// has been trained.
 func (tw *CmpChunkTableWriter) Size() int {
-	return len(tw.prefixes)
+	return len(tw.prefixes) + len(tw.pending)
 }
 
 func (tw *CmpChunkTableWriter) ChunkCount() uint32 {
-	return uint32(len(tw.prefixes))
+	return uint32(tw.Size())
 }
 
 // Gets the size of the entire table file in bytes
@@ -89,29 +106,124 @@ func (tw *CmpChunkTableWriter) AddCmpChunk(c CompressedChunk) error {
 	}
 
 	tw.chunkHashes.Insert(c.H)
-	uncmpLen, err := snappy.DecodedLen(c.CompressedData)
+
+	if tw.codec == zstdCodec && !tw.trained {
+		chk, err := c.ToChunk()
+
+		if err != nil {
+			return err
+		}
+
+		tw.pending = append(tw.pending, chk)
+		tw.pendingBytes += len(chk.Data())
+
+		if tw.pendingBytes >= dictionarySampleSize {
+			return tw.trainDictionary()
+		}
+
+		return nil
+	}
+
+	if !tw.compressedLikeTable(c) {
+		chk, err := c.ToChunk()
+
+		if err != nil {
+			return err
+		}
+
+		c, err = compressChunk(chk, tw.codec, tw.dict)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	uncmpLen, err := decodedLen(c.CompressedData, c.dict)
 
 	if err != nil {
 		return err
 	}
 
-	fullLen := len(c.FullCompressedChunk)
-	_, err = tw.sink.Write(c.FullCompressedChunk)
+	return tw.writeRecord(addr(c.H), c.FullCompressedChunk, uint64(uncmpLen))
+}
+
+// compressedLikeTable returns whether |c| is compressed the way the chunks of the table are, and can be written to it
+// as it is.
+func (tw *CmpChunkTableWriter) compressedLikeTable(c CompressedChunk) bool {
+	if !isZstdFrame(c.CompressedData) {
+		return tw.codec == snappyCodec
+	}
+	if tw.codec != zstdCodec {
+		return false
+	}
+	if tw.dict == nil {
+		id, err := zstdDictionaryID(c.CompressedData)
+		return err == nil && id == 0
+	}
+	return c.dict != nil && c.dict.a == tw.dict.a
+}
+
+// trainDictionary trains the dictionary of a zstd table on the chunks that are pending, and writes them along with it.
+func (tw *CmpChunkTableWriter) trainDictionary() error {
+	samples := make([][]byte, len(tw.pending))
+	for i, chk := range tw.pending {
+		samples[i] = chk.Data()
+	}
+
+	tw.dict = trainDictionary(samples)
+	tw.trained = true
+
+	if tw.dict != nil {
+		record, err := tw.dict.record()
+
+		if err != nil {
+			return err
+		}
+
+		err = tw.writeRecord(tw.dict.a, record, 0)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, chk := range tw.pending {
+		c, err := compressChunk(chk, zstdCodec, tw.dict)
+
+		if err != nil {
+			return err
+		}
+
+		err = tw.writeRecord(addr(c.H), c.FullCompressedChunk, uint64(len(chk.Data())))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	tw.pending = nil
+	tw.pendingBytes = 0
+
+	return nil
+}
+
+// writeRecord writes the table record |record|, which holds |uncmpLen| bytes of chunk data.
+func (tw *CmpChunkTableWriter) writeRecord(a addr, record []byte, uncmpLen uint64) error {
+	_, err := tw.sink.Write(record)
 
 	if err != nil {
 		return err
 	}
 
-	tw.totalCompressedData += uint64(len(c.CompressedData))
-	tw.totalUncompressedData += uint64(uncmpLen)
+	tw.totalCompressedData += uint64(len(record) - checksumSize)
+	tw.totalUncompressedData += uncmpLen
 
-	a := addr(c.H)
 	// Stored in insertion order
 	tw.prefixes = append(tw.prefixes, prefixIndexRec{
 		a.Prefix(),
 		a[addrPrefixSize:],
 		uint32(len(tw.prefixes)),
-		uint32(fullLen),
+		uint32(len(record)),
 	})
 
 	return nil
@@ -123,6 +235,14 @@ func (tw *CmpChunkTableWriter) Finish() (string, error) {
 		return "", ErrAlreadyFinished
 	}
 
+	if tw.codec == zstdCodec && !tw.trained {
+		err := tw.trainDictionary()
+
+		if err != nil {
+			return "", err
+		}
+	}
+
 	blockHash, err := tw.writeIndex()
 
 	if err != nil {
@@ -221,22 +341,10 @@ func (tw *CmpChunkTableWriter) writeIndex() (hash.Hash, error) {
 }
 
 func (tw *CmpChunkTableWriter) writeFooter() error {
-	// chunk count
-	err := binary.Write(tw.sink, binary.BigEndian, uint32(len(tw.prefixes)))
-
-	if err != nil {
-		return err
-	}
-
-	// total uncompressed chunk data
-	err = binary.Write(tw.sink, binary.BigEndian, tw.totalUncompressedData)
-
-	if err != nil {
-		return err
-	}
+	var footer [footerSize]byte
+	writeFooter(footer[:], uint32(len(tw.prefixes)), tw.totalUncompressedData, tw.codec)
 
-	// magic number
-	_, err = tw.sink.Write([]byte(magicNumber))
+	_, err := tw.sink.Write(footer[:])
 
 	if err != nil {
 		return err
@@ -81,6 +81,65 @@ func TestCmpChunkTableWriter(t *testing.T) {
 	compareContentsOfTables(t, ctx, hashes, tr, outputTR)
 }
 
+func TestCmpChunkTableWriterRecompresses(t *testing.T) {
+	ctx := context.Background()
+
+	var snappyChunks []CompressedChunk
+	hashes := make(hash.HashSet)
+	for _, data := range dictionaryTestChunks(100) {
+		c := chunks.NewChunk(data)
+		snappyChunks = append(snappyChunks, ChunkToCompressedChunk(c))
+		hashes.Insert(c.Hash())
+	}
+
+	writeTable := func(codec tableCodec, cmpChunks []CompressedChunk) tableReader {
+		tw, err := newCmpChunkTableWriter("", codec)
+		require.NoError(t, err)
+		for _, c := range cmpChunks {
+			require.NoError(t, tw.AddCmpChunk(c))
+		}
+		_, err = tw.Finish()
+		require.NoError(t, err)
+
+		output := bytes.NewBuffer(nil)
+		require.NoError(t, tw.Flush(output))
+
+		ti, err := parseTableIndexByCopy(output.Bytes(), &noopQuotaProvider{})
+		require.NoError(t, err)
+		assert.Equal(t, codec, ti.Codec())
+		assert.Equal(t, uint32(tw.Size()), ti.ChunkCount())
+		tr, err := newTableReader(ti, tableReaderAtFromBytes(output.Bytes()), fileBlockSize)
+		require.NoError(t, err)
+		return tr
+	}
+
+	zstdTR := writeTable(zstdCodec, snappyChunks)
+	// the chunks are compressed with the dictionary trained for the table
+	assert.Equal(t, uint32(len(snappyChunks)+1), zstdTR.chunkCount)
+
+	expected := make(map[hash.Hash][]byte)
+	for _, c := range snappyChunks {
+		chk, err := c.ToChunk()
+		require.NoError(t, err)
+		expected[chk.Hash()] = chk.Data()
+	}
+	actual, err := readAllChunks(ctx, hashes, zstdTR)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	var zstdChunks []CompressedChunk
+	eg, egCtx := errgroup.WithContext(ctx)
+	_, err = zstdTR.getManyCompressed(egCtx, eg, toGetRecords(hashes), func(ctx context.Context, c CompressedChunk) { zstdChunks = append(zstdChunks, c) }, &Stats{})
+	require.NoError(t, err)
+	require.NoError(t, eg.Wait())
+
+	snappyTR := writeTable(snappyCodec, zstdChunks)
+	assert.Equal(t, uint32(len(zstdChunks)), snappyTR.chunkCount)
+	actual, err = readAllChunks(ctx, hashes, snappyTR)
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
 func compareContentsOfTables(t *testing.T, ctx context.Context, hashes hash.HashSet, expectedRd, actualRd tableReader) {
 	expected, err := readAllChunks(ctx, hashes, expectedRd)
 	require.NoError(t, err)
@@ -0,0 +1,191 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// tableCodec is the compression that the chunk records of a table file are written with. Table files written in the
+// original format don't store one, and are compressed with snappy. Every chunk record says which codec it's
+// compressed with, so table files that store a codec can also hold records compressed with snappy, which is what
+// conjoining snappy and zstd table files produces.
+type tableCodec byte
+
+const (
+	snappyCodec tableCodec = 1
+	zstdCodec   tableCodec = 2
+)
+
+func (c tableCodec) String() string {
+	switch c {
+	case snappyCodec:
+		return "snappy"
+	case zstdCodec:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown codec %d", byte(c))
+	}
+}
+
+func (c tableCodec) valid() bool {
+	return c == snappyCodec || c == zstdCodec
+}
+
+// EnvTableFileCodec is the environment variable that selects the codec that new table files are written with, either
+// "snappy" or "zstd". Table files are written with snappy by default, since versions of Dolt that predate zstd table
+// files can't read them.
+const EnvTableFileCodec = "DOLT_TABLE_FILE_CODEC"
+
+// defaultTableCodec is the codec that new table files are written with.
+var defaultTableCodec = snappyCodec
+
+func init() {
+	if v, ok := os.LookupEnv(EnvTableFileCodec); ok && strings.EqualFold(strings.TrimSpace(v), zstdCodec.String()) {
+		defaultTableCodec = zstdCodec
+	}
+}
+
+// zstdMagic is the magic number that zstd frames begin with. A valid snappy block never begins with it, since its
+// second byte is the tag of a copy and snappy blocks begin with a literal, so chunk records are compressed with zstd
+// exactly when they begin with it.
+const zstdMagic = "\x28\xb5\x2f\xfd"
+
+// zstdMaxOverhead bounds the bytes that zstd adds to incompressible data, beyond the 1/256th of it that block
+// headers can take up.
+const zstdMaxOverhead = 128
+
+// ErrMissingDictionary is returned when decompressing a chunk record that was compressed with a dictionary that
+// hasn't been loaded from its table file.
+var ErrMissingDictionary = errors.New("chunk is compressed with a dictionary that is not available")
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+// plainZstd returns the encoder and decoder of the zstd chunk records that aren't compressed with a dictionary.
+func plainZstd() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil, zstd.WithEncoderCRC(false))
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+func isZstdFrame(data []byte) bool {
+	return len(data) >= len(zstdMagic) && string(data[:len(zstdMagic)]) == zstdMagic
+}
+
+// zstdDictionaryID returns the id of the dictionary that the zstd frame |data| was compressed with, or 0 if it
+// wasn't compressed with one.
+func zstdDictionaryID(data []byte) (uint32, error) {
+	var h zstd.Header
+	if err := h.Decode(data); err != nil {
+		return 0, err
+	}
+	return h.DictionaryID, nil
+}
+
+// zstdMaxEncodedLen returns the most bytes that |n| bytes of chunk data can be compressed to with zstd.
+func zstdMaxEncodedLen(n int) int {
+	return n + n>>8 + zstdMaxOverhead
+}
+
+// compress appends |data| compressed with |codec| to |dst|. zstd data is compressed with |dict| if it isn't nil.
+func compress(dst, data []byte, codec tableCodec, dict *Dictionary) ([]byte, error) {
+	switch codec {
+	case snappyCodec:
+		return append(dst, snappy.Encode(nil, data)...), nil
+	case zstdCodec:
+		if dict != nil {
+			enc, err := dict.encoder()
+			if err != nil {
+				return nil, err
+			}
+			return enc.EncodeAll(data, dst), nil
+		}
+		enc, _, err := plainZstd()
+		if err != nil {
+			return nil, err
+		}
+		return enc.EncodeAll(data, dst), nil
+	default:
+		return nil, fmt.Errorf("cannot compress chunks with %s", codec)
+	}
+}
+
+// decompress returns the chunk data of the compressed chunk record data |data|, which is decompressed with |dict| if
+// it's a zstd frame that was compressed with a dictionary.
+func decompress(data []byte, dict *Dictionary) ([]byte, error) {
+	if !isZstdFrame(data) {
+		return snappy.Decode(nil, data)
+	}
+
+	id, err := zstdDictionaryID(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == 0 {
+		_, dec, err := plainZstd()
+		if err != nil {
+			return nil, err
+		}
+		return dec.DecodeAll(data, nil)
+	}
+
+	if dict == nil || dict.ID() != id {
+		return nil, ErrMissingDictionary
+	}
+	dec, err := dict.decoder()
+	if err != nil {
+		return nil, err
+	}
+	return dec.DecodeAll(data, nil)
+}
+
+// decodedLen returns the length of the chunk data of the compressed chunk record data |data|.
+func decodedLen(data []byte, dict *Dictionary) (int, error) {
+	if !isZstdFrame(data) {
+		return snappy.DecodedLen(data)
+	}
+
+	var h zstd.Header
+	if err := h.Decode(data); err != nil {
+		return 0, err
+	}
+	if h.HasFCS {
+		return int(h.FrameContentSize), nil
+	}
+
+	decoded, err := decompress(data, dict)
+	if err != nil {
+		return 0, err
+	}
+	return len(decoded), nil
+}
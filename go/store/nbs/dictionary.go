@@ -0,0 +1,319 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+/*
+   The chunk records of a zstd table file can be compressed with a dictionary that was trained on the chunks of the
+   file. Dictionaries are stored in the file as records of their own, which are indexed like chunk records under an
+   address that isn't the hash of a chunk:
+
+   Dictionary Address:
+   +----------------------------+------------------------------------+
+   | (8) Dictionary Addr Prefix | (12) Prefix of the Dictionary Hash |
+   +----------------------------+------------------------------------+
+
+     -The Dictionary Addr Prefix is all zeros. Chunk addresses are hashes, so they are vanishingly unlikely to share it.
+     -The Dictionary Hash is the hash of the dictionary's content.
+     -The zstd frames compressed with a dictionary refer to it by the first 4 bytes of its Dictionary Hash.
+
+   Dictionary Record:
+   +-------------------------------------+----------------+
+   | (zstd frame) Raw Dictionary Content | (Uint32) CRC32 |
+   +-------------------------------------+----------------+
+
+   Since dictionaries are records of the files that use them, conjoining table files keeps each chunk record in the
+   same file as its dictionary, and the download locations of chunks in a table file can include the range of its
+   dictionaries.
+*/
+
+const (
+	dictionaryAddrPrefix = uint64(0)
+
+	// maxDictionarySize is the largest dictionary trained for a table file.
+	maxDictionarySize = 32 * 1024
+	// minDictionarySize is the smallest dictionary worth training for a table file.
+	minDictionarySize = 1024
+	// dictionarySampleSize is how much chunk data dictionaries are trained on.
+	dictionarySampleSize = 4 * 1024 * 1024
+	// minDictionarySamples is the fewest chunks that dictionaries are trained on.
+	minDictionarySamples = 16
+)
+
+var errDictionaryAddrMismatch = errors.New("dictionary record does not match its address")
+
+// Dictionary is a zstd dictionary stored in a table file, which chunk records of the file are compressed with.
+type Dictionary struct {
+	a       addr
+	content []byte
+
+	encOnce sync.Once
+	enc     *zstd.Encoder
+	encErr  error
+
+	decOnce sync.Once
+	dec     *zstd.Decoder
+	decErr  error
+}
+
+func newDictionary(content []byte) *Dictionary {
+	h := hash.Of(content)
+
+	var a addr
+	binary.BigEndian.PutUint64(a[:], dictionaryAddrPrefix)
+	copy(a[addrPrefixSize:], h[:addrSuffixSize])
+
+	return &Dictionary{a: a, content: content}
+}
+
+// NewDictionary returns the Dictionary stored in the table file record |record|, which is indexed under |h|.
+func NewDictionary(h hash.Hash, record []byte) (*Dictionary, error) {
+	cmp, err := NewCompressedChunk(h, record)
+	if err != nil {
+		return nil, err
+	}
+	content, err := decompress(cmp.CompressedData, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	d := newDictionary(content)
+	if d.a != addr(h) {
+		return nil, errDictionaryAddrMismatch
+	}
+	return d, nil
+}
+
+// IsDictionary returns whether |h| is the address of a dictionary record of a table file, rather than of a chunk.
+func IsDictionary(h hash.Hash) bool {
+	return isDictionaryAddr(addr(h))
+}
+
+func isDictionaryAddr(a addr) bool {
+	return a.Prefix() == dictionaryAddrPrefix
+}
+
+// dictionaryRanges returns the ranges of the dictionary records of the table file of |index|.
+func dictionaryRanges(index tableIndex) (map[hash.Hash]Range, error) {
+	if index.Codec() != zstdCodec {
+		return nil, nil
+	}
+
+	ranges := make(map[hash.Hash]Range)
+	// dictionaries are indexed first, since their addresses have the lowest prefix
+	for i := uint32(0); i < index.ChunkCount() && index.PrefixAt(i) == dictionaryAddrPrefix; i++ {
+		var a addr
+		e, err := index.IndexEntry(i, &a)
+		if err != nil {
+			return nil, err
+		}
+		ranges[hash.Hash(a)] = Range{Offset: e.Offset(), Length: e.Length()}
+	}
+	return ranges, nil
+}
+
+// DictionaryID returns the id that the zstd frames compressed with the dictionary at |h| refer to it by.
+func DictionaryID(h hash.Hash) uint32 {
+	return dictionaryID(addr(h))
+}
+
+func dictionaryID(a addr) uint32 {
+	id := binary.BigEndian.Uint32(a[addrPrefixSize:])
+	if id == 0 {
+		// zstd frames with a dictionary id of 0 don't use a dictionary
+		return 1
+	}
+	return id
+}
+
+// DictionaryResolver returns the dictionary of a table file that zstd frames refer to by |id|.
+type DictionaryResolver func(id uint32) (*Dictionary, error)
+
+// Hash returns the address that the dictionary is indexed under in its table file.
+func (d *Dictionary) Hash() hash.Hash {
+	return hash.Hash(d.a)
+}
+
+// ID returns the id that zstd frames compressed with the dictionary refer to it by.
+func (d *Dictionary) ID() uint32 {
+	return dictionaryID(d.a)
+}
+
+// record returns the dictionary encoded as a table file record.
+func (d *Dictionary) record() ([]byte, error) {
+	compressed, err := compress(nil, d.content, zstdCodec, nil)
+	if err != nil {
+		return nil, err
+	}
+	return appendChecksum(compressed), nil
+}
+
+func (d *Dictionary) encoder() (*zstd.Encoder, error) {
+	d.encOnce.Do(func() {
+		d.enc, d.encErr = zstd.NewWriter(nil, zstd.WithEncoderCRC(false), zstd.WithEncoderDictRaw(d.ID(), d.content))
+	})
+	return d.enc, d.encErr
+}
+
+func (d *Dictionary) decoder() (*zstd.Decoder, error) {
+	d.decOnce.Do(func() {
+		d.dec, d.decErr = zstd.NewReader(nil, zstd.WithDecoderDictRaw(d.ID(), d.content))
+	})
+	return d.dec, d.decErr
+}
+
+// trainDictionary returns a dictionary for compressing chunks like |samples|, or nil if there isn't enough chunk
+// data to train one that's worth storing.
+func trainDictionary(samples [][]byte) *Dictionary {
+	if len(samples) < minDictionarySamples {
+		return nil
+	}
+
+	var total int
+	for _, s := range samples {
+		total += len(s)
+	}
+	size := total / 16
+	if size > maxDictionarySize {
+		size = maxDictionarySize
+	}
+	if size < minDictionarySize {
+		return nil
+	}
+
+	content := trainRawDictionary(samples, size)
+	if len(content) < minDictionarySize {
+		return nil
+	}
+	return newDictionary(content)
+}
+
+const (
+	// dictionaryDmerSize is the length of the substrings that segments are scored by.
+	dictionaryDmerSize = 8
+	// dictionarySegmentSize is the length of the segments of the samples that dictionaries are built from.
+	dictionarySegmentSize = 64
+	dictionaryDmerBits    = 20
+)
+
+type dictionarySegment struct {
+	sample, start, end int
+	score              uint64
+}
+
+// trainRawDictionary builds a raw content dictionary of at most |size| bytes out of the segments of |samples| that
+// contain the substrings shared by the most samples. Like zstd's COVER algorithm, it splits the samples into epochs,
+// one per segment of the dictionary, and picks the best segment of each epoch, discounting the substrings of the
+// segments already picked. The best segments go at the end of the dictionary, where matches are cheapest to encode.
+func trainRawDictionary(samples [][]byte, size int) []byte {
+	// the number of samples that each substring occurs in, by the hash of the substring
+	freqs := make([]uint32, 1<<dictionaryDmerBits)
+	stamps := make([]uint32, 1<<dictionaryDmerBits)
+	stamp := uint32(0)
+	for _, s := range samples {
+		stamp++
+		for i := 0; i+dictionaryDmerSize <= len(s); i++ {
+			b := dmerBucket(s[i:])
+			if stamps[b] != stamp {
+				stamps[b] = stamp
+				freqs[b]++
+			}
+		}
+	}
+
+	var segments []dictionarySegment
+	for i, s := range samples {
+		for start := 0; start+dictionaryDmerSize <= len(s); start += dictionarySegmentSize {
+			end := start + dictionarySegmentSize
+			if end > len(s) {
+				end = len(s)
+			}
+			segments = append(segments, dictionarySegment{sample: i, start: start, end: end})
+		}
+	}
+
+	epochs := size / dictionarySegmentSize
+	if epochs == 0 || len(segments) == 0 {
+		return nil
+	}
+	epochLen := (len(segments) + epochs - 1) / epochs
+
+	var picked []dictionarySegment
+	for e := 0; e*epochLen < len(segments); e++ {
+		epoch := segments[e*epochLen:]
+		if len(epoch) > epochLen {
+			epoch = epoch[:epochLen]
+		}
+
+		best := -1
+		for i := range epoch {
+			seg := &epoch[i]
+			stamp++
+			seg.score = 0
+			data := samples[seg.sample][seg.start:seg.end]
+			for j := 0; j+dictionaryDmerSize <= len(data); j++ {
+				b := dmerBucket(data[j:])
+				if stamps[b] != stamp {
+					stamps[b] = stamp
+					if freqs[b] > 1 {
+						seg.score += uint64(freqs[b])
+					}
+				}
+			}
+			if seg.score > 0 && (best < 0 || seg.score > epoch[best].score) {
+				best = i
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		seg := epoch[best]
+		picked = append(picked, seg)
+		data := samples[seg.sample][seg.start:seg.end]
+		for j := 0; j+dictionaryDmerSize <= len(data); j++ {
+			freqs[dmerBucket(data[j:])] = 0
+		}
+	}
+
+	sort.SliceStable(picked, func(i, j int) bool {
+		return picked[i].score < picked[j].score
+	})
+
+	content := make([]byte, 0, size)
+	for _, seg := range picked {
+		data := samples[seg.sample][seg.start:seg.end]
+		if len(content)+len(data) > size {
+			break
+		}
+		content = append(content, data...)
+	}
+	return content
+}
+
+func dmerBucket(b []byte) uint32 {
+	const prime = 0xcf1bbcdcb7a56463
+	return uint32((binary.LittleEndian.Uint64(b) * prime) >> (64 - dictionaryDmerBits))
+}
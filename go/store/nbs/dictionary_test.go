@@ -0,0 +1,104 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dictionaryTestChunks returns |n| chunks that share enough structure to train a dictionary on.
+func dictionaryTestChunks(n int) [][]byte {
+	chunks := make([][]byte, n)
+	for i := range chunks {
+		chunks[i] = []byte(fmt.Sprintf(`{"id": %d, "name": "customer %d", "email": "customer%d@example.com", `+
+			`"address": {"street": "%d Main Street", "city": "Springfield", "state": "Oregon", "zip": "%05d"}, `+
+			`"tags": ["wholesale", "priority", "net-30"], "balance": %d.%02d}`, i, i, i, i*7, i*13, i*31, i%100))
+	}
+	return chunks
+}
+
+func TestTrainDictionary(t *testing.T) {
+	samples := dictionaryTestChunks(200)
+	dict := trainDictionary(samples)
+	require.NotNil(t, dict)
+	assert.True(t, IsDictionary(dict.Hash()))
+	assert.True(t, len(dict.content) >= minDictionarySize)
+	assert.True(t, len(dict.content) <= maxDictionarySize)
+
+	var plainLen, dictLen int
+	for _, s := range samples {
+		plain, err := compress(nil, s, zstdCodec, nil)
+		require.NoError(t, err)
+		plainLen += len(plain)
+
+		compressed, err := compress(nil, s, zstdCodec, dict)
+		require.NoError(t, err)
+		dictLen += len(compressed)
+
+		id, err := zstdDictionaryID(compressed)
+		require.NoError(t, err)
+		assert.Equal(t, dict.ID(), id)
+
+		decompressed, err := decompress(compressed, dict)
+		require.NoError(t, err)
+		assert.Equal(t, s, decompressed)
+
+		n, err := decodedLen(compressed, dict)
+		require.NoError(t, err)
+		assert.Equal(t, len(s), n)
+
+		_, err = decompress(compressed, nil)
+		assert.Equal(t, ErrMissingDictionary, err)
+	}
+	assert.Less(t, dictLen, plainLen)
+}
+
+func TestTrainDictionaryTooFewSamples(t *testing.T) {
+	assert.Nil(t, trainDictionary(dictionaryTestChunks(minDictionarySamples-1)))
+	assert.Nil(t, trainDictionary([][]byte{[]byte("a"), []byte("b")}))
+}
+
+func TestDictionaryRecord(t *testing.T) {
+	dict := trainDictionary(dictionaryTestChunks(200))
+	require.NotNil(t, dict)
+
+	record, err := dict.record()
+	require.NoError(t, err)
+
+	loaded, err := NewDictionary(dict.Hash(), record)
+	require.NoError(t, err)
+	assert.Equal(t, dict.Hash(), loaded.Hash())
+	assert.Equal(t, dict.ID(), loaded.ID())
+	assert.Equal(t, dict.content, loaded.content)
+
+	other := newDictionary([]byte("some other dictionary content"))
+	_, err = NewDictionary(other.Hash(), record)
+	assert.Equal(t, errDictionaryAddrMismatch, err)
+}
+
+func TestDecompressSnappy(t *testing.T) {
+	data := []byte("snappy chunk records decompress without a dictionary")
+	compressed, err := compress(nil, data, snappyCodec, nil)
+	require.NoError(t, err)
+	assert.False(t, isZstdFrame(compressed))
+
+	decompressed, err := decompress(compressed, nil)
+	require.NoError(t, err)
+	assert.Equal(t, data, decompressed)
+}
@@ -30,6 +30,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/dolthub/dolt/go/libraries/utils/file"
 	"github.com/dolthub/dolt/go/store/d"
@@ -50,7 +51,13 @@ type fsTablePersister struct {
 }
 
 func (ftp *fsTablePersister) Open(ctx context.Context, name addr, chunkCount uint32, stats *Stats) (chunkSource, error) {
-	return newFileTableReader(ftp.dir, name, chunkCount, ftp.q, ftp.fc)
+	t1 := time.Now()
+	cs, err := newFileTableReader(ftp.dir, name, chunkCount, ftp.q, ftp.fc)
+	if err == nil && stats != nil {
+		stats.IndexReadLatency.SampleTimeSince(t1)
+		stats.IndexBytesPerRead.Sample(uint64(indexSize(chunkCount) + footerSize))
+	}
+	return cs, err
 }
 
 func (ftp *fsTablePersister) Persist(ctx context.Context, mt *memTable, haver chunkReader, stats *Stats) (chunkSource, error) {
@@ -225,6 +232,10 @@ func (ftp *fsTablePersister) PruneTableFiles(ctx context.Context, contents manif
 			continue // file is referenced in the manifest
 		}
 
+		if addy == journalAddr {
+			continue // the chunk journal drops its own records
+		}
+
 		err = file.Remove(filePath)
 		if err != nil {
 			ea.add(filePath, err)
@@ -237,3 +248,34 @@ func (ftp *fsTablePersister) PruneTableFiles(ctx context.Context, contents manif
 
 	return nil
 }
+
+// removeTableFiles deletes the table files named by |addrs|, leaving every other file in the directory in place.
+func (ftp *fsTablePersister) removeTableFiles(ctx context.Context, addrs []addr) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	err := ftp.fc.ShrinkCache()
+
+	if err != nil {
+		return err
+	}
+
+	ea := make(gcErrAccum)
+	for _, a := range addrs {
+		if a == journalAddr {
+			continue // the chunk journal drops its own records
+		}
+		filePath := path.Join(ftp.dir, a.String())
+		err = file.Remove(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			ea.add(filePath, err)
+		}
+	}
+
+	if !ea.isEmpty() {
+		return ea
+	}
+
+	return nil
+}
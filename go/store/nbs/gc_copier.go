@@ -20,6 +20,8 @@ import (
 	"os"
 	"path"
 	"strings"
+
+	"github.com/dolthub/dolt/go/store/hash"
 )
 
 type gcErrAccum map[string]error
@@ -55,10 +57,19 @@ func newGarbageCollectionCopier() (*gcCopier, error) {
 	return &gcCopier{writer}, nil
 }
 
+// addChunk copies |c| into the new table file, unless it was copied already. The same chunk can be found more than
+// once, when it was put into the store again after it was persisted.
 func (gcc *gcCopier) addChunk(ctx context.Context, c CompressedChunk) error {
+	if gcc.hasChunk(c.H) {
+		return nil
+	}
 	return gcc.writer.AddCmpChunk(c)
 }
 
+func (gcc *gcCopier) hasChunk(h hash.Hash) bool {
+	return gcc.writer.chunkHashes.Has(h)
+}
+
 func (gcc *gcCopier) copyTablesToDir(ctx context.Context, destDir string) ([]tableSpec, error) {
 	filename, err := gcc.writer.Finish()
 	if err != nil {
@@ -17,6 +17,7 @@ package nbs
 import (
 	"context"
 	"io"
+	"path/filepath"
 	"sync"
 
 	"github.com/dolthub/dolt/go/store/chunks"
@@ -25,6 +26,7 @@ import (
 
 var _ chunks.ChunkStore = (*GenerationalNBS)(nil)
 var _ chunks.GenerationalCS = (*GenerationalNBS)(nil)
+var _ chunks.OnlineGarbageCollector = (*GenerationalNBS)(nil)
 var _ TableFileStore = (*GenerationalNBS)(nil)
 
 type GenerationalNBS struct {
@@ -191,7 +193,9 @@ func (gcs *GenerationalNBS) Commit(ctx context.Context, current, last hash.Hash)
 // ChunkStore instance. The type is implementation-dependent, and impls
 // may return nil
 func (gcs *GenerationalNBS) Stats() interface{} {
-	return nil
+	stats := gcs.newGen.Stats().(Stats)
+	stats.Add(gcs.oldGen.Stats().(Stats))
+	return stats
 }
 
 // StatsSummary may return a string containing summarized statistics for
@@ -304,7 +308,54 @@ func (gcs *GenerationalNBS) SetRootChunk(ctx context.Context, root, previous has
 	return gcs.newGen.SetRootChunk(ctx, root, previous)
 }
 
+// GetChunkLocationsWithPaths returns the locations of |hashes| within the table files of the old and new gen stores,
+// keyed by the path of each table file relative to the new gen store's directory.
+func (gcs *GenerationalNBS) GetChunkLocationsWithPaths(hashes hash.HashSet) (map[string]map[hash.Hash]Range, error) {
+	res, err := gcs.newGen.GetChunkLocationsWithPaths(hashes)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) > 0 {
+		prefix := gcs.RelativeOldGenPath()
+		toadd, err := gcs.oldGen.GetChunkLocationsWithPaths(hashes)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range toadd {
+			res[filepath.ToSlash(filepath.Join(prefix, k))] = v
+		}
+	}
+	return res, nil
+}
+
+// RelativeOldGenPath returns the path of the old gen store's directory relative to the new gen store's directory.
+func (gcs *GenerationalNBS) RelativeOldGenPath() string {
+	newgenpath, ngpok := gcs.newGen.Path()
+	oldgenpath, ogpok := gcs.oldGen.Path()
+	if ngpok && ogpok {
+		if p, err := filepath.Rel(newgenpath, oldgenpath); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// Path returns the directory of the new gen store, and false if it is not backed by a local directory.
+func (gcs *GenerationalNBS) Path() (string, bool) {
+	return gcs.newGen.Path()
+}
+
 // SupportedOperations returns a description of the support TableFile operations. Some stores only support reading table files, not writing.
 func (gcs *GenerationalNBS) SupportedOperations() TableFileStoreOps {
 	return gcs.newGen.SupportedOperations()
 }
+
+// BeginGC implements chunks.OnlineGarbageCollector. All writes go to the new gen, so only it needs to track them.
+func (gcs *GenerationalNBS) BeginGC() error {
+	return gcs.newGen.BeginGC()
+}
+
+// EndGC implements chunks.OnlineGarbageCollector.
+func (gcs *GenerationalNBS) EndGC() {
+	gcs.newGen.EndGC()
+}
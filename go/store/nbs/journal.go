@@ -0,0 +1,575 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+// EnvChunkJournal is the environment variable that makes local databases write their commits to a chunk journal
+// instead of to a new table file per commit. Databases that already have a chunk journal always use it.
+const EnvChunkJournal = "DOLT_ENABLE_CHUNK_JOURNAL"
+
+var chunkJournalEnabled = false
+
+func init() {
+	if v, ok := os.LookupEnv(EnvChunkJournal); ok {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "", "0", "false":
+		default:
+			chunkJournalEnabled = true
+		}
+	}
+}
+
+// ChunkJournalEnabled returns whether EnvChunkJournal enables the chunk journal for local databases.
+func ChunkJournalEnabled() bool {
+	return chunkJournalEnabled
+}
+
+// chunkJournalExists returns whether the database in |dir| has a chunk journal.
+func chunkJournalExists(dir string) (bool, error) {
+	info, err := os.Stat(filepath.Join(dir, journalAddr.String()))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return !info.IsDir(), nil
+}
+
+// journalWriters are the open journalWriters of this process by path, which every store of the database in the
+// same directory shares, since the journal lock can only be held once.
+var journalWriters = struct {
+	mu   sync.Mutex
+	open map[string]*journalWriter
+}{open: make(map[string]*journalWriter)}
+
+// chunkJournal is the tablePersister and manifest of a local store whose commits are written to its chunk journal.
+// Memtables are persisted by appending their chunks to the journal, and commits that don't change the table files
+// of the store are committed by appending a root hash record to it, so that most commits write to and sync a single
+// file. The manifest only has to be rewritten when the table files of the store change, and lists the journal with
+// its chunk records that were committed as of then.
+//
+// The journal is rolled into a table file when it's conjoined, and its chunk records that are rolled are dropped.
+// Garbage collection drops all of them, since it copies the chunks that are still reachable to new table files.
+type chunkJournal struct {
+	wr        *journalWriter
+	dir       string
+	persister *fsTablePersister
+
+	// readOnly journals were opened while another process had the journal locked. They serve the manifest and the
+	// journal as they were when they were opened, and refuse to write.
+	readOnly bool
+	exists   bool
+	contents manifestContents
+
+	// mu serializes the reads and writes of the manifest and the root hash records of the journal.
+	mu        sync.Mutex
+	closeOnce sync.Once
+}
+
+var _ tablePersister = &chunkJournal{}
+var _ manifest = &chunkJournal{}
+var _ manifestGCGenUpdater = &chunkJournal{}
+
+// openChunkJournal opens the chunk journal of the database in |dir|, creating it if it doesn't exist. If another
+// process has the journal open, it's opened read only.
+func openChunkJournal(ctx context.Context, dir string, persister *fsTablePersister) (*chunkJournal, error) {
+	path := filepath.Join(dir, journalAddr.String())
+
+	journalWriters.mu.Lock()
+	defer journalWriters.mu.Unlock()
+
+	wr, ok := journalWriters.open[path]
+	if !ok {
+		var err error
+		wr, err = openJournalWriter(path)
+		if err == ErrJournalLocked {
+			return openReadOnlyChunkJournal(ctx, dir, path, persister)
+		} else if err != nil {
+			return nil, err
+		}
+		j := &chunkJournal{wr: wr, dir: dir, persister: persister}
+		if err = j.validate(ctx); err != nil {
+			_ = wr.Close()
+			return nil, err
+		}
+		journalWriters.open[path] = wr
+	}
+	wr.refs++
+
+	return &chunkJournal{wr: wr, dir: dir, persister: persister}, nil
+}
+
+// maxReadOnlyOpenAttempts is how many times opening a journal read only is attempted while the process that has it
+// open keeps rewriting the manifest.
+const maxReadOnlyOpenAttempts = 8
+
+// openReadOnlyChunkJournal opens the chunk journal at |path| of the database in |dir| read only, while another
+// process has it open. The manifest is read before and after the journal, so that the journal has every chunk record
+// that the manifest it's served with says is committed.
+func openReadOnlyChunkJournal(ctx context.Context, dir, path string, persister *fsTablePersister) (*chunkJournal, error) {
+	for i := 0; i < maxReadOnlyOpenAttempts; i++ {
+		exists, before, err := parseIfExists(ctx, dir, nil)
+		if err != nil {
+			return nil, err
+		}
+		wr, err := openJournalReader(path)
+		if err != nil {
+			return nil, err
+		}
+		_, after, err := parseIfExists(ctx, dir, nil)
+		if err != nil {
+			_ = wr.Close()
+			return nil, err
+		}
+		if before.lock != after.lock {
+			_ = wr.Close()
+			continue
+		}
+
+		j := &chunkJournal{wr: wr, dir: dir, persister: persister, readOnly: true, exists: exists}
+		if exists {
+			j.contents = j.effective(before)
+			committed, _ := journalChunkCount(j.contents.specs)
+			if cnt := wr.count(wr.end()); committed > cnt {
+				_ = wr.Close()
+				return nil, fmt.Errorf("chunk journal of %s has %d chunk records, but %d are committed", dir, cnt, committed)
+			}
+		}
+		return j, nil
+	}
+	return nil, ErrJournalLocked
+}
+
+// validate checks that the journal has every chunk record that the manifest says is committed, and drops the chunk
+// records of a journal that the manifest doesn't list, which were never committed.
+func (j *chunkJournal) validate(ctx context.Context) error {
+	exists, contents, err := parseIfExists(ctx, j.dir, nil)
+	if err != nil {
+		return err
+	}
+
+	var committed uint32
+	var listed bool
+	if exists {
+		committed, listed = journalChunkCount(j.effective(contents).specs)
+	}
+	if !listed {
+		return j.wr.reset()
+	}
+	if cnt := j.wr.count(j.wr.end()); committed > cnt {
+		return fmt.Errorf("chunk journal of %s has %d chunk records, but %d are committed", j.dir, cnt, committed)
+	}
+	return nil
+}
+
+func (j *chunkJournal) Close() (err error) {
+	j.closeOnce.Do(func() {
+		if j.readOnly {
+			err = j.wr.Close()
+			return
+		}
+
+		journalWriters.mu.Lock()
+		defer journalWriters.mu.Unlock()
+
+		j.wr.refs--
+		if j.wr.refs == 0 {
+			delete(journalWriters.open, j.wr.path)
+			err = j.wr.Close()
+		}
+	})
+	return err
+}
+
+// journalChunkCount returns the number of committed chunk records of the journal listed by |specs|.
+func journalChunkCount(specs []tableSpec) (uint32, bool) {
+	for _, s := range specs {
+		if s.name == journalAddr {
+			return s.chunkCount, true
+		}
+	}
+	return 0, false
+}
+
+// effective returns |contents| of the manifest file updated by the root hash record of the journal that's in effect,
+// if there is one.
+func (j *chunkJournal) effective(contents manifestContents) manifestContents {
+	r, ok := j.wr.latestRoot()
+	if !ok || r.manifestLock != contents.lock {
+		return contents
+	}
+
+	specs := make([]tableSpec, len(contents.specs))
+	copy(specs, contents.specs)
+	for i := range specs {
+		if specs[i].name == journalAddr {
+			specs[i].chunkCount = r.count
+		}
+	}
+	contents.specs = specs
+	contents.root = r.root
+	contents.lock = r.lock
+	return contents
+}
+
+// Persist implements tablePersister. It appends the chunks of |mt| that |haver| doesn't have to the journal.
+func (j *chunkJournal) Persist(ctx context.Context, mt *memTable, haver chunkReader, stats *Stats) (chunkSource, error) {
+	if haver != nil {
+		sort.Sort(hasRecordByPrefix(mt.order)) // hasMany() requires addresses to be sorted.
+		if _, err := haver.hasMany(mt.order); err != nil {
+			return nil, err
+		}
+		sort.Sort(hasRecordByOrder(mt.order)) // restore "insertion" order for write
+	}
+
+	var compressed, uncompressed uint64
+	cs := make([]CompressedChunk, 0, len(mt.order))
+	for _, r := range mt.order {
+		if r.has {
+			continue
+		}
+		data := mt.chunks[*r.a]
+		cc, err := compressChunk(chunks.NewChunkWithHash(hash.Hash(*r.a), data), defaultTableCodec, nil)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, cc)
+		compressed += uint64(len(cc.CompressedData))
+		uncompressed += uint64(len(data))
+	}
+
+	end, err := j.wr.writeChunks(cs)
+	if err != nil {
+		return nil, err
+	}
+	if len(cs) > 0 {
+		stats.CompressedChunkBytesPerPersist.Sample(compressed)
+		stats.UncompressedChunkBytesPerPersist.Sample(uncompressed)
+		stats.ChunksPerPersist.Sample(uint64(len(cs)))
+	}
+
+	if j.wr.count(end) == 0 {
+		return emptyChunkSource{}, nil
+	}
+	return journalChunkSource{wr: j.wr, end: end}, nil
+}
+
+// ConjoinAll implements tablePersister. The chunk records of the journal among |sources| are rolled into the
+// conjoined table file.
+func (j *chunkJournal) ConjoinAll(ctx context.Context, sources chunkSources, stats *Stats) (chunkSource, error) {
+	if j.readOnly {
+		return nil, ErrJournalLocked
+	}
+	return j.persister.ConjoinAll(ctx, sources, stats)
+}
+
+// Open implements tablePersister.
+func (j *chunkJournal) Open(ctx context.Context, name addr, chunkCount uint32, stats *Stats) (chunkSource, error) {
+	if name != journalAddr {
+		return j.persister.Open(ctx, name, chunkCount, stats)
+	}
+
+	j.wr.mu.RLock()
+	defer j.wr.mu.RUnlock()
+	if int(chunkCount) > len(j.wr.chunks) {
+		return nil, fmt.Errorf("chunk journal of %s has %d chunk records, but %d are committed", j.dir, len(j.wr.chunks), chunkCount)
+	}
+	return journalChunkSource{wr: j.wr, end: j.wr.base + uint64(chunkCount)}, nil
+}
+
+// PruneTableFiles implements tablePersister.
+func (j *chunkJournal) PruneTableFiles(ctx context.Context, contents manifestContents) error {
+	if j.readOnly {
+		return ErrJournalLocked
+	}
+	return j.persister.PruneTableFiles(ctx, contents)
+}
+
+// Name implements manifest.
+func (j *chunkJournal) Name() string {
+	return j.dir
+}
+
+// ParseIfExists implements manifest.
+func (j *chunkJournal) ParseIfExists(ctx context.Context, stats *Stats, readHook func() error) (bool, manifestContents, error) {
+	t1 := time.Now()
+	defer func() {
+		stats.ReadManifestLatency.SampleTimeSince(t1)
+	}()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.readOnly {
+		return j.exists, j.contents, nil
+	}
+
+	exists, contents, err := parseIfExists(ctx, j.dir, readHook)
+	if err != nil || !exists {
+		return exists, contents, err
+	}
+	return true, j.effective(contents), nil
+}
+
+// Update implements manifest. Updates that only change the root hash of the store and the number of committed chunk
+// records of the journal are committed with a root hash record, and other updates rewrite the manifest.
+func (j *chunkJournal) Update(ctx context.Context, lastLock addr, newContents manifestContents, stats *Stats, writeHook func() error) (manifestContents, error) {
+	t1 := time.Now()
+	defer func() { stats.WriteManifestLatency.SampleTimeSince(t1) }()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.readOnly {
+		return manifestContents{}, ErrJournalLocked
+	}
+
+	if upstream, ok, err := j.commitRoot(lastLock, newContents); err != nil || ok {
+		return upstream, err
+	}
+
+	checker := func(upstream, contents manifestContents) error {
+		if contents.gcGen != upstream.gcGen {
+			return chunks.ErrGCGenerationExpired
+		}
+		return nil
+	}
+
+	upstream, prev, err := j.updateManifest(ctx, lastLock, newContents, checker, writeHook)
+	if err != nil || upstream.lock != newContents.lock {
+		return upstream, err
+	}
+
+	// a conjoin that rolled the journal into a table file drops its chunk records that were rolled
+	if cnt, ok := journalChunkCount(prev.specs); ok {
+		if _, ok = journalChunkCount(newContents.specs); !ok {
+			if err = j.wr.dropChunks(cnt); err != nil {
+				return manifestContents{}, err
+			}
+		}
+	}
+	return upstream, nil
+}
+
+// UpdateGCGen implements manifestGCGenUpdater.
+func (j *chunkJournal) UpdateGCGen(ctx context.Context, lastLock addr, newContents manifestContents, stats *Stats, writeHook func() error) (manifestContents, error) {
+	t1 := time.Now()
+	defer func() { stats.WriteManifestLatency.SampleTimeSince(t1) }()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.readOnly {
+		return manifestContents{}, ErrJournalLocked
+	}
+
+	checker := func(upstream, contents manifestContents) error {
+		if contents.gcGen == upstream.gcGen {
+			return errors.New("UpdateGCGen() must update the garbage collection generation")
+		}
+		if contents.root != upstream.root {
+			return errors.New("UpdateGCGen() cannot update the root")
+		}
+		return nil
+	}
+
+	upstream, _, err := j.updateManifest(ctx, lastLock, newContents, checker, writeHook)
+	if err != nil || upstream.lock != newContents.lock {
+		return upstream, err
+	}
+
+	// the chunks of the journal that are still reachable were copied to the table files of the collection
+	if _, ok := journalChunkCount(newContents.specs); !ok {
+		if err = j.wr.reset(); err != nil {
+			return manifestContents{}, err
+		}
+	}
+	return upstream, nil
+}
+
+// commitRoot commits |newContents| with a root hash record if it only differs from the contents of the manifest in
+// its root hash, lock and number of committed chunk records of the journal. It returns false if |newContents| has
+// to be committed by rewriting the manifest. Callers must hold |j.mu|.
+func (j *chunkJournal) commitRoot(lastLock addr, newContents manifestContents) (upstream manifestContents, ok bool, err error) {
+	lck := newLock(j.dir)
+	if err = lck.Lock(); err != nil {
+		return manifestContents{}, false, err
+	}
+	defer func() {
+		unlockErr := lck.Unlock()
+		if err == nil {
+			err = unlockErr
+		}
+	}()
+
+	f, err := openIfExists(filepath.Join(j.dir, manifestFileName))
+	if err != nil || f == nil {
+		return manifestContents{}, false, err
+	}
+	backing, err := parseManifest(f)
+	closeErr := f.Close()
+	if err != nil {
+		return manifestContents{}, false, err
+	} else if closeErr != nil {
+		return manifestContents{}, false, closeErr
+	}
+
+	upstream = j.effective(backing)
+	if upstream.lock != lastLock {
+		// optimistic lock failure
+		return upstream, true, nil
+	}
+	if !onlyRootChanged(upstream, newContents) {
+		return manifestContents{}, false, nil
+	}
+
+	cnt, _ := journalChunkCount(newContents.specs)
+	err = j.wr.commitRoot(journalRoot{
+		root:         newContents.root,
+		lock:         newContents.lock,
+		manifestLock: backing.lock,
+		count:        cnt,
+	})
+	if err != nil {
+		return manifestContents{}, false, err
+	}
+	return newContents, true, nil
+}
+
+// onlyRootChanged returns whether |next| only differs from |curr| in its root hash, lock and number of committed
+// chunk records of the journal.
+func onlyRootChanged(curr, next manifestContents) bool {
+	if curr.nbfVers != next.nbfVers || curr.gcGen != next.gcGen {
+		return false
+	}
+	if !sameSpecs(curr.appendix, next.appendix, false) {
+		return false
+	}
+	return sameSpecs(curr.specs, next.specs, true)
+}
+
+func sameSpecs(a, b []tableSpec, ignoreJournalCount bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[addr]uint32, len(a))
+	for _, s := range a {
+		counts[s.name] = s.chunkCount
+	}
+	for _, s := range b {
+		cnt, ok := counts[s.name]
+		if !ok {
+			return false
+		}
+		if cnt != s.chunkCount && !(ignoreJournalCount && s.name == journalAddr) {
+			return false
+		}
+	}
+	return true
+}
+
+// updateManifest rewrites the manifest with |newContents|, which supersedes the root hash records of the journal.
+// It returns the contents of the manifest before the update. Callers must hold |j.mu|.
+func (j *chunkJournal) updateManifest(ctx context.Context, lastLock addr, newContents manifestContents, validate manifestChecker, writeHook func() error) (upstream, prev manifestContents, err error) {
+	_, backing, err := parseIfExists(ctx, j.dir, nil)
+	if err != nil {
+		return manifestContents{}, manifestContents{}, err
+	}
+	prev = j.effective(backing)
+	if prev.lock != lastLock {
+		// optimistic lock failure
+		return prev, prev, nil
+	}
+
+	if _, ok := journalChunkCount(newContents.specs); ok {
+		// the chunk records that the manifest lists as committed have to be durable before it is
+		if err = j.wr.sync(); err != nil {
+			return manifestContents{}, manifestContents{}, err
+		}
+	}
+
+	checker := func(upstream, contents manifestContents) error {
+		return validate(j.effective(upstream), contents)
+	}
+	upstream, err = updateWithChecker(ctx, j.dir, checker, backing.lock, newContents, writeHook)
+	if err != nil {
+		return manifestContents{}, manifestContents{}, err
+	}
+	if upstream.lock != newContents.lock {
+		// the manifest was updated by another process since it was read
+		return j.effective(upstream), prev, nil
+	}
+	return upstream, prev, nil
+}
+
+// snapshotSpecs returns |contents| with the chunk journal replaced by its snapshot, which is the table file that its
+// committed chunk records would be rolled into, and adds the snapshot to |css|. Table files are listed this way for
+// clients that copy them, which can't read the journal file.
+func (j *chunkJournal) snapshotSpecs(contents manifestContents, css map[addr]chunkSource) (manifestContents, error) {
+	cnt, ok := journalChunkCount(contents.specs)
+	if !ok {
+		return contents, nil
+	}
+
+	j.wr.mu.RLock()
+	end := j.wr.base + uint64(cnt)
+	j.wr.mu.RUnlock()
+
+	snap, err := j.wr.snapshot(end)
+	if err != nil {
+		return manifestContents{}, err
+	}
+	name, err := snap.hash()
+	if err != nil {
+		return manifestContents{}, err
+	}
+	css[name] = snap
+
+	specs := make([]tableSpec, len(contents.specs))
+	for i, s := range contents.specs {
+		if s.name == journalAddr {
+			s.name = name
+		}
+		specs[i] = s
+	}
+	contents.specs = specs
+	return contents, nil
+}
+
+// localTablePersister returns the fsTablePersister that writes the table files of |p|, if they're local files.
+func localTablePersister(p tablePersister) (*fsTablePersister, bool) {
+	switch p := p.(type) {
+	case *fsTablePersister:
+		return p, true
+	case *chunkJournal:
+		return p.persister, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"crypto/sha512"
+	"io"
+	"os"
+	"sort"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+// journalChunkSource is the chunkSource of the chunk records of the chunk journal before chunk record |end|. Chunk
+// records appended to the journal later aren't part of it, so its contents don't change as the journal grows.
+type journalChunkSource struct {
+	wr  *journalWriter
+	end uint64
+}
+
+var _ chunkSource = journalChunkSource{}
+
+func (s journalChunkSource) has(h addr) (bool, error) {
+	return s.wr.has(h, s.end), nil
+}
+
+func (s journalChunkSource) hasMany(addrs []hasRecord) (bool, error) {
+	var remaining bool
+	for i := range addrs {
+		if addrs[i].has {
+			continue
+		}
+		if s.wr.has(*addrs[i].a, s.end) {
+			addrs[i].has = true
+		} else {
+			remaining = true
+		}
+	}
+	return remaining, nil
+}
+
+func (s journalChunkSource) get(ctx context.Context, h addr, stats *Stats) ([]byte, error) {
+	cc, ok, err := s.wr.getCompressed(h, s.end)
+	if err != nil || !ok {
+		return nil, err
+	}
+	ch, err := cc.ToChunk()
+	if err != nil {
+		return nil, err
+	}
+	return ch.Data(), nil
+}
+
+func (s journalChunkSource) getMany(ctx context.Context, eg *errgroup.Group, reqs []getRecord, found func(context.Context, *chunks.Chunk), stats *Stats) (bool, error) {
+	var remaining bool
+	for i := range reqs {
+		if reqs[i].found {
+			continue
+		}
+		data, err := s.get(ctx, *reqs[i].a, stats)
+		if err != nil {
+			return false, err
+		}
+		if data == nil {
+			remaining = true
+			continue
+		}
+		reqs[i].found = true
+		ch := chunks.NewChunkWithHash(hash.Hash(*reqs[i].a), data)
+		found(ctx, &ch)
+	}
+	return remaining, nil
+}
+
+func (s journalChunkSource) getManyCompressed(ctx context.Context, eg *errgroup.Group, reqs []getRecord, found func(context.Context, CompressedChunk), stats *Stats) (bool, error) {
+	var remaining bool
+	for i := range reqs {
+		if reqs[i].found {
+			continue
+		}
+		cc, ok, err := s.wr.getCompressed(*reqs[i].a, s.end)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			remaining = true
+			continue
+		}
+		reqs[i].found = true
+		found(ctx, cc)
+	}
+	return remaining, nil
+}
+
+func (s journalChunkSource) extract(ctx context.Context, chunks chan<- extractRecord) error {
+	snap, err := s.wr.snapshot(s.end)
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+	return snap.extract(ctx, chunks)
+}
+
+func (s journalChunkSource) count() (uint32, error) {
+	return s.wr.count(s.end), nil
+}
+
+func (s journalChunkSource) uncompressedLen() (uint64, error) {
+	return s.wr.uncompressedLen(s.end), nil
+}
+
+func (s journalChunkSource) hash() (addr, error) {
+	return journalAddr, nil
+}
+
+func (s journalChunkSource) calcReads(reqs []getRecord, blockSize uint64) (reads int, remaining bool, err error) {
+	for _, r := range reqs {
+		if r.found {
+			continue
+		}
+		if s.wr.has(*r.a, s.end) {
+			reads++
+		} else {
+			remaining = true
+		}
+	}
+	return reads, remaining, nil
+}
+
+// reader returns a reader of the snapshot of the journal source, which is the table file that it's rolled into.
+func (s journalChunkSource) reader(ctx context.Context) (io.Reader, error) {
+	snap, err := s.wr.snapshot(s.end)
+	if err != nil {
+		return nil, err
+	}
+	return snap.reader(ctx)
+}
+
+func (s journalChunkSource) size() (uint64, error) {
+	snap, err := s.wr.snapshot(s.end)
+	if err != nil {
+		return 0, err
+	}
+	defer snap.Close()
+	return snap.size()
+}
+
+func (s journalChunkSource) index() (tableIndex, error) {
+	snap, err := s.wr.snapshot(s.end)
+	if err != nil {
+		return nil, err
+	}
+	return snap.index()
+}
+
+func (s journalChunkSource) Clone() (chunkSource, error) {
+	return s, nil
+}
+
+func (s journalChunkSource) Close() error {
+	return nil
+}
+
+// journalSnapshot is the chunk records of the journal before chunk record |end| as a table file, which is their
+// table file chunk records followed by an index of them. The table file is read out of the journal file, so
+// snapshots are only written out when the journal is rolled into a table file.
+type journalSnapshot struct {
+	end uint64
+	cs  chunkSource
+}
+
+// snapshot returns the table file of the chunk records of the journal before chunk record |end|. The caller must
+// close it.
+func (wr *journalWriter) snapshot(end uint64) (chunkSource, error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	if wr.snap == nil || wr.snap.end != end {
+		cs, err := wr.newSnapshot(end)
+		if err != nil {
+			return nil, err
+		}
+		wr.snap = &journalSnapshot{end: end, cs: cs}
+	}
+	return wr.snap.cs.Clone()
+}
+
+func (wr *journalWriter) newSnapshot(end uint64) (chunkSource, error) {
+	recs := wr.chunks[:wr.countLocked(end)]
+	n := uint32(len(recs))
+
+	codec := snappyCodec
+	for _, c := range recs {
+		if c.zstd {
+			codec = zstdCodec
+			break
+		}
+	}
+
+	tw := &tableWriter{
+		buff:      make([]byte, indexSize(n)+footerSize),
+		blockHash: sha512.New(),
+		codec:     codec,
+	}
+	if n > 0 {
+		tw.totalUncompressedData = recs[n-1].uncompressed
+	}
+
+	segs := make([]journalSegment, n)
+	var dataLen uint64
+	for i := range recs {
+		c := &recs[i]
+		tw.prefixes = append(tw.prefixes, prefixIndexRec{
+			prefix: c.a.Prefix(),
+			suffix: c.a[addrPrefixSize:],
+			order:  uint32(i),
+			size:   c.length,
+		})
+		segs[i] = journalSegment{tableOff: dataLen, fileOff: c.off, length: c.length}
+		dataLen += uint64(c.length)
+		tw.totalCompressedData += uint64(c.length - checksumSize)
+	}
+
+	if err := tw.writeIndex(); err != nil {
+		return nil, err
+	}
+	tw.writeFooter()
+
+	var name addr
+	copy(name[:], tw.blockHash.Sum(nil))
+
+	rd := journalSnapshotReader{f: wr.file, segs: segs, dataLen: dataLen, index: tw.buff[:tw.pos]}
+	// snapshots aren't counted against the memory quota of the store, since they're made on demand
+	return newReaderFromIndexData(&noopQuotaProvider{}, rd.index, name, rd, fileBlockSize)
+}
+
+// journalSegment is the location of a chunk record of a snapshot in the journal file.
+type journalSegment struct {
+	tableOff uint64
+	fileOff  int64
+	length   uint32
+}
+
+// journalSnapshotReader reads the table file of a snapshot, whose chunk records are read from the journal file and
+// whose index is in memory.
+type journalSnapshotReader struct {
+	f       *os.File
+	segs    []journalSegment
+	dataLen uint64
+	index   []byte
+}
+
+func (r journalSnapshotReader) ReadAtWithStats(ctx context.Context, p []byte, off int64, stats *Stats) (n int, err error) {
+	for n < len(p) {
+		o := uint64(off) + uint64(n)
+		if o >= r.dataLen {
+			i := o - r.dataLen
+			if i >= uint64(len(r.index)) {
+				return n, io.EOF
+			}
+			n += copy(p[n:], r.index[i:])
+			continue
+		}
+
+		s := sort.Search(len(r.segs), func(i int) bool {
+			return r.segs[i].tableOff+uint64(r.segs[i].length) > o
+		})
+		seg := r.segs[s]
+		within := o - seg.tableOff
+		l := uint64(seg.length) - within
+		if rem := uint64(len(p) - n); l > rem {
+			l = rem
+		}
+
+		m, err := r.f.ReadAt(p[n:n+int(l)], seg.fileOff+int64(within))
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
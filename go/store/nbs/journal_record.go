@@ -0,0 +1,201 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+/*
+   The chunk journal is an append-only file of records. Every record is framed the same way:
+
+   Journal Record:
+   +-----------------+---------------+-----------+----------------+
+   | (Uint32) Length | (Uint8) Kind  | Payload   | (Uint32) CRC32 |
+   +-----------------+---------------+-----------+----------------+
+
+     -Length is the length of the whole record, including itself and the CRC.
+     -The CRC is the CRC32 of the rest of the record. A record whose CRC doesn't match was torn by a crash while it
+      was being written, and ends the journal.
+
+   Chunk Payload:
+   +------------+-------------------------+----------------+
+   | (20) Addr  | Compressed Chunk Data   | (Uint32) CRC32 |
+   +------------+-------------------------+----------------+
+
+     -Everything after the Addr is a table file chunk record, so chunks are read out of the journal, and served to
+      clients that download them, exactly like chunks in table files.
+
+   Root Hash Payload:
+   +------------+------------+---------------------+----------------------+
+   | (20) Root  | (20) Lock  | (20) Manifest Lock  | (Uint32) Chunk Count |
+   +------------+------------+---------------------+----------------------+
+
+     -A root hash record commits the root hash and lock of the store without rewriting the manifest. Its Chunk Count
+      is the number of chunk records of the journal that the root can reach.
+     -The Manifest Lock is the lock of the manifest that the record was written on top of. A record is only in effect
+      while the manifest still has that lock, so rewriting the manifest supersedes every earlier root hash record.
+*/
+
+type journalRecKind uint8
+
+const (
+	unknownJournalRecKind  journalRecKind = 0
+	chunkJournalRecKind    journalRecKind = 1
+	rootHashJournalRecKind journalRecKind = 2
+)
+
+const (
+	journalRecLenSz    = uint32Size
+	journalRecKindSz   = 1
+	journalRecHeaderSz = journalRecLenSz + journalRecKindSz
+	journalRecCRCSz    = checksumSize
+
+	// chunkJournalRecPayloadOffset is the offset of the table file chunk record in a chunk record.
+	chunkJournalRecPayloadOffset = journalRecHeaderSz + addrSize
+	minChunkJournalRecSize       = chunkJournalRecPayloadOffset + checksumSize + journalRecCRCSz
+
+	rootHashJournalRecSize = journalRecHeaderSz + hash.ByteLen + addrSize + addrSize + uint32Size + journalRecCRCSz
+)
+
+var errTornJournalRec = errors.New("torn journal record")
+
+// journalRec is a record read from the chunk journal.
+type journalRec struct {
+	kind journalRecKind
+
+	// address and payload of chunk records. The payload is a table file chunk record.
+	address addr
+	payload []byte
+
+	// root hash, lock, manifest lock and chunk count of root hash records.
+	root         hash.Hash
+	lock         addr
+	manifestLock addr
+	count        uint32
+}
+
+func chunkJournalRecSize(c CompressedChunk) uint32 {
+	return uint32(chunkJournalRecPayloadOffset + len(c.FullCompressedChunk) + journalRecCRCSz)
+}
+
+// writeChunkJournalRec writes the chunk record of |c| to |buf|, which must be at least chunkJournalRecSize(c) bytes,
+// and returns its length.
+func writeChunkJournalRec(buf []byte, c CompressedChunk) uint32 {
+	n := chunkJournalRecSize(c)
+	writeJournalRecHeader(buf, n, chunkJournalRecKind)
+	off := journalRecHeaderSz
+	off += copy(buf[off:], c.H[:])
+	off += copy(buf[off:], c.FullCompressedChunk)
+	binary.BigEndian.PutUint32(buf[off:], crc(buf[:off]))
+	return n
+}
+
+// writeRootHashJournalRec writes a root hash record to |buf|, which must be at least rootHashJournalRecSize bytes,
+// and returns its length.
+func writeRootHashJournalRec(buf []byte, root hash.Hash, lock, manifestLock addr, count uint32) uint32 {
+	writeJournalRecHeader(buf, rootHashJournalRecSize, rootHashJournalRecKind)
+	off := journalRecHeaderSz
+	off += copy(buf[off:], root[:])
+	off += copy(buf[off:], lock[:])
+	off += copy(buf[off:], manifestLock[:])
+	binary.BigEndian.PutUint32(buf[off:], count)
+	off += uint32Size
+	binary.BigEndian.PutUint32(buf[off:], crc(buf[:off]))
+	return rootHashJournalRecSize
+}
+
+func writeJournalRecHeader(buf []byte, length uint32, kind journalRecKind) {
+	binary.BigEndian.PutUint32(buf, length)
+	buf[journalRecLenSz] = byte(kind)
+}
+
+// readJournalRec parses the record |buf|, which is exactly one record long. The payload of a chunk record is a
+// slice of |buf|.
+func readJournalRec(buf []byte) (rec journalRec, err error) {
+	if len(buf) < journalRecHeaderSz+journalRecCRCSz || binary.BigEndian.Uint32(buf) != uint32(len(buf)) {
+		return journalRec{}, errTornJournalRec
+	}
+	end := len(buf) - journalRecCRCSz
+	if crc(buf[:end]) != binary.BigEndian.Uint32(buf[end:]) {
+		return journalRec{}, errTornJournalRec
+	}
+
+	rec.kind = journalRecKind(buf[journalRecLenSz])
+	switch rec.kind {
+	case chunkJournalRecKind:
+		if len(buf) < minChunkJournalRecSize {
+			return journalRec{}, errTornJournalRec
+		}
+		copy(rec.address[:], buf[journalRecHeaderSz:])
+		rec.payload = buf[chunkJournalRecPayloadOffset:end]
+	case rootHashJournalRecKind:
+		if len(buf) != rootHashJournalRecSize {
+			return journalRec{}, errTornJournalRec
+		}
+		off := journalRecHeaderSz
+		off += copy(rec.root[:], buf[off:])
+		off += copy(rec.lock[:], buf[off:])
+		off += copy(rec.manifestLock[:], buf[off:])
+		rec.count = binary.BigEndian.Uint32(buf[off:])
+	default:
+		return journalRec{}, fmt.Errorf("unknown journal record kind %d", rec.kind)
+	}
+	return rec, nil
+}
+
+// processJournalRecs reads the records of the journal |r|, which starts at offset |off| of a journal file of |size|
+// bytes, and calls |cb| with each of them and its offset. A record that was torn by a crash ends the journal. It
+// returns the offset of the end of the last whole record.
+func processJournalRecs(r io.Reader, off, size int64, cb func(off int64, rec journalRec) error) (int64, error) {
+	rd := bufio.NewReaderSize(r, 1<<16)
+	var lenBuf [journalRecLenSz]byte
+	for {
+		if _, err := io.ReadFull(rd, lenBuf[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return off, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		l := binary.BigEndian.Uint32(lenBuf[:])
+		if l < journalRecHeaderSz+journalRecCRCSz || int64(l) > size-off {
+			return off, nil
+		}
+		buf := make([]byte, l)
+		copy(buf, lenBuf[:])
+		if _, err := io.ReadFull(rd, buf[journalRecLenSz:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return off, nil
+		} else if err != nil {
+			return 0, err
+		}
+
+		rec, err := readJournalRec(buf)
+		if err == errTornJournalRec {
+			return off, nil
+		} else if err != nil {
+			return 0, err
+		}
+		if err = cb(off, rec); err != nil {
+			return 0, err
+		}
+		off += int64(l)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+func makeJournalChunk(t *testing.T, data string) CompressedChunk {
+	cc, err := compressChunk(chunks.NewChunk([]byte(data)), snappyCodec, nil)
+	require.NoError(t, err)
+	return cc
+}
+
+func TestChunkJournalRecRoundTrip(t *testing.T) {
+	cc := makeJournalChunk(t, "hello, journal")
+	buf := make([]byte, chunkJournalRecSize(cc))
+	n := writeChunkJournalRec(buf, cc)
+	assert.Equal(t, uint32(len(buf)), n)
+
+	rec, err := readJournalRec(buf)
+	require.NoError(t, err)
+	assert.Equal(t, chunkJournalRecKind, rec.kind)
+	assert.Equal(t, addr(cc.H), rec.address)
+	assert.Equal(t, cc.FullCompressedChunk, rec.payload)
+
+	out, err := NewCompressedChunk(cc.H, rec.payload)
+	require.NoError(t, err)
+	ch, err := out.ToChunk()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello, journal"), ch.Data())
+}
+
+func TestRootHashJournalRecRoundTrip(t *testing.T) {
+	root := hash.Of([]byte("root"))
+	lock := computeAddr([]byte("lock"))
+	manifestLock := computeAddr([]byte("manifest lock"))
+
+	buf := make([]byte, rootHashJournalRecSize)
+	n := writeRootHashJournalRec(buf, root, lock, manifestLock, 42)
+	assert.Equal(t, uint32(rootHashJournalRecSize), n)
+
+	rec, err := readJournalRec(buf)
+	require.NoError(t, err)
+	assert.Equal(t, rootHashJournalRecKind, rec.kind)
+	assert.Equal(t, root, rec.root)
+	assert.Equal(t, lock, rec.lock)
+	assert.Equal(t, manifestLock, rec.manifestLock)
+	assert.Equal(t, uint32(42), rec.count)
+}
+
+func TestReadTornJournalRec(t *testing.T) {
+	cc := makeJournalChunk(t, "hello, journal")
+	buf := make([]byte, chunkJournalRecSize(cc))
+	writeChunkJournalRec(buf, cc)
+
+	corrupt := make([]byte, len(buf))
+	copy(corrupt, buf)
+	corrupt[chunkJournalRecPayloadOffset] ^= 0xff
+	_, err := readJournalRec(corrupt)
+	assert.Equal(t, errTornJournalRec, err)
+
+	_, err = readJournalRec(buf[:len(buf)-1])
+	assert.Equal(t, errTornJournalRec, err)
+
+	unknown := make([]byte, len(buf))
+	copy(unknown, buf)
+	unknown[journalRecLenSz] = byte(unknownJournalRecKind)
+	end := len(unknown) - journalRecCRCSz
+	binary.BigEndian.PutUint32(unknown[end:], crc(unknown[:end]))
+	_, err = readJournalRec(unknown)
+	assert.Error(t, err)
+	assert.NotEqual(t, errTornJournalRec, err)
+}
+
+func TestProcessJournalRecs(t *testing.T) {
+	var journal []byte
+	var expected []addr
+	for _, data := range []string{"one", "two", "three"} {
+		cc := makeJournalChunk(t, data)
+		buf := make([]byte, chunkJournalRecSize(cc))
+		writeChunkJournalRec(buf, cc)
+		journal = append(journal, buf...)
+		expected = append(expected, addr(cc.H))
+	}
+	root := make([]byte, rootHashJournalRecSize)
+	writeRootHashJournalRec(root, hash.Of([]byte("root")), addr{}, addr{}, 3)
+	journal = append(journal, root...)
+	whole := int64(len(journal))
+
+	t.Run("whole journal", func(t *testing.T) {
+		var addrs []addr
+		var roots int
+		end, err := processJournalRecs(bytes.NewReader(journal), 0, whole, func(off int64, rec journalRec) error {
+			switch rec.kind {
+			case chunkJournalRecKind:
+				addrs = append(addrs, rec.address)
+			case rootHashJournalRecKind:
+				roots++
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, whole, end)
+		assert.Equal(t, expected, addrs)
+		assert.Equal(t, 1, roots)
+	})
+
+	t.Run("torn record", func(t *testing.T) {
+		cc := makeJournalChunk(t, "four")
+		buf := make([]byte, chunkJournalRecSize(cc))
+		writeChunkJournalRec(buf, cc)
+		for _, l := range []int{1, journalRecHeaderSz, len(buf) - 1} {
+			torn := append(append([]byte{}, journal...), buf[:l]...)
+			var cnt int
+			end, err := processJournalRecs(bytes.NewReader(torn), 0, int64(len(torn)), func(off int64, rec journalRec) error {
+				cnt++
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, whole, end)
+			assert.Equal(t, 4, cnt)
+		}
+	})
+}
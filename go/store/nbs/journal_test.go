@@ -0,0 +1,276 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/store/chunks"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/types"
+	"github.com/dolthub/dolt/go/store/util/tempfiles"
+)
+
+func makeTestJournalingStore(t *testing.T, maxTableFiles int) (st *NomsBlockStore, nomsDir string, q MemoryQuotaProvider) {
+	ctx := context.Background()
+	nomsDir = filepath.Join(tempfiles.MovableTempFileProvider.GetTempDir(), "noms_"+uuid.New().String()[:8])
+	err := os.MkdirAll(nomsDir, os.ModePerm)
+	require.NoError(t, err)
+
+	// create a v5 manifest
+	_, err = fileManifest{nomsDir}.Update(ctx, addr{}, manifestContents{}, &Stats{}, nil)
+	require.NoError(t, err)
+
+	q = NewUnlimitedMemQuotaProvider()
+	st, err = newLocalJournalingStore(ctx, types.Format_Default.VersionString(), nomsDir, defaultMemTableSize, maxTableFiles, q)
+	require.NoError(t, err)
+	return st, nomsDir, q
+}
+
+// commitChunks puts |cs| into |st| and commits one of them as its root.
+func commitChunks(t *testing.T, st *NomsBlockStore, cs map[hash.Hash]chunks.Chunk) hash.Hash {
+	ctx := context.Background()
+	var root hash.Hash
+	for h, c := range cs {
+		require.NoError(t, st.Put(ctx, c))
+		root = h
+	}
+	last, err := st.Root(ctx)
+	require.NoError(t, err)
+	ok, err := st.Commit(ctx, root, last)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return root
+}
+
+func assertChunksInStore(t *testing.T, st *NomsBlockStore, sets ...map[hash.Hash]chunks.Chunk) {
+	ctx := context.Background()
+	for _, set := range sets {
+		for h, c := range set {
+			out, err := st.Get(ctx, h)
+			require.NoError(t, err)
+			assert.Equal(t, c, out)
+		}
+	}
+}
+
+func TestChunkJournalCommits(t *testing.T) {
+	ctx := context.Background()
+	st, nomsDir, q := makeTestJournalingStore(t, defaultMaxTables)
+	defer os.RemoveAll(nomsDir)
+
+	first := makeChunkSet(64, 64)
+	commitChunks(t, st, first)
+	manifest, err := os.ReadFile(filepath.Join(nomsDir, manifestFileName))
+	require.NoError(t, err)
+
+	var sets []map[hash.Hash]chunks.Chunk
+	var root hash.Hash
+	for i := 0; i < 8; i++ {
+		set := makeChunkSet(64, 64)
+		root = commitChunks(t, st, set)
+		sets = append(sets, set)
+	}
+
+	// commits that don't change the table files of the store don't rewrite the manifest
+	after, err := os.ReadFile(filepath.Join(nomsDir, manifestFileName))
+	require.NoError(t, err)
+	assert.Equal(t, manifest, after)
+
+	entries, err := os.ReadDir(nomsDir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{manifestFileName, lockFileName, journalLockFileName, journalAddr.String()}, names)
+
+	// while the journal is locked by another process, the store is opened read only
+	journalWriters.mu.Lock()
+	delete(journalWriters.open, filepath.Join(nomsDir, journalAddr.String()))
+	journalWriters.mu.Unlock()
+	ro, err := newLocalStore(ctx, types.Format_Default.VersionString(), nomsDir, defaultMemTableSize, defaultMaxTables, q)
+	require.NoError(t, err)
+	actual, err := ro.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root, actual)
+	assertChunksInStore(t, ro, append(sets, first)...)
+	c := chunks.NewChunk([]byte("read only"))
+	require.NoError(t, ro.Put(ctx, c))
+	_, err = ro.Commit(ctx, c.Hash(), root)
+	assert.ErrorIs(t, err, ErrJournalLocked)
+	require.NoError(t, ro.Close())
+
+	// the writes of the process that has the journal open aren't affected by the read only store
+	more := makeChunkSet(64, 64)
+	root = commitChunks(t, st, more)
+	sets = append(sets, more)
+	require.NoError(t, st.Close())
+
+	// a store of a database with a journal uses it, and recovers the last root hash record
+	st, err = newLocalStore(ctx, types.Format_Default.VersionString(), nomsDir, defaultMemTableSize, defaultMaxTables, q)
+	require.NoError(t, err)
+	defer st.Close()
+	actual, err = st.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root, actual)
+	assertChunksInStore(t, st, append(sets, first)...)
+
+	// the journal is listed as a table file by its snapshot
+	_, sources, _, err := st.Sources(ctx)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	assert.NotEqual(t, journalAddr.String(), sources[0].FileID())
+	assert.Equal(t, 64*10, sources[0].NumChunks())
+}
+
+func TestChunkJournalUncommittedChunks(t *testing.T) {
+	ctx := context.Background()
+	st, nomsDir, q := makeTestJournalingStore(t, defaultMaxTables)
+	defer os.RemoveAll(nomsDir)
+
+	committed := makeChunkSet(16, 64)
+	root := commitChunks(t, st, committed)
+
+	// chunks that are persisted to the journal, but never committed, are dropped when it's opened again
+	var uncommitted [][]byte
+	for _, c := range makeChunkSet(16, 64) {
+		uncommitted = append(uncommitted, c.Data())
+	}
+	_, err := st.p.Persist(ctx, createMemTable(uncommitted), nil, &Stats{})
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	st, err = newLocalStore(ctx, types.Format_Default.VersionString(), nomsDir, defaultMemTableSize, defaultMaxTables, q)
+	require.NoError(t, err)
+	defer st.Close()
+	actual, err := st.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root, actual)
+	assertChunksInStore(t, st, committed)
+	for _, data := range uncommitted {
+		ok, err := st.Has(ctx, chunks.NewChunk(data).Hash())
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func TestChunkJournalConjoin(t *testing.T) {
+	ctx := context.Background()
+	st, nomsDir, q := makeTestJournalingStore(t, 2)
+	defer os.RemoveAll(nomsDir)
+
+	first := makeChunkSet(1, 64)
+	commitChunks(t, st, first)
+
+	p, ok := localTablePersister(st.p)
+	require.True(t, ok)
+	tableFiles, _ := persistTableFileSources(t, p, 3)
+	_, err := st.UpdateManifest(ctx, tableFiles)
+	require.NoError(t, err)
+
+	// the journal is among the smallest table files of the store, so its committed chunk records are rolled into
+	// the conjoined table file, and the chunks of the commit are written to it after them
+	wr := st.p.(*chunkJournal).wr
+	second := makeChunkSet(4, 64)
+	root := commitChunks(t, st, second)
+	assert.Equal(t, uint64(1), wr.base)
+	cnt, listed := journalChunkCount(st.upstream.specs)
+	assert.True(t, listed)
+	assert.Equal(t, uint32(4), cnt)
+	assertChunksInStore(t, st, first, second)
+
+	// every table file of the store is conjoined by the next commit
+	third := makeChunkSet(4, 64)
+	root = commitChunks(t, st, third)
+	assert.Equal(t, uint64(5), wr.base)
+	cnt, listed = journalChunkCount(st.upstream.specs)
+	assert.True(t, listed)
+	assert.Equal(t, uint32(4), cnt)
+	assert.Len(t, st.upstream.specs, 2)
+	require.NoError(t, st.Close())
+
+	st, err = newLocalStore(ctx, types.Format_Default.VersionString(), nomsDir, defaultMemTableSize, 2, q)
+	require.NoError(t, err)
+	defer st.Close()
+	actual, err := st.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, root, actual)
+	assertChunksInStore(t, st, first, second, third)
+	for h := range tableFiles {
+		ok, err := st.Has(ctx, h)
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+}
+
+func TestChunkJournalOnlineGC(t *testing.T) {
+	ctx := context.Background()
+	st, nomsDir, _ := makeTestJournalingStore(t, defaultMaxTables)
+	defer os.RemoveAll(nomsDir)
+	defer st.Close()
+
+	keepers := makeChunkSet(64, 64)
+	tossers := makeChunkSet(64, 64)
+	for _, c := range tossers {
+		require.NoError(t, st.Put(ctx, c))
+	}
+	r := commitChunks(t, st, keepers)
+
+	require.NoError(t, st.BeginGC())
+	defer st.EndGC()
+
+	keepChan := make(chan []hash.Hash, 16)
+	var msErr error
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		msErr = st.MarkAndSweepChunks(ctx, r, keepChan, nil)
+		wg.Done()
+	}()
+	for h := range keepers {
+		keepChan <- []hash.Hash{h}
+	}
+	close(keepChan)
+	wg.Wait()
+	require.NoError(t, msErr)
+
+	// the chunks of the journal that were kept were copied to the table files of the collection
+	_, listed := journalChunkCount(st.upstream.specs)
+	assert.False(t, listed)
+	wr := st.p.(*chunkJournal).wr
+	assert.Equal(t, uint32(0), wr.count(wr.end()))
+
+	assertChunksInStore(t, st, keepers)
+	for h := range tossers {
+		out, err := st.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, chunks.EmptyChunk, out)
+	}
+
+	written := makeChunkSet(64, 64)
+	commitChunks(t, st, written)
+	_, listed = journalChunkCount(st.upstream.specs)
+	assert.True(t, listed)
+	assertChunksInStore(t, st, keepers, written)
+}
@@ -0,0 +1,471 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dolthub/fslock"
+
+	"github.com/dolthub/dolt/go/libraries/utils/file"
+	"github.com/dolthub/dolt/go/store/hash"
+	"github.com/dolthub/dolt/go/store/util/tempfiles"
+)
+
+const (
+	// journalLockFileName is the lock that a process holds for as long as it has the chunk journal of a store open.
+	journalLockFileName = "JOURNAL_LOCK"
+	tempJournalPrefix   = "nbs_journal_"
+)
+
+// journalAddr is the name of the chunk journal. The manifest lists the journal like a table file of that name, with
+// the number of its chunk records that are committed. No table file can have the name, since it isn't a hash.
+var journalAddr = addr(hash.Parse("vvvvvvvvvvvvvvvvvvvvvvvvvvvvvvvv"))
+
+// ErrJournalLocked is returned when opening the chunk journal of a store that another process has open, and when
+// writing to a store that opened its journal read only because of it.
+var ErrJournalLocked = errors.New("the chunk journal of this database is locked by another process")
+
+// journalChunk is the location of a chunk record in the journal file.
+type journalChunk struct {
+	a addr
+	// off and length are the offset and length of the table file chunk record in the chunk record.
+	off    int64
+	length uint32
+	// uncompressed is the uncompressed length of this chunk and every chunk record before it.
+	uncompressed uint64
+	zstd         bool
+}
+
+// journalRoot is a root hash record of the journal.
+type journalRoot struct {
+	root         hash.Hash
+	lock         addr
+	manifestLock addr
+	count        uint32
+}
+
+// journalWriter appends the records of the chunk journal of a store to its file, and indexes its chunk records.
+// Chunk records are numbered in the order they're written, starting from the first chunk record ever written to the
+// journal. Records that are dropped from the front of the journal, once they're rolled into table files, keep their
+// numbers, so that the journalChunkSources of the journal stay valid.
+type journalWriter struct {
+	path string
+	lck  *fslock.Lock
+	// refs is the number of chunkJournals of the writer, which is guarded by journalWriters.mu.
+	refs int
+
+	mu   sync.RWMutex
+	file *os.File
+	// retired are the files that records were dropped from, which snapshots of the journal may still read from.
+	retired []*os.File
+	// off is the offset of the end of the journal file.
+	off int64
+	// base is the number of the first chunk record of the journal file.
+	base   uint64
+	chunks []journalChunk
+	index  map[addr]uint64
+	// root is the last root hash record of the journal, if it has one.
+	root    journalRoot
+	hasRoot bool
+	// snap is the most recent snapshot of the journal.
+	snap *journalSnapshot
+	// readOnly writers were opened while another process had the journal locked. They serve the records that the
+	// journal had when it was opened, and refuse to write.
+	readOnly bool
+}
+
+// openJournalWriter opens the chunk journal file at |path|, creating it if it doesn't exist, and indexes its records.
+// A record at the end of the journal that was torn by a crash is truncated. The journal stays locked until the writer
+// is closed.
+func openJournalWriter(path string) (wr *journalWriter, err error) {
+	lck := fslock.New(filepath.Join(filepath.Dir(path), journalLockFileName))
+	if err = lck.TryLock(); err == fslock.ErrLocked {
+		return nil, ErrJournalLocked
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = lck.Unlock()
+		}
+	}()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+		}
+	}()
+
+	wr, size, err := indexJournal(path, f)
+	if err != nil {
+		return nil, err
+	}
+	wr.lck = lck
+
+	if wr.off < size {
+		// the journal ends with a record that was torn by a crash
+		if err = f.Truncate(wr.off); err != nil {
+			return nil, err
+		}
+		if err = f.Sync(); err != nil {
+			return nil, err
+		}
+	}
+
+	return wr, nil
+}
+
+// openJournalReader opens the chunk journal file at |path| read only, without locking it, and indexes its records.
+// The journal is expected to be locked by another process, which may be appending a record to it, so a record at its
+// end that's incomplete is ignored rather than truncated.
+func openJournalReader(path string) (*journalWriter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	wr, _, err := indexJournal(path, f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	wr.readOnly = true
+	return wr, nil
+}
+
+// indexJournal returns a writer of the journal file |f| at |path| that indexes its records, and the size of the
+// file. The offset of the writer is the end of the last complete record.
+func indexJournal(path string, f *os.File) (*journalWriter, int64, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	wr := &journalWriter{
+		path:  path,
+		file:  f,
+		index: make(map[addr]uint64),
+	}
+
+	size := info.Size()
+	wr.off, err = processJournalRecs(io.NewSectionReader(f, 0, size), 0, size, func(off int64, rec journalRec) error {
+		switch rec.kind {
+		case chunkJournalRecKind:
+			return wr.indexChunk(rec.address, off+chunkJournalRecPayloadOffset, rec.payload)
+		case rootHashJournalRecKind:
+			wr.root = journalRoot{root: rec.root, lock: rec.lock, manifestLock: rec.manifestLock, count: rec.count}
+			wr.hasRoot = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return wr, size, nil
+}
+
+// indexChunk indexes the table file chunk record |payload| of the chunk |a|, which is at |off| of the journal file.
+func (wr *journalWriter) indexChunk(a addr, off int64, payload []byte) error {
+	data := payload[:len(payload)-checksumSize]
+	n, err := decodedLen(data, nil)
+	if err != nil {
+		return err
+	}
+
+	c := journalChunk{a: a, off: off, length: uint32(len(payload)), uncompressed: uint64(n), zstd: isZstdFrame(data)}
+	if len(wr.chunks) > 0 {
+		c.uncompressed += wr.chunks[len(wr.chunks)-1].uncompressed
+	}
+	if _, ok := wr.index[a]; !ok {
+		wr.index[a] = wr.base + uint64(len(wr.chunks))
+	}
+	wr.chunks = append(wr.chunks, c)
+	return nil
+}
+
+// end returns the number after that of the last chunk record of the journal.
+func (wr *journalWriter) end() uint64 {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	return wr.base + uint64(len(wr.chunks))
+}
+
+// count returns the number of chunk records of the journal file before |end|.
+func (wr *journalWriter) count(end uint64) uint32 {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	return wr.countLocked(end)
+}
+
+func (wr *journalWriter) countLocked(end uint64) uint32 {
+	if end <= wr.base {
+		return 0
+	}
+	return uint32(end - wr.base)
+}
+
+// uncompressedLen returns the uncompressed length of the chunk records of the journal file before |end|.
+func (wr *journalWriter) uncompressedLen(end uint64) uint64 {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	n := wr.countLocked(end)
+	if n == 0 {
+		return 0
+	}
+	return wr.chunks[n-1].uncompressed
+}
+
+// lookup returns the location of the chunk record of |a|, if it's before |end|. Callers must hold |wr.mu|.
+func (wr *journalWriter) lookup(a addr, end uint64) (journalChunk, bool) {
+	seq, ok := wr.index[a]
+	if !ok || seq >= end || seq < wr.base {
+		return journalChunk{}, false
+	}
+	return wr.chunks[seq-wr.base], true
+}
+
+func (wr *journalWriter) has(a addr, end uint64) bool {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	_, ok := wr.lookup(a, end)
+	return ok
+}
+
+// getCompressed returns the chunk |a|, if its chunk record is before |end|.
+func (wr *journalWriter) getCompressed(a addr, end uint64) (CompressedChunk, bool, error) {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	c, ok := wr.lookup(a, end)
+	if !ok {
+		return CompressedChunk{}, false, nil
+	}
+
+	buf := make([]byte, c.length)
+	if _, err := wr.file.ReadAt(buf, c.off); err != nil {
+		return CompressedChunk{}, false, err
+	}
+	cc, err := NewCompressedChunk(hash.Hash(a), buf)
+	if err != nil {
+		return CompressedChunk{}, false, err
+	}
+	return cc, true, nil
+}
+
+// ranges returns the ranges of the journal file that the table file chunk records of the chunks of |hashes| whose
+// chunk records are before |end| are in, and removes them from |hashes|.
+func (wr *journalWriter) ranges(hashes hash.HashSet, end uint64) map[hash.Hash]Range {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	ranges := make(map[hash.Hash]Range)
+	for h := range hashes {
+		if c, ok := wr.lookup(addr(h), end); ok {
+			ranges[h] = Range{Offset: uint64(c.off), Length: c.length}
+			delete(hashes, h)
+		}
+	}
+	return ranges
+}
+
+// writeChunks appends chunk records of the chunks of |cs| that the journal doesn't have yet, without syncing them,
+// and returns the number after that of the last chunk record.
+func (wr *journalWriter) writeChunks(cs []CompressedChunk) (uint64, error) {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	if wr.readOnly {
+		return 0, ErrJournalLocked
+	}
+
+	var size uint32
+	novel := make([]CompressedChunk, 0, len(cs))
+	written := make(map[hash.Hash]struct{}, len(cs))
+	for _, c := range cs {
+		if _, ok := wr.index[addr(c.H)]; ok {
+			continue
+		}
+		if _, ok := written[c.H]; ok {
+			continue
+		}
+		written[c.H] = struct{}{}
+		novel = append(novel, c)
+		size += chunkJournalRecSize(c)
+	}
+	if len(novel) == 0 {
+		return wr.base + uint64(len(wr.chunks)), nil
+	}
+
+	buf := make([]byte, size)
+	offs := make([]int64, len(novel))
+	var n uint32
+	for i, c := range novel {
+		offs[i] = wr.off + int64(n)
+		n += writeChunkJournalRec(buf[n:], c)
+	}
+	if _, err := wr.file.WriteAt(buf, wr.off); err != nil {
+		return 0, err
+	}
+
+	for i, c := range novel {
+		start := offs[i] - wr.off
+		rec := buf[start+chunkJournalRecPayloadOffset : start+int64(chunkJournalRecSize(c))-journalRecCRCSz]
+		if err := wr.indexChunk(addr(c.H), offs[i]+chunkJournalRecPayloadOffset, rec); err != nil {
+			return 0, err
+		}
+	}
+	wr.off += int64(size)
+	return wr.base + uint64(len(wr.chunks)), nil
+}
+
+// commitRoot appends a root hash record and syncs the journal file, which makes the chunk records before it durable
+// too.
+func (wr *journalWriter) commitRoot(r journalRoot) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	if wr.readOnly {
+		return ErrJournalLocked
+	}
+
+	buf := make([]byte, rootHashJournalRecSize)
+	n := writeRootHashJournalRec(buf, r.root, r.lock, r.manifestLock, r.count)
+	if _, err := wr.file.WriteAt(buf[:n], wr.off); err != nil {
+		return err
+	}
+	if err := wr.file.Sync(); err != nil {
+		return err
+	}
+	wr.off += int64(n)
+	wr.root, wr.hasRoot = r, true
+	return nil
+}
+
+// latestRoot returns the last root hash record of the journal, if it has one.
+func (wr *journalWriter) latestRoot() (journalRoot, bool) {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	return wr.root, wr.hasRoot
+}
+
+func (wr *journalWriter) sync() error {
+	wr.mu.RLock()
+	defer wr.mu.RUnlock()
+	return wr.file.Sync()
+}
+
+// dropChunks drops the first |n| chunk records of the journal file, once they've been rolled into table files. The
+// records after them, and the last root hash record, are moved to a new journal file, which replaces the journal
+// file.
+func (wr *journalWriter) dropChunks(n uint32) error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	if n == 0 {
+		return nil
+	} else if wr.readOnly {
+		return ErrJournalLocked
+	} else if int(n) > len(wr.chunks) {
+		return fmt.Errorf("cannot drop %d chunk records from a chunk journal of %d", n, len(wr.chunks))
+	}
+
+	last := wr.chunks[n-1]
+	start := last.off + int64(last.length) + journalRecCRCSz
+
+	f, err := tempfiles.MovableTempFileProvider.NewFile(filepath.Dir(wr.path), tempJournalPrefix)
+	if err != nil {
+		return err
+	}
+	// the last root hash record is kept even if it's before the chunk records that are kept
+	var prefix []byte
+	if wr.hasRoot {
+		prefix = make([]byte, rootHashJournalRecSize)
+		writeRootHashJournalRec(prefix, wr.root.root, wr.root.lock, wr.root.manifestLock, wr.root.count)
+	}
+	shift := start - int64(len(prefix))
+
+	err = func() error {
+		if _, err := f.Write(prefix); err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, io.NewSectionReader(wr.file, start, wr.off-start)); err != nil {
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			return err
+		}
+		return file.Rename(f.Name(), wr.path)
+	}()
+	if err != nil {
+		_ = f.Close()
+		_ = file.Remove(f.Name())
+		return err
+	}
+
+	for a, seq := range wr.index {
+		if seq < wr.base+uint64(n) {
+			delete(wr.index, a)
+		}
+	}
+	chunks := make([]journalChunk, len(wr.chunks)-int(n))
+	for i, c := range wr.chunks[n:] {
+		c.off -= shift
+		c.uncompressed -= last.uncompressed
+		chunks[i] = c
+	}
+
+	wr.retired = append(wr.retired, wr.file)
+	wr.file = f
+	wr.chunks = chunks
+	wr.base += uint64(n)
+	wr.off -= shift
+	wr.snap = nil
+	return nil
+}
+
+// reset drops every chunk record of the journal file.
+func (wr *journalWriter) reset() error {
+	wr.mu.RLock()
+	n := uint32(len(wr.chunks))
+	wr.mu.RUnlock()
+	return wr.dropChunks(n)
+}
+
+func (wr *journalWriter) Close() error {
+	wr.mu.Lock()
+	defer wr.mu.Unlock()
+
+	err := wr.file.Close()
+	for _, f := range wr.retired {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	wr.retired = nil
+	if wr.lck != nil {
+		if uerr := wr.lck.Unlock(); err == nil {
+			err = uerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 Dolthub, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nbs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dolthub/dolt/go/store/hash"
+)
+
+func makeJournalChunks(t *testing.T, prefix string, n int) []CompressedChunk {
+	cs := make([]CompressedChunk, n)
+	for i := range cs {
+		cs[i] = makeJournalChunk(t, fmt.Sprintf("%s:%d", prefix, i))
+	}
+	return cs
+}
+
+func openTestJournalWriter(t *testing.T, dir string) *journalWriter {
+	wr, err := openJournalWriter(filepath.Join(dir, journalAddr.String()))
+	require.NoError(t, err)
+	return wr
+}
+
+func assertJournalChunks(t *testing.T, wr *journalWriter, end uint64, cs []CompressedChunk) {
+	for _, c := range cs {
+		out, ok, err := wr.getCompressed(addr(c.H), end)
+		require.NoError(t, err)
+		require.True(t, ok)
+		assert.Equal(t, c.FullCompressedChunk, out.FullCompressedChunk)
+	}
+}
+
+func TestJournalWriterReplay(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	cs := makeJournalChunks(t, "replay", 16)
+	root := journalRoot{root: hash.Of([]byte("root")), lock: computeAddr([]byte("lock")), count: 16}
+
+	wr := openTestJournalWriter(t, dir)
+	end, err := wr.writeChunks(cs)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), end)
+	// chunks that the journal already has aren't written again
+	end, err = wr.writeChunks(cs[:4])
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), end)
+	require.NoError(t, wr.commitRoot(root))
+	size := wr.off
+
+	_, err = openJournalWriter(wr.path)
+	assert.Equal(t, ErrJournalLocked, err)
+	require.NoError(t, wr.Close())
+
+	// append a torn record to the journal
+	f, err := os.OpenFile(wr.path, os.O_WRONLY|os.O_APPEND, 0666)
+	require.NoError(t, err)
+	torn := makeJournalChunk(t, "torn")
+	buf := make([]byte, chunkJournalRecSize(torn))
+	writeChunkJournalRec(buf, torn)
+	_, err = f.Write(buf[:len(buf)-2])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// a reader ignores the torn record, which the process that has the journal open may still be writing
+	rd, err := openJournalReader(wr.path)
+	require.NoError(t, err)
+	assert.Equal(t, size, rd.off)
+	assertJournalChunks(t, rd, rd.end(), cs)
+	_, err = rd.writeChunks([]CompressedChunk{torn})
+	assert.Equal(t, ErrJournalLocked, err)
+	require.NoError(t, rd.Close())
+	info, err := os.Stat(wr.path)
+	require.NoError(t, err)
+	assert.Equal(t, size+int64(len(buf)-2), info.Size())
+
+	wr = openTestJournalWriter(t, dir)
+	defer wr.Close()
+	assert.Equal(t, size, wr.off)
+	assert.Equal(t, uint64(16), wr.end())
+	assert.False(t, wr.has(addr(torn.H), wr.end()))
+	assertJournalChunks(t, wr, wr.end(), cs)
+
+	r, ok := wr.latestRoot()
+	require.True(t, ok)
+	assert.Equal(t, root, r)
+
+	info, err = os.Stat(wr.path)
+	require.NoError(t, err)
+	assert.Equal(t, size, info.Size())
+
+	// chunk records after |end| aren't visible
+	assert.False(t, wr.has(addr(cs[8].H), 8))
+	assert.True(t, wr.has(addr(cs[7].H), 8))
+	assert.Equal(t, uint32(8), wr.count(8))
+}
+
+func TestJournalWriterDropChunks(t *testing.T) {
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	first := makeJournalChunks(t, "first", 8)
+	second := makeJournalChunks(t, "second", 8)
+	root := journalRoot{root: hash.Of([]byte("root")), lock: computeAddr([]byte("lock")), count: 8}
+
+	wr := openTestJournalWriter(t, dir)
+	_, err := wr.writeChunks(first)
+	require.NoError(t, err)
+	require.NoError(t, wr.commitRoot(root))
+	end, err := wr.writeChunks(second)
+	require.NoError(t, err)
+	uncompressed := wr.uncompressedLen(end)
+
+	require.NoError(t, wr.dropChunks(8))
+	assert.Equal(t, uint64(8), wr.base)
+	assert.Equal(t, end, wr.end())
+	assert.Equal(t, uint32(8), wr.count(end))
+	assert.Less(t, wr.uncompressedLen(end), uncompressed)
+	for _, c := range first {
+		assert.False(t, wr.has(addr(c.H), end))
+	}
+	assertJournalChunks(t, wr, end, second)
+	require.NoError(t, wr.Close())
+
+	// the new journal file has the records that were kept and the last root hash record
+	wr = openTestJournalWriter(t, dir)
+	assert.Equal(t, uint64(8), wr.end())
+	assertJournalChunks(t, wr, wr.end(), second)
+	r, ok := wr.latestRoot()
+	require.True(t, ok)
+	assert.Equal(t, root, r)
+
+	require.NoError(t, wr.reset())
+	assert.Equal(t, uint64(8), wr.end())
+	assert.Equal(t, uint32(0), wr.count(wr.end()))
+	require.NoError(t, wr.Close())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{journalAddr.String(), journalLockFileName}, names)
+}
+
+func TestJournalSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := makeTempDir(t)
+	defer os.RemoveAll(dir)
+
+	cs := makeJournalChunks(t, "snapshot", 64)
+	wr := openTestJournalWriter(t, dir)
+	defer wr.Close()
+	_, err := wr.writeChunks(cs[:48])
+	require.NoError(t, err)
+	end := wr.end()
+	_, err = wr.writeChunks(cs[48:])
+	require.NoError(t, err)
+
+	snap, err := wr.snapshot(end)
+	require.NoError(t, err)
+	defer snap.Close()
+	cnt, err := snap.count()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(48), cnt)
+
+	rd, err := snap.reader(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rd)
+	require.NoError(t, err)
+	sz, err := snap.size()
+	require.NoError(t, err)
+	assert.Equal(t, sz, uint64(len(data)))
+
+	// the snapshot is a table file of the chunk records before |end|
+	idx, err := parseTableIndexByCopy(data, &noopQuotaProvider{})
+	require.NoError(t, err)
+	tr, err := newTableReader(idx, tableReaderAtFromBytes(data), fileBlockSize)
+	require.NoError(t, err)
+	defer tr.Close()
+	for i, c := range cs {
+		out, err := tr.get(ctx, addr(c.H), &Stats{})
+		require.NoError(t, err)
+		if i < 48 {
+			expected, err := c.ToChunk()
+			require.NoError(t, err)
+			assert.Equal(t, expected.Data(), out)
+		} else {
+			assert.Nil(t, out)
+		}
+	}
+
+	name, err := snap.hash()
+	require.NoError(t, err)
+	assert.NotEqual(t, journalAddr, name)
+}
@@ -58,7 +58,8 @@ func writeChunksToMT(mt *memTable, chunks []chunks.Chunk) (string, []byte, error
 		return "", nil, err
 	}
 
-	if count != uint32(len(chunks)) {
+	// zstd tables can hold a dictionary record along with the chunks
+	if count < uint32(len(chunks)) {
 		return "", nil, errors.New("didn't write everything")
 	}
 
@@ -175,14 +176,13 @@ func (mt *memTable) extract(ctx context.Context, chunks chan<- extractRecord) er
 	return nil
 }
 
+// write writes the chunks of the memTable that |haver| doesn't have to a table, and returns the table along with the
+// number of records in it.
 func (mt *memTable) write(haver chunkReader, stats *Stats) (name addr, data []byte, count uint32, err error) {
 	numChunks := uint64(len(mt.order))
 	if numChunks == 0 {
 		return addr{}, nil, 0, fmt.Errorf("mem table cannot write with zero chunks")
 	}
-	maxSize := maxTableSize(uint64(len(mt.order)), mt.totalData)
-	buff := make([]byte, maxSize)
-	tw := newTableWriter(buff, mt.snapper)
 
 	if haver != nil {
 		sort.Sort(hasRecordByPrefix(mt.order)) // hasMany() requires addresses to be sorted.
@@ -195,11 +195,30 @@ func (mt *memTable) write(haver chunkReader, stats *Stats) (name addr, data []by
 		sort.Sort(hasRecordByOrder(mt.order)) // restore "insertion" order for write
 	}
 
+	var tw *tableWriter
+	if defaultTableCodec == zstdCodec {
+		dict := mt.trainDictionary()
+
+		maxSize := maxZstdTableSize(uint64(len(mt.order)), mt.totalData)
+		if dict != nil {
+			maxSize = maxZstdTableSize(uint64(len(mt.order))+1, mt.totalData+uint64(len(dict.content)))
+		}
+
+		tw, err = newZstdTableWriter(make([]byte, maxSize), dict)
+
+		if err != nil {
+			return addr{}, nil, 0, err
+		}
+	} else {
+		tw = newTableWriter(make([]byte, maxTableSize(uint64(len(mt.order)), mt.totalData)), mt.snapper)
+	}
+
+	var chunkCount uint64
 	for _, addr := range mt.order {
 		if !addr.has {
 			h := addr.a
 			tw.addChunk(*h, mt.chunks[*h])
-			count++
+			chunkCount++
 		}
 	}
 	tableSize, name, err := tw.finish()
@@ -208,14 +227,33 @@ func (mt *memTable) write(haver chunkReader, stats *Stats) (name addr, data []by
 		return addr{}, nil, 0, err
 	}
 
-	if count > 0 {
+	if chunkCount > 0 {
 		stats.BytesPerPersist.Sample(uint64(tableSize))
 		stats.CompressedChunkBytesPerPersist.Sample(uint64(tw.totalCompressedData))
 		stats.UncompressedChunkBytesPerPersist.Sample(uint64(tw.totalUncompressedData))
-		stats.ChunksPerPersist.Sample(uint64(count))
+		stats.ChunksPerPersist.Sample(chunkCount)
 	}
 
-	return name, buff[:tableSize], count, nil
+	return name, tw.buff[:tableSize], uint32(len(tw.prefixes)), nil
+}
+
+// trainDictionary returns a dictionary trained on the chunks of the memTable that will be written, or nil if there
+// aren't enough of them to train one.
+func (mt *memTable) trainDictionary() *Dictionary {
+	var samples [][]byte
+	var sampled int
+	for _, r := range mt.order {
+		if r.has {
+			continue
+		}
+		data := mt.chunks[*r.a]
+		samples = append(samples, data)
+		sampled += len(data)
+		if sampled >= dictionarySampleSize {
+			break
+		}
+	}
+	return trainDictionary(samples)
 }
 
 func (mt *memTable) Close() error {
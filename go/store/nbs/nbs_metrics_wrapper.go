@@ -40,6 +40,7 @@ func NewNBSMetricWrapper(nbs *NomsBlockStore) *NBSMetricWrapper {
 
 var _ TableFileStore = &NBSMetricWrapper{}
 var _ chunks.ChunkStoreGarbageCollector = &NBSMetricWrapper{}
+var _ chunks.OnlineGarbageCollector = &NBSMetricWrapper{}
 
 // Sources retrieves the current root hash, a list of all the table files,
 // and a list of the appendix table files.
@@ -75,6 +76,16 @@ func (nbsMW *NBSMetricWrapper) MarkAndSweepChunks(ctx context.Context, last hash
 	return nbsMW.nbs.MarkAndSweepChunks(ctx, last, keepChunks, dest)
 }
 
+// BeginGC forwards to the wrapped block store.
+func (nbsMW *NBSMetricWrapper) BeginGC() error {
+	return nbsMW.nbs.BeginGC()
+}
+
+// EndGC forwards to the wrapped block store.
+func (nbsMW *NBSMetricWrapper) EndGC() {
+	nbsMW.nbs.EndGC()
+}
+
 // PruneTableFiles deletes old table files that are no longer referenced in the manifest.
 func (nbsMW *NBSMetricWrapper) PruneTableFiles(ctx context.Context) error {
 	return nbsMW.nbs.PruneTableFiles(ctx)
@@ -131,6 +131,57 @@ func (s *Stats) Clone() Stats {
 	}
 }
 
+// Add adds the samples of every histogram of |other| to the histograms of |s|.
+func (s *Stats) Add(other Stats) {
+	hs, others := s.histograms(), other.histograms()
+	for i := range hs {
+		hs[i].Add(others[i])
+	}
+}
+
+func (s *Stats) histograms() []*metrics.Histogram {
+	return []*metrics.Histogram{
+		&s.OpenLatency,
+		&s.CommitLatency,
+		&s.IndexReadLatency,
+		&s.IndexBytesPerRead,
+		&s.GetLatency,
+		&s.ChunksPerGet,
+		&s.FileReadLatency,
+		&s.FileBytesPerRead,
+		&s.S3ReadLatency,
+		&s.S3BytesPerRead,
+		&s.MemReadLatency,
+		&s.MemBytesPerRead,
+		&s.DynamoReadLatency,
+		&s.DynamoBytesPerRead,
+		&s.HasLatency,
+		&s.AddressesPerHas,
+		&s.PutLatency,
+		&s.PersistLatency,
+		&s.BytesPerPersist,
+		&s.ChunksPerPersist,
+		&s.CompressedChunkBytesPerPersist,
+		&s.UncompressedChunkBytesPerPersist,
+		&s.ConjoinLatency,
+		&s.BytesPerConjoin,
+		&s.ChunksPerConjoin,
+		&s.TablesPerConjoin,
+		&s.ReadManifestLatency,
+		&s.WriteManifestLatency,
+	}
+}
+
+// CompressionRatio returns the ratio of the uncompressed size of the chunks persisted to table files to their
+// compressed size, or 0 if no chunks have been persisted.
+func (s Stats) CompressionRatio() float64 {
+	compressed := s.CompressedChunkBytesPerPersist.Sum()
+	if compressed == 0 {
+		return 0
+	}
+	return float64(s.UncompressedChunkBytesPerPersist.Sum()) / float64(compressed)
+}
+
 func (s Stats) String() string {
 	return fmt.Sprintf(`---NBS Stats---
 OpenLatecy:                       %s
@@ -155,6 +206,7 @@ BytesPerPersist:                  %s
 ChunksPerPersist:                 %s
 CompressedChunkBytesPerPersist:   %s
 UncompressedChunkBytesPerPersist: %s
+CompressionRatio:                 %.2f
 ConjoinLatency:                   %s
 BytesPerConjoin:                  %s
 ChunksPerConjoin:                 %s
@@ -194,6 +246,7 @@ WriteManifestLatency:             %s
 		s.ChunksPerPersist,
 		s.CompressedChunkBytesPerPersist,
 		s.UncompressedChunkBytesPerPersist,
+		s.CompressionRatio(),
 
 		s.ConjoinLatency,
 		s.BytesPerConjoin,
@@ -102,11 +102,20 @@ type NomsBlockStore struct {
 	mtSize   uint64
 	putCount uint64
 
+	// gcWriteMu is held for reading by every write to the store, and held for writing while an online garbage
+	// collection swaps in its table files.
+	gcWriteMu sync.RWMutex
+
+	gcMu         sync.Mutex   // protects the following state
+	gcKeepers    hash.HashSet // chunks written since BeginGC, nil if no online collection is running
+	gcAddedSpecs []tableSpec  // table files added to the manifest since BeginGC
+
 	stats *Stats
 }
 
 var _ TableFileStore = &NomsBlockStore{}
 var _ chunks.ChunkStoreGarbageCollector = &NomsBlockStore{}
+var _ chunks.OnlineGarbageCollector = &NomsBlockStore{}
 
 type Range struct {
 	Offset uint64
@@ -139,6 +148,11 @@ func (nbs *NomsBlockStore) GetChunkLocations(hashes hash.HashSet) (map[hash.Hash
 						delete(hashes, h)
 					}
 
+					err = addDictionaryRanges(y, tr.tableIndex)
+					if err != nil {
+						return err
+					}
+
 					if len(offsetRecSlice) > 0 {
 						gr = toGetRecords(hashes)
 					}
@@ -171,12 +185,34 @@ func (nbs *NomsBlockStore) GetChunkLocations(hashes hash.HashSet) (map[hash.Hash
 					}
 				}
 
+				if len(foundHashes) > 0 {
+					err = addDictionaryRanges(y, tableIndex)
+					if err != nil {
+						return err
+					}
+				}
+
 				ranges[hash.Hash(tr.h)] = y
 
 				for _, h := range foundHashes {
 					delete(hashes, h)
 				}
 
+			case journalChunkSource:
+				// chunks in the journal are served from the chunk records of the journal file
+				found := tr.wr.ranges(hashes, tr.end)
+				if len(found) > 0 {
+					y, ok := ranges[hash.Hash(journalAddr)]
+					if !ok {
+						y = make(map[hash.Hash]Range)
+					}
+					for h, r := range found {
+						y[h] = r
+					}
+					ranges[hash.Hash(journalAddr)] = y
+					gr = toGetRecords(hashes)
+				}
+
 			default:
 				panic(reflect.TypeOf(cs))
 			}
@@ -207,7 +243,53 @@ func (nbs *NomsBlockStore) GetChunkLocations(hashes hash.HashSet) (map[hash.Hash
 	return ranges, nil
 }
 
+// addDictionaryRanges adds the ranges of the dictionary records of a table file to the |ranges| of chunks found in it,
+// since the chunks may need them to be decompressed.
+func addDictionaryRanges(ranges map[hash.Hash]Range, index tableIndex) error {
+	dictRanges, err := dictionaryRanges(index)
+	if err != nil {
+		return err
+	}
+	for h, r := range dictRanges {
+		ranges[h] = r
+	}
+	return nil
+}
+
+// GetChunkLocationsWithPaths returns the locations of |hashes| within the table files of the store, keyed by the
+// path of each table file relative to the store's directory. Hashes that are found are removed from |hashes|.
+func (nbs *NomsBlockStore) GetChunkLocationsWithPaths(hashes hash.HashSet) (map[string]map[hash.Hash]Range, error) {
+	locs, err := nbs.GetChunkLocations(hashes)
+	if err != nil {
+		return nil, err
+	}
+	toret := make(map[string]map[hash.Hash]Range, len(locs))
+	for k, v := range locs {
+		toret[k.String()] = v
+	}
+	return toret, nil
+}
+
+// Path returns the directory holding the table files of the store, and false if the store is not backed by a local
+// directory.
+func (nbs *NomsBlockStore) Path() (string, bool) {
+	if fsPersister, ok := localTablePersister(nbs.p); ok {
+		return fsPersister.dir, true
+	}
+	return "", false
+}
+
 func (nbs *NomsBlockStore) UpdateManifest(ctx context.Context, updates map[hash.Hash]uint32) (mi ManifestInfo, err error) {
+	nbs.gcWriteMu.RLock()
+	defer nbs.gcWriteMu.RUnlock()
+	defer func() {
+		if err == nil {
+			for h, count := range updates {
+				nbs.keepTableForGC(tableSpec{addr(h), count})
+			}
+		}
+	}()
+
 	nbs.mm.LockForUpdate()
 	defer func() {
 		unlockErr := nbs.mm.UnlockForUpdate()
@@ -495,10 +577,20 @@ func NewBSStore(ctx context.Context, nbfVerStr string, bs blobstore.Blobstore, m
 	return newNomsBlockStore(ctx, nbfVerStr, mm, p, q, inlineConjoiner{defaultMaxTables}, memTableSize)
 }
 
+// NewLocalStore returns a store of the database in |dir|. It never creates a chunk journal, which is only created by
+// NewLocalJournalingStore when EnvChunkJournal is set, but a database that already has one has its commits in it, so
+// the store reads it and writes its commits to it. If another process has the journal open, the store is read only.
 func NewLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSize uint64, q MemoryQuotaProvider) (*NomsBlockStore, error) {
 	return newLocalStore(ctx, nbfVerStr, dir, memTableSize, defaultMaxTables, q)
 }
 
+// NewLocalJournalingStore returns a store of the database in |dir| that writes its commits to a chunk journal,
+// which is created if the database doesn't have one yet. Only one process can write to the chunk journal of a
+// database at a time, and the stores of other processes open it read only.
+func NewLocalJournalingStore(ctx context.Context, nbfVerStr string, dir string, memTableSize uint64, q MemoryQuotaProvider) (*NomsBlockStore, error) {
+	return newLocalJournalingStore(ctx, nbfVerStr, dir, memTableSize, defaultMaxTables, q)
+}
+
 func newLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSize uint64, maxTables int, q MemoryQuotaProvider) (*NomsBlockStore, error) {
 	cacheOnce.Do(makeGlobalCaches)
 	err := checkDir(dir)
@@ -507,6 +599,14 @@ func newLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSi
 		return nil, err
 	}
 
+	journaled, err := chunkJournalExists(dir)
+
+	if err != nil {
+		return nil, err
+	} else if journaled {
+		return newLocalJournalingStore(ctx, nbfVerStr, dir, memTableSize, maxTables, q)
+	}
+
 	m, err := getFileManifest(ctx, dir)
 
 	if err != nil {
@@ -524,6 +624,39 @@ func newLocalStore(ctx context.Context, nbfVerStr string, dir string, memTableSi
 	return nbs, nil
 }
 
+func newLocalJournalingStore(ctx context.Context, nbfVerStr string, dir string, memTableSize uint64, maxTables int, q MemoryQuotaProvider) (*NomsBlockStore, error) {
+	cacheOnce.Do(makeGlobalCaches)
+	err := checkDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the manifest is read by the journal, but it has to be in a format that it can read
+	_, err = getFileManifest(ctx, dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := newFSTablePersister(dir, globalFDCache, q).(*fsTablePersister)
+	j, err := openChunkJournal(ctx, dir, p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mm := makeManifestManager(j)
+	nbs, err := newNomsBlockStore(ctx, nbfVerStr, mm, j, q, inlineConjoiner{maxTables}, memTableSize)
+
+	if err != nil {
+		_ = j.Close()
+		return nil, err
+	}
+
+	return nbs, nil
+}
+
 func checkDir(dir string) error {
 	stat, err := os.Stat(dir)
 	if err != nil {
@@ -598,6 +731,9 @@ func (nbs *NomsBlockStore) WithoutConjoiner() *NomsBlockStore {
 }
 
 func (nbs *NomsBlockStore) Put(ctx context.Context, c chunks.Chunk) error {
+	nbs.gcWriteMu.RLock()
+	defer nbs.gcWriteMu.RUnlock()
+
 	t1 := time.Now()
 	a := addr(c.Hash())
 	success := nbs.addChunk(ctx, a, c.Data())
@@ -606,6 +742,7 @@ func (nbs *NomsBlockStore) Put(ctx context.Context, c chunks.Chunk) error {
 		return errors.New("failed to add chunk")
 	}
 
+	nbs.keepForGC(c.Hash())
 	nbs.putCount++
 
 	nbs.stats.PutLatency.SampleTimeSince(t1)
@@ -942,6 +1079,9 @@ func (nbs *NomsBlockStore) Root(ctx context.Context) (hash.Hash, error) {
 }
 
 func (nbs *NomsBlockStore) Commit(ctx context.Context, current, last hash.Hash) (success bool, err error) {
+	nbs.gcWriteMu.RLock()
+	defer nbs.gcWriteMu.RUnlock()
+
 	t1 := time.Now()
 	defer nbs.stats.CommitLatency.SampleTimeSince(t1)
 
@@ -1154,7 +1294,13 @@ func (nbs *NomsBlockStore) Version() string {
 }
 
 func (nbs *NomsBlockStore) Close() error {
-	return nbs.tables.Close()
+	err := nbs.tables.Close()
+	if j, ok := nbs.p.(*chunkJournal); ok {
+		if jerr := j.Close(); err == nil {
+			err = jerr
+		}
+	}
+	return err
 }
 
 func (nbs *NomsBlockStore) Stats() interface{} {
@@ -1211,6 +1357,13 @@ func (nbs *NomsBlockStore) Sources(ctx context.Context) (hash.Hash, []TableFile,
 		return hash.Hash{}, nil, nil, err
 	}
 
+	if j, ok := nbs.p.(*chunkJournal); ok {
+		contents, err = j.snapshotSpecs(contents, css)
+		if err != nil {
+			return hash.Hash{}, nil, nil, err
+		}
+	}
+
 	appendixTableFiles, err := getTableFiles(css, contents, contents.NumAppendixSpecs(), func(mc manifestContents, idx int) tableSpec {
 		return mc.getAppendixSpec(idx)
 	})
@@ -1320,7 +1473,7 @@ func (nbs *NomsBlockStore) chunkSourcesByAddr() (map[addr]chunkSource, error) {
 }
 
 func (nbs *NomsBlockStore) SupportedOperations() TableFileStoreOps {
-	_, ok := nbs.p.(*fsTablePersister)
+	_, ok := localTablePersister(nbs.p)
 	return TableFileStoreOps{
 		CanRead:  true,
 		CanWrite: ok,
@@ -1331,7 +1484,7 @@ func (nbs *NomsBlockStore) SupportedOperations() TableFileStoreOps {
 
 // WriteTableFile will read a table file from the provided reader and write it to the TableFileStore
 func (nbs *NomsBlockStore) WriteTableFile(ctx context.Context, fileId string, numChunks int, contentHash []byte, getRd func() (io.ReadCloser, uint64, error)) error {
-	fsPersister, ok := nbs.p.(*fsTablePersister)
+	fsPersister, ok := localTablePersister(nbs.p)
 
 	if !ok {
 		return errors.New("Not implemented")
@@ -1467,11 +1620,13 @@ func (nbs *NomsBlockStore) MarkAndSweepChunks(ctx context.Context, last hash.Has
 		return chunks.ErrUnsupportedOperation
 	}
 
+	online := nbs.gcInProgress()
+
 	precheck := func() error {
 		nbs.mu.RLock()
 		defer nbs.mu.RUnlock()
 
-		if nbs.upstream.root != last {
+		if !online && nbs.upstream.root != last {
 			return errLastRootMismatch
 		}
 
@@ -1498,7 +1653,7 @@ func (nbs *NomsBlockStore) MarkAndSweepChunks(ctx context.Context, last hash.Has
 		}
 	}
 
-	specs, err := nbs.copyMarkedChunks(ctx, keepChunks, destNBS)
+	gcc, err := nbs.copyMarkedChunks(ctx, keepChunks)
 	if err != nil {
 		return err
 	}
@@ -1506,8 +1661,22 @@ func (nbs *NomsBlockStore) MarkAndSweepChunks(ctx context.Context, last hash.Has
 		return ctx.Err()
 	}
 
+	if destNBS == nbs && online {
+		return nbs.finishOnlineGC(ctx, gcc)
+	}
+
+	destPersister, ok := localTablePersister(destNBS.p)
+	if !ok {
+		return chunks.ErrUnsupportedOperation
+	}
+
+	specs, err := gcc.copyTablesToDir(ctx, destPersister.dir)
+	if err != nil {
+		return err
+	}
+
 	if destNBS == nbs {
-		err = nbs.swapTables(ctx, specs)
+		err = nbs.swapTables(ctx, specs, nil)
 		if err != nil {
 			return err
 		}
@@ -1534,7 +1703,7 @@ func (nbs *NomsBlockStore) MarkAndSweepChunks(ctx context.Context, last hash.Has
 	}
 }
 
-func (nbs *NomsBlockStore) copyMarkedChunks(ctx context.Context, keepChunks <-chan []hash.Hash, dest *NomsBlockStore) ([]tableSpec, error) {
+func (nbs *NomsBlockStore) copyMarkedChunks(ctx context.Context, keepChunks <-chan []hash.Hash) (*gcCopier, error) {
 	gcc, err := newGarbageCollectionCopier()
 	if err != nil {
 		return nil, err
@@ -1547,31 +1716,156 @@ LOOP:
 			if !ok {
 				break LOOP
 			}
-			var addErr error
-			mu := new(sync.Mutex)
-			hashset := hash.NewHashSet(hs...)
-			err := nbs.GetManyCompressed(ctx, hashset, func(ctx context.Context, c CompressedChunk) {
-				mu.Lock()
-				defer mu.Unlock()
-				if addErr != nil {
-					return
-				}
-				addErr = gcc.addChunk(ctx, c)
-			})
+			err = nbs.copyChunks(ctx, hash.NewHashSet(hs...), gcc)
 			if err != nil {
 				return nil, err
 			}
-			if addErr != nil {
-				return nil, addErr
-			}
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		}
 	}
 
-	nomsDir := dest.p.(*fsTablePersister).dir
+	return gcc, nil
+}
+
+func (nbs *NomsBlockStore) copyChunks(ctx context.Context, hashes hash.HashSet, gcc *gcCopier) error {
+	var addErr error
+	mu := new(sync.Mutex)
+	err := nbs.GetManyCompressed(ctx, hashes, func(ctx context.Context, c CompressedChunk) {
+		mu.Lock()
+		defer mu.Unlock()
+		if addErr != nil {
+			return
+		}
+		addErr = gcc.addChunk(ctx, c)
+	})
+	if err != nil {
+		return err
+	}
+	return addErr
+}
+
+// BeginGC implements chunks.OnlineGarbageCollector. Until EndGC is called, every chunk put into the store, and every
+// table file added to its manifest, is kept by MarkAndSweepChunks.
+func (nbs *NomsBlockStore) BeginGC() error {
+	nbs.gcMu.Lock()
+	defer nbs.gcMu.Unlock()
+	if nbs.gcKeepers != nil {
+		return chunks.ErrGCInProgress
+	}
+	nbs.gcKeepers = make(hash.HashSet)
+	nbs.gcAddedSpecs = nil
+	return nil
+}
+
+// EndGC implements chunks.OnlineGarbageCollector.
+func (nbs *NomsBlockStore) EndGC() {
+	nbs.gcMu.Lock()
+	defer nbs.gcMu.Unlock()
+	nbs.gcKeepers = nil
+	nbs.gcAddedSpecs = nil
+}
+
+func (nbs *NomsBlockStore) gcInProgress() bool {
+	nbs.gcMu.Lock()
+	defer nbs.gcMu.Unlock()
+	return nbs.gcKeepers != nil
+}
+
+func (nbs *NomsBlockStore) keepForGC(h hash.Hash) {
+	nbs.gcMu.Lock()
+	defer nbs.gcMu.Unlock()
+	if nbs.gcKeepers != nil {
+		nbs.gcKeepers.Insert(h)
+	}
+}
+
+func (nbs *NomsBlockStore) keepTableForGC(spec tableSpec) {
+	nbs.gcMu.Lock()
+	defer nbs.gcMu.Unlock()
+	if nbs.gcKeepers != nil {
+		nbs.gcAddedSpecs = append(nbs.gcAddedSpecs, spec)
+	}
+}
+
+// takeGCKeepers returns the chunks put into the store since the last call, and the table files added to its
+// manifest since BeginGC.
+func (nbs *NomsBlockStore) takeGCKeepers() (hash.HashSet, []tableSpec) {
+	nbs.gcMu.Lock()
+	defer nbs.gcMu.Unlock()
+	keepers := nbs.gcKeepers
+	nbs.gcKeepers = make(hash.HashSet)
+	return keepers, nbs.gcAddedSpecs
+}
+
+// copyGCKeepers adds the chunks put into the store since the last call to |gcc|, skipping the ones it already holds.
+func (nbs *NomsBlockStore) copyGCKeepers(ctx context.Context, gcc *gcCopier) ([]tableSpec, error) {
+	keepers, added := nbs.takeGCKeepers()
+	for h := range keepers {
+		if gcc.hasChunk(h) {
+			keepers.Remove(h)
+		}
+	}
+	err := nbs.copyChunks(ctx, keepers, gcc)
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// finishOnlineGC adds the chunks written during an online garbage collection to the chunks marked by it, and swaps
+// the store's table files for the ones holding them. Writes to the store are blocked only for the final copy and the
+// swap, so that none of them are lost.
+func (nbs *NomsBlockStore) finishOnlineGC(ctx context.Context, gcc *gcCopier) error {
+	_, err := nbs.copyGCKeepers(ctx, gcc)
+	if err != nil {
+		return err
+	}
+
+	nbs.gcWriteMu.Lock()
+	defer nbs.gcWriteMu.Unlock()
+
+	added, err := nbs.copyGCKeepers(ctx, gcc)
+	if err != nil {
+		return err
+	}
+
+	fsPersister, ok := localTablePersister(nbs.p)
+	if !ok {
+		return chunks.ErrUnsupportedOperation
+	}
+	specs, err := gcc.copyTablesToDir(ctx, fsPersister.dir)
+	if err != nil {
+		return err
+	}
+
+	oldSpecs := func() manifestContents {
+		nbs.mu.RLock()
+		defer nbs.mu.RUnlock()
+		return nbs.upstream
+	}().getSpecSet()
+
+	err = nbs.swapTables(ctx, specs, added)
+	if err != nil {
+		return err
+	}
+
+	// Other table files in the directory may be in the middle of being written and added to the manifest, so only
+	// the files replaced by this collection are removed.
+	newSpecs := func() manifestContents {
+		nbs.mu.RLock()
+		defer nbs.mu.RUnlock()
+		return nbs.upstream
+	}().getSpecSet()
+
+	var removed []addr
+	for a := range oldSpecs {
+		if _, ok := newSpecs[a]; !ok {
+			removed = append(removed, a)
+		}
+	}
 
-	return gcc.copyTablesToDir(ctx, nomsDir)
+	return fsPersister.removeTableFiles(ctx, removed)
 }
 
 // todo: what's the optimal table size to copy to?
@@ -1591,7 +1885,9 @@ func (nbs *NomsBlockStore) gcTableSize() (uint64, error) {
 	return nbs.mtSize, nil
 }
 
-func (nbs *NomsBlockStore) swapTables(ctx context.Context, specs []tableSpec) (err error) {
+// swapTables replaces the table files of the store with |specs|, keeping the table files in |keep| that are still in
+// the manifest.
+func (nbs *NomsBlockStore) swapTables(ctx context.Context, specs []tableSpec, keep []tableSpec) (err error) {
 	nbs.mm.LockForUpdate()
 	defer func() {
 		unlockErr := nbs.mm.UnlockForUpdate()
@@ -1603,6 +1899,23 @@ func (nbs *NomsBlockStore) swapTables(ctx context.Context, specs []tableSpec) (e
 	nbs.mu.Lock()
 	defer nbs.mu.Unlock()
 
+	if len(keep) > 0 {
+		current := nbs.upstream.getSpecSet()
+		swapped := make(map[addr]struct{}, len(specs))
+		for _, spec := range specs {
+			swapped[spec.name] = struct{}{}
+		}
+		for _, spec := range keep {
+			if _, ok := current[spec.name]; !ok {
+				continue
+			}
+			if _, ok := swapped[spec.name]; !ok {
+				specs = append(specs, spec)
+				swapped[spec.name] = struct{}{}
+			}
+		}
+	}
+
 	newLock := generateLockHash(nbs.upstream.root, specs, []tableSpec{})
 	newContents := manifestContents{
 		nbfVers: nbs.upstream.nbfVers,
@@ -1647,6 +1960,9 @@ func (nbs *NomsBlockStore) swapTables(ctx context.Context, specs []tableSpec) (e
 
 // SetRootChunk changes the root chunk hash from the previous value to the new root.
 func (nbs *NomsBlockStore) SetRootChunk(ctx context.Context, root, previous hash.Hash) error {
+	nbs.gcWriteMu.RLock()
+	defer nbs.gcWriteMu.RUnlock()
+
 	nbs.mu.Lock()
 	defer nbs.mu.Unlock()
 	for {
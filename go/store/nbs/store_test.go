@@ -296,6 +296,111 @@ func TestNBSCopyGC(t *testing.T) {
 	}
 }
 
+func TestNBSOnlineGC(t *testing.T) {
+	ctx := context.Background()
+	st, _, _ := makeTestLocalStore(t, 8)
+
+	keepers := makeChunkSet(64, 64)
+	tossers := makeChunkSet(64, 64)
+	written := makeChunkSet(64, 64)
+
+	for _, c := range keepers {
+		require.NoError(t, st.Put(ctx, c))
+	}
+	for _, c := range tossers {
+		require.NoError(t, st.Put(ctx, c))
+	}
+
+	r, err := st.Root(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, st.BeginGC())
+	defer st.EndGC()
+	assert.Equal(t, chunks.ErrGCInProgress, st.BeginGC())
+
+	keepChan := make(chan []hash.Hash, 16)
+	var msErr error
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		msErr = st.MarkAndSweepChunks(ctx, r, keepChan, nil)
+		wg.Done()
+	}()
+	for h := range keepers {
+		keepChan <- []hash.Hash{h}
+	}
+
+	// chunks written and committed while the collection runs are kept, even though they were not marked
+	var newRoot hash.Hash
+	for h, c := range written {
+		require.NoError(t, st.Put(ctx, c))
+		newRoot = h
+	}
+	ok, err := st.Commit(ctx, newRoot, r)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	close(keepChan)
+	wg.Wait()
+	require.NoError(t, msErr)
+
+	root, err := st.Root(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, newRoot, root)
+
+	for _, set := range []map[hash.Hash]chunks.Chunk{keepers, written} {
+		for h, c := range set {
+			out, err := st.Get(ctx, h)
+			require.NoError(t, err)
+			assert.Equal(t, c, out)
+		}
+	}
+	for h := range tossers {
+		out, err := st.Get(ctx, h)
+		require.NoError(t, err)
+		assert.Equal(t, chunks.EmptyChunk, out)
+	}
+}
+
+func TestValueStoreOnlineGC(t *testing.T) {
+	ctx := context.Background()
+	st, err := NewLocalStore(ctx, types.Format_Default.VersionString(), t.TempDir(), defaultMemTableSize, NewUnlimitedMemQuotaProvider())
+	require.NoError(t, err)
+	vs := types.NewValueStore(st)
+
+	committed, err := vs.WriteValue(ctx, types.String("committed"))
+	require.NoError(t, err)
+	rt, err := vs.Root(ctx)
+	require.NoError(t, err)
+	ok, err := vs.Commit(ctx, committed.TargetHash(), rt)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	unreferenced, err := vs.WriteValue(ctx, types.String("unreferenced"))
+	require.NoError(t, err)
+	ok, err = vs.Commit(ctx, committed.TargetHash(), committed.TargetHash())
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, vs.BeginGC())
+	defer vs.EndGC()
+
+	// a value written during the collection may still be buffered by the value store when it runs
+	pending, err := vs.WriteValue(ctx, types.String("pending"))
+	require.NoError(t, err)
+	err = vs.GC(ctx, hash.HashSet{}, hash.HashSet{}, hash.HashSet{})
+	require.NoError(t, err)
+
+	for _, r := range []types.Ref{committed, pending} {
+		v, err := vs.ReadValue(ctx, r.TargetHash())
+		require.NoError(t, err)
+		assert.NotNil(t, v)
+	}
+	v, err := vs.ReadValue(ctx, unreferenced.TargetHash())
+	require.NoError(t, err)
+	assert.Nil(t, v)
+}
+
 func persistTableFileSources(t *testing.T, p tablePersister, numTableFiles int) (map[hash.Hash]uint32, []hash.Hash) {
 	tableFileMap := make(map[hash.Hash]uint32, numTableFiles)
 	mapIds := make([]hash.Hash, numTableFiles)
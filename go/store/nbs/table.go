@@ -51,6 +51,9 @@ import (
    | (Chunk Length) Chunk Data | (Uint32) CRC32 |
    +---------------------------+----------------+
 
+     -Chunk Data is compressed with snappy, or is a zstd frame when the Table's codec is zstd. zstd frames can be
+      compressed with one of the dictionaries of the Table, which are stored as records of their own (see dictionary.go).
+
    Index:
    +------------+---------+----------+
    | Prefix Map | Lengths | Suffixes |
@@ -98,6 +101,15 @@ import (
      -Total Uncompressed Chunk Data is the sum of the uncompressed byte lengths of all contained chunk byte slices.
      -Magic Number is the first 8 bytes of the SHA256 hash of "https://github.com/attic-labs/nbs".
 
+   Footer V2:
+   +----------------------+----------------------------------------+-----------+---------------------+
+   | (Uint32) Chunk Count | (Uint64) Total Uncompressed Chunk Data | (1) Codec | (7) Magic Number V2 |
+   +----------------------+----------------------------------------+-----------+---------------------+
+
+     -Tables that aren't compressed with snappy have a V2 Footer, which is the same size as the original one.
+     -Codec is the tableCodec that the Table's chunk records are compressed with.
+     -Magic Number V2 is the first 7 bytes of the SHA256 hash of "https://github.com/dolthub/dolt/nbs/v2".
+
     NOTE: Unsigned integer quanities, hashes and hash suffix are all encoded big-endian
 
 
@@ -133,6 +145,8 @@ const (
 	offsetSize      = uint64Size
 	magicNumber     = "\xff\xb5\xd8\xc2\x24\x63\xee\x50"
 	magicNumberSize = 8 //len(magicNumber)
+	magicNumberV2   = "\x2e\x8c\x2b\xad\xd8\x40\xc7"
+	codecSize       = 1
 	footerSize      = uint32Size + uint64Size + magicNumberSize
 	prefixTupleSize = addrPrefixSize + ordinalSize
 	checksumSize    = uint32Size
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"sync"
@@ -63,6 +64,9 @@ type tableIndex interface {
 	// TotalUncompressedData returns the total uncompressed data size of
 	// the table file. Used for informational statistics only.
 	TotalUncompressedData() uint64
+	// Codec returns the codec that the chunk records of the indexed file
+	// are compressed with.
+	Codec() tableCodec
 
 	// Close releases any resources used by this tableIndex.
 	Close() error
@@ -73,21 +77,35 @@ type tableIndex interface {
 }
 
 func ReadTableFooter(rd io.ReadSeeker) (chunkCount uint32, totalUncompressedData uint64, err error) {
+	chunkCount, totalUncompressedData, _, err = readTableFooter(rd)
+	return
+}
+
+// readTableFooter reads the footer of a table file in either format, returning the codec of the file along with its
+// chunk count and total uncompressed data.
+func readTableFooter(rd io.ReadSeeker) (chunkCount uint32, totalUncompressedData uint64, codec tableCodec, err error) {
 	footerSize := int64(magicNumberSize + uint64Size + uint32Size)
 	_, err = rd.Seek(-footerSize, io.SeekEnd)
 
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, 0, err
 	}
 
 	footer, err := iohelp.ReadNBytes(rd, int(footerSize))
 
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, 0, err
 	}
 
-	if string(footer[uint32Size+uint64Size:]) != magicNumber {
-		return 0, 0, ErrInvalidTableFile
+	if string(footer[uint32Size+uint64Size:]) == magicNumber {
+		codec = snappyCodec
+	} else if string(footer[uint32Size+uint64Size+codecSize:]) == magicNumberV2 {
+		codec = tableCodec(footer[uint32Size+uint64Size])
+		if !codec.valid() {
+			return 0, 0, 0, fmt.Errorf("%w: %s", ErrInvalidTableFile, codec)
+		}
+	} else {
+		return 0, 0, 0, ErrInvalidTableFile
 	}
 
 	chunkCount = binary.BigEndian.Uint32(footer)
@@ -108,7 +126,7 @@ func indexMemSize(chunkCount uint32) uint64 {
 // and footer and its length must match the expected indexSize for the chunkCount specified in the footer.
 // Retains the buffer and does not allocate new memory except for offsets, computes on buff in place.
 func parseTableIndex(buff []byte, q MemoryQuotaProvider) (onHeapTableIndex, error) {
-	chunkCount, totalUncompressedData, err := ReadTableFooter(bytes.NewReader(buff))
+	chunkCount, totalUncompressedData, codec, err := readTableFooter(bytes.NewReader(buff))
 	if err != nil {
 		return onHeapTableIndex{}, err
 	}
@@ -122,13 +140,13 @@ func parseTableIndex(buff []byte, q MemoryQuotaProvider) (onHeapTableIndex, erro
 	chunks1 := chunkCount - chunks2
 	offsetsBuff1 := make([]byte, chunks1*offsetSize)
 
-	return newOnHeapTableIndex(buff, offsetsBuff1, chunkCount, totalUncompressedData, q)
+	return newOnHeapTableIndex(buff, offsetsBuff1, chunkCount, totalUncompressedData, codec, q)
 }
 
 // similar to parseTableIndex except that it uses the given |offsetsBuff1|
 // instead of allocating the additional space.
 func parseTableIndexWithOffsetBuff(buff []byte, offsetsBuff1 []byte, q MemoryQuotaProvider) (onHeapTableIndex, error) {
-	chunkCount, totalUncompressedData, err := ReadTableFooter(bytes.NewReader(buff))
+	chunkCount, totalUncompressedData, codec, err := readTableFooter(bytes.NewReader(buff))
 	if err != nil {
 		return onHeapTableIndex{}, err
 	}
@@ -138,7 +156,7 @@ func parseTableIndexWithOffsetBuff(buff []byte, offsetsBuff1 []byte, q MemoryQuo
 		return onHeapTableIndex{}, err
 	}
 
-	return newOnHeapTableIndex(buff, offsetsBuff1, chunkCount, totalUncompressedData, q)
+	return newOnHeapTableIndex(buff, offsetsBuff1, chunkCount, totalUncompressedData, codec, q)
 }
 
 func removeFooter(p []byte, chunkCount uint32) (out []byte, err error) {
@@ -160,7 +178,7 @@ func parseTableIndexByCopy(buff []byte, q MemoryQuotaProvider) (onHeapTableIndex
 // ReadTableIndexByCopy loads an index into memory from an io.ReadSeeker
 // Caution: Allocates new memory for entire index
 func ReadTableIndexByCopy(rd io.ReadSeeker, q MemoryQuotaProvider) (onHeapTableIndex, error) {
-	chunkCount, totalUncompressedData, err := ReadTableFooter(rd)
+	chunkCount, totalUncompressedData, codec, err := readTableFooter(rd)
 	if err != nil {
 		return onHeapTableIndex{}, err
 	}
@@ -179,7 +197,7 @@ func ReadTableIndexByCopy(rd io.ReadSeeker, q MemoryQuotaProvider) (onHeapTableI
 	chunks1 := chunkCount - chunks2
 	offsets1Buff := make([]byte, chunks1*offsetSize)
 
-	return newOnHeapTableIndex(buff, offsets1Buff, chunkCount, totalUncompressedData, q)
+	return newOnHeapTableIndex(buff, offsets1Buff, chunkCount, totalUncompressedData, codec, q)
 }
 
 type onHeapTableIndex struct {
@@ -195,6 +213,7 @@ type onHeapTableIndex struct {
 	suffixB               []byte
 	chunkCount            uint32
 	totalUncompressedData uint64
+	codec                 tableCodec
 }
 
 var _ tableIndex = &onHeapTableIndex{}
@@ -206,7 +225,7 @@ var _ tableIndex = &onHeapTableIndex{}
 // additional space) and the rest into the region of |indexBuff| previously
 // occupied by lengths. |onHeapTableIndex| computes directly on the given
 // |indexBuff| and |offsetsBuff1| buffers.
-func newOnHeapTableIndex(indexBuff []byte, offsetsBuff1 []byte, chunkCount uint32, totalUncompressedData uint64, q MemoryQuotaProvider) (onHeapTableIndex, error) {
+func newOnHeapTableIndex(indexBuff []byte, offsetsBuff1 []byte, chunkCount uint32, totalUncompressedData uint64, codec tableCodec, q MemoryQuotaProvider) (onHeapTableIndex, error) {
 	tuples := indexBuff[:prefixTupleSize*chunkCount]
 	lengths := indexBuff[prefixTupleSize*chunkCount : prefixTupleSize*chunkCount+lengthSize*chunkCount]
 	suffixes := indexBuff[prefixTupleSize*chunkCount+lengthSize*chunkCount:]
@@ -241,6 +260,7 @@ func newOnHeapTableIndex(indexBuff []byte, offsetsBuff1 []byte, chunkCount uint3
 		suffixB:               suffixes,
 		chunkCount:            chunkCount,
 		totalUncompressedData: totalUncompressedData,
+		codec:                 codec,
 	}, nil
 }
 
@@ -531,6 +551,10 @@ func (ti onHeapTableIndex) TotalUncompressedData() uint64 {
 	return ti.totalUncompressedData
 }
 
+func (ti onHeapTableIndex) Codec() tableCodec {
+	return ti.codec
+}
+
 func (ti onHeapTableIndex) Close() error {
 	cnt := atomic.AddInt32(ti.refCnt, -1)
 	if cnt == 0 {
@@ -188,6 +188,8 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 
 	prefixIndexRecs := make(prefixIndexSlice, 0, plan.chunkCount)
 	var ordinalOffset uint32
+	// the conjoined table holds zstd records if any of its sources do
+	codec := snappyCodec
 	for _, sws := range plan.sources.sws {
 		var index tableIndex
 		index, err = sws.source.index()
@@ -196,6 +198,10 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 			return compactionPlan{}, err
 		}
 
+		if index.Codec() == zstdCodec {
+			codec = zstdCodec
+		}
+
 		ordinals, err := index.Ordinals()
 		if err != nil {
 			return compactionPlan{}, err
@@ -265,7 +271,7 @@ func planConjoin(sources chunkSources, stats *Stats) (plan compactionPlan, err e
 		pfxPos += ordinalSize
 	}
 
-	writeFooter(plan.mergedIndex[uint64(len(plan.mergedIndex))-footerSize:], plan.chunkCount, totalUncompressedData)
+	writeFooter(plan.mergedIndex[uint64(len(plan.mergedIndex))-footerSize:], plan.chunkCount, totalUncompressedData, codec)
 
 	stats.BytesPerConjoin.Sample(uint64(plan.totalCompressedData) + uint64(len(plan.mergedIndex)))
 	return plan, nil
@@ -75,3 +75,42 @@ func TestPlanCompaction(t *testing.T) {
 		assertChunksInReader(content, tr, assert)
 	}
 }
+
+func TestPlanCompactionMixedCodecs(t *testing.T) {
+	assert := assert.New(t)
+	snappyContent := [][]byte{[]byte("hello2"), []byte("goodbye2"), []byte("badbye2")}
+	zstdContent := dictionaryTestChunks(100)
+
+	var sources chunkSources
+	for i, content := range [][][]byte{snappyContent, zstdContent} {
+		build := buildTable
+		if i == 1 {
+			build = buildZstdTable
+		}
+		data, name, err := build(content)
+		require.NoError(t, err)
+		ti, err := parseTableIndexByCopy(data, &noopQuotaProvider{})
+		require.NoError(t, err)
+		tr, err := newTableReader(ti, tableReaderAtFromBytes(data), fileBlockSize)
+		require.NoError(t, err)
+		sources = append(sources, chunkSourceAdapter{tr, name})
+	}
+
+	plan, err := planConjoin(sources, &Stats{})
+	require.NoError(t, err)
+
+	idx, err := parseTableIndex(plan.mergedIndex, &noopQuotaProvider{})
+	require.NoError(t, err)
+	assert.Equal(zstdCodec, idx.Codec())
+
+	// the dictionary of the zstd table is kept along with its chunks
+	assert.Equal(uint32(len(snappyContent)+len(zstdContent)+1), idx.chunkCount)
+	ranges, err := dictionaryRanges(idx)
+	require.NoError(t, err)
+	assert.Len(ranges, 1)
+
+	tr, err := newTableReader(idx, tableReaderAtFromBytes(nil), fileBlockSize)
+	require.NoError(t, err)
+	assertChunksInReader(snappyContent, tr, assert)
+	assertChunksInReader(zstdContent, tr, assert)
+}
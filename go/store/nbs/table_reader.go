@@ -27,6 +27,7 @@ import (
 	"errors"
 	"io"
 	"sort"
+	"sync"
 	"sync/atomic"
 
 	"github.com/golang/snappy"
@@ -39,7 +40,7 @@ import (
 // Do not read more than 128MB at a time.
 const maxReadSize = 128 * 1024 * 1024
 
-// CompressedChunk represents a chunk of data in a table file which is still compressed via snappy.
+// CompressedChunk represents a chunk of data in a table file which is still compressed, with snappy or zstd.
 type CompressedChunk struct {
 	// H is the hash of the chunk
 	H hash.Hash
@@ -47,8 +48,12 @@ type CompressedChunk struct {
 	// FullCompressedChunk is the entirety of the compressed chunk data including the crc
 	FullCompressedChunk []byte
 
-	// CompressedData is just the snappy encoded byte buffer that stores the chunk data
+	// CompressedData is just the snappy encoded byte buffer or zstd frame that stores the chunk data
 	CompressedData []byte
+
+	// dict is the dictionary of the table file of the chunk that CompressedData was compressed with, if it's a zstd
+	// frame that was compressed with one
+	dict *Dictionary
 }
 
 // NewCompressedChunk creates a CompressedChunk
@@ -65,9 +70,35 @@ func NewCompressedChunk(h hash.Hash, buff []byte) (CompressedChunk, error) {
 	return CompressedChunk{H: h, FullCompressedChunk: buff, CompressedData: compressedData}, nil
 }
 
-// ToChunk snappy decodes the compressed data and returns a chunks.Chunk
+// NewCompressedChunkWithDictionary creates a CompressedChunk from a chunk record of a table file, using |resolve| to
+// find the dictionary of the table file that it was compressed with, if it was compressed with one.
+func NewCompressedChunkWithDictionary(h hash.Hash, buff []byte, resolve DictionaryResolver) (CompressedChunk, error) {
+	cmp, err := NewCompressedChunk(h, buff)
+	if err != nil {
+		return CompressedChunk{}, err
+	}
+
+	if !isZstdFrame(cmp.CompressedData) {
+		return cmp, nil
+	}
+
+	id, err := zstdDictionaryID(cmp.CompressedData)
+	if err != nil {
+		return CompressedChunk{}, err
+	}
+	if id != 0 {
+		cmp.dict, err = resolve(id)
+		if err != nil {
+			return CompressedChunk{}, err
+		}
+	}
+
+	return cmp, nil
+}
+
+// ToChunk decompresses the compressed data and returns a chunks.Chunk
 func (cmp CompressedChunk) ToChunk() (chunks.Chunk, error) {
-	data, err := snappy.Decode(nil, cmp.CompressedData)
+	data, err := decompress(cmp.CompressedData, cmp.dict)
 
 	if err != nil {
 		return chunks.Chunk{}, err
@@ -78,10 +109,33 @@ func (cmp CompressedChunk) ToChunk() (chunks.Chunk, error) {
 
 func ChunkToCompressedChunk(chunk chunks.Chunk) CompressedChunk {
 	compressed := snappy.Encode(nil, chunk.Data())
+	length := len(compressed)
+	compressed = appendChecksum(compressed)
+	return CompressedChunk{H: chunk.Hash(), FullCompressedChunk: compressed, CompressedData: compressed[:length]}
+}
+
+// compressChunk returns |chunk| compressed with |codec|. zstd chunks are compressed with |dict| if it isn't nil.
+func compressChunk(chunk chunks.Chunk, codec tableCodec, dict *Dictionary) (CompressedChunk, error) {
+	compressed, err := compress(nil, chunk.Data(), codec, dict)
+	if err != nil {
+		return CompressedChunk{}, err
+	}
+	length := len(compressed)
+	compressed = appendChecksum(compressed)
+
+	cmp := CompressedChunk{H: chunk.Hash(), FullCompressedChunk: compressed, CompressedData: compressed[:length]}
+	if codec == zstdCodec {
+		cmp.dict = dict
+	}
+	return cmp, nil
+}
+
+// appendChecksum appends the checksum of the chunk record data |compressed| to it.
+func appendChecksum(compressed []byte) []byte {
 	length := len(compressed)
 	compressed = append(compressed, []byte{0, 0, 0, 0}...)
 	binary.BigEndian.PutUint32(compressed[length:], crc(compressed[:length]))
-	return CompressedChunk{H: chunk.Hash(), FullCompressedChunk: compressed, CompressedData: compressed[:length]}
+	return compressed
 }
 
 // Hash returns the hash of the data
@@ -137,6 +191,14 @@ type tableReader struct {
 	totalUncompressedData uint64
 	r                     tableReaderAt
 	blockSize             uint64
+	dicts                 *tableDictionaries
+}
+
+// tableDictionaries holds the dictionaries of a zstd table file that have been loaded, which happens the first time
+// that one of its chunk records refers to them.
+type tableDictionaries struct {
+	mu     sync.Mutex
+	loaded map[uint32]*Dictionary
 }
 
 // newTableReader parses a valid nbs table byte stream and returns a reader. buff must end with an NBS index
@@ -154,6 +216,7 @@ func newTableReader(index tableIndex, r tableReaderAt, blockSize uint64) (tableR
 		index.TotalUncompressedData(),
 		r,
 		blockSize,
+		&tableDictionaries{loaded: make(map[uint32]*Dictionary)},
 	}, nil
 }
 
@@ -246,7 +309,7 @@ func (tr tableReader) get(ctx context.Context, h addr, stats *Stats) ([]byte, er
 		return nil, errors.New("failed to read all data")
 	}
 
-	cmp, err := NewCompressedChunk(hash.Hash(h), buff)
+	cmp, err := NewCompressedChunkWithDictionary(hash.Hash(h), buff, tr.dictionaryResolver(ctx, stats))
 
 	if err != nil {
 		return nil, err
@@ -328,8 +391,9 @@ func (tr tableReader) readAtOffsetsWithCB(
 		return errors.New("failed to read all data")
 	}
 
+	resolve := tr.dictionaryResolver(ctx, stats)
 	for i := range rb {
-		cmp, err := rb.ExtractChunkFromRead(buff, i)
+		cmp, err := rb.ExtractChunkFromRead(buff, i, resolve)
 		if err != nil {
 			return err
 		}
@@ -407,10 +471,10 @@ func (r readBatch) End() uint64 {
 	return last.offset + uint64(last.length)
 }
 
-func (s readBatch) ExtractChunkFromRead(buff []byte, idx int) (CompressedChunk, error) {
+func (s readBatch) ExtractChunkFromRead(buff []byte, idx int, resolve DictionaryResolver) (CompressedChunk, error) {
 	rec := s[idx]
 	chunkStart := rec.offset - s.Start()
-	return NewCompressedChunk(hash.Hash(*rec.a), buff[chunkStart:chunkStart+uint64(rec.length)])
+	return NewCompressedChunkWithDictionary(hash.Hash(*rec.a), buff[chunkStart:chunkStart+uint64(rec.length)], resolve)
 }
 
 func toReadBatches(offsets offsetRecSlice, blockSize uint64) []readBatch {
@@ -606,7 +670,7 @@ func (tr tableReader) extract(ctx context.Context, chunks chan<- extractRecord)
 		if uint32(n) != or.length {
 			return errors.New("did not read all data")
 		}
-		cmp, err := NewCompressedChunk(hash.Hash(*or.a), buff)
+		cmp, err := NewCompressedChunkWithDictionary(hash.Hash(*or.a), buff, tr.dictionaryResolver(ctx, &Stats{}))
 
 		if err != nil {
 			return err
@@ -629,6 +693,9 @@ func (tr tableReader) extract(ctx context.Context, chunks chan<- extractRecord)
 		if err != nil {
 			return err
 		}
+		if tr.isDictionary(*a) {
+			continue
+		}
 		ors = append(ors, offsetRec{a, e.Offset(), e.Length()})
 	}
 	sort.Sort(ors)
@@ -642,6 +709,58 @@ func (tr tableReader) extract(ctx context.Context, chunks chan<- extractRecord)
 	return nil
 }
 
+// isDictionary returns whether the record of the table at |a| is a dictionary rather than a chunk.
+func (tr tableReader) isDictionary(a addr) bool {
+	return tr.Codec() == zstdCodec && isDictionaryAddr(a)
+}
+
+// dictionaryResolver returns the DictionaryResolver of the chunk records of the table.
+func (tr tableReader) dictionaryResolver(ctx context.Context, stats *Stats) DictionaryResolver {
+	return func(id uint32) (*Dictionary, error) {
+		return tr.dictionary(ctx, id, stats)
+	}
+}
+
+// dictionary returns the dictionary of the table that zstd frames refer to by |id|, loading it if it hasn't been.
+func (tr tableReader) dictionary(ctx context.Context, id uint32, stats *Stats) (*Dictionary, error) {
+	tr.dicts.mu.Lock()
+	defer tr.dicts.mu.Unlock()
+
+	if d, ok := tr.dicts.loaded[id]; ok {
+		return d, nil
+	}
+
+	// dictionaries are indexed first, since their addresses have the lowest prefix
+	for i := uint32(0); i < tr.chunkCount && tr.prefixes[i] == dictionaryAddrPrefix; i++ {
+		var a addr
+		e, err := tr.IndexEntry(i, &a)
+		if err != nil {
+			return nil, err
+		}
+		if dictionaryID(a) != id {
+			continue
+		}
+
+		record := make([]byte, e.Length())
+		n, err := tr.r.ReadAtWithStats(ctx, record, int64(e.Offset()), stats)
+		if err != nil {
+			return nil, err
+		}
+		if n != len(record) {
+			return nil, errors.New("failed to read all data")
+		}
+
+		d, err := NewDictionary(hash.Hash(a), record)
+		if err != nil {
+			return nil, err
+		}
+		tr.dicts.loaded[id] = d
+		return d, nil
+	}
+
+	return nil, ErrMissingDictionary
+}
+
 func (tr tableReader) reader(ctx context.Context) (io.Reader, error) {
 	i, _ := tr.index()
 	return io.LimitReader(&readerAdapter{tr.r, 0, ctx}, int64(i.TableFileSize())), nil
@@ -664,7 +783,7 @@ func (tr tableReader) Clone() (tableReader, error) {
 	if err != nil {
 		return tableReader{}, err
 	}
-	return tableReader{ti, tr.prefixes, tr.chunkCount, tr.totalUncompressedData, tr.r, tr.blockSize}, nil
+	return tableReader{ti, tr.prefixes, tr.chunkCount, tr.totalUncompressedData, tr.r, tr.blockSize, tr.dicts}, nil
 }
 
 type readerAdapter struct {
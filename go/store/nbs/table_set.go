@@ -416,9 +416,52 @@ func (ts tableSet) Flatten(ctx context.Context) (tableSet, error) {
 	}
 
 	flattened.upstream = append(flattened.upstream, ts.upstream...)
+
+	// the novel and upstream sources of the chunk journal are all views of it, the newest of which has all of their
+	// chunks
+	var err error
+	flattened.upstream, err = newestSources(flattened.upstream)
+	if err != nil {
+		return tableSet{}, err
+	}
 	return flattened, nil
 }
 
+// newestSources returns |css| without the sources that have the same name as a larger source, which are the older
+// sources of the chunk journal.
+func newestSources(css chunkSources) (chunkSources, error) {
+	largest := make(map[addr]int, len(css))
+	counts := make([]uint32, len(css))
+	for i, cs := range css {
+		h, err := cs.hash()
+		if err != nil {
+			return nil, err
+		}
+		counts[i], err = cs.count()
+		if err != nil {
+			return nil, err
+		}
+		if j, ok := largest[h]; !ok || counts[i] > counts[j] {
+			largest[h] = i
+		}
+	}
+	if len(largest) == len(css) {
+		return css, nil
+	}
+
+	newest := make(chunkSources, 0, len(largest))
+	for i, cs := range css {
+		h, err := cs.hash()
+		if err != nil {
+			return nil, err
+		}
+		if largest[h] == i {
+			newest = append(newest, cs)
+		}
+	}
+	return newest, nil
+}
+
 // Rebase returns a new tableSet holding the novel tables managed by |ts| and
 // those specified by |specs|.
 func (ts tableSet) Rebase(ctx context.Context, specs []tableSpec, stats *Stats) (tableSet, error) {
@@ -473,7 +516,12 @@ OUTER:
 			if err != nil {
 				return tableSet{}, err
 			}
-			if spec.name == h {
+			cnt, err := existing.count()
+			if err != nil {
+				return tableSet{}, err
+			}
+			// sources of the chunk journal that have a different count are different views of it
+			if spec.name == h && cnt == spec.chunkCount {
 				c, err := existing.Clone()
 				if err != nil {
 					return tableSet{}, err
@@ -483,7 +531,9 @@ OUTER:
 			}
 		}
 		openOps = append(openOps, openOp{idx, spec})
-		memoryNeeded += indexMemSize(spec.chunkCount)
+		if spec.name != journalAddr {
+			memoryNeeded += indexMemSize(spec.chunkCount)
+		}
 	}
 
 	err := ts.q.AcquireQuota(ctx, memoryNeeded)
@@ -574,5 +624,27 @@ func (ts tableSet) ToSpecs() ([]tableSpec, error) {
 
 		tableSpecs = append(tableSpecs, tableSpec{h, cnt})
 	}
-	return tableSpecs, nil
+	return newestSpecs(tableSpecs), nil
+}
+
+// newestSpecs returns |specs| with only the largest spec of each name, which is the newest view of the chunk
+// journal if it has more than one.
+func newestSpecs(specs []tableSpec) []tableSpec {
+	largest := make(map[addr]int, len(specs))
+	for i, s := range specs {
+		if j, ok := largest[s.name]; !ok || s.chunkCount > specs[j].chunkCount {
+			largest[s.name] = i
+		}
+	}
+	if len(largest) == len(specs) {
+		return specs
+	}
+
+	newest := make([]tableSpec, 0, len(largest))
+	for i, s := range specs {
+		if largest[s.name] == i {
+			newest = append(newest, s)
+		}
+	}
+	return newest
 }
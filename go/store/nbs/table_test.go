@@ -22,6 +22,7 @@
 package nbs
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -412,3 +413,108 @@ func TestEmpty(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(length == footerSize)
 }
+
+func buildZstdTable(chunks [][]byte) ([]byte, addr, error) {
+	totalData := uint64(0)
+	for _, chunk := range chunks {
+		totalData += uint64(len(chunk))
+	}
+
+	dict := trainDictionary(chunks)
+	numRecords := uint64(len(chunks))
+	if dict != nil {
+		numRecords++
+		totalData += uint64(len(dict.content))
+	}
+	buff := make([]byte, maxZstdTableSize(numRecords, totalData))
+
+	tw, err := newZstdTableWriter(buff, dict)
+	if err != nil {
+		return nil, addr{}, err
+	}
+
+	for _, chunk := range chunks {
+		tw.addChunk(computeAddr(chunk), chunk)
+	}
+
+	length, blockHash, err := tw.finish()
+
+	if err != nil {
+		return nil, addr{}, err
+	}
+
+	return buff[:length], blockHash, nil
+}
+
+func TestZstdTable(t *testing.T) {
+	ctx := context.Background()
+	chunks := dictionaryTestChunks(100)
+
+	tableData, _, err := buildZstdTable(chunks)
+	require.NoError(t, err)
+	ti, err := parseTableIndexByCopy(tableData, &noopQuotaProvider{})
+	require.NoError(t, err)
+	assert.Equal(t, zstdCodec, ti.Codec())
+	assert.Equal(t, uint32(len(chunks)+1), ti.ChunkCount())
+
+	ranges, err := dictionaryRanges(ti)
+	require.NoError(t, err)
+	assert.Len(t, ranges, 1)
+
+	tr, err := newTableReader(ti, tableReaderAtFromBytes(tableData), fileBlockSize)
+	require.NoError(t, err)
+
+	assertChunksInReader(chunks, tr, assert.New(t))
+	for _, c := range chunks {
+		data, err := tr.get(ctx, computeAddr(c), &Stats{})
+		require.NoError(t, err)
+		assert.Equal(t, c, data)
+	}
+
+	hashes := make(hash.HashSet)
+	for _, c := range chunks {
+		hashes.Insert(hash.Hash(computeAddr(c)))
+	}
+	found, err := readAllChunks(ctx, hashes, tr)
+	require.NoError(t, err)
+	assert.Len(t, found, len(chunks))
+
+	chunkChan := make(chan extractRecord)
+	go func() {
+		err := tr.extract(ctx, chunkChan)
+		require.NoError(t, err)
+		close(chunkChan)
+	}()
+
+	extracted := 0
+	for rec := range chunkChan {
+		assert.False(t, isDictionaryAddr(rec.a))
+		assert.Equal(t, computeAddr(rec.data), rec.a)
+		extracted++
+	}
+	assert.Equal(t, len(chunks), extracted)
+}
+
+func TestReadTableFooter(t *testing.T) {
+	snappyData, _, err := buildTable([][]byte{[]byte("hello2"), []byte("goodbye2")})
+	require.NoError(t, err)
+	count, unc, codec, err := readTableFooter(bytes.NewReader(snappyData))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), count)
+	assert.Equal(t, uint64(len("hello2")+len("goodbye2")), unc)
+	assert.Equal(t, snappyCodec, codec)
+	assert.Equal(t, magicNumber, string(snappyData[len(snappyData)-magicNumberSize:]))
+
+	chunks := dictionaryTestChunks(100)
+	zstdData, _, err := buildZstdTable(chunks)
+	require.NoError(t, err)
+	count, _, codec, err = readTableFooter(bytes.NewReader(zstdData))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(len(chunks)+1), count)
+	assert.Equal(t, zstdCodec, codec)
+
+	// the codec precedes the v2 magic number
+	zstdData[len(zstdData)-magicNumberSize] = 0xff
+	_, _, _, err = readTableFooter(bytes.NewReader(zstdData))
+	assert.ErrorIs(t, err, ErrInvalidTableFile)
+}
@@ -44,6 +44,9 @@ type tableWriter struct {
 	blockHash             hash.Hash
 
 	snapper snappyEncoder
+	codec   tableCodec
+	// dict is the dictionary that the chunks of zstd tables are compressed with, if they're compressed with one.
+	dict *Dictionary
 }
 
 type snappyEncoder interface {
@@ -64,6 +67,13 @@ func maxTableSize(numChunks, totalData uint64) uint64 {
 	return numChunks*(prefixTupleSize+lengthSize+addrSuffixSize+checksumSize+uint64(maxSnappySize)) + footerSize
 }
 
+func maxZstdTableSize(numChunks, totalData uint64) uint64 {
+	avgChunkSize := totalData / numChunks
+	d.Chk.True(avgChunkSize < maxChunkSize)
+	maxZstdSize := zstdMaxEncodedLen(int(avgChunkSize))
+	return numChunks*(prefixTupleSize+lengthSize+addrSuffixSize+checksumSize+uint64(maxZstdSize)) + footerSize
+}
+
 func indexSize(numChunks uint32) uint64 {
 	return uint64(numChunks) * (addrSuffixSize + lengthSize + prefixTupleSize)
 }
@@ -85,7 +95,29 @@ func newTableWriter(buff []byte, snapper snappyEncoder) *tableWriter {
 		buff:      buff,
 		blockHash: sha512.New(),
 		snapper:   snapper,
+		codec:     snappyCodec,
+	}
+}
+
+// newZstdTableWriter returns a tableWriter that compresses chunks with zstd, and |dict| if it isn't nil. The
+// dictionary is the first record of the table. len(buff) must be >= maxZstdTableSize(numChunks, totalData),
+// counting the dictionary as a chunk.
+func newZstdTableWriter(buff []byte, dict *Dictionary) (*tableWriter, error) {
+	tw := &tableWriter{
+		buff:      buff,
+		blockHash: sha512.New(),
+		codec:     zstdCodec,
+		dict:      dict,
 	}
+	if dict != nil {
+		record, err := dict.record()
+		if err != nil {
+			return nil, err
+		}
+		tw.addRecord(dict.a, record)
+		tw.totalCompressedData += uint64(len(record) - checksumSize)
+	}
+	return tw, nil
 }
 
 func (tw *tableWriter) addChunk(h addr, data []byte) bool {
@@ -93,6 +125,10 @@ func (tw *tableWriter) addChunk(h addr, data []byte) bool {
 		panic("NBS blocks cannont be zero length")
 	}
 
+	if tw.codec == zstdCodec {
+		return tw.addZstdChunk(h, data)
+	}
+
 	// Compress data straight into tw.buff
 	compressed := tw.snapper.Encode(tw.buff[tw.pos:], data)
 	dataLength := uint64(len(compressed))
@@ -124,6 +160,39 @@ func (tw *tableWriter) addChunk(h addr, data []byte) bool {
 	return true
 }
 
+func (tw *tableWriter) addZstdChunk(h addr, data []byte) bool {
+	// zstd appends into the capacity of tw.buff[tw.pos:tw.pos], which maxZstdTableSize() leaves enough room for
+	compressed, err := compress(tw.buff[tw.pos:tw.pos], data, zstdCodec, tw.dict)
+	if err != nil {
+		panic(err)
+	}
+	if uint64(len(compressed)+checksumSize) > uint64(len(tw.buff))-tw.pos {
+		panic(fmt.Errorf("unbuffered chunk %s: uncompressed %d, compressed %d, tw.buff %d", h.String(), len(data), len(compressed), len(tw.buff[tw.pos:])))
+	}
+	dataLength := uint64(copy(tw.buff[tw.pos:], compressed))
+	tw.totalCompressedData += dataLength
+	tw.totalUncompressedData += uint64(len(data))
+
+	// checksum (4 LSBytes, big-endian)
+	binary.BigEndian.PutUint32(tw.buff[tw.pos+dataLength:], crc(tw.buff[tw.pos:tw.pos+dataLength]))
+	tw.addRecord(h, tw.buff[tw.pos:tw.pos+dataLength+checksumSize])
+
+	return true
+}
+
+// addRecord adds the chunk record |record|, which is already in tw.buff if it's at tw.pos.
+func (tw *tableWriter) addRecord(h addr, record []byte) {
+	tw.pos += uint64(copy(tw.buff[tw.pos:], record))
+
+	// Stored in insertion order
+	tw.prefixes = append(tw.prefixes, prefixIndexRec{
+		h.Prefix(),
+		h[addrPrefixSize:],
+		uint32(len(tw.prefixes)),
+		uint32(len(record)),
+	})
+}
+
 func (tw *tableWriter) finish() (uncompressedLength uint64, blockAddr addr, err error) {
 	err = tw.writeIndex()
 
@@ -195,10 +264,12 @@ func (tw *tableWriter) writeIndex() error {
 }
 
 func (tw *tableWriter) writeFooter() {
-	tw.pos += writeFooter(tw.buff[tw.pos:], uint32(len(tw.prefixes)), tw.totalUncompressedData)
+	tw.pos += writeFooter(tw.buff[tw.pos:], uint32(len(tw.prefixes)), tw.totalUncompressedData, tw.codec)
 }
 
-func writeFooter(dst []byte, chunkCount uint32, uncData uint64) (consumed uint64) {
+// writeFooter writes the footer of a table compressed with |codec|. Tables compressed with snappy get the original
+// footer, so that versions of Dolt that predate table codecs can read them.
+func writeFooter(dst []byte, chunkCount uint32, uncData uint64, codec tableCodec) (consumed uint64) {
 	// chunk count
 	binary.BigEndian.PutUint32(dst[consumed:], chunkCount)
 	consumed += uint32Size
@@ -207,8 +278,17 @@ func writeFooter(dst []byte, chunkCount uint32, uncData uint64) (consumed uint64
 	binary.BigEndian.PutUint64(dst[consumed:], uncData)
 	consumed += uint64Size
 
-	// magic number
-	copy(dst[consumed:], magicNumber)
-	consumed += magicNumberSize
+	if codec == snappyCodec {
+		// magic number
+		copy(dst[consumed:], magicNumber)
+		consumed += magicNumberSize
+		return
+	}
+
+	// codec and v2 magic number
+	dst[consumed] = byte(codec)
+	consumed += codecSize
+	copy(dst[consumed:], magicNumberV2)
+	consumed += magicNumberSize - codecSize
 	return
 }
@@ -32,6 +32,15 @@ func IterChunks(rd io.ReadSeeker, cb func(chunk chunks.Chunk) (stop bool, err er
 
 	defer idx.Close()
 
+	// dictionaries are indexed before the chunks that are compressed with them
+	dicts := make(map[uint32]*Dictionary)
+	resolve := func(id uint32) (*Dictionary, error) {
+		if d, ok := dicts[id]; ok {
+			return d, nil
+		}
+		return nil, ErrMissingDictionary
+	}
+
 	seen := make(map[addr]bool)
 	for i := uint32(0); i < idx.ChunkCount(); i++ {
 		var a addr
@@ -46,7 +55,16 @@ func IterChunks(rd io.ReadSeeker, cb func(chunk chunks.Chunk) (stop bool, err er
 				return err
 			}
 
-			cmpChnk, err := NewCompressedChunk(hash.Hash(a), chunkBytes)
+			if idx.Codec() == zstdCodec && isDictionaryAddr(a) {
+				d, err := NewDictionary(hash.Hash(a), chunkBytes)
+				if err != nil {
+					return err
+				}
+				dicts[d.ID()] = d
+				continue
+			}
+
+			cmpChnk, err := NewCompressedChunkWithDictionary(hash.Hash(a), chunkBytes, resolve)
 			if err != nil {
 				return err
 			}
@@ -41,6 +41,12 @@ const (
 	ArtifactTypeUniqueKeyViol
 	// ArtifactTypeChkConsViol is the type for check constraint violations.
 	ArtifactTypeChkConsViol
+	// ArtifactTypeSchemaConvViol is the type for rows that could not be
+	// converted to the schema of their table during a merge.
+	ArtifactTypeSchemaConvViol
+	// ArtifactTypeDefinitionViol is the type for views, triggers and stored
+	// procedures whose definitions are no longer valid after a merge.
+	ArtifactTypeDefinitionViol
 )
 
 type ArtifactMap struct {
@@ -189,7 +195,7 @@ func (m ArtifactMap) IterAll(ctx context.Context) (ArtifactIter, error) {
 }
 
 func (m ArtifactMap) IterAllCVs(ctx context.Context) (ArtifactIter, error) {
-	itr, err := m.iterAllOfTypes(ctx, ArtifactTypeForeignKeyViol, ArtifactTypeUniqueKeyViol, ArtifactTypeChkConsViol)
+	itr, err := m.iterAllOfTypes(ctx, ArtifactTypeForeignKeyViol, ArtifactTypeUniqueKeyViol, ArtifactTypeChkConsViol, ArtifactTypeSchemaConvViol, ArtifactTypeDefinitionViol)
 	if err != nil {
 		return nil, err
 	}
@@ -505,7 +511,7 @@ var _ ArtifactIter = multiArtifactTypeItr{}
 
 // newMultiArtifactTypeItr creates an iter that iterates an artifact if its type exists in |types|.
 func newMultiArtifactTypeItr(itr ArtifactIter, types []ArtifactType) multiArtifactTypeItr {
-	members := make([]bool, 5)
+	members := make([]bool, ArtifactTypeDefinitionViol+1)
 	for _, t := range types {
 		members[uint8(t)] = true
 	}
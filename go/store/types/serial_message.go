@@ -218,6 +218,23 @@ func (sm SerialMessage) walkRefs(nbf *NomsBinFormat, cb RefCallback) error {
 				return err
 			}
 		}
+		rebaseState := msg.RebaseState(nil)
+		if rebaseState != nil {
+			for _, addrBytes := range [][]byte{
+				rebaseState.PreWorkingRootAddrBytes(),
+				rebaseState.OntoCommitAddrBytes(),
+				rebaseState.RebasedHeadAddrBytes(),
+			} {
+				addr = hash.New(addrBytes)
+				r, err = constructRef(nbf, addr, PrimitiveTypeMap[ValueKind], SerialMessageRefHeight)
+				if err != nil {
+					return err
+				}
+				if err = cb(r); err != nil {
+					return err
+				}
+			}
+		}
 	case serial.RootValueFileID:
 		msg := serial.GetRootAsRootValue([]byte(sm), serial.MessagePrefixSz)
 		err := SerialMessage(msg.TablesBytes()).walkRefs(nbf, cb)
@@ -84,6 +84,8 @@ type ValueStore struct {
 	validateContentAddr  bool
 	decodedChunks        *sizecache.SizeCache
 	nbf                  *NomsBinFormat
+	// gcOnline is set between BeginGC and EndGC, while the ValueStore may be written to during a GC.
+	gcOnline bool
 
 	versOnce sync.Once
 }
@@ -662,21 +664,47 @@ func makeBatches(hss []hash.HashSet, count int) [][]hash.Hash {
 	return res
 }
 
-func (lvs *ValueStore) numBuffChunks() int {
-	lvs.bufferMu.RLock()
-	defer lvs.bufferMu.RUnlock()
-	return len(lvs.bufferedChunks)
+// BeginGC prepares the ChunkStore for a garbage collection that runs while the ValueStore continues to be written
+// to. Chunks written to the ChunkStore after BeginGC returns are kept by GC. It returns
+// chunks.ErrUnsupportedOperation if the ChunkStore can't be collected while it's in use.
+func (lvs *ValueStore) BeginGC() error {
+	collector, ok := lvs.cs.(chunks.OnlineGarbageCollector)
+	if !ok {
+		return chunks.ErrUnsupportedOperation
+	}
+	err := collector.BeginGC()
+	if err != nil {
+		return err
+	}
+
+	lvs.bufferMu.Lock()
+	defer lvs.bufferMu.Unlock()
+	lvs.gcOnline = true
+	return nil
 }
 
-// GC traverses the ValueStore from the root and removes unreferenced chunks from the ChunkStore
-func (lvs *ValueStore) GC(ctx context.Context, oldGenRefs, newGenRefs hash.HashSet) error {
-	if lvs.numBuffChunks() > 0 {
-		return errors.New("invalid GC state; bufferedChunks must be empty.")
+// EndGC ends a garbage collection started with BeginGC.
+func (lvs *ValueStore) EndGC() {
+	if collector, ok := lvs.cs.(chunks.OnlineGarbageCollector); ok {
+		collector.EndGC()
 	}
 
+	lvs.bufferMu.Lock()
+	defer lvs.bufferMu.Unlock()
+	lvs.gcOnline = false
+}
+
+// GC traverses the ValueStore from the root and removes unreferenced chunks from the ChunkStore. Unless a GC was
+// started with BeginGC, there must not be any buffered chunks. During an online GC the buffered chunks are flushed
+// instead, since the ChunkStore keeps the chunks put into it after BeginGC. The chunks in |excludedRefs| are never
+// visited; they are the chunks a shallow clone references but never fetched.
+func (lvs *ValueStore) GC(ctx context.Context, oldGenRefs, newGenRefs, excludedRefs hash.HashSet) error {
 	err := func() error {
-		lvs.bufferMu.RLock()
-		defer lvs.bufferMu.RUnlock()
+		lvs.bufferMu.Lock()
+		defer lvs.bufferMu.Unlock()
+		if lvs.gcOnline {
+			return lvs.flush(ctx, hash.Hash{})
+		}
 		if len(lvs.bufferedChunks) > 0 {
 			return errors.New("invalid GC state; bufferedChunks must be empty.")
 		}
@@ -708,24 +736,24 @@ func (lvs *ValueStore) GC(ctx context.Context, oldGenRefs, newGenRefs hash.HashS
 	if gcs, ok := lvs.cs.(chunks.GenerationalCS); ok {
 		oldGen := gcs.OldGen()
 		newGen := gcs.NewGen()
-		err = lvs.gc(ctx, root, oldGenRefs, oldGen.HasMany, newGen, oldGen)
+		err = lvs.gc(ctx, root, oldGenRefs, excludedRefs, oldGen.HasMany, newGen, oldGen)
 		if err != nil {
 			return err
 		}
 
-		return lvs.gc(ctx, root, newGenRefs, oldGen.HasMany, newGen, newGen)
+		return lvs.gc(ctx, root, newGenRefs, excludedRefs, oldGen.HasMany, newGen, newGen)
 	} else if collector, ok := lvs.cs.(chunks.ChunkStoreGarbageCollector); ok {
 		if len(oldGenRefs) > 0 {
 			newGenRefs.InsertAll(oldGenRefs)
 		}
 
-		return lvs.gc(ctx, root, newGenRefs, unfilteredHashFunc, collector, collector)
+		return lvs.gc(ctx, root, newGenRefs, excludedRefs, unfilteredHashFunc, collector, collector)
 	} else {
 		return chunks.ErrUnsupportedOperation
 	}
 }
 
-func (lvs *ValueStore) gc(ctx context.Context, root hash.Hash, toVisit hash.HashSet, hashFilter HashFilterFunc, src, dest chunks.ChunkStoreGarbageCollector) error {
+func (lvs *ValueStore) gc(ctx context.Context, root hash.Hash, toVisit, excluded hash.HashSet, hashFilter HashFilterFunc, src, dest chunks.ChunkStoreGarbageCollector) error {
 	keepChunks := make(chan []hash.Hash, gcBuffSize)
 
 	eg, ctx := errgroup.WithContext(ctx)
@@ -752,6 +780,8 @@ func (lvs *ValueStore) gc(ctx context.Context, root hash.Hash, toVisit hash.Hash
 		defer walker.Close()
 
 		visited := toVisit.Copy()
+		// the excluded chunks count as visited, so the walk never follows a reference to them
+		visited.InsertAll(excluded)
 		err := lvs.gcProcessRefs(ctx, visited, []hash.HashSet{toVisit}, keepHashes, walker, hashFilter)
 		if err != nil {
 			return err
@@ -819,12 +849,18 @@ func (lvs *ValueStore) gcProcessRefs(ctx context.Context, visited hash.HashSet,
 	lvs.bufferMu.Lock()
 	defer lvs.bufferMu.Unlock()
 
+	if lvs.gcOnline {
+		// the chunks buffered by the writers of an online GC are flushed as they commit
+		lvs.decodedChunks.Purge()
+		return nil
+	}
+
 	if len(lvs.bufferedChunks) > 0 {
 		return errors.New("invalid GC state; bufferedChunks started empty and was not empty at end of run.")
 	}
 
 	// purge the cache
-	lvs.decodedChunks = sizecache.New(lvs.decodedChunks.Size())
+	lvs.decodedChunks.Purge()
 	lvs.bufferedChunks = make(map[hash.Hash]chunks.Chunk, lvs.bufferedChunkSize)
 	lvs.bufferedChunkSize = 0
 	lvs.withBufferedChildren = map[hash.Hash]uint64{}
@@ -384,7 +384,7 @@ func TestGC(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(v2)
 
-	err = vs.GC(ctx, hash.HashSet{}, hash.HashSet{})
+	err = vs.GC(ctx, hash.HashSet{}, hash.HashSet{}, hash.HashSet{})
 	require.NoError(t, err)
 
 	v1, err = vs.ReadValue(ctx, h1) // non-nil
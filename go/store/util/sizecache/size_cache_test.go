@@ -112,6 +112,24 @@ func TestSizeCacheWithExpiry(t *testing.T) {
 	assert.Equal(t, data, expired)
 }
 
+func TestSizeCachePurge(t *testing.T) {
+	c := New(5)
+	for i, k := range []string{"a", "b", "c"} {
+		c.Add(k, 1, i)
+	}
+
+	c.Purge()
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, uint64(0), c.totalSize)
+	assert.Equal(t, 0, c.lru.Len())
+
+	c.Add("d", 5, 3)
+	v, ok := c.Get("d")
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+}
+
 func concurrencySizeCacheTest(data []string) {
 	dchan := make(chan string, 128)
 	go func() {
@@ -15,19 +15,13 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"log"
-	"net"
-	"net/http"
 	"os"
 	"os/signal"
-	"sync"
 
-	"google.golang.org/grpc"
-
-	remotesapi "github.com/dolthub/dolt/go/gen/proto/dolt/services/remotesapi/v1alpha1"
+	"github.com/dolthub/dolt/go/libraries/doltcore/remotesrv"
 	"github.com/dolthub/dolt/go/libraries/utils/filesys"
 )
 
@@ -62,80 +56,29 @@ func main() {
 		log.Println("'grpc-port' parameter not provided. Using default port 50051")
 	}
 
-	stopChan, wg := startServer(*httpHostParam, *httpPortParam, *grpcPortParam)
-	waitForSignal()
-
-	close(stopChan)
-	wg.Wait()
-}
-
-func waitForSignal() {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, os.Kill)
-	<-c
-}
-
-func startServer(httpHost string, httpPort, grpcPort int) (chan interface{}, *sync.WaitGroup) {
-	wg := sync.WaitGroup{}
-	stopChan := make(chan interface{})
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		httpServer(httpPort, stopChan)
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		grpcServer(httpHost, grpcPort, stopChan)
-	}()
-
-	return stopChan, &wg
-}
-
-func grpcServer(httpHost string, grpcPort int, stopChan chan interface{}) {
-	defer func() {
-		log.Println("exiting grpc Server go routine")
-	}()
-
-	dbCache := NewLocalCSCache(filesys.LocalFS)
-	chnkSt := NewHttpFSBackedChunkStore(httpHost, dbCache)
-
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", grpcPort))
+	server := remotesrv.NewServer(remotesrv.ServerArgs{
+		HttpHost: *httpHostParam,
+		HttpPort: *httpPortParam,
+		GrpcPort: *grpcPortParam,
+		DBCache:  NewLocalCSCache(filesys.LocalFS),
+	})
+	listeners, err := server.Listeners()
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		log.Fatalf("error starting remotesrv Server, could not open listeners: %v\n", err)
 	}
-
-	grpcServer := grpc.NewServer(grpc.MaxRecvMsgSize(128 * 1024 * 1024))
+	done := make(chan struct{})
 	go func() {
-		remotesapi.RegisterChunkStoreServiceServer(grpcServer, chnkSt)
-
-		log.Println("Starting grpc server on port", grpcPort)
-		err := grpcServer.Serve(lis)
-		log.Println("grpc server exited. error:", err)
+		defer close(done)
+		server.Serve(listeners)
 	}()
 
-	<-stopChan
-	grpcServer.GracefulStop()
+	waitForSignal()
+	server.GracefulStop()
+	<-done
 }
 
-func httpServer(httpPort int, stopChan chan interface{}) {
-	defer func() {
-		log.Println("exiting http Server go routine")
-	}()
-
-	server := http.Server{
-		Addr:    fmt.Sprintf(":%d", httpPort),
-		Handler: http.HandlerFunc(ServeHTTP),
-	}
-
-	go func() {
-		log.Println("Starting http server on port ", httpPort)
-		err := server.ListenAndServe()
-		log.Println("http server exited. exit error:", err)
-	}()
-
-	<-stopChan
-	server.Shutdown(context.Background())
+func waitForSignal() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, os.Kill)
+	<-c
 }
@@ -0,0 +1,100 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+load $BATS_TEST_DIRNAME/helper/query-server-common.bash
+
+make_repo() {
+  mkdir "$1"
+  cd "$1"
+  dolt init
+  cd ..
+}
+
+setup() {
+    skiponwindows "tests are flaky on Windows"
+    setup_no_dolt_init
+    make_repo repo1
+    cd repo1
+
+    dolt sql -q "CREATE TABLE test (pk int primary key)"
+    dolt add -A
+    dolt commit -m "Created table"
+    dolt branch feature1
+
+    start_sql_server repo1
+    server_query repo1 1 "CREATE USER analyst@'%'; GRANT ALL ON *.* TO analyst@'%'; CREATE USER ci@'%'; GRANT ALL ON *.* TO ci@'%'" ""
+}
+
+teardown() {
+    stop_sql_server
+    teardown_common
+}
+
+@test "branch-control: every user may write to every branch by default" {
+    server_query repo1 1 "SELECT * FROM dolt_branch_control" "database,branch,user,host,permissions\n%,%,%,%,write"
+    server_query_with_user repo1 1 analyst "INSERT INTO test VALUES (1)" ""
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('-d', 'feature1')" "status\n0"
+}
+
+@test "branch-control: writes are limited to the branches a user may write to" {
+    server_query repo1 1 "DELETE FROM dolt_branch_control; INSERT INTO dolt_branch_control VALUES ('%', 'main', 'ci', '%', 'write'), ('%', 'feature%', 'analyst', '%', 'write')" ""
+
+    server_query_with_user repo1 1 analyst "INSERT INTO test VALUES (1)" "" "does not have the correct permissions on branch \`main\`"
+    server_query_with_user repo1 1 ci "INSERT INTO test VALUES (1)" ""
+    server_query_with_user repo1/feature1 1 analyst "INSERT INTO test VALUES (2)" ""
+    server_query_with_user repo1/feature1 1 ci "INSERT INTO test VALUES (3)" "" "does not have the correct permissions on branch \`feature1\`"
+
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('-d', 'main')" "" "cannot delete the branch \`main\`"
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('-m', 'feature1', 'feature2')" "status\n0"
+
+    server_query repo1 1 "SELECT pk FROM test" "pk\n1"
+    server_query repo1 1 "SELECT name FROM dolt_branches WHERE name LIKE 'feature%'" "name\nfeature2"
+}
+
+@test "branch-control: namespaces restrict who may create branches" {
+    server_query repo1 1 "INSERT INTO dolt_branch_namespace_control VALUES ('%', 'release%', 'ci', '%')" ""
+
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('release1')" "" "cannot create a branch named \`release1\`"
+    server_query_with_user repo1 1 analyst "CALL dolt_checkout('-b', 'release1')" "" "cannot create a branch named \`release1\`"
+    server_query_with_user repo1 1 ci "CALL dolt_branch('release1')" "status\n0"
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('feature2')" "status\n0"
+
+    # creating a branch makes its creator an admin of it
+    server_query repo1 1 "SELECT \`database\`, user, permissions FROM dolt_branch_control WHERE branch = 'feature2'" "database,user,permissions\nrepo1,analyst,admin"
+}
+
+@test "branch-control: only admins may edit the branch control tables" {
+    server_query_with_user repo1 1 analyst "INSERT INTO dolt_branch_control VALUES ('%', 'main', 'analyst', '%', 'admin')" "" "cannot modify the row for database \`%\` and branch \`main\`"
+    server_query_with_user repo1 1 analyst "DELETE FROM dolt_branch_control" "" "cannot modify the row"
+
+    server_query repo1 1 "INSERT INTO dolt_branch_control VALUES ('%', 'feature%', 'analyst', '%', 'admin')" ""
+    server_query_with_user repo1 1 analyst "INSERT INTO dolt_branch_control VALUES ('%', 'feature1', 'ci', '%', 'read')" ""
+    server_query_with_user repo1 1 analyst "INSERT INTO dolt_branch_control VALUES ('%', 'main', 'ci', '%', 'read')" "" "cannot modify the row"
+}
+
+@test "branch-control: permissions persist across restarts" {
+    server_query repo1 1 "INSERT INTO dolt_branch_control VALUES ('%', 'main', 'analyst', '%', 'read')" ""
+    server_query repo1 1 "INSERT INTO dolt_branch_namespace_control VALUES ('%', 'release%', 'ci', '%')" ""
+    stop_sql_server 1
+
+    [ -f .doltcfg/branch_control.db ]
+
+    start_sql_server repo1
+    server_query repo1 1 "SELECT * FROM dolt_branch_control ORDER BY branch" "database,branch,user,host,permissions\n%,%,%,%,write\n%,main,analyst,%,read"
+    server_query repo1 1 "SELECT * FROM dolt_branch_namespace_control" "database,branch,user,host\n%,release%,ci,%"
+    server_query_with_user repo1 1 analyst "INSERT INTO test VALUES (1)" "" "does not have the correct permissions on branch \`main\`"
+}
+
+@test "branch-control: deleting or renaming a branch revokes its creator's admin permissions" {
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('feature2')" "status\n0"
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('-m', 'feature2', 'feature3')" "status\n0"
+    server_query repo1 1 "SELECT branch, user, permissions FROM dolt_branch_control WHERE user = 'analyst'" "branch,user,permissions\nfeature3,analyst,admin"
+
+    server_query_with_user repo1 1 analyst "CALL dolt_branch('-d', 'feature3')" "status\n0"
+    server_query repo1 1 "SELECT count(*) FROM dolt_branch_control WHERE user = 'analyst'" "count(*)\n0"
+}
+
+@test "branch-control: merges and resets are limited to the branches a user may write to" {
+    server_query repo1 1 "DELETE FROM dolt_branch_control; INSERT INTO dolt_branch_control VALUES ('%', '%', 'analyst', '%', 'read')" ""
+
+    server_query_with_user repo1 1 analyst "CALL dolt_merge('feature1')" "" "does not have the correct permissions on branch \`main\`"
+}
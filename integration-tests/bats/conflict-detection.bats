@@ -544,3 +544,189 @@ SQL
     [ $status -eq 0 ]
     [[ ! "$output" =~ "CONFLICT" ]] || false
 }
+
+@test "conflict-detection: two branches change the type of the same column. merge. schema conflict" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk INT NOT NULL,
+  c1 INT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0,0), (1,1);
+SQL
+    dolt add test
+    dolt commit -m "table created"
+    dolt branch other
+    dolt sql -q "alter table test modify c1 bigint"
+    dolt sql -q "insert into test values (2,2)"
+    dolt commit -am "changed c1 to bigint"
+    dolt checkout other
+    dolt sql -q "alter table test modify c1 varchar(20)"
+    dolt sql -q "update test set c1 = 'one' where pk = 1"
+    dolt commit -am "changed c1 to varchar"
+    dolt checkout main
+    run dolt merge other
+    [ $status -eq 0 ]
+    [[ "$output" =~ "CONFLICT (schema): Merge conflict in test" ]] || false
+
+    run dolt sql -r csv -q "select table_name, description from dolt_schema_conflicts"
+    [ $status -eq 0 ]
+    [[ "$output" =~ "test,different column definitions for our column c1 and their column c1" ]] || false
+
+    run dolt sql -r csv -q "select our_schema, their_schema from dolt_schema_conflicts"
+    [[ "$output" =~ '`c1` bigint' ]] || false
+    [[ "$output" =~ '`c1` varchar(20)' ]] || false
+
+    run dolt status
+    [[ "$output" =~ "both modified:  test" ]] || false
+
+    run dolt commit -am "merged"
+    [ $status -eq 1 ]
+    [[ "$output" =~ "unresolved schema conflicts" ]] || false
+}
+
+@test "conflict-detection: resolve schema conflict with theirs. data is merged" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk INT NOT NULL,
+  c1 INT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0,0), (1,1);
+SQL
+    dolt add test
+    dolt commit -m "table created"
+    dolt branch other
+    dolt sql -q "alter table test modify c1 bigint"
+    dolt sql -q "insert into test values (2,2)"
+    dolt commit -am "changed c1 to bigint"
+    dolt checkout other
+    dolt sql -q "alter table test modify c1 varchar(20)"
+    dolt sql -q "update test set c1 = 'one' where pk = 1"
+    dolt commit -am "changed c1 to varchar"
+    dolt checkout main
+    dolt merge other
+
+    run dolt conflicts resolve --theirs test
+    [ $status -eq 0 ]
+
+    run dolt sql -r csv -q "select * from test order by pk"
+    [ $status -eq 0 ]
+    [[ "$output" =~ "0,0" ]] || false
+    [[ "$output" =~ "1,one" ]] || false
+    [[ "$output" =~ "2,2" ]] || false
+
+    run dolt sql -q "show create table test"
+    [[ "$output" =~ '`c1` varchar(20)' ]] || false
+
+    run dolt sql -r csv -q "select count(*) from dolt_schema_conflicts"
+    [[ "$output" =~ "0" ]] || false
+
+    dolt commit -am "merged"
+    run dolt log -n 1
+    [[ "$output" =~ "Merge:" ]] || false
+}
+
+@test "conflict-detection: resolve schema conflict with ours. unconvertible rows are constraint violations" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk INT NOT NULL,
+  c1 INT,
+  PRIMARY KEY (pk)
+);
+INSERT INTO test VALUES (0,0), (1,1);
+SQL
+    dolt add test
+    dolt commit -m "table created"
+    dolt branch other
+    dolt sql -q "alter table test modify c1 bigint"
+    dolt commit -am "changed c1 to bigint"
+    dolt checkout other
+    dolt sql -q "alter table test modify c1 varchar(20)"
+    dolt sql -q "update test set c1 = 'one' where pk = 1"
+    dolt sql -q "insert into test values (2,'2')"
+    dolt commit -am "changed c1 to varchar"
+    dolt checkout main
+    dolt merge other
+
+    run dolt conflicts resolve --ours test
+    [ $status -eq 0 ]
+    [[ "$output" =~ "CONSTRAINT VIOLATION" ]] || false
+
+    run dolt sql -r csv -q "select * from test order by pk"
+    [[ "$output" =~ "0,0" ]] || false
+    [[ "$output" =~ "2,2" ]] || false
+    [[ ! "$output" =~ "1," ]] || false
+
+    run dolt sql -r csv -q "select violation_type, pk from dolt_constraint_violations_test"
+    [[ "$output" =~ "schema conversion,1" ]] || false
+
+    run dolt sql -q "show create table test"
+    [[ "$output" =~ '`c1` bigint' ]] || false
+}
+
+@test "conflict-detection: two branches add an index with the same name. resolve schema conflict" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk INT NOT NULL,
+  c1 INT,
+  c2 INT,
+  PRIMARY KEY (pk)
+);
+SQL
+    dolt add test
+    dolt commit -m "table created"
+    dolt branch other
+    dolt sql -q "create index idx on test (c1)"
+    dolt commit -am "added idx on c1"
+    dolt checkout other
+    dolt sql -q "create index idx on test (c2)"
+    dolt sql -q "insert into test values (1,1,1)"
+    dolt commit -am "added idx on c2"
+    dolt checkout main
+    run dolt merge other
+    [ $status -eq 0 ]
+    [[ "$output" =~ "CONFLICT (schema)" ]] || false
+
+    run dolt sql -r csv -q "select description from dolt_schema_conflicts"
+    [[ "$output" =~ "two indexes with the name 'idx' but different definitions" ]] || false
+
+    dolt conflicts resolve --theirs .
+    run dolt sql -q "show create table test"
+    [[ "$output" =~ 'KEY `idx` (`c2`)' ]] || false
+
+    run dolt sql -r csv -q "select pk from test where c2 = 1"
+    [[ "$output" =~ "1" ]] || false
+
+    dolt commit -am "merged"
+}
+
+@test "conflict-detection: merge --abort clears schema conflicts" {
+    dolt sql <<SQL
+CREATE TABLE test (
+  pk INT NOT NULL,
+  c1 INT,
+  PRIMARY KEY (pk)
+);
+SQL
+    dolt add test
+    dolt commit -m "table created"
+    dolt branch other
+    dolt sql -q "alter table test add constraint chk check (c1 > 0)"
+    dolt commit -am "added chk"
+    dolt checkout other
+    dolt sql -q "alter table test add constraint chk check (c1 < 10)"
+    dolt commit -am "added another chk"
+    dolt checkout main
+    dolt merge other
+
+    run dolt sql -r csv -q "select count(*) from dolt_schema_conflicts"
+    [[ "$output" =~ "1" ]] || false
+
+    dolt merge --abort
+    run dolt sql -r csv -q "select count(*) from dolt_schema_conflicts"
+    [[ "$output" =~ "0" ]] || false
+
+    run dolt status
+    [[ "$output" =~ "nothing to commit" ]] || false
+}
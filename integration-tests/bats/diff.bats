@@ -850,3 +850,105 @@ SQL
     run dolt diff HEAD~1
     [ "${#lines[@]}" -eq 2007 ] # 2000 diffs + 6 for top rows before data + 1 for bottom row of table
 }
+
+@test "diff: json output" {
+    dolt sql -q "insert into test values (0, 0, 0, 0, 0, 0), (1, 1, 1, 1, 1, 1)"
+    dolt add .
+    dolt commit -m "table with rows"
+    dolt sql -q "update test set c1 = 10 where pk = 1"
+    dolt sql -q "delete from test where pk = 0"
+    dolt sql -q "insert into test values (2, 2, 2, 2, 2, 2)"
+    dolt sql -q "alter table test add column c6 bigint"
+
+    run dolt diff -r json
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '{"tables":[{"name":"test","from_name":"test","to_name":"test","diff_type":"modified",' ]] || false
+    [[ "$output" =~ '"schema_diff":["ALTER TABLE' ]] || false
+    [[ "$output" =~ '{"from_row":{"c1":1,"c2":1,"c3":1,"c4":1,"c5":1,"pk":1},"to_row":{"c1":10,"c2":1,"c3":1,"c4":1,"c5":1,"pk":1}}' ]] || false
+    [[ "$output" =~ '{"from_row":{"c1":0,"c2":0,"c3":0,"c4":0,"c5":0,"pk":0},"to_row":{}}' ]] || false
+    [[ "$output" =~ '{"from_row":{},"to_row":{"c1":2,"c2":2,"c3":2,"c4":2,"c5":2,"pk":2}}' ]] || false
+
+    run dolt diff -r json --data
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '"schema_diff":[]' ]] || false
+
+    run dolt diff -r json --schema
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '"data_diff":[]' ]] || false
+
+    dolt commit -am "changes"
+    run dolt diff -r json
+    [ "$status" -eq 0 ]
+    [ "$output" = '{"tables":[]}' ]
+
+    run dolt diff -r json --summary
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--summary is not supported for json output" ]] || false
+}
+
+@test "diff: csv output" {
+    dolt sql -q "insert into test values (0, 0, 0, 0, 0, 0), (1, 1, 1, 1, 1, 1)"
+    dolt add .
+    dolt commit -m "table with rows"
+    dolt sql -q "update test set c1 = 10 where pk = 1"
+    dolt sql -q "delete from test where pk = 0"
+    dolt sql -q "insert into test values (2, 2, 2, 2, 2, 2)"
+
+    run dolt diff -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[0]}" = "from_pk,from_c1,from_c2,from_c3,from_c4,from_c5,to_pk,to_c1,to_c2,to_c3,to_c4,to_c5,diff_type" ]
+    [[ "$output" =~ "1,1,1,1,1,1,1,10,1,1,1,1,modified" ]] || false
+    [[ "$output" =~ "0,0,0,0,0,0,,,,,,,removed" ]] || false
+    [[ "$output" =~ ",,,,,,2,2,2,2,2,2,added" ]] || false
+    [ "${#lines[@]}" -eq 4 ]
+
+    run dolt diff -r csv --schema
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "schema diffs are not supported for csv output" ]] || false
+}
+
+@test "diff: stat and name-only" {
+    dolt sql -q "insert into test values (0, 0, 0, 0, 0, 0), (1, 1, 1, 1, 1, 1)"
+    dolt add .
+    dolt commit -m "table with rows"
+    dolt sql -q "update test set c1 = 10 where pk = 1"
+    dolt sql -q "delete from test where pk = 0"
+    dolt sql -q "insert into test values (2, 2, 2, 2, 2, 2)"
+    dolt sql -q "create table other (pk int primary key)"
+
+    run dolt diff --name-only
+    [ "$status" -eq 0 ]
+    [ "${#lines[@]}" -eq 2 ]
+    [ "${lines[0]}" = "other" ]
+    [ "${lines[1]}" = "test" ]
+
+    run dolt diff --name-only -r csv
+    [ "$status" -eq 0 ]
+    [ "${lines[0]}" = "name,from_name,to_name,diff_type" ]
+    [ "${lines[1]}" = "other,,other,added" ]
+    [ "${lines[2]}" = "test,test,test,modified" ]
+
+    run dolt diff --stat
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "other | 0 rows added, 0 rows deleted, 0 rows modified, 0 cells modified" ]] || false
+    [[ "$output" =~ "test | 1 row added, 1 row deleted, 1 row modified, 1 cell modified" ]] || false
+    [[ "$output" =~ "2 tables changed, 1 row added, 1 row deleted, 1 row modified, 1 cell modified" ]] || false
+
+    run dolt diff --stat -r json
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ '{"name":"other","from_name":"","to_name":"other","diff_type":"added","rows_unmodified":0,"rows_added":0,"rows_deleted":0,"rows_modified":0,"cells_modified":0,"old_row_count":0,"new_row_count":0}' ]] || false
+    [[ "$output" =~ '{"name":"test","from_name":"test","to_name":"test","diff_type":"modified","rows_unmodified":0,"rows_added":1,"rows_deleted":1,"rows_modified":1,"cells_modified":1,"old_row_count":2,"new_row_count":2}' ]] || false
+
+    run dolt diff --stat -r csv test
+    [ "$status" -eq 0 ]
+    [ "${lines[0]}" = "name,from_name,to_name,diff_type,rows_unmodified,rows_added,rows_deleted,rows_modified,cells_modified,old_row_count,new_row_count" ]
+    [ "${lines[1]}" = "test,test,test,modified,0,1,1,1,1,2,2" ]
+
+    run dolt diff --stat --data
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--stat cannot be combined with --schema or --data" ]] || false
+
+    run dolt diff --stat -r sql
+    [ "$status" -eq 1 ]
+    [[ "$output" =~ "--stat and --name-only are not supported for sql output" ]] || false
+}
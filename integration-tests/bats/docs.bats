@@ -132,3 +132,54 @@ TXT
     [[ "$output" =~ "-  0. You just DO WHAT THE FUCK YOU WANT TO"               ]] || false
     [[ "$output" =~ "+  0. You just DO WHAT THE F*CK YOU WANT TO"               ]] || false
 }
+
+@test "docs: merge doc changes to different lines" {
+    dolt docs read LICENSE.md LICENSE.md
+    dolt add . && dolt commit -m "added a license file"
+    dolt branch other
+
+    sed -i.bak 's/You just DO WHAT THE FUCK YOU WANT TO/You just DO WHAT THE F*CK YOU WANT TO/' LICENSE.md
+    dolt docs read LICENSE.md LICENSE.md
+    dolt add . && dolt commit -m "censored the license terms"
+
+    dolt checkout other
+    dolt docs write LICENSE.md > LICENSE.md
+    sed -i.bak 's/Version 2, December 2004/Version 2.1, December 2004/' LICENSE.md
+    dolt docs read LICENSE.md LICENSE.md
+    dolt add . && dolt commit -m "bumped the license version"
+
+    dolt checkout main
+    run dolt merge other
+    [ "$status" -eq 0 ]
+    ! [[ "$output" =~ "CONFLICT" ]] || false
+    run dolt docs write LICENSE.md
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "Version 2.1, December 2004" ]] || false
+    [[ "$output" =~ "0. You just DO WHAT THE F*CK YOU WANT TO." ]] || false
+}
+
+@test "docs: merge doc changes to the same line" {
+    dolt docs read LICENSE.md LICENSE.md
+    dolt add . && dolt commit -m "added a license file"
+    dolt branch other
+
+    sed -i.bak 's/Version 2, December 2004/Version 2.1, December 2004/' LICENSE.md
+    dolt docs read LICENSE.md LICENSE.md
+    dolt add . && dolt commit -m "bumped the license version"
+
+    dolt checkout other
+    dolt docs write LICENSE.md > LICENSE.md
+    sed -i.bak 's/Version 2, December 2004/Version 3, December 2004/' LICENSE.md
+    dolt docs read LICENSE.md LICENSE.md
+    dolt add . && dolt commit -m "bumped the license version again"
+
+    dolt checkout main
+    run dolt merge other
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "CONFLICT" ]] || false
+    run dolt conflicts cat dolt_docs
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "conflict in dolt_docs: LICENSE.md" ]] || false
+    [[ "$output" =~ "+                    Version 2.1, December 2004" ]] || false
+    [[ "$output" =~ "+                    Version 3, December 2004" ]] || false
+}
@@ -247,3 +247,130 @@ SQL
     [[ "$output" =~ "9,9" ]] || false
     [[ "$output" =~ "9,9" ]] || false
 }
+
+@test "filter-branch: keeps originals under refs/original" {
+    dolt sql -q "INSERT INTO test VALUES (7,7),(8,8),(9,9);"
+    dolt add -A && dolt commit -m "added more rows"
+
+    dolt filter-branch "DELETE FROM test WHERE pk > 1;"
+    run dolt sql -q "SELECT count(*) FROM test AS OF 'refs/original/heads/main'" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "6" ]] || false
+
+    run dolt filter-branch "DELETE FROM test WHERE pk > 0;"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "a previous backup already exists in refs/original/" ]] || false
+
+    dolt filter-branch --force "DELETE FROM test WHERE pk > 0;"
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [[ "$output" =~ "1" ]] || false
+
+    dolt reset --hard refs/original/heads/main
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [[ "$output" =~ "2" ]] || false
+}
+
+@test "filter-branch: branch and tag globs" {
+    dolt branch release/one
+    dolt branch other
+    dolt tag v1
+    dolt tag keep
+
+    dolt filter-branch --branches "release/*" --tags "v*" "DELETE FROM test WHERE pk > 0;"
+
+    run dolt sql -q "SELECT count(*) FROM test AS OF 'release/one'" -r csv
+    [[ "$output" =~ "1" ]] || false
+    run dolt sql -q "SELECT count(*) FROM test AS OF 'v1'" -r csv
+    [[ "$output" =~ "1" ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM test AS OF 'other'" -r csv
+    [[ "$output" =~ "3" ]] || false
+    run dolt sql -q "SELECT count(*) FROM test AS OF 'keep'" -r csv
+    [[ "$output" =~ "3" ]] || false
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [[ "$output" =~ "3" ]] || false
+
+    run dolt filter-branch --force --branches "nope*" "DELETE FROM test;"
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "no branches match nope*" ]] || false
+}
+
+@test "filter-branch: script file and table subset" {
+    dolt sql -q "INSERT INTO to_drop VALUES (1),(2);"
+    dolt add -A && dolt commit -m "added rows to to_drop"
+
+    cat > script.sql <<SQL
+DELETE FROM test WHERE pk = 0;
+DELETE FROM to_drop WHERE pk = 1;
+SQL
+    dolt filter-branch --tables to_drop --file script.sql
+
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [[ "$output" =~ "3" ]] || false
+    run dolt sql -q "SELECT pk FROM to_drop" -r csv
+    [[ "$output" =~ "2" ]] || false
+    [[ ! "$output" =~ "1" ]] || false
+}
+
+@test "filter-branch: rewrite authors and messages" {
+    dolt sql -q "INSERT INTO test VALUES (4,4);"
+    dolt add -A && dolt commit -m "added row with secret token" --author "Old Name <old@example.com>"
+
+    cat > map.txt <<MAP
+# rewrite the old identity
+author Old Name <old@example.com> => New Name <new@example.com>
+message secret token => [redacted]
+MAP
+    dolt filter-branch --rewrite-map map.txt
+
+    run dolt log -n 1
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "New Name <new@example.com>" ]] || false
+    [[ "$output" =~ "added row with [redacted]" ]] || false
+    [[ ! "$output" =~ "old@example.com" ]] || false
+
+    echo "bogus entry" > bad.txt
+    run dolt filter-branch --force --rewrite-map bad.txt
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "bad.txt:1" ]] || false
+}
+
+@test "filter-branch: rewrite the author of the initial commit" {
+    dolt sql -q "INSERT INTO test VALUES (4,4);"
+    dolt add -A && dolt commit -m "added row 4"
+
+    name=`dolt config --get user.name`
+    email=`dolt config --get user.email`
+    cat > map.txt <<MAP
+author $name <$email> => New Name <new@example.com>
+MAP
+    dolt filter-branch --rewrite-map map.txt
+
+    run dolt sql -q "SELECT committer, email FROM dolt_log WHERE message = 'Initialize data repository'" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "New Name,new@example.com" ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM dolt_log WHERE email = '$email'" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "0" ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM dolt_log" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "3" ]] || false
+}
+
+@test "filter-branch: prune empty commits and write commit map" {
+    dolt sql -q "INSERT INTO test VALUES (4,4);"
+    dolt add -A && dolt commit -m "added row 4"
+    dolt sql -q "INSERT INTO test VALUES (5,5);"
+    dolt add -A && dolt commit -m "added row 5"
+
+    dolt filter-branch --prune-empty --commit-map map.txt "DELETE FROM test WHERE pk = 4;"
+
+    run dolt log
+    [[ ! "$output" =~ "added row 4" ]] || false
+    [[ "$output" =~ "added row 5" ]] || false
+
+    run wc -l map.txt
+    [[ "$output" =~ "3" ]] || false
+}
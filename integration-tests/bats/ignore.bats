@@ -0,0 +1,93 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+
+    dolt sql -q "INSERT INTO dolt_ignore VALUES ('tmp_*', true), ('staging_*', true), ('tmp_keep', false)"
+    dolt add dolt_ignore
+    dolt commit -m "Added dolt_ignore"
+}
+
+teardown() {
+    assert_feature_version
+    teardown_common
+}
+
+@test "ignore: ignored tables are not shown by status" {
+    dolt sql -q "CREATE TABLE tmp_scratch (pk int primary key)"
+    dolt sql -q "CREATE TABLE tmp_keep (pk int primary key)"
+    dolt sql -q "CREATE TABLE users (pk int primary key)"
+
+    run dolt status
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "tmp_keep" ]] || false
+    [[ "$output" =~ "users" ]] || false
+    [[ ! "$output" =~ "tmp_scratch" ]] || false
+}
+
+@test "ignore: add -A and commit -a skip ignored tables" {
+    dolt sql -q "CREATE TABLE tmp_scratch (pk int primary key)"
+    dolt sql -q "CREATE TABLE users (pk int primary key)"
+
+    dolt add -A
+    run dolt sql -q "SELECT table_name FROM dolt_status WHERE staged = true" -r csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "users" ]] || false
+    [[ ! "$output" =~ "tmp_scratch" ]] || false
+
+    dolt commit -m "Added users"
+    dolt sql -q "CREATE TABLE staging_orders (pk int primary key)"
+    run dolt commit -am "should have nothing to commit"
+    [ "$status" -eq "1" ]
+
+    run dolt ls
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "tmp_scratch" ]] || false
+    [[ "$output" =~ "staging_orders" ]] || false
+}
+
+@test "ignore: adding an ignored table by name requires --force" {
+    dolt sql -q "CREATE TABLE tmp_scratch (pk int primary key)"
+
+    run dolt add tmp_scratch
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "ignored by one of your dolt_ignore patterns" ]] || false
+    [[ "$output" =~ "tmp_scratch" ]] || false
+
+    run dolt add -f tmp_scratch
+    [ "$status" -eq "0" ]
+
+    dolt commit -m "Added tmp_scratch"
+
+    # tracked tables are never ignored
+    dolt sql -q "INSERT INTO tmp_scratch VALUES (1)"
+    run dolt status
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "tmp_scratch" ]] || false
+}
+
+@test "ignore: clean skips ignored tables unless -x is given" {
+    dolt sql -q "CREATE TABLE tmp_scratch (pk int primary key)"
+    dolt sql -q "CREATE TABLE users (pk int primary key)"
+
+    dolt clean
+    run dolt ls
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "tmp_scratch" ]] || false
+    [[ ! "$output" =~ "users" ]] || false
+
+    dolt clean -x
+    run dolt ls
+    [ "$status" -eq "0" ]
+    [[ ! "$output" =~ "tmp_scratch" ]] || false
+}
+
+@test "ignore: conflicting patterns are an error" {
+    dolt sql -q "INSERT INTO dolt_ignore VALUES ('*_orders', false)"
+    dolt sql -q "CREATE TABLE staging_orders (pk int primary key)"
+
+    run dolt add -A
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "matches conflicting patterns in dolt_ignore" ]] || false
+}
@@ -346,25 +346,33 @@ SQL
     [[ "${lines[6]}" =~ "22" ]] || false
 }
 
-@test "merge: Add views on two branches, merge" {
-    dolt branch other
+@test "merge: Change a view differently on two branches, merge" {
     dolt sql -q "CREATE VIEW pkpk AS SELECT pk*pk FROM test1;"
     dolt add . && dolt commit -m "added view on table test1"
+    dolt branch other
+
+    dolt sql -q "DROP VIEW pkpk; CREATE VIEW pkpk AS SELECT pk*pk*pk FROM test1;"
+    dolt add . && dolt commit -m "changed view on main"
 
     dolt checkout other
-    dolt sql -q "CREATE VIEW c1c1 AS SELECT c1*c1 FROM test2;"
-    dolt add . && dolt commit -m "added view on table test2"
+    dolt sql -q "DROP VIEW pkpk; CREATE VIEW pkpk AS SELECT pk*pk FROM test2;"
+    dolt add . && dolt commit -m "changed view on other"
 
     dolt checkout main
     run dolt merge other
     log_status_eq 0
     [[ "$output" =~ "CONFLICT" ]] || false
-    dolt conflicts resolve --theirs dolt_schemas
-    run dolt conflicts resolve --theirs dolt_schemas
+    run dolt conflicts cat dolt_schemas
     log_status_eq 0
-    run dolt sql -q "select name from dolt_schemas" -r csv
+    [[ "$output" =~ "conflict in dolt_schemas: view pkpk" ]] || false
+    [[ "$output" =~ "-SELECT pk*pk FROM test1" ]] || false
+    [[ "$output" =~ "+SELECT pk*pk*pk FROM test1" ]] || false
+    [[ "$output" =~ "+SELECT pk*pk FROM test2" ]] || false
+    dolt conflicts resolve --theirs dolt_schemas
+    run dolt sql -q "select name, fragment from dolt_schemas" -r csv
     log_status_eq 0
-    [[ "$output" =~ "c1c1" ]] || false
+    [[ "$output" =~ "test2" ]] || false
+    [[ "${#lines[@]}" = "2" ]] || false
 }
 
 @test "merge: Add views on two branches, merge without conflicts" {
@@ -378,7 +386,6 @@ SQL
 
     dolt checkout main
     run dolt merge other
-    skip "key collision in dolt_schemas"
     log_status_eq 0
     run dolt sql -q "select name from dolt_schemas" -r csv
     log_status_eq 0
@@ -851,3 +858,44 @@ SQL
     log_status_eq 1
     [[ "$output" =~ "table with same name deleted and modified" ]] || false
 }
+
+@test "merge: column type changed on one branch converts the rows of the other" {
+    dolt checkout -b merge_branch
+    dolt sql -q "ALTER TABLE test1 MODIFY COLUMN c1 bigint"
+    dolt commit -am "widen c1"
+
+    dolt checkout main
+    dolt sql -q "INSERT INTO test1 VALUES (0,1,2)"
+    dolt commit -am "add pk 0 to test1"
+
+    run dolt merge merge_branch
+    log_status_eq 0
+
+    run dolt sql -r csv -q "SELECT * FROM test1"
+    log_status_eq 0
+    [[ "$output" =~ "0,1,2" ]] || false
+
+    run dolt sql -q "SHOW CREATE TABLE test1"
+    [[ "$output" =~ '`c1` bigint' ]] || false
+}
+
+@test "merge: rows that can't be converted to the column type of the other branch are constraint violations" {
+    dolt checkout -b merge_branch
+    dolt sql -q "ALTER TABLE test1 MODIFY COLUMN c1 tinyint"
+    dolt commit -am "narrow c1"
+
+    dolt checkout main
+    dolt sql -q "INSERT INTO test1 VALUES (0,1,2), (1,300,2)"
+    dolt commit -am "add rows to test1"
+
+    run dolt merge merge_branch
+    log_status_eq 0
+    [[ "$output" =~ "CONSTRAINT VIOLATION" ]] || false
+
+    run dolt sql -r csv -q "SELECT pk FROM test1"
+    [[ "$output" =~ "0" ]] || false
+    ! [[ "$output" =~ "1" ]] || false
+
+    run dolt sql -r csv -q "SELECT violation_type, pk FROM dolt_constraint_violations_test1"
+    [[ "$output" =~ "schema conversion,1" ]] || false
+}
@@ -492,7 +492,7 @@ SQL
     [[ "$output" =~ "error: key column 'pk1' doesn't exist in table" ]] || false
 }
 
-@test "primary-key-changes: same primary key set in different order is detected and blocked on merge" {
+@test "primary-key-changes: same primary key set in different order is applied to the other branch on merge" {
     dolt sql -q "CREATE table t (pk int, val int, primary key (pk, val))"
     dolt commit -am "cm1"
 
@@ -517,14 +517,15 @@ SQL
     dolt commit -am "insert"
 
     run dolt merge test
-    [ "$status" -eq 1 ]
-    [[ "$output" =~ 'error: cannot merge two tables with different primary key sets' ]] || false
+    [ "$status" -eq 0 ]
 
-    run dolt sql -q "SELECT DOLT_MERGE('test')"
-    [ "$status" -eq 1 ]
-    [[ "$output" =~ 'error: cannot merge two tables with different primary key sets' ]] || false
+    run dolt sql -q "SELECT * FROM t" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "1,1" ]] || false
 
-    skip "Dolt doesn't correctly store primary key order if it doesn't match the column order"
+    run dolt sql -q "SHOW CREATE TABLE t"
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ 'PRIMARY KEY (`val`,`pk`)' ]] || false
 }
 
 @test "primary-key-changes: correct diff is returned even with a new added column" {
@@ -0,0 +1,154 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+
+    dolt sql -q "CREATE TABLE test(pk BIGINT PRIMARY KEY, v1 BIGINT)"
+    dolt sql -q "INSERT INTO test VALUES (1, 1), (2, 2)"
+    dolt add -A
+    dolt commit -m "Created table"
+
+    dolt checkout -b feature
+    dolt sql -q "INSERT INTO test VALUES (3, 3)"
+    dolt commit -am "Added row 3"
+    dolt sql -q "INSERT INTO test VALUES (4, 4)"
+    dolt commit -am "Added row 4"
+
+    dolt checkout main
+    dolt sql -q "INSERT INTO test VALUES (10, 10)"
+    dolt commit -am "Added row 10"
+    dolt checkout feature
+}
+
+teardown() {
+    assert_feature_version
+    teardown_common
+}
+
+@test "rebase: rebase branch onto main" {
+    run dolt rebase main
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "Successfully rebased and updated refs/heads/feature" ]] || false
+
+    run dolt sql -q "SELECT * FROM test" -r csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "3,3" ]] || false
+    [[ "$output" =~ "4,4" ]] || false
+    [[ "$output" =~ "10,10" ]] || false
+
+    run dolt log --oneline
+    [ "$status" -eq "0" ]
+    [[ "${lines[0]}" =~ "Added row 4" ]] || false
+    [[ "${lines[1]}" =~ "Added row 3" ]] || false
+    [[ "${lines[2]}" =~ "Added row 10" ]] || false
+
+    run dolt rebase main
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "Current branch feature is up to date" ]] || false
+}
+
+@test "rebase: interactive rebase with an edited plan" {
+    run dolt rebase -i main
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "pick" ]] || false
+    [[ "$output" =~ "Added row 3" ]] || false
+
+    dolt sql -q "UPDATE dolt_rebase SET action = 'fixup' WHERE rebase_order = 2"
+
+    run dolt rebase --continue
+    [ "$status" -eq "0" ]
+
+    run dolt log --oneline
+    [ "$status" -eq "0" ]
+    [[ "${lines[0]}" =~ "Added row 3" ]] || false
+    [[ "${lines[1]}" =~ "Added row 10" ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "5" ]] || false
+}
+
+@test "rebase: dropping a commit removes its changes" {
+    dolt rebase -i main
+    dolt sql -q "UPDATE dolt_rebase SET action = 'drop' WHERE rebase_order = 1"
+    dolt rebase --continue
+
+    run dolt sql -q "SELECT pk FROM test WHERE pk = 3" -r csv
+    [ "$status" -eq "0" ]
+    [ "${#lines[@]}" -eq 1 ]
+}
+
+@test "rebase: abort restores the branch" {
+    dolt checkout main
+    dolt sql -q "UPDATE test SET v1 = 20 WHERE pk = 1"
+    dolt commit -am "Updated row 1 on main"
+    dolt checkout feature
+    dolt sql -q "UPDATE test SET v1 = 30 WHERE pk = 1"
+    dolt commit -am "Updated row 1 on feature"
+
+    run dolt rebase main
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "CONFLICT (content): Merge conflict in test" ]] || false
+
+    run dolt rebase main
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "a rebase is already in progress" ]] || false
+
+    run dolt rebase --continue
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "unresolved conflicts" ]] || false
+
+    run dolt rebase --abort
+    [ "$status" -eq "0" ]
+
+    run dolt sql -q "SELECT v1 FROM test WHERE pk = 1" -r csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "30" ]] || false
+
+    run dolt status
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+}
+
+@test "rebase: continue after resolving conflicts" {
+    dolt checkout main
+    dolt sql -q "UPDATE test SET v1 = 20 WHERE pk = 1"
+    dolt commit -am "Updated row 1 on main"
+    dolt checkout feature
+    dolt sql -q "UPDATE test SET v1 = 30 WHERE pk = 1"
+    dolt commit -am "Updated row 1 on feature"
+
+    run dolt rebase main
+    [ "$status" -eq "1" ]
+
+    dolt conflicts resolve --theirs test
+    run dolt rebase --continue
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "Successfully rebased and updated refs/heads/feature" ]] || false
+
+    run dolt sql -q "SELECT v1 FROM test WHERE pk = 1" -r csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "30" ]] || false
+
+    run dolt log --oneline
+    [ "$status" -eq "0" ]
+    [[ "${lines[0]}" =~ "Updated row 1 on feature" ]] || false
+}
+
+@test "rebase: uncommitted changes block the rebase" {
+    dolt sql -q "INSERT INTO test VALUES (5, 5)"
+    run dolt rebase main
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "uncommitted changes" ]] || false
+}
+
+@test "rebase: no rebase in progress" {
+    run dolt rebase --continue
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "no rebase in progress" ]] || false
+
+    run dolt rebase --abort
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "no rebase in progress" ]] || false
+}
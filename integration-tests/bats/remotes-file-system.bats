@@ -175,3 +175,202 @@ SQL
     [ ! -d test-repo ]
     cd ..
 }
+
+@test "remotes-file-system: shallow clone with --depth" {
+    dolt sql -q "create table test (pk int primary key, c1 int)"
+    for i in 1 2 3 4; do
+        dolt sql -q "insert into test values ($i, $i)"
+        dolt add test
+        dolt commit -m "commit $i"
+    done
+    dolt branch other
+    dolt tag v1 HEAD~3
+
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin main
+    dolt push origin other
+    dolt push origin v1
+
+    cd dolt-repo-clones
+    dolt clone --depth 2 file://../remotedir shallow-repo
+    cd shallow-repo
+
+    run dolt sql -q "select count(*) from test" -r csv
+    [ $status -eq 0 ]
+    [[ "$output" =~ "4" ]] || false
+
+    run dolt branch -a
+    [ $status -eq 0 ]
+    [[ "$output" =~ "remotes/origin/main" ]] || false
+    [[ ! "$output" =~ "remotes/origin/other" ]] || false
+
+    # the tag points at a commit beyond the depth
+    run dolt tag
+    [ $status -eq 0 ]
+    [[ ! "$output" =~ "v1" ]] || false
+
+    run dolt diff HEAD~1 HEAD
+    [ $status -eq 0 ]
+
+    # the log stops at the shallow commits
+    run dolt log
+    [ $status -eq 0 ]
+    [[ "$output" =~ "commit 4" ]] || false
+    [[ "$output" =~ "commit 3" ]] || false
+    [[ ! "$output" =~ "commit 2" ]] || false
+
+    run dolt diff HEAD~2 HEAD
+    [ $status -ne 0 ]
+    [[ "$output" =~ "commit history is incomplete" ]] || false
+
+    dolt fetch --unshallow
+    run dolt log
+    [ $status -eq 0 ]
+    [[ "$output" =~ "commit 1" ]] || false
+
+    run dolt fetch --unshallow
+    [ $status -ne 0 ]
+    [[ "$output" =~ "complete repository" ]] || false
+}
+
+@test "remotes-file-system: gc on a shallow clone" {
+    dolt sql -q "create table test (pk int primary key, c1 int)"
+    for i in 1 2 3 4; do
+        dolt sql -q "insert into test values ($i, $i)"
+        dolt add test
+        dolt commit -m "commit $i"
+    done
+
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin main
+
+    cd dolt-repo-clones
+    dolt clone --depth 2 file://../remotedir shallow-repo
+    cd shallow-repo
+
+    run dolt gc
+    [ $status -eq 0 ]
+
+    run dolt sql -q "call dolt_gc()"
+    [ $status -eq 0 ]
+
+    run dolt sql -q "select count(*) from test" -r csv
+    [ $status -eq 0 ]
+    [[ "$output" =~ "4" ]] || false
+
+    run dolt diff HEAD~1 HEAD
+    [ $status -eq 0 ]
+    [[ "$output" =~ "4" ]] || false
+
+    # the history missing from the clone can still be fetched after a gc
+    dolt fetch --unshallow
+    run dolt sql -q "select count(*) from test as of 'HEAD~3'" -r csv
+    [ $status -eq 0 ]
+    [[ "$output" =~ "1" ]] || false
+}
+
+@test "remotes-file-system: fetch into a shallow clone stays shallow" {
+    dolt sql -q "create table test (pk int primary key, c1 int)"
+    for i in 1 2 3; do
+        dolt sql -q "insert into test values ($i, $i)"
+        dolt add test
+        dolt commit -m "commit $i"
+    done
+
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin main
+
+    cd dolt-repo-clones
+    dolt clone --depth 1 file://../remotedir shallow-repo
+
+    cd ../
+    dolt sql -q "insert into test values (4, 4)"
+    dolt add test
+    dolt commit -m "commit 4"
+    dolt push origin main
+
+    cd dolt-repo-clones/shallow-repo
+    dolt fetch
+    run dolt diff main origin/main
+    [ $status -eq 0 ]
+    [[ "$output" =~ "4" ]] || false
+
+    run dolt log origin/main
+    [ $status -eq 0 ]
+    [[ "$output" =~ "commit 4" ]] || false
+    [[ "$output" =~ "commit 3" ]] || false
+    [[ ! "$output" =~ "commit 2" ]] || false
+
+    dolt fetch --unshallow
+    run dolt log origin/main
+    [ $status -eq 0 ]
+    [[ "$output" =~ "commit 1" ]] || false
+}
+
+@test "remotes-file-system: fetch into a shallow clone marks new commits with missing parents as shallow" {
+    dolt sql -q "create table test (pk int primary key, c1 int)"
+    for i in 1 2 3; do
+        dolt sql -q "insert into test values ($i, $i)"
+        dolt add test
+        dolt commit -m "commit $i"
+    done
+
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin main
+
+    cd dolt-repo-clones
+    dolt clone --depth 1 file://../remotedir shallow-repo
+
+    # branch off a commit beyond the depth of the clone
+    cd ../
+    dolt branch old HEAD~2
+    dolt checkout old
+    dolt sql -q "insert into test values (10, 10)"
+    dolt add test
+    dolt commit -m "commit on old"
+    dolt push origin old
+
+    cd dolt-repo-clones/shallow-repo
+    dolt fetch
+    run dolt sql -q "select count(*) from test as of 'origin/old'" -r csv
+    [ $status -eq 0 ]
+    [[ "$output" =~ "2" ]] || false
+}
+
+@test "remotes-file-system: clone with --single-branch" {
+    dolt sql -q "create table test (pk int primary key)"
+    dolt add test
+    dolt commit -m "create table"
+    dolt checkout -b other
+    dolt sql -q "insert into test values (1)"
+    dolt add test
+    dolt commit -m "insert on other"
+    dolt checkout main
+
+    mkdir remotedir
+    dolt remote add origin file://remotedir
+    dolt push origin main
+    dolt push origin other
+
+    cd dolt-repo-clones
+    dolt clone --single-branch -b other file://../remotedir single-repo
+    cd single-repo
+
+    run dolt branch -a
+    [ $status -eq 0 ]
+    [[ "$output" =~ "* other" ]] || false
+    [[ "$output" =~ "remotes/origin/other" ]] || false
+    [[ ! "$output" =~ "remotes/origin/main" ]] || false
+
+    run dolt log
+    [ $status -eq 0 ]
+    [[ "$output" =~ "create table" ]] || false
+
+    run dolt clone --depth 0 file://../../remotedir bad-repo
+    [ $status -ne 0 ]
+    [[ "$output" =~ "not a positive number" ]] || false
+}
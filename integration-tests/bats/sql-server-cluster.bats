@@ -0,0 +1,153 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+load $BATS_TEST_DIRNAME/helper/query-server-common.bash
+
+make_repo() {
+  mkdir -p "$1"
+  cd "$1"
+  dolt init
+  cd -
+}
+
+setup() {
+    skiponwindows "tests are flaky on Windows"
+    setup_no_dolt_init
+    make_repo primary/repo1
+    make_repo standby/repo1
+
+    let PORT="$$ % (65536-1024) + 1024"
+    let STANDBY_PORT="($$ + 1) % (65536-1024) + 1024"
+    let PRIMARY_REMOTESAPI_PORT="($$ + 2) % (65536-1024) + 1024"
+    let STANDBY_REMOTESAPI_PORT="($$ + 3) % (65536-1024) + 1024"
+}
+
+teardown() {
+    stop_sql_server
+    if [ ! -z "$STANDBY_PID" ]; then
+        kill $STANDBY_PID
+    fi
+    teardown_common
+}
+
+# write_cluster_config writes the config of a server of the cluster to server.yaml of the current directory
+#  * param1 is the port of the server
+#  * param2 is the bootstrap role of the server
+#  * param3 is the port of the remotesapi endpoint of the server
+#  * param4 is the port of the remotesapi endpoint of the other server of the cluster
+write_cluster_config() {
+    cat > server.yaml <<YAML
+log_level: trace
+
+user:
+  name: dolt
+
+listener:
+  host: 0.0.0.0
+  port: $1
+
+cluster:
+  standby_remotes:
+  - name: standby
+    remote_url_template: http://localhost:$4/{database}
+  bootstrap_role: $2
+  bootstrap_epoch: 1
+  remotesapi:
+    port: $3
+    shared_secret: cluster-secret
+YAML
+}
+
+@test "sql-server-cluster: role and epoch system variables are set from the bootstrap config" {
+    cd primary
+    write_cluster_config $PORT primary $PRIMARY_REMOTESAPI_PORT $STANDBY_REMOTESAPI_PORT
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    server_query repo1 1 "SELECT @@GLOBAL.dolt_cluster_role, @@GLOBAL.dolt_cluster_role_epoch" "@@GLOBAL.dolt_cluster_role,@@GLOBAL.dolt_cluster_role_epoch\nprimary,1"
+    server_query repo1 1 "SET @@GLOBAL.dolt_cluster_role = 'standby'" "" "read only"
+}
+
+@test "sql-server-cluster: standby does not accept writes" {
+    cd standby
+    dolt --data-dir repo1 sql -q "CREATE TABLE test (pk int PRIMARY KEY)"
+    write_cluster_config $PORT standby $STANDBY_REMOTESAPI_PORT $PRIMARY_REMOTESAPI_PORT
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    server_query repo1 1 "SELECT count(*) FROM test" "count(*)\n0"
+    server_query repo1 1 "INSERT INTO test VALUES (1)" "" "does not accept writes"
+    server_query repo1 1 "CALL dolt_branch('new_branch')" "" "does not accept writes"
+}
+
+@test "sql-server-cluster: primary replicates writes to the standby" {
+    cd standby
+    write_cluster_config $STANDBY_PORT standby $STANDBY_REMOTESAPI_PORT $PRIMARY_REMOTESAPI_PORT
+    dolt sql-server --config server.yaml &
+    STANDBY_PID=$!
+    wait_for_connection $STANDBY_PORT 5000
+
+    cd ../primary
+    write_cluster_config $PORT primary $PRIMARY_REMOTESAPI_PORT $STANDBY_REMOTESAPI_PORT
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    server_query repo1 1 "CREATE TABLE test (pk int PRIMARY KEY)"
+    server_query repo1 1 "INSERT INTO test VALUES (1), (2), (3)"
+    server_query repo1 1 "CALL dolt_commit('-am', 'added rows')"
+
+    kill $STANDBY_PID
+    while ps -p $STANDBY_PID > /dev/null; do
+        sleep .1;
+    done
+    STANDBY_PID=
+
+    cd ../standby/repo1
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "3" ]] || false
+    run dolt log
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "added rows" ]] || false
+}
+
+@test "sql-server-cluster: dolt_assume_cluster_role requires a higher epoch" {
+    cd standby
+    write_cluster_config $PORT standby $STANDBY_REMOTESAPI_PORT $PRIMARY_REMOTESAPI_PORT
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    server_query repo1 1 "CALL dolt_assume_cluster_role('primary', 1)" "" "must be higher than the current epoch"
+    server_query repo1 1 "CALL dolt_assume_cluster_role('primary', 2)" "status\n0"
+    server_query repo1 1 "SELECT @@GLOBAL.dolt_cluster_role, @@GLOBAL.dolt_cluster_role_epoch" "@@GLOBAL.dolt_cluster_role,@@GLOBAL.dolt_cluster_role_epoch\nprimary,2"
+
+    stop_sql_server 1
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    server_query repo1 1 "SELECT @@GLOBAL.dolt_cluster_role, @@GLOBAL.dolt_cluster_role_epoch" "@@GLOBAL.dolt_cluster_role,@@GLOBAL.dolt_cluster_role_epoch\nprimary,2"
+}
+
+@test "sql-server-cluster: a write the standby does not acknowledge in time is committed with a warning" {
+    cd primary
+    write_cluster_config $PORT primary $PRIMARY_REMOTESAPI_PORT $STANDBY_REMOTESAPI_PORT
+    echo "  replication_ack_timeout_millis: 500" >> server.yaml
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    # the standby is not running
+    run dolt sql-client --host=0.0.0.0 --port=$PORT --user=dolt <<SQL
+USE repo1;
+CREATE TABLE test (pk int PRIMARY KEY);
+SHOW WARNINGS;
+SQL
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "may not have been replicated" ]] || false
+
+    server_query repo1 1 "SELECT count(*) FROM test" "count(*)\n0"
+}
@@ -0,0 +1,139 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+load $BATS_TEST_DIRNAME/helper/query-server-common.bash
+
+setup() {
+    skiponwindows "tests are flaky on Windows"
+    setup_no_dolt_init
+    mkdir remote
+    cd remote
+    dolt init
+    dolt sql -q "CREATE TABLE test (pk int PRIMARY KEY)"
+    dolt sql -q "INSERT INTO test VALUES (1), (2)"
+    dolt add .
+    dolt commit -m "added test"
+    cd ..
+
+    let REMOTESAPI_PORT="($$ + 1) % (65536-1024) + 1024"
+}
+
+teardown() {
+    stop_sql_server
+    teardown_common
+}
+
+start_remotesapi_server() {
+    cd remote
+    let PORT="$$ % (65536-1024) + 1024"
+    cat > server.yaml <<YAML
+log_level: debug
+
+user:
+  name: dolt
+
+listener:
+  host: 0.0.0.0
+  port: $PORT
+
+remotesapi:
+  port: $REMOTESAPI_PORT
+YAML
+    dolt sql-server --config server.yaml &
+    SERVER_PID=$!
+    DEFAULT_DB=remote
+    wait_for_connection $PORT 5000
+    cd ..
+}
+
+@test "sql-server-remotesrv: clone and pull as a sql user" {
+    start_remotesapi_server
+    server_query remote 1 "CREATE USER reader@'%' IDENTIFIED BY 'pass'"
+    server_query remote 1 "GRANT SELECT ON remote.* TO reader@'%'"
+
+    export DOLT_REMOTE_PASSWORD=pass
+    dolt clone --user reader http://localhost:$REMOTESAPI_PORT/remote cloned
+    cd cloned
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "2" ]] || false
+
+    server_query remote 1 "INSERT INTO test VALUES (3)"
+    server_query remote 1 "CALL dolt_commit('-am', 'added a row')"
+
+    dolt pull origin
+    run dolt sql -q "SELECT count(*) FROM test" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "3" ]] || false
+}
+
+@test "sql-server-remotesrv: requests need valid credentials" {
+    start_remotesapi_server
+    server_query remote 1 "CREATE USER reader@'%' IDENTIFIED BY 'pass'"
+    server_query remote 1 "GRANT SELECT ON remote.* TO reader@'%'"
+
+    run dolt clone http://localhost:$REMOTESAPI_PORT/remote cloned
+    [ "$status" -ne 0 ]
+
+    export DOLT_REMOTE_PASSWORD=wrong
+    run dolt clone --user reader http://localhost:$REMOTESAPI_PORT/remote cloned
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "access denied" ]] || false
+}
+
+@test "sql-server-remotesrv: users need privileges on the database" {
+    start_remotesapi_server
+    server_query remote 1 "CREATE USER nobody@'%' IDENTIFIED BY 'pass'"
+
+    export DOLT_REMOTE_PASSWORD=pass
+    run dolt clone --user nobody http://localhost:$REMOTESAPI_PORT/remote cloned
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "does not have read access" ]] || false
+}
+
+@test "sql-server-remotesrv: push requires write access" {
+    start_remotesapi_server
+    server_query remote 1 "CREATE USER reader@'%' IDENTIFIED BY 'pass'"
+    server_query remote 1 "GRANT SELECT ON remote.* TO reader@'%'"
+    server_query remote 1 "CREATE USER writer@'%' IDENTIFIED BY 'pass'"
+    server_query remote 1 "GRANT SELECT, INSERT, UPDATE, DELETE ON remote.* TO writer@'%'"
+
+    export DOLT_REMOTE_PASSWORD=pass
+    dolt clone --user reader http://localhost:$REMOTESAPI_PORT/remote cloned
+    cd cloned
+    dolt checkout -b new_branch
+    dolt sql -q "INSERT INTO test VALUES (10)"
+    dolt commit -am "added a row"
+
+    run dolt push origin new_branch
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "does not have write access" ]] || false
+
+    dolt remote add writer --user writer http://localhost:$REMOTESAPI_PORT/remote
+    dolt push writer new_branch
+
+    cd ..
+    server_query remote 1 "SELECT count(*) FROM test AS OF 'new_branch'" "count(*)\n3"
+}
+
+@test "sql-server-remotesrv: push requires branch permissions" {
+    start_remotesapi_server
+    server_query remote 1 "CREATE USER writer@'%' IDENTIFIED BY 'pass'"
+    server_query remote 1 "GRANT SELECT, INSERT, UPDATE, DELETE ON remote.* TO writer@'%'"
+    server_query remote 1 "DELETE FROM dolt_branch_control; INSERT INTO dolt_branch_control VALUES ('%', 'feature%', 'writer', '%', 'write')" ""
+
+    export DOLT_REMOTE_PASSWORD=pass
+    dolt clone --user writer http://localhost:$REMOTESAPI_PORT/remote cloned
+    cd cloned
+    dolt sql -q "INSERT INTO test VALUES (10)"
+    dolt commit -am "added a row"
+
+    run dolt push origin main
+    [ "$status" -ne 0 ]
+    [[ "$output" =~ "does not have the correct permissions on branch \`main\`" ]] || false
+
+    dolt push origin main:feature1
+
+    cd ..
+    server_query remote 1 "SELECT count(*) FROM test AS OF 'feature1'" "count(*)\n3"
+    server_query remote 1 "SELECT count(*) FROM test" "count(*)\n2"
+}
@@ -1504,3 +1504,88 @@ behavior:
     [ "$status" -eq 0 ]
     [ "${#lines[@]}" -eq 1 ]
 }
+
+@test "sql-server: dolt_gc collects garbage while the server is running" {
+    cd repo1
+    dolt sql <<SQL
+CREATE TABLE test (pk int PRIMARY KEY);
+INSERT INTO test VALUES (1),(2),(3),(4),(5);
+SQL
+    dolt add .
+    dolt commit -m "added values 1 - 5"
+
+    # make some garbage
+    dolt sql -q "INSERT INTO test VALUES (6),(7),(8);"
+    dolt reset --hard
+
+    start_sql_server repo1
+
+    # leave data in the working set
+    server_query repo1 1 "INSERT INTO test VALUES (11),(12),(13),(14),(15);"
+
+    BEFORE=$(du -c .dolt/noms/ | grep total | sed 's/[^0-9]*//g')
+
+    run dolt sql-client --host=0.0.0.0 --port=$PORT --user=dolt <<SQL
+USE repo1;
+CALL dolt_gc();
+SQL
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "freed_bytes" ]] || false
+
+    server_query repo1 1 "SELECT sum(pk) FROM test" "sum(pk)\n80"
+    server_query repo1 1 "INSERT INTO test VALUES (16);"
+    server_query repo1 1 "SELECT sum(pk) FROM test" "sum(pk)\n96"
+
+    AFTER=$(du -c .dolt/noms/ | grep total | sed 's/[^0-9]*//g')
+
+    # assert space was reclaimed
+    echo "$BEFORE"
+    echo "$AFTER"
+    [ "$BEFORE" -gt "$AFTER" ]
+
+    stop_sql_server 1
+    run dolt sql -q "SELECT sum(pk) FROM test;" -r csv
+    [ "$status" -eq 0 ]
+    [[ "$output" =~ "96" ]] || false
+}
+
+@test "sql-server: auto_gc collects garbage once a threshold is crossed" {
+    cd repo1
+    dolt sql -q "CREATE TABLE test (pk int PRIMARY KEY);"
+    dolt add .
+    dolt commit -m "created table test"
+
+    let PORT="$$ % (65536-1024) + 1024"
+    cat > server.yaml <<YAML
+log_level: info
+
+user:
+  name: dolt
+
+listener:
+  host: 0.0.0.0
+  port: $PORT
+
+behavior:
+  auto_gc:
+    enable: true
+    check_interval_millis: 100
+    table_file_threshold: 2
+YAML
+
+    dolt sql-server --config server.yaml > log.txt 2>&1 &
+    SERVER_PID=$!
+    wait_for_connection $PORT 5000
+
+    for i in 1 2 3 4 5; do
+        server_query repo1 1 "INSERT INTO test VALUES ($i);"
+    done
+    sleep 2
+
+    run grep "auto_gc: database repo1" log.txt
+    [ "$status" -eq 0 ]
+    run grep "dolt_gc: collected garbage in database repo1" log.txt
+    [ "$status" -eq 0 ]
+
+    server_query repo1 1 "SELECT count(*) FROM test" "count(*)\n5"
+}
@@ -0,0 +1,137 @@
+#!/usr/bin/env bats
+load $BATS_TEST_DIRNAME/helper/common.bash
+
+setup() {
+    setup_common
+
+    dolt sql -q "CREATE TABLE test(pk BIGINT PRIMARY KEY, v1 BIGINT)"
+    dolt sql -q "INSERT INTO test VALUES (1, 1), (2, 2)"
+    dolt add -A
+    dolt commit -m "Created table"
+}
+
+teardown() {
+    assert_feature_version
+    teardown_common
+}
+
+@test "stash: push and pop" {
+    dolt sql -q "UPDATE test SET v1 = 10 WHERE pk = 1"
+    run dolt stash
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "Saved working directory and index state WIP on main:" ]] || false
+
+    run dolt status
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "nothing to commit, working tree clean" ]] || false
+
+    run dolt stash pop
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "Dropped stash@{0}" ]] || false
+
+    run dolt sql -q "SELECT * FROM test WHERE pk = 1" -r=csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "1,10" ]] || false
+
+    run dolt stash list
+    [ "$status" -eq "0" ]
+    [ "$output" = "" ]
+}
+
+@test "stash: no local changes" {
+    run dolt stash
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "No local changes to save" ]] || false
+
+    run dolt stash pop
+    [ "$status" -eq "1" ]
+    [[ "$output" =~ "No stash entries found." ]] || false
+}
+
+@test "stash: list, apply, drop and clear" {
+    dolt sql -q "INSERT INTO test VALUES (3, 3)"
+    dolt stash push -m "first"
+    dolt sql -q "INSERT INTO test VALUES (4, 4)"
+    dolt stash push -m "second"
+
+    run dolt stash list
+    [ "$status" -eq "0" ]
+    [[ "${lines[0]}" = "stash@{0}: On main: second" ]] || false
+    [[ "${lines[1]}" = "stash@{1}: On main: first" ]] || false
+
+    run dolt stash apply stash@{1}
+    [ "$status" -eq "0" ]
+    run dolt sql -q "SELECT * FROM test" -r=csv
+    [[ "$output" =~ "3,3" ]] || false
+    [[ ! "$output" =~ "4,4" ]] || false
+
+    run dolt stash list
+    [ "${#lines[@]}" -eq 2 ]
+
+    run dolt stash drop 0
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "Dropped stash@{0}" ]] || false
+    run dolt stash list
+    [[ "${lines[0]}" = "stash@{0}: On main: first" ]] || false
+
+    dolt stash clear
+    run dolt stash list
+    [ "$output" = "" ]
+}
+
+@test "stash: untracked tables are left in the working set" {
+    dolt sql -q "CREATE TABLE new_table(pk BIGINT PRIMARY KEY)"
+    dolt sql -q "INSERT INTO test VALUES (3, 3)"
+    dolt stash
+
+    run dolt ls
+    [[ "$output" =~ "new_table" ]] || false
+    run dolt sql -q "SELECT count(*) FROM test" -r=csv
+    [[ "$output" =~ "2" ]] || false
+
+    dolt stash pop
+    run dolt sql -q "SELECT count(*) FROM test" -r=csv
+    [[ "$output" =~ "3" ]] || false
+}
+
+@test "stash: include untracked tables" {
+    dolt sql -q "CREATE TABLE new_table(pk BIGINT PRIMARY KEY)"
+    dolt stash -u
+
+    run dolt ls
+    [[ ! "$output" =~ "new_table" ]] || false
+
+    dolt stash pop
+    run dolt ls
+    [[ "$output" =~ "new_table" ]] || false
+}
+
+@test "stash: pop with conflicts keeps the stash entry" {
+    dolt sql -q "UPDATE test SET v1 = 10 WHERE pk = 1"
+    dolt stash
+    dolt sql -q "UPDATE test SET v1 = 20 WHERE pk = 1"
+    dolt commit -am "conflicting change"
+
+    run dolt stash pop
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "CONFLICT (content): Merge conflict in test" ]] || false
+    [[ "$output" =~ "The stash entry is kept in case you need it again." ]] || false
+
+    run dolt sql -q "SELECT count(*) FROM dolt_conflicts" -r=csv
+    [[ "$output" =~ "1" ]] || false
+
+    run dolt stash list
+    [ "${#lines[@]}" -eq 1 ]
+}
+
+@test "stash: dolt_stash procedure" {
+    dolt sql -q "UPDATE test SET v1 = 10 WHERE pk = 1"
+    dolt sql -q "CALL dolt_stash('push', '-m', 'from sql')"
+
+    run dolt stash list
+    [[ "$output" =~ "stash@{0}: On main: from sql" ]] || false
+
+    dolt sql -q "CALL dolt_stash('pop')"
+    run dolt sql -q "SELECT * FROM test WHERE pk = 1" -r=csv
+    [[ "$output" =~ "1,10" ]] || false
+}
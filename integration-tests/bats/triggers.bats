@@ -127,7 +127,7 @@ SQL
     [[ "${#lines[@]}" = "2" ]] || false
 }
 
-@test "triggers: Merge with manual resolution" {
+@test "triggers: Merge triggers added on both branches" {
     dolt sql <<SQL
 CREATE TABLE x(a BIGINT PRIMARY KEY);
 CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 1;
@@ -152,37 +152,60 @@ SQL
     [ "$status" -eq "0" ]
     [[ "$output" =~ "CREATE TRIGGER trigger2 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 10" ]] || false
     [[ "$output" =~ "CREATE TRIGGER trigger3 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 100" ]] || false
-    dolt merge other
-    dolt add dolt_schemas
-    run dolt commit -am "can't commit conflicts"
-    [ "$status" -eq "1" ]
-    [[ "$output" =~ "dolt_schemas" ]] || false
-    run dolt conflicts cat dolt_schemas
+    run dolt merge other
     [ "$status" -eq "0" ]
-    [[ "$output" =~ "CREATE TRIGGER trigger2 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 10" ]] || false
-    [[ "$output" =~ "CREATE TRIGGER trigger3 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 100" ]] || false
+    ! [[ "$output" =~ "CONFLICT" ]] || false
+    dolt commit -m "Merged other"
+    run dolt sql -q "SELECT type, name, fragment FROM dolt_schemas ORDER BY name" -r=csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "type,name,fragment" ]] || false
+    [[ "$output" =~ "trigger,trigger1,CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 1" ]] || false
+    [[ "$output" =~ "trigger,trigger2,CREATE TRIGGER trigger2 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 10" ]] || false
+    [[ "$output" =~ "trigger,trigger3,CREATE TRIGGER trigger3 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 100" ]] || false
+    [[ "$output" =~ "trigger,trigger4,CREATE TRIGGER trigger4 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 1000" ]] || false
+    [[ "${#lines[@]}" = "5" ]] || false
+    run dolt sql -q "SELECT count(distinct id) FROM dolt_schemas" -r=csv
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "4" ]] || false
+}
 
-    skip "SQL conflict resolution not working below"
-    
-    dolt sql  <<SQL
-    INSERT INTO dolt_schemas VALUES ('trigger', 'trigger3', 'CREATE TRIGGER trigger3 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 100', 3);
-    DELETE FROM dolt_conflicts_dolt_schemas;
-    commit;
+@test "triggers: Merge a trigger changed differently on both branches" {
+    dolt sql <<SQL
+CREATE TABLE x(a BIGINT PRIMARY KEY);
+CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 1;
 SQL
-
+    dolt add -A
+    dolt commit -m "Initial Commit"
+    dolt branch other
+    dolt sql <<SQL
+DROP TRIGGER trigger1;
+CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 10;
+SQL
+    dolt add -A
+    dolt commit -m "On main"
+    dolt checkout other
+    dolt sql <<SQL
+DROP TRIGGER trigger1;
+CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 100;
+SQL
+    dolt add -A
+    dolt commit -m "On other"
+    dolt checkout main
+    run dolt merge other
+    [ "$status" -eq "0" ]
+    [[ "$output" =~ "CONFLICT" ]] || false
     run dolt conflicts cat dolt_schemas
     [ "$status" -eq "0" ]
-    ! [[ "$output" =~ "CREATE TRIGGER" ]] || false
-    dolt add dolt_schemas
-    dolt commit -m "Merged other table"
-    run dolt sql -q "SELECT * FROM dolt_schemas" -r=csv
+    [[ "$output" =~ "conflict in dolt_schemas: trigger trigger1" ]] || false
+    [[ "$output" =~ "-CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 1" ]] || false
+    [[ "$output" =~ "+CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 10" ]] || false
+    [[ "$output" =~ "+CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 100" ]] || false
+    dolt conflicts resolve --theirs dolt_schemas
+    dolt commit -m "Merged other"
+    run dolt sql -q "SELECT fragment FROM dolt_schemas" -r=csv
     [ "$status" -eq "0" ]
-    [[ "$output" =~ "type,name,fragment,id" ]] || false
-    [[ "$output" =~ "trigger,trigger1,CREATE TRIGGER trigger1 BEFORE INSERT ON x FOR EACH ROW SET new.a = new.a + 1,1" ]] || false
-    [[ "$output" =~ "trigger,trigger2,CREATE TRIGGER trigger2 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 10,2" ]] || false
-    [[ "$output" =~ "trigger,trigger3,CREATE TRIGGER trigger3 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 100,3" ]] || false
-    [[ "$output" =~ "trigger,trigger4,CREATE TRIGGER trigger4 BEFORE INSERT ON x FOR EACH ROW SET new.a = (new.a * 2) + 1000,4" ]] || false
-    [[ "${#lines[@]}" = "5" ]] || false
+    [[ "$output" =~ "new.a + 100" ]] || false
+    [[ "${#lines[@]}" = "2" ]] || false
 }
 
 @test "triggers: Upgrade dolt_schemas" {